@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"github.com/cliq-cli/cliq/internal/config"
+	"github.com/cliq-cli/cliq/internal/llm"
+	"github.com/cliq-cli/cliq/internal/store"
+)
+
+// answerFingerprint identifies the setup an answer was given for: the
+// prompt's context without the question, and the model and its settings
+func answerFingerprint(cfg *config.Config, pctx *llm.PromptContext) string {
+	// References are retrieved for the question's wording, which the
+	// normalized question already stands for
+	ctx := *pctx
+	ctx.References = nil
+
+	h := sha256.New()
+	h.Write([]byte(llm.BuildPrompt("", &ctx)))
+	// on_prompt may change what the model is asked
+	h.Write([]byte(hooksSource()))
+	fmt.Fprintf(h, "\x00%s\x00%s\x00%s\x00%s\x00%s",
+		cfg.Model.Backend, cfg.Model.OllamaModel, cfg.Model.PluginModel, cfg.GetModelPath(), describeSampling(cfg))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// answerCacheMu keeps the questions cliq batch asks at once from loading
+// and saving the answer cache over each other
+var answerCacheMu sync.Mutex
+
+// answerCacheOn reports whether answers are read from and kept in the cache
+func answerCacheOn(cfg *config.Config) bool {
+	return cfg.Cache.Answers && !viper.GetBool("no-llm-cache")
+}
+
+// cachedAnswer returns the model's earlier answer to the question, if it
+// was asked about the same setup within cache.answer_ttl_hours
+func cachedAnswer(query string, cfg *config.Config, pctx *llm.PromptContext) (string, bool) {
+	if !answerCacheOn(cfg) {
+		return "", false
+	}
+	answerCacheMu.Lock()
+	defer answerCacheMu.Unlock()
+	answers, err := store.LoadAnswerCache()
+	if err != nil {
+		if verbose {
+			fmt.Fprintf(os.Stderr, "Warning: could not load answer cache: %v\n", err)
+		}
+		return "", false
+	}
+	answer, ok := answers.Get(query, answerFingerprint(cfg, pctx), answerTTL(cfg))
+	if ok && !incognito {
+		// Only the hit count changed, so a failure to save doesn't matter
+		answers.Save()
+	}
+	return answer, ok
+}
+
+// cacheAnswer keeps the model's answer for the next time the question is
+// asked. Incognito runs and questions history excludes aren't kept.
+func cacheAnswer(query string, cfg *config.Config, pctx *llm.PromptContext, answer string) {
+	if !answerCacheOn(cfg) || incognito || strings.TrimSpace(answer) == "" || historyRetention(cfg).Excludes(query) {
+		return
+	}
+	answerCacheMu.Lock()
+	defer answerCacheMu.Unlock()
+	answers, err := store.LoadAnswerCache()
+	if err == nil {
+		answers.Put(query, answerFingerprint(cfg, pctx), answer, answerTTL(cfg))
+		err = answers.Save()
+	}
+	if err != nil && verbose {
+		fmt.Fprintf(os.Stderr, "Warning: could not save answer cache: %v\n", err)
+	}
+}
+
+// answerTTL is how long cached answers are used
+func answerTTL(cfg *config.Config) time.Duration {
+	return time.Duration(cfg.Cache.AnswerTTLHours) * time.Hour
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/cliq-cli/cliq/internal/config"
+	"github.com/cliq-cli/cliq/internal/terminal"
+	"github.com/cliq-cli/cliq/internal/writeback"
+)
+
+// applyAnswer is set by --apply: add the answer's keymap or tmux binding to
+// the config
+var applyAnswer bool
+
+// applyCommand adds the vim.keymap.set lines or tmux bindings in command to
+// the Neovim keymaps file or tmux.conf, after showing the diff and asking
+func applyCommand(cfg *config.Config, command string) error {
+	var path string
+	var lines []string
+	if lines = writeback.Keymaps(command); len(lines) > 0 {
+		if cfg.Nvim.ConfigPath == "" {
+			return fmt.Errorf("no Neovim config to add the keymap to (set nvim.config_path)")
+		}
+		path = nvimKeymapsFile(cfg)
+	} else if lines = writeback.TmuxBindings(command); len(lines) > 0 {
+		if cfg.Tmux.ConfigPath == "" {
+			return fmt.Errorf("no tmux config to add the binding to (set tmux.config_path)")
+		}
+		path = cfg.Tmux.ConfigPath
+	} else {
+		return fmt.Errorf("nothing to apply: the command is not a vim.keymap.set line or tmux binding")
+	}
+
+	change, err := writeback.Append(path, lines)
+	if errors.Is(err, writeback.ErrPresent) {
+		fmt.Printf("Already in %s\n", path)
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	addedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	fmt.Println()
+	for _, line := range strings.Split(strings.TrimSuffix(change.Diff(), "\n"), "\n") {
+		if strings.HasPrefix(line, "+") && !strings.HasPrefix(line, "+++") {
+			fmt.Println(addedStyle.Render(line))
+		} else {
+			fmt.Println(dimStyle.Render(line))
+		}
+	}
+
+	if !terminal.IsTerminal(os.Stdin) {
+		return fmt.Errorf("not changing %s without a terminal to confirm on", path)
+	}
+	fmt.Fprintf(os.Stderr, "Apply this change? [y/N] ")
+	var answer string
+	fmt.Scanln(&answer)
+	if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+		fmt.Println("Not applied.")
+		return nil
+	}
+
+	backup, err := change.Apply()
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	fmt.Println(addedStyle.Render("✓ Added to " + path))
+	if backup != "" {
+		fmt.Println(dimStyle.Render("  Backup: " + backup))
+	}
+
+	if path == cfg.Tmux.ConfigPath {
+		fmt.Printf("Reload tmux to use it: tmux source-file %s\n", path)
+	} else if module := writeback.LuaModule(cfg.Nvim.ConfigPath, path); module != "" && !writeback.Required(cfg.Nvim.ConfigPath, module) {
+		fmt.Printf("Load the file from your init.lua: require(%q)\n", module)
+	}
+	return nil
+}
+
+// nvimKeymapsFile is the file --apply adds keymaps to
+func nvimKeymapsFile(cfg *config.Config) string {
+	file := cfg.Nvim.KeymapsFile
+	if file == "" {
+		file = config.Default().Nvim.KeymapsFile
+	}
+	if filepath.IsAbs(file) {
+		return file
+	}
+	return filepath.Join(cfg.Nvim.ConfigPath, file)
+}
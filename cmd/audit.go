@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+
+	"github.com/cliq-cli/cliq/internal/config"
+	"github.com/cliq-cli/cliq/internal/keyaudit"
+	"github.com/cliq-cli/cliq/internal/parser"
+)
+
+// auditCmd represents the audit command
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Check your configs for problems",
+	Long:  `Check your Neovim and tmux configs for problems cliq can spot.`,
+}
+
+// auditKeymapsCmd checks keymaps for conflicts and shadowing
+var auditKeymapsCmd = &cobra.Command{
+	Use:   "keymaps",
+	Short: "Find conflicting and shadowing keymaps",
+	Long: `Look through your Neovim and tmux keymaps for:
+
+  - keys mapped more than once, where only one of the mappings works
+  - mappings over important built-in keys, like u or <C-o>
+  - tmux bindings made without the prefix (bind -n) that take keys the
+    shell or Neovim need, including keys your Neovim config maps
+
+Findings are grouped by severity. The command fails if any are high.
+
+Examples:
+  cliq audit keymaps
+  cliq audit keymaps --json`,
+	Args: cobra.NoArgs,
+	RunE: runAuditKeymaps,
+}
+
+func init() {
+	rootCmd.AddCommand(auditCmd)
+	auditCmd.AddCommand(auditKeymapsCmd)
+
+	auditKeymapsCmd.Flags().Bool("json", false, "print the findings as JSON")
+}
+
+func runAuditKeymaps(cmd *cobra.Command, args []string) error {
+	asJSON, _ := cmd.Flags().GetBool("json")
+
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = config.Default()
+	}
+
+	var nvimConfig *parser.NvimConfig
+	if cfg.Nvim.ConfigPath != "" {
+		if nvimConfig, err = parser.ParseNvimConfig(cfg.Nvim.ConfigPath); err != nil && verbose {
+			fmt.Fprintf(os.Stderr, "Warning: could not parse nvim config: %v\n", err)
+		}
+	}
+	var tmuxConfig *parser.TmuxConfig
+	if cfg.Tmux.ConfigPath != "" {
+		if tmuxConfig, err = parser.ParseTmuxConfig(cfg.Tmux.ConfigPath); err != nil && verbose {
+			fmt.Fprintf(os.Stderr, "Warning: could not parse tmux config: %v\n", err)
+		}
+	}
+	if nvimConfig == nil && tmuxConfig == nil {
+		return fmt.Errorf("no Neovim or tmux config to audit (run cliq init)")
+	}
+
+	findings := keyaudit.Audit(nvimConfig, tmuxConfig)
+	high := 0
+	for _, f := range findings {
+		if f.Severity == keyaudit.High {
+			high++
+		}
+	}
+
+	if asJSON {
+		if findings == nil {
+			findings = []keyaudit.Finding{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(findings); err != nil {
+			return err
+		}
+	} else {
+		printAudit(findings)
+	}
+
+	if high > 0 {
+		return fmt.Errorf("%d high-severity keymap problem(s) found", high)
+	}
+	return nil
+}
+
+// printAudit prints findings under a heading per severity
+func printAudit(findings []keyaudit.Finding) {
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("42"))
+	keyStyle := lipgloss.NewStyle().Bold(true)
+	headings := map[keyaudit.Severity]string{
+		keyaudit.High:   lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("196")).Render("High severity"),
+		keyaudit.Medium: lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("214")).Render("Medium severity"),
+		keyaudit.Low:    lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("241")).Render("Low severity"),
+	}
+
+	fmt.Println(titleStyle.Render("Keymap audit"))
+	if len(findings) == 0 {
+		fmt.Println()
+		fmt.Println("No conflicts or shadowed keys found.")
+		return
+	}
+
+	for i, f := range findings {
+		if i == 0 || findings[i-1].Severity != f.Severity {
+			fmt.Println()
+			fmt.Println(headings[f.Severity])
+		}
+		fmt.Printf("  [%s %s] %s  %s\n", f.Tool, f.Mode, keyStyle.Render(f.Keys), f.Message)
+	}
+}
@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/cliq-cli/cliq/internal/llm"
+	"github.com/cliq-cli/cliq/internal/vault"
+)
+
+// authCmd represents the auth command
+var authCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Manage API keys for backends",
+	Long: `Keep the API keys of backends that need one in the OS keychain (macOS
+Keychain, or the Secret Service via secret-tool on Linux) rather than in a
+file. A key is sent as a bearer token to llama-server started with
+--api-key, or to ollama behind a proxy that checks one.
+
+Where there's no keychain, as on a headless machine, set the key in
+CLIQ_LLAMA_SERVER_API_KEY or CLIQ_OLLAMA_API_KEY instead. The keychain's
+key is used when there's one in both.
+
+Subcommands:
+  set   Save a backend's API key in the keychain
+  del   Remove a backend's API key from the keychain
+  list  Show which backends have a key, and where it comes from
+
+Examples:
+  cliq auth set llama-server
+  echo "$KEY" | cliq auth set ollama
+  cliq auth del ollama
+  cliq auth list`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var authSetCmd = &cobra.Command{
+	Use:       "set <backend>",
+	Short:     "Save a backend's API key in the keychain",
+	Long:      `Save a backend's API key in the OS keychain, asking for it on the terminal or reading it from stdin.`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: llm.KeyedBackends,
+	RunE:      runAuthSet,
+}
+
+var authDelCmd = &cobra.Command{
+	Use:       "del <backend>",
+	Short:     "Remove a backend's API key from the keychain",
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: llm.KeyedBackends,
+	RunE:      runAuthDel,
+}
+
+var authListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Show which backends have an API key",
+	Args:  cobra.NoArgs,
+	RunE:  runAuthList,
+}
+
+func init() {
+	rootCmd.AddCommand(authCmd)
+	authCmd.AddCommand(authSetCmd)
+	authCmd.AddCommand(authDelCmd)
+	authCmd.AddCommand(authListCmd)
+}
+
+// apiKeyAccount is the keychain account a backend's API key is kept under
+func apiKeyAccount(backend string) string {
+	return "api-key:" + backend
+}
+
+// apiKeyEnv is the environment variable a backend's API key can be set in
+func apiKeyEnv(backend string) string {
+	return "CLIQ_" + strings.ToUpper(strings.ReplaceAll(backend, "-", "_")) + "_API_KEY"
+}
+
+// checkKeyedBackend returns an error for a backend that takes no API key
+func checkKeyedBackend(backend string) error {
+	if !slices.Contains(llm.KeyedBackends, backend) {
+		return fmt.Errorf("%s takes no API key (backends that do: %s)", backend, strings.Join(llm.KeyedBackends, ", "))
+	}
+	return nil
+}
+
+// apiKeys caches the keys apiKeyFor found, so the keychain is asked once a
+// run
+var apiKeys = struct {
+	sync.Mutex
+	found map[string]string
+}{found: map[string]string{}}
+
+// apiKeyFor returns a backend's API key: the keychain's, then the one in
+// its environment variable, or "" for none
+func apiKeyFor(backend string) string {
+	apiKeys.Lock()
+	defer apiKeys.Unlock()
+	if key, ok := apiKeys.found[backend]; ok {
+		return key
+	}
+
+	key, err := vault.SecretGet(apiKeyAccount(backend))
+	if err != nil || key == "" {
+		key = os.Getenv(apiKeyEnv(backend))
+	}
+	apiKeys.found[backend] = key
+	return key
+}
+
+func runAuthSet(cmd *cobra.Command, args []string) error {
+	backend := args[0]
+	if err := checkKeyedBackend(backend); err != nil {
+		return err
+	}
+
+	key, err := readAPIKey(fmt.Sprintf("API key for %s: ", backend))
+	if err != nil {
+		return err
+	}
+	if err := vault.SecretSet(apiKeyAccount(backend), "cliq "+backend+" API key", key); err != nil {
+		if errors.Is(err, vault.ErrNoKeychain) {
+			return fmt.Errorf("%w; set %s instead", err, apiKeyEnv(backend))
+		}
+		return fmt.Errorf("could not save the API key: %w", err)
+	}
+
+	successStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	fmt.Println(successStyle.Render(fmt.Sprintf("✓ Saved the %s API key in the keychain", backend)))
+	return nil
+}
+
+// readAPIKey asks for a key on the terminal without echoing it, or reads
+// the first line of stdin when it isn't a terminal
+func readAPIKey(prompt string) (string, error) {
+	var key string
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		fmt.Fprint(os.Stderr, prompt)
+		b, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", err
+		}
+		key = string(b)
+	} else {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("no API key on stdin")
+		}
+		key = line
+	}
+
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return "", errors.New("empty API key")
+	}
+	return key, nil
+}
+
+func runAuthDel(cmd *cobra.Command, args []string) error {
+	backend := args[0]
+	if err := checkKeyedBackend(backend); err != nil {
+		return err
+	}
+	if _, err := vault.SecretGet(apiKeyAccount(backend)); err != nil {
+		if errors.Is(err, vault.ErrNoKeychain) {
+			return err
+		}
+		fmt.Printf("No %s API key in the keychain.\n", backend)
+		return nil
+	}
+	if err := vault.SecretDelete(apiKeyAccount(backend)); err != nil {
+		return fmt.Errorf("could not remove the API key: %w", err)
+	}
+	fmt.Printf("Removed the %s API key from the keychain.\n", backend)
+	if os.Getenv(apiKeyEnv(backend)) != "" {
+		fmt.Printf("%s is still set and will be used.\n", apiKeyEnv(backend))
+	}
+	return nil
+}
+
+func runAuthList(cmd *cobra.Command, args []string) error {
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("99"))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
+	for _, backend := range llm.KeyedBackends {
+		source := dimStyle.Render("none")
+		if key, err := vault.SecretGet(apiKeyAccount(backend)); err == nil && key != "" {
+			source = "keychain"
+		} else if os.Getenv(apiKeyEnv(backend)) != "" {
+			source = "$" + apiKeyEnv(backend)
+		}
+		fmt.Printf("%s %s\n", labelStyle.Render(fmt.Sprintf("%-13s", backend)), source)
+	}
+	return nil
+}
@@ -0,0 +1,290 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+
+	"github.com/cliq-cli/cliq/internal/config"
+	"github.com/cliq-cli/cliq/internal/llm"
+	"github.com/cliq-cli/cliq/internal/response"
+	"github.com/cliq-cli/cliq/internal/terminal"
+)
+
+// batchFormats are the reports cliq batch can write
+var batchFormats = []string{"text", "json", "jsonl", "markdown"}
+
+// batchCmd represents the batch command
+var batchCmd = &cobra.Command{
+	Use:   "batch <file>",
+	Short: "Answer every question in a file",
+	Long: `Ask each line of a file as a question, with your configs as for any
+query, and write a report of the answers: to build a cheatsheet for your
+team, or to compare how models answer the same questions. Blank lines and
+lines starting with # are skipped; "-" reads the questions from stdin.
+
+--format picks the report: text lists each question with its command,
+json writes one document with every answer, jsonl writes a line of JSON
+for each answer as it's ready, and markdown writes a cheatsheet with a
+section for each question.
+
+With --parallel, that many questions are asked at once, for a server
+backend (llama-server, ollama, or a plugin) that can answer them together.
+llama-cli loads the model for each question, so they're asked one at a
+time with it.
+
+Examples:
+  cliq batch questions.txt
+  cliq batch questions.txt --format json > answers.json
+  cliq batch questions.txt --format markdown --parallel 4 > CHEATSHEET.md
+  grep -v vim questions.txt | cliq batch - --format jsonl`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBatch,
+}
+
+func init() {
+	rootCmd.AddCommand(batchCmd)
+
+	batchCmd.Flags().StringP("format", "f", "text", "report format: "+strings.Join(batchFormats, ", "))
+	batchCmd.Flags().IntP("parallel", "p", 1, "questions to ask at once, with a server backend")
+}
+
+// batchResult is the answer to one question of a batch
+type batchResult struct {
+	Line  int    `json:"line"`
+	Query string `json:"query"`
+	*response.Response
+	Error     string `json:"error,omitempty"`
+	LatencyMS int64  `json:"latency_ms"`
+
+	// done is closed once the question is answered
+	done chan struct{}
+}
+
+func runBatch(cmd *cobra.Command, args []string) error {
+	format, _ := cmd.Flags().GetString("format")
+	parallel, _ := cmd.Flags().GetInt("parallel")
+	if !slices.Contains(batchFormats, format) {
+		return fmt.Errorf("--format must be one of %s", strings.Join(batchFormats, ", "))
+	}
+	if parallel < 1 {
+		return fmt.Errorf("--parallel must be at least 1")
+	}
+
+	results, err := readBatch(args[0])
+	if err != nil {
+		return err
+	}
+	if len(results) == 0 {
+		return fmt.Errorf("%s has no questions", args[0])
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = config.Default()
+	}
+	// The configs are parsed once for every question, with none skipped
+	pctx := gatherPromptContext("", cfg, nil)
+	if parallel, err = prepareBatch(cfg, pctx, results, parallel); err != nil {
+		return err
+	}
+
+	ctx := cmd.Context()
+	go answerBatch(ctx, cfg, pctx, results, parallel)
+
+	// Answers are written in the file's order as they come in, with a
+	// spinner counting them on a terminal
+	prog := startProgress(ctx, cfg.TUI.Icons)
+	defer prog.Stop()
+	failed := 0
+	for i, r := range results {
+		prog.Phase(fmt.Sprintf("Answering %d of %d", i+1, len(results)))
+		<-r.done
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if r.Error != "" {
+			failed++
+		}
+		prog.Pause()
+		if err := writeBatchResult(os.Stdout, format, r); err != nil {
+			return err
+		}
+	}
+	prog.Stop()
+
+	if format == "json" {
+		if err := writeBatchReport(os.Stdout, results, failed); err != nil {
+			return err
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d questions failed", failed, len(results))
+	}
+	return nil
+}
+
+// readBatch reads the questions in a file, or stdin for "-"
+func readBatch(path string) ([]*batchResult, error) {
+	in := os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	var results []*batchResult
+	scanner := bufio.NewScanner(in)
+	for line := 1; scanner.Scan(); line++ {
+		query := strings.TrimSpace(scanner.Text())
+		if query == "" || strings.HasPrefix(query, "#") {
+			continue
+		}
+		results = append(results, &batchResult{Line: line, Query: query, done: make(chan struct{})})
+	}
+	return results, scanner.Err()
+}
+
+// prepareBatch returns how many questions can be asked at once of the
+// backend they'd be sent to, and for a remote one shows what the whole
+// batch could cost and asks once before any is sent
+func prepareBatch(cfg *config.Config, pctx *llm.PromptContext, results []*batchResult, parallel int) (int, error) {
+	client, err := llm.NewClient(cfg.GetModelPath(), cfg.Model.OllamaModel, cfg.Model.Temperature, cfg.Model.MaxTokens)
+	if err != nil {
+		// Each question fails with the error
+		return parallel, nil
+	}
+	defer client.Close()
+
+	if parallel > 1 && strings.HasPrefix(client.GetBackend(), "llama-cli:") {
+		fmt.Fprintln(os.Stderr, "Warning: llama-cli loads the model for each question, so they're asked one at a time")
+		parallel = 1
+	}
+	if !client.IsRemote() {
+		return parallel, nil
+	}
+
+	var total llm.CostEstimate
+	for _, r := range results {
+		est := client.EstimateCost(llm.BuildPrompt(r.Query, pctx))
+		total.Model, total.Priced = est.Model, est.Priced
+		total.PromptTokens += est.PromptTokens
+		total.CompletionTokens += est.CompletionTokens
+		total.USD += est.USD
+	}
+	if total.Priced {
+		fmt.Fprintf(os.Stderr, "Estimated cost of %d questions: %s\n", len(results), total)
+	}
+	if err := confirmCost(total, cfg.Model.CostConfirmUSD); err != nil {
+		return 0, err
+	}
+	costConfirmed = true
+	return parallel, nil
+}
+
+// answerBatch answers the questions, parallel at a time, with the prompt
+// context gathered for them, marking each done as it's answered. Once ctx is canceled, the rest are marked done
+// unanswered.
+func answerBatch(ctx context.Context, cfg *config.Config, pctx *llm.PromptContext, results []*batchResult, parallel int) {
+	sem := make(chan struct{}, parallel)
+	for _, r := range results {
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem }()
+			defer close(r.done)
+			if ctx.Err() != nil {
+				return
+			}
+
+			// The documentation retrieved is the question's own
+			qctx := *pctx
+			qctx.References = llm.Retrieve(r.Query)
+			start := time.Now()
+			resp, err := answerWithContext(ctx, r.Query, cfg, &qctx, nil)
+			r.LatencyMS = time.Since(start).Milliseconds()
+			if err != nil {
+				r.Error = err.Error()
+				return
+			}
+			resp.Query = r.Query
+			r.Response = resp
+		}()
+	}
+}
+
+// writeBatchResult writes one answer of the report; json's are written
+// together at the end
+func writeBatchResult(w io.Writer, format string, r *batchResult) error {
+	switch format {
+	case "jsonl":
+		data, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case "markdown":
+		fmt.Fprintf(w, "## %s\n\n", r.Query)
+		switch {
+		case r.Error != "":
+			fmt.Fprintf(w, "_No answer: %s_\n\n", r.Error)
+		case r.Command != "":
+			fmt.Fprintf(w, "```\n%s\n```\n\n", r.Command)
+		}
+		if r.Response != nil && r.Explanation != "" {
+			fmt.Fprintf(w, "%s\n\n", r.Explanation)
+		}
+	case "text":
+		plain := !terminal.ColorEnabled(os.Stdout)
+		queryStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("99"))
+		cmdStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("42"))
+		errStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+		render := func(style lipgloss.Style, s string) string {
+			if plain {
+				return s
+			}
+			return style.Render(s)
+		}
+
+		fmt.Fprintln(w, render(queryStyle, r.Query))
+		switch {
+		case r.Error != "":
+			fmt.Fprintln(w, "  "+render(errStyle, "failed: "+r.Error))
+		case r.Command != "":
+			fmt.Fprintln(w, "  "+render(cmdStyle, r.Command))
+		}
+		if r.Response != nil && r.Explanation != "" {
+			fmt.Fprintln(w, "  "+r.Explanation)
+		}
+		fmt.Fprintln(w)
+	}
+	return nil
+}
+
+// writeBatchReport writes the json report: every answer, with counts
+func writeBatchReport(w io.Writer, results []*batchResult, failed int) error {
+	report := struct {
+		Questions int            `json:"questions"`
+		Answered  int            `json:"answered"`
+		Failed    int            `json:"failed"`
+		Results   []*batchResult `json:"results"`
+	}{len(results), len(results) - failed, failed, results}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
@@ -0,0 +1,275 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+
+	"github.com/cliq-cli/cliq/internal/config"
+	"github.com/cliq-cli/cliq/internal/llm"
+	"github.com/cliq-cli/cliq/internal/metrics"
+	"github.com/cliq-cli/cliq/internal/response"
+)
+
+// benchSuite is the fixed set of questions cliq bench asks: the kinds of
+// question cliq gets, across vim, tmux, and the shell
+var benchSuite = []string{
+	"how do I delete a line in vim",
+	"how do I search and replace in the whole file in vim",
+	"how do I split a tmux window vertically",
+	"how do I rename a tmux session",
+	"find files modified in the last day",
+	"show which process is listening on port 8080",
+	"count the lines of every go file in this directory",
+	"extract a tar.gz archive",
+}
+
+// benchCmd represents the bench command
+var benchCmd = &cobra.Command{
+	Use:   "bench",
+	Short: "Compare the speed and answer quality of backends and models",
+	Long: `Ask every available backend and model the same fixed set of questions
+and compare them: the median time to the first token and to the whole
+answer, generation speed in tokens per second, and how many answers kept
+to the Command:/Explanation: format cliq parses.
+
+The questions are asked without your Neovim, tmux, or shell configs, so
+results compare across machines. Queries aren't retried or sent to another
+backend while benchmarking. The first answer from a llama-cli model
+includes loading it, so use --runs to ask the suite more than once.
+
+Examples:
+  cliq bench
+  cliq bench --backend ollama
+  cliq bench --backend ollama:mistral --backend llama-cli --runs 3
+  cliq bench --json`,
+	Args: cobra.NoArgs,
+	RunE: runBench,
+}
+
+func init() {
+	rootCmd.AddCommand(benchCmd)
+
+	benchCmd.Flags().StringSlice("backend", nil, "benchmark only this backend or plugin, or <backend>:<model> for one of its models, as in ollama:mistral (repeatable; default all but offline)")
+	benchCmd.Flags().Int("runs", 1, "times to ask each question")
+	benchCmd.Flags().Bool("json", false, "print the results as JSON")
+}
+
+// benchResult is how one backend and model did on the suite
+type benchResult struct {
+	Backend string `json:"backend"`
+	Model   string `json:"model,omitempty"`
+	Queries int    `json:"queries"`
+	Errors  int    `json:"errors"`
+	// Compliant is how many answers had a Command: and Explanation:
+	Compliant     int     `json:"compliant"`
+	FirstTokenMS  int64   `json:"first_token_ms"`
+	LatencyMS     int64   `json:"latency_ms"`
+	TokensPerSec  float64 `json:"tokens_per_sec,omitempty"`
+	LastError     string  `json:"last_error,omitempty"`
+	compliantRate float64
+}
+
+func runBench(cmd *cobra.Command, args []string) error {
+	filters, _ := cmd.Flags().GetStringSlice("backend")
+	runs, _ := cmd.Flags().GetInt("runs")
+	asJSON, _ := cmd.Flags().GetBool("json")
+	if runs < 1 {
+		return fmt.Errorf("--runs must be at least 1")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = config.Default()
+	}
+
+	choices := benchChoices(llm.ListBackends(cmd.Context(), cfg.GetModelPath()), filters)
+	if len(choices) == 0 {
+		if len(filters) > 0 {
+			return fmt.Errorf("no available backend matches %s; see cliq status", strings.Join(filters, ", "))
+		}
+		return fmt.Errorf("no LLM backend is available to benchmark; run cliq init to set one up")
+	}
+
+	client, err := llm.NewClient(cfg.GetModelPath(), cfg.Model.OllamaModel, cfg.Model.Temperature, cfg.Model.MaxTokens)
+	if err != nil {
+		return fmt.Errorf("failed to initialize LLM: %w", err)
+	}
+	defer client.Close()
+	client = client.WithSamplingOptions(samplingOptions(cfg))
+
+	// A backend that fails should show in its own results, not be answered
+	// for by another
+	llm.SetFallback(0, nil)
+
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	pctx := &llm.PromptContext{Style: cfg.General.ResponseStyle}
+	var results []benchResult
+	for _, choice := range choices {
+		if !asJSON {
+			fmt.Fprintln(os.Stderr, dimStyle.Render(fmt.Sprintf("Benchmarking %s (%d queries)...", choice.Label(), len(benchSuite)*runs)))
+		}
+		results = append(results, benchBackend(cmd, client.WithBackend(choice), choice, pctx, runs))
+		if cmd.Context().Err() != nil {
+			return cmd.Context().Err()
+		}
+	}
+
+	if asJSON {
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	printBench(results)
+	return nil
+}
+
+// benchChoices picks the backends to benchmark: those the filters name, by
+// backend or plugin or as <backend>:<model>, or all but the offline
+// knowledge base
+func benchChoices(available []llm.BackendChoice, filters []string) []llm.BackendChoice {
+	var choices []llm.BackendChoice
+	for _, c := range available {
+		name, _, _ := strings.Cut(c.Backend, ":")
+		if llm.IsPlugin(c.Backend) {
+			name = llm.PluginName(c.Backend)
+		}
+		if len(filters) == 0 {
+			if name != "offline" {
+				choices = append(choices, c)
+			}
+			continue
+		}
+		for _, f := range filters {
+			backend, model, _ := strings.Cut(f, ":")
+			if backend == name && (model == "" || model == c.Model) {
+				choices = append(choices, c)
+				break
+			}
+		}
+	}
+	return choices
+}
+
+// benchBackend asks one backend the suite runs times
+func benchBackend(cmd *cobra.Command, client *llm.Client, choice llm.BackendChoice, pctx *llm.PromptContext, runs int) benchResult {
+	r := benchResult{Backend: llm.BackendChoice{Backend: choice.Backend}.Label(), Model: choice.Model}
+
+	var firstTokens, latencies []time.Duration
+	var tokens int
+	var generating time.Duration
+	for run := 0; run < runs; run++ {
+		for _, query := range benchSuite {
+			if cmd.Context().Err() != nil {
+				return r
+			}
+			r.Queries++
+			meter := metrics.NewMeter()
+			raw, err := client.QueryStreamContext(cmd.Context(), llm.BuildPrompt(query, pctx), func(token string) {
+				meter.Add(client.CountTokens(token))
+			})
+			if err != nil {
+				r.Errors++
+				r.LastError, _, _ = strings.Cut(err.Error(), "\n")
+				continue
+			}
+
+			latencies = append(latencies, meter.Elapsed())
+			firstTokens = append(firstTokens, meter.FirstToken())
+			// An answer that arrived all at once can't be timed
+			if meter.Streamed() {
+				tokens += meter.Tokens()
+				generating += meter.Elapsed() - meter.FirstToken()
+			}
+			if benchCompliant(raw) {
+				r.Compliant++
+			}
+		}
+	}
+
+	r.FirstTokenMS = medianDuration(firstTokens).Milliseconds()
+	r.LatencyMS = medianDuration(latencies).Milliseconds()
+	if generating > 0 {
+		r.TokensPerSec = float64(tokens) / generating.Seconds()
+	}
+	if answered := r.Queries - r.Errors; answered > 0 {
+		r.compliantRate = float64(r.Compliant) / float64(answered)
+	}
+	return r
+}
+
+// benchCompliant reports whether an answer kept to the response format:
+// a single-line command and an explanation of it
+func benchCompliant(raw string) bool {
+	resp := response.Parse(raw)
+	return resp.Command != "" && !strings.Contains(resp.Command, "\n") &&
+		resp.Explanation != "" && resp.Explanation != strings.TrimSpace(raw)
+}
+
+// medianDuration returns the median, or 0 for none
+func medianDuration(ds []time.Duration) time.Duration {
+	if len(ds) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), ds...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[len(sorted)/2]
+}
+
+// printBench prints the results as a table, fastest first
+func printBench(results []benchResult) {
+	sort.SliceStable(results, func(i, j int) bool {
+		// Backends that answered nothing go last
+		if (results[i].Errors == results[i].Queries) != (results[j].Errors == results[j].Queries) {
+			return results[j].Errors == results[j].Queries
+		}
+		return results[i].LatencyMS < results[j].LatencyMS
+	})
+
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("99"))
+	warnStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
+	width := len("Backend")
+	for _, r := range results {
+		width = max(width, lipgloss.Width(benchName(r)))
+	}
+	fmt.Println(labelStyle.Render(fmt.Sprintf("%-*s  %11s  %9s  %9s  %9s  %s", width, "Backend", "First token", "Latency", "Tokens/s", "Format", "Errors")))
+	for _, r := range results {
+		name := benchName(r) + strings.Repeat(" ", width-lipgloss.Width(benchName(r)))
+		if r.Errors == r.Queries {
+			fmt.Printf("%s  %s\n", name, warnStyle.Render(fmt.Sprintf("all %d queries failed", r.Queries)))
+		} else {
+			rate := "-"
+			if r.TokensPerSec > 0 {
+				rate = fmt.Sprintf("%.1f", r.TokensPerSec)
+			}
+			fmt.Printf("%s  %11s  %9s  %9s  %8.0f%%  %d/%d\n", name,
+				formatPhaseDuration(time.Duration(r.FirstTokenMS)*time.Millisecond),
+				formatPhaseDuration(time.Duration(r.LatencyMS)*time.Millisecond),
+				rate, r.compliantRate*100, r.Errors, r.Queries)
+		}
+		if r.LastError != "" {
+			fmt.Println(dimStyle.Render("  last error: " + r.LastError))
+		}
+	}
+	fmt.Println()
+	fmt.Println(dimStyle.Render("First token and latency are medians. Tokens/s is - for backends that don't stream. Format is the share of answers cliq could parse into a command and explanation."))
+}
+
+// benchName names a result's backend and model for the table
+func benchName(r benchResult) string {
+	if r.Model == "" {
+		return r.Backend
+	}
+	return r.Backend + " · " + r.Model
+}
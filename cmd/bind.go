@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+
+	"github.com/cliq-cli/cliq/internal/config"
+	"github.com/cliq-cli/cliq/internal/freekeys"
+	"github.com/cliq-cli/cliq/internal/knowledge"
+	"github.com/cliq-cli/cliq/internal/parser"
+)
+
+// bindCmd groups commands for making new keybindings
+var bindCmd = &cobra.Command{
+	Use:   "bind",
+	Short: "Help with new keybindings",
+	Long:  `Help with adding keybindings to your Neovim config.`,
+}
+
+// bindSuggestCmd suggests free leader keys for a new mapping
+var bindSuggestCmd = &cobra.Command{
+	Use:   "suggest <what it does>",
+	Short: "Suggest free leader keys for a new mapping",
+	Long: `Look through your Neovim keymaps for leader combinations nothing uses, and
+suggest a few that suit what the new mapping does, with the vim.keymap.set
+line to add. With --apply, the chosen one (--pick, the first by default) is
+added to nvim.keymaps_file after you confirm the diff.
+
+Combinations that fit one of your existing leader groups, like <leader>f for
+files, come first. A suggestion never starts, or is the start of, an
+existing mapping.
+
+Examples:
+  cliq bind suggest "toggle file tree"
+  cliq bind suggest --mode v "sort lines"
+  cliq bind suggest --apply --pick 2 "toggle file tree"`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runBindSuggest,
+}
+
+func init() {
+	rootCmd.AddCommand(bindCmd)
+	bindCmd.AddCommand(bindSuggestCmd)
+
+	bindSuggestCmd.Flags().StringP("mode", "m", "n", "mode of the new mapping")
+	bindSuggestCmd.Flags().IntP("count", "n", 3, "how many keys to suggest")
+	bindSuggestCmd.Flags().Bool("apply", false, "add a suggestion to your keymaps file, after confirming")
+	bindSuggestCmd.Flags().Int("pick", 1, "which suggestion --apply adds")
+}
+
+func runBindSuggest(cmd *cobra.Command, args []string) error {
+	mode, _ := cmd.Flags().GetString("mode")
+	count, _ := cmd.Flags().GetInt("count")
+	apply, _ := cmd.Flags().GetBool("apply")
+	pick, _ := cmd.Flags().GetInt("pick")
+	task := strings.Join(args, " ")
+
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = config.Default()
+	}
+	if cfg.Nvim.ConfigPath == "" {
+		return fmt.Errorf("no Neovim config found (set nvim.config_path, or run cliq init)")
+	}
+	nvimConfig, err := parser.ParseNvimConfig(cfg.Nvim.ConfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to parse nvim config: %w", err)
+	}
+
+	suggestions := freekeys.Suggest(nvimConfig, mode, task, count)
+	if len(suggestions) == 0 {
+		return fmt.Errorf("no free leader keys found for %q", task)
+	}
+
+	rhs := "<cmd>YourCommand<cr>"
+	command, plugin := knowledge.PluginCommand(pluginNames(nvimConfig), task)
+	if command != "" {
+		rhs = "<cmd>" + command + "<cr>"
+	}
+
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("42"))
+	keyStyle := lipgloss.NewStyle().Bold(true)
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	codeStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+
+	fmt.Println(titleStyle.Render(fmt.Sprintf("Free keys for %q", task)))
+	fmt.Println(dimStyle.Render("Leader: " + formatLeader(nvimConfig.Leader)))
+	for i, s := range suggestions {
+		fmt.Println()
+		fmt.Printf("%d. %s  %s\n", i+1, keyStyle.Render(s.Lhs), dimStyle.Render("("+s.Keys+")"))
+		fmt.Printf("   %s\n", s.Reason)
+		fmt.Printf("   %s\n", codeStyle.Render(freekeys.KeymapLine(mode, s.Lhs, rhs, task)))
+	}
+
+	fmt.Println()
+	if command != "" {
+		fmt.Println(dimStyle.Render(fmt.Sprintf("The command is %s's; change it if you want something else.", plugin)))
+	} else {
+		fmt.Println(dimStyle.Render("Replace YourCommand with the command the mapping should run."))
+	}
+
+	if !apply {
+		return nil
+	}
+	if pick < 1 || pick > len(suggestions) {
+		return fmt.Errorf("--pick must be between 1 and %d", len(suggestions))
+	}
+	s := suggestions[pick-1]
+	return applyCommand(cfg, freekeys.KeymapLine(mode, s.Lhs, rhs, task))
+}
+
+// pluginNames returns the names of the config's plugins
+func pluginNames(cfg *parser.NvimConfig) []string {
+	names := make([]string, 0, len(cfg.Plugins))
+	for _, p := range cfg.Plugins {
+		names = append(names, p.Name)
+	}
+	return names
+}
@@ -0,0 +1,331 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+
+	"github.com/cliq-cli/cliq/internal/config"
+	"github.com/cliq-cli/cliq/internal/daemon"
+	"github.com/cliq-cli/cliq/internal/parser"
+	"github.com/cliq-cli/cliq/internal/store"
+)
+
+// cacheCmd groups commands for the parsed config cache
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Inspect and manage the parsed config cache",
+	Long: `Inspect and manage the cache of parsed Neovim and tmux configs that
+queries read instead of parsing every time. Each NVIM_APPNAME profile has
+its own cache; these commands work on the one --nvim-profile or NVIM_APPNAME
+picks.
+
+Subcommands:
+  show     Show when the cache was built and what's in it
+  stats    List the config files behind the cache and which changed since
+  refresh  Re-parse the configs and rebuild the cache
+  clear    Delete the cache, or with --answers the cached model answers
+
+Examples:
+  cliq cache show
+  cliq cache stats --json
+  cliq cache clear`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var cacheShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show when the cache was built and what's in it",
+	Args:  cobra.NoArgs,
+	RunE:  runCacheShow,
+}
+
+var cacheStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "List the config files behind the cache, with their hashes and times",
+	Long: `List each config file the cache was parsed from, with its size, when it
+was last modified, and its SHA-256, marking files that changed, appeared,
+or were deleted since the cache was saved.`,
+	Args: cobra.NoArgs,
+	RunE: runCacheStats,
+}
+
+var cacheRefreshCmd = &cobra.Command{
+	Use:   "refresh",
+	Short: "Re-parse the configs and rebuild the cache",
+	Args:  cobra.NoArgs,
+	RunE:  runConfigReload,
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Delete the cache (--all for every profile's)",
+	Args:  cobra.NoArgs,
+	RunE:  runCacheClear,
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheShowCmd)
+	cacheCmd.AddCommand(cacheStatsCmd)
+	cacheCmd.AddCommand(cacheRefreshCmd)
+	cacheCmd.AddCommand(cacheClearCmd)
+
+	cacheShowCmd.Flags().Bool("json", false, "print the summary as JSON")
+	cacheStatsCmd.Flags().Bool("json", false, "print the files as JSON")
+	cacheClearCmd.Flags().Bool("all", false, "clear the caches of every NVIM_APPNAME profile")
+	cacheClearCmd.Flags().Bool("answers", false, "clear the cached model answers instead")
+}
+
+func runCacheShow(cmd *cobra.Command, args []string) error {
+	asJSON, _ := cmd.Flags().GetBool("json")
+
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = config.Default()
+	}
+	path, err := parser.CachePath(cfg.Nvim.ActiveProfile)
+	if err != nil {
+		return err
+	}
+	cache, err := parser.LoadCache(cfg.Nvim.ActiveProfile)
+	if err != nil {
+		return fmt.Errorf("failed to read cache: %w", err)
+	}
+
+	summary := cache.GetSummary()
+	summary["path"] = path
+	summary["profile"] = cfg.Nvim.ActiveProfile
+	summary["enabled"] = cfg.Cache.Enabled
+	summary["is_stale"] = cache.IsStale(cfg.Cache.TTLHours)
+	summary["ttl_hours"] = cfg.Cache.TTLHours
+	summary["daemon"] = daemon.Running()
+	if answers, err := store.LoadAnswerCache(); err == nil {
+		summary["answers_count"] = len(answers.Answers)
+	}
+	if info, err := os.Stat(path); err == nil {
+		summary["size_bytes"] = info.Size()
+	}
+
+	if asJSON {
+		data, err := json.MarshalIndent(summary, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("42"))
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("99"))
+	warnStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	row := func(label string, value any) {
+		fmt.Printf("  %s %v\n", labelStyle.Render(fmt.Sprintf("%-14s", label+":")), value)
+	}
+
+	fmt.Println(titleStyle.Render("Config cache"))
+	row("Path", path)
+	if cfg.Nvim.ActiveProfile != "" {
+		row("Profile", cfg.Nvim.ActiveProfile)
+	}
+	if !cfg.Cache.Enabled {
+		row("Enabled", warnStyle.Render("no (cache.enabled = false)"))
+	}
+	if cache.LastParsed.IsZero() {
+		row("Built", dimStyle.Render("never"))
+		fmt.Println()
+		fmt.Println(dimStyle.Render("Run cliq cache refresh to build it."))
+		return nil
+	}
+	if size, ok := summary["size_bytes"].(int64); ok {
+		row("Size", fmt.Sprintf("%.1f KB", float64(size)/1024))
+	}
+	age := time.Since(cache.LastParsed).Round(time.Second)
+	row("Built", fmt.Sprintf("%s (%s ago)", cache.LastParsed.Format(time.DateTime), age))
+	switch {
+	case cache.Outdated():
+		row("Fresh", warnStyle.Render("no, written by another version of cliq, so queries re-parse"))
+	case cache.IsStale(cfg.Cache.TTLHours):
+		row("Fresh", warnStyle.Render(fmt.Sprintf("no, older than %dh, so queries re-parse", cfg.Cache.TTLHours)))
+	case cache.NeedsRefresh():
+		row("Fresh", warnStyle.Render("no, the configs changed since (cliq cache stats shows which)"))
+	default:
+		row("Fresh", "yes")
+	}
+	if daemon.Running() {
+		row("Daemon", "running, and keeps the cache up to date")
+	}
+	if answers, err := store.LoadAnswerCache(); err == nil && cfg.Cache.Answers {
+		row("Answers", fmt.Sprintf("%d cached (cliq cache clear --answers to drop them)", len(answers.Answers)))
+	}
+
+	if cache.NvimConfig != nil {
+		fmt.Println()
+		fmt.Println(titleStyle.Render("Neovim"))
+		row("Config", cache.NvimConfig.ConfigPath)
+		row("Leader", formatLeader(cache.NvimConfig.Leader))
+		row("Keymaps", len(cache.NvimConfig.Keymaps))
+		row("Plugins", len(cache.NvimConfig.Plugins))
+	}
+	if cache.TmuxConfig != nil {
+		fmt.Println()
+		fmt.Println(titleStyle.Render("tmux"))
+		row("Config", cache.TmuxConfig.ConfigPath)
+		row("Prefix", cache.TmuxConfig.Prefix)
+		row("Keymaps", len(cache.TmuxConfig.Keymaps))
+		row("Includes", len(cache.TmuxConfig.Includes))
+	}
+	return nil
+}
+
+func runCacheStats(cmd *cobra.Command, args []string) error {
+	asJSON, _ := cmd.Flags().GetBool("json")
+
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = config.Default()
+	}
+	cache, err := parser.LoadCache(cfg.Nvim.ActiveProfile)
+	if err != nil {
+		return fmt.Errorf("failed to read cache: %w", err)
+	}
+	files := cache.Files()
+
+	if asJSON {
+		data, err := json.MarshalIndent(files, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("42"))
+	warnStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
+	if len(files) == 0 {
+		fmt.Println(dimStyle.Render("The cache is empty. Run cliq cache refresh to build it."))
+		return nil
+	}
+
+	home, _ := os.UserHomeDir()
+	fmt.Println(titleStyle.Render(fmt.Sprintf("%d config files", len(files))))
+	var total int64
+	changed := 0
+	for _, f := range files {
+		total += f.Size
+		state := ""
+		switch {
+		case f.Hash == "":
+			state = "deleted"
+		case f.Cached == "":
+			state = "new"
+		case f.Changed():
+			state = "modified"
+		}
+		if state != "" {
+			changed++
+		}
+
+		hash := f.Hash
+		if hash == "" {
+			hash = f.Cached
+		}
+		if len(hash) > 12 {
+			hash = hash[:12]
+		}
+		modified := "-"
+		if !f.ModTime.IsZero() {
+			modified = f.ModTime.Format(time.DateTime)
+		}
+		path := f.Path
+		if rel, err := filepath.Rel(home, path); err == nil && home != "" && !strings.HasPrefix(rel, "..") {
+			path = filepath.Join("~", rel)
+		}
+		line := fmt.Sprintf("  %s  %8d  %s  %s", hash, f.Size, modified, path)
+		if state != "" {
+			fmt.Println(warnStyle.Render(line + "  (" + state + ")"))
+		} else {
+			fmt.Println(line)
+		}
+	}
+
+	fmt.Println()
+	fmt.Println(dimStyle.Render(fmt.Sprintf("%d bytes in all; %d changed since the cache was saved %s",
+		total, changed, cache.LastParsed.Format(time.DateTime))))
+	if changed > 0 {
+		fmt.Println(dimStyle.Render("Run cliq cache refresh to re-parse them."))
+	}
+	return nil
+}
+
+func runCacheClear(cmd *cobra.Command, args []string) error {
+	all, _ := cmd.Flags().GetBool("all")
+	clearAnswers, _ := cmd.Flags().GetBool("answers")
+	if clearAnswers {
+		answers, err := store.LoadAnswerCache()
+		if err != nil {
+			return fmt.Errorf("failed to load answer cache: %w", err)
+		}
+		n := len(answers.Answers)
+		answers.Clear()
+		if err := answers.Save(); err != nil {
+			return fmt.Errorf("failed to clear answer cache: %w", err)
+		}
+		fmt.Println(lipgloss.NewStyle().Foreground(lipgloss.Color("42")).Render(fmt.Sprintf("✓ Cleared %d cached answers", n)))
+		return nil
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = config.Default()
+	}
+	profiles := []string{cfg.Nvim.ActiveProfile}
+	if all {
+		// Every cache file, including those of profiles since removed
+		profiles = nil
+		dir, err := config.GetCacheDir()
+		if err != nil {
+			return err
+		}
+		matches, _ := filepath.Glob(filepath.Join(dir, "config-cache*.json"))
+		for _, m := range matches {
+			name := strings.TrimSuffix(filepath.Base(m), ".json")
+			profiles = append(profiles, strings.TrimPrefix(strings.TrimPrefix(name, "config-cache"), "-"))
+		}
+	}
+
+	successStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	cleared := 0
+	for _, profile := range profiles {
+		if err := (&parser.Cache{Profile: profile}).Clear(); os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("failed to clear cache: %w", err)
+		}
+		cleared++
+		if profile != "" {
+			fmt.Println(successStyle.Render("✓ Cache cleared for profile " + profile))
+		} else {
+			fmt.Println(successStyle.Render("✓ Cache cleared"))
+		}
+	}
+	if cleared == 0 {
+		fmt.Println("The cache is already empty.")
+		return nil
+	}
+	if daemon.Running() {
+		fmt.Println("The daemon is running and rebuilds it on the next config change; cliq daemon stop stops it.")
+	}
+	return nil
+}
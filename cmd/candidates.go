@@ -0,0 +1,237 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/viper"
+
+	"github.com/cliq-cli/cliq/internal/config"
+	"github.com/cliq-cli/cliq/internal/llm"
+	"github.com/cliq-cli/cliq/internal/response"
+	"github.com/cliq-cli/cliq/internal/terminal"
+)
+
+// maxCandidates bounds --candidates, as each is a full generation
+const maxCandidates = 10
+
+// candidateTemperature is the least temperature samples after the first
+// run at, so they can differ from it
+const candidateTemperature = 0.8
+
+// candidate is one distinct command among the model's answers
+type candidate struct {
+	Response *response.Response
+	// Votes is how many of the answers gave the command
+	Votes int
+}
+
+// answerCandidates asks the model the question n times and ranks the
+// distinct commands in its answers by how many gave each, so an approach
+// the model keeps coming back to comes first. It also returns how many
+// answers there were, as a failed sample is skipped.
+func answerCandidates(ctx context.Context, query string, cfg *config.Config, n int) ([]candidate, int, error) {
+	queryProgress.Phase("Parsing configs")
+	pctx := gatherPromptContext(query, cfg, nil)
+	pctx.FollowUp = followUp
+	if len(cfg.Hooks.Before) > 0 {
+		queryProgress.Phase("Running hooks")
+	}
+	pctx.Commands = runBeforeHooks(ctx, cfg.Hooks, query)
+	applyLessons(pctx, query)
+	queryProgress.Phase("Building prompt")
+	prompt, err := hookPrompt(query, llm.BuildPrompt(query, pctx))
+	warnHook(err)
+
+	queryProgress.Phase("Starting backend")
+	client, err := llm.NewClient(cfg.GetModelPath(), cfg.Model.OllamaModel, cfg.Model.Temperature, cfg.Model.MaxTokens)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to initialize LLM: %w", err)
+	}
+	defer client.Close()
+	client = client.WithSamplingOptions(samplingOptions(cfg))
+
+	if verbose {
+		fmt.Fprintln(os.Stderr, "Query:", query)
+		fmt.Fprintln(os.Stderr, "Backend:", client.GetBackend())
+		fmt.Fprintln(os.Stderr, "Sampling:", describeSampling(cfg))
+	}
+	if client.IsRemote() {
+		est := client.EstimateCost(prompt)
+		est.PromptTokens *= n
+		est.CompletionTokens *= n
+		est.USD *= float64(n)
+		queryProgress.Pause()
+		if est.Priced {
+			fmt.Fprintln(os.Stderr, "Estimated cost:", est)
+		}
+		if err := confirmCost(est, cfg.Model.CostConfirmUSD); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	samples := 0
+	var ranked []*candidate
+	byCommand := map[string]*candidate{}
+	for i := 0; i < n; i++ {
+		sample := client
+		if i > 0 {
+			// Later samples run hotter, and with the next seed when one was
+			// given, so they can take another approach
+			sample = client.WithSampling(math.Max(cfg.Model.Temperature, candidateTemperature), cfg.Model.MaxTokens)
+			if cfg.Model.Seed != nil {
+				opts := samplingOptions(cfg)
+				seed := *cfg.Model.Seed + i
+				opts.Seed = &seed
+				sample = sample.WithSamplingOptions(opts)
+			}
+		}
+
+		queryProgress.Phase(fmt.Sprintf("Generating answer %d of %d", i+1, n))
+		raw, err := sample.QueryContext(ctx, prompt)
+		reportAttempts(sample)
+		if err != nil {
+			if i == 0 || ctx.Err() != nil {
+				return nil, 0, fmt.Errorf("failed to generate response: %w", err)
+			}
+			if verbose {
+				fmt.Fprintf(os.Stderr, "Warning: sample %d failed: %v\n", i+1, err)
+			}
+			continue
+		}
+		samples++
+		resp := response.Parse(raw)
+		personalizeResponse(resp, pctx, query)
+		warnHook(hookResponse(resp))
+
+		key := strings.Join(strings.Fields(resp.Command), " ")
+		if key == "" {
+			continue
+		}
+		if c, ok := byCommand[key]; ok {
+			c.Votes++
+			continue
+		}
+		c := &candidate{Response: resp, Votes: 1}
+		byCommand[key] = c
+		ranked = append(ranked, c)
+	}
+
+	// Ties keep the order the commands were first given in
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Votes > ranked[j].Votes
+	})
+	candidates := make([]candidate, len(ranked))
+	for i, c := range ranked {
+		candidates[i] = *c
+	}
+	return candidates, samples, nil
+}
+
+// executeCandidates shows the ranked candidates in the --format asked for,
+// and with --apply, applies the one picked
+func executeCandidates(ctx context.Context, query string, cfg *config.Config, n int) error {
+	if n > maxCandidates {
+		return fmt.Errorf("--candidates is at most %d", maxCandidates)
+	}
+	started := time.Now()
+	queryProgress = startProgress(ctx, cfg.TUI.Icons)
+	defer queryProgress.Stop()
+	candidates, samples, err := answerCandidates(ctx, query, cfg, n)
+	queryProgress.Stop()
+	if err != nil {
+		return err
+	}
+	if len(candidates) == 0 {
+		return ErrNoCommand
+	}
+
+	switch viper.GetString("format") {
+	case "json":
+		type jsonCandidate struct {
+			*response.Response
+			Votes int `json:"votes"`
+		}
+		out := struct {
+			Query      string          `json:"query"`
+			Samples    int             `json:"samples"`
+			Candidates []jsonCandidate `json:"candidates"`
+		}{Query: query, Samples: samples}
+		for _, c := range candidates {
+			out.Candidates = append(out.Candidates, jsonCandidate{c.Response, c.Votes})
+		}
+		data, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	case "cmd":
+		for _, c := range candidates {
+			fmt.Println(c.Response.Command)
+		}
+	default:
+		printCandidates(candidates, samples)
+	}
+	recordQuery(cfg, query, candidates[0].Response)
+	notifySlowAnswer(cfg, query, candidates[0].Response, started)
+	runAfterHooks(ctx, cfg.Hooks, query, candidates[0].Response, os.Stderr)
+
+	if applyAnswer {
+		pick, err := pickCandidate(len(candidates))
+		if err != nil || pick < 0 {
+			return err
+		}
+		return applyCommand(cfg, candidates[pick].Response.Command)
+	}
+	return nil
+}
+
+// printCandidates lists the candidates with how many samples gave each
+func printCandidates(candidates []candidate, samples int) {
+	plain := !terminal.ColorEnabled(os.Stdout)
+	numStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("99"))
+	cmdStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("42"))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
+	for i, c := range candidates {
+		if i > 0 {
+			fmt.Println()
+		}
+		votes := fmt.Sprintf("(%d of %d samples)", c.Votes, samples)
+		if plain {
+			fmt.Printf("%d. %s  %s\n", i+1, c.Response.Command, votes)
+		} else {
+			fmt.Printf("%s %s  %s\n", numStyle.Render(fmt.Sprintf("%d.", i+1)), cmdStyle.Render(c.Response.Command), dimStyle.Render(votes))
+		}
+		if c.Response.Explanation != "" {
+			fmt.Println("   " + c.Response.Explanation)
+		}
+	}
+}
+
+// pickCandidate asks which of n candidates to apply, returning -1 when
+// none is picked
+func pickCandidate(n int) (int, error) {
+	if info, err := os.Stdin.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return -1, fmt.Errorf("--apply with --candidates asks which one to apply, so it needs a terminal")
+	}
+	fmt.Fprintf(os.Stderr, "\nApply which candidate? [1-%d, Enter to skip] ", n)
+	var answer string
+	fmt.Scanln(&answer)
+	if answer = strings.TrimSpace(answer); answer == "" {
+		return -1, nil
+	}
+	pick, err := strconv.Atoi(answer)
+	if err != nil || pick < 1 || pick > n {
+		return -1, fmt.Errorf("no candidate %q", answer)
+	}
+	return pick - 1, nil
+}
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+
+	"github.com/cliq-cli/cliq/internal/knowledge"
+)
+
+// cheatCmd represents the cheat command
+var cheatCmd = &cobra.Command{
+	Use:   "cheat",
+	Short: "Manage community cheatsheet packs",
+	Long: `Install community cheatsheet packs to extend the offline knowledge base.
+
+A pack is a gzipped tarball holding a pack.yaml manifest (name, version,
+description, author) and YAML files of entries in the knowledge base format.
+Packs are validated before they're installed; their answers are used when no
+LLM backend is available, just like the built-in ones.
+
+Packs must carry a minisign signature (<pack>.minisig beside the tarball)
+made with one of the keys built into cliq; --insecure installs unsigned or
+unverified packs anyway.
+
+Subcommands:
+  install  Install a pack from a URL, a file, or the pack index by name
+  list     List installed packs
+  remove   Remove an installed pack
+
+Examples:
+  cliq cheat install kubectl
+  cliq cheat install https://example.com/git-pack.tar.gz
+  cliq cheat install ./my-pack.tar.gz
+  cliq cheat list
+  cliq cheat remove kubectl`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// cheatInstallCmd represents the cheat install command
+var cheatInstallCmd = &cobra.Command{
+	Use:   "install <url|file|name>",
+	Short: "Install a pack from a URL, a file, or the pack index by name",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runCheatInstall,
+}
+
+// cheatListCmd represents the cheat list command
+var cheatListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List installed packs",
+	Args:  cobra.NoArgs,
+	RunE:  runCheatList,
+}
+
+// cheatRemoveCmd represents the cheat remove command
+var cheatRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Remove an installed pack",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runCheatRemove,
+}
+
+var cheatInsecure bool
+
+func init() {
+	rootCmd.AddCommand(cheatCmd)
+	cheatCmd.AddCommand(cheatInstallCmd)
+	cheatCmd.AddCommand(cheatListCmd)
+	cheatCmd.AddCommand(cheatRemoveCmd)
+
+	cheatInstallCmd.Flags().BoolVar(&cheatInsecure, "insecure", false, "install a pack even if it's unsigned or its signature doesn't verify")
+}
+
+func runCheatInstall(cmd *cobra.Command, args []string) error {
+	data, sig, err := knowledge.FetchPack(cmd.Context(), args[0])
+	if err != nil {
+		return fmt.Errorf("failed to fetch pack: %w", err)
+	}
+
+	// Packs end up suggesting shell commands, so only trust signed ones
+	signedBy, err := knowledge.VerifyPack(data, sig)
+	if err != nil {
+		if !cheatInsecure {
+			return fmt.Errorf("refusing to install pack: %w (pass --insecure to install it anyway)", err)
+		}
+		fmt.Fprintf(os.Stderr, "Warning: installing a pack that failed verification: %v\n", err)
+	} else if verbose {
+		fmt.Fprintf(os.Stderr, "Signature verified: %s\n", signedBy)
+	}
+
+	pack, err := knowledge.ParsePack(data)
+	if err != nil {
+		return err
+	}
+	if err := knowledge.InstallPack(pack); err != nil {
+		return fmt.Errorf("failed to install pack: %w", err)
+	}
+
+	successStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	fmt.Println(successStyle.Render(fmt.Sprintf("✓ Installed %s %s (%d answers)", pack.Name, pack.Version, len(pack.Entries))))
+	return nil
+}
+
+func runCheatList(cmd *cobra.Command, args []string) error {
+	packs, err := knowledge.InstalledPacks()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	if len(packs) == 0 {
+		fmt.Println("No cheatsheet packs installed. Add one with: cliq cheat install <url|file|name>")
+		return nil
+	}
+
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("99"))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	for _, pack := range packs {
+		line := fmt.Sprintf("%s %s  %d answers", labelStyle.Render(pack.Name), pack.Version, len(pack.Entries))
+		if pack.Description != "" {
+			line += dimStyle.Render("  " + pack.Description)
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
+
+func runCheatRemove(cmd *cobra.Command, args []string) error {
+	if err := knowledge.RemovePack(args[0]); err != nil {
+		return err
+	}
+	fmt.Printf("Removed %s\n", args[0])
+	return nil
+}
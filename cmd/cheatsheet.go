@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cliq-cli/cliq/internal/cheatsheet"
+	"github.com/cliq-cli/cliq/internal/config"
+	"github.com/cliq-cli/cliq/internal/llm"
+	"github.com/cliq-cli/cliq/internal/parser"
+)
+
+// cheatsheetCmd generates a cheatsheet of the user's own bindings
+var cheatsheetCmd = &cobra.Command{
+	Use:   "cheatsheet [nvim|tmux|shell]",
+	Short: "Generate a cheatsheet of your own keybindings",
+	Long: `Generate a cheatsheet of the keybindings and aliases in your own configs,
+grouped by category: Neovim keymaps, tmux bindings, and shell aliases and
+functions. Without an argument, every config cliq found is included.
+
+Formats:
+  terminal   styled for the terminal (default)
+  markdown   a table per category
+  html       a standalone page laid out to print, or save as PDF
+
+Bindings no rule places go under Other; --llm asks the model to label them.
+Defaults that a Neovim distribution or tmux plugin binds are left out
+unless --defaults is given.
+
+Examples:
+  cliq cheatsheet
+  cliq cheatsheet tmux --format markdown
+  cliq cheatsheet --format html -o cheatsheet.html --llm`,
+	Args:      cobra.MatchAll(cobra.MaximumNArgs(1), cobra.OnlyValidArgs),
+	ValidArgs: []string{"nvim", "tmux", "shell"},
+	RunE:      runCheatsheet,
+}
+
+func init() {
+	rootCmd.AddCommand(cheatsheetCmd)
+
+	cheatsheetCmd.Flags().StringP("format", "f", "terminal", "output format: terminal, markdown, or html")
+	cheatsheetCmd.Flags().StringP("output", "o", "", "write to this file instead of standard output")
+	cheatsheetCmd.Flags().Bool("llm", false, "ask the model to label bindings no rule places")
+	cheatsheetCmd.Flags().Bool("defaults", false, "include distribution and plugin default bindings")
+}
+
+func runCheatsheet(cmd *cobra.Command, args []string) error {
+	format, _ := cmd.Flags().GetString("format")
+	output, _ := cmd.Flags().GetString("output")
+	useLLM, _ := cmd.Flags().GetBool("llm")
+	defaults, _ := cmd.Flags().GetBool("defaults")
+
+	render := map[string]func([]cheatsheet.Sheet) string{
+		"terminal": cheatsheet.Terminal,
+		"markdown": cheatsheet.Markdown,
+		"md":       cheatsheet.Markdown,
+		"html":     cheatsheet.HTML,
+	}[format]
+	if render == nil {
+		return fmt.Errorf("unknown format %q (use terminal, markdown, or html)", format)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = config.Default()
+	}
+	tool := ""
+	if len(args) > 0 {
+		tool = args[0]
+	}
+	notation := cfg.General.KeyNotation
+
+	var sheets []cheatsheet.Sheet
+	if (tool == "" || tool == "nvim") && cfg.Nvim.ConfigPath != "" {
+		nvimConfig, err := parser.ParseNvimConfig(cfg.Nvim.ConfigPath)
+		if err != nil {
+			if tool != "" {
+				return fmt.Errorf("failed to parse nvim config: %w", err)
+			}
+			if verbose {
+				fmt.Fprintf(os.Stderr, "Warning: could not parse nvim config: %v\n", err)
+			}
+		} else {
+			sheets = append(sheets, cheatsheet.Nvim(nvimConfig, notation, defaults))
+		}
+	}
+	if (tool == "" || tool == "tmux") && cfg.Tmux.ConfigPath != "" {
+		tmuxConfig, err := parser.ParseTmuxConfig(cfg.Tmux.ConfigPath)
+		if err != nil {
+			if tool != "" {
+				return fmt.Errorf("failed to parse tmux config: %w", err)
+			}
+			if verbose {
+				fmt.Fprintf(os.Stderr, "Warning: could not parse tmux config: %v\n", err)
+			}
+		} else {
+			sheets = append(sheets, cheatsheet.Tmux(tmuxConfig, notation, defaults))
+		}
+	}
+	if tool == "" || tool == "shell" {
+		if shellConfig := parseShellConfig(cfg); shellConfig != nil {
+			sheets = append(sheets, cheatsheet.Shell(shellConfig))
+		}
+	}
+
+	// Keep only sheets with something on them
+	var nonEmpty []cheatsheet.Sheet
+	for _, s := range sheets {
+		if len(s.Sections) > 0 {
+			nonEmpty = append(nonEmpty, s)
+		}
+	}
+	sheets = nonEmpty
+	if len(sheets) == 0 {
+		if tool != "" {
+			return fmt.Errorf("no %s bindings found (check cliq config show %s)", tool, tool)
+		}
+		return fmt.Errorf("no bindings found (run cliq init, or check cliq config show)")
+	}
+
+	if useLLM {
+		if err := labelCheatsheets(cmd, cfg, sheets); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not label bindings: %v\n", err)
+		}
+	}
+
+	text := render(sheets)
+	if output == "" {
+		fmt.Print(text)
+		return nil
+	}
+	if err := os.WriteFile(output, []byte(text), 0644); err != nil {
+		return fmt.Errorf("failed to write cheatsheet: %w", err)
+	}
+	fmt.Printf("Wrote %s\n", output)
+	return nil
+}
+
+// labelCheatsheets asks the model to categorize the bindings each sheet
+// has under Other
+func labelCheatsheets(cmd *cobra.Command, cfg *config.Config, sheets []cheatsheet.Sheet) error {
+	client, err := llm.NewClient(cfg.GetModelPath(), cfg.Model.OllamaModel, cfg.Model.Temperature, cfg.Model.MaxTokens)
+	if err != nil {
+		return fmt.Errorf("failed to initialize LLM: %w", err)
+	}
+	defer client.Close()
+
+	for i := range sheets {
+		other := sheets[i].Uncategorized()
+		if len(other) == 0 {
+			continue
+		}
+		raw, err := client.QueryContext(cmd.Context(), cheatsheet.LabelPrompt(sheets[i]))
+		if err != nil {
+			return err
+		}
+		sheets[i].Relabel(cheatsheet.ParseLabels(raw, len(other)))
+	}
+	return nil
+}
@@ -5,12 +5,17 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"sort"
+	"strings"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/spf13/cobra"
 
 	"github.com/cliq-cli/cliq/internal/config"
+	"github.com/cliq-cli/cliq/internal/keynotation"
+	"github.com/cliq-cli/cliq/internal/llm"
 	"github.com/cliq-cli/cliq/internal/parser"
+	"github.com/cliq-cli/cliq/internal/shelltype"
 )
 
 // configCmd represents the config command
@@ -21,9 +26,13 @@ var configCmd = &cobra.Command{
 reloading config files, and editing the configuration.
 
 Subcommands:
-  show    Show parsed configuration
-  reload  Reload and re-parse configs
-  edit    Open config file in $EDITOR`,
+  show      Show parsed configuration
+  reload    Reload and re-parse configs
+  edit      Open config file in $EDITOR
+  get       Print a setting
+  set       Change a setting
+  unset     Return a setting to its default
+  validate  Check the config file for mistakes`,
 	Run: func(cmd *cobra.Command, args []string) {
 		cmd.Help()
 	},
@@ -31,9 +40,9 @@ Subcommands:
 
 // showCmd represents the config show command
 var showCmd = &cobra.Command{
-	Use:   "show [nvim|tmux|all]",
+	Use:   "show [nvim|tmux|shell|all]",
 	Short: "Show parsed configuration",
-	Long:  `Display the parsed Neovim or tmux configuration, including detected keymaps and settings.`,
+	Long:  `Display the parsed Neovim, tmux, or shell configuration, including detected keymaps, aliases, and settings.`,
 	Args:  cobra.MaximumNArgs(1),
 	RunE:  runConfigShow,
 }
@@ -54,11 +63,84 @@ var editCmd = &cobra.Command{
 	RunE:  runConfigEdit,
 }
 
+// configGetCmd represents the config get command
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print a setting",
+	Long: `Print the setting at a dotted key, as cliq uses it: from your config
+file, or the default when the file doesn't set it. A section name, such as
+model, prints the whole section.
+
+Examples:
+  cliq config get model.temperature
+  cliq config get model
+  cliq config get history.exclude --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runConfigGet,
+}
+
+// configSetCmd represents the config set command
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Change a setting",
+	Long: `Change the setting at a dotted key in your config file. The value must
+suit the setting: true or false, a number, or for lists either
+comma-separated items or a TOML array. The rest of the file, comments
+included, is left as it was.
+
+Examples:
+  cliq config set model.temperature 0.3
+  cliq config set model.backend ollama
+  cliq config set history.exclude customer,prod-db
+  cliq config set invocations.gitq.prefix "Using git,"`,
+	Args: cobra.ExactArgs(2),
+	RunE: runConfigSet,
+}
+
+// configUnsetCmd represents the config unset command
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset <key>",
+	Short: "Return a setting to its default",
+	Long: `Remove the setting at a dotted key from your config file, so cliq uses
+its default again.
+
+Examples:
+  cliq config unset model.temperature`,
+	Args: cobra.ExactArgs(1),
+	RunE: runConfigUnset,
+}
+
+// configValidateCmd represents the config validate command
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the config files for mistakes",
+	Long: `Check your config file for settings cliq doesn't know, such as typos of
+ones it does, values of the wrong type, and values out of range, like a
+negative TTL or a temperature above 2. Without this, they're ignored or the
+whole file is. Every run warns about them too.
+
+The project's .cliq.toml, if you're in one, and the file of the profile in
+effect are checked as well, including for settings they can't set.
+
+Examples:
+  cliq config validate
+  cliq config validate --json`,
+	Args: cobra.NoArgs,
+	RunE: runConfigValidate,
+}
+
 func init() {
 	rootCmd.AddCommand(configCmd)
 	configCmd.AddCommand(showCmd)
 	configCmd.AddCommand(reloadCmd)
 	configCmd.AddCommand(editCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configUnsetCmd)
+	configCmd.AddCommand(configValidateCmd)
+
+	configGetCmd.Flags().Bool("json", false, "print the setting as JSON")
+	configValidateCmd.Flags().Bool("json", false, "print the problems as JSON")
 }
 
 func runConfigShow(cmd *cobra.Command, args []string) error {
@@ -80,13 +162,31 @@ func runConfigShow(cmd *cobra.Command, args []string) error {
 		return showNvimConfig(cfg, titleStyle, labelStyle)
 	case "tmux":
 		return showTmuxConfig(cfg, titleStyle, labelStyle)
+	case "shell":
+		return showShellConfig(cfg, titleStyle, labelStyle)
 	case "all":
 		fmt.Println(titleStyle.Render("=== Cliq Configuration ===\n"))
 
 		// Show general config
 		fmt.Println(labelStyle.Render("Config File:"), config.GetConfigPath())
+		if cfg.ActiveProfile != "" {
+			fmt.Println(labelStyle.Render("Profile:"), cfg.ActiveProfile)
+		}
+		if profiles := cfg.ProfileNames(); len(profiles) > 0 {
+			fmt.Println(labelStyle.Render("Profiles:"), strings.Join(profiles, ", "), "(pick one with --profile or CLIQ_PROFILE)")
+		}
 		fmt.Println(labelStyle.Render("Model Path:"), cfg.GetModelPath())
+		if format := llm.FormatFor(cfg.GetModelPath()); format.Metadata != nil {
+			template := "raw"
+			if format.Template != nil {
+				template = format.Template.Name
+			}
+			fmt.Println(labelStyle.Render("Model:"), fmt.Sprintf("%s, %s template, %d context", format.Metadata.Architecture, template, format.Context))
+		}
+		in := llm.InferenceFor(cfg.GetModelPath())
+		fmt.Println(labelStyle.Render("llama.cpp:"), fmt.Sprintf("%d GPU layers, %d threads, batch size %d", in.GPULayers, in.Threads, in.BatchSize))
 		fmt.Println(labelStyle.Render("Response Style:"), cfg.General.ResponseStyle)
+		fmt.Println(labelStyle.Render("Keyboard Layout:"), cfg.General.KeyboardLayout)
 		fmt.Println()
 
 		if err := showNvimConfig(cfg, titleStyle, labelStyle); err != nil {
@@ -97,8 +197,13 @@ func runConfigShow(cmd *cobra.Command, args []string) error {
 		if err := showTmuxConfig(cfg, titleStyle, labelStyle); err != nil {
 			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
 		}
+		fmt.Println()
+
+		if err := showShellConfig(cfg, titleStyle, labelStyle); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
 	default:
-		return fmt.Errorf("unknown target: %s (use nvim, tmux, or all)", target)
+		return fmt.Errorf("unknown target: %s (use nvim, tmux, shell, or all)", target)
 	}
 
 	return nil
@@ -112,16 +217,28 @@ func showNvimConfig(cfg *config.Config, titleStyle, labelStyle lipgloss.Style) e
 		return nil
 	}
 
+	if cfg.Nvim.ActiveProfile != "" {
+		fmt.Println(labelStyle.Render("Profile:"), cfg.Nvim.ActiveProfile)
+	}
 	fmt.Println(labelStyle.Render("Config Path:"), cfg.Nvim.ConfigPath)
+	if profiles := cfg.NvimProfiles(); len(profiles) > 0 {
+		fmt.Println(labelStyle.Render("Profiles:"), strings.Join(profiles, ", "), "(pick one with --nvim-profile or NVIM_APPNAME)")
+	}
 
 	nvimConfig, err := parser.ParseNvimConfig(cfg.Nvim.ConfigPath)
 	if err != nil {
 		return fmt.Errorf("could not parse nvim config: %w", err)
 	}
 
+	if nvimConfig.Distro != "" {
+		fmt.Println(labelStyle.Render("Distribution:"), nvimConfig.Distro)
+	}
 	fmt.Println(labelStyle.Render("Leader Key:"), nvimConfig.Leader)
 	fmt.Println(labelStyle.Render("Keymaps Found:"), len(nvimConfig.Keymaps))
 	fmt.Println(labelStyle.Render("Plugins Found:"), len(nvimConfig.Plugins))
+	fmt.Println(labelStyle.Render("Options Set:"), len(nvimConfig.Options))
+	fmt.Println(labelStyle.Render("Autocommands:"), len(nvimConfig.Autocmds))
+	fmt.Println(labelStyle.Render("User Commands:"), len(nvimConfig.UserCommands))
 
 	if len(nvimConfig.Keymaps) > 0 {
 		fmt.Println(labelStyle.Render("\nSample Keymaps:"))
@@ -130,10 +247,14 @@ func showNvimConfig(cfg *config.Config, titleStyle, labelStyle lipgloss.Style) e
 				fmt.Printf("  ... and %d more\n", len(nvimConfig.Keymaps)-5)
 				break
 			}
-			fmt.Printf("  [%s] %s -> %s\n", km.Mode, km.Lhs, km.Rhs)
+			fmt.Printf("  [%s] %s -> %s\n", km.Mode, keynotation.Normalize(km.Keys, cfg.General.KeyNotation), km.Rhs)
 		}
 	}
 
+	if managers := nvimConfig.PluginManagers(); len(managers) > 0 {
+		fmt.Println(labelStyle.Render("Plugin Managers:"), strings.Join(managers, ", "))
+	}
+
 	if len(nvimConfig.Plugins) > 0 {
 		fmt.Println(labelStyle.Render("\nDetected Plugins:"))
 		for i, p := range nvimConfig.Plugins {
@@ -145,10 +266,44 @@ func showNvimConfig(cfg *config.Config, titleStyle, labelStyle lipgloss.Style) e
 			if !p.Enabled {
 				status = "disabled"
 			}
+			if p.Manager != "" {
+				status += ", " + p.Manager
+			}
 			fmt.Printf("  %s (%s)\n", p.Name, status)
 		}
 	}
 
+	if len(nvimConfig.Options) > 0 {
+		fmt.Println(labelStyle.Render("\nOptions:"))
+		names := make([]string, 0, len(nvimConfig.Options))
+		for name := range nvimConfig.Options {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for i, name := range names {
+			if i >= 10 {
+				fmt.Printf("  ... and %d more\n", len(names)-10)
+				break
+			}
+			fmt.Printf("  %s = %s\n", name, nvimConfig.Options[name])
+		}
+	}
+
+	if len(nvimConfig.UserCommands) > 0 {
+		fmt.Println(labelStyle.Render("\nUser Commands:"))
+		for i, uc := range nvimConfig.UserCommands {
+			if i >= 5 {
+				fmt.Printf("  ... and %d more\n", len(nvimConfig.UserCommands)-5)
+				break
+			}
+			fmt.Printf("  :%s -> %s", uc.Name, uc.Command)
+			if uc.Description != "" {
+				fmt.Printf(" (%s)", uc.Description)
+			}
+			fmt.Println()
+		}
+	}
+
 	return nil
 }
 
@@ -166,9 +321,22 @@ func showTmuxConfig(cfg *config.Config, titleStyle, labelStyle lipgloss.Style) e
 	if err != nil {
 		return fmt.Errorf("could not parse tmux config: %w", err)
 	}
+	tmuxConfig = withLiveTmux(cfg, tmuxConfig)
 
-	fmt.Println(labelStyle.Render("Prefix:"), tmuxConfig.Prefix)
+	if tmuxConfig.Live {
+		fmt.Println(labelStyle.Render("Source:"), "config file and running tmux server")
+	}
+	if tmuxConfig.Session != nil {
+		fmt.Println(labelStyle.Render("Session:"), tmuxConfig.Session.Describe())
+	}
+	fmt.Println(labelStyle.Render("Prefix:"), keynotation.Normalize(tmuxConfig.Prefix, cfg.General.KeyNotation))
 	fmt.Println(labelStyle.Render("Keymaps Found:"), len(tmuxConfig.Keymaps))
+	if len(tmuxConfig.Plugins) > 0 {
+		fmt.Println(labelStyle.Render("Plugins:"), strings.Join(tmuxConfig.PluginNames(), ", "))
+	}
+	if len(tmuxConfig.Includes) > 0 {
+		fmt.Println(labelStyle.Render("Included Files:"), strings.Join(tmuxConfig.Includes, ", "))
+	}
 
 	if len(tmuxConfig.Keymaps) > 0 {
 		fmt.Println(labelStyle.Render("\nSample Keymaps:"))
@@ -177,7 +345,47 @@ func showTmuxConfig(cfg *config.Config, titleStyle, labelStyle lipgloss.Style) e
 				fmt.Printf("  ... and %d more\n", len(tmuxConfig.Keymaps)-5)
 				break
 			}
-			fmt.Printf("  %s -> %s\n", km.Key, km.Command)
+			fmt.Printf("  %s -> %s", keynotation.Normalize(km.Key, cfg.General.KeyNotation), km.Command)
+			if km.Condition != "" {
+				fmt.Printf(" (if %s)", km.Condition)
+			}
+			fmt.Println()
+		}
+	}
+
+	return nil
+}
+
+func showShellConfig(cfg *config.Config, titleStyle, labelStyle lipgloss.Style) error {
+	fmt.Println(titleStyle.Render("--- Shell Configuration ---"))
+
+	if name := shelltype.Detect(); name != "" {
+		fmt.Println(labelStyle.Render("Shell:"), name)
+	}
+
+	paths := cfg.GetShellConfigPaths()
+	if len(paths) == 0 {
+		fmt.Println("  No shell configuration detected")
+		return nil
+	}
+
+	shellConfig, err := parser.ParseShellConfig(paths...)
+	if err != nil {
+		return fmt.Errorf("could not parse shell config: %w", err)
+	}
+
+	fmt.Println(labelStyle.Render("Config Files:"), strings.Join(shellConfig.Files, ", "))
+	fmt.Println(labelStyle.Render("Aliases Found:"), len(shellConfig.Aliases))
+	fmt.Println(labelStyle.Render("Functions Found:"), len(shellConfig.Functions))
+
+	if len(shellConfig.Aliases) > 0 {
+		fmt.Println(labelStyle.Render("\nSample Aliases:"))
+		for i, a := range shellConfig.Aliases {
+			if i >= 5 {
+				fmt.Printf("  ... and %d more\n", len(shellConfig.Aliases)-5)
+				break
+			}
+			fmt.Printf("  %s -> %s\n", a.Name, a.Expansion)
 		}
 	}
 
@@ -219,6 +427,7 @@ func runConfigReload(cmd *cobra.Command, args []string) error {
 
 	// Save cache
 	cache := &parser.Cache{
+		Profile:    cfg.Nvim.ActiveProfile,
 		NvimConfig: nvimConfig,
 		TmuxConfig: tmuxConfig,
 	}
@@ -229,13 +438,16 @@ func runConfigReload(cmd *cobra.Command, args []string) error {
 
 	fmt.Println(successStyle.Render("  ✓ Cache updated"))
 
+	// Answers saved before the change may now describe the old setup
+	reportStaleAnswers(newPromptContext(cfg, nvimConfig, tmuxConfig))
+
 	// Print summary as JSON for debugging
 	if verbose {
 		summary := map[string]interface{}{
-			"nvim_keymaps":  len(nvimConfig.Keymaps),
-			"nvim_plugins":  len(nvimConfig.Plugins),
-			"tmux_keymaps":  len(tmuxConfig.Keymaps),
-			"tmux_prefix":   tmuxConfig.Prefix,
+			"nvim_keymaps": len(nvimConfig.Keymaps),
+			"nvim_plugins": len(nvimConfig.Plugins),
+			"tmux_keymaps": len(tmuxConfig.Keymaps),
+			"tmux_prefix":  tmuxConfig.Prefix,
 		}
 		data, _ := json.MarshalIndent(summary, "", "  ")
 		fmt.Println("\nSummary:")
@@ -245,6 +457,145 @@ func runConfigReload(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func runConfigGet(cmd *cobra.Command, args []string) error {
+	asJSON, _ := cmd.Flags().GetBool("json")
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	value, err := cfg.Get(args[0])
+	if err != nil {
+		return err
+	}
+	if source, ok := cfg.OverriddenBy(args[0]); ok {
+		dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+		fmt.Fprintln(os.Stderr, dimStyle.Render("(from "+source+")"))
+	}
+
+	if asJSON {
+		plain, err := config.Plain(value)
+		if err != nil {
+			return err
+		}
+		data, err := json.MarshalIndent(plain, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	switch v := value.(type) {
+	case string:
+		fmt.Println(v)
+	case []string:
+		// One item a line, for scripts to read
+		for _, item := range v {
+			fmt.Println(item)
+		}
+	case bool, int, float64:
+		fmt.Println(v)
+	default:
+		text, err := config.FormatTOML(v)
+		if err != nil {
+			return err
+		}
+		fmt.Println(text)
+	}
+	return nil
+}
+
+func runConfigSet(cmd *cobra.Command, args []string) error {
+	key := args[0]
+	value, err := config.ParseValue(key, args[1])
+	if err != nil {
+		return err
+	}
+	if err := config.SetKey(key, value); err != nil {
+		return err
+	}
+	fmt.Printf("Set %s in %s\n", key, config.GetConfigPath())
+	if cfg, err := config.Load(); err == nil {
+		if source, ok := cfg.OverriddenBy(key); ok {
+			fmt.Fprintf(os.Stderr, "Warning: %s sets it too, and overrides this\n", source)
+		}
+	}
+	return nil
+}
+
+func runConfigUnset(cmd *cobra.Command, args []string) error {
+	unset, err := config.UnsetKey(args[0])
+	if err != nil {
+		return err
+	}
+	if !unset {
+		fmt.Printf("%s isn't set in %s; it already has its default\n", args[0], config.GetConfigPath())
+		return nil
+	}
+	fmt.Printf("Unset %s; it has its default again\n", args[0])
+	return nil
+}
+
+func runConfigValidate(cmd *cobra.Command, args []string) error {
+	asJSON, _ := cmd.Flags().GetBool("json")
+
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = config.Default()
+	}
+	files := configFiles(cfg)
+	var problems []config.Problem
+	for _, path := range files {
+		found, err := config.ValidateFile(path)
+		if err != nil {
+			return fmt.Errorf("%s is not valid TOML: %w", path, err)
+		}
+		problems = append(problems, found...)
+	}
+
+	if asJSON {
+		if problems == nil {
+			problems = []config.Problem{}
+		}
+		data, err := json.MarshalIndent(problems, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	} else {
+		okStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+		labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("99"))
+		keyStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+		for i, path := range files {
+			var found []config.Problem
+			for _, p := range problems {
+				if p.File == path {
+					found = append(found, p)
+				}
+			}
+			if len(found) == 0 {
+				fmt.Println(okStyle.Render("✓ " + path + " has no problems"))
+				continue
+			}
+			if len(files) > 1 {
+				fmt.Println(labelStyle.Render(path))
+			}
+			for _, p := range found {
+				fmt.Printf("%s %s\n", keyStyle.Render(p.Key+":"), p.Message)
+			}
+			if i < len(files)-1 {
+				fmt.Println()
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%d problem(s) in the config", len(problems))
+	}
+	return nil
+}
+
 func runConfigEdit(cmd *cobra.Command, args []string) error {
 	editor := os.Getenv("EDITOR")
 	if editor == "" {
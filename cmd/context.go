@@ -0,0 +1,530 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+
+	"github.com/cliq-cli/cliq/internal/config"
+	"github.com/cliq-cli/cliq/internal/keyboard"
+	"github.com/cliq-cli/cliq/internal/keynotation"
+	"github.com/cliq-cli/cliq/internal/llm"
+	"github.com/cliq-cli/cliq/internal/locale"
+	"github.com/cliq-cli/cliq/internal/parser"
+	"github.com/cliq-cli/cliq/internal/project"
+	"github.com/cliq-cli/cliq/internal/shelltype"
+	"github.com/cliq-cli/cliq/internal/store"
+	"github.com/cliq-cli/cliq/internal/terminal"
+	"github.com/cliq-cli/cliq/internal/toolchain"
+)
+
+// contextCmd represents the context command
+var contextCmd = &cobra.Command{
+	Use:   "context",
+	Short: "Manage context that is always sent with queries",
+	Long: `Manage pinned context. Pinned keymaps, aliases, and notes are included in
+every prompt regardless of how relevant they look to the question.
+
+Subcommands:
+  pin       Pin a note, keymap, or alias
+  list      List pinned context
+  unpin     Remove a pinned item
+  terminal  Show detected terminal capabilities
+  project   Show the project context pack and tasks for this directory
+  toolchain Show detected version managers and pinned tool versions
+
+Examples:
+  cliq context pin "I use colemak"
+  cliq context pin "my Caps Lock is Ctrl"
+  cliq context pin --keymap "<leader>ff"
+  cliq context pin --alias "gs = git status"
+
+Projects can ship their own context in .cliq/context.md (build commands,
+conventions, key scripts). It is included in prompts whenever cliq runs
+inside that project.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// contextPinCmd represents the context pin command
+var contextPinCmd = &cobra.Command{
+	Use:   "pin <text>",
+	Short: "Pin a note, keymap, or alias",
+	Long: `Pin a piece of context so it is always included in prompts.
+
+With --keymap, the text is looked up among your parsed Neovim and tmux
+keymaps and the full mapping is pinned.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runContextPin,
+}
+
+// contextListCmd represents the context list command
+var contextListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List pinned context",
+	RunE:  runContextList,
+}
+
+// contextUnpinCmd represents the context unpin command
+var contextUnpinCmd = &cobra.Command{
+	Use:   "unpin <id>",
+	Short: "Remove a pinned item",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runContextUnpin,
+}
+
+// contextTerminalCmd represents the context terminal command
+var contextTerminalCmd = &cobra.Command{
+	Use:   "terminal",
+	Short: "Show detected terminal capabilities",
+	Long: `Show the terminal emulator, multiplexer, and capabilities cliq detected.
+This is included in prompts for questions about keys, colors, and the clipboard.`,
+	RunE: runContextTerminal,
+}
+
+// contextProjectCmd represents the context project command
+var contextProjectCmd = &cobra.Command{
+	Use:   "project",
+	Short: "Show the project context pack and tasks for this directory",
+	Long: `Show the .cliq/context.md found in this directory or its parents, up to
+the repository root. Once trusted with 'cliq project trust', its contents
+are included in every prompt made from inside the project, along with the
+files context_files under [general] in the project's .cliq.toml names.
+
+Also lists the Makefile targets, justfile recipes, and package.json scripts
+cliq answers "how do I build/test this" questions from.`,
+	Args: cobra.NoArgs,
+	RunE: runContextProject,
+}
+
+// contextToolchainCmd represents the context toolchain command
+var contextToolchainCmd = &cobra.Command{
+	Use:   "toolchain",
+	Short: "Show detected version managers and pinned tool versions",
+	Long: `Show the version managers (mise, asdf, nvm, pyenv, ...) on PATH, the .envrc
+direnv has loaded, and the .tool-versions, .nvmrc, and similar files that
+apply to this directory. This is included in prompts for questions about
+tool versions and PATH, such as "why is the wrong node being used".`,
+	Args: cobra.NoArgs,
+	RunE: runContextToolchain,
+}
+
+func init() {
+	rootCmd.AddCommand(contextCmd)
+	contextCmd.AddCommand(contextPinCmd)
+	contextCmd.AddCommand(contextListCmd)
+	contextCmd.AddCommand(contextUnpinCmd)
+	contextCmd.AddCommand(contextTerminalCmd)
+	contextCmd.AddCommand(contextProjectCmd)
+	contextCmd.AddCommand(contextToolchainCmd)
+
+	contextPinCmd.Flags().Bool("keymap", false, "pin a keymap from your parsed config")
+	contextPinCmd.Flags().Bool("alias", false, "pin a shell alias")
+}
+
+func runContextPin(cmd *cobra.Command, args []string) error {
+	text := strings.TrimSpace(strings.Join(args, " "))
+	if text == "" {
+		return fmt.Errorf("nothing to pin")
+	}
+
+	pinKeymap, _ := cmd.Flags().GetBool("keymap")
+	pinAlias, _ := cmd.Flags().GetBool("alias")
+	if pinKeymap && pinAlias {
+		return fmt.Errorf("--keymap and --alias can't be used together; a pin is one or the other")
+	}
+
+	kind := store.PinNote
+	switch {
+	case pinKeymap:
+		kind = store.PinKeymap
+		if resolved := resolveKeymap(text); resolved != "" {
+			text = resolved
+		} else {
+			fmt.Fprintf(os.Stderr, "Warning: %s not found in your parsed config, pinning as written\n", text)
+		}
+	case pinAlias:
+		kind = store.PinAlias
+	}
+
+	pins, err := store.LoadPins()
+	if err != nil {
+		return fmt.Errorf("failed to load pins: %w", err)
+	}
+
+	pin := pins.Add(kind, text)
+	if err := pins.Save(); err != nil {
+		return fmt.Errorf("failed to save pins: %w", err)
+	}
+
+	successStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	fmt.Println(successStyle.Render(fmt.Sprintf("✓ Pinned #%d (%s): %s", pin.ID, pin.Kind, pin.Text)))
+	return nil
+}
+
+func runContextList(cmd *cobra.Command, args []string) error {
+	pins, err := store.LoadPins()
+	if err != nil {
+		return fmt.Errorf("failed to load pins: %w", err)
+	}
+
+	if len(pins.Items) == 0 {
+		fmt.Println("No pinned context. Add some with: cliq context pin \"I use colemak\"")
+		return nil
+	}
+
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("99"))
+	for _, pin := range pins.Items {
+		fmt.Printf("%s %s\n", labelStyle.Render(fmt.Sprintf("#%d [%s]", pin.ID, pin.Kind)), pin.Text)
+	}
+	return nil
+}
+
+func runContextUnpin(cmd *cobra.Command, args []string) error {
+	id, err := strconv.Atoi(strings.TrimPrefix(args[0], "#"))
+	if err != nil {
+		return fmt.Errorf("invalid pin id: %s", args[0])
+	}
+
+	pins, err := store.LoadPins()
+	if err != nil {
+		return fmt.Errorf("failed to load pins: %w", err)
+	}
+
+	if !pins.Remove(id) {
+		return fmt.Errorf("no pin with id %d", id)
+	}
+	if err := pins.Save(); err != nil {
+		return fmt.Errorf("failed to save pins: %w", err)
+	}
+
+	fmt.Printf("Unpinned #%d\n", id)
+	return nil
+}
+
+func runContextTerminal(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = config.Default()
+	}
+
+	var tmuxConfig *parser.TmuxConfig
+	if cfg.Tmux.ConfigPath != "" {
+		tmuxConfig, _ = parser.ParseTmuxConfig(cfg.Tmux.ConfigPath)
+	}
+
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("42"))
+	fmt.Println(titleStyle.Render("Terminal Capabilities"))
+	fmt.Println()
+	for _, line := range terminal.Detect(tmuxConfig).Lines() {
+		fmt.Println("  " + line)
+	}
+	return nil
+}
+
+func runContextProject(cmd *cobra.Command, args []string) error {
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("99"))
+
+	proj := findProjectContext()
+	if proj == nil {
+		fmt.Printf("No %s found in this directory or its parents.\n", project.ContextFile)
+	} else {
+		fmt.Println(labelStyle.Render(proj.Path))
+		fmt.Println()
+		fmt.Println(proj.Text)
+		printContextFileStatus(proj, true)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = config.Default()
+	}
+	if path, err := config.FindProjectFile(); err == nil && path != "" {
+		fmt.Println()
+		fmt.Println(labelStyle.Render("Settings from " + path))
+	}
+	for _, path := range cfg.ContextFilePaths() {
+		file, err := project.ReadContextFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not read context file: %v\n", err)
+			continue
+		}
+		fmt.Println()
+		fmt.Println(labelStyle.Render(path))
+		fmt.Println()
+		fmt.Println(file.Text)
+		printContextFileStatus(file, cfg.ContextFilesFromProject())
+	}
+
+	tasks := findProjectTasks()
+	if len(tasks) == 0 {
+		return nil
+	}
+	fmt.Println()
+	fmt.Println(labelStyle.Render("Tasks (used to answer \"how do I build/test this\")"))
+	for _, t := range tasks {
+		fmt.Println("  " + t.String())
+	}
+	return nil
+}
+
+// printContextFileStatus notes below a context file shown by context project
+// whether it's sent with queries, and whether only in part
+func printContextFileStatus(file *project.Context, fromProject bool) {
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("99"))
+	if fromProject {
+		trusted, err := store.LoadTrustedFiles()
+		if err != nil || !trusted.Trusted(file.Path, file.SHA256) {
+			fmt.Println()
+			fmt.Println(labelStyle.Render("(not trusted, so not sent with queries; run cliq project trust to allow it)"))
+			return
+		}
+	}
+	if file.Truncated {
+		fmt.Println()
+		fmt.Println(labelStyle.Render("(truncated; only the part above is sent with queries)"))
+	}
+}
+
+func runContextToolchain(cmd *cobra.Command, args []string) error {
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	tc := toolchain.Detect(wd)
+	if tc.IsEmpty() {
+		fmt.Println("No version managers or version files detected.")
+		return nil
+	}
+
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("42"))
+	fmt.Println(titleStyle.Render("Toolchain"))
+	fmt.Println()
+	for _, line := range tc.Lines() {
+		fmt.Println("  " + line)
+	}
+	return nil
+}
+
+// findProjectTasks returns the Makefile, justfile, and package.json tasks
+// of the project in the working directory
+func findProjectTasks() []project.Task {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil
+	}
+	tasks, err := project.FindTasks(wd)
+	if err != nil && verbose {
+		fmt.Fprintf(os.Stderr, "Warning: could not read project tasks: %v\n", err)
+	}
+	return tasks
+}
+
+// withLiveTmux merges the running tmux server's state into the parsed
+// tmux.conf, when cliq runs inside tmux and tmux.live is on
+func withLiveTmux(cfg *config.Config, tmuxConfig *parser.TmuxConfig) *parser.TmuxConfig {
+	if !cfg.Tmux.Live || !parser.TmuxRunning() {
+		return tmuxConfig
+	}
+	live, err := parser.ParseLiveTmux(context.Background())
+	if err != nil {
+		if verbose {
+			fmt.Fprintf(os.Stderr, "Warning: could not read tmux state: %v\n", err)
+		}
+		return tmuxConfig
+	}
+	return parser.MergeLive(tmuxConfig, live)
+}
+
+// parseShellConfig reads the aliases and functions from the user's shell
+// startup files, and which shell they run
+func parseShellConfig(cfg *config.Config) *parser.ShellConfig {
+	name := shelltype.Detect()
+	paths := cfg.GetShellConfigPaths()
+	if len(paths) == 0 {
+		if name == "" {
+			return nil
+		}
+		return &parser.ShellConfig{Name: name}
+	}
+
+	shell, err := parser.ParseShellConfig(paths...)
+	if err != nil {
+		if verbose {
+			fmt.Fprintf(os.Stderr, "Warning: could not parse shell config: %v\n", err)
+		}
+		shell = &parser.ShellConfig{}
+	}
+	shell.Name = name
+	return shell
+}
+
+// findProjectContext returns the context pack for the working directory, or
+// nil if there is none
+func findProjectContext() *project.Context {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil
+	}
+	proj, err := project.FindContext(wd)
+	if err != nil {
+		if verbose {
+			fmt.Fprintf(os.Stderr, "Warning: could not read project context: %v\n", err)
+		}
+		return nil
+	}
+	return proj
+}
+
+// projectFileTrusted reports whether the user has trusted a file a project
+// ships, as it is now, to go into prompts; a project's files could
+// otherwise steer the commands cliq suggests
+func projectFileTrusted(file *project.Context) bool {
+	trusted, err := store.LoadTrustedFiles()
+	if err == nil && trusted.Trusted(file.Path, file.SHA256) {
+		return true
+	}
+	if verbose {
+		fmt.Fprintf(os.Stderr, "Not using %s until you trust it with: cliq project trust\n", file.Path)
+	}
+	return false
+}
+
+// readContextFiles returns the text of the files general.context_files
+// names, skipping any that can't be read, and those a project file names
+// that the user hasn't trusted
+func readContextFiles(cfg *config.Config) []string {
+	var texts []string
+	fromProject := cfg.ContextFilesFromProject()
+	for _, path := range cfg.ContextFilePaths() {
+		file, err := project.ReadContextFile(path)
+		if err != nil {
+			if verbose {
+				fmt.Fprintf(os.Stderr, "Warning: could not read context file: %v\n", err)
+			}
+			continue
+		}
+		if fromProject && !projectFileTrusted(file) {
+			continue
+		}
+		if verbose {
+			fmt.Fprintf(os.Stderr, "Using context from %s\n", path)
+		}
+		texts = append(texts, file.Text)
+	}
+	return texts
+}
+
+// resolveKeymap looks up a key binding in the parsed configs and returns a
+// full description of it, or "" if it isn't mapped
+func resolveKeymap(lhs string) string {
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = config.Default()
+	}
+
+	if cfg.Nvim.ConfigPath != "" {
+		if nvimConfig, err := parser.ParseNvimConfig(cfg.Nvim.ConfigPath); err == nil {
+			for _, km := range nvimConfig.Keymaps {
+				if strings.EqualFold(km.Lhs, lhs) || strings.EqualFold(km.Keys, lhs) {
+					resolved := fmt.Sprintf("[%s] %s -> %s", km.Mode, km.Keys, km.Rhs)
+					if km.Description != "" {
+						resolved += fmt.Sprintf(" (%s)", km.Description)
+					}
+					return resolved
+				}
+			}
+		}
+	}
+
+	if cfg.Tmux.ConfigPath != "" {
+		if tmuxConfig, err := parser.ParseTmuxConfig(cfg.Tmux.ConfigPath); err == nil {
+			for _, km := range tmuxConfig.Keymaps {
+				if km.Key == lhs {
+					return fmt.Sprintf("tmux %s %s -> %s", km.Table, km.Key, km.Command)
+				}
+			}
+		}
+	}
+
+	return ""
+}
+
+// genericPromptContext is the prompt context of privacy mode: cliq's own
+// answer settings, with nothing read from the user's configs, projects, or
+// pinned context
+func genericPromptContext(cfg *config.Config) *llm.PromptContext {
+	pctx := &llm.PromptContext{Style: cfg.General.ResponseStyle}
+	if keynotation.Valid(cfg.General.KeyNotation) {
+		pctx.KeyNotation = cfg.General.KeyNotation
+	}
+	if layout, ok := keyboard.Lookup(cfg.General.KeyboardLayout); ok && !layout.IsDefault() {
+		pctx.Layout = layout
+	}
+	return pctx
+}
+
+// newPromptContext gathers everything about the user's setup that goes into
+// a prompt alongside the parsed configs
+func newPromptContext(cfg *config.Config, nvimConfig *parser.NvimConfig, tmuxConfig *parser.TmuxConfig) *llm.PromptContext {
+	tmuxConfig = withLiveTmux(cfg, tmuxConfig)
+
+	pctx := &llm.PromptContext{
+		Nvim:  nvimConfig,
+		Tmux:  tmuxConfig,
+		Style: cfg.General.ResponseStyle,
+	}
+
+	if keynotation.Valid(cfg.General.KeyNotation) {
+		pctx.KeyNotation = cfg.General.KeyNotation
+	} else if verbose {
+		fmt.Fprintf(os.Stderr, "Warning: unknown key notation %q (supported: %s)\n",
+			cfg.General.KeyNotation, strings.Join(keynotation.Names, ", "))
+	}
+
+	if layout, ok := keyboard.Lookup(cfg.General.KeyboardLayout); ok {
+		if !layout.IsDefault() {
+			pctx.Layout = layout
+		}
+	} else if cfg.General.KeyboardLayout != "" && verbose {
+		fmt.Fprintf(os.Stderr, "Warning: unknown keyboard layout %q (supported: %s)\n",
+			cfg.General.KeyboardLayout, strings.Join(keyboard.Names(), ", "))
+	}
+
+	pctx.Shell = parseShellConfig(cfg)
+	pctx.Terminal = terminal.Detect(tmuxConfig)
+	pctx.Locale = locale.Detect()
+
+	pins, err := store.LoadPins()
+	if err != nil {
+		if verbose {
+			fmt.Fprintf(os.Stderr, "Warning: could not load pinned context: %v\n", err)
+		}
+	} else {
+		pctx.Pinned = pins.Lines()
+	}
+
+	var project []string
+	if proj := findProjectContext(); proj != nil && projectFileTrusted(proj) {
+		project = append(project, proj.Text)
+		if verbose {
+			fmt.Fprintf(os.Stderr, "Using project context from %s\n", proj.Path)
+		}
+	}
+	project = append(project, readContextFiles(cfg)...)
+	pctx.Project = strings.Join(project, "\n\n")
+	pctx.Tasks = findProjectTasks()
+
+	if wd, err := os.Getwd(); err == nil {
+		pctx.Toolchain = toolchain.Detect(wd)
+	}
+
+	return pctx
+}
@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+
+	"github.com/cliq-cli/cliq/internal/config"
+	"github.com/cliq-cli/cliq/internal/daemon"
+	"github.com/cliq-cli/cliq/internal/llm"
+	"github.com/cliq-cli/cliq/internal/parser"
+)
+
+// daemonCmd runs the config watcher in the foreground
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Keep your parsed configs and model in memory for faster queries",
+	Long: `Parse your Neovim and tmux configs once, then watch them and re-parse
+whichever one changes. Queries and interactive mode ask the daemon for the
+parsed configs over a unix socket in ~/.cache/cliq, so they skip parsing.
+A change the daemon hasn't re-parsed yet is parsed before it answers, so
+answers never use an old config.
+
+The daemon also loads the model of a local backend and keeps it loaded, as
+interactive mode does, and queries have it generate their answers, so they
+don't wait for the model to load: with llama-cli that's a warm llama-server
+in place of loading the model for every question, and with ollama the model
+is kept loaded. Each query still builds its prompt itself, from its own
+directory and settings. --no-model keeps only the configs, to spare the
+memory the model takes.
+
+The daemon runs in the foreground until interrupted; start it from your
+shell profile, a systemd user unit, or launchd. Without a daemon, cliq
+parses and loads the model as usual.
+
+Examples:
+  cliq daemon &
+  cliq daemon --no-model &
+  cliq daemon status
+  cliq daemon stop`,
+	Args: cobra.NoArgs,
+	RunE: runDaemon,
+}
+
+var daemonStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether the daemon is running and what it watches",
+	Args:  cobra.NoArgs,
+	RunE:  runDaemonStatus,
+}
+
+var daemonStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop the running daemon",
+	Args:  cobra.NoArgs,
+	RunE:  runDaemonStop,
+}
+
+func init() {
+	rootCmd.AddCommand(daemonCmd)
+	daemonCmd.AddCommand(daemonStatusCmd)
+	daemonCmd.AddCommand(daemonStopCmd)
+
+	daemonCmd.Flags().Bool("no-model", false, "keep only the parsed configs, not the model, in memory")
+}
+
+func runDaemon(cmd *cobra.Command, args []string) error {
+	noModel, _ := cmd.Flags().GetBool("no-model")
+	logger := log.New(os.Stderr, "cliq daemon: ", log.LstdFlags)
+	err := daemon.Run(cmd.Context(), logger.Printf, !noModel)
+	if errors.Is(err, daemon.ErrRunning) {
+		return fmt.Errorf("%w (cliq daemon stop to stop it)", err)
+	}
+	return err
+}
+
+func runDaemonStatus(cmd *cobra.Command, args []string) error {
+	status, err := daemon.GetStatus()
+	if errors.Is(err, daemon.ErrNotRunning) {
+		fmt.Println("The daemon isn't running. Start it with: cliq daemon &")
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("99"))
+	errStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	row := func(label, value string) {
+		fmt.Printf("%s %s\n", labelStyle.Render(fmt.Sprintf("%-10s", label+":")), value)
+	}
+	orNone := func(s string) string {
+		if s == "" {
+			return "(none)"
+		}
+		return s
+	}
+
+	row("PID", fmt.Sprint(status.Pid))
+	row("Running", "since "+status.Started.Format(time.DateTime))
+	row("Neovim", orNone(status.NvimPath))
+	row("tmux", orNone(status.TmuxPath))
+	row("Model", orNone(status.Model))
+	row("Watching", fmt.Sprintf("%d directories", status.Watching))
+	row("Parsed", fmt.Sprintf("%s (%d parses)", status.Parsed.Format(time.DateTime), status.Reparses))
+	if status.LastError != "" {
+		row("Error", errStyle.Render(status.LastError))
+	}
+	return nil
+}
+
+func runDaemonStop(cmd *cobra.Command, args []string) error {
+	if err := daemon.Stop(); errors.Is(err, daemon.ErrNotRunning) {
+		fmt.Println("The daemon isn't running.")
+		return nil
+	} else if err != nil {
+		return err
+	}
+	fmt.Println("Stopped the daemon.")
+	return nil
+}
+
+// daemonConfigs fetches the parsed configs from a running daemon. ok is
+// false when none is running, or it watches other config paths than cfg's.
+func daemonConfigs(cfg *config.Config) (nvim *parser.NvimConfig, tmux *parser.TmuxConfig, ok bool) {
+	nvim, tmux, err := daemon.Context()
+	if err != nil {
+		if verbose && !errors.Is(err, daemon.ErrNotRunning) {
+			fmt.Fprintf(os.Stderr, "Warning: could not get configs from the daemon: %v\n", err)
+		}
+		return nil, nil, false
+	}
+	if (nvim == nil) != (cfg.Nvim.ConfigPath == "") || (nvim != nil && nvim.ConfigPath != cfg.Nvim.ConfigPath) {
+		return nil, nil, false
+	}
+	if (tmux == nil) != (cfg.Tmux.ConfigPath == "") || (tmux != nil && tmux.ConfigPath != cfg.Tmux.ConfigPath) {
+		return nil, nil, false
+	}
+	return nvim, tmux, true
+}
+
+// daemonGenerate has a running daemon's loaded model answer the prompt. ok
+// is false when there's no daemon, it keeps no model loaded for cfg's, or
+// generating failed; the query is then run in process.
+func daemonGenerate(ctx context.Context, cfg *config.Config, prompt string) (*daemon.Generation, bool) {
+	// A plugin runs its own model, which the daemon can't keep loaded
+	if llm.PluginConfigured() {
+		return nil, false
+	}
+	gen, err := daemon.Generate(ctx, daemon.GenerateRequest{
+		Prompt:      prompt,
+		ModelPath:   cfg.GetModelPath(),
+		OllamaModel: cfg.Model.OllamaModel,
+		Temperature: cfg.Model.Temperature,
+		MaxTokens:   cfg.Model.MaxTokens,
+		Sampling:    samplingOptions(cfg),
+		Policy: &daemon.Policy{
+			Retries:      cfg.Model.Retries,
+			Fallback:     cfg.Model.Fallback,
+			AuditLog:     auditLogMode(cfg),
+			AuditExclude: cfg.History.Exclude,
+		},
+	})
+	if err != nil {
+		if verbose && !errors.Is(err, daemon.ErrNotRunning) && !errors.Is(err, daemon.ErrNotWarm) && ctx.Err() == nil {
+			fmt.Fprintf(os.Stderr, "Warning: the daemon could not answer: %v\n", err)
+		}
+		return nil, false
+	}
+	return gen, true
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cliq-cli/cliq/internal/config"
+	"github.com/cliq-cli/cliq/internal/debug"
+)
+
+var (
+	debugPprof bool
+	profiler   *debug.Profiler
+)
+
+// startDebugPprof enables profiling when --debug-pprof is set. Long-running
+// processes (interactive sessions, the daemon, and the managed server)
+// serve pprof on localhost; one-shot runs write profiles to disk.
+func startDebugPprof(cmd *cobra.Command, args []string) error {
+	if !debugPprof {
+		return nil
+	}
+
+	if longRunning(cmd) {
+		addr, err := debug.ServePprof(debug.DefaultPprofAddr)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "pprof: serving on http://%s/debug/pprof/\n", addr)
+		return nil
+	}
+
+	cacheDir, err := config.GetCacheDir()
+	if err != nil {
+		return err
+	}
+	dir := filepath.Join(cacheDir, "pprof", time.Now().Format("20060102-150405"))
+
+	profiler, err = debug.StartProfiling(dir)
+	return err
+}
+
+// longRunning reports whether cmd runs until it's stopped, rather than
+// answering and exiting
+func longRunning(cmd *cobra.Command) bool {
+	if interactive, _ := cmd.Flags().GetBool("interactive"); interactive {
+		return true
+	}
+	return cmd == daemonCmd || cmd == serverRunCmd
+}
+
+// stopDebugPprof finishes any profiles started for this run
+func stopDebugPprof() {
+	if profiler == nil {
+		return
+	}
+	if err := profiler.Stop(); err != nil {
+		fmt.Fprintf(os.Stderr, "pprof: %v\n", err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "pprof: profiles written to %s\n", profiler.Dir)
+	fmt.Fprintf(os.Stderr, "  go tool pprof %s\n", filepath.Join(profiler.Dir, "cpu.pprof"))
+	profiler = nil
+}
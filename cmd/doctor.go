@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+
+	"github.com/cliq-cli/cliq/internal/config"
+	"github.com/cliq-cli/cliq/internal/llm"
+	"github.com/cliq-cli/cliq/internal/lockfile"
+)
+
+var doctorFix bool
+
+// doctorCmd represents the doctor command
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check Cliq's installation for problems",
+	Long: `Check Cliq's configuration and state files for problems, such as
+locks, sockets, and partial downloads left behind by a crashed run.
+
+Pass --fix to repair the problems that can be fixed automatically.
+
+Examples:
+  cliq doctor
+  cliq doctor --fix`,
+	Args: cobra.NoArgs,
+	RunE: runDoctor,
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+
+	doctorCmd.Flags().BoolVar(&doctorFix, "fix", false, "repair problems that can be fixed automatically")
+}
+
+// doctorIssue is a problem found by a doctor check. Issues with a fix
+// function can be repaired by 'cliq doctor --fix'.
+type doctorIssue struct {
+	message string
+	fix     func() error
+}
+
+// doctorCheck is one named group of checks run by 'cliq doctor'
+type doctorCheck struct {
+	name string
+	run  func() []doctorIssue
+}
+
+// doctorChecks returns the checks 'cliq doctor' runs, in order
+func doctorChecks() []doctorCheck {
+	return []doctorCheck{
+		{name: "Configuration", run: checkConfigFile},
+		{name: "Hooks", run: checkHooks},
+		{name: "Stale files", run: checkStaleFiles},
+		{name: "File permissions", run: checkPermissions},
+		{name: "Backend health", run: checkBackendHealth},
+	}
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("42"))
+	successStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	warnStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+	errStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+
+	fmt.Println(titleStyle.Render("Cliq doctor"))
+	fmt.Println()
+
+	remaining, fixable := 0, 0
+	for _, check := range doctorChecks() {
+		issues := check.run()
+		if len(issues) == 0 {
+			fmt.Println(successStyle.Render("✓ " + check.name))
+			continue
+		}
+
+		fmt.Println(warnStyle.Render("! " + check.name))
+		for _, issue := range issues {
+			fmt.Printf("    %s\n", issue.message)
+			if issue.fix == nil {
+				remaining++
+				continue
+			}
+			if !doctorFix {
+				remaining++
+				fixable++
+				continue
+			}
+			if err := issue.fix(); err != nil {
+				fmt.Println(errStyle.Render(fmt.Sprintf("      ✗ could not fix: %v", err)))
+				remaining++
+			} else {
+				fmt.Println(successStyle.Render("      ✓ fixed"))
+			}
+		}
+	}
+
+	fmt.Println()
+	if remaining == 0 {
+		fmt.Println(successStyle.Render("No problems found"))
+		return nil
+	}
+	if fixable > 0 {
+		fmt.Printf("Run %s to repair %d of them.\n", titleStyle.Render("cliq doctor --fix"), fixable)
+	}
+	return fmt.Errorf("%d problem(s) found", remaining)
+}
+
+// checkBackendHealth reports backends skipped after repeated failures
+func checkBackendHealth() []doctorIssue {
+	var issues []doctorIssue
+	for _, h := range llm.Health() {
+		if h.Healthy() {
+			continue
+		}
+		issues = append(issues, doctorIssue{
+			message: fmt.Sprintf("%s failed %d of its last %d queries and is skipped until %s",
+				h.Backend, h.Errors, h.Queries, h.UnhealthyUntil.Format("15:04:05")),
+			fix: func() error {
+				return llm.ResetHealth(h.Backend)
+			},
+		})
+	}
+	return issues
+}
+
+// checkConfigFile reports a config file that can't be read or parsed
+func checkConfigFile() []doctorIssue {
+	if _, err := config.Load(); err != nil {
+		return []doctorIssue{{message: fmt.Sprintf("%s: %v", config.GetConfigPath(), err)}}
+	}
+	return nil
+}
+
+// checkHooks reports a hooks.lua that doesn't load
+func checkHooks() []doctorIssue {
+	if _, err := userHooks(); err != nil {
+		return []doctorIssue{{message: err.Error()}}
+	}
+	return nil
+}
+
+// stateDirs returns the directories cliq keeps its config and state in
+func stateDirs() []string {
+	var dirs []string
+	for _, get := range []func() (string, error){config.GetConfigDir, config.GetDataDir, config.GetCacheDir} {
+		if dir, err := get(); err == nil {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// checkPermissions reports config and state files that other users can read
+func checkPermissions() []doctorIssue {
+	loose, err := config.FindLoosePermissions(stateDirs()...)
+	if err != nil {
+		return []doctorIssue{{message: fmt.Sprintf("could not check permissions: %v", err)}}
+	}
+
+	var issues []doctorIssue
+	for _, p := range loose {
+		issues = append(issues, doctorIssue{
+			message: fmt.Sprintf("%s is %04o, should be %04o", p.Path, p.Mode, p.Want),
+			fix: func() error {
+				return os.Chmod(p.Path, p.Want)
+			},
+		})
+	}
+	return issues
+}
+
+// ownLocks name the locks and pidfiles cliq itself keeps: one beside each
+// model download, and the managed llama-server's pidfile
+var ownLocks = []string{"*.gguf.lock", "llama-server.pid"}
+
+// checkStaleFiles reports locks, sockets, and partial downloads left behind
+// by crashed runs. A partial download under an hour old is left alone, as
+// another run may still be writing it.
+func checkStaleFiles() []doctorIssue {
+	stale, err := lockfile.FindStale(ownLocks, stateDirs()...)
+	if err != nil {
+		return []doctorIssue{{message: fmt.Sprintf("could not scan for stale files: %v", err)}}
+	}
+
+	var issues []doctorIssue
+	for _, s := range stale {
+		issues = append(issues, doctorIssue{
+			message: fmt.Sprintf("%s (%s)", s.Path, s.Reason),
+			fix: func() error {
+				return lockfile.Clean([]lockfile.Stale{s})
+			},
+		})
+	}
+	return issues
+}
@@ -0,0 +1,285 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/cliq-cli/cliq/internal/config"
+	"github.com/cliq-cli/cliq/internal/llm"
+	"github.com/cliq-cli/cliq/internal/store"
+	"github.com/cliq-cli/cliq/internal/vault"
+)
+
+// saltFile holds the salt the passphrase is stretched with, in the data dir
+const saltFile = "vault.salt"
+
+var encryptKeychain bool
+
+// encryptCmd represents the encrypt command
+var encryptCmd = &cobra.Command{
+	Use:   "encrypt",
+	Short: "Encrypt history and personal stores at rest",
+	Long: `Encrypt the interactive session, input history, learned answers, and
+pinned context with XChaCha20-Poly1305.
+
+The key comes from a passphrase (asked for when needed, or read from
+$CLIQ_PASSPHRASE) or from a random key kept in the OS keychain (macOS
+Keychain, or the Secret Service via secret-tool on Linux).
+
+Subcommands:
+  enable   Turn on encryption and encrypt existing stores
+  disable  Turn off encryption and decrypt the stores
+  status   Show whether each store is encrypted
+
+Examples:
+  cliq encrypt enable
+  cliq encrypt enable --keychain
+  cliq encrypt status`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// encryptEnableCmd represents the encrypt enable command
+var encryptEnableCmd = &cobra.Command{
+	Use:   "enable",
+	Short: "Turn on encryption and encrypt existing stores",
+	Args:  cobra.NoArgs,
+	RunE:  runEncryptEnable,
+}
+
+// encryptDisableCmd represents the encrypt disable command
+var encryptDisableCmd = &cobra.Command{
+	Use:   "disable",
+	Short: "Turn off encryption and decrypt the stores",
+	Args:  cobra.NoArgs,
+	RunE:  runEncryptDisable,
+}
+
+// encryptStatusCmd represents the encrypt status command
+var encryptStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether each store is encrypted",
+	Args:  cobra.NoArgs,
+	RunE:  runEncryptStatus,
+}
+
+func init() {
+	rootCmd.AddCommand(encryptCmd)
+	encryptCmd.AddCommand(encryptEnableCmd)
+	encryptCmd.AddCommand(encryptDisableCmd)
+	encryptCmd.AddCommand(encryptStatusCmd)
+
+	encryptEnableCmd.Flags().BoolVar(&encryptKeychain, "keychain", false, "keep a random key in the OS keychain instead of using a passphrase")
+}
+
+// setupStoreKey tells the stores where their key comes from, per the config
+func setupStoreKey(cfg *config.Config) {
+	switch cfg.Encryption.Mode {
+	case "keychain":
+		store.UseKey(vault.KeychainGet, true)
+	case "passphrase":
+		store.UseKey(passphraseKey, true)
+	}
+}
+
+// encrypting reports whether the personal stores are encrypted
+func encrypting(cfg *config.Config) bool {
+	return cfg.Encryption.Mode != "" && cfg.Encryption.Mode != "off"
+}
+
+// auditLogMode is history.audit_log, which stays off while encryption is
+// on: the audit log is plain JSON lines, for reading with other tools, and
+// would keep every prompt in the clear that encryption is meant to hide
+func auditLogMode(cfg *config.Config) string {
+	if encrypting(cfg) {
+		return "off"
+	}
+	return cfg.History.AuditLog
+}
+
+// passphraseKey derives the store key from the user's passphrase
+func passphraseKey() ([]byte, error) {
+	salt, err := os.ReadFile(saltPath())
+	if err != nil {
+		return nil, fmt.Errorf("could not read the passphrase salt: %w", err)
+	}
+	pass, err := readPassphrase("Passphrase for cliq history: ")
+	if err != nil {
+		return nil, err
+	}
+	return vault.DeriveKey(pass, salt)
+}
+
+// readPassphrase reads a passphrase from $CLIQ_PASSPHRASE or, failing that,
+// asks for it on the terminal
+func readPassphrase(prompt string) (string, error) {
+	if pass := os.Getenv("CLIQ_PASSPHRASE"); pass != "" {
+		return pass, nil
+	}
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", errors.New("a passphrase is needed: set CLIQ_PASSPHRASE or run from a terminal")
+	}
+
+	fmt.Fprint(os.Stderr, prompt)
+	pass, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	if len(pass) == 0 {
+		return "", errors.New("empty passphrase")
+	}
+	return string(pass), nil
+}
+
+// saltPath returns where the passphrase salt is kept
+func saltPath() string {
+	dataDir, _ := config.GetDataDir()
+	return filepath.Join(dataDir, saltFile)
+}
+
+func runEncryptEnable(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if encrypting(cfg) {
+		return fmt.Errorf("encryption is already on (%s); run 'cliq encrypt disable' first to change how it's keyed", cfg.Encryption.Mode)
+	}
+
+	var key []byte
+	if encryptKeychain {
+		if key, err = vault.NewKey(); err != nil {
+			return err
+		}
+		if err := vault.KeychainSet(key); err != nil {
+			return err
+		}
+		cfg.Encryption.Mode = "keychain"
+	} else {
+		pass, err := readPassphrase("New passphrase: ")
+		if err != nil {
+			return err
+		}
+		if os.Getenv("CLIQ_PASSPHRASE") == "" {
+			again, err := readPassphrase("Repeat passphrase: ")
+			if err != nil {
+				return err
+			}
+			if again != pass {
+				return errors.New("passphrases don't match")
+			}
+		}
+
+		salt, err := vault.NewSalt()
+		if err != nil {
+			return err
+		}
+		if err := config.WriteFile(saltPath(), salt); err != nil {
+			return fmt.Errorf("failed to save the passphrase salt: %w", err)
+		}
+		if key, err = vault.DeriveKey(pass, salt); err != nil {
+			return err
+		}
+		cfg.Encryption.Mode = "passphrase"
+	}
+
+	store.UseKey(func() ([]byte, error) { return key, nil }, true)
+	if err := store.Reseal(); err != nil {
+		return fmt.Errorf("failed to encrypt stores: %w", err)
+	}
+	if err := cfg.Save(); err != nil {
+		return fmt.Errorf("failed to save configuration: %w", err)
+	}
+
+	successStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	fmt.Println(successStyle.Render("✓ History and personal stores are encrypted (" + cfg.Encryption.Mode + ")"))
+	if cfg.Encryption.Mode == "passphrase" {
+		fmt.Println("There is no way to recover the stores if you forget the passphrase.")
+	}
+	if cfg.History.AuditLog != "" && cfg.History.AuditLog != "off" {
+		fmt.Println("The audit log can't be encrypted, so history.audit_log is ignored while encryption is on.")
+	}
+	if path, err := llm.AuditLogPath(); err == nil {
+		if _, err := os.Stat(path); err == nil {
+			fmt.Printf("%s still holds earlier prompts in plain text; delete it if they're private.\n", path)
+		}
+	}
+	return nil
+}
+
+func runEncryptDisable(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	mode := cfg.Encryption.Mode
+	if mode == "" || mode == "off" {
+		return errors.New("encryption is not on")
+	}
+
+	// Decrypt with the configured key, then write everything back in plain
+	getKey := passphraseKey
+	if mode == "keychain" {
+		getKey = vault.KeychainGet
+	}
+	key, err := getKey()
+	if err != nil {
+		return err
+	}
+	store.UseKey(func() ([]byte, error) { return key, nil }, false)
+	if err := store.Reseal(); err != nil {
+		return fmt.Errorf("failed to decrypt stores: %w", err)
+	}
+
+	cfg.Encryption.Mode = "off"
+	if err := cfg.Save(); err != nil {
+		return fmt.Errorf("failed to save configuration: %w", err)
+	}
+	if mode == "keychain" {
+		vault.KeychainDelete()
+	} else {
+		os.Remove(saltPath())
+	}
+
+	fmt.Println("Encryption is off; the stores are decrypted.")
+	return nil
+}
+
+func runEncryptStatus(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = config.Default()
+	}
+	mode := cfg.Encryption.Mode
+	if mode == "" {
+		mode = "off"
+	}
+
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("99"))
+	fmt.Println(labelStyle.Render("Encryption: ") + mode)
+
+	files, err := store.PersonalFiles()
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		state := "not created"
+		if f.Exists {
+			state = "plain"
+			if f.Sealed {
+				state = "encrypted"
+			}
+		}
+		fmt.Printf("  %-16s %s\n", f.Name, state)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/cliq-cli/cliq/internal/config"
+	"github.com/cliq-cli/cliq/internal/llm"
+	"github.com/cliq-cli/cliq/internal/response"
+	"github.com/cliq-cli/cliq/internal/store"
+)
+
+// continueLast is set by --continue: follow up on the last question
+var continueLast bool
+
+// followUp is the question and answer a one-shot question follows up on,
+// nil without --continue
+var followUp *llm.Exchange
+
+// loadFollowUp returns the last question asked, one-shot or in interactive
+// mode, with its answer
+func loadFollowUp() (*llm.Exchange, error) {
+	last, err := store.LastExchange()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load history: %w", err)
+	}
+	if last == nil || last.Answer == nil {
+		return nil, fmt.Errorf("no earlier question to follow up on")
+	}
+	if verbose {
+		fmt.Fprintln(os.Stderr, "Following up on:", last.Query)
+	}
+	return &llm.Exchange{Query: last.Query, Command: last.Answer.Command, Explanation: last.Answer.Explanation}, nil
+}
+
+// recordQuery keeps a one-shot question and its answer, the most voted for
+// with --candidates, for --continue to follow up on. Incognito runs and
+// questions history excludes aren't kept.
+func recordQuery(cfg *config.Config, query string, resp *response.Response) {
+	if incognito {
+		return
+	}
+	queries, err := store.LoadQueries()
+	if err != nil {
+		if verbose {
+			fmt.Fprintf(os.Stderr, "Warning: could not load history: %v\n", err)
+		}
+		return
+	}
+	queries.Add(query, resp, historyRetention(cfg))
+	if err := queries.Save(); err != nil && verbose {
+		fmt.Fprintf(os.Stderr, "Warning: could not save history: %v\n", err)
+	}
+}
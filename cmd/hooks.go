@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/spf13/viper"
+
+	"github.com/cliq-cli/cliq/internal/config"
+	"github.com/cliq-cli/cliq/internal/hooks"
+	"github.com/cliq-cli/cliq/internal/response"
+	"github.com/cliq-cli/cliq/internal/shelltype"
+)
+
+// loadedHooks is the user's hooks.lua, loaded once a run
+var loadedHooks struct {
+	once  sync.Once
+	hooks *hooks.Hooks
+}
+
+// hooksPath is where the user's hooks.lua is
+func hooksPath() (string, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, hooks.File), nil
+}
+
+// userHooks returns the user's hooks, or nil without a hooks.lua, with
+// --no-hooks, or when it couldn't be loaded. Why it couldn't is returned
+// by the call that tried, so it's pointed out once a run.
+func userHooks() (*hooks.Hooks, error) {
+	if viper.GetBool("no-hooks") {
+		return nil, nil
+	}
+	var err error
+	loadedHooks.once.Do(func() {
+		var path string
+		if path, err = hooksPath(); err == nil {
+			loadedHooks.hooks, err = hooks.Load(path)
+		}
+	})
+	return loadedHooks.hooks, err
+}
+
+// hookPrompt passes the prompt for a query through on_prompt. When the
+// hooks fail, the prompt is sent as it was.
+func hookPrompt(query, prompt string) (string, error) {
+	h, err := userHooks()
+	if h == nil {
+		return prompt, err
+	}
+	dir, _ := os.Getwd()
+	return h.OnPrompt(hooks.Prompt{Query: query, Prompt: prompt, Dir: dir, Shell: shelltype.Detect()})
+}
+
+// hookResponse passes the answer through on_response. When the hooks
+// fail, the answer is left as it was.
+func hookResponse(resp *response.Response) error {
+	h, err := userHooks()
+	if h == nil {
+		return err
+	}
+	return h.OnResponse(resp)
+}
+
+// warnHook points out a hook that failed, which a query goes on without
+func warnHook(err error) {
+	if err != nil && !inView {
+		queryProgress.Pause()
+		fmt.Fprintf(os.Stderr, "Warning: hooks: %v\n", err)
+	}
+}
+
+// hooksSource is the text of the hooks in effect, "" for none
+func hooksSource() string {
+	if h, _ := userHooks(); h != nil {
+		return h.Source
+	}
+	return ""
+}
@@ -1,26 +1,44 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/spf13/cobra"
 
 	"github.com/cliq-cli/cliq/internal/config"
 	"github.com/cliq-cli/cliq/internal/llm"
+	"github.com/cliq-cli/cliq/internal/terminal"
 )
 
 var (
 	modelURL     string
+	modelSHA256  string
 	skipConfig   bool
 	forceInit    bool
 	useOllama    bool
 	downloadGGUF bool
+	initBackend  string
+	initYes      bool
+	initFormat   string
+	initNoVerify bool
 )
 
+// ErrNoBackend is returned by Execute when init found no backend it could
+// set up
+var ErrNoBackend = errors.New("no LLM backend available")
+
 // initCmd represents the init command
 var initCmd = &cobra.Command{
 	Use:   "init",
@@ -40,7 +58,21 @@ Cliq supports multiple LLM backends:
 3. Direct GGUF download - Downloads Phi-3 model (~2.3GB)
    Run: cliq init --download
 
-This command will also detect your Neovim and tmux configurations.`,
+This command will also detect your Neovim and tmux configurations, and
+finally asks the backend a test question, so a setup that can't answer
+shows up now rather than on your first real question. --no-verify skips it.
+
+An existing config is only replaced once you confirm it. For provisioning
+scripts and dotfile installers, --yes skips that and --format json prints a
+summary of what was configured and what failed instead of the progress.
+init exits 0 once the config is saved, 4 when no backend could be set up,
+and 1 on any other failure, including a backend that doesn't answer the
+test question.
+
+Examples:
+  cliq init
+  cliq init --ollama
+  cliq init --yes --backend ollama --format json`,
 	RunE: runInit,
 }
 
@@ -50,224 +82,469 @@ func init() {
 	initCmd.Flags().BoolVar(&useOllama, "ollama", false, "set up with Ollama (recommended)")
 	initCmd.Flags().BoolVar(&downloadGGUF, "download", false, "download GGUF model directly")
 	initCmd.Flags().StringVar(&modelURL, "model-url", "", "custom model URL for --download")
+	initCmd.Flags().StringVar(&modelSHA256, "sha256", "", "SHA-256 the --download model must match")
 	initCmd.Flags().BoolVar(&skipConfig, "skip-config", false, "skip config detection")
 	initCmd.Flags().BoolVar(&forceInit, "force", false, "re-download model even if exists")
+	initCmd.Flags().StringVar(&initBackend, "backend", "auto", "backend to set up (auto|ollama|llama-server|llama-cli)")
+	initCmd.Flags().BoolVarP(&initYes, "yes", "y", false, "don't ask before replacing an existing config")
+	initCmd.Flags().StringVar(&initFormat, "format", "text", "output format (text|json)")
+	initCmd.Flags().BoolVar(&initNoVerify, "no-verify", false, "don't ask the backend a test question")
+}
+
+// initSummary is what init configured and what failed, for --format json
+type initSummary struct {
+	OK    bool `json:"ok"`
+	Saved bool `json:"saved"`
+	// Verified is set once the backend answered the test question
+	Verified   bool   `json:"verified"`
+	ConfigFile string `json:"config_file"`
+	Backend    string `json:"backend,omitempty"`
+	// Model is the ollama model pulled or the GGUF file llama-cli runs
+	Model      string `json:"model,omitempty"`
+	GPU        string `json:"gpu,omitempty"`
+	NvimConfig string `json:"nvim_config,omitempty"`
+	TmuxConfig string `json:"tmux_config,omitempty"`
+	// Failed are the steps that failed without stopping init
+	Failed []string `json:"failed,omitempty"`
+	Error  string   `json:"error,omitempty"`
 }
 
 func runInit(cmd *cobra.Command, args []string) error {
+	backend, err := initBackendChoice()
+	if err != nil {
+		return err
+	}
+	if initFormat != "text" && initFormat != "json" {
+		return fmt.Errorf("unknown format %q (want text or json)", initFormat)
+	}
+
+	summary := &initSummary{ConfigFile: config.GetConfigPath()}
+	if initFormat == "text" {
+		return setUpCliq(cmd.Context(), os.Stdout, os.Stdout, backend, summary)
+	}
+
+	// The summary is the only thing on stdout; ollama's pull progress goes
+	// to stderr with the download's
+	err = setUpCliq(cmd.Context(), io.Discard, os.Stderr, backend, summary)
+	summary.OK = err == nil
+	if err != nil {
+		summary.Error = err.Error()
+	}
+	data, jsonErr := json.MarshalIndent(summary, "", "  ")
+	if jsonErr != nil {
+		return jsonErr
+	}
+	fmt.Println(string(data))
+	return err
+}
+
+// initBackendChoice returns the backend asked for with --backend, --ollama,
+// or --download, which is "auto" when none was
+func initBackendChoice() (string, error) {
+	backend := initBackend
+	if !slices.Contains([]string{"auto", "ollama", "llama-server", "llama-cli"}, backend) {
+		return "", fmt.Errorf("unknown backend %q (want auto, ollama, llama-server, or llama-cli)", backend)
+	}
+	for _, implied := range []struct {
+		set     bool
+		flag    string
+		backend string
+	}{
+		{useOllama, "--ollama", "ollama"},
+		{downloadGGUF, "--download", "llama-cli"},
+	} {
+		if !implied.set {
+			continue
+		}
+		if backend != "auto" && backend != implied.backend {
+			return "", fmt.Errorf("%s sets up %s, not %s", implied.flag, implied.backend, backend)
+		}
+		backend = implied.backend
+	}
+	return backend, nil
+}
+
+// confirmReplace asks before init replaces an existing config with a fresh
+// one. It doesn't ask with --yes or without a terminal to ask on.
+func confirmReplace(path string) bool {
+	if _, err := os.Stat(path); err != nil || initYes || !terminal.IsTerminal(os.Stdin) {
+		return true
+	}
+	fmt.Fprintf(os.Stderr, "%s already exists and will be replaced. Continue? [y/N] ", path)
+	var answer string
+	fmt.Scanln(&answer)
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// setUpCliq does the work of init, printing its progress to out and the
+// output of ollama pull to pullOut, and recording it in summary
+func setUpCliq(ctx context.Context, out, pullOut io.Writer, backend string, summary *initSummary) error {
 	// Styles for output
 	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("42"))
 	successStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
 	infoStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("99"))
 	warnStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
 	cmdStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("39"))
+	failed := func(format string, args ...any) {
+		summary.Failed = append(summary.Failed, fmt.Sprintf(format, args...))
+	}
 
-	fmt.Println(titleStyle.Render("\n🚀 Initializing Cliq...\n"))
+	if !confirmReplace(summary.ConfigFile) {
+		fmt.Fprintln(out, "Not changed.")
+		return nil
+	}
+
+	fmt.Fprintln(out, titleStyle.Render("\n🚀 Initializing Cliq...\n"))
 
 	// Step 1: Create directories
-	fmt.Println(infoStyle.Render("Creating directories..."))
+	fmt.Fprintln(out, infoStyle.Render("Creating directories..."))
 	if err := createDirectories(); err != nil {
 		return fmt.Errorf("failed to create directories: %w", err)
 	}
-	fmt.Println(successStyle.Render("  ✓ Directories created"))
+	fmt.Fprintln(out, successStyle.Render("  ✓ Directories created"))
 
 	cfg := config.Default()
 
+	// Backends and configs are probed together up front; on slow systems
+	// this is most of init's time
+	probe := probeSystem(ctx, !skipConfig)
+
 	// Step 2: Set up LLM backend
-	fmt.Println(infoStyle.Render("\nSetting up LLM backend..."))
-
-	if useOllama {
-		// Check if ollama is installed
-		if _, err := exec.LookPath("ollama"); err != nil {
-			fmt.Println(warnStyle.Render("  ! Ollama not found"))
-			fmt.Println()
-			fmt.Println("Install Ollama from: https://ollama.ai")
-			fmt.Println("Then run: " + cmdStyle.Render("cliq init --ollama"))
-			return fmt.Errorf("ollama not installed")
-		}
+	fmt.Fprintln(out, infoStyle.Render("\nSetting up LLM backend..."))
 
-		fmt.Println(successStyle.Render("  ✓ Ollama detected"))
+	// A backend asked for by name has to be set up; one found by probing
+	// is set up as far as it can be
+	chosen := backend != "auto"
+	if !chosen {
+		backend = probe.backend
+	}
+	switch backend {
+	case "ollama":
+		if chosen {
+			// Check if ollama is installed
+			if _, err := exec.LookPath("ollama"); err != nil {
+				fmt.Fprintln(out, warnStyle.Render("  ! Ollama not found"))
+				fmt.Fprintln(out)
+				fmt.Fprintln(out, "Install Ollama from: https://ollama.ai")
+				fmt.Fprintln(out, "Then run: "+cmdStyle.Render("cliq init --ollama"))
+				return fmt.Errorf("%w: ollama not installed", ErrNoBackend)
+			}
+			fmt.Fprintln(out, successStyle.Render("  ✓ Ollama detected"))
+		} else {
+			fmt.Fprintln(out, successStyle.Render("  ✓ Ollama detected and running"))
+		}
 
 		// Pull phi3 model
-		fmt.Println(infoStyle.Render("  Pulling phi3 model (this may take a while)..."))
-		pullCmd := exec.Command("ollama", "pull", "phi3")
-		pullCmd.Stdout = os.Stdout
-		pullCmd.Stderr = os.Stderr
-		if err := pullCmd.Run(); err != nil {
-			return fmt.Errorf("failed to pull phi3 model: %w", err)
+		if !probe.hasOllamaModel("phi3") {
+			fmt.Fprintln(out, infoStyle.Render("  Pulling phi3 model (this may take a while)..."))
+			pullCmd := exec.CommandContext(ctx, "ollama", "pull", "phi3")
+			pullCmd.Stdout = pullOut
+			pullCmd.Stderr = os.Stderr
+			if err := pullCmd.Run(); err != nil {
+				if chosen {
+					return fmt.Errorf("failed to pull phi3 model: %w", err)
+				}
+				fmt.Fprintln(out, warnStyle.Render("  ! Failed to pull phi3, you may need to pull it manually"))
+				failed("pull phi3: %v", err)
+			} else {
+				fmt.Fprintln(out, successStyle.Render("  ✓ phi3 model ready"))
+				summary.Model = "phi3"
+			}
+		} else {
+			fmt.Fprintln(out, successStyle.Render("  ✓ phi3 model available"))
+			summary.Model = "phi3"
 		}
-		fmt.Println(successStyle.Render("  ✓ phi3 model ready"))
-
 		cfg.Model.Backend = "ollama"
+		cfg.Model.OllamaModel = "phi3"
 
-	} else if downloadGGUF {
-		// Download GGUF model directly
-		modelPath := cfg.GetModelPath()
-
-		if _, err := os.Stat(modelPath); os.IsNotExist(err) || forceInit {
-			fmt.Println(infoStyle.Render("  Downloading model (~2.3GB, this may take a while)..."))
-
-			url := modelURL
-			if url == "" {
-				url = llm.DefaultModelURL
-			}
-
-			if err := llm.DownloadModel(url, modelPath); err != nil {
-				return fmt.Errorf("failed to download model: %w", err)
-			}
-			fmt.Println(successStyle.Render("  ✓ Model downloaded"))
-		} else {
-			fmt.Println(successStyle.Render("  ✓ Model already exists"))
+	case "llama-server":
+		if !probe.has("llama-server") {
+			fmt.Fprintln(out, warnStyle.Render("  ! llama-server isn't running"))
+			fmt.Fprintln(out, "Run "+cmdStyle.Render("llama-server -m model.gguf --port 8080")+" and try again.")
+			return fmt.Errorf("%w: llama-server isn't running", ErrNoBackend)
 		}
+		fmt.Fprintln(out, successStyle.Render("  ✓ llama-server detected and running"))
+		cfg.Model.Backend = "llama-server"
 
-		cfg.Model.Backend = "llama-cli"
+	case "llama-cli":
+		modelPath := cfg.GetModelPath()
+		if downloadGGUF {
+			// Download GGUF model directly
+			if _, err := os.Stat(modelPath); os.IsNotExist(err) || forceInit {
+				fmt.Fprintln(out, infoStyle.Render("  Downloading model (~2.3GB, this may take a while)..."))
+
+				url := modelURL
+				if url == "" {
+					url = llm.DefaultModelURL
+				}
 
-	} else {
-		// Auto-detect available backend
-		backend := detectAvailableBackend()
-
-		switch backend {
-		case "ollama":
-			fmt.Println(successStyle.Render("  ✓ Ollama detected and running"))
-			// Check if phi3 is available
-			if !checkOllamaModel("phi3") {
-				fmt.Println(infoStyle.Render("  Pulling phi3 model..."))
-				pullCmd := exec.Command("ollama", "pull", "phi3")
-				pullCmd.Stdout = os.Stdout
-				pullCmd.Stderr = os.Stderr
-				if err := pullCmd.Run(); err != nil {
-					fmt.Println(warnStyle.Render("  ! Failed to pull phi3, you may need to pull it manually"))
-				} else {
-					fmt.Println(successStyle.Render("  ✓ phi3 model ready"))
+				if err := llm.DownloadModel(ctx, url, modelPath, modelSHA256); err != nil {
+					return fmt.Errorf("failed to download model: %w", err)
 				}
+				fmt.Fprintln(out, successStyle.Render("  ✓ Model downloaded"))
 			} else {
-				fmt.Println(successStyle.Render("  ✓ phi3 model available"))
+				fmt.Fprintln(out, successStyle.Render("  ✓ Model already exists"))
 			}
-			cfg.Model.Backend = "ollama"
-
-		case "llama-server":
-			fmt.Println(successStyle.Render("  ✓ llama-server detected and running"))
-			cfg.Model.Backend = "llama-server"
-
-		case "llama-cli":
-			fmt.Println(successStyle.Render("  ✓ llama-cli detected"))
-			modelPath := cfg.GetModelPath()
+		} else {
+			if !probe.has("llama-cli") {
+				fmt.Fprintln(out, warnStyle.Render("  ! llama-cli not found"))
+				return fmt.Errorf("%w: llama-cli not installed", ErrNoBackend)
+			}
+			fmt.Fprintln(out, successStyle.Render("  ✓ llama-cli detected"))
 			if _, err := os.Stat(modelPath); os.IsNotExist(err) {
-				fmt.Println(warnStyle.Render("  ! Model file not found"))
-				fmt.Println()
-				fmt.Println("You have llama-cli but no model. Options:")
-				fmt.Println("  1. " + cmdStyle.Render("cliq init --download") + " to download Phi-3")
-				fmt.Println("  2. " + cmdStyle.Render("cliq init --ollama") + " to use Ollama instead (recommended)")
-				return fmt.Errorf("model not found")
+				fmt.Fprintln(out, warnStyle.Render("  ! Model file not found"))
+				fmt.Fprintln(out)
+				fmt.Fprintln(out, "You have llama-cli but no model. Options:")
+				fmt.Fprintln(out, "  1. "+cmdStyle.Render("cliq init --download")+" to download Phi-3")
+				fmt.Fprintln(out, "  2. "+cmdStyle.Render("cliq init --ollama")+" to use Ollama instead (recommended)")
+				return fmt.Errorf("%w: model not found", ErrNoBackend)
 			}
-			cfg.Model.Backend = "llama-cli"
-
-		default:
-			fmt.Println(warnStyle.Render("  ! No LLM backend detected"))
-			fmt.Println()
-			fmt.Println("Please install an LLM backend:")
-			fmt.Println()
-			fmt.Println("Option 1 - Ollama (recommended, easiest):")
-			fmt.Println("  1. Install from " + cmdStyle.Render("https://ollama.ai"))
-			fmt.Println("  2. Run " + cmdStyle.Render("ollama serve") + " (or it auto-starts)")
-			fmt.Println("  3. Run " + cmdStyle.Render("cliq init --ollama"))
-			fmt.Println()
-			fmt.Println("Option 2 - llama.cpp server:")
-			fmt.Println("  1. Build llama.cpp from https://github.com/ggerganov/llama.cpp")
-			fmt.Println("  2. Run " + cmdStyle.Render("llama-server -m model.gguf --port 8080"))
-			fmt.Println("  3. Run " + cmdStyle.Render("cliq init"))
-			fmt.Println()
-			fmt.Println("Option 3 - Download model directly:")
-			fmt.Println("  Run " + cmdStyle.Render("cliq init --download"))
-			return fmt.Errorf("no LLM backend available")
 		}
+		cfg.Model.Backend = "llama-cli"
+		summary.Model = modelPath
+
+	default:
+		fmt.Fprintln(out, warnStyle.Render("  ! No LLM backend detected"))
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Please install an LLM backend:")
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Option 1 - Ollama (recommended, easiest):")
+		fmt.Fprintln(out, "  1. Install from "+cmdStyle.Render("https://ollama.ai"))
+		fmt.Fprintln(out, "  2. Run "+cmdStyle.Render("ollama serve")+" (or it auto-starts)")
+		fmt.Fprintln(out, "  3. Run "+cmdStyle.Render("cliq init --ollama"))
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Option 2 - llama.cpp server:")
+		fmt.Fprintln(out, "  1. Build llama.cpp from https://github.com/ggerganov/llama.cpp")
+		fmt.Fprintln(out, "  2. Run "+cmdStyle.Render("llama-server -m model.gguf --port 8080"))
+		fmt.Fprintln(out, "  3. Run "+cmdStyle.Render("cliq init"))
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Option 3 - Download model directly:")
+		fmt.Fprintln(out, "  Run "+cmdStyle.Render("cliq init --download"))
+		return ErrNoBackend
+	}
+	summary.Backend = cfg.Model.Backend
+
+	// llama-cli and llama-server are tuned for the GPU found here
+	if err := llm.SaveGPU(probe.gpu); err != nil {
+		failed("save GPU: %v", err)
+		if verbose {
+			fmt.Fprintf(os.Stderr, "Warning: could not save the GPU: %v\n", err)
+		}
+	}
+	summary.GPU = probe.gpu.String()
+	fmt.Fprintln(out, successStyle.Render("  ✓ GPU: "+probe.gpu.String()))
+	if strings.HasPrefix(cfg.Model.Backend, "llama") {
+		in := llm.InferenceFor(cfg.GetModelPath())
+		fmt.Fprintln(out, infoStyle.Render(fmt.Sprintf("    llama.cpp runs with %d GPU layers, %d threads, batch size %d", in.GPULayers, in.Threads, in.BatchSize)))
 	}
 
 	// Step 3: Detect configurations
 	if !skipConfig {
-		fmt.Println(infoStyle.Render("\nDetecting configurations..."))
+		fmt.Fprintln(out, infoStyle.Render("\nDetecting configurations..."))
 
-		// Detect Neovim config
-		nvimPath, err := config.DetectNvimConfig()
-		if err == nil {
-			fmt.Printf("  ✓ Found Neovim config: %s\n", nvimPath)
-			cfg.Nvim.ConfigPath = nvimPath
+		// Neovim config
+		if probe.nvimErr == nil {
+			fmt.Fprintf(out, "  ✓ Found Neovim config: %s\n", probe.nvimPath)
+			cfg.Nvim.ConfigPath = probe.nvimPath
+			summary.NvimConfig = probe.nvimPath
 		} else {
-			fmt.Println(warnStyle.Render("  ! Neovim config not found"))
+			fmt.Fprintln(out, warnStyle.Render("  ! Neovim config not found"))
+			failed("detect Neovim config: %v", probe.nvimErr)
 		}
 
-		// Detect tmux config
-		tmuxPath, err := config.DetectTmuxConfig()
-		if err == nil {
-			fmt.Printf("  ✓ Found tmux config: %s\n", tmuxPath)
-			cfg.Tmux.ConfigPath = tmuxPath
+		// tmux config
+		if probe.tmuxErr == nil {
+			fmt.Fprintf(out, "  ✓ Found tmux config: %s\n", probe.tmuxPath)
+			cfg.Tmux.ConfigPath = probe.tmuxPath
+			summary.TmuxConfig = probe.tmuxPath
+		} else if errors.Is(probe.tmuxErr, config.ErrNoTmux) {
+			fmt.Fprintf(out, "  - Skipping tmux: %v\n", probe.tmuxErr)
 		} else {
-			fmt.Println(warnStyle.Render("  ! tmux config not found"))
+			fmt.Fprintln(out, warnStyle.Render("  ! tmux config not found"))
+			failed("detect tmux config: %v", probe.tmuxErr)
 		}
 	}
 
 	// Step 4: Save configuration
-	fmt.Println(infoStyle.Render("\nSaving configuration..."))
+	fmt.Fprintln(out, infoStyle.Render("\nSaving configuration..."))
 	if err := cfg.Save(); err != nil {
 		return fmt.Errorf("failed to save configuration: %w", err)
 	}
-	fmt.Println(successStyle.Render("  ✓ Configuration saved"))
+	summary.Saved = true
+	fmt.Fprintln(out, successStyle.Render("  ✓ Configuration saved"))
+
+	// Step 5: Ask the backend a test question
+	if !initNoVerify {
+		fmt.Fprintln(out, infoStyle.Render("\nVerifying the backend..."))
+		start := time.Now()
+		if err := verifyBackend(ctx, cfg); err != nil {
+			reason, _, _ := strings.Cut(err.Error(), "\n")
+			fmt.Fprintln(out, warnStyle.Render(fmt.Sprintf("  ! %s didn't answer: %s", cfg.Model.Backend, reason)))
+			fmt.Fprintln(out, "Run "+cmdStyle.Render("cliq doctor")+" to find out why, then "+cmdStyle.Render("cliq init")+" again.")
+			return fmt.Errorf("%s didn't answer a test question: %w", cfg.Model.Backend, err)
+		}
+		summary.Verified = true
+		fmt.Fprintln(out, successStyle.Render(fmt.Sprintf("  ✓ %s answered in %s", cfg.Model.Backend, formatPhaseDuration(time.Since(start)))))
+	}
 
 	// Done
-	fmt.Println(titleStyle.Render("\n✨ Cliq is ready to use!\n"))
-	fmt.Println("Try running:")
-	fmt.Println(cmdStyle.Render("  cliq \"how do I delete a line in vim\""))
-	fmt.Println(cmdStyle.Render("  cliq \"jump to the 6th f character\""))
-	fmt.Println(cmdStyle.Render("  cliq \"split tmux window vertically\""))
-	fmt.Println(cmdStyle.Render("  cliq -i") + "   # Interactive mode")
+	fmt.Fprintln(out, titleStyle.Render("\n✨ Cliq is ready to use!\n"))
+	fmt.Fprintln(out, "Try running:")
+	fmt.Fprintln(out, cmdStyle.Render("  cliq \"how do I delete a line in vim\""))
+	fmt.Fprintln(out, cmdStyle.Render("  cliq \"jump to the 6th f character\""))
+	fmt.Fprintln(out, cmdStyle.Render("  cliq \"split tmux window vertically\""))
+	fmt.Fprintln(out, cmdStyle.Render("  cliq -i")+"   # Interactive mode")
 
 	return nil
 }
 
-func detectAvailableBackend() string {
-	// Check for running llama-server
-	if llm.CheckLlamaServerRunning() {
-		return "llama-server"
-	}
+// initVerifyPrompt is the test question init asks; any answer passes
+const initVerifyPrompt = "Reply with the single word: ready"
 
-	// Check for ollama
-	if _, err := exec.LookPath("ollama"); err == nil {
-		if llm.CheckOllamaRunning() {
-			return "ollama"
-		}
-	}
+// initVerifyTimeout bounds the wait for the test answer, long enough for
+// llama-cli to load a model from a cold disk
+const initVerifyTimeout = 90 * time.Second
+
+// verifyBackend asks the backend the config was set up with the test
+// question, without falling back to another
+func verifyBackend(ctx context.Context, cfg *config.Config) error {
+	ctx, cancel := context.WithTimeout(ctx, initVerifyTimeout)
+	defer cancel()
 
-	// Check for llama-cli
-	if _, err := exec.LookPath("llama-cli"); err == nil {
-		return "llama-cli"
+	choice, ok := configuredChoice(ctx, cfg)
+	if !ok {
+		return fmt.Errorf("it isn't available")
 	}
-	if _, err := exec.LookPath("llama"); err == nil {
-		return "llama-cli"
+	client, err := llm.NewClient(cfg.GetModelPath(), cfg.Model.OllamaModel, cfg.Model.Temperature, 16)
+	if err != nil {
+		return err
 	}
+	defer client.Close()
+	llm.SetFallback(0, nil)
 
-	return ""
+	answer, err := client.WithBackend(choice).QueryContext(ctx, initVerifyPrompt)
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("no answer within %s", initVerifyTimeout)
+	}
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(answer) == "" {
+		return fmt.Errorf("empty answer")
+	}
+	return nil
 }
 
-func checkOllamaModel(model string) bool {
-	cmd := exec.Command("ollama", "list")
-	output, err := cmd.Output()
-	if err != nil {
-		return false
+// configuredChoice finds the backend and model the config names among
+// those available
+func configuredChoice(ctx context.Context, cfg *config.Config) (llm.BackendChoice, bool) {
+	for _, c := range llm.ListBackends(ctx, cfg.GetModelPath()) {
+		name, _, _ := strings.Cut(c.Backend, ":")
+		if name != cfg.Model.Backend {
+			continue
+		}
+		if name == "ollama" && c.Model != cfg.Model.OllamaModel && c.Model != cfg.Model.OllamaModel+":latest" {
+			continue
+		}
+		return c, true
 	}
-	return contains(string(output), model)
+	return llm.BackendChoice{}, false
 }
 
-func contains(s, substr string) bool {
-	return len(s) >= len(substr) && (s == substr || len(s) > 0 && containsImpl(s, substr))
+// initProbeTimeout bounds how long init waits on any one probe, so a hung
+// ollama or slow network mount can't stall it
+const initProbeTimeout = 5 * time.Second
+
+// initProbe is what init found on the system
+type initProbe struct {
+	// backend is the best available backend: llama-server, ollama,
+	// llama-cli, or empty
+	backend string
+	// backends are all the backends available, best first
+	backends []string
+	// ollamaModels is the output of ollama list, when ollama is running
+	ollamaModels string
+	// gpu is the GPU llama.cpp can offload the model to
+	gpu llm.GPU
+
+	nvimPath, tmuxPath string
+	nvimErr, tmuxErr   error
 }
 
-func containsImpl(s, substr string) bool {
-	for i := 0; i <= len(s)-len(substr); i++ {
-		if s[i:i+len(substr)] == substr {
-			return true
+// probeSystem looks for running backends, installed binaries, and editor
+// configs all at once rather than one timeout after another. Configs are
+// only looked for when detectConfigs is set.
+func probeSystem(ctx context.Context, detectConfigs bool) *initProbe {
+	ctx, cancel := context.WithTimeout(ctx, initProbeTimeout)
+	defer cancel()
+
+	var (
+		probe                             initProbe
+		serverRunning, ollamaRunning, cli bool
+		wg                                sync.WaitGroup
+	)
+	run := func(f func()) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			f()
+		}()
+	}
+
+	run(func() { serverRunning = llm.CheckLlamaServerRunning() })
+	run(func() { probe.gpu = llm.DetectGPU(ctx) })
+	run(func() {
+		if _, err := exec.LookPath("ollama"); err != nil || !llm.CheckOllamaRunning() {
+			return
+		}
+		ollamaRunning = true
+		// Listing models is the slowest probe, so it runs speculatively
+		// alongside the others
+		if output, err := exec.CommandContext(ctx, "ollama", "list").Output(); err == nil {
+			probe.ollamaModels = string(output)
+		}
+	})
+	run(func() {
+		for _, name := range []string{"llama-cli", "llama"} {
+			if _, err := exec.LookPath(name); err == nil {
+				cli = true
+				return
+			}
+		}
+	})
+	if detectConfigs {
+		run(func() { probe.nvimPath, probe.nvimErr = config.DetectNvimConfig() })
+		run(func() { probe.tmuxPath, probe.tmuxErr = config.DetectTmuxConfig() })
+	}
+	wg.Wait()
+
+	for _, b := range []struct {
+		found bool
+		name  string
+	}{
+		{serverRunning, "llama-server"},
+		{ollamaRunning, "ollama"},
+		{cli, "llama-cli"},
+	} {
+		if b.found {
+			probe.backends = append(probe.backends, b.name)
 		}
 	}
-	return false
+	if len(probe.backends) > 0 {
+		probe.backend = probe.backends[0]
+	}
+	return &probe
+}
+
+// has reports whether the backend was found
+func (p *initProbe) has(backend string) bool {
+	return slices.Contains(p.backends, backend)
+}
+
+// hasOllamaModel reports whether ollama list showed the model
+func (p *initProbe) hasOllamaModel(model string) bool {
+	return strings.Contains(p.ollamaModels, model)
 }
 
 func createDirectories() error {
@@ -294,7 +571,7 @@ func createDirectories() error {
 	}
 
 	for _, dir := range dirs {
-		if err := os.MkdirAll(dir, 0755); err != nil {
+		if err := os.MkdirAll(dir, config.DirPerm); err != nil {
 			return fmt.Errorf("failed to create directory %s: %w", dir, err)
 		}
 	}
@@ -1,10 +1,16 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"math"
 	"os"
+	"os/exec"
 	"strings"
+	"time"
 
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/viewport"
@@ -13,8 +19,12 @@ import (
 
 	"github.com/cliq-cli/cliq/internal/config"
 	"github.com/cliq-cli/cliq/internal/llm"
+	"github.com/cliq-cli/cliq/internal/metrics"
 	"github.com/cliq-cli/cliq/internal/parser"
 	"github.com/cliq-cli/cliq/internal/response"
+	"github.com/cliq-cli/cliq/internal/store"
+	"github.com/cliq-cli/cliq/internal/terminal"
+	"github.com/cliq-cli/cliq/internal/theme"
 )
 
 // Styles
@@ -39,49 +49,193 @@ var (
 			Foreground(lipgloss.Color("196"))
 )
 
+// applyTheme restyles the TUI and its rendered answers with the theme's colors
+func applyTheme(t theme.Theme) {
+	titleStyle = titleStyle.
+		Foreground(lipgloss.Color(t.Accent)).
+		Background(lipgloss.Color(t.TitleBg))
+	promptStyle = promptStyle.Foreground(lipgloss.Color(t.Accent))
+	helpStyle = helpStyle.Foreground(lipgloss.Color(t.Dim))
+	errorStyle = errorStyle.Foreground(lipgloss.Color(t.Error))
+	response.ApplyTheme(t)
+}
+
+// maxBranches is how many answers to one question are shown side by side
+const maxBranches = 3
+
 // Model represents the TUI application state
 type model struct {
-	textarea    textarea.Model
-	viewport    viewport.Model
-	spinner     spinner.Model
-	history     []queryResult
-	loading     bool
-	err         error
-	width       int
-	height      int
-	llmClient   *llm.Client
-	nvimConfig  *parser.NvimConfig
-	tmuxConfig  *parser.TmuxConfig
-	ready       bool
+	textarea  textarea.Model
+	viewport  viewport.Model
+	spinner   spinner.Model
+	history   []queryResult
+	err       error
+	width     int
+	height    int
+	llmClient *llm.Client
+	promptCtx *llm.PromptContext
+	ready     bool
+	lastStats string
+	session   *store.Session
+	ctx       context.Context
+	cancel    context.CancelFunc
+
+	// queries holds the queries still streaming, by ID; nextID numbers the
+	// history entries so answers land on the entry that asked for them
+	queries map[int]*inflight
+	nextID  int
+
+	// focused is the history entry copy and run act on; -1 follows the latest
+	focused int
+	// confirmRun is set after the first Ctrl+X, until the run is confirmed
+	confirmRun bool
+	running    bool
+	status     string
+
+	// confirmCost is set after the first Enter or Ctrl+G for a query
+	// estimated above costConfirmUSD, until it's sent
+	confirmCost    bool
+	costConfirmUSD float64
+
+	// retention decides which questions are saved; with --incognito
+	// nothing is
+	retention store.Retention
+
+	// Input history: histPos indexes inputs.Entries while browsing with
+	// Up/Down (-1 when not browsing), and draft keeps the unsent input
+	inputs  *store.InputHistory
+	histPos int
+	draft   string
+
+	// Generation settings for new queries, edited in the Ctrl+S pane
+	settings      tuiSettings
+	settingsOpen  bool
+	settingsField int
+
+	// shellHooks are the commands run before and after each query
+	shellHooks config.HooksConfig
+
+	// Desktop notification of slow answers: focusKnown is set once the
+	// terminal reports its focus, and blurred while it's elsewhere
+	notify     config.NotifyConfig
+	focusKnown bool
+	blurred    bool
+
+	// Backend and model switcher (Ctrl+O)
+	modelPath  string
+	pickerOpen bool
+	choices    []llm.BackendChoice
+	pickerPos  int
+
+	// History list pane (Tab): listFocused moves keys from the input to the
+	// list, and filter narrows it while filtering is set
+	listFocused bool
+	filtering   bool
+	filter      string
+
+	// Reverse search over the input history (Ctrl+R)
+	searching bool
+	search    string
+	hits      []string
+	hitPos    int
 }
 
 type queryResult struct {
+	id       int
 	Query    string
 	Response string
+	Answer   *response.Response
+
+	// Partial holds the raw text streamed so far, until Response is ready
+	Partial string
+
+	// Output holds the output of running the answer's command with Ctrl+X
+	Output string
+
+	// Branch numbers regenerated answers to the question before (0 for the
+	// original); branches are shown side by side
+	Branch      int
+	Temperature float64
+	Copied      bool
+
+	// sessionIdx is the entry's index in the saved session, -1 until saved
+	sessionIdx int
+}
+
+// inflight is a query whose answer is still streaming in
+type inflight struct {
+	stream chan tea.Msg
+	stop   context.CancelFunc
+	meter  *metrics.Meter
+	client *llm.Client
+}
+
+// Messages. Stream messages carry the ID of the query they answer; once a
+// query is cancelled its ID is unknown and anything it still sends is
+// dropped.
+type tokenMsg struct {
+	id    int
+	token string
 }
 
-// Messages
 type responseMsg struct {
+	id       int
 	response string
+	answer   *response.Response
 	err      error
 }
 
+// execMsg reports the result of running an answer's command
+type execMsg struct {
+	id     int
+	output string
+	err    error
+}
+
 type initMsg struct {
+	settings   tuiSettings
+	modelPath  string
 	client     *llm.Client
-	nvimConfig *parser.NvimConfig
-	tmuxConfig *parser.TmuxConfig
-	err        error
+	promptCtx  *llm.PromptContext
+	shellHooks config.HooksConfig
+	notify     config.NotifyConfig
+	// costConfirmUSD is model.cost_confirm_usd
+	costConfirmUSD float64
+	err            error
 }
 
-func runInteractive() error {
-	p := tea.NewProgram(initialModel(), tea.WithAltScreen())
-	if _, err := p.Run(); err != nil {
-		return err
+func runInteractive(ctx context.Context) error {
+	// The theme is resolved before the TUI starts, while the terminal can
+	// still be asked for its background color
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = config.Default()
+	}
+	t, err := theme.Load(cfg.TUI.Theme)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v; using the %s theme\n", err, t.Name)
+	}
+	applyTheme(t)
+	// So is the store key, as a passphrase can't be asked for once the
+	// TUI has the terminal
+	if err := store.Unlock(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v; history is not available\n", err)
 	}
-	return nil
+
+	// Queries run under their own context so quitting cancels any in flight
+	queryCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	p := tea.NewProgram(initialModel(queryCtx, cancel, historyRetention(cfg)), tea.WithAltScreen(), tea.WithReportFocus(), tea.WithContext(ctx))
+	final, err := p.Run()
+	// However the TUI ended, stop the warm session's llama-server
+	if m, ok := final.(model); ok && m.llmClient != nil {
+		m.llmClient.Close()
+	}
+	return err
 }
 
-func initialModel() model {
+func initialModel(ctx context.Context, cancel context.CancelFunc, retention store.Retention) model {
 	ta := textarea.New()
 	ta.Placeholder = "Ask about Neovim or tmux commands..."
 	ta.Focus()
@@ -92,53 +246,125 @@ func initialModel() model {
 
 	s := spinner.New()
 	s.Spinner = spinner.Dot
-	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	s.Style = lipgloss.NewStyle().Foreground(promptStyle.GetForeground())
+
+	// Pick up where the last session left off
+	session, err := store.LoadSession()
+	if err != nil {
+		session = &store.Session{Started: time.Now()}
+	}
+	if session.Prune(retention) && !incognito {
+		session.Save()
+	}
+	history := []queryResult{}
+	for i, entry := range session.Entries {
+		if entry.Answer == nil {
+			continue
+		}
+		history = append(history, queryResult{
+			id:          len(history) + 1,
+			Query:       entry.Query,
+			Response:    entry.Answer.ToText(),
+			Answer:      entry.Answer,
+			Branch:      entry.Branch,
+			Temperature: entry.Temperature,
+			Copied:      entry.Copied,
+			sessionIdx:  i,
+		})
+	}
+
+	inputs, err := store.LoadInputHistory()
+	if err != nil {
+		inputs = &store.InputHistory{}
+	}
+	inputs.Retention = retention
+	if !incognito {
+		inputs.Prune()
+	}
 
 	return model{
-		textarea: ta,
-		spinner:  s,
-		history:  []queryResult{},
+		retention: retention,
+		inputs:    inputs,
+		histPos:   -1,
+		textarea:  ta,
+		spinner:   s,
+		history:   history,
+		queries:   map[int]*inflight{},
+		nextID:    len(history),
+		session:   session,
+		ctx:       ctx,
+		cancel:    cancel,
+		focused:   -1,
 	}
 }
 
 func (m model) Init() tea.Cmd {
 	return tea.Batch(
 		textarea.Blink,
-		initLLM,
+		func() tea.Msg { return initLLM(m.ctx) },
 	)
 }
 
-func initLLM() tea.Msg {
+func initLLM(ctx context.Context) tea.Msg {
 	cfg, err := config.Load()
 	if err != nil {
 		cfg = config.Default()
 	}
+	if err := applySamplingFlags(cfg); err != nil {
+		return initMsg{err: err}
+	}
 
 	modelPath := cfg.GetModelPath()
-	if _, err := os.Stat(modelPath); os.IsNotExist(err) {
-		return initMsg{err: fmt.Errorf("model not found. Run 'cliq init' first")}
+	if cfg.Model.Backend == "llama-cli" {
+		if _, err := os.Stat(modelPath); os.IsNotExist(err) {
+			return initMsg{err: fmt.Errorf("model not found. Run 'cliq init' first")}
+		}
 	}
 
 	client, err := llm.NewClient(modelPath, cfg.Model.OllamaModel, cfg.Model.Temperature, cfg.Model.MaxTokens)
 	if err != nil {
 		return initMsg{err: fmt.Errorf("failed to load model: %w", err)}
 	}
+	client = client.WithSamplingOptions(samplingOptions(cfg))
+	// Keep the model loaded between questions, rather than loading it for
+	// each; without llama-server, llama-cli still works as before
+	if cfg.Model.WarmSession {
+		client.StartSession(ctx)
+	}
 
-	// Parse configs
-	var nvimConfig *parser.NvimConfig
-	var tmuxConfig *parser.TmuxConfig
+	if noContext {
+		return initMsg{
+			settings:   settingsFromConfig(cfg),
+			modelPath:  modelPath,
+			client:     client,
+			promptCtx:  genericPromptContext(cfg),
+			shellHooks: cfg.Hooks,
+			notify:     cfg.Notify,
 
-	if cfg.Nvim.ConfigPath != "" {
-		nvimConfig, _ = parser.ParseNvimConfig(cfg.Nvim.ConfigPath)
+			costConfirmUSD: cfg.Model.CostConfirmUSD,
+		}
 	}
-	if cfg.Tmux.ConfigPath != "" {
-		tmuxConfig, _ = parser.ParseTmuxConfig(cfg.Tmux.ConfigPath)
+
+	// Parse configs, unless a running daemon has them
+	nvimConfig, tmuxConfig, ok := daemonConfigs(cfg)
+	if !ok {
+		if cfg.Nvim.ConfigPath != "" {
+			nvimConfig, _ = parser.ParseNvimConfig(cfg.Nvim.ConfigPath)
+		}
+		if cfg.Tmux.ConfigPath != "" {
+			tmuxConfig, _ = parser.ParseTmuxConfig(cfg.Tmux.ConfigPath)
+		}
 	}
 
 	return initMsg{
+		settings:   settingsFromConfig(cfg),
+		modelPath:  modelPath,
 		client:     client,
-		nvimConfig: nvimConfig,
-		tmuxConfig: tmuxConfig,
+		promptCtx:  newPromptContext(cfg, nvimConfig, tmuxConfig),
+		shellHooks: cfg.Hooks,
+		notify:     cfg.Notify,
+
+		costConfirmUSD: cfg.Model.CostConfirmUSD,
 	}
 }
 
@@ -147,27 +373,220 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if msg.Type != tea.KeyCtrlX {
+			m.confirmRun = false
+		}
+		if msg.Type != tea.KeyEnter && msg.Type != tea.KeyCtrlG {
+			m.confirmCost = false
+		}
+		if m.searching && msg.Type != tea.KeyCtrlC {
+			return m.updateSearch(msg), nil
+		}
+		if m.settingsOpen && msg.Type != tea.KeyCtrlC {
+			return m.updateSettings(msg)
+		}
+		if m.pickerOpen && msg.Type != tea.KeyCtrlC {
+			return m.updatePicker(msg)
+		}
+		if msg.Type == tea.KeyEsc && m.loading() && !m.filtering {
+			m.cancelQuery()
+			return m, nil
+		}
+		if m.listFocused {
+			var handled bool
+			if m, handled = m.updateList(msg); handled {
+				return m, nil
+			}
+		}
 		switch msg.Type {
+		case tea.KeyTab:
+			if len(m.history) > 0 {
+				m.listFocused = true
+				m.textarea.Blur()
+			}
+			return m, nil
+
+		case tea.KeyCtrlS:
+			if m.llmClient != nil {
+				m.settingsOpen = true
+				m.status = ""
+			}
+			return m, nil
+
+		case tea.KeyCtrlO:
+			if m.llmClient != nil {
+				m.pickerOpen = true
+				m.choices = nil
+				m.pickerPos = 0
+				m.status = ""
+				return m, tea.Batch(m.spinner.Tick, loadBackends(m.ctx, m.modelPath))
+			}
+			return m, nil
+
+		case tea.KeyCtrlR:
+			m.searching = true
+			m.search = ""
+			m.hits = m.inputs.Search("")
+			m.hitPos = 0
+			return m, nil
+
+		case tea.KeyUp:
+			// Recall older input once the cursor is on the first line
+			if m.textarea.Line() == 0 && len(m.inputs.Entries) > 0 {
+				if m.histPos == -1 {
+					m.draft = m.textarea.Value()
+					m.histPos = len(m.inputs.Entries)
+				}
+				if m.histPos > 0 {
+					m.histPos--
+					m.textarea.SetValue(m.inputs.Entries[m.histPos])
+				}
+				return m, nil
+			}
+
+		case tea.KeyDown:
+			if m.histPos != -1 && m.textarea.Line() == m.textarea.LineCount()-1 {
+				m.histPos++
+				if m.histPos >= len(m.inputs.Entries) {
+					m.histPos = -1
+					m.textarea.SetValue(m.draft)
+				} else {
+					m.textarea.SetValue(m.inputs.Entries[m.histPos])
+				}
+				return m, nil
+			}
+
 		case tea.KeyCtrlC, tea.KeyEsc:
+			m.cancel()
 			if m.llmClient != nil {
 				m.llmClient.Close()
 			}
+			m.saveSession()
 			return m, tea.Quit
 
+		case tea.KeyCtrlN:
+			// Start a fresh session
+			if !m.loading() {
+				m.history = []queryResult{}
+				m.session.Clear()
+				m.saveSession()
+				m.lastStats = ""
+				m.focused = -1
+				m.filter = ""
+				m.listFocused = false
+				m.textarea.Focus()
+				m.viewport.SetContent(m.renderDetail())
+				m.viewport.GotoTop()
+				return m, nil
+			}
+
+		case tea.KeyCtrlUp, tea.KeyCtrlDown:
+			if len(m.history) > 0 {
+				idx := m.focusedIndex()
+				if msg.Type == tea.KeyCtrlUp && idx > 0 {
+					idx--
+				} else if msg.Type == tea.KeyCtrlDown && idx < len(m.history)-1 {
+					idx++
+				}
+				m.focused = idx
+				if idx == len(m.history)-1 {
+					m.focused = -1
+				}
+				m.confirmRun = false
+				m.status = ""
+				m.viewport.SetContent(m.renderDetail())
+			}
+			return m, nil
+
+		case tea.KeyCtrlY:
+			if entry := m.focusedEntry(); entry != nil {
+				if err := terminal.Copy(entry.Answer.Command); err != nil {
+					m.status = "Copy failed: " + err.Error()
+				} else {
+					m.status = "Copied: " + entry.Answer.Command
+					m.markCopied(m.focusedIndex())
+				}
+			} else {
+				m.status = "No command to copy"
+			}
+			return m, nil
+
+		case tea.KeyCtrlG:
+			if !m.ready || len(m.history) == 0 {
+				return m, nil
+			}
+			last := m.history[len(m.history)-1]
+			if last.Answer == nil {
+				m.status = "Wait for the answer before regenerating it"
+				return m, nil
+			}
+			if last.Branch+1 >= maxBranches {
+				m.status = fmt.Sprintf("At most %d answers are shown side by side", maxBranches)
+				return m, nil
+			}
+			// Each regeneration runs hotter for a more varied answer
+			settings := m.settings
+			settings.temperature = math.Min(settings.temperature+0.3*float64(last.Branch+1), 1.5)
+			status, hold := m.checkCost(last.Query, settings, "Ctrl+G")
+			m.status = status
+			if hold {
+				return m, nil
+			}
+			m.focused = -1
+			m.filter = ""
+			return m, tea.Batch(m.spinner.Tick, m.queryLLM(last.Query, settings, last.Branch+1))
+
+		case tea.KeyCtrlX:
+			entry := m.focusedEntry()
+			switch {
+			case entry == nil:
+				m.status = "No command to run"
+			case m.running:
+				m.status = "A command is already running"
+			case !m.confirmRun:
+				// Answers aren't always shell commands, so ask before running
+				m.confirmRun = true
+				m.status = "Run `" + entry.Answer.Command + "` in " + userShell() + "? Press Ctrl+X again to confirm"
+			default:
+				m.confirmRun = false
+				m.running = true
+				m.status = "Running " + entry.Answer.Command + "..."
+				return m, runAnswerCommand(m.ctx, entry.id, entry.Answer.Command)
+			}
+			return m, nil
+
 		case tea.KeyEnter:
-			if !m.loading && m.ready {
+			// Questions can be asked while earlier answers are still coming in
+			if m.ready {
 				query := strings.TrimSpace(m.textarea.Value())
 				if query != "" {
-					m.loading = true
+					status, hold := m.checkCost(query, m.settings, "Enter")
+					m.status = status
+					if hold {
+						return m, nil
+					}
+					m.focused = -1
+					m.filter = ""
+					m.histPos = -1
+					if !incognito {
+						m.inputs.Add(query)
+					}
 					m.textarea.Reset()
+					cmd := m.queryLLM(query, m.settings, 0)
 					return m, tea.Batch(
 						m.spinner.Tick,
-						m.queryLLM(query),
+						cmd,
 					)
 				}
 			}
 		}
 
+	case tea.FocusMsg:
+		m.focusKnown, m.blurred = true, false
+
+	case tea.BlurMsg:
+		m.focusKnown, m.blurred = true, true
+
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
@@ -179,40 +598,128 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		helpHeight := 2
 		viewportHeight := msg.Height - headerHeight - inputHeight - helpHeight - 2
 
+		// The history list takes the left of the answer area, plus a
+		// column for its border and one for a gap
+		viewportWidth := msg.Width - 4
+		if lw := listWidth(msg.Width); lw > 0 {
+			viewportWidth -= lw + 2
+		}
+
 		if !m.ready {
-			m.viewport = viewport.New(msg.Width-4, viewportHeight)
-			m.viewport.SetContent(m.renderHistory())
+			m.viewport = viewport.New(viewportWidth, viewportHeight)
+			// Letters and arrows belong to the input, so only paging scrolls
+			m.viewport.KeyMap = viewport.KeyMap{
+				PageDown: key.NewBinding(key.WithKeys("pgdown")),
+				PageUp:   key.NewBinding(key.WithKeys("pgup")),
+			}
+			m.viewport.SetContent(m.renderDetail())
 			m.ready = true
 		} else {
-			m.viewport.Width = msg.Width - 4
+			m.viewport.Width = viewportWidth
 			m.viewport.Height = viewportHeight
+			m.viewport.SetContent(m.renderDetail())
 		}
 
+	case backendsMsg:
+		m.choices = msg.choices
+		current := m.llmClient.Choice()
+		for i, choice := range m.choices {
+			if choice.Backend == current.Backend && choice.Model == current.Model {
+				m.pickerPos = i
+			}
+		}
+
+	case execMsg:
+		m.running = false
+		out := strings.TrimRight(msg.output, "\n")
+		if msg.err != nil {
+			out = strings.TrimSpace(out + "\n" + msg.err.Error())
+			m.status = "Command failed"
+		} else {
+			m.status = "Command finished"
+		}
+		if out == "" {
+			out = "(no output)"
+		}
+		if i := m.entryIndex(msg.id); i >= 0 {
+			m.history[i].Output = out
+		}
+		m.viewport.SetContent(m.renderDetail())
+
 	case initMsg:
 		if msg.err != nil {
 			m.err = msg.err
 		} else {
 			m.llmClient = msg.client
-			m.nvimConfig = msg.nvimConfig
-			m.tmuxConfig = msg.tmuxConfig
+			m.promptCtx = msg.promptCtx
+			m.shellHooks = msg.shellHooks
+			m.notify = msg.notify
+			m.costConfirmUSD = msg.costConfirmUSD
+			m.settings = msg.settings
+			m.modelPath = msg.modelPath
 			m.ready = true
 		}
 
+	case tokenMsg:
+		q, ok := m.queries[msg.id]
+		if !ok {
+			return m, nil
+		}
+		q.meter.Add(q.client.CountTokens(msg.token))
+		if i := m.entryIndex(msg.id); i >= 0 {
+			m.history[i].Partial += msg.token
+			if g := m.focusedGroup(); i >= g.start && i < g.end {
+				m.viewport.SetContent(m.renderDetail())
+				if m.focused == -1 {
+					m.viewport.GotoBottom()
+				}
+			}
+		}
+		cmds = append(cmds, waitForStream(q.stream))
+
 	case responseMsg:
-		m.loading = false
+		q, ok := m.queries[msg.id]
+		if !ok {
+			return m, nil
+		}
+		delete(m.queries, msg.id)
+		rec := q.meter.Record(q.client.GetBackend(), q.client.GetModel())
+		m.lastStats = fmt.Sprintf("%d tokens in %.1fs", rec.Tokens, q.meter.Elapsed().Seconds())
+		if rec.TokensPerSec > 0 {
+			m.lastStats += fmt.Sprintf(" (%.1f tok/s)", rec.TokensPerSec)
+		}
+
+		i := m.entryIndex(msg.id)
+		if i < 0 {
+			return m, nil
+		}
 		if msg.err != nil {
-			m.err = msg.err
+			// One failed query shouldn't take down the others
+			m.status = fmt.Sprintf("%q failed: %v", m.history[i].Query, msg.err)
+			m.removeEntry(i)
 		} else {
-			m.history = append(m.history, queryResult{
-				Query:    m.history[len(m.history)-1].Query,
-				Response: msg.response,
-			})
-			m.viewport.SetContent(m.renderHistory())
+			metrics.Append(rec)
+
+			entry := &m.history[i]
+			entry.Response = msg.response
+			entry.Answer = msg.answer
+			entry.Partial = ""
+
+			// Save after every answer so a crash doesn't lose the session
+			if entry.sessionIdx >= 0 {
+				m.session.Entries[entry.sessionIdx].Answer = msg.answer
+				m.session.Entries[entry.sessionIdx].Raw = msg.answer.Raw
+				m.saveSession()
+			}
+			cmds = append(cmds, m.notifySlow(entry.Query, msg.answer, q.meter.Elapsed()))
+		}
+		m.viewport.SetContent(m.renderDetail())
+		if m.focused == -1 {
 			m.viewport.GotoBottom()
 		}
 
 	case spinner.TickMsg:
-		if m.loading {
+		if m.loading() || (m.pickerOpen && m.choices == nil) {
 			var cmd tea.Cmd
 			m.spinner, cmd = m.spinner.Update(msg)
 			cmds = append(cmds, cmd)
@@ -220,34 +727,221 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 
 	// Update textarea
-	if !m.loading {
-		var cmd tea.Cmd
-		m.textarea, cmd = m.textarea.Update(msg)
-		cmds = append(cmds, cmd)
-	}
+	var cmd tea.Cmd
+	m.textarea, cmd = m.textarea.Update(msg)
+	cmds = append(cmds, cmd)
 
 	// Update viewport
-	var cmd tea.Cmd
 	m.viewport, cmd = m.viewport.Update(msg)
 	cmds = append(cmds, cmd)
 
 	return m, tea.Batch(cmds...)
 }
 
-func (m model) queryLLM(query string) tea.Cmd {
-	// Add query to history first (response will be added when complete)
-	m.history = append(m.history, queryResult{Query: query})
+// checkCost estimates what asking the query with settings could cost on a
+// remote backend with a known price, returning it as the status to show.
+// hold is set for a query above model.cost_confirm_usd until key is
+// pressed again, as Ctrl+X is for running a command.
+func (m *model) checkCost(query string, settings tuiSettings, key string) (status string, hold bool) {
+	client := m.llmClient.WithSampling(settings.temperature, settings.maxTokens)
+	if !client.IsRemote() {
+		return "", false
+	}
+	est := client.EstimateCost(llm.BuildPrompt(query, m.promptCtx))
+	if !est.Priced {
+		return "", false
+	}
+	if m.costConfirmUSD > 0 && est.USD > m.costConfirmUSD && !m.confirmCost {
+		m.confirmCost = true
+		return fmt.Sprintf("This may cost up to $%.4f. Press %s again to send it", est.USD, key), true
+	}
+	m.confirmCost = false
+	return "Estimated cost: " + est.String(), false
+}
 
-	return func() tea.Msg {
-		prompt := llm.BuildPrompt(query, m.nvimConfig, m.tmuxConfig)
-		resp, err := m.llmClient.Query(prompt)
-		if err != nil {
-			return responseMsg{err: err}
+// queryLLM starts streaming a response for the query with the given
+// settings; branch is non-zero when regenerating the previous answer.
+// Tokens are delivered to Update as tokenMsg values, followed by a single
+// responseMsg, all tagged with the new history entry's ID.
+func (m *model) queryLLM(query string, settings tuiSettings, branch int) tea.Cmd {
+	m.nextID++
+	id := m.nextID
+
+	// Add query to history first (response will be filled in when
+	// complete). Its session entry is reserved now so the saved session
+	// keeps the order questions were asked in, not answered in.
+	sessionIdx := -1
+	if !m.retention.Excludes(query) {
+		sessionIdx = m.session.Add(query, nil)
+		m.session.Entries[sessionIdx].Branch = branch
+		m.session.Entries[sessionIdx].Temperature = settings.temperature
+	}
+	m.history = append(m.history, queryResult{
+		id:          id,
+		Query:       query,
+		Branch:      branch,
+		Temperature: settings.temperature,
+		sessionIdx:  sessionIdx,
+	})
+
+	// Each query gets its own context so Esc can cancel just this one.
+	// Learned answers are matched per query, so work on a copy of the
+	// context; the client is copied too so settings changes don't race
+	ctx, cancel := context.WithCancel(m.ctx)
+	stream := make(chan tea.Msg)
+	client := m.llmClient.WithSampling(settings.temperature, settings.maxTokens)
+	m.queries[id] = &inflight{
+		stream: stream,
+		stop:   cancel,
+		meter:  metrics.NewMeter(),
+		client: client,
+	}
+
+	promptCtx := *m.promptCtx
+	promptCtx.Style = settings.style
+	shellHooks := m.shellHooks
+	go func() {
+		defer close(stream)
+		defer cancel()
+
+		promptCtx.Commands = runBeforeHooks(ctx, shellHooks, query)
+		resp, learned := applyLessons(&promptCtx, query)
+		promptCtx.References = llm.Retrieve(query)
+		if learned {
+			stream <- tokenMsg{id: id, token: resp}
+		} else {
+			// A failing hook would only garble the TUI with its warning;
+			// cliq doctor points it out
+			prompt, _ := hookPrompt(query, llm.BuildPrompt(query, &promptCtx))
+			var err error
+			resp, err = client.QueryStreamContext(ctx, prompt, func(token string) {
+				stream <- tokenMsg{id: id, token: token}
+			})
+			if err != nil {
+				stream <- responseMsg{id: id, err: err}
+				return
+			}
 		}
 
 		// Format response
 		parsed := response.Parse(resp)
-		return responseMsg{response: parsed.ToText()}
+		personalizeResponse(parsed, &promptCtx, query)
+		hookResponse(parsed)
+		stream <- responseMsg{id: id, response: parsed.ToText(), answer: parsed}
+		// Once the answer is shown; what they print would garble the TUI
+		runAfterHooks(ctx, shellHooks, query, parsed, io.Discard)
+	}()
+
+	return waitForStream(stream)
+}
+
+// notifySlow notifies an answer that took long enough while the terminal
+// is elsewhere. Without focus reports from the terminal, it's asked as for
+// a one-shot query.
+func (m model) notifySlow(query string, answer *response.Response, took time.Duration) tea.Cmd {
+	focusKnown, blurred := m.focusKnown, m.blurred
+	focused := func() (bool, bool) {
+		if focusKnown {
+			return !blurred, true
+		}
+		return terminal.Focused()
+	}
+	notify := m.notify
+	return func() tea.Msg {
+		// A notifier that's missing or failed is only a missed nicety
+		if shouldNotify(notify, took, focused) {
+			notifyAnswer(query, answer)
+		}
+		return nil
+	}
+}
+
+// saveSession saves the session unless running incognito
+func (m model) saveSession() {
+	if !incognito {
+		m.session.Save()
+	}
+}
+
+// loading reports whether any query is still streaming
+func (m model) loading() bool {
+	return len(m.queries) > 0
+}
+
+// latestQuery returns the most recently asked query still streaming
+func (m model) latestQuery() (int, *inflight) {
+	latest := 0
+	for id := range m.queries {
+		latest = max(latest, id)
+	}
+	return latest, m.queries[latest]
+}
+
+// entryIndex returns the index of the history entry with the given ID, or
+// -1 if it has been cancelled or cleared
+func (m model) entryIndex(id int) int {
+	for i := len(m.history) - 1; i >= 0; i-- {
+		if m.history[i].id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// removeEntry drops an unanswered history entry and its reserved session
+// entry
+func (m *model) removeEntry(i int) {
+	if idx := m.history[i].sessionIdx; idx >= 0 {
+		m.session.Remove(idx)
+		for j := range m.history {
+			if m.history[j].sessionIdx > idx {
+				m.history[j].sessionIdx--
+			}
+		}
+	}
+	m.history = append(m.history[:i], m.history[i+1:]...)
+	if m.focused > i {
+		m.focused--
+	} else if m.focused == i {
+		m.focused = -1
+	}
+}
+
+// cancelQuery stops the most recently asked query still in flight and puts
+// its text back in the input, unless something else has been typed there
+func (m *model) cancelQuery() {
+	id, q := m.latestQuery()
+	if q == nil {
+		return
+	}
+	q.stop()
+	delete(m.queries, id)
+
+	// Drain what the cancelled query still sends so its goroutine can exit
+	go func() {
+		for range q.stream {
+		}
+	}()
+
+	m.status = "Cancelled"
+	if i := m.entryIndex(id); i >= 0 {
+		if m.textarea.Value() == "" {
+			m.textarea.SetValue(m.history[i].Query)
+		}
+		m.removeEntry(i)
+	}
+	m.viewport.SetContent(m.renderDetail())
+	m.viewport.GotoBottom()
+}
+
+// waitForStream waits for the next message from a streaming query
+func waitForStream(stream chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-stream
+		if !ok {
+			return nil
+		}
+		return msg
 	}
 }
 
@@ -261,50 +955,243 @@ func (m model) View() string {
 	// Title
 	title := titleStyle.Render(" Cliq - Interactive Mode ")
 	b.WriteString(title)
+	// Status bar: the backend and model new questions go to
+	if m.llmClient != nil {
+		b.WriteString(helpStyle.Render("  " + m.llmClient.Choice().Label()))
+		if status := m.llmClient.SessionStatus(); status != "" {
+			b.WriteString(helpStyle.Render(" · " + status))
+		}
+	}
+	if incognito {
+		b.WriteString(promptStyle.Render("  incognito"))
+	}
 	b.WriteString("\n\n")
 
 	// Response area
-	if m.ready {
-		b.WriteString(m.viewport.View())
+	if m.settingsOpen {
+		b.WriteString(m.renderSettings())
+	} else if m.pickerOpen {
+		b.WriteString(m.renderPicker())
+	} else if m.ready {
+		if lw := listWidth(m.width); lw > 0 {
+			list := m.renderList(lw, m.viewport.Height)
+			b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, list, " ", m.viewport.View()))
+		} else {
+			b.WriteString(m.viewport.View())
+		}
 	} else {
 		b.WriteString("Loading model...")
 	}
 	b.WriteString("\n\n")
 
-	// Loading indicator
-	if m.loading {
-		b.WriteString(m.spinner.View())
-		b.WriteString(" Thinking...")
+	// Loading indicator: a spinner until the first token arrives, then
+	// live throughput while the answer streams in
+	if m.loading() {
+		_, q := m.latestQuery()
+		if q.meter.Tokens() > 0 {
+			b.WriteString(promptStyle.Render("⚡"))
+			rate := ""
+			if q.meter.Streamed() {
+				rate = fmt.Sprintf(" %.1f tok/s •", q.meter.TokensPerSec())
+			}
+			b.WriteString(helpStyle.Render(fmt.Sprintf("%s %d tokens • %.1fs • Esc to cancel",
+				rate, q.meter.Tokens(), q.meter.Elapsed().Seconds())))
+		} else {
+			b.WriteString(m.spinner.View())
+			b.WriteString(" Thinking...")
+			b.WriteString(helpStyle.Render(" (Esc to cancel)"))
+		}
+		if n := len(m.queries); n > 1 {
+			b.WriteString(helpStyle.Render(fmt.Sprintf(" • %d questions in flight", n)))
+		}
+		b.WriteString("\n")
+	} else if m.status != "" {
+		b.WriteString(helpStyle.Render(m.status))
+		b.WriteString("\n")
+	} else if m.lastStats != "" {
+		b.WriteString(helpStyle.Render("Last answer: " + m.lastStats))
 		b.WriteString("\n")
 	}
 
 	// Input area
-	b.WriteString(promptStyle.Render("❯ "))
-	b.WriteString(m.textarea.View())
+	if m.searching {
+		hit := ""
+		if m.hitPos < len(m.hits) {
+			hit = m.hits[m.hitPos]
+		}
+		b.WriteString(promptStyle.Render(fmt.Sprintf("(reverse-i-search)`%s': ", m.search)))
+		b.WriteString(hit)
+		b.WriteString("\n\n\n")
+	} else {
+		b.WriteString(promptStyle.Render("❯ "))
+		b.WriteString(m.textarea.View())
+	}
 	b.WriteString("\n")
 
 	// Help
-	help := helpStyle.Render("Enter: submit • Ctrl+C: quit • ↑↓: scroll")
-	b.WriteString(help)
+	help := "Enter: submit • ↑↓: history • Ctrl+R: search • Tab: list • Ctrl+Y: copy • Ctrl+X: run • PgUp/PgDn: scroll • Ctrl+G: regenerate • Ctrl+S: settings • Ctrl+O: backend • Ctrl+N: new • Ctrl+C: quit"
+	if m.filtering {
+		help = "Type to filter • Enter: keep filter • Esc: clear filter"
+	} else if m.listFocused {
+		help = "j/k: select • g/G: first/last • /: filter • Tab/Esc: back to input • Ctrl+Y: copy • Ctrl+X: run • Ctrl+G: regenerate • PgUp/PgDn: scroll"
+	}
+	b.WriteString(helpStyle.Render(help))
 
 	return b.String()
 }
 
-func (m model) renderHistory() string {
+// renderDetail renders the question selected in the history list with its
+// answers; regenerated answers are shown side by side
+func (m model) renderDetail() string {
 	if len(m.history) == 0 {
 		return helpStyle.Render("Welcome to Cliq! Ask me anything about Neovim or tmux.\n\nExamples:\n  • How do I delete a line?\n  • Split tmux window vertically\n  • Search and replace in vim")
 	}
 
+	// Answers are rendered at view time so markdown wraps to the current width
+	width := m.viewport.Width - responseStyle.GetHorizontalFrameSize()
+
+	focused := m.focusedIndex()
+	g := m.focusedGroup()
+
 	var b strings.Builder
-	for _, h := range m.history {
-		b.WriteString(promptStyle.Render("❯ "))
-		b.WriteString(h.Query)
-		b.WriteString("\n\n")
-		if h.Response != "" {
-			b.WriteString(responseStyle.Render(h.Response))
-			b.WriteString("\n\n")
-		}
+	b.WriteString(promptStyle.Render("❯ "))
+	b.WriteString(m.history[g.start].Query)
+	b.WriteString("\n\n")
+
+	if g.end-g.start == 1 {
+		b.WriteString(responseStyle.Render(renderAnswer(m.history[g.start], width)))
+		return b.String()
 	}
 
+	// Branches side by side, each headed by its temperature
+	gap := 2
+	colWidth := (width - gap*(g.end-g.start-1)) / (g.end - g.start)
+	var cols []string
+	for j := g.start; j < g.end; j++ {
+		h := m.history[j]
+		header := fmt.Sprintf("Answer %d · temp %.1f", h.Branch+1, h.Temperature)
+		if h.Copied {
+			header += " · ✓ copied"
+		}
+		headerStyle := helpStyle
+		if j == focused {
+			headerStyle = promptStyle
+		}
+		col := headerStyle.Render(header) + "\n\n" + renderAnswer(h, colWidth)
+		if j < g.end-1 {
+			col = lipgloss.NewStyle().Width(colWidth).MarginRight(gap).Render(col)
+		}
+		cols = append(cols, col)
+	}
+	b.WriteString(responseStyle.Render(lipgloss.JoinHorizontal(lipgloss.Top, cols...)))
 	return b.String()
 }
+
+// renderAnswer renders one history entry's answer, or what has streamed so
+// far, and the output of running its command, wrapped to width
+func renderAnswer(h queryResult, width int) string {
+	var parts []string
+	if h.Answer != nil {
+		parts = append(parts, h.Answer.ToTUI(width))
+	} else if h.Response != "" {
+		parts = append(parts, h.Response)
+	} else if h.Partial != "" {
+		// Render the markdown streamed so far; an open code fence shows as code
+		parts = append(parts, response.RenderMarkdown(h.Partial, width)+"▌")
+	}
+	if h.Output != "" {
+		parts = append(parts, response.CodeBlockStyle.Render(h.Output))
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// markCopied records that the user copied an entry's command, as implicit
+// feedback on which answer helped
+func (m *model) markCopied(idx int) {
+	h := &m.history[idx]
+	h.Copied = true
+	if h.sessionIdx >= 0 && h.sessionIdx < len(m.session.Entries) {
+		m.session.Entries[h.sessionIdx].Copied = true
+		m.saveSession()
+	}
+	m.viewport.SetContent(m.renderDetail())
+}
+
+// updateSearch handles keys while reverse-searching the input history.
+// Typing narrows the search, Ctrl+R steps to older matches, Enter puts the
+// match in the input for editing, and Esc cancels.
+func (m model) updateSearch(msg tea.KeyMsg) model {
+	switch msg.Type {
+	case tea.KeyEsc, tea.KeyCtrlG:
+		m.searching = false
+		return m
+	case tea.KeyEnter:
+		if m.hitPos < len(m.hits) {
+			m.textarea.SetValue(m.hits[m.hitPos])
+		}
+		m.searching = false
+		m.histPos = -1
+		return m
+	case tea.KeyCtrlR:
+		if m.hitPos < len(m.hits)-1 {
+			m.hitPos++
+		}
+		return m
+	case tea.KeyBackspace:
+		if r := []rune(m.search); len(r) > 0 {
+			m.search = string(r[:len(r)-1])
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		m.search += string(msg.Runes)
+	default:
+		return m
+	}
+
+	m.hits = m.inputs.Search(m.search)
+	m.hitPos = 0
+	return m
+}
+
+// focusedIndex returns the index of the history entry copy and run act on
+func (m model) focusedIndex() int {
+	if m.focused < 0 || m.focused >= len(m.history) {
+		return len(m.history) - 1
+	}
+	return m.focused
+}
+
+// focusedEntry returns the focused history entry if it has a command
+func (m model) focusedEntry() *queryResult {
+	idx := m.focusedIndex()
+	if idx < 0 {
+		return nil
+	}
+	entry := &m.history[idx]
+	if entry.Answer == nil || entry.Answer.Command == "" {
+		return nil
+	}
+	return entry
+}
+
+// userShell returns the shell commands are run in
+func userShell() string {
+	if sh := os.Getenv("SHELL"); sh != "" {
+		return sh
+	}
+	return "/bin/sh"
+}
+
+// runAnswerCommand runs a command in a subshell and reports its combined
+// output. Stdin is empty so interactive commands exit instead of waiting.
+func runAnswerCommand(ctx context.Context, id int, command string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+
+		out, err := exec.CommandContext(ctx, userShell(), "-c", command).CombinedOutput()
+		if ctx.Err() == context.DeadlineExceeded {
+			err = fmt.Errorf("timed out after 30s")
+		}
+		return execMsg{id: id, output: string(out), err: err}
+	}
+}
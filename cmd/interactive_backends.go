@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/cliq-cli/cliq/internal/llm"
+)
+
+// backendsMsg delivers the backends found for the switcher
+type backendsMsg struct {
+	choices []llm.BackendChoice
+}
+
+// loadBackends looks for available backends in the background, since
+// probing servers takes a moment
+func loadBackends(ctx context.Context, modelPath string) tea.Cmd {
+	return func() tea.Msg {
+		return backendsMsg{choices: llm.ListBackends(ctx, modelPath)}
+	}
+}
+
+// updatePicker handles keys while the backend switcher is open. Enter
+// switches the following queries to the selected backend and model.
+func (m model) updatePicker(msg tea.KeyMsg) (model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "ctrl+o":
+		m.pickerOpen = false
+	case "up", "k":
+		if m.pickerPos > 0 {
+			m.pickerPos--
+		}
+	case "down", "j", "tab":
+		if m.pickerPos < len(m.choices)-1 {
+			m.pickerPos++
+		}
+	case "enter":
+		if m.pickerPos < len(m.choices) {
+			choice := m.choices[m.pickerPos]
+			m.llmClient = m.llmClient.WithBackend(choice)
+			m.status = "Switched to " + choice.Label()
+		}
+		m.pickerOpen = false
+	}
+	return m, nil
+}
+
+// renderPicker renders the backend switcher
+func (m model) renderPicker() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render(" Backend and model "))
+	b.WriteString("\n\n")
+
+	if m.choices == nil {
+		b.WriteString(m.spinner.View() + " Looking for backends...\n")
+	}
+	current := m.llmClient.Choice()
+	for i, choice := range m.choices {
+		line := choice.Label()
+		if choice.Backend == current.Backend && choice.Model == current.Model {
+			line += " (active)"
+		}
+		if i == m.pickerPos {
+			b.WriteString(promptStyle.Render("▶ " + line))
+		} else {
+			b.WriteString("  " + line)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render("↑↓: select • Enter: switch • Esc: close"))
+
+	return lipgloss.NewStyle().Padding(1, 2).Render(b.String())
+}
@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// historyGroup is one question and the answers regenerated for it: the
+// history entries from start up to end
+type historyGroup struct {
+	start, end int
+}
+
+// groups splits the history into questions; regenerated answers follow the
+// original and share its question
+func (m model) groups() []historyGroup {
+	var groups []historyGroup
+	for i := 0; i < len(m.history); {
+		end := i + 1
+		for end < len(m.history) && m.history[end].Branch > 0 {
+			end++
+		}
+		groups = append(groups, historyGroup{start: i, end: end})
+		i = end
+	}
+	return groups
+}
+
+// visibleGroups returns the questions matching the list filter
+func (m model) visibleGroups() []historyGroup {
+	if m.filter == "" {
+		return m.groups()
+	}
+	filter := strings.ToLower(m.filter)
+
+	var visible []historyGroup
+	for _, g := range m.groups() {
+		for i := g.start; i < g.end; i++ {
+			h := m.history[i]
+			text := h.Query
+			if h.Answer != nil {
+				text += "\n" + h.Answer.Command
+			}
+			if strings.Contains(strings.ToLower(text), filter) {
+				visible = append(visible, g)
+				break
+			}
+		}
+	}
+	return visible
+}
+
+// focusedGroup returns the question the focused entry belongs to
+func (m model) focusedGroup() historyGroup {
+	idx := m.focusedIndex()
+	for _, g := range m.groups() {
+		if idx >= g.start && idx < g.end {
+			return g
+		}
+	}
+	return historyGroup{}
+}
+
+// selectGroup shows a question and its answers in the detail pane
+func (m *model) selectGroup(g historyGroup) {
+	m.focused = g.start
+	m.confirmRun = false
+	m.status = ""
+	m.viewport.SetContent(m.renderDetail())
+	m.viewport.GotoTop()
+}
+
+// moveSelection moves the list selection by delta visible questions
+func (m *model) moveSelection(delta int) {
+	visible := m.visibleGroups()
+	if len(visible) == 0 {
+		return
+	}
+	cur := m.focusedGroup()
+	pos := len(visible) - 1
+	for i, g := range visible {
+		if g == cur {
+			pos = i
+		}
+	}
+	pos = max(0, min(pos+delta, len(visible)-1))
+	m.selectGroup(visible[pos])
+}
+
+// updateList handles keys while the history list has focus: j/k move, /
+// filters, and Tab, Enter, or Esc return to the input. It reports whether
+// the key was handled; copy, run, and the other Ctrl keys fall through.
+func (m model) updateList(msg tea.KeyMsg) (model, bool) {
+	if m.filtering {
+		switch msg.Type {
+		case tea.KeyEsc:
+			m.filtering = false
+			m.filter = ""
+		case tea.KeyEnter:
+			m.filtering = false
+		case tea.KeyBackspace:
+			if r := []rune(m.filter); len(r) > 0 {
+				m.filter = string(r[:len(r)-1])
+			}
+		case tea.KeyRunes, tea.KeySpace:
+			m.filter += string(msg.Runes)
+		default:
+			return m, false
+		}
+
+		// Keep the selection on a question that's still listed
+		visible := m.visibleGroups()
+		cur := m.focusedGroup()
+		for _, g := range visible {
+			if g == cur {
+				return m, true
+			}
+		}
+		if len(visible) > 0 {
+			m.selectGroup(visible[len(visible)-1])
+		}
+		return m, true
+	}
+
+	switch msg.String() {
+	case "j", "down":
+		m.moveSelection(1)
+	case "k", "up":
+		m.moveSelection(-1)
+	case "g", "home":
+		m.moveSelection(-len(m.history))
+	case "G", "end":
+		m.moveSelection(len(m.history))
+	case "/":
+		m.filtering = true
+		m.filter = ""
+	case "tab", "enter", "esc":
+		m.listFocused = false
+		m.textarea.Focus()
+	default:
+		return m, false
+	}
+	return m, true
+}
+
+// listWidth returns the width of the history list for a terminal width, or
+// 0 when the terminal is too narrow to show it beside the answers
+func listWidth(total int) int {
+	if total < 60 {
+		return 0
+	}
+	return max(20, min(total/4, 40))
+}
+
+// renderList renders the history list pane
+func (m model) renderList(width, height int) string {
+	border := helpStyle.GetForeground()
+	if m.listFocused {
+		border = promptStyle.GetForeground()
+	}
+	box := lipgloss.NewStyle().
+		Width(width).
+		Height(height).
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderRight(true).
+		BorderForeground(border)
+
+	var lines []string
+	if m.filtering || m.filter != "" {
+		lines = append(lines, promptStyle.Render("/"+m.filter))
+		height--
+	}
+
+	visible := m.visibleGroups()
+	if len(visible) == 0 {
+		if len(m.history) == 0 {
+			lines = append(lines, helpStyle.Render("No questions yet"))
+		} else {
+			lines = append(lines, helpStyle.Render("No matches"))
+		}
+		return box.Render(strings.Join(lines, "\n"))
+	}
+
+	cur := m.focusedGroup()
+	sel := len(visible) - 1
+	for i, g := range visible {
+		if g == cur {
+			sel = i
+		}
+	}
+
+	// Scroll so the selection stays in view
+	offset := 0
+	if sel >= height {
+		offset = sel - height + 1
+	}
+	for i := offset; i < len(visible) && i < offset+height; i++ {
+		g := visible[i]
+		label := m.history[g.start].Query
+		if n := g.end - g.start; n > 1 {
+			label = fmt.Sprintf("%s (%d)", label, n)
+		}
+		label = truncateLabel(label, width-3)
+		if i == sel {
+			lines = append(lines, promptStyle.Render("▶ "+label))
+		} else {
+			lines = append(lines, "  "+label)
+		}
+	}
+	return box.Render(strings.Join(lines, "\n"))
+}
+
+// truncateLabel shortens a label to width runes, ending it with an ellipsis
+func truncateLabel(s string, width int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	r := []rune(s)
+	if width < 1 || len(r) <= width {
+		return s
+	}
+	return string(r[:width-1]) + "…"
+}
@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/cliq-cli/cliq/internal/config"
+)
+
+// samplingPreset is a named set of sampling parameters offered in the
+// settings pane
+type samplingPreset struct {
+	name        string
+	temperature float64
+	maxTokens   int
+}
+
+var samplingPresets = []samplingPreset{
+	{name: "precise", temperature: 0.1, maxTokens: 384},
+	{name: "balanced", temperature: 0.3, maxTokens: 512},
+	{name: "creative", temperature: 0.8, maxTokens: 768},
+}
+
+var responseStyles = []string{"concise", "detailed", "minimal"}
+
+// Fields of the settings pane, in display order
+const (
+	settingPreset = iota
+	settingTemperature
+	settingMaxTokens
+	settingStyle
+	settingCount
+)
+
+// tuiSettings are the generation settings the TUI applies to new queries
+type tuiSettings struct {
+	temperature float64
+	maxTokens   int
+	style       string
+}
+
+// settingsFromConfig returns the settings a session starts with
+func settingsFromConfig(cfg *config.Config) tuiSettings {
+	return tuiSettings{
+		temperature: cfg.Model.Temperature,
+		maxTokens:   cfg.Model.MaxTokens,
+		style:       cfg.General.ResponseStyle,
+	}
+}
+
+// preset returns the name of the preset the settings match, or "custom"
+func (s tuiSettings) preset() string {
+	for _, p := range samplingPresets {
+		if math.Abs(p.temperature-s.temperature) < 0.001 && p.maxTokens == s.maxTokens {
+			return p.name
+		}
+	}
+	return "custom"
+}
+
+// adjust steps a field up (dir > 0) or down (dir < 0)
+func (s *tuiSettings) adjust(field, dir int) {
+	switch field {
+	case settingPreset:
+		idx := -1
+		for i, p := range samplingPresets {
+			if p.name == s.preset() {
+				idx = i
+			}
+		}
+		idx = cycle(idx, dir, len(samplingPresets))
+		s.temperature = samplingPresets[idx].temperature
+		s.maxTokens = samplingPresets[idx].maxTokens
+	case settingTemperature:
+		s.temperature = math.Round((s.temperature+0.1*float64(dir))*10) / 10
+		s.temperature = math.Max(0, math.Min(2, s.temperature))
+	case settingMaxTokens:
+		s.maxTokens = max(64, min(4096, s.maxTokens+64*dir))
+	case settingStyle:
+		idx := -1
+		for i, style := range responseStyles {
+			if style == s.style {
+				idx = i
+			}
+		}
+		s.style = responseStyles[cycle(idx, dir, len(responseStyles))]
+	}
+}
+
+// cycle moves idx by dir, wrapping around n items. An idx of -1 (not in the
+// list) moves to the first or last item.
+func cycle(idx, dir, n int) int {
+	if idx < 0 {
+		if dir > 0 {
+			return 0
+		}
+		return n - 1
+	}
+	return (idx + dir + n) % n
+}
+
+// save persists the settings to the config file
+func (s tuiSettings) save() error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	cfg.Model.Temperature = s.temperature
+	cfg.Model.MaxTokens = s.maxTokens
+	cfg.General.ResponseStyle = s.style
+	return cfg.Save()
+}
+
+// updateSettings handles keys while the settings pane is open. Changes
+// apply to the next query; w also writes them to the config file.
+func (m model) updateSettings(msg tea.KeyMsg) (model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "ctrl+s":
+		m.settingsOpen = false
+	case "up", "k":
+		m.settingsField = cycle(m.settingsField, -1, settingCount)
+	case "down", "j", "tab":
+		m.settingsField = cycle(m.settingsField, 1, settingCount)
+	case "left", "h", "-":
+		m.settings.adjust(m.settingsField, -1)
+	case "right", "l", "+", "=":
+		m.settings.adjust(m.settingsField, 1)
+	case "w":
+		if err := m.settings.save(); err != nil {
+			m.status = "Could not save settings: " + err.Error()
+		} else {
+			m.status = "Settings saved to " + config.GetConfigPath()
+		}
+	}
+	return m, nil
+}
+
+// renderSettings renders the settings pane
+func (m model) renderSettings() string {
+	rows := []struct{ label, value string }{
+		{"Preset", m.settings.preset()},
+		{"Temperature", fmt.Sprintf("%.1f", m.settings.temperature)},
+		{"Max tokens", fmt.Sprintf("%d", m.settings.maxTokens)},
+		{"Style", m.settings.style},
+	}
+
+	var b strings.Builder
+	b.WriteString(titleStyle.Render(" Settings "))
+	b.WriteString("\n\n")
+	for i, row := range rows {
+		line := fmt.Sprintf("%-12s ‹ %s ›", row.label, row.value)
+		if i == m.settingsField {
+			b.WriteString(promptStyle.Render("▶ " + line))
+		} else {
+			b.WriteString("  " + line)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render("↑↓: field • ←→: change • w: save to config • Esc: close"))
+
+	return lipgloss.NewStyle().Padding(1, 2).Render(b.String())
+}
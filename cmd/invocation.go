@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/cliq-cli/cliq/internal/config"
+)
+
+// invocation is the profile selected by the name cliq was run as, through a
+// symlink such as vimhow; nil when run as cliq
+var invocation *config.Invocation
+
+// routeInvocation looks up the name cliq was run as and, when it selects an
+// invocation, turns the root command into a plain question asker for it:
+// every argument is part of the question, so `vimhow delete a line` needs
+// no quotes and can't be taken for a subcommand
+func routeInvocation(argv0 string) {
+	name := strings.TrimSuffix(filepath.Base(argv0), ".exe")
+	if name == "" || name == "cliq" {
+		return
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = config.Default()
+	}
+	inv, ok := cfg.GetInvocation(name)
+	if !ok {
+		return
+	}
+
+	invocation = &inv
+	rootCmd.Use = name + " <question>"
+	rootCmd.Long = fmt.Sprintf("%s is cliq for one-shot questions, without quotes:\n\n  %s how do I ...", name, name)
+	if inv.Prefix != "" {
+		rootCmd.Long += fmt.Sprintf("\n\nEach question is asked as %q.", inv.Prefix+" <question>")
+	}
+	rootCmd.Args = cobra.ArbitraryArgs
+	rootCmd.ResetCommands()
+}
+
+// applyInvocation adapts a one-shot question and its output format to the
+// invocation, if any. A format given on the command line still wins.
+func applyInvocation(cmd *cobra.Command, query string) string {
+	if invocation == nil {
+		return query
+	}
+	if invocation.Format != "" && !cmd.Flags().Changed("format") && !cmd.Flags().Changed("quiet") {
+		viper.Set("format", invocation.Format)
+	}
+	if invocation.Prefix != "" {
+		query = invocation.Prefix + " " + query
+	}
+	return query
+}
+
+// applyInvocationStyle sets the response style the invocation asks for
+func applyInvocationStyle(cfg *config.Config) {
+	if invocation != nil && invocation.Style != "" {
+		cfg.General.ResponseStyle = invocation.Style
+	}
+}
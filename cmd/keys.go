@@ -0,0 +1,394 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+
+	"github.com/cliq-cli/cliq/internal/config"
+	"github.com/cliq-cli/cliq/internal/keynotation"
+	"github.com/cliq-cli/cliq/internal/parser"
+	"github.com/cliq-cli/cliq/internal/terminal"
+	"github.com/cliq-cli/cliq/internal/theme"
+)
+
+// keysCmd browses the parsed keymaps
+var keysCmd = &cobra.Command{
+	Use:   "keys [filter]",
+	Short: "Browse your Neovim and tmux keymaps",
+	Long: `Browse every keymap cliq parsed from your Neovim and tmux configs, with its
+mode, keys, action, description, and the file it comes from. Type to filter:
+each word must match, in order of its characters, somewhere in the entry.
+
+With --plain, or when output isn't a terminal, the keymaps matching the
+filter are printed instead.
+
+Examples:
+  cliq keys
+  cliq keys split
+  cliq keys --plain telescope`,
+	RunE: runKeys,
+}
+
+func init() {
+	rootCmd.AddCommand(keysCmd)
+
+	keysCmd.Flags().Bool("plain", false, "print the keymaps instead of opening the browser")
+}
+
+// keyEntry is one keymap as the browser shows it
+type keyEntry struct {
+	tool        string // nvim or tmux
+	mode        string // Neovim mode, or tmux key table
+	keys        string
+	action      string
+	description string
+	source      string
+}
+
+// text is what the filter matches against
+func (e keyEntry) text() string {
+	return strings.ToLower(strings.Join([]string{e.tool, e.mode, e.keys, e.action, e.description, e.source}, " "))
+}
+
+func runKeys(cmd *cobra.Command, args []string) error {
+	plain, _ := cmd.Flags().GetBool("plain")
+
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = config.Default()
+	}
+
+	var nvimConfig *parser.NvimConfig
+	if cfg.Nvim.ConfigPath != "" {
+		if nvimConfig, err = parser.ParseNvimConfig(cfg.Nvim.ConfigPath); err != nil && verbose {
+			fmt.Fprintf(os.Stderr, "Warning: could not parse nvim config: %v\n", err)
+		}
+	}
+	var tmuxConfig *parser.TmuxConfig
+	if cfg.Tmux.ConfigPath != "" {
+		if tmuxConfig, err = parser.ParseTmuxConfig(cfg.Tmux.ConfigPath); err != nil && verbose {
+			fmt.Fprintf(os.Stderr, "Warning: could not parse tmux config: %v\n", err)
+		}
+	}
+	entries := keyEntries(nvimConfig, tmuxConfig, cfg.General.KeyNotation)
+	if len(entries) == 0 {
+		return fmt.Errorf("no keymaps found (run cliq init, or check cliq config show)")
+	}
+
+	filter := strings.Join(args, " ")
+	if plain || !terminal.IsTerminal(os.Stdout) {
+		printKeys(entries, filterKeys(entries, filter))
+		return nil
+	}
+
+	t, err := theme.Load(cfg.TUI.Theme)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v; using the %s theme\n", err, t.Name)
+	}
+	applyTheme(t)
+
+	input := textinput.New()
+	input.Prompt = "/ "
+	input.Placeholder = "filter keymaps"
+	input.SetValue(filter)
+	input.Focus()
+
+	m := keysModel{
+		entries: entries,
+		visible: filterKeys(entries, filter),
+		input:   input,
+		width:   80,
+		height:  24,
+	}
+	p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithContext(cmd.Context()))
+	_, err = p.Run()
+	return err
+}
+
+// keyEntries lists the Neovim keymaps and then the tmux bindings, with keys
+// written in the configured notation
+func keyEntries(nvimConfig *parser.NvimConfig, tmuxConfig *parser.TmuxConfig, notation string) []keyEntry {
+	home, _ := os.UserHomeDir()
+	source := func(path, from string) string {
+		if path == "" {
+			if from != "" {
+				return "[" + from + "]"
+			}
+			return ""
+		}
+		if rel, err := filepath.Rel(home, path); err == nil && home != "" && !strings.HasPrefix(rel, "..") {
+			return filepath.Join("~", rel)
+		}
+		return path
+	}
+
+	var entries []keyEntry
+	if nvimConfig != nil {
+		for _, km := range nvimConfig.Keymaps {
+			from := km.Distro
+			if km.Plugin != "" {
+				from = km.Plugin
+			}
+			entries = append(entries, keyEntry{
+				tool:        "nvim",
+				mode:        km.Mode,
+				keys:        keynotation.Normalize(km.Keys, notation),
+				action:      km.Rhs,
+				description: km.Description,
+				source:      source(km.Source, from),
+			})
+		}
+	}
+	if tmuxConfig != nil {
+		prefix := keynotation.Normalize(tmuxConfig.Prefix, notation)
+		for _, km := range tmuxConfig.Keymaps {
+			keys := keynotation.Normalize(km.Key, notation)
+			if km.Table == "prefix" {
+				keys = prefix + " " + keys
+			}
+			description := km.Description
+			if km.Condition != "" {
+				description = strings.TrimSpace(description + " (if " + km.Condition + ")")
+			}
+			entries = append(entries, keyEntry{
+				tool:        "tmux",
+				mode:        km.Table,
+				keys:        keys,
+				action:      km.Command,
+				description: description,
+				source:      source(km.Source, km.Plugin),
+			})
+		}
+	}
+	return entries
+}
+
+// filterKeys returns the indexes of the entries matching filter, those
+// containing every word as written before those only matching fuzzily
+func filterKeys(entries []keyEntry, filter string) []int {
+	words := strings.Fields(strings.ToLower(filter))
+	var exact, fuzzy []int
+	for i, e := range entries {
+		text := e.text()
+		contains, matches := true, true
+		for _, w := range words {
+			if !strings.Contains(text, w) {
+				contains = false
+				if !fuzzyMatch(text, w) {
+					matches = false
+					break
+				}
+			}
+		}
+		switch {
+		case contains:
+			exact = append(exact, i)
+		case matches:
+			fuzzy = append(fuzzy, i)
+		}
+	}
+	return append(exact, fuzzy...)
+}
+
+// fuzzyMatch reports whether the characters of pattern appear in text in order
+func fuzzyMatch(text, pattern string) bool {
+	for _, r := range pattern {
+		idx := strings.IndexRune(text, r)
+		if idx < 0 {
+			return false
+		}
+		text = text[idx+len(string(r)):]
+	}
+	return true
+}
+
+// printKeys prints the visible entries, one per line
+func printKeys(entries []keyEntry, visible []int) {
+	for _, i := range visible {
+		e := entries[i]
+		line := fmt.Sprintf("%-4s %-12s %-20s %s", e.tool, e.mode, e.keys, e.action)
+		if e.description != "" {
+			line += "  # " + e.description
+		}
+		if e.source != "" {
+			line += "  (" + e.source + ")"
+		}
+		fmt.Println(strings.TrimRight(line, " "))
+	}
+}
+
+// keysModel is the state of the keymap browser
+type keysModel struct {
+	entries []keyEntry
+	visible []int // indexes into entries matching the filter
+	input   textinput.Model
+	cursor  int // position in visible
+	offset  int // first visible row shown
+	width   int
+	height  int
+	status  string
+}
+
+var keysHeaderStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("99"))
+
+func (m keysModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m keysModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.input.Width = max(m.width-4, 10)
+		m.scroll()
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyCtrlC:
+			return m, tea.Quit
+		case tea.KeyEsc:
+			if m.input.Value() == "" {
+				return m, tea.Quit
+			}
+			m.input.SetValue("")
+			m.refilter()
+			return m, nil
+		case tea.KeyUp, tea.KeyCtrlP, tea.KeyCtrlK:
+			m.move(-1)
+			return m, nil
+		case tea.KeyDown, tea.KeyCtrlN, tea.KeyCtrlJ:
+			m.move(1)
+			return m, nil
+		case tea.KeyPgUp:
+			m.move(-m.rows())
+			return m, nil
+		case tea.KeyPgDown:
+			m.move(m.rows())
+			return m, nil
+		case tea.KeyEnter, tea.KeyCtrlY:
+			if len(m.visible) == 0 {
+				return m, nil
+			}
+			e := m.entries[m.visible[m.cursor]]
+			if err := terminal.Copy(e.keys); err != nil {
+				m.status = "Could not copy: " + err.Error()
+			} else {
+				m.status = "Copied " + e.keys
+			}
+			return m, nil
+		}
+
+		var cmd tea.Cmd
+		before := m.input.Value()
+		m.input, cmd = m.input.Update(msg)
+		if m.input.Value() != before {
+			m.refilter()
+		}
+		return m, cmd
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+// refilter applies the filter and moves the cursor back to the first match
+func (m *keysModel) refilter() {
+	m.visible = filterKeys(m.entries, m.input.Value())
+	m.cursor, m.offset = 0, 0
+	m.status = ""
+}
+
+// move moves the cursor by delta rows, scrolling to keep it shown
+func (m *keysModel) move(delta int) {
+	if len(m.visible) == 0 {
+		return
+	}
+	m.cursor = min(max(m.cursor+delta, 0), len(m.visible)-1)
+	m.status = ""
+	m.scroll()
+}
+
+// scroll keeps the cursor within the rows shown
+func (m *keysModel) scroll() {
+	rows := m.rows()
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+rows {
+		m.offset = m.cursor - rows + 1
+	}
+}
+
+// rows is how many keymaps fit between the filter and the details
+func (m keysModel) rows() int {
+	// title, input, header, and blank lines above; details and help below
+	return max(m.height-11, 1)
+}
+
+func (m keysModel) View() string {
+	var sb strings.Builder
+	sb.WriteString(titleStyle.Render(fmt.Sprintf("cliq keys · %d/%d", len(m.visible), len(m.entries))))
+	sb.WriteString("\n\n")
+	sb.WriteString(m.input.View())
+	sb.WriteString("\n\n")
+
+	keysWidth := 20
+	actionWidth := max(m.width-keysWidth-23, 10)
+	row := func(tool, mode, keys, action string) string {
+		return fmt.Sprintf("%-4s %-12s %-*s %s", tool, truncateLabel(mode, 12), keysWidth, truncateLabel(keys, keysWidth), truncateLabel(action, actionWidth))
+	}
+	sb.WriteString(keysHeaderStyle.Render("  " + row("", "mode", "keys", "action")))
+	sb.WriteString("\n")
+
+	end := min(m.offset+m.rows(), len(m.visible))
+	for pos := m.offset; pos < end; pos++ {
+		e := m.entries[m.visible[pos]]
+		action := e.action
+		if e.description != "" {
+			action = e.description
+		}
+		line := row(e.tool, e.mode, e.keys, action)
+		if pos == m.cursor {
+			sb.WriteString(promptStyle.Render("> " + line))
+		} else {
+			sb.WriteString("  " + line)
+		}
+		sb.WriteString("\n")
+	}
+	for pos := end; pos < m.offset+m.rows(); pos++ {
+		sb.WriteString("\n")
+	}
+
+	// Everything known about the selected keymap
+	sb.WriteString("\n")
+	if len(m.visible) == 0 {
+		sb.WriteString(helpStyle.Render("No keymaps match"))
+		sb.WriteString("\n\n\n")
+	} else {
+		e := m.entries[m.visible[m.cursor]]
+		fmt.Fprintf(&sb, "%s  %s\n", keysHeaderStyle.Render(e.keys), truncateLabel(e.action, max(m.width-len(e.keys)-4, 10)))
+		description := e.description
+		if description == "" {
+			description = "(no description)"
+		}
+		sb.WriteString(truncateLabel(description, m.width) + "\n")
+		sb.WriteString(helpStyle.Render(truncateLabel(e.source, m.width)) + "\n")
+	}
+
+	sb.WriteString("\n")
+	if m.status != "" {
+		sb.WriteString(helpStyle.Render(m.status))
+	} else {
+		sb.WriteString(helpStyle.Render("Type to filter • ↑/↓: move • Enter: copy keys • Esc: clear/quit"))
+	}
+	return sb.String()
+}
@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+
+	"github.com/cliq-cli/cliq/internal/knowledge"
+	"github.com/cliq-cli/cliq/internal/llm"
+	"github.com/cliq-cli/cliq/internal/store"
+)
+
+const (
+	// lessonAnswerScore is the similarity above which a learned answer is
+	// returned directly instead of querying the model
+	lessonAnswerScore = 0.8
+	// lessonContextScore is the similarity above which a learned answer is
+	// added to the prompt as context
+	lessonContextScore = 0.4
+	// maxLessonContext limits how many learned answers go into a prompt
+	maxLessonContext = 3
+)
+
+// learnCmd represents the learn command
+var learnCmd = &cobra.Command{
+	Use:   "learn <question> <answer>",
+	Short: "Teach cliq your own answer to a question",
+	Long: `Store your own answer to a question. When you later ask something that
+closely matches, cliq returns your answer directly; when a question is only
+related, your answer is given to the model as high-priority context.
+
+Subcommands:
+  list    List learned answers
+  remove  Remove a learned answer
+
+Examples:
+  cliq learn "deploy staging" "make deploy ENV=staging"
+  cliq learn "reload tmux config" "prefix + r"
+  cliq learn list
+  cliq learn remove 2`,
+	Args: cobra.ExactArgs(2),
+	RunE: runLearn,
+}
+
+// learnListCmd represents the learn list command
+var learnListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List learned answers",
+	RunE:  runLearnList,
+}
+
+// learnRemoveCmd represents the learn remove command
+var learnRemoveCmd = &cobra.Command{
+	Use:   "remove <id>",
+	Short: "Remove a learned answer",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runLearnRemove,
+}
+
+func init() {
+	rootCmd.AddCommand(learnCmd)
+	learnCmd.AddCommand(learnListCmd)
+	learnCmd.AddCommand(learnRemoveCmd)
+}
+
+func runLearn(cmd *cobra.Command, args []string) error {
+	question := strings.TrimSpace(args[0])
+	answer := strings.TrimSpace(args[1])
+	if question == "" || answer == "" {
+		return fmt.Errorf("both a question and an answer are required")
+	}
+
+	lessons, err := store.LoadLessons()
+	if err != nil {
+		return fmt.Errorf("failed to load learned answers: %w", err)
+	}
+
+	lesson := lessons.Add(question, answer)
+	if err := lessons.Save(); err != nil {
+		return fmt.Errorf("failed to save learned answers: %w", err)
+	}
+
+	successStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	fmt.Println(successStyle.Render(fmt.Sprintf("✓ Learned #%d: %s → %s", lesson.ID, lesson.Question, lesson.Answer)))
+	return nil
+}
+
+func runLearnList(cmd *cobra.Command, args []string) error {
+	lessons, err := store.LoadLessons()
+	if err != nil {
+		return fmt.Errorf("failed to load learned answers: %w", err)
+	}
+
+	if len(lessons.Items) == 0 {
+		fmt.Println("No learned answers. Add one with: cliq learn \"deploy staging\" \"make deploy ENV=staging\"")
+		return nil
+	}
+
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("99"))
+	cmdStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("39"))
+	for _, lesson := range lessons.Items {
+		fmt.Printf("%s %s → %s\n", labelStyle.Render(fmt.Sprintf("#%d", lesson.ID)), lesson.Question, cmdStyle.Render(lesson.Answer))
+	}
+	return nil
+}
+
+func runLearnRemove(cmd *cobra.Command, args []string) error {
+	id, err := strconv.Atoi(strings.TrimPrefix(args[0], "#"))
+	if err != nil {
+		return fmt.Errorf("invalid id: %s", args[0])
+	}
+
+	lessons, err := store.LoadLessons()
+	if err != nil {
+		return fmt.Errorf("failed to load learned answers: %w", err)
+	}
+
+	if !lessons.Remove(id) {
+		return fmt.Errorf("no learned answer with id %d", id)
+	}
+	if err := lessons.Save(); err != nil {
+		return fmt.Errorf("failed to save learned answers: %w", err)
+	}
+
+	fmt.Printf("Removed #%d\n", id)
+	return nil
+}
+
+// applyLessons matches the query against the user's learned answers. A close
+// match is returned as a ready-made response in the model's output format;
+// otherwise related answers are added to the prompt context, unless in
+// privacy mode.
+func applyLessons(pctx *llm.PromptContext, query string) (string, bool) {
+	lessons, err := store.LoadLessons()
+	if err != nil {
+		if verbose {
+			fmt.Fprintf(os.Stderr, "Warning: could not load learned answers: %v\n", err)
+		}
+		return "", false
+	}
+
+	type scored struct {
+		lesson store.Lesson
+		score  float64
+	}
+	var related []scored
+	for _, lesson := range lessons.Items {
+		score := knowledge.Similarity(query, lesson.Question)
+		if score >= lessonContextScore {
+			related = append(related, scored{lesson, score})
+		}
+	}
+	if len(related) == 0 {
+		return "", false
+	}
+
+	sort.SliceStable(related, func(i, j int) bool {
+		return related[i].score > related[j].score
+	})
+
+	if best := related[0]; best.score >= lessonAnswerScore {
+		return fmt.Sprintf("Command: %s\nExplanation: Your saved answer for %q (from cliq learn).",
+			best.lesson.Answer, best.lesson.Question), true
+	}
+
+	if noContext {
+		return "", false
+	}
+	for i, r := range related {
+		if i == maxLessonContext {
+			break
+		}
+		pctx.Learned = append(pctx.Learned, fmt.Sprintf("%q → %s", r.lesson.Question, r.lesson.Answer))
+	}
+	return "", false
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+
+	"github.com/cliq-cli/cliq/internal/config"
+	"github.com/cliq-cli/cliq/internal/lint"
+	"github.com/cliq-cli/cliq/internal/parser"
+	"github.com/cliq-cli/cliq/internal/terminal"
+)
+
+// lintCmd checks the Neovim and tmux configs for common mistakes
+var lintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Check your Neovim and tmux configs for common mistakes",
+	Long: `Check your Neovim and tmux configs for mistakes cliq's parsers can see:
+
+  - tmux options and syntax your tmux version removed, such as mode-mouse,
+    status-utf8, bind -t vi-copy, and the -fg/-bg/-attr options
+  - the tmux mouse option set both on and off, or toggled without a value
+  - Neovim keymaps defined twice, and keymaps without a desc
+  - lazy.nvim specs with fields lazy.nvim doesn't have, such as packer's
+    requires and run, or values of the wrong type, such as lazy = "true"
+
+The command fails if any problem is an error.
+
+Examples:
+  cliq lint
+  cliq lint --json`,
+	Args: cobra.NoArgs,
+	RunE: runLint,
+}
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+
+	lintCmd.Flags().Bool("json", false, "print the problems as JSON")
+}
+
+func runLint(cmd *cobra.Command, args []string) error {
+	asJSON, _ := cmd.Flags().GetBool("json")
+
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = config.Default()
+	}
+
+	var nvimConfig *parser.NvimConfig
+	if cfg.Nvim.ConfigPath != "" {
+		if nvimConfig, err = parser.ParseNvimConfig(cfg.Nvim.ConfigPath); err != nil && verbose {
+			fmt.Fprintf(os.Stderr, "Warning: could not parse nvim config: %v\n", err)
+		}
+	}
+	var tmuxConfig *parser.TmuxConfig
+	if cfg.Tmux.ConfigPath != "" {
+		if tmuxConfig, err = parser.ParseTmuxConfig(cfg.Tmux.ConfigPath); err != nil && verbose {
+			fmt.Fprintf(os.Stderr, "Warning: could not parse tmux config: %v\n", err)
+		}
+	}
+	if nvimConfig == nil && tmuxConfig == nil {
+		return fmt.Errorf("no Neovim or tmux config to lint (run cliq init)")
+	}
+
+	version := ""
+	if tmuxConfig != nil {
+		version = terminal.TmuxVersion()
+	}
+	problems := lint.Lint(nvimConfig, tmuxConfig, version)
+	errors := 0
+	for _, p := range problems {
+		if p.Level == lint.Error {
+			errors++
+		}
+	}
+
+	if asJSON {
+		if problems == nil {
+			problems = []lint.Problem{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(problems); err != nil {
+			return err
+		}
+	} else {
+		printLint(problems)
+	}
+
+	if errors > 0 {
+		return fmt.Errorf("%d error(s) found", errors)
+	}
+	return nil
+}
+
+// printLint prints each problem with its level and location
+func printLint(problems []lint.Problem) {
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("42"))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	levels := map[lint.Level]string{
+		lint.Error:   lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("196")).Render("error  "),
+		lint.Warning: lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("214")).Render("warning"),
+		lint.Info:    lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render("info   "),
+	}
+
+	fmt.Println(titleStyle.Render("Config lint"))
+	fmt.Println()
+	if len(problems) == 0 {
+		fmt.Println("No problems found.")
+		return
+	}
+	for _, p := range problems {
+		fmt.Printf("%s [%s] %s\n", levels[p.Level], p.Tool, p.Message)
+		if loc := p.Location(); loc != "" {
+			fmt.Printf("        %s\n", dimStyle.Render(loc))
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cliq-cli/cliq/internal/config"
+	"github.com/cliq-cli/cliq/internal/response"
+	"github.com/cliq-cli/cliq/internal/terminal"
+)
+
+// notifyTitleLen is how much of the question a notification's title shows
+const notifyTitleLen = 60
+
+// shouldNotify reports whether an answer that took took is notified, with
+// focused telling whether the terminal is in front. A terminal whose focus
+// can't be told counts as in front, so only when = "always" notifies there.
+func shouldNotify(n config.NotifyConfig, took time.Duration, focused func() (bool, bool)) bool {
+	if n.When == "off" || took < time.Duration(n.AfterSeconds)*time.Second {
+		return false
+	}
+	if n.When == "always" {
+		return true
+	}
+	isFocused, known := focused()
+	return known && !isFocused
+}
+
+// notifyAnswer shows a desktop notification with the command answering a
+// query
+func notifyAnswer(query string, resp *response.Response) error {
+	title := "cliq: " + query
+	if len([]rune(title)) > notifyTitleLen {
+		title = string([]rune(title)[:notifyTitleLen-1]) + "…"
+	}
+	body := resp.Command
+	if body == "" {
+		body, _, _ = strings.Cut(resp.Explanation, "\n")
+	}
+	if body == "" {
+		body = "Your answer is ready"
+	}
+	return terminal.Notify(title, body)
+}
+
+// notifySlowAnswer notifies a one-shot answer that took long enough, when
+// a person ran cliq rather than a script or an editor
+func notifySlowAnswer(cfg *config.Config, query string, resp *response.Response, started time.Time) {
+	if !terminal.IsTerminal(os.Stderr) {
+		return
+	}
+	if !shouldNotify(cfg.Notify, time.Since(started), terminal.Focused) {
+		return
+	}
+	if err := notifyAnswer(query, resp); err != nil && verbose {
+		fmt.Fprintf(os.Stderr, "Warning: could not show a notification: %v\n", err)
+	}
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/cliq-cli/cliq/internal/config"
+	"github.com/cliq-cli/cliq/internal/metrics"
+)
+
+// processStart is set when the cmd package is initialized, as close to
+// process start as cliq can observe
+var processStart = time.Now()
+
+// defaultProfileQuery is used when cliq profile is run without a query
+const defaultProfileQuery = "how do I split a tmux pane vertically"
+
+// profileCmd represents the profile command
+var profileCmd = &cobra.Command{
+	Use:   "profile [query]",
+	Short: "Show where time goes when cliq answers a query",
+	Long: `Run a query and break down cliq's startup and query latency by phase:
+config load, cache, config parsing, prompt build, backend init, backend
+query, and rendering.
+
+Examples:
+  cliq profile
+  cliq profile "how do I delete a line"
+  cliq profile --no-cache "search and replace"`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runProfile,
+}
+
+func init() {
+	rootCmd.AddCommand(profileCmd)
+
+	profileCmd.Flags().Bool("show", false, "print the answer after the profile")
+	profileCmd.Flags().Bool("no-cache", false, "skip config cache, to profile parsing the configs")
+}
+
+func runProfile(cmd *cobra.Command, args []string) error {
+	query := defaultProfileQuery
+	if len(args) > 0 {
+		query = args[0]
+	}
+	show, _ := cmd.Flags().GetBool("show")
+	if noCache, _ := cmd.Flags().GetBool("no-cache"); noCache {
+		viper.Set("no-cache", true)
+	}
+
+	prof := metrics.NewProfile(processStart)
+	prof.Mark("startup")
+
+	cfg, err := config.Load()
+	if err != nil {
+		if verbose {
+			fmt.Fprintf(os.Stderr, "Warning: could not load config: %v\n", err)
+		}
+		cfg = config.Default()
+	}
+	prof.Mark("config load")
+
+	output, queryErr := answerQuery(cmd.Context(), query, cfg, prof)
+
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("42"))
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("99"))
+	warnStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+
+	fmt.Println(titleStyle.Render("Cliq Profile"))
+	fmt.Println()
+	fmt.Printf("%s %s\n\n", labelStyle.Render("Query:"), query)
+
+	total := prof.Total()
+	for _, phase := range prof.Phases {
+		share := 0.0
+		if total > 0 {
+			share = float64(phase.Duration) / float64(total)
+		}
+		bar := strings.Repeat("█", int(share*30+0.5))
+		line := fmt.Sprintf("  %-18s %10s %5.1f%%  %s", phase.Name, formatPhaseDuration(phase.Duration), share*100, bar)
+		if share >= 0.5 && total > time.Second {
+			line = warnStyle.Render(line)
+		}
+		fmt.Println(line)
+	}
+	fmt.Printf("  %-18s %10s\n", "total", formatPhaseDuration(total))
+
+	if queryErr != nil {
+		fmt.Println()
+		fmt.Println(warnStyle.Render(fmt.Sprintf("Query failed after the last phase shown: %v", queryErr)))
+		return nil
+	}
+
+	if show {
+		fmt.Println()
+		fmt.Println(output)
+	}
+	return nil
+}
+
+// formatPhaseDuration renders a duration with a precision suited to its size
+func formatPhaseDuration(d time.Duration) string {
+	switch {
+	case d < time.Millisecond:
+		return fmt.Sprintf("%dµs", d.Microseconds())
+	case d < time.Second:
+		return fmt.Sprintf("%.1fms", float64(d.Microseconds())/1000)
+	default:
+		return fmt.Sprintf("%.2fs", d.Seconds())
+	}
+}
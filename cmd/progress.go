@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/cliq-cli/cliq/internal/terminal"
+)
+
+// progressTick is how often the spinner is redrawn
+const progressTick = 100 * time.Millisecond
+
+// Spinner frames, for emoji-capable terminals and for --ascii
+var (
+	spinnerFrames      = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+	asciiSpinnerFrames = []string{"|", "/", "-", "\\"}
+)
+
+// queryProgress is the spinner shown while a one-shot query is answered,
+// nil when none is
+var queryProgress *progress
+
+// progress is a spinner on stderr with what a query is doing and for how
+// long it has been going. A nil progress shows nothing.
+type progress struct {
+	mu     sync.Mutex
+	phase  string
+	paused bool
+	shown  bool
+	frames []string
+	start  time.Time
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// startProgress starts a spinner on stderr, which stops when ctx is
+// canceled, as by Ctrl-C. It returns nil, showing nothing, when stderr
+// isn't a terminal or --verbose prints what's happening instead.
+func startProgress(ctx context.Context, icons string) *progress {
+	if verbose || !terminal.IsTerminal(os.Stderr) {
+		return nil
+	}
+	p := &progress{
+		frames: spinnerFrames,
+		start:  time.Now(),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	if icons == "ascii" || icons == "none" {
+		p.frames = asciiSpinnerFrames
+	}
+
+	go func() {
+		defer close(p.done)
+		ticker := time.NewTicker(progressTick)
+		defer ticker.Stop()
+		for frame := 0; ; frame++ {
+			p.draw(frame)
+			select {
+			case <-ticker.C:
+			case <-p.stop:
+				p.clear()
+				return
+			case <-ctx.Done():
+				p.clear()
+				return
+			}
+		}
+	}()
+	return p
+}
+
+// Phase sets what the query is doing, showing the spinner again after
+// Pause
+func (p *progress) Phase(phase string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.phase, p.paused = phase, false
+}
+
+// Pause hides the spinner until the next Phase, so something else can be
+// printed or asked on the terminal
+func (p *progress) Pause() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.paused = true
+	p.mu.Unlock()
+	p.clear()
+}
+
+// Stop hides the spinner for good, before the answer is printed
+func (p *progress) Stop() {
+	if p == nil {
+		return
+	}
+	select {
+	case <-p.stop:
+	default:
+		close(p.stop)
+	}
+	<-p.done
+}
+
+// draw writes the spinner over the line it's on
+func (p *progress) draw(frame int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.paused || p.phase == "" {
+		return
+	}
+	elapsed := fmt.Sprintf("%.1fs", time.Since(p.start).Seconds())
+	if terminal.ColorEnabled(os.Stderr) {
+		elapsed = lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render(elapsed)
+	}
+	fmt.Fprintf(os.Stderr, "\r\033[K%s %s... %s", p.frames[frame%len(p.frames)], p.phase, elapsed)
+	p.shown = true
+}
+
+// clear erases the spinner's line, if it's been drawn
+func (p *progress) clear() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.shown {
+		fmt.Fprint(os.Stderr, "\r\033[K")
+		p.shown = false
+	}
+}
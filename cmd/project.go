@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+
+	"github.com/cliq-cli/cliq/internal/config"
+	"github.com/cliq-cli/cliq/internal/project"
+	"github.com/cliq-cli/cliq/internal/store"
+)
+
+// projectCmd represents the project command
+var projectCmd = &cobra.Command{
+	Use:   "project",
+	Short: "Choose whether this project's context goes into prompts",
+	Long: `A repository can ship a .cliq/context.md, and its .cliq.toml can name
+context_files, describing how to build and work on it. Whoever wrote the
+repository wrote those files, and what they say steers the commands cliq
+suggests, so they're only sent with queries once you've trusted them.
+
+Trust is recorded per file with a hash of its contents: a file that changes
+afterwards, say after a git pull, isn't used until it's trusted again.
+
+Subcommands:
+  trust    Send this project's context files with queries
+  untrust  Stop sending them
+
+Examples:
+  cliq context project
+  cliq project trust`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// projectTrustCmd represents the project trust command
+var projectTrustCmd = &cobra.Command{
+	Use:   "trust",
+	Short: "Send this project's context files with queries",
+	Args:  cobra.NoArgs,
+	RunE:  runProjectTrust,
+}
+
+// projectUntrustCmd represents the project untrust command
+var projectUntrustCmd = &cobra.Command{
+	Use:   "untrust",
+	Short: "Stop sending this project's context files with queries",
+	Args:  cobra.NoArgs,
+	RunE:  runProjectUntrust,
+}
+
+func init() {
+	rootCmd.AddCommand(projectCmd)
+	projectCmd.AddCommand(projectTrustCmd)
+	projectCmd.AddCommand(projectUntrustCmd)
+}
+
+// projectFiles returns the context files the project in the working
+// directory ships: its .cliq/context.md and the context_files its
+// .cliq.toml names
+func projectFiles() ([]*project.Context, error) {
+	var files []*project.Context
+	if proj := findProjectContext(); proj != nil {
+		files = append(files, proj)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	if cfg.ContextFilesFromProject() {
+		for _, path := range cfg.ContextFilePaths() {
+			file, err := project.ReadContextFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("could not read context file: %w", err)
+			}
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+func runProjectTrust(cmd *cobra.Command, args []string) error {
+	files, err := projectFiles()
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		fmt.Printf("No %s or project context_files found in this directory or its parents.\n", project.ContextFile)
+		return nil
+	}
+
+	trusted, err := store.LoadTrustedFiles()
+	if err != nil {
+		return fmt.Errorf("failed to load trusted files: %w", err)
+	}
+	for _, file := range files {
+		trusted.Trust(file.Path, file.SHA256)
+	}
+	if err := trusted.Save(); err != nil {
+		return fmt.Errorf("failed to save trusted files: %w", err)
+	}
+
+	successStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	for _, file := range files {
+		fmt.Println(successStyle.Render("✓ Trusted " + file.Path))
+	}
+	return nil
+}
+
+func runProjectUntrust(cmd *cobra.Command, args []string) error {
+	files, err := projectFiles()
+	if err != nil {
+		return err
+	}
+
+	trusted, err := store.LoadTrustedFiles()
+	if err != nil {
+		return fmt.Errorf("failed to load trusted files: %w", err)
+	}
+	removed := 0
+	for _, file := range files {
+		if trusted.Untrust(file.Path) {
+			fmt.Println("Untrusted " + file.Path)
+			removed++
+		}
+	}
+	if removed == 0 {
+		fmt.Println("Nothing in this project is trusted.")
+		return nil
+	}
+	return trusted.Save()
+}
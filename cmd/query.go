@@ -1,54 +1,216 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
+	"golang.org/x/sync/errgroup"
 
 	"github.com/cliq-cli/cliq/internal/config"
+	"github.com/cliq-cli/cliq/internal/knowledge"
 	"github.com/cliq-cli/cliq/internal/llm"
+	"github.com/cliq-cli/cliq/internal/metrics"
 	"github.com/cliq-cli/cliq/internal/parser"
+	"github.com/cliq-cli/cliq/internal/project"
 	"github.com/cliq-cli/cliq/internal/response"
+	"github.com/cliq-cli/cliq/internal/shelltype"
+	"github.com/cliq-cli/cliq/internal/terminal"
 )
 
 // executeQuery runs the query through the LLM and displays the response
-func executeQuery(query string, cfg *config.Config) error {
+func executeQuery(ctx context.Context, query string, cfg *config.Config) error {
+	started := time.Now()
+	queryProgress = startProgress(ctx, cfg.TUI.Icons)
+	defer queryProgress.Stop()
+	resp, err := answerResponse(ctx, query, cfg, nil)
+	queryProgress.Stop()
+	if err != nil {
+		return err
+	}
+	output, err := formatAnswer(resp, nil)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(output)
+	recordQuery(cfg, query, resp)
+	notifySlowAnswer(cfg, query, resp, started)
+	runAfterHooks(ctx, cfg.Hooks, query, resp, os.Stderr)
+	if applyAnswer {
+		return applyCommand(cfg, resp.Command)
+	}
+	return nil
+}
+
+// answerQuery runs the query pipeline and returns the formatted response.
+// When prof is non-nil, each phase of the pipeline is timed.
+func answerQuery(ctx context.Context, query string, cfg *config.Config, prof *metrics.Profile) (string, error) {
+	resp, err := answerResponse(ctx, query, cfg, prof)
+	if err != nil {
+		return "", err
+	}
+	return formatAnswer(resp, prof)
+}
+
+// formatAnswer formats a response in the --format asked for
+func formatAnswer(resp *response.Response, prof *metrics.Profile) (string, error) {
+	format := viper.GetString("format")
+	output, err := formatOutput(resp, format)
+	if errors.Is(err, ErrNoCommand) {
+		return "", err
+	} else if err != nil {
+		return "", fmt.Errorf("failed to format response: %w", err)
+	}
+	prof.Mark("render")
+
+	return output, nil
+}
+
+// answerResponse runs the query pipeline up to the answer, adapted to the
+// user's setup but not yet formatted
+func answerResponse(ctx context.Context, query string, cfg *config.Config, prof *metrics.Profile) (*response.Response, error) {
+	queryProgress.Phase("Parsing configs")
+	return answerWithContext(ctx, query, cfg, gatherPromptContext(query, cfg, prof), prof)
+}
+
+// answerWithContext is answerResponse with the prompt context gathered
+// already, which it adds the follow-up and hooks' output to
+func answerWithContext(ctx context.Context, query string, cfg *config.Config, pctx *llm.PromptContext, prof *metrics.Profile) (*response.Response, error) {
+	pctx.FollowUp = followUp
+	if len(cfg.Hooks.Before) > 0 {
+		queryProgress.Phase("Running hooks")
+	}
+	pctx.Commands = runBeforeHooks(ctx, cfg.Hooks, query)
+	prof.Mark("before hooks")
+	learnedAnswer, learned := applyLessons(pctx, query)
+
+	var llmResponse string
+	if learned {
+		if verbose {
+			fmt.Fprintln(os.Stderr, "Query:", query)
+			fmt.Fprintln(os.Stderr, "Answered from learned answers (cliq learn)")
+		}
+		llmResponse = learnedAnswer
+	} else if cached, ok := cachedAnswer(query, cfg, pctx); ok {
+		if verbose {
+			fmt.Fprintln(os.Stderr, "Query:", query)
+			fmt.Fprintln(os.Stderr, "Answered from the answer cache (--no-llm-cache to ask the model)")
+		}
+		llmResponse = cached
+		prof.Mark("answer cache")
+	} else {
+		var err error
+		var cacheable bool
+		llmResponse, cacheable, err = queryBackend(ctx, query, cfg, pctx, prof)
+		if err == nil && cacheable {
+			cacheAnswer(query, cfg, pctx, llmResponse)
+		}
+		if err != nil {
+			// The project's own tasks can answer without a model; the
+			// command and explanation are filled in when personalizing
+			if _, _, ok := project.MatchTask(query, pctx.Tasks); !ok {
+				return nil, err
+			}
+			if verbose {
+				fmt.Fprintf(os.Stderr, "Warning: %v; answering from the project's tasks\n", err)
+			}
+			llmResponse = ""
+		}
+	}
+
+	// Parse the LLM response and adapt it to the user's setup
+	resp := response.Parse(llmResponse)
+	personalizeResponse(resp, pctx, query)
+	warnHook(hookResponse(resp))
+	return resp, nil
+}
+
+// gatherPromptContext collects what the prompt says about the user's
+// setup: the parsed configs, from the daemon, the cache, or parsing them
+// now, and the documentation retrieved for the question
+func gatherPromptContext(query string, cfg *config.Config, prof *metrics.Profile) *llm.PromptContext {
+	if noContext {
+		if verbose {
+			fmt.Fprintln(os.Stderr, "Privacy mode: answering without your configs")
+		}
+		pctx := genericPromptContext(cfg)
+		pctx.References = llm.Retrieve(query)
+		prof.Mark("context gather")
+		return pctx
+	}
+
 	// Load or create cache
 	var nvimConfig *parser.NvimConfig
 	var tmuxConfig *parser.TmuxConfig
 
 	noCache := viper.GetBool("no-cache")
 
-	if !noCache && cfg.Cache.Enabled {
-		cache, err := parser.LoadCache()
+	// A running daemon has the configs parsed already, and up to date
+	fromDaemon := false
+	if !noCache {
+		nvimConfig, tmuxConfig, fromDaemon = daemonConfigs(cfg)
+	}
+	prof.Mark("daemon")
+
+	if !fromDaemon && !noCache && cfg.Cache.Enabled {
+		cache, err := parser.LoadCache(cfg.Nvim.ActiveProfile)
 		if err == nil && !cache.IsStale(cfg.Cache.TTLHours) {
 			nvimConfig = cache.NvimConfig
 			tmuxConfig = cache.TmuxConfig
 		}
-	}
-
-	// Parse configs if not cached
-	if nvimConfig == nil && cfg.Nvim.ConfigPath != "" {
-		var err error
-		nvimConfig, err = parser.ParseNvimConfig(cfg.Nvim.ConfigPath)
-		if err != nil && verbose {
-			fmt.Fprintf(os.Stderr, "Warning: could not parse nvim config: %v\n", err)
+		// A cache of another config directory would answer for the wrong
+		// profile
+		if nvimConfig != nil && nvimConfig.ConfigPath != cfg.Nvim.ConfigPath {
+			nvimConfig = nil
 		}
 	}
+	prof.Mark("cache load")
 
-	if tmuxConfig == nil && cfg.Tmux.ConfigPath != "" {
-		var err error
-		tmuxConfig, err = parser.ParseTmuxConfig(cfg.Tmux.ConfigPath)
-		if err != nil && verbose {
-			fmt.Fprintf(os.Stderr, "Warning: could not parse tmux config: %v\n", err)
-		}
+	// Parse the configs the cache didn't have, both at once. A config the
+	// question clearly doesn't need, such as tmux's for a Neovim question,
+	// isn't parsed at all.
+	needNvim, needTmux := llm.ConfigNeeds(query)
+	parsed := false
+	var g errgroup.Group
+	if nvimConfig == nil && cfg.Nvim.ConfigPath != "" && needNvim {
+		parsed = true
+		g.Go(func() error {
+			var err error
+			if nvimConfig, err = parser.ParseNvimConfig(cfg.Nvim.ConfigPath); err != nil {
+				return fmt.Errorf("could not parse nvim config: %w", err)
+			}
+			return nil
+		})
+	}
+	if tmuxConfig == nil && cfg.Tmux.ConfigPath != "" && needTmux {
+		parsed = true
+		g.Go(func() error {
+			var err error
+			if tmuxConfig, err = parser.ParseTmuxConfig(cfg.Tmux.ConfigPath); err != nil {
+				return fmt.Errorf("could not parse tmux config: %w", err)
+			}
+			return nil
+		})
+	}
+	// A config that fails to parse is left out of the prompt
+	if err := g.Wait(); err != nil && verbose {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
 	}
+	prof.Mark("config parse")
 
-	// Save to cache if enabled
-	if cfg.Cache.Enabled && !noCache {
+	// Save what was parsed to the cache if enabled, even when a skipped
+	// config is missing from it; that one is parsed when a question needs
+	// it. The daemon keeps the cache up to date itself.
+	if cfg.Cache.Enabled && !noCache && !fromDaemon && parsed {
 		cache := &parser.Cache{
+			Profile:    cfg.Nvim.ActiveProfile,
 			NvimConfig: nvimConfig,
 			TmuxConfig: tmuxConfig,
 		}
@@ -56,65 +218,263 @@ func executeQuery(query string, cfg *config.Config) error {
 			fmt.Fprintf(os.Stderr, "Warning: could not save cache: %v\n", err)
 		}
 	}
+	prof.Mark("cache save")
 
 	// Build prompt with configuration context
-	prompt := llm.BuildPrompt(query, nvimConfig, tmuxConfig)
+	pctx := newPromptContext(cfg, nvimConfig, tmuxConfig)
+	pctx.References = llm.Retrieve(query)
+	prof.Mark("context gather")
+	return pctx
+}
+
+// queryBackend builds the prompt and generates a response with the LLM
+// backend. cacheable is false when a fallback or the offline knowledge base
+// answered, whose answer shouldn't stand in for the model's next time.
+func queryBackend(ctx context.Context, query string, cfg *config.Config, pctx *llm.PromptContext, prof *metrics.Profile) (answer string, cacheable bool, err error) {
+	queryProgress.Phase("Building prompt")
+	prompt, err := hookPrompt(query, llm.BuildPrompt(query, pctx))
+	warnHook(err)
+	prof.Mark("prompt build")
+
+	// A running daemon keeps the model loaded, so it answers without the
+	// wait for loading it. Incognito queries stay in this process, whose
+	// audit log is off for them. The daemon only keeps local models, but
+	// it could fall back to a remote backend with no one to ask about the
+	// cost, so with model.cost_confirm_usd set it's left to this process.
+	if !incognito && backendOverride == nil && !(cfg.Model.CostConfirmUSD > 0 && len(cfg.Model.Fallback) > 0) {
+		queryProgress.Phase("Generating")
+		if gen, ok := daemonGenerate(ctx, cfg, prompt); ok {
+			if verbose {
+				fmt.Fprintln(os.Stderr, "Query:", query)
+				fmt.Fprintln(os.Stderr, "Backend:", llm.BackendChoice{Backend: gen.Backend}.Label(), "(cliq daemon)")
+				fmt.Fprintln(os.Stderr, "Sampling:", describeSampling(cfg))
+			}
+			if gen.FellBackFrom != "" {
+				warnFallback(gen.FellBackFrom, gen.Backend)
+			}
+			prof.Mark("backend query")
+			return gen.Text, gen.FellBackFrom == "" && gen.Backend != "offline", nil
+		}
+	}
 
 	// Create LLM client
+	queryProgress.Phase("Starting backend")
 	client, err := llm.NewClient(cfg.GetModelPath(), cfg.Model.OllamaModel, cfg.Model.Temperature, cfg.Model.MaxTokens)
 	if err != nil {
-		return fmt.Errorf("failed to initialize LLM: %w", err)
+		return "", false, fmt.Errorf("failed to initialize LLM: %w", err)
 	}
 	defer client.Close()
+	client = client.WithSamplingOptions(samplingOptions(cfg))
+	if backendOverride != nil {
+		client = client.WithBackend(*backendOverride)
+	}
+	prof.Mark("backend init")
 
 	if verbose {
 		fmt.Fprintln(os.Stderr, "Query:", query)
 		fmt.Fprintln(os.Stderr, "Backend:", client.GetBackend())
-		if client.GetBackend() == "ollama" {
-			fmt.Fprintln(os.Stderr, "Model:", cfg.Model.OllamaModel)
+		if client.GetBackend() == "ollama" || (llm.IsPlugin(client.GetBackend()) && client.GetModel() != "") {
+			fmt.Fprintln(os.Stderr, "Model:", client.GetModel())
+		}
+		fmt.Fprintln(os.Stderr, "Sampling:", describeSampling(cfg))
+	}
+
+	// Remote backends may bill per token, so show what this query could
+	// cost, unless it was agreed to along with others' or there's no price
+	// to show, as for a model on the LAN
+	if client.IsRemote() && !costConfirmed {
+		est := client.EstimateCost(prompt)
+		queryProgress.Pause()
+		if !inView && est.Priced {
+			fmt.Fprintln(os.Stderr, "Estimated cost:", est)
+		}
+		if err := confirmCost(est, cfg.Model.CostConfirmUSD); err != nil {
+			return "", false, err
 		}
 	}
 
 	// Generate response
-	llmResponse, err := client.Query(prompt)
+	queryProgress.Phase("Generating")
+	llmResponse, err := client.QueryContext(ctx, prompt)
+	reportAttempts(client)
 	if err != nil {
-		return fmt.Errorf("failed to generate response: %w", err)
+		return "", false, fmt.Errorf("failed to generate response: %w", err)
 	}
+	prof.Mark("backend query")
 
-	// Format and display response
-	format := viper.GetString("format")
-	output, err := formatOutput(llmResponse, format, nvimConfig, tmuxConfig, query)
-	if err != nil {
-		return fmt.Errorf("failed to format response: %w", err)
+	return llmResponse, !client.FellBack() && client.AnsweredBy() != "offline", nil
+}
+
+// reportAttempts shows, in verbose mode, the backends a query was retried
+// on or fell back to, and which one answered. Otherwise only an answer from
+// a fallback is pointed out.
+func reportAttempts(client *llm.Client) {
+	if !verbose {
+		if client.FellBack() {
+			warnFallback(client.GetBackend(), client.AnsweredBy())
+		}
+		return
+	}
+	attempts := client.Attempts()
+	if len(attempts) < 2 {
+		return
+	}
+	for _, a := range attempts {
+		if a.Err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %s failed: %v\n", llm.BackendChoice{Backend: a.Backend}.Label(), a.Err)
+		}
 	}
+	if answered := client.AnsweredBy(); answered != "" {
+		fmt.Fprintln(os.Stderr, "Answered by:", llm.BackendChoice{Backend: answered}.Label())
+	}
+}
 
-	fmt.Println(output)
-	return nil
+// warnFallback points out that a backend of the fallback chain answered
+// because the one asked failed
+func warnFallback(failed, answered string) {
+	if inView {
+		return
+	}
+	queryProgress.Pause()
+	fmt.Fprintf(os.Stderr, "Warning: %s failed, so %s answered\n",
+		llm.BackendChoice{Backend: failed}.Label(), llm.BackendChoice{Backend: answered}.Label())
 }
 
-// formatOutput formats the LLM response based on the specified format
-func formatOutput(llmResponse, format string, nvimCfg *parser.NvimConfig, tmuxCfg *parser.TmuxConfig, query string) (string, error) {
-	// Parse the LLM response
-	resp := response.Parse(llmResponse)
+// costConfirmed is set once the cost of every query to come has been
+// agreed to, as cliq batch does before asking its questions
+var costConfirmed bool
+
+// inView is set while queries are answered inside a full-screen view, as
+// cliq tour's, which owns the terminal: nothing is asked on stdin or
+// printed to stderr, and a query costing more than model.cost_confirm_usd
+// is refused with an error the view shows
+var inView bool
 
-	// Add user-specific keymaps if relevant
-	if nvimCfg != nil {
-		relevantKeymaps := findRelevantKeymaps(query, nvimCfg.Keymaps)
-		resp.UserKeymaps = relevantKeymaps
+// confirmCost asks before sending a query whose estimated cost exceeds
+// threshold. Without a terminal to ask on, or in a full-screen view, the
+// query is refused.
+func confirmCost(est llm.CostEstimate, threshold float64) error {
+	if threshold <= 0 || !est.Priced || est.USD <= threshold {
+		return nil
 	}
 
-	if tmuxCfg != nil && strings.Contains(strings.ToLower(query), "tmux") {
-		resp.TmuxPrefix = tmuxCfg.Prefix
+	if info, err := os.Stdin.Stat(); inView || err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return fmt.Errorf("estimated cost $%.4f exceeds model.cost_confirm_usd ($%.4f)", est.USD, threshold)
 	}
 
+	fmt.Fprintf(os.Stderr, "This may cost up to $%.4f. Continue? [y/N] ", est.USD)
+	var answer string
+	fmt.Scanln(&answer)
+	if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+		return fmt.Errorf("query cancelled")
+	}
+	return nil
+}
+
+// formatOutput formats the response based on the specified format
+func formatOutput(resp *response.Response, format string) (string, error) {
 	switch format {
 	case "json":
 		return resp.ToJSON()
 	case "markdown":
 		return resp.ToMarkdown(), nil
+	case "cmd":
+		// Only the command, for scripts and the shell widgets
+		if resp.Command == "" {
+			return "", ErrNoCommand
+		}
+		return resp.Command, nil
 	default:
-		return resp.ToText(), nil
+		// On a terminal the answer's markdown is rendered and wrapped to
+		// fit; piped output, NO_COLOR, and --no-color stay plain so it's
+		// easy to process
+		if !terminal.ColorEnabled(os.Stdout) {
+			return resp.ToPlain(), nil
+		}
+		return resp.ToTUI(terminal.Width(os.Stdout)), nil
+	}
+}
+
+// personalizeResponse adapts the response to the user's setup: their tmux
+// prefix, leader key, keymaps, keyboard layout, and installed plugins
+func personalizeResponse(resp *response.Response, pctx *llm.PromptContext, query string) {
+	resp.UserKeymaps, resp.TmuxPrefix = setupNotes(query, pctx)
+
+	var prefix, leader string
+	if pctx.Tmux != nil {
+		prefix = pctx.Tmux.Prefix
+	}
+	if pctx.Nvim != nil {
+		leader = pctx.Nvim.Leader
+	}
+	resp.Personalize(prefix, leader)
+
+	// The project's tasks for a question naming their runner are offered
+	// as alternatives to the model's command, and answer on their own only
+	// when the model gave none
+	if best, others, ok := project.MatchTask(query, pctx.Tasks); ok {
+		for _, t := range append([]project.Task{best}, others...) {
+			command := t.Command()
+			switch {
+			case resp.Command == "":
+				resp.Command = command
+			case command != resp.Command && !slices.Contains(resp.Alternatives, command):
+				resp.Alternatives = append(resp.Alternatives, command)
+			}
+		}
+		if resp.Explanation == "" && resp.Command == best.Command() {
+			resp.Explanation = fmt.Sprintf("Runs the %s task from %s.", best.Name, filepath.Base(best.Source))
+			if best.Description != "" {
+				resp.Explanation = fmt.Sprintf("%s (%s, from %s)", best.Description, best.Name, filepath.Base(best.Source))
+			}
+		}
+	}
+
+	if pctx.Shell != nil && resp.Command != "" {
+		resp.ShellNotes = shelltype.New(pctx.Shell.Name, pctx.Shell).Conflicts(resp.Command)
+	}
+
+	if pctx.Layout != nil && resp.Command != "" {
+		resp.LayoutNotes = pctx.Layout.DescribeKeys(resp.Command)
+	}
+
+	if pctx.Nvim != nil && len(pctx.Nvim.Plugins) > 0 {
+		var plugins []string
+		for _, p := range pctx.Nvim.Plugins {
+			if p.Enabled {
+				plugins = append(plugins, p.Name)
+			}
+		}
+		resp.WithPlugins = knowledge.SuggestForPlugins(plugins, query, resp.Command, 3)
+	}
+
+	var refs []response.Citation
+	for _, ref := range pctx.References {
+		refs = append(refs, response.Citation{Source: ref.Source, Excerpt: ref.Excerpt})
+	}
+	resp.Cite(refs)
+
+	// Only the first tip is shown, and an existing alias is the more useful
+	// one, so it goes first
+	if alias, ok := pctx.Shell.AliasFor(resp.Command); ok {
+		note := fmt.Sprintf("You already have `%s` aliased to `%s`.", alias.Name, alias.Expansion)
+		resp.Tips = append([]string{note}, resp.Tips...)
+	}
+
+	resp.Localize(pctx.Locale)
+	resp.KeyNotation = pctx.KeyNotation
+}
+
+// setupNotes returns the "In your setup" part of an answer to query: the
+// user's keymaps related to it and, for tmux questions, their prefix
+func setupNotes(query string, pctx *llm.PromptContext) (keymaps []string, prefix string) {
+	if pctx.Nvim != nil {
+		keymaps = findRelevantKeymaps(query, pctx.Nvim.Keymaps)
+	}
+	if pctx.Tmux != nil && strings.Contains(strings.ToLower(query), "tmux") {
+		prefix = pctx.Tmux.Prefix
 	}
+	return keymaps, prefix
 }
 
 // findRelevantKeymaps finds keymaps that might be relevant to the query
@@ -127,10 +487,11 @@ func findRelevantKeymaps(query string, keymaps []parser.Keymap) []string {
 	for _, km := range keymaps {
 		desc := strings.ToLower(km.Description)
 		rhs := strings.ToLower(km.Rhs)
+		plugin := strings.ToLower(km.Plugin)
 
 		for _, keyword := range keywords {
-			if strings.Contains(desc, keyword) || strings.Contains(rhs, keyword) {
-				relevant = append(relevant, fmt.Sprintf("%s -> %s (%s)", km.Lhs, km.Rhs, km.Description))
+			if strings.Contains(desc, keyword) || strings.Contains(rhs, keyword) || strings.Contains(plugin, keyword) {
+				relevant = append(relevant, fmt.Sprintf("%s -> %s (%s)", km.Keys, km.Rhs, km.Description))
 				break
 			}
 		}
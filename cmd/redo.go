@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/cliq-cli/cliq/internal/config"
+	"github.com/cliq-cli/cliq/internal/llm"
+	"github.com/cliq-cli/cliq/internal/response"
+	"github.com/cliq-cli/cliq/internal/store"
+	"github.com/cliq-cli/cliq/internal/terminal"
+)
+
+// redoCmd represents the redo command
+var redoCmd = &cobra.Command{
+	Use:   "redo",
+	Short: "Ask your last question again, of another backend or model",
+	Long: `Ask the most recent question, one-shot or from interactive mode, again,
+of the backend or model you name, to see whether it answers better. The
+backend is one cliq can use right now: llama-server, ollama, llama-cli,
+offline, or a plugin's name; --model picks one of its models, or without
+--backend, whichever backend has that model. Without either, the question
+is asked the usual way again, skipping the answer cache.
+
+The question isn't sent to a fallback when the backend fails. With --diff,
+the earlier answer and the new one are shown side by side.
+
+Examples:
+  cliq redo --backend ollama
+  cliq redo --model mistral --diff
+  cliq redo --backend ollama --model llama3.2`,
+	Args: cobra.NoArgs,
+	RunE: runRedo,
+}
+
+func init() {
+	rootCmd.AddCommand(redoCmd)
+
+	redoCmd.Flags().String("backend", "", "backend or plugin to ask, as listed by cliq status")
+	redoCmd.Flags().String("model", "", "model to ask, of --backend or of whichever backend has it")
+	redoCmd.Flags().Bool("diff", false, "show the earlier answer and the new one side by side")
+}
+
+// backendOverride is the backend queries are sent to instead of the one
+// detected, set by cliq redo
+var backendOverride *llm.BackendChoice
+
+func runRedo(cmd *cobra.Command, args []string) error {
+	backend, _ := cmd.Flags().GetString("backend")
+	model, _ := cmd.Flags().GetString("model")
+	showDiff, _ := cmd.Flags().GetBool("diff")
+	ctx := cmd.Context()
+
+	last, err := store.LastExchange()
+	if err != nil {
+		return fmt.Errorf("failed to load history: %w", err)
+	}
+	if last == nil || last.Answer == nil {
+		return fmt.Errorf("no earlier question to ask again")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = config.Default()
+	}
+
+	label := "Again"
+	if backend != "" || model != "" {
+		choice, err := redoChoice(llm.ListBackends(ctx, cfg.GetModelPath()), backend, model, cfg)
+		if err != nil {
+			return err
+		}
+		backendOverride = &choice
+		label = choice.Label()
+		// Another backend answering would defeat the comparison
+		llm.SetFallback(0, nil)
+	}
+	viper.Set("no-llm-cache", true)
+
+	queryProgress = startProgress(ctx, cfg.TUI.Icons)
+	defer queryProgress.Stop()
+	resp, err := answerResponse(ctx, last.Query, cfg, nil)
+	queryProgress.Stop()
+	if err != nil {
+		return err
+	}
+	recordQuery(cfg, last.Query, resp)
+
+	if showDiff {
+		fmt.Println(sideBySide(last.Query, last.Answer, "Before", resp, label))
+		return nil
+	}
+	output, err := formatOutput(resp, "text")
+	if err != nil {
+		return err
+	}
+	fmt.Println(output)
+	return nil
+}
+
+// redoChoice picks the available backend and model the flags name. A
+// backend named without a model is asked for the model the config sets
+// when it has it, or else its first.
+func redoChoice(available []llm.BackendChoice, backend, model string, cfg *config.Config) (llm.BackendChoice, error) {
+	var choices []llm.BackendChoice
+	if backend != "" {
+		filter := backend
+		if model != "" {
+			filter += ":" + model
+		}
+		choices = benchChoices(available, []string{filter})
+	} else {
+		for _, c := range available {
+			if c.Model == model {
+				choices = append(choices, c)
+			}
+		}
+	}
+
+	if len(choices) == 0 {
+		wanted := strings.Trim(backend+" "+model, " ")
+		return llm.BackendChoice{}, fmt.Errorf("no available backend matches %s; see cliq status", wanted)
+	}
+	for _, c := range choices {
+		if c.Model != "" && (c.Model == cfg.Model.OllamaModel || c.Model == cfg.Model.PluginModel) {
+			return c, nil
+		}
+	}
+	return choices[0], nil
+}
+
+// sideBySide renders two answers to a question in columns, each headed by
+// where it came from
+func sideBySide(query string, left *response.Response, leftLabel string, right *response.Response, rightLabel string) string {
+	width := terminal.Width(os.Stdout)
+	if width <= 0 {
+		width = 100
+	}
+	gap := 3
+	colWidth := (width - gap) / 2
+
+	plain := !terminal.ColorEnabled(os.Stdout)
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("99"))
+	render := func(label string, r *response.Response) string {
+		if plain {
+			return label + "\n\n" + r.ToPlain()
+		}
+		return headerStyle.Render(label) + "\n\n" + r.ToTUI(colWidth)
+	}
+
+	leftCol := lipgloss.NewStyle().Width(colWidth).MarginRight(gap).Render(render(leftLabel, left))
+	rightCol := lipgloss.NewStyle().Width(colWidth).Render(render(rightLabel, right))
+	out := query + "\n\n" + lipgloss.JoinHorizontal(lipgloss.Top, leftCol, rightCol)
+	if left.Command != "" && left.Command == right.Command {
+		out += "\n\nBoth answers give the same command."
+	}
+	return out
+}
@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+
+	"github.com/cliq-cli/cliq/internal/config"
+	"github.com/cliq-cli/cliq/internal/llm"
+	"github.com/cliq-cli/cliq/internal/parser"
+	"github.com/cliq-cli/cliq/internal/response"
+	"github.com/cliq-cli/cliq/internal/store"
+)
+
+var refreshList bool
+
+// refreshPinsCmd represents the refresh-pins command
+var refreshPinsCmd = &cobra.Command{
+	Use:   "refresh-pins",
+	Short: "Regenerate saved answers whose setup notes are out of date",
+	Long: `Find saved interactive answers whose "In your setup" notes no longer match
+your configuration, such as after changing your tmux prefix or Neovim
+keymaps, and ask the model again with your current setup.
+
+'cliq config reload' tells you when there are any.
+
+Examples:
+  cliq refresh-pins --list
+  cliq refresh-pins`,
+	Args: cobra.NoArgs,
+	RunE: runRefreshPins,
+}
+
+func init() {
+	rootCmd.AddCommand(refreshPinsCmd)
+
+	refreshPinsCmd.Flags().BoolVar(&refreshList, "list", false, "only list the out-of-date answers")
+}
+
+// staleAnswer is a saved answer whose setup notes have changed
+type staleAnswer struct {
+	index   int
+	keymaps []string
+	prefix  string
+}
+
+// findStaleAnswers returns the saved answers whose "In your setup" notes
+// differ from what the current setup would give
+func findStaleAnswers(session *store.Session, pctx *llm.PromptContext) []staleAnswer {
+	var stale []staleAnswer
+	for i, entry := range session.Entries {
+		// Only answers that have an "In your setup" section can be out of
+		// date with it
+		if entry.Answer == nil || len(entry.Answer.UserKeymaps) == 0 && entry.Answer.TmuxPrefix == "" {
+			continue
+		}
+		keymaps, prefix := setupNotes(entry.Query, pctx)
+		if prefix != entry.Answer.TmuxPrefix || !slices.Equal(keymaps, entry.Answer.UserKeymaps) {
+			stale = append(stale, staleAnswer{index: i, keymaps: keymaps, prefix: prefix})
+		}
+	}
+	return stale
+}
+
+// currentPromptContext parses the user's configs afresh for comparing and
+// regenerating saved answers
+func currentPromptContext(cfg *config.Config) *llm.PromptContext {
+	var nvimConfig *parser.NvimConfig
+	var tmuxConfig *parser.TmuxConfig
+	if cfg.Nvim.ConfigPath != "" {
+		nvimConfig, _ = parser.ParseNvimConfig(cfg.Nvim.ConfigPath)
+	}
+	if cfg.Tmux.ConfigPath != "" {
+		tmuxConfig, _ = parser.ParseTmuxConfig(cfg.Tmux.ConfigPath)
+	}
+	return newPromptContext(cfg, nvimConfig, tmuxConfig)
+}
+
+// reportStaleAnswers tells the user, after a config reload, which saved
+// answers no longer match their setup
+func reportStaleAnswers(pctx *llm.PromptContext) {
+	session, err := store.LoadSession()
+	if err != nil {
+		return
+	}
+	stale := findStaleAnswers(session, pctx)
+	if len(stale) == 0 {
+		return
+	}
+
+	warnStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+	fmt.Println()
+	fmt.Println(warnStyle.Render(fmt.Sprintf("! %d saved answer(s) mention keymaps or a prefix that changed:", len(stale))))
+	for i, s := range stale {
+		if i == 5 {
+			fmt.Printf("    … and %d more\n", len(stale)-i)
+			break
+		}
+		fmt.Printf("    %s\n", session.Entries[s.index].Query)
+	}
+	fmt.Println("  Run 'cliq refresh-pins' to regenerate them with your current setup.")
+}
+
+func runRefreshPins(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = config.Default()
+	}
+	session, err := store.LoadSession()
+	if err != nil {
+		return fmt.Errorf("failed to load session: %w", err)
+	}
+
+	pctx := currentPromptContext(cfg)
+	stale := findStaleAnswers(session, pctx)
+	if len(stale) == 0 {
+		fmt.Println("All saved answers match your current setup.")
+		return nil
+	}
+
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("99"))
+	if refreshList {
+		for _, s := range stale {
+			entry := session.Entries[s.index]
+			fmt.Println(labelStyle.Render(entry.Query))
+			if entry.Answer.TmuxPrefix != s.prefix {
+				fmt.Printf("  prefix: %s → %s\n", entry.Answer.TmuxPrefix, s.prefix)
+			}
+			for _, km := range entry.Answer.UserKeymaps {
+				if !slices.Contains(s.keymaps, km) {
+					fmt.Printf("  - %s\n", km)
+				}
+			}
+			for _, km := range s.keymaps {
+				if !slices.Contains(entry.Answer.UserKeymaps, km) {
+					fmt.Printf("  + %s\n", km)
+				}
+			}
+		}
+		return nil
+	}
+
+	client, err := llm.NewClient(cfg.GetModelPath(), cfg.Model.OllamaModel, cfg.Model.Temperature, cfg.Model.MaxTokens)
+	if err != nil {
+		return fmt.Errorf("failed to initialize LLM: %w", err)
+	}
+	defer client.Close()
+
+	refreshed := 0
+	for _, s := range stale {
+		entry := &session.Entries[s.index]
+		fmt.Println(labelStyle.Render("Refreshing: ") + entry.Query)
+
+		raw, err := client.QueryContext(cmd.Context(), llm.BuildPrompt(entry.Query, pctx))
+		if err != nil {
+			if cmd.Context().Err() != nil {
+				break
+			}
+			fmt.Printf("  could not regenerate: %v\n", err)
+			continue
+		}
+		answer := response.Parse(raw)
+		personalizeResponse(answer, pctx, entry.Query)
+		entry.Answer = answer
+		entry.Raw = answer.Raw
+		refreshed++
+	}
+
+	if refreshed > 0 {
+		if err := session.Save(); err != nil {
+			return fmt.Errorf("failed to save session: %w", err)
+		}
+	}
+	successStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	fmt.Println(successStyle.Render(fmt.Sprintf("✓ Refreshed %d of %d answer(s)", refreshed, len(stale))))
+	return nil
+}
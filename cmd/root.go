@@ -1,18 +1,33 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
 	"github.com/cliq-cli/cliq/internal/config"
+	"github.com/cliq-cli/cliq/internal/llm"
+	"github.com/cliq-cli/cliq/internal/response"
+	"github.com/cliq-cli/cliq/internal/terminal"
 )
 
 var (
 	cfgFile     string
 	verbose     bool
+	incognito   bool
+	noColor     bool
+	asciiIcons  bool
+	noContext   bool
+	noUpdates   bool
+	nvimProfile string
+	profile     string
 	versionInfo struct {
 		Version string
 		Commit  string
@@ -32,6 +47,7 @@ Examples:
   cliq "how do I delete a line"
   cliq "split tmux window vertically"
   cliq "search and replace in visual mode"
+  cliq -c "and in visual mode?"        # Follow up on the last answer
   cliq -i                              # Interactive mode`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runRootCmd,
@@ -41,18 +57,54 @@ func runRootCmd(cmd *cobra.Command, args []string) error {
 	// Check if interactive mode
 	interactive, _ := cmd.Flags().GetBool("interactive")
 	if interactive {
-		return runInteractive()
+		return runInteractive(cmd.Context())
 	}
 
 	if len(args) == 0 {
 		return cmd.Help()
 	}
-	return runQuery(args[0])
+	if quiet, _ := cmd.Flags().GetBool("quiet"); quiet {
+		viper.Set("format", "cmd")
+	}
+	return runQuery(cmd.Context(), applyInvocation(cmd, strings.Join(args, " ")))
 }
 
+// ErrInterrupted is returned by Execute when cliq was stopped by SIGINT or SIGTERM
+var ErrInterrupted = errors.New("interrupted")
+
+// ErrNoCommand is returned by Execute when --format cmd was asked for but no
+// command could be extracted from the answer
+var ErrNoCommand = errors.New("no command in the answer")
+
 // Execute adds all child commands to the root command and sets flags appropriately.
+// SIGINT and SIGTERM cancel the command's context so backends, subprocesses,
+// and downloads can clean up; a second signal exits immediately.
 func Execute() error {
-	return rootCmd.Execute()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	defer stopDebugPprof()
+	routeInvocation(os.Args[0])
+	// Whether cliq was set up before this run, which tells an upgrade
+	// from a fresh install for the whatsnew notice
+	_, statErr := os.Stat(config.GetConfigPath())
+	setUp := statErr == nil
+	cmd, err := rootCmd.ExecuteContextC(ctx)
+	finishUpdateCheck()
+	if ctx.Err() != nil {
+		return ErrInterrupted
+	}
+	if err == nil {
+		whatsNewNotice(cmd, setUp)
+	}
+	if err != nil && cmd.SilenceErrors {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+	}
+	return err
 }
 
 // SetVersionInfo sets the version information from main
@@ -73,19 +125,135 @@ func init() {
 	// Global flags
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.config/cliq/config.toml)")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose output")
+	rootCmd.PersistentFlags().BoolVar(&incognito, "incognito", false, "don't record queries or answers in history")
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "plain output without colors or styling (also NO_COLOR)")
+	rootCmd.PersistentFlags().BoolVar(&asciiIcons, "ascii", false, "use ASCII instead of emoji icons (same as tui.icons = \"ascii\")")
+	rootCmd.PersistentFlags().BoolVar(&noUpdates, "disable-update-check", false, "don't check for a newer release, even if updates.check is on")
+	rootCmd.PersistentFlags().StringVar(&profile, "profile", "", "settings profile to use, from [profiles.<name>] or profiles/<name>.toml (default $CLIQ_PROFILE)")
+	rootCmd.PersistentFlags().StringVar(&nvimProfile, "nvim-profile", "", "NVIM_APPNAME of the Neovim config to use (default $NVIM_APPNAME, then nvim.profile)")
+	rootCmd.PersistentFlags().BoolVar(&debugPprof, "debug-pprof", false, "write CPU/heap/trace profiles (serve pprof on localhost in interactive mode and the daemon)")
+	rootCmd.PersistentPreRunE = rootPreRun
 
 	// Query-specific flags
-	rootCmd.Flags().StringP("format", "f", "text", "output format (text|json|markdown)")
+	rootCmd.Flags().StringP("format", "f", "text", "output format (text|json|markdown|cmd)")
+	rootCmd.Flags().BoolP("quiet", "q", false, "print only the command (same as --format cmd)")
 	rootCmd.Flags().Bool("no-cache", false, "skip config cache")
+	rootCmd.Flags().Bool("no-llm-cache", false, "ask the model even if the question was answered before")
+	rootCmd.Flags().Bool("no-hooks", false, "don't run the on_prompt and on_response hooks in hooks.lua")
 	rootCmd.Flags().BoolP("interactive", "i", false, "launch interactive TUI mode")
+	rootCmd.Flags().BoolVar(&applyAnswer, "apply", false, "add the answer's keymap or tmux binding to your config, after confirming")
+	rootCmd.Flags().Float64("temperature", 0, "sampling temperature for this query (default model.temperature)")
+	rootCmd.Flags().Int("max-tokens", 0, "longest answer, in tokens, for this query (default model.max_tokens)")
+	rootCmd.Flags().Float64("top-p", 0, "nucleus sampling threshold for this query (default model.top_p)")
+	rootCmd.Flags().Int("top-k", 0, "sample from only the k likeliest tokens for this query (default model.top_k)")
+	rootCmd.Flags().Int("seed", 0, "random seed, so the same question gets the same answer (default model.seed)")
+	rootCmd.Flags().BoolVar(&noContext, "no-context", false, "answer without reading or sending your Neovim, tmux, or shell configs (same as general.privacy_mode)")
+	rootCmd.Flags().Int("candidates", 0, "ask for this many answers and rank the distinct commands in them")
+	rootCmd.Flags().BoolVarP(&continueLast, "continue", "c", false, "follow up on your last question and its answer")
+	samplingFlags = rootCmd.Flags()
 
 	// Bind flags to viper
 	viper.BindPFlag("format", rootCmd.Flags().Lookup("format"))
 	viper.BindPFlag("no-cache", rootCmd.Flags().Lookup("no-cache"))
+	viper.BindPFlag("no-llm-cache", rootCmd.Flags().Lookup("no-llm-cache"))
+	viper.BindPFlag("no-hooks", rootCmd.Flags().Lookup("no-hooks"))
+}
+
+// rootPreRun runs before every command, after flags and arguments are validated
+func rootPreRun(cmd *cobra.Command, args []string) error {
+	// Usage is only helpful for flag and argument mistakes, which cobra
+	// reports before this point; later errors are printed by Execute
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+
+	cfg, err := config.Load()
+	if errors.Is(err, config.ErrUnknownProfile) {
+		// Answering from another setup than the one asked for is worse
+		// than not answering
+		return err
+	} else if err != nil {
+		cfg = config.Default()
+	}
+	if m, ok := cfg.Migration(); ok {
+		fmt.Fprintf(os.Stderr, "Upgraded %s from config version %d to %d; the old file is saved as %s\n", config.GetConfigPath(), m.From, m.To, m.Backup)
+	}
+	if cfg.ActiveProfile != "" && verbose {
+		fmt.Fprintf(os.Stderr, "Using profile %s\n", cfg.ActiveProfile)
+	}
+	// The config commands are how problems get looked at and fixed
+	if cmd.Parent() != configCmd {
+		warnConfigProblems(cfg, err)
+	}
+
+	if noColor {
+		terminal.DisableColor()
+	}
+	if asciiIcons {
+		cfg.TUI.Icons = "ascii"
+	}
+	if cfg.General.PrivacyMode {
+		noContext = true
+	}
+	response.SetIcons(cfg.TUI.Icons)
+	llm.SetInference(cfg.Model.GPULayers, cfg.Model.Threads, cfg.Model.BatchSize)
+	llm.SetPromptFormat(cfg.Model.ChatTemplate, cfg.Model.ContextLength)
+	llm.SetFallback(cfg.Model.Retries, cfg.Model.Fallback)
+	llm.SetPlugin(cfg.Model.Backend, cfg.Model.PluginModel)
+	if !incognito {
+		llm.SetAuditLog(auditLogMode(cfg), historyRetention(cfg).Excludes)
+	}
+	if err := llm.PruneAuditLog(cfg.History.MaxEntries, historyRetention(cfg).MaxAge); err != nil && verbose {
+		fmt.Fprintf(os.Stderr, "Warning: could not prune the audit log: %v\n", err)
+	}
+	llm.SetAPIKeys(apiKeyFor)
+	if cfg.Model.ManageServer {
+		llm.ManageServer(serverIdle(cfg))
+	}
+	setupStoreKey(cfg)
+	startUpdateCheck(cmd.Context(), cfg)
+	return startDebugPprof(cmd, args)
+}
+
+// warnConfigProblems points out mistakes in the config files, which would
+// otherwise be ignored, or have the whole config ignored when it won't load
+func warnConfigProblems(cfg *config.Config, loadErr error) {
+	if loadErr != nil {
+		fmt.Fprintf(os.Stderr, "Warning: the config could not be loaded, so the defaults are used: %v\n", loadErr)
+	}
+	var problems []config.Problem
+	for _, path := range configFiles(cfg) {
+		found, err := config.ValidateFile(path)
+		if err == nil {
+			problems = append(problems, found...)
+		}
+	}
+	if len(problems) == 0 {
+		return
+	}
+	for _, p := range problems {
+		fmt.Fprintf(os.Stderr, "Warning: %s: %s\n", p.File, p)
+	}
+	fmt.Fprintln(os.Stderr, "Run 'cliq config validate' to check the config again once it's fixed.")
+}
+
+// configFiles returns the global config file, the file of the profile in
+// effect, and the project file for the current directory, those there are
+func configFiles(cfg *config.Config) []string {
+	files := []string{config.GetConfigPath()}
+	if path := cfg.ProfileFile(); path != "" {
+		files = append(files, path)
+	}
+	if path, err := config.FindProjectFile(); err == nil && path != "" {
+		files = append(files, path)
+	}
+	return files
 }
 
 // initConfig reads in config file and ENV variables if set.
 func initConfig() {
+	config.SetNvimProfile(nvimProfile)
+	config.SetProfile(profile)
+
 	if cfgFile != "" {
 		// Use config file from the flag.
 		viper.SetConfigFile(cfgFile)
@@ -103,6 +271,10 @@ func initConfig() {
 		viper.SetConfigName("config")
 	}
 
+	// CLIQ_FORMAT, CLIQ_NO_CACHE, and so on stand in for the flags; config
+	// settings are overridden the same way in config.Load
+	viper.SetEnvPrefix(config.EnvPrefix)
+	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
 	viper.AutomaticEnv() // read in environment variables that match
 
 	// If a config file is found, read it in.
@@ -114,7 +286,7 @@ func initConfig() {
 }
 
 // runQuery handles the main query execution
-func runQuery(query string) error {
+func runQuery(ctx context.Context, query string) error {
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
@@ -123,14 +295,29 @@ func runQuery(query string) error {
 		}
 		cfg = config.Default()
 	}
+	applyInvocationStyle(cfg)
+	if err := applySamplingFlags(cfg); err != nil {
+		return err
+	}
+	if continueLast {
+		if followUp, err = loadFollowUp(); err != nil {
+			return err
+		}
+	}
 
-	// Check if model exists
+	// llama-cli needs a local model file; other backends manage their own
+	// models, and without any backend we fall back to the offline knowledge base
 	modelPath := cfg.GetModelPath()
-	if _, err := os.Stat(modelPath); os.IsNotExist(err) {
-		fmt.Println("Model not found. Please run 'cliq init' first to download the model.")
-		return fmt.Errorf("model not found at %s", modelPath)
+	if cfg.Model.Backend == "llama-cli" {
+		if _, err := os.Stat(modelPath); os.IsNotExist(err) {
+			fmt.Println("Model not found. Please run 'cliq init' first to download the model.")
+			return fmt.Errorf("model not found at %s", modelPath)
+		}
 	}
 
 	// Execute query using LLM
-	return executeQuery(query, cfg)
+	if n, _ := samplingFlags.GetInt("candidates"); n > 1 {
+		return executeCandidates(ctx, query, cfg, n)
+	}
+	return executeQuery(ctx, query, cfg)
 }
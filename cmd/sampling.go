@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+
+	"github.com/cliq-cli/cliq/internal/config"
+	"github.com/cliq-cli/cliq/internal/llm"
+)
+
+// samplingFlags are the root command's flags, which hold the sampling
+// settings; set in init, as rootCmd refers to the functions reading them
+var samplingFlags *pflag.FlagSet
+
+// applySamplingFlags overrides the config's sampling settings with the
+// --temperature, --max-tokens, --top-p, --top-k, and --seed given for this
+// invocation
+func applySamplingFlags(cfg *config.Config) error {
+	flags := samplingFlags
+	if flags.Changed("temperature") {
+		t, _ := flags.GetFloat64("temperature")
+		if t < 0 || t > 2 {
+			return fmt.Errorf("--temperature must be between 0 and 2")
+		}
+		cfg.Model.Temperature = t
+	}
+	if flags.Changed("max-tokens") {
+		n, _ := flags.GetInt("max-tokens")
+		if n <= 0 {
+			return fmt.Errorf("--max-tokens must be positive")
+		}
+		cfg.Model.MaxTokens = n
+	}
+	if flags.Changed("top-p") {
+		p, _ := flags.GetFloat64("top-p")
+		if p <= 0 || p > 1 {
+			return fmt.Errorf("--top-p must be above 0 and at most 1")
+		}
+		cfg.Model.TopP = p
+	}
+	if flags.Changed("top-k") {
+		k, _ := flags.GetInt("top-k")
+		if k <= 0 {
+			return fmt.Errorf("--top-k must be positive")
+		}
+		cfg.Model.TopK = k
+	}
+	if flags.Changed("seed") {
+		seed, _ := flags.GetInt("seed")
+		if seed < 0 {
+			return fmt.Errorf("--seed must not be negative")
+		}
+		cfg.Model.Seed = &seed
+	}
+	return nil
+}
+
+// samplingOptions returns the config's sampling settings for the client
+func samplingOptions(cfg *config.Config) llm.SamplingOptions {
+	return llm.SamplingOptions{TopP: cfg.Model.TopP, TopK: cfg.Model.TopK, Seed: cfg.Model.Seed}
+}
+
+// describeSampling lists the sampling settings, for verbose output and bug
+// reports
+func describeSampling(cfg *config.Config) string {
+	s := fmt.Sprintf("temperature %g, max tokens %d", cfg.Model.Temperature, cfg.Model.MaxTokens)
+	if cfg.Model.TopP > 0 {
+		s += fmt.Sprintf(", top-p %g", cfg.Model.TopP)
+	}
+	if cfg.Model.TopK > 0 {
+		s += fmt.Sprintf(", top-k %d", cfg.Model.TopK)
+	}
+	if cfg.Model.Seed != nil {
+		s += fmt.Sprintf(", seed %d", *cfg.Model.Seed)
+	}
+	return s
+}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+
+	"github.com/cliq-cli/cliq/internal/config"
+	"github.com/cliq-cli/cliq/internal/llm"
+	"github.com/cliq-cli/cliq/internal/parser"
+	"github.com/cliq-cli/cliq/internal/response"
+)
+
+// selftestQuery is asked by cliq selftest; any non-empty answer passes
+const selftestQuery = "how do I delete a line in vim"
+
+// selftestCmd represents the selftest command
+var selftestCmd = &cobra.Command{
+	Use:   "selftest",
+	Short: "Verify cliq works end to end",
+	Long: `Run the full query pipeline end to end: config parsing, prompt
+building, inference, and response formatting.
+
+By default a tiny (~100MB) GGUF model is downloaded to the cache directory
+and run with llama-cli, so the check doesn't depend on your backend setup.
+Pass --installed to test your configured backend instead.
+
+Examples:
+  cliq selftest
+  cliq selftest --installed`,
+	Args: cobra.NoArgs,
+	RunE: runSelftest,
+}
+
+func init() {
+	rootCmd.AddCommand(selftestCmd)
+
+	selftestCmd.Flags().Bool("installed", false, "test the configured backend instead of the tiny test model")
+	selftestCmd.Flags().String("model-url", llm.TinyModelURL, "GGUF model to download for the test")
+	selftestCmd.Flags().String("sha256", "", "SHA-256 the --model-url download must match")
+}
+
+// selftestStep prints the outcome of one self-test step
+func selftestStep(name string, start time.Time, err error) error {
+	successStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	errStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+
+	elapsed := formatPhaseDuration(time.Since(start))
+	if err != nil {
+		fmt.Println(errStyle.Render(fmt.Sprintf("  ✗ %s (%s): %v", name, elapsed, err)))
+		return fmt.Errorf("selftest failed at step %q", name)
+	}
+	fmt.Println(successStyle.Render(fmt.Sprintf("  ✓ %s (%s)", name, elapsed)))
+	return nil
+}
+
+func runSelftest(cmd *cobra.Command, args []string) error {
+	installed, _ := cmd.Flags().GetBool("installed")
+	modelURL, _ := cmd.Flags().GetString("model-url")
+	modelSHA256, _ := cmd.Flags().GetString("sha256")
+	ctx := cmd.Context()
+
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("42"))
+	fmt.Println(titleStyle.Render("Cliq selftest"))
+	fmt.Println()
+
+	start := time.Now()
+	cfg, err := config.Load()
+	if err := selftestStep("load config", start, err); err != nil {
+		return err
+	}
+
+	if installed {
+		start = time.Now()
+		output, err := answerQuery(ctx, selftestQuery, cfg, nil)
+		if err == nil && strings.TrimSpace(output) == "" {
+			err = fmt.Errorf("empty answer")
+		}
+		if err := selftestStep("query configured backend", start, err); err != nil {
+			return err
+		}
+		fmt.Println(titleStyle.Render("\nAll checks passed"))
+		return nil
+	}
+
+	start = time.Now()
+	cacheDir, err := config.GetCacheDir()
+	if err != nil {
+		return selftestStep("locate cache directory", start, err)
+	}
+	modelPath := filepath.Join(cacheDir, "selftest", path.Base(modelURL))
+
+	// Check for llama-cli first so a missing binary fails before the download
+	client, err := llm.NewLlamaCLIClient(modelPath, cfg.Model.Temperature, 64)
+	if err := selftestStep("find llama-cli", start, err); err != nil {
+		return err
+	}
+	defer client.Close()
+
+	start = time.Now()
+	if _, statErr := os.Stat(modelPath); os.IsNotExist(statErr) {
+		err = llm.DownloadModel(ctx, modelURL, modelPath, modelSHA256)
+	}
+	if err := selftestStep("download test model", start, err); err != nil {
+		return err
+	}
+
+	start = time.Now()
+	var nvimConfig *parser.NvimConfig
+	var tmuxConfig *parser.TmuxConfig
+	if cfg.Nvim.ConfigPath != "" {
+		nvimConfig, err = parser.ParseNvimConfig(cfg.Nvim.ConfigPath)
+	}
+	if err == nil && cfg.Tmux.ConfigPath != "" {
+		tmuxConfig, err = parser.ParseTmuxConfig(cfg.Tmux.ConfigPath)
+	}
+	if err := selftestStep("parse configs", start, err); err != nil {
+		return err
+	}
+
+	start = time.Now()
+	pctx := newPromptContext(cfg, nvimConfig, tmuxConfig)
+	prompt := llm.BuildPrompt(selftestQuery, pctx)
+	if err := selftestStep("build prompt", start, nil); err != nil {
+		return err
+	}
+
+	start = time.Now()
+	raw, err := client.QueryContext(ctx, prompt)
+	if err == nil && strings.TrimSpace(raw) == "" {
+		err = fmt.Errorf("the model returned no text")
+	}
+	if err := selftestStep("run inference", start, err); err != nil {
+		return err
+	}
+
+	start = time.Now()
+	resp := response.Parse(raw)
+	_, err = resp.ToJSON()
+	if err == nil && resp.Command == "" && resp.Explanation == "" {
+		err = fmt.Errorf("could not parse the answer")
+	}
+	if err := selftestStep("format response", start, err); err != nil {
+		return err
+	}
+
+	fmt.Println(titleStyle.Render("\nAll checks passed"))
+	return nil
+}
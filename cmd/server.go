@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+
+	"github.com/cliq-cli/cliq/internal/config"
+	"github.com/cliq-cli/cliq/internal/llm"
+)
+
+// serverCmd groups the commands for the llama-server cliq manages
+var serverCmd = &cobra.Command{
+	Use:   "server",
+	Short: "Manage the llama-server cliq runs for you",
+	Long: `With manage_server = true in the [model] section of your config, cliq
+starts llama-server itself with your GGUF model on a free local port, the
+first time it needs the model. Later runs reuse it, so the model is loaded
+once rather than on every query, and it stops after server_idle_minutes
+without a query (15 by default).
+
+Examples:
+  cliq server status
+  cliq server start
+  cliq server stop`,
+	Args: cobra.NoArgs,
+	RunE: runServerStatus,
+}
+
+var serverStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether the managed llama-server is running",
+	Args:  cobra.NoArgs,
+	RunE:  runServerStatus,
+}
+
+var serverStartCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Start the managed llama-server and wait for it to load the model",
+	Args:  cobra.NoArgs,
+	RunE:  runServerStart,
+}
+
+var serverStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop the managed llama-server",
+	Args:  cobra.NoArgs,
+	RunE:  runServerStop,
+}
+
+// serverRunCmd is the process that watches over llama-server; cliq starts
+// it in the background
+var serverRunCmd = &cobra.Command{
+	Use:    "run",
+	Short:  "Run llama-server in the foreground, stopping it when idle",
+	Args:   cobra.NoArgs,
+	Hidden: true,
+	RunE:   runServerRun,
+}
+
+func init() {
+	rootCmd.AddCommand(serverCmd)
+	serverCmd.AddCommand(serverStatusCmd)
+	serverCmd.AddCommand(serverStartCmd)
+	serverCmd.AddCommand(serverStopCmd)
+	serverCmd.AddCommand(serverRunCmd)
+
+	serverRunCmd.Flags().String("model", "", "GGUF model to serve")
+	serverRunCmd.Flags().Duration("idle", 15*time.Minute, "stop after this long without a query")
+}
+
+// serverIdle is how long the managed llama-server may go unused
+func serverIdle(cfg *config.Config) time.Duration {
+	minutes := cfg.Model.ServerIdleMinutes
+	if minutes <= 0 {
+		minutes = config.Default().Model.ServerIdleMinutes
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+func runServerStatus(cmd *cobra.Command, args []string) error {
+	s, err := llm.LoadManagedServer()
+	if err != nil {
+		return err
+	}
+	if s == nil {
+		fmt.Println("The managed llama-server isn't running.")
+		cfg, err := config.Load()
+		if err == nil && !cfg.Model.ManageServer {
+			fmt.Println("Set manage_server = true under [model] in your config to have cliq run it, or start it with: cliq server start")
+		}
+		return nil
+	}
+
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("99"))
+	row := func(label, value string) {
+		fmt.Printf("%s %s\n", labelStyle.Render(fmt.Sprintf("%-10s", label+":")), value)
+	}
+	row("URL", s.URL)
+	row("Model", filepath.Base(s.Model))
+	row("PID", fmt.Sprint(s.ServerPid))
+	row("Running", "since "+s.Started.Format(time.DateTime))
+	row("Last used", s.LastUsed.Format(time.DateTime))
+	return nil
+}
+
+func runServerStart(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = config.Default()
+	}
+	modelPath := cfg.GetModelPath()
+	fmt.Printf("Loading %s into llama-server...\n", filepath.Base(modelPath))
+	url, err := llm.StartManagedServer(cmd.Context(), modelPath, serverIdle(cfg))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("llama-server is running on %s, and stops after %s unused.\n", url, serverIdle(cfg))
+	return nil
+}
+
+func runServerStop(cmd *cobra.Command, args []string) error {
+	stopped, err := llm.StopManagedServer()
+	if err != nil {
+		return err
+	}
+	if !stopped {
+		fmt.Println("The managed llama-server isn't running.")
+		return nil
+	}
+	fmt.Println("Stopped the managed llama-server.")
+	return nil
+}
+
+func runServerRun(cmd *cobra.Command, args []string) error {
+	model, _ := cmd.Flags().GetString("model")
+	idle, _ := cmd.Flags().GetDuration("idle")
+	if model == "" {
+		cfg, err := config.Load()
+		if err != nil {
+			cfg = config.Default()
+		}
+		model = cfg.GetModelPath()
+	}
+	return llm.RunManagedServer(cmd.Context(), model, idle)
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+
+	"github.com/cliq-cli/cliq/internal/config"
+	"github.com/cliq-cli/cliq/internal/store"
+)
+
+// sessionCmd represents the session command
+var sessionCmd = &cobra.Command{
+	Use:   "session",
+	Short: "Manage the saved interactive session",
+	Long: `Manage the interactive mode session. Questions and answers from
+'cliq -i' are saved and restored the next time you start it.
+
+Subcommands:
+  export  Export the session to Markdown
+  clear   Start a fresh session
+
+Examples:
+  cliq session export
+  cliq session export notes.md
+  cliq session clear`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// sessionExportCmd represents the session export command
+var sessionExportCmd = &cobra.Command{
+	Use:   "export [file]",
+	Short: "Export the session to Markdown",
+	Long:  `Export the saved interactive session as Markdown, to a file or to stdout.`,
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runSessionExport,
+}
+
+// sessionClearCmd represents the session clear command
+var sessionClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Start a fresh session",
+	Long: `Start a fresh interactive session, and forget the one-shot questions
+kept for 'cliq -c' and 'cliq redo', so neither goes back to a question
+asked before.`,
+	RunE: runSessionClear,
+}
+
+func init() {
+	rootCmd.AddCommand(sessionCmd)
+	sessionCmd.AddCommand(sessionExportCmd)
+	sessionCmd.AddCommand(sessionClearCmd)
+}
+
+func runSessionExport(cmd *cobra.Command, args []string) error {
+	session, err := store.LoadSession()
+	if err != nil {
+		return fmt.Errorf("failed to load session: %w", err)
+	}
+
+	if len(session.Entries) == 0 {
+		return fmt.Errorf("the session is empty. Start one with: cliq -i")
+	}
+
+	markdown := session.ToMarkdown()
+	if len(args) == 0 {
+		fmt.Print(markdown)
+		return nil
+	}
+
+	if err := os.WriteFile(args[0], []byte(markdown), config.FilePerm); err != nil {
+		return fmt.Errorf("failed to write %s: %w", args[0], err)
+	}
+
+	successStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	fmt.Println(successStyle.Render(fmt.Sprintf("✓ Exported %d entries to %s", len(session.Entries), args[0])))
+	return nil
+}
+
+// historyRetention returns the retention policy for the history stores
+func historyRetention(cfg *config.Config) store.Retention {
+	return store.Retention{
+		MaxEntries: cfg.History.MaxEntries,
+		MaxAge:     time.Duration(cfg.History.MaxAgeDays) * 24 * time.Hour,
+		Exclude:    cfg.History.Exclude,
+	}
+}
+
+func runSessionClear(cmd *cobra.Command, args []string) error {
+	session, err := store.LoadSession()
+	if err != nil {
+		return fmt.Errorf("failed to load session: %w", err)
+	}
+
+	session.Clear()
+	if err := session.Save(); err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+	if err := (&store.Queries{}).Save(); err != nil {
+		return fmt.Errorf("failed to clear history: %w", err)
+	}
+
+	fmt.Println("Started a fresh session")
+	return nil
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cliq-cli/cliq/internal/config"
+	"github.com/cliq-cli/cliq/internal/llm"
+	"github.com/cliq-cli/cliq/internal/response"
+)
+
+// maxHookOutput is the most of a before hook's output that goes into the
+// prompt
+const maxHookOutput = 2000
+
+// runBeforeHooks runs hooks.before for a query, all at once, and returns
+// what they printed for the prompt when hooks.context is on, and not with
+// --no-context. A hook that fails or prints nothing adds nothing.
+func runBeforeHooks(ctx context.Context, h config.HooksConfig, query string) []llm.CommandOutput {
+	if len(h.Before) == 0 {
+		return nil
+	}
+
+	outputs := make([]llm.CommandOutput, len(h.Before))
+	var wg sync.WaitGroup
+	for i, command := range h.Before {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var stdout bytes.Buffer
+			if err := runHook(ctx, h, command, []string{"CLIQ_QUERY=" + query}, nil, &stdout); err != nil {
+				if verbose {
+					fmt.Fprintf(os.Stderr, "Warning: before hook %q: %v\n", command, err)
+				}
+				return
+			}
+			out := strings.TrimSpace(stdout.String())
+			if len(out) > maxHookOutput {
+				out = strings.ToValidUTF8(out[:maxHookOutput], "") + "…"
+			}
+			outputs[i] = llm.CommandOutput{Command: command, Output: out}
+		}()
+	}
+	wg.Wait()
+
+	if !h.Context || noContext {
+		return nil
+	}
+	var printed []llm.CommandOutput
+	for _, o := range outputs {
+		if o.Output != "" {
+			printed = append(printed, o)
+		}
+	}
+	return printed
+}
+
+// runAfterHooks runs hooks.after with the answer, one after another. What
+// they print goes to out.
+func runAfterHooks(ctx context.Context, h config.HooksConfig, query string, resp *response.Response, out io.Writer) {
+	if len(h.After) == 0 {
+		return
+	}
+	answer, err := json.Marshal(resp)
+	if err != nil {
+		return
+	}
+	env := []string{
+		"CLIQ_QUERY=" + query,
+		"CLIQ_COMMAND=" + resp.Command,
+		"CLIQ_EXPLANATION=" + resp.Explanation,
+	}
+	for _, command := range h.After {
+		if err := runHook(ctx, h, command, env, answer, out); err != nil && verbose {
+			fmt.Fprintf(os.Stderr, "Warning: after hook %q: %v\n", command, err)
+		}
+	}
+}
+
+// runHook runs a hook command in the user's shell, with env added to the
+// environment and stdin as its input, for up to hooks.timeout_seconds
+func runHook(ctx context.Context, h config.HooksConfig, command string, env []string, stdin []byte, stdout io.Writer) error {
+	timeout := time.Duration(max(h.TimeoutSeconds, 1)) * time.Second
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, userShell(), "-c", command)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stdout = stdout
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timed out after %s", timeout)
+	}
+	if err != nil {
+		if msg, _, _ := strings.Cut(strings.TrimSpace(stderr.String()), "\n"); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
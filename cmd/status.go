@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+
+	"github.com/cliq-cli/cliq/internal/config"
+	"github.com/cliq-cli/cliq/internal/llm"
+)
+
+// statusCmd represents the status command
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the active backend and the health of each backend",
+	Long: `Show which backend and model a query would use right now, and each
+backend's recent error rate and latency.
+
+A backend whose recent queries mostly failed is marked unhealthy and skipped
+for a few minutes, so queries fall back to the next available backend. Run
+'cliq doctor --fix' to clear the cool-down early.`,
+	Args: cobra.NoArgs,
+	RunE: runStatus,
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+}
+
+func runStatus(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = config.Default()
+	}
+
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("99"))
+	okStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	warnStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
+	client, err := llm.NewClient(cfg.GetModelPath(), cfg.Model.OllamaModel, cfg.Model.Temperature, cfg.Model.MaxTokens)
+	if err != nil {
+		return err
+	}
+	fmt.Println(labelStyle.Render("Active backend: ") + client.Choice().Label())
+
+	health := llm.Health()
+	if len(health) == 0 {
+		fmt.Println(dimStyle.Render("No queries recorded yet"))
+		return nil
+	}
+
+	fmt.Println()
+	fmt.Println(labelStyle.Render("Backend health (last queries):"))
+	for _, h := range health {
+		state := okStyle.Render("healthy")
+		if !h.Healthy() {
+			state = warnStyle.Render(fmt.Sprintf("unhealthy, retried in %s", time.Until(h.UnhealthyUntil).Round(time.Second)))
+		}
+		fmt.Printf("  %-14s %s  %d/%d failed (%.0f%%), median %s\n",
+			h.Backend, state, h.Errors, h.Queries, h.ErrorRate*100, h.MedianLatency.Round(time.Millisecond))
+		if h.LastError != "" && h.Errors > 0 {
+			msg, _, _ := strings.Cut(h.LastError, "\n")
+			fmt.Println(dimStyle.Render("    last error: " + msg))
+		}
+	}
+	return nil
+}
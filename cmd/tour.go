@@ -0,0 +1,349 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+
+	"github.com/cliq-cli/cliq/internal/config"
+	"github.com/cliq-cli/cliq/internal/keynotation"
+	"github.com/cliq-cli/cliq/internal/parser"
+	"github.com/cliq-cli/cliq/internal/response"
+	"github.com/cliq-cli/cliq/internal/terminal"
+	"github.com/cliq-cli/cliq/internal/theme"
+)
+
+// tourCmd represents the tour command
+var tourCmd = &cobra.Command{
+	Use:   "tour",
+	Short: "Take a guided tour of cliq",
+	Long: `Walk through asking questions, copying commands, interactive mode, and
+customizing cliq, with examples taken from your own Neovim, tmux, and shell
+configuration. You can try a question along the way.`,
+	Args: cobra.NoArgs,
+	RunE: runTour,
+}
+
+func init() {
+	rootCmd.AddCommand(tourCmd)
+}
+
+// tourStep is one page of the tour
+type tourStep struct {
+	title string
+	body  string
+	// try steps take a question and answer it
+	try bool
+}
+
+// tourAnswerMsg carries the answer to the question asked on a try step
+type tourAnswerMsg struct {
+	answer *response.Response
+	err    error
+}
+
+// tourModel is the state of the tour
+type tourModel struct {
+	ctx     context.Context
+	cfg     *config.Config
+	steps   []tourStep
+	step    int
+	input   textinput.Model
+	asking  bool
+	answer  *response.Response
+	err     error
+	status  string
+	width   int
+	example string
+}
+
+var (
+	tourHeadingStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("99"))
+	tourCodeStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+)
+
+func runTour(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = config.Default()
+	}
+	t, err := theme.Load(cfg.TUI.Theme)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v; using the %s theme\n", err, t.Name)
+	}
+	applyTheme(t)
+
+	var nvimConfig *parser.NvimConfig
+	var tmuxConfig *parser.TmuxConfig
+	if cfg.Nvim.ConfigPath != "" {
+		nvimConfig, _ = parser.ParseNvimConfig(cfg.Nvim.ConfigPath)
+	}
+	if cfg.Tmux.ConfigPath != "" {
+		tmuxConfig, _ = parser.ParseTmuxConfig(cfg.Tmux.ConfigPath)
+	}
+	shellConfig := parseShellConfig(cfg)
+
+	input := textinput.New()
+	input.Prompt = "> "
+	input.CharLimit = 200
+	example := tourExample(tmuxConfig)
+	input.Placeholder = example
+
+	m := tourModel{
+		ctx:     cmd.Context(),
+		cfg:     cfg,
+		steps:   tourSteps(cfg, nvimConfig, tmuxConfig, shellConfig),
+		input:   input,
+		width:   80,
+		example: example,
+	}
+	// The tour's view owns the terminal, so the question tried in it is
+	// answered without printing what --verbose would
+	inView, verbose = true, false
+	p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithContext(cmd.Context()))
+	_, err = p.Run()
+	return err
+}
+
+// tourExample is the question suggested on the try step, picked for what
+// the user has configured
+func tourExample(tmuxConfig *parser.TmuxConfig) string {
+	if tmuxConfig != nil {
+		return "split tmux window vertically"
+	}
+	return "delete 3 lines in vim"
+}
+
+// tourSteps builds the pages of the tour, using the user's own setup in the
+// examples where there is one
+func tourSteps(cfg *config.Config, nvimConfig *parser.NvimConfig, tmuxConfig *parser.TmuxConfig, shellConfig *parser.ShellConfig) []tourStep {
+	notation := cfg.General.KeyNotation
+	code := func(s string) string { return tourCodeStyle.Render(s) }
+
+	// What cliq found
+	var setup strings.Builder
+	setup.WriteString("Cliq answers questions about Neovim, tmux, and the shell, and it reads\nyour configuration so answers use your keys. Here is what it found:\n\n")
+	if nvimConfig != nil {
+		fmt.Fprintf(&setup, "  Neovim   %s\n", cfg.Nvim.ConfigPath)
+		fmt.Fprintf(&setup, "           leader %s, %d keymaps, %d plugins\n",
+			code(formatLeader(nvimConfig.Leader)), len(nvimConfig.Keymaps), len(nvimConfig.Plugins))
+	} else {
+		setup.WriteString("  Neovim   not found (set nvim.config_path, or run cliq init)\n")
+	}
+	if tmuxConfig != nil {
+		fmt.Fprintf(&setup, "  tmux     %s\n", cfg.Tmux.ConfigPath)
+		fmt.Fprintf(&setup, "           prefix %s, %d bindings\n",
+			code(keynotation.Normalize(tmuxConfig.Prefix, notation)), len(tmuxConfig.Keymaps))
+	} else {
+		setup.WriteString("  tmux     not found (set tmux.config_path, or run cliq init)\n")
+	}
+	if shellConfig != nil && len(shellConfig.Aliases) > 0 {
+		fmt.Fprintf(&setup, "  Shell    %d aliases, %d functions\n", len(shellConfig.Aliases), len(shellConfig.Functions))
+	}
+	setup.WriteString("\nUse → or Tab to go on, ← or Shift+Tab to go back, and Esc to leave.")
+
+	// Asking, with one of the user's keymaps as what an answer looks like
+	var ask strings.Builder
+	ask.WriteString("Ask a question in plain words, quoted so the shell keeps it together:\n\n")
+	fmt.Fprintf(&ask, "  %s\n\n", code(fmt.Sprintf("cliq %q", tourExample(tmuxConfig))))
+	ask.WriteString("The answer is a command, what it does, and alternatives.")
+	if nvimConfig != nil && len(nvimConfig.Keymaps) > 0 {
+		km := nvimConfig.Keymaps[0]
+		fmt.Fprintf(&ask, " When one of your\nkeymaps fits, it's listed under \"In your setup\", for example:\n\n  %s -> %s\n",
+			code(keynotation.Normalize(km.Keys, notation)), km.Rhs)
+	} else {
+		ask.WriteString("\n")
+	}
+	if tmuxConfig != nil {
+		fmt.Fprintf(&ask, "\ntmux answers are written with your prefix, %s, rather than the default.\n",
+			code(keynotation.Normalize(tmuxConfig.Prefix, notation)))
+	}
+
+	copying := "For scripts and shell widgets, -q prints nothing but the command:\n\n" +
+		"  " + code(`cmd=$(cliq -q "list listening ports") && echo "$cmd"`) + "\n\n" +
+		"In interactive mode, Ctrl+Y copies the answer's command to the clipboard,\n" +
+		"and Ctrl+X twice runs it in your shell.\n\n" +
+		"If you tried a question on the last page, press c to copy its command."
+
+	interactive := "Run " + code("cliq -i") + " for interactive mode, where you can ask one question\nafter another:\n\n" +
+		"  ↑/↓       earlier questions        Ctrl+R   search them\n" +
+		"  Esc       cancel an answer         Tab      browse past answers\n" +
+		"  Ctrl+Y    copy the command         Ctrl+X   run it\n" +
+		"  Ctrl+G    ask again, differently   Ctrl+S   temperature and style\n" +
+		"  Ctrl+O    switch backend or model  Ctrl+N   start a new session\n\n" +
+		"The session is saved, and restored the next time you start it."
+
+	style := cfg.General.ResponseStyle
+	if style == "" {
+		style = "concise"
+	}
+	keys := cfg.General.KeyNotation
+	if keys == "" {
+		keys = "as the model writes them"
+	}
+	customizing := "Settings live in " + code(config.GetConfigPath()) + ". Right now:\n\n" +
+		fmt.Sprintf("  response_style   %s (concise, detailed, minimal)\n", style) +
+		fmt.Sprintf("  key_notation     %s (vim, ctrl, caret)\n", keys) +
+		fmt.Sprintf("  keyboard_layout  %s\n\n", cfg.General.KeyboardLayout) +
+		"  " + code("cliq config edit") + "          open it in $EDITOR\n" +
+		"  " + code("cliq config show") + "          see what cliq parsed from your configs\n" +
+		"  " + code(`cliq context pin "..."`) + "    a note sent with every question\n" +
+		"  " + code(`cliq learn "q" "cmd"`) + "      your own answer to a question"
+
+	return []tourStep{
+		{title: "Welcome to cliq", body: setup.String()},
+		{title: "Asking questions", body: ask.String()},
+		{title: "Try it", body: "Type a question and press Enter, or press Enter on an empty line to ask\nthe suggested one.", try: true},
+		{title: "Copying commands", body: copying},
+		{title: "Interactive mode", body: interactive},
+		{title: "Making it yours", body: customizing},
+		{title: "That's it", body: "Run " + code("cliq tour") + " again any time, or " + code("cliq --help") + " for every command.\n\nPress Enter to finish."},
+	}
+}
+
+// formatLeader names the leader key for display
+func formatLeader(leader string) string {
+	switch leader {
+	case " ":
+		return "<Space>"
+	case "":
+		return "\\"
+	default:
+		return leader
+	}
+}
+
+func (m tourModel) Init() tea.Cmd {
+	return nil
+}
+
+// askTour answers a question through the normal query pipeline
+func (m tourModel) askTour(question string) tea.Cmd {
+	return func() tea.Msg {
+		answer, err := answerResponse(m.ctx, question, m.cfg, nil)
+		return tourAnswerMsg{answer: answer, err: err}
+	}
+}
+
+func (m tourModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		return m, nil
+
+	case tourAnswerMsg:
+		m.asking = false
+		m.answer, m.err = msg.answer, msg.err
+		return m, nil
+
+	case tea.KeyMsg:
+		current := m.steps[m.step]
+		switch msg.Type {
+		case tea.KeyCtrlC, tea.KeyEsc:
+			return m, tea.Quit
+		case tea.KeyTab:
+			return m.move(1)
+		case tea.KeyShiftTab:
+			return m.move(-1)
+		case tea.KeyEnter:
+			if current.try {
+				if m.asking {
+					return m, nil
+				}
+				question := strings.TrimSpace(m.input.Value())
+				if question == "" {
+					question = m.example
+				}
+				m.asking = true
+				m.answer, m.err = nil, nil
+				return m, m.askTour(question)
+			}
+			if m.step == len(m.steps)-1 {
+				return m, tea.Quit
+			}
+			return m.move(1)
+		}
+
+		if current.try {
+			var cmd tea.Cmd
+			m.input, cmd = m.input.Update(msg)
+			return m, cmd
+		}
+
+		switch msg.String() {
+		case "right", "l", "n", " ":
+			return m.move(1)
+		case "left", "h", "p":
+			return m.move(-1)
+		case "q":
+			return m, tea.Quit
+		case "c":
+			if m.answer == nil || m.answer.Command == "" {
+				m.status = "Nothing to copy yet: ask a question on the Try it page first"
+			} else if err := terminal.Copy(m.answer.Command); err != nil {
+				m.status = "Could not copy: " + err.Error()
+			} else {
+				m.status = "Copied " + m.answer.Command
+			}
+		}
+	}
+	return m, nil
+}
+
+// move goes forward or back by delta pages, focusing the input on try steps
+func (m tourModel) move(delta int) (tea.Model, tea.Cmd) {
+	m.step = min(max(m.step+delta, 0), len(m.steps)-1)
+	m.status = ""
+	if m.steps[m.step].try {
+		return m, m.input.Focus()
+	}
+	m.input.Blur()
+	return m, nil
+}
+
+func (m tourModel) View() string {
+	current := m.steps[m.step]
+
+	var sb strings.Builder
+	sb.WriteString(titleStyle.Render(fmt.Sprintf("cliq tour · %d/%d", m.step+1, len(m.steps))))
+	sb.WriteString("\n\n")
+	sb.WriteString(tourHeadingStyle.Render(current.title))
+	sb.WriteString("\n\n")
+	sb.WriteString(current.body)
+	sb.WriteString("\n")
+
+	if current.try {
+		sb.WriteString("\n")
+		sb.WriteString(m.input.View())
+		sb.WriteString("\n\n")
+		switch {
+		case m.asking:
+			sb.WriteString(helpStyle.Render("Thinking..."))
+		case m.err != nil:
+			sb.WriteString(errorStyle.Render("Error: " + m.err.Error()))
+		case m.answer != nil:
+			sb.WriteString(m.answer.ToTUI(max(m.width-4, 20)))
+		}
+		sb.WriteString("\n")
+	}
+
+	if m.status != "" {
+		sb.WriteString("\n")
+		sb.WriteString(helpStyle.Render(m.status))
+		sb.WriteString("\n")
+	}
+
+	help := "→/Tab: next • ←/Shift+Tab: back • Esc: quit"
+	if current.try {
+		help = "Enter: ask • Tab: next • Shift+Tab: back • Esc: quit"
+	}
+	sb.WriteString("\n")
+	sb.WriteString(helpStyle.Render(help))
+	return sb.String()
+}
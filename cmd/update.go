@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/cliq-cli/cliq/internal/config"
+	"github.com/cliq-cli/cliq/internal/terminal"
+	"github.com/cliq-cli/cliq/internal/update"
+)
+
+// updateWait is how long cliq waits on exit for an update check still in
+// flight; a check that doesn't finish in time is tried again once
+// update.Interval has passed, like one that failed
+const updateWait = time.Second
+
+// updateDone is closed when the update check started by rootPreRun ends,
+// and is nil when none was started
+var updateDone chan struct{}
+
+// updateCheckEnabled reports whether the user opted in to update checks and
+// didn't turn them off for this run. Development builds are never checked.
+func updateCheckEnabled(cfg *config.Config) bool {
+	version, _, _ := GetVersionInfo()
+	return cfg.Updates.Check && !noUpdates && os.Getenv("CLIQ_DISABLE_UPDATE_CHECK") == "" &&
+		version != "" && version != "dev"
+}
+
+// startUpdateCheck asks for the latest release in the background, at most
+// once per update.Interval
+func startUpdateCheck(ctx context.Context, cfg *config.Config) {
+	if !updateCheckEnabled(cfg) || !terminal.IsTerminal(os.Stderr) {
+		return
+	}
+
+	if !update.Due() {
+		updateDone = make(chan struct{})
+		close(updateDone)
+		return
+	}
+
+	version, _, _ := GetVersionInfo()
+	updateDone = make(chan struct{})
+	go func() {
+		defer close(updateDone)
+		if err := update.Refresh(ctx, version); err != nil && verbose {
+			fmt.Fprintf(os.Stderr, "Warning: update check failed: %v\n", err)
+		}
+	}()
+}
+
+// finishUpdateCheck waits briefly for the update check and prints a notice
+// on stderr when a newer release is out
+func finishUpdateCheck() {
+	if updateDone == nil {
+		return
+	}
+	select {
+	case <-updateDone:
+	case <-time.After(updateWait):
+	}
+
+	version, _, _ := GetVersionInfo()
+	if notice := update.Notice(version); notice != "" {
+		style := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+		fmt.Fprintln(os.Stderr, style.Render(notice))
+	}
+}
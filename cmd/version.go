@@ -1,56 +1,290 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"maps"
 	"os"
+	"os/exec"
 	"runtime"
+	"slices"
+	"sync"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/spf13/cobra"
 
 	"github.com/cliq-cli/cliq/internal/config"
 	"github.com/cliq-cli/cliq/internal/llm"
+	"github.com/cliq-cli/cliq/internal/parser"
+	"github.com/cliq-cli/cliq/internal/update"
 )
 
 // versionCmd represents the version command
 var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Show version information",
-	Long:  `Display version information including the Cliq version, model details, and system information.`,
-	Run:   runVersion,
+	Long: `Display version information including the Cliq version, model details, and system information.
+
+Which backends are reachable, the backend and model a query would use, the
+config files in effect, and how old the parsed config cache is are shown
+too. With --json all of it is printed as one JSON object, to paste into a
+bug report or read from a script.
+
+Examples:
+  cliq version
+  cliq version --json`,
+	Args: cobra.NoArgs,
+	RunE: runVersion,
 }
 
 func init() {
 	rootCmd.AddCommand(versionCmd)
+
+	versionCmd.Flags().Bool("json", false, "print the version and environment as JSON")
 }
 
-func runVersion(cmd *cobra.Command, args []string) {
-	version, commit, date := GetVersionInfo()
+// versionReport is the build and the state of the environment, as cliq
+// version --json prints them
+type versionReport struct {
+	Version string `json:"version"`
+	Commit  string `json:"commit"`
+	Built   string `json:"built"`
+	Go      string `json:"go"`
+	OS      string `json:"os"`
+	Arch    string `json:"arch"`
+	// UpdateCheck is "off", "on", or the notice of a newer release
+	UpdateCheck string `json:"update_check"`
+
+	// Backends are whether each backend, and each backend plugin in PATH,
+	// can be reached right now
+	Backends map[string]bool `json:"backends"`
+	Active   versionActive   `json:"active"`
+	Model    versionModel    `json:"model"`
+	Config   versionConfig   `json:"config"`
+	Cache    versionCache    `json:"cache"`
+}
+
+// versionActive is the backend and model a query would use
+type versionActive struct {
+	Backend string `json:"backend"`
+	Model   string `json:"model,omitempty"`
+}
+
+// versionModel is the GGUF model llama-cli runs
+type versionModel struct {
+	Name      string `json:"name"`
+	Path      string `json:"path"`
+	Installed bool   `json:"installed"`
+	SizeBytes int64  `json:"size_bytes,omitempty"`
+}
+
+// versionConfig is the config in effect and what was parsed from the
+// Neovim and tmux configs it points at
+type versionConfig struct {
+	Files      []string `json:"files"`
+	Version    int      `json:"version"`
+	Profile    string   `json:"profile,omitempty"`
+	NvimConfig string   `json:"nvim_config,omitempty"`
+	TmuxConfig string   `json:"tmux_config,omitempty"`
+	Shell      []string `json:"shell_configs,omitempty"`
+	// Problems counts what cliq config validate would report
+	Problems int `json:"problems"`
+	// Parsed summarizes the cached parse: keymap and plugin counts, the
+	// leader, and the tmux prefix
+	Parsed map[string]any `json:"parsed,omitempty"`
+}
+
+// versionCache is the parsed config cache
+type versionCache struct {
+	Path       string    `json:"path"`
+	Enabled    bool      `json:"enabled"`
+	LastParsed time.Time `json:"last_parsed,omitzero"`
+	AgeSeconds int64     `json:"age_seconds,omitempty"`
+	Stale      bool      `json:"stale"`
+}
+
+func runVersion(cmd *cobra.Command, args []string) error {
+	asJSON, _ := cmd.Flags().GetBool("json")
+
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = config.Default()
+	}
+	info := gatherVersionReport(cfg)
+
+	if asJSON {
+		data, err := json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
 
 	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("42"))
 	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("99"))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
 
 	fmt.Println(titleStyle.Render("Cliq - AI-powered CLI assistant for Neovim and tmux"))
 	fmt.Println()
 
-	fmt.Printf("%s %s\n", labelStyle.Render("Version:"), version)
-	fmt.Printf("%s %s\n", labelStyle.Render("Commit:"), commit)
-	fmt.Printf("%s %s\n", labelStyle.Render("Built:"), date)
-	fmt.Printf("%s %s\n", labelStyle.Render("Go:"), runtime.Version())
-	fmt.Printf("%s %s/%s\n", labelStyle.Render("OS/Arch:"), runtime.GOOS, runtime.GOARCH)
+	fmt.Printf("%s %s\n", labelStyle.Render("Version:"), info.Version)
+	fmt.Printf("%s %s\n", labelStyle.Render("Commit:"), info.Commit)
+	fmt.Printf("%s %s\n", labelStyle.Render("Built:"), info.Built)
+	fmt.Printf("%s %s\n", labelStyle.Render("Go:"), info.Go)
+	fmt.Printf("%s %s/%s\n", labelStyle.Render("OS/Arch:"), info.OS, info.Arch)
 
-	// Check model status
-	cfg, err := config.Load()
-	if err != nil {
-		cfg = config.Default()
+	switch info.UpdateCheck {
+	case "off":
+		fmt.Printf("%s off (set updates.check = true to be told about new releases)\n", labelStyle.Render("Update Check:"))
+	default:
+		fmt.Printf("%s %s\n", labelStyle.Render("Update Check:"), info.UpdateCheck)
 	}
 
-	modelPath := cfg.GetModelPath()
-	if info, err := os.Stat(modelPath); err == nil {
-		sizeMB := float64(info.Size()) / (1024 * 1024)
-		fmt.Printf("%s %s (%.1f MB)\n", labelStyle.Render("Model:"), llm.ModelName, sizeMB)
-		fmt.Printf("%s %s\n", labelStyle.Render("Model Path:"), modelPath)
+	// Check model status
+	if info.Model.Installed {
+		sizeMB := float64(info.Model.SizeBytes) / (1024 * 1024)
+		fmt.Printf("%s %s (%.1f MB)\n", labelStyle.Render("Model:"), info.Model.Name, sizeMB)
+		fmt.Printf("%s %s\n", labelStyle.Render("Model Path:"), info.Model.Path)
 	} else {
 		fmt.Printf("%s Not installed (run 'cliq init')\n", labelStyle.Render("Model:"))
 	}
+
+	active := info.Active.Backend
+	if info.Active.Model != "" {
+		active += " · " + info.Active.Model
+	}
+	fmt.Printf("%s %s\n", labelStyle.Render("Backend:"), active)
+	names := []string{"llama-server", "ollama", "llama-cli"}
+	for _, name := range slices.Sorted(maps.Keys(info.Backends)) {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	for _, name := range names {
+		state := dimStyle.Render("not reachable")
+		if info.Backends[name] {
+			state = "reachable"
+		}
+		fmt.Printf("  %-13s %s\n", name, state)
+	}
+
+	switch {
+	case info.Cache.LastParsed.IsZero():
+		fmt.Printf("%s %s\n", labelStyle.Render("Config Cache:"), dimStyle.Render("never built"))
+	case info.Cache.Stale:
+		fmt.Printf("%s %s old, stale\n", labelStyle.Render("Config Cache:"), time.Duration(info.Cache.AgeSeconds)*time.Second)
+	default:
+		fmt.Printf("%s %s old\n", labelStyle.Render("Config Cache:"), time.Duration(info.Cache.AgeSeconds)*time.Second)
+	}
+	if info.Config.Problems > 0 {
+		fmt.Printf("%s %d problem(s); run cliq config validate\n", labelStyle.Render("Config:"), info.Config.Problems)
+	}
+	return nil
+}
+
+// gatherVersionReport collects the build and environment cliq version shows.
+// The backends are probed at once, so nothing running costs one timeout.
+func gatherVersionReport(cfg *config.Config) versionReport {
+	version, commit, date := GetVersionInfo()
+	info := versionReport{
+		Version:  version,
+		Commit:   commit,
+		Built:    date,
+		Go:       runtime.Version(),
+		OS:       runtime.GOOS,
+		Arch:     runtime.GOARCH,
+		Backends: map[string]bool{},
+	}
+
+	switch {
+	case !cfg.Updates.Check:
+		info.UpdateCheck = "off"
+	case update.Notice(version) != "":
+		info.UpdateCheck = update.Notice(version)
+	default:
+		info.UpdateCheck = "on"
+	}
+
+	var (
+		serverUp, ollamaUp bool
+		active             llm.BackendChoice
+		plugins            = llm.FindPlugins()
+		pluginUp           = make([]bool, len(plugins))
+		wg                 sync.WaitGroup
+	)
+	wg.Add(3 + len(plugins))
+	go func() {
+		defer wg.Done()
+		serverUp = llm.CheckLlamaServerRunning()
+	}()
+	go func() {
+		defer wg.Done()
+		ollamaUp = llm.CheckOllamaRunning()
+	}()
+	for i, p := range plugins {
+		go func() {
+			defer wg.Done()
+			_, err := p.Info(context.Background())
+			pluginUp[i] = err == nil
+		}()
+	}
+	go func() {
+		defer wg.Done()
+		if client, err := llm.NewClient(cfg.GetModelPath(), cfg.Model.OllamaModel, cfg.Model.Temperature, cfg.Model.MaxTokens); err == nil {
+			active = client.Choice()
+			client.Close()
+		}
+	}()
+	wg.Wait()
+	info.Backends["llama-server"] = serverUp
+	info.Backends["ollama"] = ollamaUp
+	_, cliErr := exec.LookPath("llama-cli")
+	_, llamaErr := exec.LookPath("llama")
+	info.Backends["llama-cli"] = cliErr == nil || llamaErr == nil
+	for i, p := range plugins {
+		info.Backends[p.Name] = pluginUp[i]
+	}
+	info.Active = versionActive{Backend: llm.BackendChoice{Backend: active.Backend}.Label(), Model: active.Model}
+
+	info.Model = versionModel{Name: llm.ModelName, Path: cfg.GetModelPath()}
+	if stat, err := os.Stat(info.Model.Path); err == nil {
+		info.Model.Installed = true
+		info.Model.SizeBytes = stat.Size()
+	}
+
+	info.Config = versionConfig{
+		Files:      configFiles(cfg),
+		Version:    cfg.Version,
+		Profile:    cfg.ActiveProfile,
+		NvimConfig: cfg.Nvim.ConfigPath,
+		TmuxConfig: cfg.Tmux.ConfigPath,
+		Shell:      cfg.GetShellConfigPaths(),
+	}
+	for _, path := range info.Config.Files {
+		if problems, err := config.ValidateFile(path); err == nil {
+			info.Config.Problems += len(problems)
+		}
+	}
+
+	info.Cache = versionCache{Enabled: cfg.Cache.Enabled}
+	info.Cache.Path, _ = parser.CachePath(cfg.Nvim.ActiveProfile)
+	if cache, err := parser.LoadCache(cfg.Nvim.ActiveProfile); err == nil && !cache.LastParsed.IsZero() {
+		info.Cache.LastParsed = cache.LastParsed
+		info.Cache.AgeSeconds = int64(time.Since(cache.LastParsed).Seconds())
+		info.Cache.Stale = cache.IsStale(cfg.Cache.TTLHours) || cache.NeedsRefresh()
+		info.Config.Parsed = map[string]any{}
+		if cache.NvimConfig != nil {
+			info.Config.Parsed["nvim_keymaps"] = len(cache.NvimConfig.Keymaps)
+			info.Config.Parsed["nvim_plugins"] = len(cache.NvimConfig.Plugins)
+			info.Config.Parsed["nvim_leader"] = cache.NvimConfig.Leader
+		}
+		if cache.TmuxConfig != nil {
+			info.Config.Parsed["tmux_keymaps"] = len(cache.TmuxConfig.Keymaps)
+			info.Config.Parsed["tmux_prefix"] = cache.TmuxConfig.Prefix
+		}
+	}
+	return info
 }
@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+
+	"github.com/cliq-cli/cliq/internal/config"
+	"github.com/cliq-cli/cliq/internal/parser"
+	"github.com/cliq-cli/cliq/internal/shelltype"
+	"github.com/cliq-cli/cliq/internal/terminal"
+	"github.com/cliq-cli/cliq/internal/whatsnew"
+)
+
+// whatsnewCmd represents the whatsnew command
+var whatsnewCmd = &cobra.Command{
+	Use:   "whatsnew",
+	Short: "Show what changed since you last looked",
+	Long: `Show the release notes for the versions since you last ran whatsnew,
+keeping to the changes that matter to your setup: your shell, plugin
+manager, and whether you use Neovim and tmux.
+
+The first time, the notes for the running version are shown.`,
+	RunE: runWhatsNew,
+}
+
+func init() {
+	rootCmd.AddCommand(whatsnewCmd)
+	whatsnewCmd.Flags().Bool("all", false, "include changes that don't affect your setup")
+	whatsnewCmd.Flags().String("since", "", "show changes after this version instead of the last one seen")
+}
+
+func runWhatsNew(cmd *cobra.Command, args []string) error {
+	all, _ := cmd.Flags().GetBool("all")
+	since, _ := cmd.Flags().GetString("since")
+	if since == "" {
+		since = whatsnew.Seen()
+	}
+
+	releases, err := whatsnew.Changelog()
+	if err != nil {
+		return err
+	}
+	version, _, _ := GetVersionInfo()
+	releases = whatsnew.Between(releases, since, version)
+
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("42"))
+	versionStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("99"))
+	noteStyle := lipgloss.NewStyle().Bold(true).PaddingLeft(2)
+	detailStyle := lipgloss.NewStyle().PaddingLeft(4).Width(80)
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
+	features := setupFeatures()
+	shown, hidden := 0, 0
+	for _, r := range releases {
+		var notes []whatsnew.Note
+		for _, n := range r.Notes {
+			if all || n.Relevant(features) {
+				notes = append(notes, n)
+			} else {
+				hidden++
+			}
+		}
+		if len(notes) == 0 {
+			continue
+		}
+
+		if shown == 0 {
+			fmt.Println(titleStyle.Render("What's new in cliq"))
+		}
+		fmt.Println()
+		fmt.Println(versionStyle.Render(r.Version))
+		for _, n := range notes {
+			fmt.Println(noteStyle.Render(n.Title))
+			fmt.Println(detailStyle.Render(n.Detail))
+		}
+		shown += len(notes)
+	}
+
+	if shown == 0 {
+		fmt.Println("Nothing new for your setup.")
+	}
+	if hidden > 0 {
+		fmt.Println()
+		fmt.Println(dimStyle.Render(fmt.Sprintf("%d other changes don't affect your setup (cliq whatsnew --all)", hidden)))
+	}
+
+	if version != "" && version != "dev" {
+		if err := whatsnew.MarkSeen(version); err != nil && verbose {
+			fmt.Fprintf(os.Stderr, "Warning: could not record the notes as read: %v\n", err)
+		}
+	}
+	return nil
+}
+
+// setupFeatures describes the user's setup in the terms release notes are
+// keyed by: nvim, tmux, tmux-session, the shell (zsh, bash, fish), and the
+// Neovim plugin managers in use
+func setupFeatures() map[string]bool {
+	features := map[string]bool{}
+
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = config.Default()
+	}
+
+	if cfg.Nvim.ConfigPath != "" {
+		features["nvim"] = true
+		if nvimConfig, err := parser.ParseNvimConfig(cfg.Nvim.ConfigPath); err == nil {
+			for _, manager := range nvimConfig.PluginManagers() {
+				features[manager] = true
+			}
+		}
+	}
+	if cfg.Tmux.ConfigPath != "" {
+		features["tmux"] = true
+	}
+	if parser.TmuxRunning() {
+		features["tmux"] = true
+		features["tmux-session"] = true
+	}
+
+	if shell := shelltype.Detect(); shell != "" {
+		features[shell] = true
+	}
+	for _, path := range cfg.GetShellConfigPaths() {
+		if shell := parser.StartupShell(path); shell != "" {
+			features[shell] = true
+		}
+	}
+	return features
+}
+
+// whatsNewNotice prints, once after an upgrade, how many changes in the new
+// version matter to the user's setup. It stays quiet for development
+// builds, outside a terminal, and when the user ran whatsnew itself.
+// setUp is whether a config file existed before the command ran.
+func whatsNewNotice(cmd *cobra.Command, setUp bool) {
+	version, _, _ := GetVersionInfo()
+	if version == "" || version == "dev" || cmd == whatsnewCmd || !terminal.IsTerminal(os.Stderr) {
+		return
+	}
+	if !whatsnew.NeedsNotice(version, setUp) {
+		return
+	}
+
+	releases, err := whatsnew.Changelog()
+	if err != nil {
+		return
+	}
+	features := setupFeatures()
+	count := 0
+	for _, r := range whatsnew.Between(releases, whatsnew.Seen(), version) {
+		for _, n := range r.Notes {
+			if n.Relevant(features) {
+				count++
+			}
+		}
+	}
+	if count == 0 {
+		return
+	}
+
+	style := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+	changes := "changes affect"
+	if count == 1 {
+		changes = "change affects"
+	}
+	fmt.Fprintln(os.Stderr, style.Render(fmt.Sprintf("cliq was updated to %s: %d %s your setup (cliq whatsnew)", version, count, changes)))
+}
@@ -0,0 +1,254 @@
+// Package cheatsheet builds cheatsheets of the user's own keybindings and
+// aliases from the parsed configs, grouped by category
+package cheatsheet
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/cliq-cli/cliq/internal/keynotation"
+	"github.com/cliq-cli/cliq/internal/parser"
+)
+
+// Other is the category of entries no rule places
+const Other = "Other"
+
+// Sheet is the cheatsheet for one tool
+type Sheet struct {
+	Title    string
+	Sections []Section
+}
+
+// Section is one category of a sheet
+type Section struct {
+	Category string
+	Entries  []Entry
+}
+
+// Entry is one binding or alias
+type Entry struct {
+	Keys string
+	// Mode is the Neovim mode or tmux key table, when it isn't the usual
+	// one, normal mode or the prefix table
+	Mode        string
+	Action      string
+	Description string
+}
+
+// Label is what the entry does: its description, or else its action
+func (e Entry) Label() string {
+	if e.Description != "" {
+		return e.Description
+	}
+	return e.Action
+}
+
+// categoryRule places entries whose action or description contains one of
+// words in category
+type categoryRule struct {
+	category string
+	words    []string
+}
+
+var nvimRules = []categoryRule{
+	{"Files & search", []string{"telescope", "fzf", "find", "grep", "files", "oil", "neo-tree", "nvim-tree", "explorer", "harpoon", "search"}},
+	{"Git", []string{"git", "fugitive", "hunk", "diffview", "blame"}},
+	{"LSP & code", []string{"lsp", "definition", "references", "rename", "code action", "format", "diagnostic", "hover", "implementation", "symbol"}},
+	{"Debugging & tests", []string{"dap", "debug", "breakpoint", "neotest", "test"}},
+	{"Windows & buffers", []string{"window", "split", "buffer", "bnext", "bprev", "bdelete", "<c-w>", "wincmd", "tab"}},
+	{"Terminal", []string{"term", "toggleterm"}},
+	{"Editing", []string{"comment", "yank", "paste", "undo", "surround", "indent", "move line", "replace", "join"}},
+	{"Saving & quitting", []string{"<cmd>w", "<cmd>q", ":w", ":q", "save", "quit", "write"}},
+}
+
+var tmuxRules = []categoryRule{
+	{"Config", []string{"source-file", "reload", "plugin"}},
+	{"Sessions", []string{"session", "switch-client", "detach", "choose-tree", "resurrect", "restore"}},
+	{"Panes", []string{"pane", "split", "navigator"}},
+	{"Windows", []string{"window"}},
+	{"Copy mode", []string{"copy", "paste", "yank", "buffer"}},
+}
+
+var shellRules = []categoryRule{
+	{"Git", []string{"git ", "lazygit", "gh "}},
+	{"Containers", []string{"docker", "podman", "kubectl", "k9s", "helm"}},
+	{"Navigation", []string{"cd ", "ls", "eza", "exa", "tree", "zoxide", "z "}},
+	{"Editors", []string{"nvim", "vim", "code "}},
+	{"Packages", []string{"brew", "apt", "dnf", "pacman", "npm", "pnpm", "yarn", "pip", "cargo"}},
+	{"tmux", []string{"tmux"}},
+}
+
+// categorize returns the category of the first rule matching text
+func categorize(rules []categoryRule, text string) string {
+	text = strings.ToLower(text)
+	for _, r := range rules {
+		for _, w := range r.words {
+			if strings.Contains(text, w) {
+				return r.category
+			}
+		}
+	}
+	return Other
+}
+
+// group builds a sheet from entries and their categories, with sections in
+// the order of rules and Other last
+func group(title string, rules []categoryRule, entries []Entry, categories []string) Sheet {
+	sheet := Sheet{Title: title}
+	order := make([]string, 0, len(rules)+1)
+	for _, r := range rules {
+		order = append(order, r.category)
+	}
+	for _, c := range categories {
+		if !slices.Contains(order, c) && c != Other {
+			order = append(order, c)
+		}
+	}
+	order = append(order, Other)
+
+	for _, category := range order {
+		var section Section
+		for i, e := range entries {
+			if categories[i] == category {
+				section.Entries = append(section.Entries, e)
+			}
+		}
+		if len(section.Entries) > 0 {
+			section.Category = category
+			sheet.Sections = append(sheet.Sections, section)
+		}
+	}
+	return sheet
+}
+
+// Nvim builds the Neovim sheet, with keys written in notation. Keymaps a
+// distribution or plugin binds by default are left out unless defaults is
+// set, and of keymaps bound to the same keys only the last is kept.
+func Nvim(cfg *parser.NvimConfig, notation string, defaults bool) Sheet {
+	last := map[string]int{}
+	for i, km := range cfg.Keymaps {
+		last[km.Mode+" "+km.Keys] = i
+	}
+
+	var entries []Entry
+	var categories []string
+	for i, km := range cfg.Keymaps {
+		if last[km.Mode+" "+km.Keys] != i || (km.Distro != "" && !defaults) {
+			continue
+		}
+		e := Entry{
+			Keys:        keynotation.Normalize(km.Keys, notation),
+			Action:      km.Rhs,
+			Description: km.Description,
+		}
+		if km.Mode != "n" {
+			e.Mode = km.Mode
+		}
+		entries = append(entries, e)
+		categories = append(categories, categorize(nvimRules, km.Description+" "+km.Rhs))
+	}
+	return group("Neovim", nvimRules, entries, categories)
+}
+
+// Tmux builds the tmux sheet, as for Nvim. Prefix-table keys are written
+// after the prefix.
+func Tmux(cfg *parser.TmuxConfig, notation string, defaults bool) Sheet {
+	last := map[string]int{}
+	for i, km := range cfg.Keymaps {
+		last[km.Table+" "+km.Key] = i
+	}
+
+	prefix := keynotation.Normalize(cfg.Prefix, notation)
+	var entries []Entry
+	var categories []string
+	for i, km := range cfg.Keymaps {
+		if last[km.Table+" "+km.Key] != i || (km.Plugin != "" && !defaults) {
+			continue
+		}
+		e := Entry{
+			Keys:        keynotation.Normalize(km.Key, notation),
+			Action:      km.Command,
+			Description: km.Description,
+		}
+		switch km.Table {
+		case "prefix":
+			e.Keys = prefix + " " + e.Keys
+		case "root":
+		default:
+			e.Mode = km.Table
+		}
+		category := categorize(tmuxRules, km.Command+" "+km.Description)
+		if strings.HasPrefix(km.Table, "copy-mode") {
+			category = "Copy mode"
+		}
+		entries = append(entries, e)
+		categories = append(categories, category)
+	}
+	return group("tmux", tmuxRules, entries, categories)
+}
+
+// Shell builds the shell sheet from aliases, by what they expand to, and
+// functions, which are listed by name
+func Shell(cfg *parser.ShellConfig) Sheet {
+	var entries []Entry
+	var categories []string
+	for _, a := range cfg.Aliases {
+		entries = append(entries, Entry{Keys: a.Name, Action: a.Expansion})
+		categories = append(categories, categorize(shellRules, a.Expansion+" "))
+	}
+	for _, f := range cfg.Functions {
+		entries = append(entries, Entry{Keys: f.Name, Action: "function"})
+		categories = append(categories, "Functions")
+	}
+	rules := append(append([]categoryRule{}, shellRules...), categoryRule{category: "Functions"})
+	return group("Shell", rules, entries, categories)
+}
+
+// Uncategorized returns the entries in the sheet's Other section
+func (s Sheet) Uncategorized() []Entry {
+	for _, section := range s.Sections {
+		if section.Category == Other {
+			return section.Entries
+		}
+	}
+	return nil
+}
+
+// Relabel moves entries out of Other into the categories given by labels,
+// keyed by their position in Uncategorized. Categories already on the sheet
+// are reused whatever their case.
+func (s *Sheet) Relabel(labels map[int]string) {
+	other := s.Uncategorized()
+	if len(other) == 0 || len(labels) == 0 {
+		return
+	}
+
+	var rest []Entry
+	for i, e := range other {
+		label := strings.TrimSpace(labels[i])
+		if label == "" || strings.EqualFold(label, Other) {
+			rest = append(rest, e)
+			continue
+		}
+		idx := -1
+		for j, section := range s.Sections {
+			if strings.EqualFold(section.Category, label) {
+				idx = j
+				break
+			}
+		}
+		if idx < 0 {
+			// New categories go before Other, which stays last
+			s.Sections = append(s.Sections[:len(s.Sections)-1], Section{Category: label}, s.Sections[len(s.Sections)-1])
+			idx = len(s.Sections) - 2
+		}
+		s.Sections[idx].Entries = append(s.Sections[idx].Entries, e)
+	}
+
+	last := len(s.Sections) - 1
+	if len(rest) == 0 {
+		s.Sections = s.Sections[:last]
+	} else {
+		s.Sections[last].Entries = rest
+	}
+}
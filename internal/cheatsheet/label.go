@@ -0,0 +1,59 @@
+package cheatsheet
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// LabelPrompt asks the model to put the sheet's uncategorized entries into
+// short categories, answering one numbered line per entry
+func LabelPrompt(s Sheet) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "These are %s shortcuts from a user's config. Give each one a short category, ", s.Title)
+	sb.WriteString("two or three words such as \"Navigation\" or \"Quickfix\".")
+	var existing []string
+	for _, section := range s.Sections {
+		if section.Category != Other {
+			existing = append(existing, section.Category)
+		}
+	}
+	if len(existing) > 0 {
+		fmt.Fprintf(&sb, " Reuse these categories where one fits: %s.", strings.Join(existing, ", "))
+	}
+	sb.WriteString("\n\nAnswer with one line per shortcut, as the number, a colon, and the category, and nothing else.\n\n")
+	for i, e := range s.Uncategorized() {
+		fmt.Fprintf(&sb, "%d: %s -> %s", i+1, e.Keys, e.Action)
+		if e.Description != "" {
+			fmt.Fprintf(&sb, " (%s)", e.Description)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// labelLineRe matches a "3: Category" line of the model's answer
+var labelLineRe = regexp.MustCompile(`^\s*(\d+)[:.)]\s*(.+?)\s*$`)
+
+// ParseLabels reads the model's answer to LabelPrompt into categories keyed
+// by position in Uncategorized, ignoring lines that don't fit
+func ParseLabels(raw string, n int) map[int]string {
+	labels := map[int]string{}
+	for _, line := range strings.Split(raw, "\n") {
+		m := labelLineRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		i, err := strconv.Atoi(m[1])
+		if err != nil || i < 1 || i > n {
+			continue
+		}
+		label := strings.Trim(m[2], "*\"'`.")
+		if label == "" || len(label) > 30 {
+			continue
+		}
+		labels[i-1] = label
+	}
+	return labels
+}
@@ -0,0 +1,116 @@
+package cheatsheet
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	titleStyle    = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("42"))
+	categoryStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("99"))
+	keysStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+	modeStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+)
+
+// keysWidth is the widest keys column in the terminal rendering
+const keysWidth = 24
+
+// Terminal renders sheets for the terminal, the keys of each section in an
+// aligned column
+func Terminal(sheets []Sheet) string {
+	var sb strings.Builder
+	for i, s := range sheets {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(titleStyle.Render(s.Title + " cheatsheet"))
+		sb.WriteString("\n")
+		for _, section := range s.Sections {
+			sb.WriteString("\n")
+			sb.WriteString(categoryStyle.Render(section.Category))
+			sb.WriteString("\n")
+
+			width := 0
+			for _, e := range section.Entries {
+				width = max(width, min(len(e.Keys), keysWidth))
+			}
+			for _, e := range section.Entries {
+				fmt.Fprintf(&sb, "  %s  %s", keysStyle.Render(fmt.Sprintf("%-*s", width, e.Keys)), e.Label())
+				if e.Mode != "" {
+					sb.WriteString(" " + modeStyle.Render("("+e.Mode+")"))
+				}
+				sb.WriteString("\n")
+			}
+		}
+	}
+	return sb.String()
+}
+
+// Markdown renders sheets as Markdown, a table per section
+func Markdown(sheets []Sheet) string {
+	cell := func(s string) string {
+		return strings.ReplaceAll(strings.Join(strings.Fields(s), " "), "|", `\|`)
+	}
+
+	var sb strings.Builder
+	for i, s := range sheets {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		fmt.Fprintf(&sb, "# %s cheatsheet\n", s.Title)
+		for _, section := range s.Sections {
+			fmt.Fprintf(&sb, "\n## %s\n\n| Keys | Mode | Does |\n| --- | --- | --- |\n", section.Category)
+			for _, e := range section.Entries {
+				fmt.Fprintf(&sb, "| `%s` | %s | %s |\n", cell(e.Keys), cell(e.Mode), cell(e.Label()))
+			}
+		}
+	}
+	return sb.String()
+}
+
+// htmlStyle lays sections out in columns that print on A4 or Letter
+const htmlStyle = `body { font: 11px/1.4 -apple-system, "Segoe UI", sans-serif; margin: 1.5em; color: #222; }
+h1 { font-size: 18px; margin: 0 0 .5em; border-bottom: 2px solid #5f5fd7; }
+.sheet { page-break-after: always; }
+.sheet:last-child { page-break-after: auto; }
+.sections { columns: 3 16em; column-gap: 1.5em; }
+section { break-inside: avoid; margin-bottom: 1em; }
+h2 { font-size: 13px; color: #5f5fd7; margin: 0 0 .3em; }
+table { border-collapse: collapse; width: 100%; }
+td { padding: 1px 4px; vertical-align: top; border-bottom: 1px solid #eee; }
+td.keys { white-space: nowrap; width: 1%; }
+kbd { font: 11px ui-monospace, Menlo, monospace; background: #f4f4f4; border: 1px solid #ccc; border-radius: 3px; padding: 0 3px; }
+.mode { color: #888; }
+@media print { body { margin: 0; } }
+`
+
+// HTML renders sheets as a standalone page, one sheet per printed page
+func HTML(sheets []Sheet) string {
+	var sb strings.Builder
+	titles := make([]string, 0, len(sheets))
+	for _, s := range sheets {
+		titles = append(titles, s.Title)
+	}
+	fmt.Fprintf(&sb, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s cheatsheet</title>\n<style>\n%s</style>\n</head>\n<body>\n",
+		html.EscapeString(strings.Join(titles, ", ")), htmlStyle)
+	for _, s := range sheets {
+		fmt.Fprintf(&sb, "<div class=\"sheet\">\n<h1>%s cheatsheet</h1>\n<div class=\"sections\">\n", html.EscapeString(s.Title))
+		for _, section := range s.Sections {
+			fmt.Fprintf(&sb, "<section>\n<h2>%s</h2>\n<table>\n", html.EscapeString(section.Category))
+			for _, e := range section.Entries {
+				fmt.Fprintf(&sb, "<tr><td class=\"keys\"><kbd>%s</kbd></td><td>%s", html.EscapeString(e.Keys), html.EscapeString(e.Label()))
+				if e.Mode != "" {
+					fmt.Fprintf(&sb, " <span class=\"mode\">(%s)</span>", html.EscapeString(e.Mode))
+				}
+				sb.WriteString("</td></tr>\n")
+			}
+			sb.WriteString("</table>\n</section>\n")
+		}
+		sb.WriteString("</div>\n</div>\n")
+	}
+	sb.WriteString("</body>\n</html>\n")
+	return sb.String()
+}
@@ -9,27 +9,89 @@ import (
 
 // Config represents the application configuration
 type Config struct {
-	General GeneralConfig `toml:"general"`
-	Model   ModelConfig   `toml:"model"`
-	Nvim    NvimConfig    `toml:"nvim"`
-	Tmux    TmuxConfig    `toml:"tmux"`
-	Cache   CacheConfig   `toml:"cache"`
-	TUI     TUIConfig     `toml:"tui"`
+	// Version is the layout of the file, which cliq upgrades when it
+	// changes; see ConfigVersion
+	Version    int              `toml:"version"`
+	General    GeneralConfig    `toml:"general"`
+	Model      ModelConfig      `toml:"model"`
+	Nvim       NvimConfig       `toml:"nvim"`
+	Tmux       TmuxConfig       `toml:"tmux"`
+	Shell      ShellConfig      `toml:"shell"`
+	Cache      CacheConfig      `toml:"cache"`
+	TUI        TUIConfig        `toml:"tui"`
+	History    HistoryConfig    `toml:"history"`
+	Encryption EncryptionConfig `toml:"encryption"`
+	Updates    UpdatesConfig    `toml:"updates"`
+	Hooks      HooksConfig      `toml:"hooks"`
+	Notify     NotifyConfig     `toml:"notify"`
+	// Invocations are selected by the name cliq runs as, keyed by that name
+	Invocations map[string]Invocation `toml:"invocations"`
+	// Profiles are sets of settings picked with --profile, keyed by name,
+	// each laid over the rest of the config like a file of its own
+	Profiles map[string]map[string]any `toml:"profiles,omitempty"`
+	// ActiveProfile is the profile Load laid over the config, if any
+	ActiveProfile string `toml:"-"`
+
+	// migration is the upgrade Load made to the file
+	migration Migration
+
+	// overrides are the settings environment variables and the project
+	// file overrode, which Save leaves as the global config file had them
+	overrides map[string]override
 }
 
 // GeneralConfig holds general application settings
 type GeneralConfig struct {
-	ResponseStyle string `toml:"response_style"` // concise, detailed, minimal
+	ResponseStyle  string `toml:"response_style"`  // concise, detailed, minimal
+	KeyboardLayout string `toml:"keyboard_layout"` // qwerty, colemak, dvorak, azerty, qwertz
+	KeyNotation    string `toml:"key_notation"`    // vim (<C-b>), ctrl (Ctrl-b), caret (^B); empty keeps the answer's own
+	// PrivacyMode answers from the generic prompt alone, never reading the
+	// Neovim, tmux, or shell configs or sending anything from them
+	PrivacyMode bool `toml:"privacy_mode"`
+	// ContextFiles go into every prompt, like a project's context pack;
+	// relative paths are from the file that sets them, usually a project's
+	// .cliq.toml
+	ContextFiles []string `toml:"context_files,omitempty"`
 }
 
 // ModelConfig holds model-related settings
 type ModelConfig struct {
-	Path        string  `toml:"path"`
-	Backend     string  `toml:"backend"`      // ollama, llama-server, llama-cli, auto
-	OllamaModel string  `toml:"ollama_model"` // model name for ollama (default: phi3)
-	AutoUpdate  bool    `toml:"auto_update"`
-	Temperature float64 `toml:"temperature"`
-	MaxTokens   int     `toml:"max_tokens"`
+	Path           string  `toml:"path"`
+	Backend        string  `toml:"backend"`      // ollama, llama-server, llama-cli, auto, or a plugin's name
+	OllamaModel    string  `toml:"ollama_model"` // model name for ollama (default: phi3)
+	PluginModel    string  `toml:"plugin_model"` // model a backend plugin is asked for ("" = its default)
+	AutoUpdate     bool    `toml:"auto_update"`
+	Temperature    float64 `toml:"temperature"`
+	MaxTokens      int     `toml:"max_tokens"`
+	TopP           float64 `toml:"top_p"`            // 0 = the backend's default
+	TopK           int     `toml:"top_k"`            // 0 = the backend's default
+	Seed           *int    `toml:"seed,omitempty"`   // fixed seed for reproducible answers (unset = random)
+	CostConfirmUSD float64 `toml:"cost_confirm_usd"` // ask before remote queries costing more (0 = never)
+	// WarmSession keeps the model loaded for the whole of interactive mode:
+	// llama-cli is swapped for a llama-server cliq runs, and ollama is
+	// asked to keep the model in memory
+	WarmSession bool `toml:"warm_session"`
+	// ManageServer has cliq start llama-server itself on a free port and
+	// reuse it across runs, stopping it after ServerIdleMinutes unused
+	ManageServer      bool `toml:"manage_server"`
+	ServerIdleMinutes int  `toml:"server_idle_minutes"`
+	// GPULayers, Threads, and BatchSize override what cliq picks for
+	// llama.cpp from the GPU init found: -1 layers, or 0 threads or batch
+	// size, is automatic, and 0 layers runs on the CPU only
+	GPULayers int `toml:"gpu_layers"`
+	Threads   int `toml:"threads"`
+	BatchSize int `toml:"batch_size"`
+	// ChatTemplate formats prompts for llama-cli and llama-server: auto
+	// reads it from the GGUF, raw sends them as is, or a name such as phi3
+	// or llama3. ContextLength overrides the GGUF's context window (0 =
+	// the model's, up to 8192).
+	ChatTemplate  string `toml:"chat_template"`
+	ContextLength int    `toml:"context_length"`
+	// Retries is how often a query is retried with backoff when its
+	// backend fails transiently, as when it's down or rate limiting;
+	// Fallback is the backends then tried in turn (empty = none)
+	Retries  int      `toml:"retries"`
+	Fallback []string `toml:"fallback"`
 }
 
 // NvimConfig holds Neovim-related settings
@@ -38,12 +100,34 @@ type NvimConfig struct {
 	AutoDetect     bool     `toml:"auto_detect"`
 	ParsePlugins   bool     `toml:"parse_plugins"`
 	TrackedPlugins []string `toml:"tracked_plugins"`
+	// KeymapsFile is where --apply adds keymaps, relative to ConfigPath
+	KeymapsFile string `toml:"keymaps_file"`
+
+	// Profile is the NVIM_APPNAME to use when neither --nvim-profile nor
+	// NVIM_APPNAME picks one. Profiles maps profile names to their config
+	// directories, for those not at $XDG_CONFIG_HOME/<name>.
+	Profile  string            `toml:"profile"`
+	Profiles map[string]string `toml:"profiles"`
+
+	// ActiveProfile is the profile Load chose, whose directory it put in
+	// ConfigPath; empty for the default config
+	ActiveProfile string `toml:"-"`
+	// configuredPath is config_path as written, which Save keeps while
+	// ConfigPath is still the profile's profilePath
+	configuredPath, profilePath string
 }
 
 // TmuxConfig holds tmux-related settings
 type TmuxConfig struct {
 	ConfigPath string `toml:"config_path"`
 	AutoDetect bool   `toml:"auto_detect"`
+	Live       bool   `toml:"live"` // ask the running tmux server for its prefix, bindings, and session
+}
+
+// ShellConfig holds shell-related settings
+type ShellConfig struct {
+	ConfigPaths []string `toml:"config_paths"` // startup files read for aliases and functions (empty = detect)
+	AutoDetect  bool     `toml:"auto_detect"`
 }
 
 // CacheConfig holds caching settings
@@ -51,6 +135,11 @@ type CacheConfig struct {
 	Enabled  bool   `toml:"enabled"`
 	TTLHours int    `toml:"ttl_hours"`
 	Path     string `toml:"path"`
+	// Answers keeps the model's answers, so a question asked again about
+	// the same setup is answered from disk; AnswerTTLHours is how long
+	// they're kept (0 = until the setup changes)
+	Answers        bool `toml:"answers"`
+	AnswerTTLHours int  `toml:"answer_ttl_hours"`
 }
 
 // TUIConfig holds TUI-related settings
@@ -58,6 +147,74 @@ type TUIConfig struct {
 	Mouse    bool   `toml:"mouse"`
 	Theme    string `toml:"theme"` // auto, light, dark
 	ShowTips bool   `toml:"show_tips"`
+	Icons    string `toml:"icons"` // emoji (default), ascii, none
+}
+
+// HistoryConfig holds what interactive history is recorded and for how long
+type HistoryConfig struct {
+	MaxEntries int      `toml:"max_entries"`  // per history file (0 = unlimited)
+	MaxAgeDays int      `toml:"max_age_days"` // drop older entries (0 = keep forever)
+	Exclude    []string `toml:"exclude"`      // never record queries mentioning these
+	// AuditLog records every prompt sent to a backend and its response in
+	// audit.jsonl: off (default), remote for backends off this machine
+	// only, or all
+	AuditLog string `toml:"audit_log"`
+}
+
+// EncryptionConfig holds at-rest encryption of history and personal stores
+type EncryptionConfig struct {
+	Mode string `toml:"mode"` // off (default), passphrase, keychain
+}
+
+// UpdatesConfig holds the opt-in check for new releases
+type UpdatesConfig struct {
+	Check bool `toml:"check"` // ask once a day whether a newer release exists (off by default)
+}
+
+// HooksConfig holds shell commands run around each query
+type HooksConfig struct {
+	// Before are run before each query; with Context, what they print goes
+	// into the prompt, as for kubectl config current-context
+	Before  []string `toml:"before"`
+	Context bool     `toml:"context"`
+	// After are run once the answer is shown, with it in CLIQ_QUERY,
+	// CLIQ_COMMAND, and CLIQ_EXPLANATION and as JSON on stdin, as for a
+	// desktop notification
+	After          []string `toml:"after"`
+	TimeoutSeconds int      `toml:"timeout_seconds"` // how long each may run
+}
+
+// NotifyConfig holds the desktop notification for a slow answer
+type NotifyConfig struct {
+	// When is unfocused (default) to notify only when the terminal isn't
+	// focused, always, or off
+	When         string `toml:"when"`
+	AfterSeconds int    `toml:"after_seconds"` // how long a query takes before it's notified
+}
+
+// Invocation is what running cliq under another name, through a symlink
+// such as vimhow, selects for one-shot questions
+type Invocation struct {
+	Prefix string `toml:"prefix"` // put before the question, e.g. "In Neovim,"
+	Format string `toml:"format"` // text, json, markdown, cmd (empty = --format)
+	Style  string `toml:"style"`  // concise, detailed, minimal (empty = response_style)
+}
+
+// BuiltinInvocations are the names cliq answers to without configuration;
+// [invocations] entries of the same name replace them
+var BuiltinInvocations = map[string]Invocation{
+	"vimhow":  {Prefix: "In Neovim,"},
+	"tmuxhow": {Prefix: "In tmux,"},
+	"howdoi":  {Prefix: "In the shell,", Style: "minimal"},
+}
+
+// GetInvocation returns the invocation for the name cliq runs as
+func (c *Config) GetInvocation(name string) (Invocation, bool) {
+	if inv, ok := c.Invocations[name]; ok {
+		return inv, true
+	}
+	inv, ok := BuiltinInvocations[name]
+	return inv, ok
 }
 
 // Default returns a configuration with default values
@@ -66,16 +223,24 @@ func Default() *Config {
 	cacheDir, _ := GetCacheDir()
 
 	return &Config{
+		Version: ConfigVersion,
 		General: GeneralConfig{
-			ResponseStyle: "concise",
+			ResponseStyle:  "concise",
+			KeyboardLayout: "qwerty",
 		},
 		Model: ModelConfig{
-			Path:        filepath.Join(dataDir, "model", "phi-3-mini-q4.gguf"),
-			Backend:     "auto",
-			OllamaModel: "mistral",
-			AutoUpdate:  false,
-			Temperature: 0.3, // Lower temperature for factual accuracy
-			MaxTokens:   512,
+			Path:              filepath.Join(dataDir, "model", "phi-3-mini-q4.gguf"),
+			Backend:           "auto",
+			OllamaModel:       "mistral",
+			AutoUpdate:        false,
+			Temperature:       0.3, // Lower temperature for factual accuracy
+			MaxTokens:         512,
+			WarmSession:       true,
+			ServerIdleMinutes: 15,
+			GPULayers:         -1,
+			ChatTemplate:      "auto",
+			Retries:           2,
+			Fallback:          []string{"llama-server", "ollama", "llama-cli", "offline"},
 		},
 		Nvim: NvimConfig{
 			ConfigPath:   "",
@@ -87,60 +252,102 @@ func Default() *Config {
 				"nvim-tree.lua",
 				"which-key.nvim",
 			},
+			KeymapsFile: filepath.Join("lua", "config", "cliq-keymaps.lua"),
 		},
 		Tmux: TmuxConfig{
 			ConfigPath: "",
 			AutoDetect: true,
+			Live:       true,
+		},
+		Shell: ShellConfig{
+			AutoDetect: true,
 		},
 		Cache: CacheConfig{
-			Enabled:  true,
-			TTLHours: 24,
-			Path:     cacheDir,
+			Enabled:        true,
+			TTLHours:       24,
+			Path:           cacheDir,
+			Answers:        true,
+			AnswerTTLHours: 168,
 		},
 		TUI: TUIConfig{
 			Mouse:    true,
 			Theme:    "auto",
 			ShowTips: true,
+			Icons:    "emoji",
+		},
+		History: HistoryConfig{
+			MaxEntries: 1000,
+		},
+		Hooks: HooksConfig{
+			Context:        true,
+			TimeoutSeconds: 5,
+		},
+		Notify: NotifyConfig{
+			When:         "unfocused",
+			AfterSeconds: 10,
 		},
 	}
 }
 
-// Load loads the configuration from file
+// Load loads the configuration from file, with the settings of the
+// profile in effect, the project file, and environment variables laid over
+// it in turn
 func Load() (*Config, error) {
 	configPath := GetConfigPath()
 
 	data, err := os.ReadFile(configPath)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return Default(), nil
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+		data = nil
+	}
+
+	var migration Migration
+	if data != nil {
+		if data, migration, err = migrate(configPath, data); err != nil {
+			return nil, err
 		}
-		return nil, err
 	}
 
 	cfg := Default()
 	if err := toml.Unmarshal(data, cfg); err != nil {
 		return nil, err
 	}
+	cfg.migration = migration
+	if err := cfg.applyProfile(); err != nil {
+		return nil, err
+	}
+	projectPath, err := FindProjectFile()
+	if err != nil {
+		return nil, err
+	}
+	if projectPath != "" {
+		if err := cfg.applyProject(projectPath); err != nil {
+			return nil, err
+		}
+	}
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+	cfg.useNvimProfile()
 
 	return cfg, nil
 }
 
 // Save saves the configuration to file
 func (c *Config) Save() error {
-	configPath := GetConfigPath()
-
-	// Ensure directory exists
-	dir := filepath.Dir(configPath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return err
+	saved := c.withoutOverrides()
+	if c.Nvim.ActiveProfile != "" && c.Nvim.ConfigPath == c.Nvim.profilePath {
+		// Keep the profile out of config_path, or it would stick
+		saved.Nvim.ConfigPath = c.Nvim.configuredPath
 	}
-
-	data, err := toml.Marshal(c)
+	data, err := toml.Marshal(&saved)
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(configPath, data, 0644)
+	return WriteFile(GetConfigPath(), data)
 }
 
 // GetModelPath returns the full path to the model file
@@ -153,6 +360,24 @@ func (c *Config) GetModelPath() string {
 	return filepath.Join(dataDir, "model", "phi-3-mini-q4.gguf")
 }
 
+// GetShellConfigPaths returns the shell startup files to read aliases and
+// functions from: the configured ones, or the detected ones when none are
+// configured
+func (c *Config) GetShellConfigPaths() []string {
+	if len(c.Shell.ConfigPaths) == 0 {
+		if c.Shell.AutoDetect {
+			return DetectShellConfigs()
+		}
+		return nil
+	}
+
+	paths := make([]string, len(c.Shell.ConfigPaths))
+	for i, path := range c.Shell.ConfigPaths {
+		paths[i] = expandPath(path)
+	}
+	return paths
+}
+
 // expandPath expands ~ to home directory
 func expandPath(path string) string {
 	if len(path) > 0 && path[0] == '~' {
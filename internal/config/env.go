@@ -0,0 +1,135 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// EnvPrefix begins the environment variables that override settings: each
+// is the prefix and the setting's dotted key in capitals, with underscores
+// for the dots, so CLIQ_MODEL_BACKEND overrides model.backend
+const EnvPrefix = "CLIQ"
+
+// override is a setting an environment variable or the project file
+// overrode, with the global config file's value and the one it was given
+type override struct {
+	source      string
+	file, value reflect.Value
+}
+
+// EnvName returns the environment variable that overrides the setting at key
+func EnvName(key string) string {
+	return EnvPrefix + "_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// SettingKeys returns the dotted key of every setting, in the order of the
+// config file. Settings under named tables, like [invocations.gitq], aren't
+// included.
+func SettingKeys() []string {
+	return settingKeys(reflect.TypeOf(Config{}), "")
+}
+
+func settingKeys(t reflect.Type, prefix string) []string {
+	var keys []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, _, _ := strings.Cut(f.Tag.Get("toml"), ",")
+		if !f.IsExported() || tag == "" || tag == "-" {
+			continue
+		}
+		switch f.Type.Kind() {
+		case reflect.Struct:
+			keys = append(keys, settingKeys(f.Type, prefix+tag+".")...)
+		case reflect.Map:
+		default:
+			// The top level holds nothing but the version cliq keeps
+			if prefix != "" {
+				keys = append(keys, prefix+tag)
+			}
+		}
+	}
+	return keys
+}
+
+// OverriddenBy returns what overrides the global config file's value of
+// the setting at key, if anything does: an environment variable's name, or
+// the path of the project file
+func (c *Config) OverriddenBy(key string) (string, bool) {
+	o, ok := c.overrides[key]
+	return o.source, ok
+}
+
+// applyEnv overrides settings with the environment variables set for them,
+// read through viper's env layer. Values are written as for cliq config
+// set: lists comma-separated or as a TOML array.
+func (c *Config) applyEnv() error {
+	v := viper.New()
+	v.SetEnvPrefix(EnvPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+
+	for _, key := range SettingKeys() {
+		v.BindEnv(key)
+		if !v.IsSet(key) {
+			continue
+		}
+		value, err := ParseValue(key, v.GetString(key))
+		if err != nil {
+			return fmt.Errorf("%s: %w", EnvName(key), err)
+		}
+
+		t := c.settingField(key).Type()
+		set := reflect.ValueOf(value)
+		if t.Kind() == reflect.Pointer {
+			ptr := reflect.New(t.Elem())
+			ptr.Elem().Set(set.Convert(t.Elem()))
+			set = ptr
+		}
+		c.override(key, EnvName(key), set.Convert(t))
+	}
+	return nil
+}
+
+// override sets the setting at key to value, remembering the global config
+// file's value for Save
+func (c *Config) override(key, source string, value reflect.Value) {
+	field := c.settingField(key)
+	o, ok := c.overrides[key]
+	if !ok {
+		o.file = reflect.New(field.Type()).Elem()
+		o.file.Set(field)
+	}
+	field.Set(value)
+	o.source = source
+	o.value = reflect.New(field.Type()).Elem()
+	o.value.Set(field)
+
+	if c.overrides == nil {
+		c.overrides = map[string]override{}
+	}
+	c.overrides[key] = o
+}
+
+// withoutOverrides returns the config with the global config file's values
+// back for settings that were overridden and haven't changed since, for Save
+func (c Config) withoutOverrides() Config {
+	for key, o := range c.overrides {
+		field := c.settingField(key)
+		if reflect.DeepEqual(field.Interface(), o.value.Interface()) {
+			field.Set(o.file)
+		}
+	}
+	return c
+}
+
+// settingField returns the field of a setting under the struct sections
+func (c *Config) settingField(key string) reflect.Value {
+	v := reflect.ValueOf(c).Elem()
+	for _, part := range strings.Split(key, ".") {
+		f, _ := fieldByTag(v.Type(), part)
+		v = v.FieldByIndex(f.Index)
+	}
+	return v
+}
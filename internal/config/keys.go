@@ -0,0 +1,300 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// ErrSection is returned when a key names a section rather than a setting
+var ErrSection = errors.New("is a section, not a setting")
+
+// Get returns the setting at a dotted key, such as model.temperature, or a
+// whole section, such as model
+func (c *Config) Get(key string) (any, error) {
+	v := reflect.ValueOf(c).Elem()
+	for _, part := range strings.Split(key, ".") {
+		switch v.Kind() {
+		case reflect.Struct:
+			f, ok := fieldByTag(v.Type(), part)
+			if !ok {
+				return nil, unknownKey(key)
+			}
+			v = v.FieldByIndex(f.Index)
+		case reflect.Map:
+			elem := v.MapIndex(reflect.ValueOf(part))
+			if !elem.IsValid() {
+				return nil, fmt.Errorf("%s is not set", key)
+			}
+			v = elem
+		default:
+			return nil, unknownKey(key)
+		}
+	}
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil, fmt.Errorf("%s is not set", key)
+		}
+		v = v.Elem()
+	}
+	return v.Interface(), nil
+}
+
+// Plain converts a value Get returned to maps, lists, and scalars keyed by
+// their names in the TOML, as JSON shows them
+func Plain(v any) (any, error) {
+	data, err := toml.Marshal(map[string]any{"v": v})
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]any
+	if err := toml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return doc["v"], nil
+}
+
+// FormatTOML writes a section Get returned as it would appear in the
+// config file
+func FormatTOML(v any) (string, error) {
+	data, err := toml.Marshal(v)
+	return strings.TrimRight(string(data), "\n"), err
+}
+
+// ParseValue converts text to the type of the setting at key: true or
+// false for switches, numbers for numbers, and for lists either a TOML
+// array or comma-separated items
+func ParseValue(key, text string) (any, error) {
+	t, err := settingType(key)
+	if err != nil {
+		return nil, err
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return text, nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s is true or false, not %q", key, text)
+		}
+		return b, nil
+	case reflect.Int:
+		n, err := strconv.Atoi(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s is a whole number, not %q", key, text)
+		}
+		return n, nil
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s is a number, not %q", key, text)
+		}
+		return f, nil
+	case reflect.Slice:
+		if strings.HasPrefix(strings.TrimSpace(text), "[") {
+			var doc struct {
+				V []string `toml:"v"`
+			}
+			if err := toml.Unmarshal([]byte("v = "+text), &doc); err != nil {
+				return nil, fmt.Errorf("%s is a list of strings: %w", key, err)
+			}
+			return doc.V, nil
+		}
+		items := []string{}
+		for _, item := range strings.Split(text, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("%s %w", key, ErrSection)
+}
+
+// SetKey sets the setting at a dotted key in the config file to value,
+// leaving the rest of the file, comments included, as it was
+func SetKey(key string, value any) error {
+	if _, err := settingType(key); err != nil {
+		return err
+	}
+	encoded, err := toml.Marshal(map[string]any{"v": value})
+	if err != nil {
+		return err
+	}
+	_, literal, _ := strings.Cut(strings.TrimSpace(string(encoded)), "=")
+
+	section, name := splitKey(key)
+	lines, err := readConfigLines()
+	if err != nil {
+		return err
+	}
+	line := name + " = " + strings.TrimSpace(literal)
+	if start, end, ok := findKey(lines, section, name); ok {
+		lines = append(lines[:start], append([]string{line}, lines[end:]...)...)
+	} else if at, ok := sectionEnd(lines, section); ok {
+		lines = append(lines[:at], append([]string{line}, lines[at:]...)...)
+	} else {
+		if len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) != "" {
+			lines = append(lines, "")
+		}
+		lines = append(lines, "["+section+"]", line)
+	}
+	return writeConfigLines(lines, key)
+}
+
+// UnsetKey removes the setting at a dotted key from the config file, so
+// its default applies again. It reports whether the file set it.
+func UnsetKey(key string) (bool, error) {
+	if _, err := settingType(key); err != nil {
+		return false, err
+	}
+	section, name := splitKey(key)
+	lines, err := readConfigLines()
+	if err != nil {
+		return false, err
+	}
+	start, end, ok := findKey(lines, section, name)
+	if !ok {
+		return false, nil
+	}
+	return true, writeConfigLines(append(lines[:start], lines[end:]...), key)
+}
+
+// settingType returns the type of the setting or section at a dotted key
+func settingType(key string) (reflect.Type, error) {
+	t := reflect.TypeOf(Config{})
+	for _, part := range strings.Split(key, ".") {
+		switch t.Kind() {
+		case reflect.Struct:
+			f, ok := fieldByTag(t, part)
+			if !ok {
+				return nil, unknownKey(key)
+			}
+			t = f.Type
+		case reflect.Map:
+			t = t.Elem()
+		default:
+			return nil, unknownKey(key)
+		}
+	}
+	if t.Kind() == reflect.Struct || t.Kind() == reflect.Map {
+		return t, fmt.Errorf("%s %w", key, ErrSection)
+	}
+	if !strings.Contains(key, ".") {
+		return nil, fmt.Errorf("%s is kept by cliq itself", key)
+	}
+	return t, nil
+}
+
+// fieldByTag finds the struct field written as name in the TOML
+func fieldByTag(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, _, _ := strings.Cut(f.Tag.Get("toml"), ",")
+		if f.IsExported() && tag != "-" && tag == name {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func unknownKey(key string) error {
+	return fmt.Errorf("unknown setting %q", key)
+}
+
+// splitKey splits a dotted key into its table and its name in the table
+func splitKey(key string) (section, name string) {
+	i := strings.LastIndex(key, ".")
+	return key[:i], key[i+1:]
+}
+
+var (
+	headerRe = regexp.MustCompile(`^\s*\[\s*([^\[\]]+?)\s*\]\s*(#.*)?$`)
+	keyRe    = regexp.MustCompile(`^\s*("?)([A-Za-z0-9_-]+)("?)\s*=`)
+)
+
+// findKey finds the lines [start, end) of name's assignment in the section,
+// which is more than one line for a list written across several
+func findKey(lines []string, section, name string) (start, end int, ok bool) {
+	current := ""
+	for i := 0; i < len(lines); i++ {
+		if m := headerRe.FindStringSubmatch(lines[i]); m != nil {
+			current = strings.ReplaceAll(m[1], " ", "")
+			continue
+		}
+		m := keyRe.FindStringSubmatch(lines[i])
+		if current != section || m == nil || m[2] != name {
+			continue
+		}
+		end = i + 1
+		_, value, _ := strings.Cut(lines[i], "=")
+		depth := strings.Count(value, "[") - strings.Count(value, "]")
+		for depth > 0 && end < len(lines) {
+			depth += strings.Count(lines[end], "[") - strings.Count(lines[end], "]")
+			end++
+		}
+		return i, end, true
+	}
+	return 0, 0, false
+}
+
+// sectionEnd returns the line after the section's last setting, where a new
+// one goes
+func sectionEnd(lines []string, section string) (int, bool) {
+	found := false
+	at := 0
+	for i, line := range lines {
+		if m := headerRe.FindStringSubmatch(line); m != nil {
+			if found {
+				break
+			}
+			found = strings.ReplaceAll(m[1], " ", "") == section
+			at = i + 1
+			continue
+		}
+		if found && strings.TrimSpace(line) != "" {
+			at = i + 1
+		}
+	}
+	return at, found
+}
+
+// readConfigLines reads the config file as lines; a missing file has none
+func readConfigLines() ([]string, error) {
+	data, err := os.ReadFile(GetConfigPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return strings.Split(strings.TrimRight(string(data), "\n"), "\n"), nil
+}
+
+// writeConfigLines writes the config file after changing key, once it's
+// been checked to load and the key's new value is in range
+func writeConfigLines(lines []string, key string) error {
+	data := []byte(strings.Join(lines, "\n") + "\n")
+	if err := toml.Unmarshal(data, Default()); err != nil {
+		return fmt.Errorf("the edited config would not load: %w", err)
+	}
+	problems, err := Validate(data)
+	if err != nil {
+		return err
+	}
+	for _, p := range problems {
+		if p.Key == key {
+			return errors.New(p.String())
+		}
+	}
+	return WriteFile(GetConfigPath(), data)
+}
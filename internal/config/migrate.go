@@ -0,0 +1,92 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// ConfigVersion is the version of the config file's layout this cliq
+// writes. A file of an older version is upgraded by the migrations when
+// it's loaded.
+const ConfigVersion = 1
+
+// migration upgrades a config file, decoded as TOML, to version to from
+// the one before. It reports whether it changed any setting.
+type migration struct {
+	to      int
+	migrate func(doc map[string]any) bool
+}
+
+// unversioned is the version of a file that records none: the layout
+// cliq used before versions were recorded, which is version 1
+const unversioned = 1
+
+// migrations upgrade config files written by older versions of cliq, in
+// order. Renaming a setting or changing a default adds one, with
+// ConfigVersion raised to its version, so those settings aren't lost.
+var migrations []migration
+
+// Migration is an upgrade Load made to the config file
+type Migration struct {
+	From, To int
+	// Backup is where the file was saved as it was before
+	Backup string
+}
+
+// Migration returns the upgrade Load made to the config file, if it made
+// one
+func (c *Config) Migration() (Migration, bool) {
+	return c.migration, c.migration.To != 0
+}
+
+// fileVersion returns the version a decoded config file records, or
+// unversioned for one from before versions were
+func fileVersion(doc map[string]any) int {
+	v, ok := doc["version"].(int64)
+	if !ok {
+		return unversioned
+	}
+	return int(v)
+}
+
+// migrate upgrades the config file at path, whose contents are data, to
+// ConfigVersion, saving the old file next to it first. It returns the
+// upgraded contents, which are used even if they couldn't be written.
+// A file no migration changes is left alone, comments and all, to be
+// read as it is.
+func migrate(path string, data []byte) ([]byte, Migration, error) {
+	var doc map[string]any
+	if err := toml.Unmarshal(data, &doc); err != nil {
+		return nil, Migration{}, err
+	}
+	from := fileVersion(doc)
+	if from >= ConfigVersion {
+		return data, Migration{}, nil
+	}
+
+	changed := false
+	for _, m := range migrations {
+		if m.to > from && m.migrate(doc) {
+			changed = true
+		}
+	}
+
+	if !changed {
+		return data, Migration{}, nil
+	}
+	doc["version"] = ConfigVersion
+	upgraded, err := toml.Marshal(doc)
+	if err != nil {
+		return nil, Migration{}, err
+	}
+
+	m := Migration{From: from, To: ConfigVersion, Backup: fmt.Sprintf("%s.v%d.bak", path, from)}
+	if err := WriteFile(m.Backup, data); err != nil {
+		return upgraded, Migration{}, nil
+	}
+	if err := WriteFile(path, upgraded); err != nil {
+		return upgraded, Migration{}, nil
+	}
+	return upgraded, m, nil
+}
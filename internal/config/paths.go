@@ -1,11 +1,18 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
 )
 
+// ErrNoTmux is returned by DetectTmuxConfig on Windows, where tmux only runs
+// inside WSL
+var ErrNoTmux = errors.New("tmux isn't available on Windows")
+
 // GetConfigDir returns the configuration directory path
 func GetConfigDir() (string, error) {
 	// Check XDG_CONFIG_HOME first
@@ -13,6 +20,15 @@ func GetConfigDir() (string, error) {
 		return filepath.Join(xdgConfig, "cliq"), nil
 	}
 
+	// Windows keeps settings that roam with the user under %APPDATA%
+	if runtime.GOOS == "windows" {
+		appData, err := os.UserConfigDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(appData, "cliq"), nil
+	}
+
 	// Fall back to ~/.config
 	home, err := os.UserHomeDir()
 	if err != nil {
@@ -29,12 +45,17 @@ func GetDataDir() (string, error) {
 		return filepath.Join(xdgData, "cliq"), nil
 	}
 
-	// Fall back to ~/.local/share
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return "", err
 	}
 
+	// Windows keeps the model and the rest under %LOCALAPPDATA%
+	if runtime.GOOS == "windows" {
+		return filepath.Join(localAppData(home), "cliq"), nil
+	}
+
+	// Fall back to ~/.local/share
 	return filepath.Join(home, ".local", "share", "cliq"), nil
 }
 
@@ -45,12 +66,18 @@ func GetCacheDir() (string, error) {
 		return filepath.Join(xdgCache, "cliq"), nil
 	}
 
-	// Fall back to ~/.cache
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return "", err
 	}
 
+	// Under %LOCALAPPDATA% beside the data on Windows, which has no
+	// directory of its own for caches
+	if runtime.GOOS == "windows" {
+		return filepath.Join(localAppData(home), "cliq", "cache"), nil
+	}
+
+	// Fall back to ~/.cache
 	return filepath.Join(home, ".cache", "cliq"), nil
 }
 
@@ -63,6 +90,27 @@ func GetConfigPath() string {
 	return filepath.Join(configDir, "config.toml")
 }
 
+// localAppData returns %LOCALAPPDATA%, or ~/AppData/Local where it's unset
+func localAppData(home string) string {
+	if dir := os.Getenv("LOCALAPPDATA"); dir != "" {
+		return dir
+	}
+	return filepath.Join(home, "AppData", "Local")
+}
+
+// nvimConfigBase returns the directory Neovim looks for its config and
+// NVIM_APPNAME profiles in: $XDG_CONFIG_HOME, then %LOCALAPPDATA% on
+// Windows and ~/.config elsewhere
+func nvimConfigBase(home string) string {
+	if xdgConfig := os.Getenv("XDG_CONFIG_HOME"); xdgConfig != "" {
+		return xdgConfig
+	}
+	if runtime.GOOS == "windows" {
+		return localAppData(home)
+	}
+	return filepath.Join(home, ".config")
+}
+
 // DetectNvimConfig attempts to find the Neovim configuration directory
 func DetectNvimConfig() (string, error) {
 	home, err := os.UserHomeDir()
@@ -77,15 +125,18 @@ func DetectNvimConfig() (string, error) {
 		// Standard locations
 		filepath.Join(home, ".config", "nvim"),
 		filepath.Join(home, ".nvim"),
+		// LunarVim runs its own config directory
+		filepath.Join(home, ".config", "lvim"),
+	}
+
+	// Neovim on Windows looks in %LOCALAPPDATA% when XDG_CONFIG_HOME is unset
+	if runtime.GOOS == "windows" {
+		paths = slices.Insert(paths, 1, filepath.Join(localAppData(home), "nvim"))
 	}
 
 	// Also check NVIM_APPNAME for custom nvim configurations
 	if appName := os.Getenv("NVIM_APPNAME"); appName != "" {
-		xdgConfig := os.Getenv("XDG_CONFIG_HOME")
-		if xdgConfig == "" {
-			xdgConfig = filepath.Join(home, ".config")
-		}
-		paths = append([]string{filepath.Join(xdgConfig, appName)}, paths...)
+		paths = append([]string{filepath.Join(nvimConfigBase(home), appName)}, paths...)
 	}
 
 	for _, path := range paths {
@@ -103,13 +154,21 @@ func DetectNvimConfig() (string, error) {
 		if _, err := os.Stat(initVim); err == nil {
 			return path, nil
 		}
+		if _, err := os.Stat(filepath.Join(path, "config.lua")); err == nil && filepath.Base(path) == "lvim" {
+			return path, nil
+		}
 	}
 
 	return "", fmt.Errorf("neovim configuration not found")
 }
 
-// DetectTmuxConfig attempts to find the tmux configuration file
+// DetectTmuxConfig attempts to find the tmux configuration file. It returns
+// ErrNoTmux on Windows, so callers can skip tmux there.
 func DetectTmuxConfig() (string, error) {
+	if runtime.GOOS == "windows" {
+		return "", ErrNoTmux
+	}
+
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return "", err
@@ -137,6 +196,44 @@ func DetectTmuxConfig() (string, error) {
 	return "", fmt.Errorf("tmux configuration not found")
 }
 
+// DetectShellConfigs finds the zsh, bash, and fish startup files that exist,
+// along with the alias files commonly kept beside them
+func DetectShellConfigs() []string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+
+	zdotdir := os.Getenv("ZDOTDIR")
+	if zdotdir == "" {
+		zdotdir = home
+	}
+	candidates := []string{
+		filepath.Join(zdotdir, ".zshrc"),
+		filepath.Join(home, ".zsh_aliases"),
+		filepath.Join(home, ".bashrc"),
+		filepath.Join(home, ".bash_aliases"),
+		filepath.Join(home, ".aliases"),
+		filepath.Join(fishConfigDir(home), "config.fish"),
+	}
+
+	var paths []string
+	for _, path := range candidates {
+		if _, err := os.Stat(path); err == nil {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// fishConfigDir returns fish's configuration directory
+func fishConfigDir(home string) string {
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		return filepath.Join(xdg, "fish")
+	}
+	return filepath.Join(home, ".config", "fish")
+}
+
 // DetectAllConfigs attempts to detect both nvim and tmux configurations
 func DetectAllConfigs() (nvimPath, tmuxPath string) {
 	nvimPath, _ = DetectNvimConfig()
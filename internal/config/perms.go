@@ -0,0 +1,109 @@
+package config
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Permissions for everything cliq writes. Query history and config can be
+// sensitive, so files are private to the user; the process umask is still
+// applied on top.
+const (
+	// DirPerm is the mode for directories cliq creates
+	DirPerm os.FileMode = 0700
+	// FilePerm is the mode for config, cache, and state files
+	FilePerm os.FileMode = 0600
+)
+
+// WriteFile writes data to path with FilePerm, creating parent directories
+// with DirPerm. The data goes to a temporary file that is renamed into
+// place, so an existing file also ends up with private permissions. A
+// symlink, such as to a dotfiles repo, is written through rather than
+// replaced.
+func WriteFile(path string, data []byte) error {
+	path = followLink(path)
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, DirPerm); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// followLink returns the file a symlink at path points to, even one not
+// created yet, or path itself when it isn't a symlink
+func followLink(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	info, err := os.Lstat(path)
+	if err != nil || info.Mode()&fs.ModeSymlink == 0 {
+		return path
+	}
+	target, err := os.Readlink(path)
+	if err != nil {
+		return path
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(path), target)
+	}
+	return target
+}
+
+// PermIssue is a file or directory that other users can access
+type PermIssue struct {
+	Path string
+	Mode os.FileMode
+	Want os.FileMode
+}
+
+// FindLoosePermissions scans dirs for files and directories accessible to
+// group or other users. Downloaded models aren't sensitive and are skipped.
+// Missing directories are skipped.
+func FindLoosePermissions(dirs ...string) ([]PermIssue, error) {
+	var issues []PermIssue
+
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			if d.Type()&fs.ModeSymlink != 0 || strings.HasSuffix(path, ".gguf") {
+				return nil
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			mode := info.Mode().Perm()
+			if mode&0077 != 0 {
+				issues = append(issues, PermIssue{Path: path, Mode: mode, Want: mode &^ 0077})
+			}
+			return nil
+		})
+		if err != nil {
+			return issues, err
+		}
+	}
+
+	return issues, nil
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// nvimProfile is the profile picked by --nvim-profile
+var nvimProfile string
+
+// SetNvimProfile makes Load use the NVIM_APPNAME profile name, over the
+// NVIM_APPNAME environment variable and nvim.profile
+func SetNvimProfile(name string) {
+	nvimProfile = name
+}
+
+// NvimProfileName returns the profile in effect: --nvim-profile, then
+// NVIM_APPNAME, then nvim.profile. "nvim" is Neovim's own default, so it
+// means no profile.
+func (c *Config) NvimProfileName() string {
+	name := nvimProfile
+	if name == "" {
+		name = os.Getenv("NVIM_APPNAME")
+	}
+	if name == "" {
+		name = c.Nvim.Profile
+	}
+	if name == "nvim" {
+		return ""
+	}
+	return name
+}
+
+// NvimProfilePath returns the config directory of a profile: the one given
+// under nvim.profiles, or $XDG_CONFIG_HOME/<name> as Neovim finds it
+// (%LOCALAPPDATA%\<name> on Windows)
+func (c *Config) NvimProfilePath(name string) string {
+	if path, ok := c.Nvim.Profiles[name]; ok {
+		return expandPath(path)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(nvimConfigBase(home), name)
+}
+
+// NvimProfiles lists the profiles named under nvim.profiles and the other
+// Neovim configs found next to the default one, sorted by name
+func (c *Config) NvimProfiles() []string {
+	seen := map[string]bool{}
+	var names []string
+	for name := range c.Nvim.Profiles {
+		seen[name] = true
+		names = append(names, name)
+	}
+
+	xdgConfig := filepath.Dir(c.NvimProfilePath("nvim"))
+	entries, _ := os.ReadDir(xdgConfig)
+	for _, e := range entries {
+		if !e.IsDir() || seen[e.Name()] || e.Name() == "nvim" {
+			continue
+		}
+		dir := filepath.Join(xdgConfig, e.Name())
+		for _, init := range []string{"init.lua", "init.vim"} {
+			if _, err := os.Stat(filepath.Join(dir, init)); err == nil {
+				names = append(names, e.Name())
+				break
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// useNvimProfile points nvim.config_path at the profile in effect
+func (c *Config) useNvimProfile() {
+	name := c.NvimProfileName()
+	if name == "" {
+		return
+	}
+	path := c.NvimProfilePath(name)
+	if path == "" {
+		return
+	}
+	c.Nvim.configuredPath = c.Nvim.ConfigPath
+	c.Nvim.profilePath = path
+	c.Nvim.ConfigPath = path
+	c.Nvim.ActiveProfile = name
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// ErrUnknownProfile is returned by Load when the profile asked for is
+// neither under [profiles] nor in the profiles directory
+var ErrUnknownProfile = errors.New("unknown profile")
+
+// profile is the settings profile picked by --profile. Neovim configs have
+// profiles of their own; see SetNvimProfile.
+var profile string
+
+// SetProfile makes Load lay the named settings profile over the config,
+// over the CLIQ_PROFILE environment variable
+func SetProfile(name string) {
+	profile = name
+}
+
+// ProfileName returns the settings profile in effect: --profile, then
+// CLIQ_PROFILE. "" is none.
+func ProfileName() string {
+	if profile != "" {
+		return profile
+	}
+	return os.Getenv(EnvPrefix + "_PROFILE")
+}
+
+// ProfilesDir returns the directory of profiles kept in files of their
+// own, as <name>.toml next to the config file
+func ProfilesDir() string {
+	return filepath.Join(filepath.Dir(GetConfigPath()), "profiles")
+}
+
+// ProfileNames lists the profiles under [profiles] and in ProfilesDir,
+// sorted by name
+func (c *Config) ProfileNames() []string {
+	seen := map[string]bool{}
+	var names []string
+	for name := range c.Profiles {
+		seen[name] = true
+		names = append(names, name)
+	}
+	entries, _ := os.ReadDir(ProfilesDir())
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".toml")
+		if ok && !e.IsDir() && !seen[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// profileSettings returns a profile's settings as TOML, and where they
+// were found: under [profiles] or, failing that, in ProfilesDir
+func (c *Config) profileSettings(name string) ([]byte, string, error) {
+	if table, ok := c.Profiles[name]; ok {
+		data, err := toml.Marshal(table)
+		return data, "profile " + name, err
+	}
+	path := filepath.Join(ProfilesDir(), name+".toml")
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, "", fmt.Errorf("%w %q; profiles are set under [profiles.%s] or in %s", ErrUnknownProfile, name, name, path)
+	}
+	return data, path, err
+}
+
+// ProfileFile returns the file of the profile in effect, or "" if there is
+// none or it's under [profiles]
+func (c *Config) ProfileFile() string {
+	if _, ok := c.Profiles[c.ActiveProfile]; ok || c.ActiveProfile == "" {
+		return ""
+	}
+	return filepath.Join(ProfilesDir(), c.ActiveProfile+".toml")
+}
+
+// applyProfile overlays the settings of the profile in effect on the
+// config. A profile can set anything but other profiles and invocations.
+func (c *Config) applyProfile() error {
+	name := ProfileName()
+	if name == "" {
+		return nil
+	}
+	data, source, err := c.profileSettings(name)
+	if err != nil {
+		return err
+	}
+	if err := c.overlay(data, source, notInProfile); err != nil {
+		return fmt.Errorf("%s: %w", source, err)
+	}
+	c.ActiveProfile = name
+	return nil
+}
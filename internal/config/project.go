@@ -0,0 +1,170 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+
+	"github.com/cliq-cli/cliq/internal/project"
+)
+
+// ProjectFile is a project's own config, found in the current directory or
+// a parent, whose settings overlay the global config's for everyone working
+// in the project
+const ProjectFile = ".cliq.toml"
+
+// globalOnly are the settings and sections a project file can't change:
+// where the user's own files are, what's kept of their questions and how,
+// and the commands run for them
+var globalOnly = []string{
+	"model.path",
+	"nvim.config_path", "nvim.keymaps_file", "nvim.profile", "nvim.profiles",
+	"tmux.config_path",
+	"shell.config_paths",
+	"cache", "history", "encryption", "updates", "invocations", "profiles", "hooks",
+}
+
+// GlobalOnly reports whether the setting at key can only be set in the
+// global config file
+func GlobalOnly(key string) bool {
+	for _, g := range globalOnly {
+		if key == g || strings.HasPrefix(key, g+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// FindProjectFile returns the path of the project file for the current
+// directory, or "" if there is none
+func FindProjectFile() (string, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	return project.FindUp(wd, ProjectFile)
+}
+
+// applyProject overlays the settings of the project file at path on the
+// config. Those only the global config can set are left alone.
+func (c *Config) applyProject(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	// A project can turn privacy mode on for everyone working in it, but
+	// not off for someone who has it on
+	var privacy struct {
+		General struct {
+			PrivacyMode bool `toml:"privacy_mode"`
+		} `toml:"general"`
+	}
+	if err := toml.Unmarshal(data, &privacy); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	skip := func(key string) bool {
+		return GlobalOnly(key) || (key == "general.privacy_mode" && !privacy.General.PrivacyMode)
+	}
+	if err := c.overlay(data, path, skip); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// overlay overrides the config with the settings a TOML document sets,
+// except those skip reports true for, recording source as what set them
+func (c *Config) overlay(data []byte, source string, skip func(key string) bool) error {
+	var doc map[string]any
+	if err := toml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	var over Config
+	if err := toml.Unmarshal(data, &over); err != nil {
+		return err
+	}
+
+	for _, key := range SettingKeys() {
+		if skip(key) || !hasKey(doc, key) {
+			continue
+		}
+		c.override(key, source, over.settingField(key))
+	}
+	return nil
+}
+
+// hasKey reports whether a decoded TOML document sets the dotted key
+func hasKey(doc map[string]any, key string) bool {
+	section, name := splitKey(key)
+	for _, part := range strings.Split(section, ".") {
+		table, ok := doc[part].(map[string]any)
+		if !ok {
+			return false
+		}
+		doc = table
+	}
+	_, ok := doc[name]
+	return ok
+}
+
+// ContextFilePaths returns general.context_files as paths: relative ones
+// are taken from the directory of the project file that set them, from the
+// current directory for an environment variable, and otherwise from the
+// global config's directory. A project file's paths outside its directory
+// are left out, so a cloned repository can't have ~/.ssh sent with every
+// question.
+func (c *Config) ContextFilePaths() []string {
+	base := filepath.Dir(GetConfigPath())
+	fromProject := false
+	if source, ok := c.OverriddenBy("general.context_files"); ok {
+		switch {
+		case strings.HasSuffix(source, ProjectFile):
+			base = filepath.Dir(source)
+			fromProject = true
+		case strings.HasPrefix(source, EnvPrefix+"_"):
+			base, _ = os.Getwd()
+		}
+	}
+
+	paths := make([]string, 0, len(c.General.ContextFiles))
+	for _, path := range c.General.ContextFiles {
+		path = resolvePath(base, path)
+		if fromProject && !within(base, path) {
+			continue
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// ContextFilesFromProject reports whether general.context_files was set by
+// a project file, which makes the files it names the project's rather than
+// the user's own
+func (c *Config) ContextFilesFromProject() bool {
+	source, ok := c.OverriddenBy("general.context_files")
+	return ok && strings.HasSuffix(source, ProjectFile)
+}
+
+// resolvePath expands ~ in path and takes it from base if it's relative
+func resolvePath(base, path string) string {
+	path = expandPath(path)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(base, path)
+	}
+	return path
+}
+
+// within reports whether path is dir or inside it, with the symlinks of
+// both followed where they exist
+func within(dir, path string) bool {
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
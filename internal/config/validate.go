@@ -0,0 +1,400 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+
+	"github.com/cliq-cli/cliq/internal/keynotation"
+)
+
+// Problem is something wrong with a setting in a config file
+type Problem struct {
+	File    string `json:"file,omitempty"`
+	Key     string `json:"key"`
+	Message string `json:"message"`
+}
+
+func (p Problem) String() string {
+	return p.Key + ": " + p.Message
+}
+
+// ValidateFile checks a config file with Validate, and a project file for
+// settings only the global config can set too. A missing file has no
+// problems.
+func ValidateFile(path string) ([]Problem, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	problems, err := Validate(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc map[string]any
+	if err := toml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	switch {
+	case filepath.Base(path) == ProjectFile:
+		misplacedProblems(doc, "", GlobalOnly, "can only be set in the global config, so the project's is ignored", &problems)
+		problems = append(problems, projectProblems(doc, filepath.Dir(path))...)
+	case filepath.Dir(path) == ProfilesDir():
+		misplacedProblems(doc, "", notInProfile, "can't be set in a profile, so it's ignored", &problems)
+	}
+	for i := range problems {
+		problems[i].File = path
+	}
+	return problems, nil
+}
+
+// projectProblems points out the settings of a project file in dir that
+// are ignored for what they're set to: privacy mode turned off, and context
+// files outside the project
+func projectProblems(doc map[string]any, dir string) []Problem {
+	general, _ := doc["general"].(map[string]any)
+	var problems []Problem
+	if privacy, ok := general["privacy_mode"].(bool); ok && !privacy {
+		problems = append(problems, Problem{Key: "general.privacy_mode", Message: "a project can only turn it on, so false is ignored"})
+	}
+	files, _ := general["context_files"].([]any)
+	for _, f := range files {
+		if path, ok := f.(string); ok && !within(dir, resolvePath(dir, path)) {
+			problems = append(problems, Problem{Key: "general.context_files", Message: fmt.Sprintf("%s is outside the project, so it's ignored", path)})
+		}
+	}
+	return problems
+}
+
+// misplacedProblems points out the settings of a table that misplaced
+// reports can't be set there
+func misplacedProblems(table map[string]any, prefix string, misplaced func(key string) bool, message string, problems *[]Problem) {
+	for _, k := range sortedKeys(table) {
+		key := prefix + k
+		if misplaced(key) {
+			*problems = append(*problems, Problem{Key: key, Message: message})
+		} else if sub, ok := table[k].(map[string]any); ok {
+			misplacedProblems(sub, key+".", misplaced, message, problems)
+		}
+	}
+}
+
+// notInProfile reports whether a key is one a profile can't set
+func notInProfile(key string) bool {
+	return key == "profiles" || key == "invocations"
+}
+
+// Validate checks a config file for what toml.Unmarshal would silently
+// ignore or give up on: keys cliq doesn't know, with the one likely meant,
+// values of the wrong type, and values out of range. Data that isn't TOML
+// at all is an error.
+func Validate(data []byte) ([]Problem, error) {
+	var doc map[string]any
+	if err := toml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	var problems []Problem
+	checkTable(doc, reflect.TypeOf(Config{}), "", &problems)
+	profiles, _ := doc["profiles"].(map[string]any)
+	for _, name := range sortedKeys(profiles) {
+		if table, ok := profiles[name].(map[string]any); ok {
+			checkProfile(table, "profiles."+name+".", &problems)
+		}
+	}
+	if len(problems) > 0 {
+		// Out-of-range values can only be checked once the types are right
+		return problems, nil
+	}
+
+	cfg := Default()
+	if err := toml.Unmarshal(data, cfg); err != nil {
+		return nil, err
+	}
+	problems = cfg.rangeProblems()
+
+	// A profile's values are checked as they'd be with it in effect
+	for _, name := range sortedKeys(profiles) {
+		table := profiles[name].(map[string]any)
+		profileData, err := toml.Marshal(table)
+		if err != nil {
+			return nil, err
+		}
+		withProfile := Default()
+		if err := toml.Unmarshal(data, withProfile); err != nil {
+			return nil, err
+		}
+		if err := withProfile.overlay(profileData, "", notInProfile); err != nil {
+			return nil, err
+		}
+		for _, p := range withProfile.rangeProblems() {
+			if hasKey(table, p.Key) {
+				problems = append(problems, Problem{Key: "profiles." + name + "." + p.Key, Message: p.Message})
+			}
+		}
+	}
+	return problems, nil
+}
+
+// checkProfile checks a profile's table as a config file of its own
+func checkProfile(table map[string]any, prefix string, problems *[]Problem) {
+	rest := map[string]any{}
+	for k, v := range table {
+		if notInProfile(k) {
+			*problems = append(*problems, Problem{Key: prefix + k, Message: "can't be set in a profile"})
+		} else {
+			rest[k] = v
+		}
+	}
+	checkTable(rest, reflect.TypeOf(Config{}), prefix, problems)
+}
+
+// sortedKeys returns the keys of a decoded TOML table in order
+func sortedKeys(table map[string]any) []string {
+	keys := make([]string, 0, len(table))
+	for k := range table {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// checkTable checks a table of the file against the struct or map type it
+// decodes into
+func checkTable(table map[string]any, t reflect.Type, prefix string, problems *[]Problem) {
+	for _, k := range sortedKeys(table) {
+		key := prefix + k
+		var want reflect.Type
+		if t.Kind() == reflect.Map {
+			want = t.Elem()
+		} else {
+			f, ok := fieldByTag(t, k)
+			if !ok {
+				msg := "unknown setting"
+				if guess := closestKey(k, t); guess != "" {
+					msg += fmt.Sprintf("; did you mean %s?", prefix+guess)
+				}
+				*problems = append(*problems, Problem{Key: key, Message: msg})
+				continue
+			}
+			want = f.Type
+		}
+		checkValue(table[k], want, key, problems)
+	}
+}
+
+// checkValue checks a value of the file has the type the setting takes
+func checkValue(v any, t reflect.Type, key string, problems *[]Problem) {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	wrong := func(want string) {
+		*problems = append(*problems, Problem{Key: key, Message: fmt.Sprintf("should be %s, not %s", want, describeValue(v))})
+	}
+
+	switch t.Kind() {
+	case reflect.Struct, reflect.Map:
+		table, ok := v.(map[string]any)
+		if !ok {
+			wrong("a table")
+			return
+		}
+		checkTable(table, t, key+".", problems)
+	case reflect.String:
+		if _, ok := v.(string); !ok {
+			wrong("a string")
+		}
+	case reflect.Bool:
+		if _, ok := v.(bool); !ok {
+			wrong("true or false")
+		}
+	case reflect.Int:
+		if _, ok := v.(int64); !ok {
+			wrong("a whole number")
+		}
+	case reflect.Float64:
+		switch v.(type) {
+		case int64, float64:
+		default:
+			wrong("a number")
+		}
+	case reflect.Slice:
+		items, ok := v.([]any)
+		if !ok {
+			wrong("a list of strings")
+			return
+		}
+		for _, item := range items {
+			if _, ok := item.(string); !ok {
+				wrong("a list of strings")
+				return
+			}
+		}
+	}
+}
+
+// describeValue names the type of a value from the file
+func describeValue(v any) string {
+	switch v := v.(type) {
+	case string:
+		return fmt.Sprintf("the string %q", v)
+	case bool:
+		return fmt.Sprint(v)
+	case int64, float64:
+		return fmt.Sprint(v)
+	case []any:
+		return "a list"
+	case map[string]any:
+		return "a table"
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// closestKey returns the setting of the struct whose name is nearest to
+// name, if it's near enough to be a typo of it
+func closestKey(name string, t reflect.Type) string {
+	best, bestDist := "", max(2, len(name)/3)+1
+	for i := 0; i < t.NumField(); i++ {
+		tag, _, _ := strings.Cut(t.Field(i).Tag.Get("toml"), ",")
+		if tag == "" || tag == "-" || !t.Field(i).IsExported() {
+			continue
+		}
+		if d := editDistance(strings.ToLower(name), tag); d < bestDist {
+			best, bestDist = tag, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// Settings that take one of a few words, and the words they take. An empty
+// string is allowed for those where it means the default.
+var (
+	responseStyles = []string{"concise", "detailed", "minimal"}
+	backends       = []string{"auto", "ollama", "llama-server", "llama-cli"}
+	fallbacks      = []string{"llama-server", "ollama", "llama-cli", "offline"}
+	iconSets       = []string{"", "emoji", "ascii", "none"}
+	encryptModes   = []string{"", "off", "passphrase", "keychain"}
+	auditModes     = []string{"", "off", "remote", "all"}
+	notifyModes    = []string{"", "unfocused", "always", "off"}
+	outputFormats  = []string{"", "text", "json", "markdown", "cmd"}
+)
+
+// pluginPrefix starts the name of a backend plugin's binary, as in
+// llm.PluginPrefix, which config can't import
+const pluginPrefix = "cliq-backend-"
+
+// isPlugin reports whether a backend name is a plugin's in PATH
+func isPlugin(name string) bool {
+	if slices.Contains(fallbacks, name) || name == "" {
+		return false
+	}
+	_, err := exec.LookPath(pluginPrefix + name)
+	return err == nil
+}
+
+// rangeProblems checks the values that decode but make no sense
+func (c *Config) rangeProblems() []Problem {
+	var problems []Problem
+	add := func(key, format string, args ...any) {
+		problems = append(problems, Problem{Key: key, Message: fmt.Sprintf(format, args...)})
+	}
+	oneOf := func(key, value string, allowed []string) {
+		if !slices.Contains(allowed, value) {
+			add(key, "%q isn't one of %s", value, strings.Join(slices.DeleteFunc(slices.Clone(allowed), func(s string) bool { return s == "" }), ", "))
+		}
+	}
+	atLeast := func(key string, value, least int) {
+		if value < least {
+			add(key, "%d is less than %d", value, least)
+		}
+	}
+
+	if c.Version > ConfigVersion {
+		add("version", "%d is newer than this cliq's %d, so settings added since may be ignored; upgrade cliq", c.Version, ConfigVersion)
+	}
+	oneOf("general.response_style", c.General.ResponseStyle, responseStyles)
+	oneOf("general.key_notation", c.General.KeyNotation, append([]string{""}, keynotation.Names...))
+
+	m := c.Model
+	if !slices.Contains(backends, m.Backend) && !isPlugin(m.Backend) {
+		add("model.backend", "%q isn't one of %s, or a plugin in PATH (%s%s)", m.Backend, strings.Join(backends, ", "), pluginPrefix, m.Backend)
+	}
+	if m.Temperature < 0 || m.Temperature > 2 {
+		add("model.temperature", "%g is outside 0 to 2", m.Temperature)
+	}
+	atLeast("model.max_tokens", m.MaxTokens, 1)
+	if m.TopP < 0 || m.TopP > 1 {
+		add("model.top_p", "%g is outside 0 to 1", m.TopP)
+	}
+	atLeast("model.top_k", m.TopK, 0)
+	if m.CostConfirmUSD < 0 {
+		add("model.cost_confirm_usd", "%g is negative", m.CostConfirmUSD)
+	}
+	atLeast("model.server_idle_minutes", m.ServerIdleMinutes, 0)
+	atLeast("model.gpu_layers", m.GPULayers, -1)
+	atLeast("model.threads", m.Threads, 0)
+	atLeast("model.batch_size", m.BatchSize, 0)
+	atLeast("model.context_length", m.ContextLength, 0)
+	atLeast("model.retries", m.Retries, 0)
+	for _, name := range m.Fallback {
+		if !isPlugin(name) {
+			oneOf("model.fallback", name, fallbacks)
+		}
+	}
+
+	atLeast("cache.ttl_hours", c.Cache.TTLHours, 0)
+	atLeast("cache.answer_ttl_hours", c.Cache.AnswerTTLHours, 0)
+	oneOf("tui.icons", c.TUI.Icons, iconSets)
+	atLeast("history.max_entries", c.History.MaxEntries, 0)
+	atLeast("history.max_age_days", c.History.MaxAgeDays, 0)
+	oneOf("history.audit_log", c.History.AuditLog, auditModes)
+	oneOf("encryption.mode", c.Encryption.Mode, encryptModes)
+	atLeast("hooks.timeout_seconds", c.Hooks.TimeoutSeconds, 1)
+	oneOf("notify.when", c.Notify.When, notifyModes)
+	atLeast("notify.after_seconds", c.Notify.AfterSeconds, 0)
+
+	names := make([]string, 0, len(c.Invocations))
+	for name := range c.Invocations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		inv := c.Invocations[name]
+		oneOf("invocations."+name+".format", inv.Format, outputFormats)
+		if inv.Style != "" {
+			oneOf("invocations."+name+".style", inv.Style, responseStyles)
+		}
+	}
+	return problems
+}
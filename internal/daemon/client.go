@@ -0,0 +1,99 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/cliq-cli/cliq/internal/parser"
+)
+
+// dialTimeout is short: with no daemon the socket refuses at once, and a
+// query shouldn't wait long on one that's stuck
+const dialTimeout = 100 * time.Millisecond
+
+// ErrNotRunning is returned when no daemon answers on the socket
+var ErrNotRunning = errors.New("no cliq daemon is running")
+
+// call sends one request and reads the reply
+func call(op string, timeout time.Duration) (*reply, error) {
+	return send(context.Background(), request{Op: op}, timeout)
+}
+
+// send sends a request and reads the reply, hanging up if ctx is done first
+func send(ctx context.Context, req request, timeout time.Duration) (*reply, error) {
+	path, err := SocketPath()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.DialTimeout("unix", path, dialTimeout)
+	if err != nil {
+		return nil, ErrNotRunning
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, err
+	}
+	var resp reply
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+	switch resp.Error {
+	case "":
+		return &resp, nil
+	case ErrNotWarm.Error():
+		return nil, ErrNotWarm
+	}
+	return nil, errors.New(resp.Error)
+}
+
+// Context fetches the parsed configs from the daemon. Either may be nil
+// when that tool isn't configured.
+func Context() (*parser.NvimConfig, *parser.TmuxConfig, error) {
+	// Long enough for the daemon to re-parse a config that just changed
+	resp, err := call("context", 5*time.Second)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp.Nvim, resp.Tmux, nil
+}
+
+// Generate has the daemon's loaded model answer a prompt. It's ErrNotWarm
+// when the daemon keeps no model loaded for it.
+func Generate(ctx context.Context, req GenerateRequest) (*Generation, error) {
+	resp, err := send(ctx, request{Op: "generate", Generate: &req}, generateTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Generation, nil
+}
+
+// GetStatus asks the running daemon how it's doing
+func GetStatus() (*Status, error) {
+	resp, err := call("status", time.Second)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Status, nil
+}
+
+// Stop asks the running daemon to exit
+func Stop() error {
+	_, err := call("stop", time.Second)
+	return err
+}
+
+// Running reports whether a daemon answers on the socket
+func Running() bool {
+	_, err := GetStatus()
+	return err == nil
+}
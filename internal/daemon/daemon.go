@@ -0,0 +1,516 @@
+// Package daemon keeps the parsed Neovim and tmux configs in memory for
+// cliq queries. The daemon watches the config files, re-parses whichever
+// config changed, and answers requests on a unix socket in the cache
+// directory, so a query skips parsing without risking a stale config. It
+// can keep the model loaded too, and generate the answers to queries'
+// prompts with it, so they skip loading it.
+package daemon
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/cliq-cli/cliq/internal/config"
+	"github.com/cliq-cli/cliq/internal/parser"
+)
+
+// settle is how long the watcher waits after a change before re-parsing,
+// so that an editor's write, rename, and chmod cost one parse
+const settle = 150 * time.Millisecond
+
+// mtimeSlack allows for file systems that stamp modification times with a
+// coarser clock than time.Now, so a file written just after a parse began
+// can look older than the parse
+const mtimeSlack = time.Second
+
+// ErrRunning is returned by Run when another daemon answers on the socket
+var ErrRunning = errors.New("a cliq daemon is already running")
+
+// SocketPath is where the daemon listens
+func SocketPath() (string, error) {
+	dir, err := config.GetCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "daemon.sock"), nil
+}
+
+// Status describes a running daemon
+type Status struct {
+	Pid      int       `json:"pid"`
+	Started  time.Time `json:"started"`
+	NvimPath string    `json:"nvim_path,omitempty"`
+	TmuxPath string    `json:"tmux_path,omitempty"`
+	Parsed   time.Time `json:"parsed"`
+	Reparses int       `json:"reparses"`
+	Watching int       `json:"watching"`
+	// Model is the backend and model kept loaded, if one is
+	Model     string `json:"model,omitempty"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+// request is a line a client sends
+type request struct {
+	Op       string           `json:"op"` // context, generate, status or stop
+	Generate *GenerateRequest `json:"generate,omitempty"`
+}
+
+// reply is the line the daemon answers with
+type reply struct {
+	Nvim       *parser.NvimConfig `json:"nvim,omitempty"`
+	Tmux       *parser.TmuxConfig `json:"tmux,omitempty"`
+	Status     *Status            `json:"status,omitempty"`
+	Generation *Generation        `json:"generation,omitempty"`
+	Error      string             `json:"error,omitempty"`
+}
+
+// Logger receives a line for each re-parse and error
+type Logger func(format string, args ...any)
+
+// server holds the parsed configs and what changed since they were parsed
+type server struct {
+	mu      sync.Mutex
+	cfg     *config.Config
+	nvim    *parser.NvimConfig
+	tmux    *parser.TmuxConfig
+	status  Status
+	watcher *fsnotify.Watcher
+	watched map[string]bool // directories being watched
+
+	// dirty marks the configs changed on disk but not yet re-parsed, and
+	// the times are when each parse started
+	dirtyNvim, dirtyTmux, dirtyConfig bool
+	nvimParsed, tmuxParsed            time.Time
+
+	// model is nil when the daemon keeps no model loaded
+	model *model
+
+	log  Logger
+	stop context.CancelFunc
+}
+
+// Run parses the configs, then watches and serves them until ctx is done or
+// a client asks it to stop. With warm set, it loads the configured model
+// and keeps it loaded to generate answers with.
+func Run(ctx context.Context, log Logger, warm bool) error {
+	path, err := SocketPath()
+	if err != nil {
+		return err
+	}
+	if Running() {
+		return ErrRunning
+	}
+	if err := os.MkdirAll(filepath.Dir(path), config.DirPerm); err != nil {
+		return err
+	}
+	// Nothing answered, so a socket file left here is from a daemon that died
+	os.Remove(path)
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to start watching: %w", err)
+	}
+	defer watcher.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	s := &server{
+		watcher: watcher,
+		watched: map[string]bool{},
+		log:     log,
+		stop:    cancel,
+	}
+	s.status = Status{Pid: os.Getpid(), Started: time.Now()}
+	s.mu.Lock()
+	s.dirtyConfig = true
+	s.refresh()
+	modelPath, ollamaModel := s.cfg.GetModelPath(), s.cfg.Model.OllamaModel
+	s.mu.Unlock()
+
+	if warm {
+		s.model = &model{ctx: ctx, log: log}
+		defer func() {
+			s.model.mu.Lock()
+			s.model.close()
+			s.model.mu.Unlock()
+		}()
+		// Loaded in the background, so the configs are served meanwhile
+		go func() {
+			s.model.mu.Lock()
+			defer s.model.mu.Unlock()
+			s.model.load(modelPath, ollamaModel)
+		}()
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	defer os.Remove(path)
+	os.Chmod(path, config.FilePerm)
+	log("listening on %s", path)
+
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+	go s.watch(ctx)
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		go s.serve(conn)
+	}
+}
+
+// serve answers one client
+func (s *server) serve(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	var req request
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil && len(line) == 0 {
+		return
+	}
+	var resp reply
+	if err := json.Unmarshal(line, &req); err != nil {
+		resp.Error = "bad request: " + err.Error()
+	} else if req.Op == "generate" {
+		resp = s.generate(conn, req.Generate)
+	} else {
+		resp = s.handle(req)
+	}
+	json.NewEncoder(conn).Encode(resp)
+}
+
+// generate answers a prompt with the loaded model. The client hanging up,
+// as when it's interrupted, stops the generation.
+func (s *server) generate(conn net.Conn, req *GenerateRequest) reply {
+	if req == nil {
+		return reply{Error: "bad request: no prompt"}
+	}
+	if s.model == nil {
+		return reply{Error: ErrNotWarm.Error()}
+	}
+	conn.SetDeadline(time.Now().Add(generateTimeout))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		// Nothing more is sent, so a read only returns once the client
+		// hangs up or the reply is written and the connection closed
+		conn.Read(make([]byte, 1))
+		cancel()
+	}()
+
+	gen, err := s.model.generate(ctx, *req)
+	if err != nil {
+		return reply{Error: err.Error()}
+	}
+	return reply{Generation: gen}
+}
+
+func (s *server) handle(req request) reply {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch req.Op {
+	case "context":
+		// A change the watcher hasn't settled on, or hasn't heard of yet,
+		// is parsed now, so the answer is never older than the files
+		s.checkModified()
+		s.refresh()
+		return reply{Nvim: s.nvim, Tmux: s.tmux}
+	case "status":
+		status := s.status
+		status.Watching = len(s.watched)
+		if s.model != nil {
+			status.Model = s.model.backend()
+		}
+		return reply{Status: &status}
+	case "stop":
+		s.stop()
+		return reply{}
+	}
+	return reply{Error: fmt.Sprintf("unknown op %q", req.Op)}
+}
+
+// watch marks configs dirty as their files change, and re-parses them once
+// the changes settle
+func (s *server) watch(ctx context.Context) {
+	timer := time.NewTimer(settle)
+	timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-s.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Has(fsnotify.Chmod) && !event.Has(fsnotify.Write) {
+				continue
+			}
+			s.mu.Lock()
+			if s.mark(event) {
+				timer.Reset(settle)
+			}
+			s.mu.Unlock()
+		case err, ok := <-s.watcher.Errors:
+			if !ok {
+				return
+			}
+			s.log("watch error: %v", err)
+		case <-timer.C:
+			s.mu.Lock()
+			s.refresh()
+			s.mu.Unlock()
+		}
+	}
+}
+
+// mark records which config a changed file belongs to, and reports
+// whether it belongs to one
+func (s *server) mark(event fsnotify.Event) bool {
+	name := filepath.Clean(event.Name)
+	switch {
+	case name == filepath.Clean(config.GetConfigPath()):
+		s.dirtyConfig = true
+	case s.tmux != nil && s.tmuxFile(name):
+		s.dirtyTmux = true
+	case s.cfg.Nvim.ConfigPath != "" && within(name, s.cfg.Nvim.ConfigPath):
+		if strings.HasPrefix(filepath.Base(name), ".") {
+			return false
+		}
+		if event.Has(fsnotify.Create) {
+			// A new directory in the config needs watching too
+			if info, err := os.Stat(name); err == nil && info.IsDir() {
+				s.watchTree(name)
+			}
+		}
+		s.dirtyNvim = true
+	case s.tmux == nil && s.cfg.Tmux.ConfigPath != "" && name == filepath.Clean(s.cfg.Tmux.ConfigPath):
+		s.dirtyTmux = true
+	default:
+		return false
+	}
+	return true
+}
+
+// tmuxFile reports whether name is tmux.conf or a file it sources
+func (s *server) tmuxFile(name string) bool {
+	if name == filepath.Clean(s.tmux.ConfigPath) {
+		return true
+	}
+	for _, inc := range s.tmux.Includes {
+		if name == filepath.Clean(inc) {
+			return true
+		}
+	}
+	return false
+}
+
+// refresh re-parses the dirty configs. It's called with s.mu held.
+func (s *server) refresh() {
+	if !s.dirtyConfig && !s.dirtyNvim && !s.dirtyTmux {
+		return
+	}
+
+	if s.dirtyConfig {
+		cfg, err := config.Load()
+		if err != nil {
+			s.fail("could not load config: %v", err)
+			cfg = config.Default()
+		}
+		if s.cfg == nil || cfg.Nvim.ConfigPath != s.cfg.Nvim.ConfigPath {
+			s.dirtyNvim = true
+		}
+		if s.cfg == nil || cfg.Tmux.ConfigPath != s.cfg.Tmux.ConfigPath {
+			s.dirtyTmux = true
+		}
+		s.cfg = cfg
+		s.status.NvimPath = cfg.Nvim.ConfigPath
+		s.status.TmuxPath = cfg.Tmux.ConfigPath
+		s.dirtyConfig = false
+		s.rewatch()
+	}
+
+	var parsed []string
+	if s.dirtyNvim {
+		s.nvim = nil
+		s.nvimParsed = time.Now()
+		if s.cfg.Nvim.ConfigPath != "" {
+			nvim, err := parser.ParseNvimConfig(s.cfg.Nvim.ConfigPath)
+			if err != nil {
+				s.fail("could not parse nvim config: %v", err)
+			}
+			s.nvim = nvim
+			parsed = append(parsed, "nvim")
+		}
+		s.dirtyNvim = false
+	}
+	if s.dirtyTmux {
+		s.tmux = nil
+		s.tmuxParsed = time.Now()
+		if s.cfg.Tmux.ConfigPath != "" {
+			tmux, err := parser.ParseTmuxConfig(s.cfg.Tmux.ConfigPath)
+			if err != nil {
+				s.fail("could not parse tmux config: %v", err)
+			}
+			s.tmux = tmux
+			parsed = append(parsed, "tmux")
+		}
+		s.dirtyTmux = false
+		// The files it sources may have changed
+		s.rewatch()
+	}
+	if len(parsed) == 0 {
+		return
+	}
+
+	s.status.Parsed = time.Now()
+	s.status.Reparses++
+	s.log("parsed %s", strings.Join(parsed, " and "))
+
+	// Keep the on-disk cache warm for queries run without the daemon
+	if s.cfg.Cache.Enabled {
+		cache := &parser.Cache{Profile: s.cfg.Nvim.ActiveProfile, NvimConfig: s.nvim, TmuxConfig: s.tmux}
+		if err := cache.Save(); err != nil {
+			s.fail("could not save cache: %v", err)
+		}
+	}
+}
+
+// checkModified marks a config dirty if one of its files was written
+// after it was parsed. Statting the files is far cheaper than parsing them.
+// It's called with s.mu held.
+func (s *server) checkModified() {
+	if s.tmux != nil && !s.dirtyTmux {
+		for _, file := range append([]string{s.tmux.ConfigPath}, s.tmux.Includes...) {
+			if modifiedSince(file, s.tmuxParsed.Add(-mtimeSlack)) {
+				s.dirtyTmux = true
+				break
+			}
+		}
+	}
+	if s.cfg.Nvim.ConfigPath != "" && !s.dirtyNvim {
+		filepath.WalkDir(s.cfg.Nvim.ConfigPath, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if path != s.cfg.Nvim.ConfigPath && strings.HasPrefix(d.Name(), ".") {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if info, err := d.Info(); err == nil && !info.ModTime().Before(s.nvimParsed.Add(-mtimeSlack)) {
+				s.dirtyNvim = true
+				return filepath.SkipAll
+			}
+			return nil
+		})
+	}
+}
+
+// modifiedSince reports whether the file was written at or after t
+func modifiedSince(file string, t time.Time) bool {
+	info, err := os.Stat(file)
+	return err == nil && !info.ModTime().Before(t)
+}
+
+// rewatch watches the directories holding the cliq config, the whole
+// Neovim config, and tmux.conf and the files it sources. Directories are
+// watched rather than files, since editors often save by renaming a new
+// file over the old one.
+func (s *server) rewatch() {
+	want := map[string]bool{filepath.Dir(config.GetConfigPath()): true}
+	if s.cfg.Tmux.ConfigPath != "" {
+		want[filepath.Dir(s.cfg.Tmux.ConfigPath)] = true
+	}
+	if s.tmux != nil {
+		for _, inc := range s.tmux.Includes {
+			want[filepath.Dir(inc)] = true
+		}
+	}
+	if s.cfg.Nvim.ConfigPath != "" {
+		filepath.WalkDir(s.cfg.Nvim.ConfigPath, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || !d.IsDir() {
+				return nil
+			}
+			if path != s.cfg.Nvim.ConfigPath && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			want[path] = true
+			return nil
+		})
+	}
+
+	for dir := range s.watched {
+		if !want[dir] {
+			s.watcher.Remove(dir)
+			delete(s.watched, dir)
+		}
+	}
+	for dir := range want {
+		s.add(dir)
+	}
+}
+
+// watchTree watches a new directory in the Neovim config and those in it
+func (s *server) watchTree(root string) {
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		s.add(path)
+		return nil
+	})
+}
+
+func (s *server) add(dir string) {
+	if s.watched[dir] {
+		return
+	}
+	if err := s.watcher.Add(dir); err != nil {
+		if !os.IsNotExist(err) {
+			s.fail("could not watch %s: %v", dir, err)
+		}
+		return
+	}
+	s.watched[dir] = true
+}
+
+// fail logs an error and keeps it for status
+func (s *server) fail(format string, args ...any) {
+	s.status.LastError = fmt.Sprintf(format, args...)
+	s.log(format, args...)
+}
+
+// within reports whether path is dir or inside it
+func within(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
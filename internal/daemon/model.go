@@ -0,0 +1,138 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cliq-cli/cliq/internal/llm"
+	"github.com/cliq-cli/cliq/internal/store"
+)
+
+// generateTimeout bounds a generate request: long enough for the model to
+// load on the first one and answer
+const generateTimeout = 5 * time.Minute
+
+// ErrNotWarm is returned by Generate when the daemon keeps no model loaded
+// for the query, because it was started with --no-model, no local backend
+// is available, the backend is remote or a plugin, or llama-server isn't
+// installed to keep a llama-cli model loaded with. The query is run in
+// process.
+var ErrNotWarm = errors.New("the daemon keeps no model loaded for this query")
+
+// GenerateRequest is a prompt for the daemon's model, with the model,
+// sampling, and policy the client's config asks for
+type GenerateRequest struct {
+	Prompt      string              `json:"prompt"`
+	ModelPath   string              `json:"model_path"`
+	OllamaModel string              `json:"ollama_model"`
+	Temperature float64             `json:"temperature"`
+	MaxTokens   int                 `json:"max_tokens"`
+	Sampling    llm.SamplingOptions `json:"sampling"`
+	// Policy is answered with rather than the daemon's own, which was
+	// read when it started; nil keeps the daemon's
+	Policy *Policy `json:"policy,omitempty"`
+}
+
+// Policy is how the client's config retries, falls back from, and audits
+// a query: model.retries, model.fallback, history.audit_log, and
+// history.exclude
+type Policy struct {
+	Retries      int      `json:"retries"`
+	Fallback     []string `json:"fallback"`
+	AuditLog     string   `json:"audit_log"`
+	AuditExclude []string `json:"audit_exclude,omitempty"`
+}
+
+// Generation is what the daemon's model answered
+type Generation struct {
+	Text string `json:"text"`
+	// Backend is the backend that answered
+	Backend string `json:"backend"`
+	// FellBackFrom is the daemon's backend when it failed and one of the
+	// fallback chain answered instead
+	FellBackFrom string `json:"fell_back_from,omitempty"`
+}
+
+// model is the LLM client the daemon keeps its model loaded with, much as
+// interactive mode does. It generates one answer at a time.
+type model struct {
+	mu     sync.Mutex
+	ctx    context.Context
+	log    Logger
+	client *llm.Client
+
+	// modelPath and ollamaModel are what the client was made for
+	modelPath, ollamaModel string
+	// loaded describes the backend and model for status, which shouldn't
+	// wait on a generation holding mu
+	loaded atomic.Value
+}
+
+// load makes a client for the model and starts loading it. It's called
+// with m.mu held.
+func (m *model) load(modelPath, ollamaModel string) {
+	m.close()
+	client, err := llm.NewClient(modelPath, ollamaModel, 0, 0)
+	if err != nil {
+		m.log("could not start the model: %v", err)
+		return
+	}
+	m.client, m.modelPath, m.ollamaModel = client, modelPath, ollamaModel
+	if client.GetBackend() == "offline" || llm.IsPlugin(client.GetBackend()) || client.IsRemote() {
+		return
+	}
+	if err := client.StartSession(m.ctx); err != nil {
+		m.log("not keeping the model loaded: %v", err)
+		return
+	}
+	m.loaded.Store(client.Choice().Label())
+	m.log("keeping %s loaded", client.Choice().Label())
+}
+
+// backend describes the backend and model kept loaded, or "" for none
+func (m *model) backend() string {
+	label, _ := m.loaded.Load().(string)
+	return label
+}
+
+// generate answers a prompt with the loaded model, first loading the one
+// the request asks for if it's another
+func (m *model) generate(ctx context.Context, req GenerateRequest) (*Generation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// A backend that wasn't running when the model was loaded may be now
+	if m.client == nil || req.ModelPath != m.modelPath || req.OllamaModel != m.ollamaModel || m.client.GetBackend() == "offline" {
+		m.load(req.ModelPath, req.OllamaModel)
+	}
+	if m.backend() == "" {
+		return nil, ErrNotWarm
+	}
+
+	client := m.client.WithSampling(req.Temperature, req.MaxTokens).WithSamplingOptions(req.Sampling)
+	if p := req.Policy; p != nil {
+		client = client.WithFallback(p.Retries, p.Fallback).
+			WithAuditLog(p.AuditLog, store.Retention{Exclude: p.AuditExclude}.Excludes)
+	}
+	text, err := client.QueryContext(ctx, req.Prompt)
+	if err != nil {
+		return nil, err
+	}
+	gen := &Generation{Text: text, Backend: client.AnsweredBy()}
+	if client.FellBack() {
+		gen.FellBackFrom = client.GetBackend()
+	}
+	return gen, nil
+}
+
+// close stops the model's session. It's called with m.mu held.
+func (m *model) close() {
+	if m.client != nil {
+		m.client.Close()
+		m.client = nil
+	}
+	m.loaded.Store("")
+}
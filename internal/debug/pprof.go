@@ -0,0 +1,108 @@
+package debug
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	_ "net/http/pprof" // registers /debug/pprof handlers on the default mux
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+
+	"github.com/cliq-cli/cliq/internal/config"
+)
+
+// DefaultPprofAddr is where pprof is served for long-running modes
+const DefaultPprofAddr = "127.0.0.1:6060"
+
+// Profiler writes CPU, heap, and execution trace profiles for a single run
+type Profiler struct {
+	Dir   string
+	cpu   *os.File
+	trace *os.File
+}
+
+// StartProfiling begins CPU profiling and execution tracing into dir.
+// Call Stop to finish the profiles and write a heap snapshot.
+func StartProfiling(dir string) (*Profiler, error) {
+	if err := os.MkdirAll(dir, config.DirPerm); err != nil {
+		return nil, fmt.Errorf("failed to create profile directory: %w", err)
+	}
+
+	cpu, err := createProfile(filepath.Join(dir, "cpu.pprof"))
+	if err != nil {
+		return nil, err
+	}
+	if err := pprof.StartCPUProfile(cpu); err != nil {
+		cpu.Close()
+		return nil, fmt.Errorf("failed to start CPU profile: %w", err)
+	}
+
+	traceFile, err := createProfile(filepath.Join(dir, "trace.out"))
+	if err != nil {
+		pprof.StopCPUProfile()
+		cpu.Close()
+		return nil, err
+	}
+	if err := trace.Start(traceFile); err != nil {
+		pprof.StopCPUProfile()
+		cpu.Close()
+		traceFile.Close()
+		return nil, fmt.Errorf("failed to start trace: %w", err)
+	}
+
+	return &Profiler{Dir: dir, cpu: cpu, trace: traceFile}, nil
+}
+
+// Stop ends CPU profiling and tracing and writes the heap profile
+func (p *Profiler) Stop() error {
+	trace.Stop()
+	p.trace.Close()
+	pprof.StopCPUProfile()
+	p.cpu.Close()
+
+	heap, err := createProfile(filepath.Join(p.Dir, "heap.pprof"))
+	if err != nil {
+		return err
+	}
+	defer heap.Close()
+
+	// Collect garbage first so the profile reflects live memory
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(heap); err != nil {
+		return fmt.Errorf("failed to write heap profile: %w", err)
+	}
+	return nil
+}
+
+// createProfile creates a profile file; profiles can hold query text, so
+// they're private like other state
+func createProfile(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, config.FilePerm)
+}
+
+// ServePprof exposes the pprof HTTP endpoints on addr in the background and
+// returns the address actually listened on. If addr is taken, a free port
+// on the same host is used instead.
+func ServePprof(addr string) (string, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", fmt.Errorf("invalid pprof address %q: %w", addr, err)
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return "", fmt.Errorf("pprof is only served on localhost, not %s", host)
+	}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		ln, err = net.Listen("tcp", net.JoinHostPort(host, "0"))
+		if err != nil {
+			return "", fmt.Errorf("failed to listen for pprof: %w", err)
+		}
+	}
+
+	go http.Serve(ln, nil)
+	return ln.Addr().String(), nil
+}
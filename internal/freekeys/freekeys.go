@@ -0,0 +1,184 @@
+// Package freekeys finds leader-key combinations a Neovim config leaves
+// free and suggests ones that suit a new mapping
+package freekeys
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/cliq-cli/cliq/internal/parser"
+)
+
+// Suggestion is a free leader combination for a new mapping
+type Suggestion struct {
+	Lhs    string // as written in a config, such as <leader>ft
+	Keys   string // Lhs with the leader resolved
+	Reason string // why it suits the mapping
+	score  int
+}
+
+// stopWords are left out when picking letters from a task description
+var stopWords = map[string]bool{
+	"a": true, "an": true, "the": true, "to": true, "of": true, "for": true,
+	"and": true, "or": true, "my": true, "in": true, "on": true, "with": true,
+	"from": true, "into": true, "this": true, "current": true,
+}
+
+// homeRow letters are the easiest to reach after the leader
+const homeRow = "asdfghjkl"
+
+var keyNameRe = regexp.MustCompile(`<[^<>]+>`)
+
+// leaderMaps holds what follows the leader in the config's mappings
+type leaderMaps struct {
+	used   map[string]bool
+	groups map[string]int // first key after the leader -> longer mappings under it
+}
+
+// normalizeKeys lowercases the <...> key names in keys, and spells a
+// literal space leader as <space>
+func normalizeKeys(keys string) string {
+	keys = keyNameRe.ReplaceAllStringFunc(keys, strings.ToLower)
+	if strings.HasPrefix(keys, " ") {
+		keys = "<space>" + keys[1:]
+	}
+	return keys
+}
+
+// collect gathers the leader mappings of mode
+func collect(cfg *parser.NvimConfig, mode string) leaderMaps {
+	leader := normalizeKeys(cfg.ExpandLeader("<leader>"))
+	lm := leaderMaps{used: map[string]bool{}, groups: map[string]int{}}
+	for _, km := range cfg.Keymaps {
+		modes := km.Mode
+		if modes == "" {
+			modes = "n"
+		}
+		if !strings.Contains(modes, mode) {
+			continue
+		}
+		keys := normalizeKeys(km.Keys)
+		rest, ok := strings.CutPrefix(keys, leader)
+		if !ok || rest == "" {
+			continue
+		}
+		lm.used[rest] = true
+		if r := []rune(rest); len(r) > 1 && r[0] != '<' {
+			lm.groups[string(r[0])]++
+		}
+	}
+	return lm
+}
+
+// free reports whether rest can follow the leader without clashing: it
+// isn't mapped, and it neither starts nor is the start of a mapping, which
+// would leave Neovim waiting on timeoutlen to tell them apart
+func (lm leaderMaps) free(rest string) bool {
+	for used := range lm.used {
+		if strings.HasPrefix(used, rest) || strings.HasPrefix(rest, used) {
+			return false
+		}
+	}
+	return true
+}
+
+// taskWords returns the lowercase words of a task, without stop words
+func taskWords(task string) []string {
+	var words []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(task), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}) {
+		if !stopWords[w] && w[0] >= 'a' && w[0] <= 'z' {
+			words = append(words, w)
+		}
+	}
+	return words
+}
+
+// Suggest returns up to n free leader combinations for a mapping in mode
+// that does task, best first. Combinations that extend one of the config's
+// existing leader groups rank highest, then pairs of initials, then single
+// keys; each is picked from the letters of the task's words.
+func Suggest(cfg *parser.NvimConfig, mode, task string, n int) []Suggestion {
+	lm := collect(cfg, mode)
+	words := taskWords(task)
+
+	candidates := map[string]Suggestion{}
+	add := func(rest string, score int, reason string) {
+		if !lm.free(rest) {
+			return
+		}
+		for _, r := range rest {
+			if strings.ContainsRune(homeRow, r) {
+				score++
+			}
+		}
+		if s, ok := candidates[rest]; ok && s.score >= score {
+			return
+		}
+		candidates[rest] = Suggestion{Lhs: "<leader>" + rest, Keys: cfg.ExpandLeader("<leader>") + rest, Reason: reason, score: score}
+	}
+
+	for i, w := range words {
+		initial := w[:1]
+		// An existing group named by one word, with a letter of another
+		if count := lm.groups[initial]; count > 0 {
+			for j, other := range words {
+				if j == i {
+					continue
+				}
+				joins := fmt.Sprintf("joins your <leader>%s group (%d mappings): %s for %s", initial, count, initial, w)
+				add(initial+other[:1], 10+min(count, 5), fmt.Sprintf("%s, %s for %s", joins, other[:1], other))
+				for k, r := range other[1:] {
+					add(initial+string(r), 3-k, fmt.Sprintf("%s, %s from %s", joins, string(r), other))
+				}
+			}
+		}
+		// The word's initial on its own
+		add(initial, 6-i, fmt.Sprintf("a single key: %s for %s", initial, w))
+	}
+	for i, w := range words {
+		for _, other := range words[i+1:] {
+			add(w[:1]+other[:1], 7, fmt.Sprintf("%s%s for %s %s", w[:1], other[:1], w, other))
+		}
+		// The initial and a later letter of the same word
+		for k, r := range w[1:] {
+			add(w[:1]+string(r), 2-k, fmt.Sprintf("%s%s from %s", w[:1], string(r), w))
+		}
+	}
+
+	// Nothing fits the words: fall back to free home-row keys
+	if len(candidates) == 0 {
+		for _, r := range homeRow {
+			add(string(r), 0, "a free home-row key")
+		}
+	}
+
+	suggestions := make([]Suggestion, 0, len(candidates))
+	for _, s := range candidates {
+		suggestions = append(suggestions, s)
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].score != suggestions[j].score {
+			return suggestions[i].score > suggestions[j].score
+		}
+		return suggestions[i].Lhs < suggestions[j].Lhs
+	})
+	if len(suggestions) > n {
+		suggestions = suggestions[:n]
+	}
+	return suggestions
+}
+
+// KeymapLine is the vim.keymap.set call that makes a mapping of lhs in mode
+// running rhs, described as task
+func KeymapLine(mode, lhs, rhs, task string) string {
+	desc := strings.TrimSpace(task)
+	if r := []rune(desc); len(r) > 0 {
+		desc = string(unicode.ToUpper(r[0])) + string(r[1:])
+	}
+	return fmt.Sprintf("vim.keymap.set(%q, %q, %q, { desc = %q })", mode, lhs, rhs, desc)
+}
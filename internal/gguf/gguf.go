@@ -0,0 +1,214 @@
+// Package gguf reads the metadata at the start of a GGUF model file: the
+// model's architecture, its context window, how many layers it has, and the
+// chat template it was trained with.
+package gguf
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+// Metadata is what cliq uses from a model's header
+type Metadata struct {
+	Architecture  string `json:"architecture"`
+	Name          string `json:"name,omitempty"`
+	ContextLength int    `json:"context_length,omitempty"`
+	BlockCount    int    `json:"block_count,omitempty"`
+	ChatTemplate  string `json:"chat_template,omitempty"`
+}
+
+// ErrNotGGUF is returned for files that don't start with the GGUF magic
+var ErrNotGGUF = errors.New("not a GGUF file")
+
+// value types in the header
+const (
+	typeUint8 = iota
+	typeInt8
+	typeUint16
+	typeInt16
+	typeUint32
+	typeInt32
+	typeFloat32
+	typeBool
+	typeString
+	typeArray
+	typeUint64
+	typeInt64
+	typeFloat64
+)
+
+// maxString bounds strings read from the header, so a corrupt length
+// can't allocate gigabytes
+const maxString = 1 << 24
+
+// Read reads the metadata of the GGUF file at path. Only the header is
+// read, not the tensors.
+func Read(path string) (*Metadata, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := &reader{r: bufio.NewReaderSize(f, 1<<16)}
+	var magic [4]byte
+	if _, err := io.ReadFull(r.r, magic[:]); err != nil || string(magic[:]) != "GGUF" {
+		return nil, ErrNotGGUF
+	}
+	version := r.uint32()
+	if version < 2 {
+		// Version 1 used 32-bit counts and lengths; nothing current writes it
+		return nil, fmt.Errorf("GGUF version %d is not supported", version)
+	}
+	r.uint64() // tensor count
+	count := r.uint64()
+
+	values := map[string]any{}
+	for i := uint64(0); i < count && r.err == nil; i++ {
+		key := r.string()
+		typ := r.uint32()
+		if r.err != nil {
+			break
+		}
+		if wanted(key) {
+			values[key] = r.value(typ)
+		} else {
+			r.skip(typ)
+		}
+	}
+	if r.err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, r.err)
+	}
+
+	m := &Metadata{}
+	m.Architecture, _ = values["general.architecture"].(string)
+	m.Name, _ = values["general.name"].(string)
+	m.ChatTemplate, _ = values["tokenizer.chat_template"].(string)
+	m.ContextLength = toInt(values[m.Architecture+".context_length"])
+	m.BlockCount = toInt(values[m.Architecture+".block_count"])
+	return m, nil
+}
+
+// wanted reports whether Read keeps the key's value. The architecture
+// isn't known until its key is read, so every context_length and
+// block_count is kept.
+func wanted(key string) bool {
+	switch key {
+	case "general.architecture", "general.name", "tokenizer.chat_template":
+		return true
+	}
+	return strings.HasSuffix(key, ".context_length") || strings.HasSuffix(key, ".block_count")
+}
+
+func toInt(v any) int {
+	switch n := v.(type) {
+	case uint64:
+		return int(min(n, math.MaxInt32))
+	case int64:
+		return int(max(0, min(n, math.MaxInt32)))
+	}
+	return 0
+}
+
+// reader reads little-endian values, keeping the first error
+type reader struct {
+	r   *bufio.Reader
+	err error
+	buf [8]byte
+}
+
+func (r *reader) bytes(n int) []byte {
+	if r.err != nil {
+		return r.buf[:n]
+	}
+	_, r.err = io.ReadFull(r.r, r.buf[:n])
+	return r.buf[:n]
+}
+
+func (r *reader) uint32() uint32 { return binary.LittleEndian.Uint32(r.bytes(4)) }
+func (r *reader) uint64() uint64 { return binary.LittleEndian.Uint64(r.bytes(8)) }
+
+func (r *reader) string() string {
+	n := r.uint64()
+	if r.err != nil {
+		return ""
+	}
+	if n > maxString {
+		r.err = fmt.Errorf("string of %d bytes", n)
+		return ""
+	}
+	b := make([]byte, n)
+	_, r.err = io.ReadFull(r.r, b)
+	return string(b)
+}
+
+// value reads a scalar or string; integers come back as uint64 or int64.
+// Arrays are skipped, as nothing Read keeps is one.
+func (r *reader) value(typ uint32) any {
+	switch typ {
+	case typeUint8:
+		return uint64(r.bytes(1)[0])
+	case typeInt8:
+		return int64(int8(r.bytes(1)[0]))
+	case typeUint16:
+		return uint64(binary.LittleEndian.Uint16(r.bytes(2)))
+	case typeInt16:
+		return int64(int16(binary.LittleEndian.Uint16(r.bytes(2))))
+	case typeUint32:
+		return uint64(r.uint32())
+	case typeInt32:
+		return int64(int32(r.uint32()))
+	case typeUint64:
+		return r.uint64()
+	case typeInt64:
+		return int64(r.uint64())
+	case typeString:
+		return r.string()
+	}
+	r.skip(typ)
+	return nil
+}
+
+// skip reads past a value of the type
+func (r *reader) skip(typ uint32) {
+	switch typ {
+	case typeUint8, typeInt8, typeBool:
+		r.discard(1)
+	case typeUint16, typeInt16:
+		r.discard(2)
+	case typeUint32, typeInt32, typeFloat32:
+		r.discard(4)
+	case typeUint64, typeInt64, typeFloat64:
+		r.discard(8)
+	case typeString:
+		r.discard(int(r.uint64()))
+	case typeArray:
+		elem := r.uint32()
+		n := r.uint64()
+		// The vocabulary is an array of strings, often 100k or more
+		for i := uint64(0); i < n && r.err == nil; i++ {
+			r.skip(elem)
+		}
+	default:
+		if r.err == nil {
+			r.err = fmt.Errorf("unknown value type %d", typ)
+		}
+	}
+}
+
+func (r *reader) discard(n int) {
+	if r.err != nil {
+		return
+	}
+	if n < 0 || n > maxString {
+		r.err = fmt.Errorf("value of %d bytes", n)
+		return
+	}
+	_, r.err = r.r.Discard(n)
+}
@@ -0,0 +1,213 @@
+// Package hooks runs the user's hooks.lua, whose functions can change the
+// prompt before it's sent to the model and the answer before it's shown
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	lua "github.com/yuin/gopher-lua"
+
+	"github.com/cliq-cli/cliq/internal/response"
+)
+
+// File is the name of the hooks file in cliq's config directory
+const File = "hooks.lua"
+
+// timeout bounds loading the file and each call of a hook, so a hook
+// stuck in a loop can't hang a query
+const timeout = time.Second
+
+// Hooks is a loaded hooks file. Its hooks are called one at a time.
+type Hooks struct {
+	mu    sync.Mutex
+	state *lua.LState
+	path  string
+
+	// Source is the file's text, which an answer cached with another
+	// on_prompt may not be the answer to
+	Source string
+}
+
+// Prompt is what on_prompt is given: the prompt, and what it was built for
+type Prompt struct {
+	Query  string
+	Prompt string
+	Dir    string
+	Shell  string
+}
+
+// Load runs the hooks file at path, returning nil without one. The hooks
+// get Lua's base, string, table, and math libraries, and os.getenv,
+// os.time, and os.date; they can't read or write files or run commands.
+func Load(path string) (*Hooks, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	h := &Hooks{state: newState(), path: path, Source: string(data)}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	h.state.SetContext(ctx)
+	defer h.state.RemoveContext()
+
+	fn, err := h.state.Load(bytes.NewReader(data), "@"+path)
+	if err == nil {
+		h.state.Push(fn)
+		err = h.state.PCall(0, 0, nil)
+	}
+	if ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		h.state.Close()
+		return nil, h.luaError("loading", err)
+	}
+	return h, nil
+}
+
+// newState returns a Lua state with only the libraries hooks get
+func newState() *lua.LState {
+	L := lua.NewState(lua.Options{SkipOpenLibs: true})
+	for _, lib := range []struct {
+		name string
+		open lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+		{lua.OsLibName, lua.OpenOs},
+	} {
+		L.Push(L.NewFunction(lib.open))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+
+	// Nothing that reads files or runs commands
+	for _, name := range []string{"dofile", "loadfile"} {
+		L.SetGlobal(name, lua.LNil)
+	}
+	osLib := L.NewTable()
+	for _, name := range []string{"getenv", "time", "date", "clock"} {
+		osLib.RawSetString(name, L.GetField(L.GetGlobal("os"), name))
+	}
+	L.SetGlobal("os", osLib)
+	return L
+}
+
+// call runs the named hook with arg and returns what it returned. ok is
+// false for a hook the file doesn't define.
+func (h *Hooks) call(name string, arg lua.LValue) (ret lua.LValue, ok bool, err error) {
+	fn := h.state.GetGlobal(name)
+	if fn.Type() != lua.LTFunction {
+		return nil, false, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	h.state.SetContext(ctx)
+	defer h.state.RemoveContext()
+
+	if err := h.state.CallByParam(lua.P{Fn: fn, NRet: 1, Protect: true}, arg); err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		return nil, true, h.luaError(name, err)
+	}
+	ret = h.state.Get(-1)
+	h.state.Pop(1)
+	return ret, true, nil
+}
+
+// luaError describes an error running the hooks file: where it was, from
+// Lua, and what happened, without the traceback
+func (h *Hooks) luaError(what string, err error) error {
+	var apiErr *lua.ApiError
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return fmt.Errorf("%s: %s: took longer than %s", what, h.path, timeout)
+	case errors.As(err, &apiErr) && apiErr.Object != nil:
+		msg := strings.Join(strings.Fields(apiErr.Object.String()), " ")
+		return fmt.Errorf("%s: %s", what, strings.TrimPrefix(msg, "@"))
+	}
+	return fmt.Errorf("%s: %s: %w", h.path, what, err)
+}
+
+// OnPrompt calls on_prompt(ctx), with ctx.prompt, ctx.query, ctx.dir, and
+// ctx.shell, and returns the prompt it leaves: the string it returns, or
+// else ctx.prompt, which it may have changed. Without on_prompt, the
+// prompt is returned as it was.
+func (h *Hooks) OnPrompt(p Prompt) (string, error) {
+	if h == nil {
+		return p.Prompt, nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ctx := h.state.NewTable()
+	ctx.RawSetString("prompt", lua.LString(p.Prompt))
+	ctx.RawSetString("query", lua.LString(p.Query))
+	ctx.RawSetString("dir", lua.LString(p.Dir))
+	ctx.RawSetString("shell", lua.LString(p.Shell))
+
+	ret, ok, err := h.call("on_prompt", ctx)
+	if err != nil || !ok {
+		return p.Prompt, err
+	}
+	if s, isString := ret.(lua.LString); isString {
+		return string(s), nil
+	}
+	prompt, isString := ctx.RawGetString("prompt").(lua.LString)
+	if !isString {
+		return p.Prompt, fmt.Errorf("%s: on_prompt left ctx.prompt %s, not a string", h.path, ctx.RawGetString("prompt").Type())
+	}
+	return string(prompt), nil
+}
+
+// OnResponse calls on_response(resp) with the answer as a table of the
+// fields --format json prints, and sets the answer to the table it
+// returns, or else to resp, which it may have changed. A field it sets to
+// nil is left out of the answer.
+func (h *Hooks) OnResponse(resp *response.Response) error {
+	if h == nil {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	table := toTable(h.state, resp)
+	ret, ok, err := h.call("on_response", table)
+	if err != nil || !ok {
+		return err
+	}
+	if t, isTable := ret.(*lua.LTable); isTable {
+		table = t
+	}
+	changed, err := fromTable(table)
+	if err != nil {
+		return fmt.Errorf("%s: on_response: %w", h.path, err)
+	}
+	changed.Raw = resp.Raw
+	*resp = *changed
+	return nil
+}
+
+// Close releases the Lua state
+func (h *Hooks) Close() {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.state.Close()
+}
@@ -0,0 +1,124 @@
+package hooks
+
+import (
+	"fmt"
+
+	lua "github.com/yuin/gopher-lua"
+
+	"github.com/cliq-cli/cliq/internal/response"
+)
+
+// stringFields and listFields are the fields of a response a hook sees,
+// named as in --format json, and where each is kept
+func stringFields(r *response.Response) map[string]*string {
+	return map[string]*string{
+		"query":       &r.Query,
+		"command":     &r.Command,
+		"explanation": &r.Explanation,
+		"tmux_prefix": &r.TmuxPrefix,
+	}
+}
+
+func listFields(r *response.Response) map[string]*[]string {
+	return map[string]*[]string{
+		"alternatives": &r.Alternatives,
+		"user_keymaps": &r.UserKeymaps,
+		"with_plugins": &r.WithPlugins,
+		"related":      &r.Related,
+		"tips":         &r.Tips,
+		"layout_notes": &r.LayoutNotes,
+		"shell_notes":  &r.ShellNotes,
+	}
+}
+
+// toTable converts a response to the table on_response is given
+func toTable(L *lua.LState, r *response.Response) *lua.LTable {
+	t := L.NewTable()
+	for name, field := range stringFields(r) {
+		if *field != "" {
+			t.RawSetString(name, lua.LString(*field))
+		}
+	}
+	for name, field := range listFields(r) {
+		if len(*field) == 0 {
+			continue
+		}
+		list := L.NewTable()
+		for _, item := range *field {
+			list.Append(lua.LString(item))
+		}
+		t.RawSetString(name, list)
+	}
+	if len(r.Citations) > 0 {
+		list := L.NewTable()
+		for _, c := range r.Citations {
+			cite := L.NewTable()
+			cite.RawSetString("marker", lua.LNumber(c.Marker))
+			cite.RawSetString("source", lua.LString(c.Source))
+			if c.Excerpt != "" {
+				cite.RawSetString("excerpt", lua.LString(c.Excerpt))
+			}
+			list.Append(cite)
+		}
+		t.RawSetString("citations", list)
+	}
+	return t
+}
+
+// fromTable converts the table on_response left back to a response
+func fromTable(t *lua.LTable) (*response.Response, error) {
+	r := &response.Response{}
+	for name, field := range stringFields(r) {
+		switch v := t.RawGetString(name).(type) {
+		case lua.LString:
+			*field = string(v)
+		case *lua.LNilType:
+		default:
+			return nil, fmt.Errorf("%s should be a string, not a %s", name, v.Type())
+		}
+	}
+	for name, field := range listFields(r) {
+		switch v := t.RawGetString(name).(type) {
+		case *lua.LTable:
+			items, err := stringList(name, v)
+			if err != nil {
+				return nil, err
+			}
+			*field = items
+		case *lua.LNilType:
+		default:
+			return nil, fmt.Errorf("%s should be a list of strings, not a %s", name, v.Type())
+		}
+	}
+
+	switch v := t.RawGetString("citations").(type) {
+	case *lua.LTable:
+		for i := 1; i <= v.Len(); i++ {
+			cite, ok := v.RawGetInt(i).(*lua.LTable)
+			if !ok {
+				return nil, fmt.Errorf("citations[%d] should be a table", i)
+			}
+			marker, _ := cite.RawGetString("marker").(lua.LNumber)
+			source, _ := cite.RawGetString("source").(lua.LString)
+			excerpt, _ := cite.RawGetString("excerpt").(lua.LString)
+			r.Citations = append(r.Citations, response.Citation{Marker: int(marker), Source: string(source), Excerpt: string(excerpt)})
+		}
+	case *lua.LNilType:
+	default:
+		return nil, fmt.Errorf("citations should be a list, not a %s", v.Type())
+	}
+	return r, nil
+}
+
+// stringList converts a Lua list of strings
+func stringList(name string, t *lua.LTable) ([]string, error) {
+	var items []string
+	for i := 1; i <= t.Len(); i++ {
+		s, ok := t.RawGetInt(i).(lua.LString)
+		if !ok {
+			return nil, fmt.Errorf("%s[%d] should be a string, not a %s", name, i, t.RawGetInt(i).Type())
+		}
+		items = append(items, string(s))
+	}
+	return items, nil
+}
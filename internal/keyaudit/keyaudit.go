@@ -0,0 +1,403 @@
+// Package keyaudit looks through parsed Neovim and tmux keymaps for
+// problems: keys bound more than once, mappings that shadow important
+// built-in keys, and tmux bindings that take keys from the shell or Neovim
+// running inside it.
+package keyaudit
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/cliq-cli/cliq/internal/keynotation"
+	"github.com/cliq-cli/cliq/internal/parser"
+)
+
+// Severity ranks a finding
+type Severity int
+
+const (
+	// Low findings are worth knowing but often intended
+	Low Severity = iota
+	// Medium findings lose a useful built-in key or a binding of the user's
+	Medium
+	// High findings make a key unusable
+	High
+)
+
+// String names the severity for reports
+func (s Severity) String() string {
+	switch s {
+	case High:
+		return "high"
+	case Medium:
+		return "medium"
+	}
+	return "low"
+}
+
+// MarshalText writes the severity's name, for JSON reports
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Finding is one problem found
+type Finding struct {
+	Severity Severity `json:"severity"`
+	Tool     string   `json:"tool"` // nvim or tmux
+	// Mode is the Neovim mode, or the tmux key table
+	Mode    string `json:"mode"`
+	Keys    string `json:"keys"`
+	Message string `json:"message"`
+}
+
+// Audit checks the keymaps of either config, which may be nil, and returns
+// the findings, most severe first
+func Audit(nvim *parser.NvimConfig, tmux *parser.TmuxConfig) []Finding {
+	var findings []Finding
+	if nvim != nil {
+		findings = append(findings, NvimDuplicates(nvim)...)
+		findings = append(findings, nvimShadowed(nvim)...)
+	}
+	if tmux != nil {
+		findings = append(findings, tmuxDuplicates(tmux)...)
+		findings = append(findings, tmuxRoot(tmux)...)
+		findings = append(findings, tmuxPrefix(tmux)...)
+	}
+	if nvim != nil && tmux != nil {
+		findings = append(findings, tmuxSwallows(nvim, tmux)...)
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Severity > findings[j].Severity
+	})
+	return findings
+}
+
+// keyNameRe matches the <...> key names in an lhs, which are case
+// insensitive, unlike the keys between them
+var keyNameRe = regexp.MustCompile(`<[^<>]+>`)
+
+// lhsKey normalizes a Neovim lhs for comparison
+func lhsKey(km parser.Keymap) string {
+	keys := km.Keys
+	if keys == "" {
+		keys = km.Lhs
+	}
+	return keyNameRe.ReplaceAllStringFunc(keys, strings.ToLower)
+}
+
+// userKeymaps are the keymaps the user made, without distribution defaults,
+// one per mode
+func userKeymaps(nvim *parser.NvimConfig) []parser.Keymap {
+	var keymaps []parser.Keymap
+	for _, km := range nvim.Keymaps {
+		if km.Distro != "" {
+			continue
+		}
+		modes := km.Mode
+		if modes == "" {
+			modes = "n"
+		}
+		for _, mode := range modes {
+			one := km
+			one.Mode = string(mode)
+			keymaps = append(keymaps, one)
+		}
+	}
+	return keymaps
+}
+
+// NvimDuplicates finds keys the user maps more than once in the same mode
+func NvimDuplicates(nvim *parser.NvimConfig) []Finding {
+	groups := map[string][]parser.Keymap{}
+	var order []string
+	for _, km := range userKeymaps(nvim) {
+		id := km.Mode + " " + lhsKey(km)
+		if groups[id] == nil {
+			order = append(order, id)
+		}
+		groups[id] = append(groups[id], km)
+	}
+
+	var findings []Finding
+	for _, id := range order {
+		kms := groups[id]
+		if len(kms) < 2 {
+			continue
+		}
+		same := true
+		var where []string
+		for _, km := range kms {
+			same = same && km.Rhs == kms[0].Rhs
+			where = append(where, describeNvim(km))
+		}
+		f := Finding{Severity: Medium, Tool: "nvim", Mode: kms[0].Mode, Keys: kms[0].Keys}
+		if same {
+			f.Severity = Low
+			f.Message = fmt.Sprintf("mapped %d times to the same thing: %s", len(kms), strings.Join(where, "; "))
+		} else {
+			f.Message = fmt.Sprintf("mapped %d times, and only the one loaded last takes effect: %s", len(kms), strings.Join(where, "; "))
+		}
+		findings = append(findings, f)
+	}
+	return findings
+}
+
+// describeNvim says what a keymap does and where it's made
+func describeNvim(km parser.Keymap) string {
+	s := km.Rhs
+	switch {
+	case km.Plugin != "":
+		s += " (" + km.Plugin + ")"
+	case km.Source != "":
+		s += " (" + shortPath(km.Source) + ")"
+	}
+	return s
+}
+
+// shortPath keeps the last two elements of a path, enough to find the file
+func shortPath(path string) string {
+	parts := strings.Split(path, "/")
+	if len(parts) > 2 {
+		return strings.Join(parts[len(parts)-2:], "/")
+	}
+	return path
+}
+
+// builtin is a built-in Neovim key worth keeping
+type builtin struct {
+	severity Severity
+	does     string
+}
+
+// nvimBuiltins are the built-in keys, by mode and key, that mapping over
+// loses something
+var nvimBuiltins = map[string]map[string]builtin{
+	"n": {
+		"u": {High, "undo"}, "<c-r>": {High, "redo"}, ".": {High, "repeat the last change"},
+		":": {High, "the command line"}, "/": {High, "search"}, "?": {Medium, "search backwards"},
+		"i": {High, "insert mode"}, "a": {High, "append"}, "o": {High, "open a line below"},
+		"v": {High, "visual mode"}, "<esc>": {Low, "cancel"},
+		"<c-o>": {Medium, "jump back"}, "<c-i>": {Medium, "jump forward"},
+		"<tab>": {Medium, "jump forward (Tab is <C-i> in a terminal)"},
+		"n":     {Medium, "next search match"}, "N": {Medium, "previous search match"},
+		"q": {Medium, "macro recording"}, "@": {Medium, "running a macro"},
+		"p": {Medium, "paste"}, "P": {Medium, "paste before"}, "y": {Medium, "yank"},
+		"d": {Medium, "delete"}, "c": {Medium, "change"}, "x": {Low, "delete a character"},
+		"<c-v>": {Medium, "visual block mode"}, "V": {Medium, "visual line mode"},
+		"<c-w>": {Medium, "window commands"}, "gg": {Medium, "go to the first line"},
+		"G": {Medium, "go to the last line"}, "<c-a>": {Low, "increment a number"},
+		"<c-x>": {Low, "decrement a number"}, "*": {Low, "search for the word under the cursor"},
+		"%": {Low, "jump to the matching bracket"}, "s": {Low, "substitute a character"},
+		"S": {Low, "substitute a line"}, "<c-]>": {Medium, "jump to a tag or definition"},
+	},
+	"i": {
+		"<c-w>": {Medium, "delete the word before the cursor"}, "<c-r>": {Medium, "insert a register"},
+		"<c-o>": {Medium, "run one normal mode command"}, "<c-u>": {Low, "delete the line before the cursor"},
+		"<c-n>": {Low, "complete the next match"}, "<c-p>": {Low, "complete the previous match"},
+	},
+}
+
+// nvimShadowed finds user keymaps over important built-in keys. A mapping
+// whose rhs still runs the key, like n to nzzzv, extends it rather than
+// shadowing it.
+func nvimShadowed(nvim *parser.NvimConfig) []Finding {
+	var findings []Finding
+	for _, km := range userKeymaps(nvim) {
+		lhs := lhsKey(km)
+		b, ok := nvimBuiltins[km.Mode][lhs]
+		if !ok {
+			continue
+		}
+		rhs := keyNameRe.ReplaceAllStringFunc(km.Rhs, strings.ToLower)
+		if strings.HasPrefix(rhs, lhs) || len(lhs) > 1 && strings.Contains(rhs, lhs) {
+			continue
+		}
+
+		f := Finding{Severity: b.severity, Tool: "nvim", Mode: km.Mode, Keys: km.Keys}
+		if strings.EqualFold(km.Rhs, "<nop>") {
+			f.Severity = Low
+			f.Message = fmt.Sprintf("disables %s", b.does)
+		} else {
+			f.Message = fmt.Sprintf("shadows the built-in %s, mapped to %s", b.does, describeNvim(km))
+		}
+		findings = append(findings, f)
+	}
+	return findings
+}
+
+// tmuxDuplicates finds keys bound more than once in the same table, outside
+// if-shell and %if, where each binding replaces the last
+func tmuxDuplicates(tmux *parser.TmuxConfig) []Finding {
+	groups := map[string][]parser.TmuxKeymap{}
+	var order []string
+	for _, km := range tmux.Keymaps {
+		if km.Condition != "" {
+			continue
+		}
+		id := km.Table + " " + km.Key
+		if groups[id] == nil {
+			order = append(order, id)
+		}
+		groups[id] = append(groups[id], km)
+	}
+
+	var findings []Finding
+	for _, id := range order {
+		kms := groups[id]
+		if len(kms) < 2 {
+			continue
+		}
+		var commands []string
+		for _, km := range kms {
+			commands = append(commands, km.Command)
+		}
+		findings = append(findings, Finding{
+			Severity: Medium, Tool: "tmux", Mode: kms[0].Table, Keys: kms[0].Key,
+			Message: fmt.Sprintf("bound %d times, and the last binding wins: %s", len(kms), strings.Join(commands, "; ")),
+		})
+	}
+	return findings
+}
+
+// terminalKey is a key the shell or Neovim running inside tmux uses
+type terminalKey struct {
+	severity Severity
+	uses     string
+}
+
+// terminalKeys are the keys, in tmux notation, that a root-table binding
+// takes from every program in the pane
+var terminalKeys = map[string]terminalKey{
+	"C-c": {High, "interrupting a program"}, "C-d": {High, "end of input and Vim's scroll down"},
+	"C-z": {High, "suspending a program"}, "Enter": {High, "running a command"},
+	"Tab": {High, "completion"}, "Escape": {High, "Vim's normal mode"},
+	"C-r": {High, "shell history search and Vim's redo"}, "C-w": {High, "deleting a word and Vim's window commands"},
+	"C-u": {High, "deleting the line and Vim's scroll up"},
+	"C-a": {Medium, "the shell's start of line and Vim's increment"}, "C-e": {Medium, "the shell's end of line"},
+	"C-k": {Medium, "the shell's kill to end of line"}, "C-l": {Medium, "clearing the screen"},
+	"C-h": {Medium, "backspace in many terminals"}, "C-p": {Medium, "previous history entry and completion"},
+	"C-n": {Medium, "next history entry and completion"}, "C-b": {Medium, "moving back and Vim's page up"},
+	"C-f": {Medium, "moving forward and Vim's page down"}, "C-o": {Medium, "Vim's jump back"},
+	"C-v": {Medium, "quoting a key and Vim's visual block mode"}, "C-t": {Low, "transposing characters"},
+	"C-y": {Low, "pasting killed text"}, "C-j": {Low, "newline"}, "C-x": {Low, "the shell's editing prefix and Vim's decrement"},
+	"M-b": {Low, "moving back a word"}, "M-f": {Low, "moving forward a word"}, "M-.": {Low, "inserting the last argument"},
+}
+
+// tmuxKeyName writes a tmux key in tmux's own notation, so C-H and C-h
+// compare equal
+func tmuxKeyName(key string) string {
+	k, ok := keynotation.ParseKey(key)
+	if !ok {
+		return key
+	}
+	name := k.Name
+	switch name {
+	case "Esc":
+		name = "Escape"
+	}
+	var sb strings.Builder
+	if k.Ctrl {
+		sb.WriteString("C-")
+	}
+	if k.Alt {
+		sb.WriteString("M-")
+	}
+	if k.Shift {
+		sb.WriteString("S-")
+	}
+	sb.WriteString(name)
+	return sb.String()
+}
+
+// passesThrough reports whether a root binding hands the key on to Vim, as
+// vim-tmux-navigator's do
+func passesThrough(km parser.TmuxKeymap) bool {
+	return km.Plugin == "vim-tmux-navigator" || strings.Contains(km.Command, "is_vim")
+}
+
+// effective returns the tmux bindings in effect: of those made for the same
+// key, table, and condition, the last
+func effective(tmux *parser.TmuxConfig) []parser.TmuxKeymap {
+	last := map[string]int{}
+	for i, km := range tmux.Keymaps {
+		last[km.Table+" "+km.Key+" "+km.Condition] = i
+	}
+	var keymaps []parser.TmuxKeymap
+	for i, km := range tmux.Keymaps {
+		if last[km.Table+" "+km.Key+" "+km.Condition] == i {
+			keymaps = append(keymaps, km)
+		}
+	}
+	return keymaps
+}
+
+// tmuxRoot finds root-table bindings, made with bind -n, of keys the shell
+// and Neovim use: tmux takes them before any program in the pane sees them
+func tmuxRoot(tmux *parser.TmuxConfig) []Finding {
+	var findings []Finding
+	for _, km := range effective(tmux) {
+		if km.Table != "root" {
+			continue
+		}
+		key := tmuxKeyName(km.Key)
+		f := Finding{Tool: "tmux", Mode: "root", Keys: km.Key}
+		if tk, ok := terminalKeys[key]; ok {
+			f.Severity = tk.severity
+			f.Message = fmt.Sprintf("bound without the prefix, taking it from programs in the pane, which use it for %s", tk.uses)
+			if passesThrough(km) {
+				f.Severity = Low
+				f.Message = fmt.Sprintf("passed on to Vim, but taken from the shell, which uses it for %s", tk.uses)
+			}
+		} else if len([]rune(km.Key)) == 1 {
+			f.Severity = High
+			f.Message = "bound without the prefix, so it can't be typed in any pane"
+		} else {
+			continue
+		}
+		findings = append(findings, f)
+	}
+	return findings
+}
+
+// tmuxPrefix notes a prefix taken from a key the shell and Neovim use, which
+// then has to be pressed twice
+func tmuxPrefix(tmux *parser.TmuxConfig) []Finding {
+	key := tmuxKeyName(tmux.Prefix)
+	tk, ok := terminalKeys[key]
+	if !ok || key == "C-b" {
+		return nil
+	}
+	return []Finding{{
+		Severity: Low, Tool: "tmux", Mode: "prefix", Keys: tmux.Prefix,
+		Message: fmt.Sprintf("the prefix takes %s; send it on with prefix then %s (send-prefix)", tk.uses, tmux.Prefix),
+	}}
+}
+
+// tmuxSwallows finds Neovim keymaps on keys tmux binds in its root table,
+// which Neovim never receives when run inside tmux
+func tmuxSwallows(nvim *parser.NvimConfig, tmux *parser.TmuxConfig) []Finding {
+	root := map[string]parser.TmuxKeymap{}
+	for _, km := range effective(tmux) {
+		if km.Table == "root" && km.Condition == "" && !passesThrough(km) {
+			root[tmuxKeyName(km.Key)] = km
+		}
+	}
+
+	var findings []Finding
+	seen := map[string]bool{}
+	for _, km := range userKeymaps(nvim) {
+		t, ok := root[tmuxKeyName(km.Keys)]
+		if !ok || seen[km.Keys] {
+			continue
+		}
+		seen[km.Keys] = true
+		findings = append(findings, Finding{
+			Severity: High, Tool: "nvim", Mode: km.Mode, Keys: km.Keys,
+			Message: fmt.Sprintf("never reaches Neovim inside tmux, which binds %s to %s without the prefix", t.Key, t.Command),
+		})
+	}
+	return findings
+}
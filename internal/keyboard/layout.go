@@ -0,0 +1,116 @@
+package keyboard
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Layout describes a keyboard layout and how it affects Vim/tmux usage
+type Layout struct {
+	Name string
+
+	// Advice is included in the prompt so ergonomic suggestions fit the layout
+	Advice string
+
+	// Keystrokes maps characters to how they are typed on this layout, for
+	// characters that need a different key or modifier than on US QWERTY
+	Keystrokes map[string]string
+}
+
+// DefaultLayout is the layout assumed when none is configured
+const DefaultLayout = "qwerty"
+
+// layouts holds the supported keyboard layouts, keyed by config name
+var layouts = map[string]*Layout{
+	"qwerty": {
+		Name: "QWERTY (US)",
+	},
+	"colemak": {
+		Name: "Colemak",
+		Advice: "The user types on Colemak. The QWERTY h/j/k/l positions hold h/n/e/i, " +
+			"so j and k are not on the home row. Do not describe hjkl as home-row keys; " +
+			"when ergonomics matter, suggest alternatives such as search (/), f/t motions, " +
+			"or remapping (many Colemak users map n/e/i to j/k/l).",
+	},
+	"dvorak": {
+		Name: "Dvorak",
+		Advice: "The user types on Dvorak. h and l are far apart and j/k sit on the left " +
+			"hand's bottom row, so hjkl is not a home-row cluster. Do not describe hjkl as " +
+			"home-row keys; prefer word motions, search, and f/t jumps when suggesting " +
+			"efficient movement.",
+	},
+	"azerty": {
+		Name: "AZERTY (French)",
+		Advice: "The user types on a French AZERTY keyboard. Digits on the main row need " +
+			"Shift, and brackets, backslash, and pipe need AltGr, so prefer suggestions " +
+			"that avoid them when an equivalent exists.",
+		Keystrokes: map[string]string{
+			"0": "Shift-à", "1": "Shift-&", "2": "Shift-é", "3": "Shift-\"", "4": "Shift-'",
+			"5": "Shift-(", "6": "Shift--", "7": "Shift-è", "8": "Shift-_", "9": "Shift-ç",
+			"[": "AltGr-5", "]": "AltGr-°", "{": "AltGr-4", "}": "AltGr-=",
+			"\\": "AltGr-8", "|": "AltGr-6", "@": "AltGr-à", "#": "AltGr-\"",
+			"~": "AltGr-é", "`": "AltGr-è", "^": "^ then Space (dead key)",
+			".": "Shift-;", "/": "Shift-:", "?": "Shift-,", "%": "Shift-ù",
+		},
+	},
+	"qwertz": {
+		Name: "QWERTZ (German)",
+		Advice: "The user types on a German QWERTZ keyboard. Brackets, braces, backslash, " +
+			"pipe, and @ need AltGr, and / : ; need Shift, so prefer suggestions that " +
+			"avoid them when an equivalent exists.",
+		Keystrokes: map[string]string{
+			"[": "AltGr-8", "]": "AltGr-9", "{": "AltGr-7", "}": "AltGr-0",
+			"\\": "AltGr-ß", "|": "AltGr-<", "@": "AltGr-q", "~": "AltGr-+",
+			"/": "Shift-7", ":": "Shift-.", ";": "Shift-,", "=": "Shift-0",
+			"*": "Shift-+", "?": "Shift-ß", "'": "Shift-#", "\"": "Shift-2",
+			"^": "^ then Space (dead key)", "`": "Shift-´ then Space (dead key)",
+		},
+	},
+}
+
+// Lookup returns the layout with the given config name
+func Lookup(name string) (*Layout, bool) {
+	layout, ok := layouts[strings.ToLower(strings.TrimSpace(name))]
+	return layout, ok
+}
+
+// Names returns the config names of all supported layouts
+func Names() []string {
+	names := make([]string, 0, len(layouts))
+	for name := range layouts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsDefault reports whether the layout is plain US QWERTY
+func (l *Layout) IsDefault() bool {
+	return l.Advice == "" && len(l.Keystrokes) == 0
+}
+
+// DescribeKeys returns how to type each character in keys that differs from
+// US QWERTY, in the order the characters first appear
+func (l *Layout) DescribeKeys(keys string) []string {
+	if len(l.Keystrokes) == 0 {
+		return nil
+	}
+
+	var notes []string
+	seen := make(map[string]bool)
+
+	for _, r := range keys {
+		ch := string(r)
+		if seen[ch] {
+			continue
+		}
+		seen[ch] = true
+
+		if stroke, ok := l.Keystrokes[ch]; ok {
+			notes = append(notes, fmt.Sprintf("%s = %s", ch, stroke))
+		}
+	}
+
+	return notes
+}
@@ -0,0 +1,242 @@
+// Package keynotation normalizes how keybindings are written. Vim writes
+// Ctrl-b as <C-b>, tmux as C-b, most documentation as Ctrl-b or Ctrl+b, and
+// terminals as ^B; this package recognises all of them and rewrites them to
+// the one notation the user prefers.
+package keynotation
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Notations the user can choose from
+const (
+	// Vim writes keys like <C-b> and <M-x>
+	Vim = "vim"
+	// Ctrl writes keys like Ctrl-b and Alt-x
+	Ctrl = "ctrl"
+	// Caret writes control keys like ^B, and other keys like Ctrl does
+	Caret = "caret"
+)
+
+// Names lists the supported notations
+var Names = []string{Vim, Ctrl, Caret}
+
+// Key is a key with its modifiers
+type Key struct {
+	Ctrl, Alt, Shift bool
+	// Name is the key itself: a single character, or a name like Space or
+	// Enter
+	Name string
+}
+
+var (
+	// <C-b>, <M-x>, <C-S-Tab>
+	vimKeyRe = regexp.MustCompile(`(?i)<((?:[CMAS]-)+)([^<>\s-]+|-)>`)
+	// Ctrl-b, Ctrl+Shift+x, CTRL-W, Alt-Enter
+	wordKeyRe = regexp.MustCompile(`(?i)\b((?:(?:ctrl|control|alt|meta|option|shift)[-+])+)([A-Za-z0-9]+\b|[^\sA-Za-z0-9])`)
+	// C-b, M-x, C-M-a as tmux writes them
+	tmuxKeyRe = regexp.MustCompile(`\b((?:[CMS]-)+)([A-Za-z0-9]+\b|[^\sA-Za-z0-9])`)
+	// ^B, ^[
+	caretKeyRe = regexp.MustCompile(`\^([A-Z@\[\\\]^_])([^A-Za-z0-9]|$)`)
+
+	// namedKeys canonicalises the spellings of named keys
+	namedKeys = map[string]string{
+		"space": "Space", "spc": "Space",
+		"enter": "Enter", "return": "Enter", "cr": "Enter", "ret": "Enter",
+		"tab": "Tab", "esc": "Esc", "escape": "Esc",
+		"bs": "Backspace", "backspace": "Backspace", "bspace": "Backspace",
+		"del": "Delete", "delete": "Delete", "dc": "Delete",
+		"up": "Up", "down": "Down", "left": "Left", "right": "Right",
+		"home": "Home", "end": "End", "pageup": "PageUp", "pagedown": "PageDown",
+		"ppage": "PageUp", "npage": "PageDown",
+	}
+
+	// vimNames are how Vim spells named keys inside <>
+	vimNames = map[string]string{"Enter": "CR", "Backspace": "BS", "Delete": "Del", "Escape": "Esc"}
+)
+
+// Valid reports whether name is a supported notation; the empty string
+// leaves keys as they are written
+func Valid(name string) bool {
+	return name == "" || name == Vim || name == Ctrl || name == Caret
+}
+
+// Format writes a key in the given notation
+func (k Key) Format(notation string) string {
+	switch notation {
+	case Vim:
+		var sb strings.Builder
+		sb.WriteString("<")
+		if k.Ctrl {
+			sb.WriteString("C-")
+		}
+		if k.Alt {
+			sb.WriteString("M-")
+		}
+		if k.Shift {
+			sb.WriteString("S-")
+		}
+		name := k.Name
+		if v, ok := vimNames[name]; ok {
+			name = v
+		}
+		sb.WriteString(name)
+		sb.WriteString(">")
+		return sb.String()
+	case Caret:
+		if k.Ctrl && !k.Alt && !k.Shift && len(k.Name) == 1 {
+			return "^" + strings.ToUpper(k.Name)
+		}
+	}
+
+	var parts []string
+	if k.Ctrl {
+		parts = append(parts, "Ctrl")
+	}
+	if k.Alt {
+		parts = append(parts, "Alt")
+	}
+	if k.Shift {
+		parts = append(parts, "Shift")
+	}
+	return strings.Join(append(parts, k.Name), "-")
+}
+
+// newKey builds a key from modifier prefixes and a key name
+func newKey(mods []string, name string) (Key, bool) {
+	var k Key
+	for _, m := range mods {
+		switch strings.ToLower(m) {
+		case "c", "ctrl", "control":
+			k.Ctrl = true
+		case "m", "a", "alt", "meta", "option":
+			k.Alt = true
+		case "s", "shift":
+			k.Shift = true
+		case "":
+		default:
+			return Key{}, false
+		}
+	}
+	if !k.Ctrl && !k.Alt && !k.Shift {
+		return Key{}, false
+	}
+
+	if canonical, ok := namedKeys[strings.ToLower(name)]; ok {
+		name = canonical
+	} else if len([]rune(name)) != 1 && !isFunctionKey(name) {
+		// A word after Ctrl- that isn't a key, e.g. "Ctrl-click"
+		return Key{}, false
+	}
+	// Ctrl-W and Ctrl-w are the same key; Shift is spelled out
+	if len(name) == 1 && unicode.IsLetter(rune(name[0])) {
+		name = strings.ToLower(name)
+	}
+	k.Name = name
+	return k, true
+}
+
+// isFunctionKey reports whether name is F1 to F24
+func isFunctionKey(name string) bool {
+	if len(name) < 2 || len(name) > 3 || (name[0] != 'F' && name[0] != 'f') {
+		return false
+	}
+	for _, r := range name[1:] {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseKey parses a single key in any supported notation
+func ParseKey(s string) (Key, bool) {
+	s = strings.TrimSpace(s)
+	for _, re := range []*regexp.Regexp{vimKeyRe, wordKeyRe, tmuxKeyRe} {
+		if m := re.FindStringSubmatch(s); m != nil && m[0] == s {
+			return newKey(splitMods(m[1]), m[2])
+		}
+	}
+	if m := caretKeyRe.FindStringSubmatch(s); m != nil && m[0] == s {
+		return Key{Ctrl: true, Name: strings.ToLower(m[1])}, true
+	}
+	return Key{}, false
+}
+
+// splitMods splits "C-S-" or "Ctrl+Shift+" into its modifiers
+func splitMods(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool { return r == '-' || r == '+' })
+}
+
+// Normalize rewrites every keybinding in text to the given notation. An
+// empty notation returns text unchanged.
+func Normalize(text, notation string) string {
+	return normalize(text, notation, true)
+}
+
+// NormalizeCommand is like Normalize for text that may be a shell command,
+// where ^X is more likely part of a regular expression than a key
+func NormalizeCommand(text, notation string) string {
+	return normalize(text, notation, false)
+}
+
+func normalize(text, notation string, carets bool) string {
+	if notation == "" || text == "" {
+		return text
+	}
+
+	// Vim's <...> first, so the C- inside it isn't taken for tmux notation
+	text = replaceKeys(text, vimKeyRe, notation)
+	text = replaceKeys(text, wordKeyRe, notation)
+	text = replaceOutside(text, vimKeyRe, tmuxKeyRe, notation)
+
+	if carets && notation != Caret {
+		text = caretKeyRe.ReplaceAllStringFunc(text, func(match string) string {
+			m := caretKeyRe.FindStringSubmatch(match)
+			k := Key{Ctrl: true, Name: strings.ToLower(m[1])}
+			return k.Format(notation) + m[2]
+		})
+	}
+	return text
+}
+
+// replaceOutside rewrites re's keys in text, except inside matches of skip
+func replaceOutside(text string, skip, re *regexp.Regexp, notation string) string {
+	var sb strings.Builder
+	last := 0
+	for _, loc := range skip.FindAllStringIndex(text, -1) {
+		sb.WriteString(replaceKeys(text[last:loc[0]], re, notation))
+		sb.WriteString(text[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	sb.WriteString(replaceKeys(text[last:], re, notation))
+	return sb.String()
+}
+
+// replaceKeys rewrites re's keys in text. Outside Vim's <...>, a key typed
+// straight before another ("<C-w>v") gets a space so it stays readable.
+func replaceKeys(text string, re *regexp.Regexp, notation string) string {
+	var sb strings.Builder
+	last := 0
+	for _, loc := range re.FindAllStringSubmatchIndex(text, -1) {
+		k, ok := newKey(splitMods(text[loc[2]:loc[3]]), text[loc[4]:loc[5]])
+		if !ok {
+			continue
+		}
+		sb.WriteString(text[last:loc[0]])
+		sb.WriteString(k.Format(notation))
+		if notation != Vim && loc[1] < len(text) && isAlnum(text[loc[1]]) {
+			sb.WriteString(" ")
+		}
+		last = loc[1]
+	}
+	sb.WriteString(text[last:])
+	return sb.String()
+}
+
+// isAlnum reports whether b is an ASCII letter or digit
+func isAlnum(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9'
+}
@@ -0,0 +1,344 @@
+package knowledge
+
+import (
+	_ "embed"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.yaml.in/yaml/v3"
+)
+
+//go:embed data/kb.yaml
+var kbData []byte
+
+// MinScore is the lowest search score considered a confident answer
+const MinScore = 4.0
+
+// Entry is a curated question/answer pair in the knowledge base
+type Entry struct {
+	ID           string   `yaml:"id"`
+	Topic        string   `yaml:"topic"` // vim, tmux, shell
+	Questions    []string `yaml:"questions"`
+	Keywords     []string `yaml:"keywords"`
+	Command      string   `yaml:"command"`
+	DefaultN     int      `yaml:"default_n"`
+	Explanation  string   `yaml:"explanation"`
+	Alternatives []string `yaml:"alternatives"`
+	Related      []string `yaml:"related"`
+	Tip          string   `yaml:"tip"`
+	Source       string   `yaml:"source"` // :help tag or man page the answer is based on
+}
+
+// Base is a searchable collection of knowledge base entries
+type Base struct {
+	Entries []Entry
+}
+
+// Match is a search result with its relevance score
+type Match struct {
+	Entry *Entry
+	Score float64
+}
+
+var (
+	builtinOnce sync.Once
+	builtinBase *Base
+	builtinErr  error
+
+	numberRe = regexp.MustCompile(`\b\d+\b`)
+	wordRe   = regexp.MustCompile(`[a-z0-9]+`)
+)
+
+// stopwords are ignored when matching questions
+var stopwords = map[string]bool{
+	"a": true, "an": true, "the": true, "how": true, "do": true, "i": true,
+	"to": true, "in": true, "of": true, "on": true, "is": true, "what": true,
+	"can": true, "you": true, "my": true, "me": true, "for": true, "and": true,
+	"with": true, "from": true, "it": true, "at": true, "by": true, "way": true,
+	"vim": true, "neovim": true, "nvim": true, "please": true, "using": true,
+}
+
+// Load returns the built-in knowledge base embedded in the binary, extended
+// with the entries of installed cheatsheet packs. Packs that can't be read
+// are skipped.
+func Load() (*Base, error) {
+	builtinOnce.Do(func() {
+		builtinBase, builtinErr = Parse(kbData)
+		if builtinErr != nil {
+			return
+		}
+		packs, _ := InstalledPacks()
+		for _, pack := range packs {
+			builtinBase.Entries = append(builtinBase.Entries, pack.Entries...)
+		}
+	})
+	return builtinBase, builtinErr
+}
+
+// Parse parses knowledge base entries from YAML
+func Parse(data []byte) (*Base, error) {
+	var entries []Entry
+	if err := yaml.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("invalid knowledge base: %w", err)
+	}
+	return &Base{Entries: entries}, nil
+}
+
+// Search returns the entries best matching the query, highest score first
+func (b *Base) Search(query string, limit int) []Match {
+	queryTokens := tokenize(query)
+	if len(queryTokens) == 0 {
+		return nil
+	}
+
+	var matches []Match
+	for i := range b.Entries {
+		entry := &b.Entries[i]
+		if score := entry.score(query, queryTokens); score > 0 {
+			matches = append(matches, Match{Entry: entry, Score: score})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// Answer returns a formatted answer for the query if a confident match exists
+func (b *Base) Answer(query string) (string, bool) {
+	matches := b.Search(query, 1)
+	if len(matches) == 0 || matches[0].Score < MinScore {
+		return "", false
+	}
+	return matches[0].Entry.Format(query), true
+}
+
+// score rates how well the entry matches the query tokens
+func (e *Entry) score(query string, queryTokens []string) float64 {
+	var score float64
+
+	keywords := make(map[string]bool, len(e.Keywords))
+	for _, kw := range e.Keywords {
+		keywords[strings.ToLower(kw)] = true
+	}
+
+	for _, token := range queryTokens {
+		switch {
+		case keywords[token]:
+			score += 3
+		case fuzzyContains(keywords, token):
+			score += 2
+		}
+	}
+
+	// Reward questions whose wording is covered by the query
+	best := 0.0
+	for _, q := range e.Questions {
+		qTokens := tokenize(q)
+		if len(qTokens) == 0 {
+			continue
+		}
+		covered := 0
+		for _, qt := range qTokens {
+			for _, token := range queryTokens {
+				if qt == token || similar(qt, token) {
+					covered++
+					break
+				}
+			}
+		}
+		if ratio := float64(covered) / float64(len(qTokens)); ratio > best {
+			best = ratio
+		}
+	}
+	score += best * 4
+
+	// Keep tmux answers for tmux questions and vice versa
+	lower := strings.ToLower(query)
+	mentionsTmux := strings.Contains(lower, "tmux")
+	switch {
+	case mentionsTmux && e.Topic == "tmux":
+		score += 2
+	case mentionsTmux && e.Topic != "tmux":
+		score -= 3
+	case !mentionsTmux && e.Topic == "tmux":
+		score -= 1
+	}
+
+	return score
+}
+
+// Format renders the entry in the same labelled format the LLM is asked to
+// use, substituting the first number in the query for {n} and {count}
+func (e *Entry) Format(query string) string {
+	expand := e.expander(query)
+
+	var sb strings.Builder
+	sb.WriteString("Command: ")
+	sb.WriteString(expand(e.Command))
+	sb.WriteString("\nExplanation: ")
+	sb.WriteString(expand(e.Explanation))
+
+	if len(e.Alternatives) > 0 {
+		sb.WriteString("\nAlternatives:\n")
+		for _, alt := range e.Alternatives {
+			sb.WriteString("- ")
+			sb.WriteString(expand(alt))
+			sb.WriteString("\n")
+		}
+	}
+
+	if len(e.Related) > 0 {
+		sb.WriteString("\nRelated:\n")
+		for _, rel := range e.Related {
+			sb.WriteString("- ")
+			sb.WriteString(expand(rel))
+			sb.WriteString("\n")
+		}
+	}
+
+	if e.Tip != "" {
+		sb.WriteString("\nTip: ")
+		sb.WriteString(expand(e.Tip))
+	}
+
+	return strings.TrimSpace(sb.String())
+}
+
+// Excerpt is the entry's command and explanation in one line, for quoting
+// as a reference
+func (e *Entry) Excerpt(query string) string {
+	expand := e.expander(query)
+	return fmt.Sprintf("%s: %s", expand(e.Command), expand(e.Explanation))
+}
+
+// expander returns a function substituting the first number in the query,
+// or the entry's default, for {n}, and for {count} unless it's 1, as a
+// count prefix of 1 is left out
+func (e *Entry) expander(query string) func(string) string {
+	n := e.DefaultN
+	if m := numberRe.FindString(query); m != "" {
+		if v, err := strconv.Atoi(m); err == nil {
+			n = v
+		}
+	}
+	count := strconv.Itoa(n)
+	if n == 1 {
+		count = ""
+	}
+	return func(s string) string {
+		s = strings.ReplaceAll(s, "{count}", count)
+		return strings.ReplaceAll(s, "{n}", strconv.Itoa(n))
+	}
+}
+
+// tokenize lowercases text and splits it into words, dropping stopwords
+func tokenize(text string) []string {
+	var tokens []string
+	for _, word := range wordRe.FindAllString(strings.ToLower(text), -1) {
+		if stopwords[word] {
+			continue
+		}
+		tokens = append(tokens, word)
+	}
+	return tokens
+}
+
+// fuzzyContains reports whether any keyword is similar to token
+func fuzzyContains(keywords map[string]bool, token string) bool {
+	for kw := range keywords {
+		if similar(kw, token) {
+			return true
+		}
+	}
+	return false
+}
+
+// similar reports whether two words are close enough to count as the same,
+// tolerating plurals, verb forms, and single-letter typos
+func similar(a, b string) bool {
+	if a == b {
+		return true
+	}
+	if len(a) < 4 || len(b) < 4 {
+		return false
+	}
+	// Shared stem, e.g. "delete"/"deleting", "split"/"splits"
+	if commonPrefix(a, b) >= 5 || (commonPrefix(a, b) >= 4 && abs(len(a)-len(b)) <= 2) {
+		return true
+	}
+	return levenshtein(a, b) <= 1
+}
+
+// commonPrefix returns the length of the common prefix of a and b
+func commonPrefix(a, b string) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return n
+}
+
+// levenshtein returns the edit distance between a and b
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// Similarity scores how closely two questions match, from 0 (unrelated)
+// to 1 (same significant words)
+func Similarity(a, b string) float64 {
+	aTokens, bTokens := tokenize(a), tokenize(b)
+	if len(aTokens) == 0 || len(bTokens) == 0 {
+		return 0
+	}
+
+	matched := 0
+	for _, at := range aTokens {
+		for _, bt := range bTokens {
+			if similar(at, bt) {
+				matched++
+				break
+			}
+		}
+	}
+
+	longest := len(aTokens)
+	if len(bTokens) > longest {
+		longest = len(bTokens)
+	}
+	return float64(matched) / float64(longest)
+}
@@ -0,0 +1,453 @@
+package knowledge
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+
+	"github.com/cliq-cli/cliq/internal/config"
+	"github.com/cliq-cli/cliq/internal/signature"
+)
+
+const (
+	// DefaultPackIndex lists the community cheatsheet packs that can be
+	// installed by name
+	DefaultPackIndex = "https://raw.githubusercontent.com/cliq-cli/cheatsheets/main/index.json"
+
+	// packsDirName is the directory under the data dir packs are installed to
+	packsDirName = "cheatsheets"
+	// manifestFile describes a pack, at the root of its tarball
+	manifestFile = "pack.yaml"
+	// entriesFile holds an installed pack's validated entries
+	entriesFile = "entries.yaml"
+
+	// maxPackSize limits how much a pack may download or unpack to
+	maxPackSize = 16 << 20
+
+	// sigExt is appended to a pack's URL or path to find its minisign
+	// signature
+	sigExt = ".minisig"
+)
+
+// trustedPackKeys are the minisign public keys packs must be signed with,
+// pinned so a compromised index or mirror can't hand out its own
+var trustedPackKeys = []string{
+	// cliq-cli cheatsheets
+	"RWQnMfhlu89ijxCdzTHwGwlVcH6cklGEhwSHj0VGErNWR839w+fhaglr",
+}
+
+// ErrUnsigned is returned by VerifyPack for a pack without a signature
+var ErrUnsigned = errors.New("pack is not signed")
+
+var (
+	packNameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,63}$`)
+
+	// topics are the values an entry's topic may take
+	topics = map[string]bool{"vim": true, "tmux": true, "shell": true}
+)
+
+// PackManifest describes a cheatsheet pack
+type PackManifest struct {
+	Name        string `yaml:"name"`
+	Version     string `yaml:"version"`
+	Description string `yaml:"description"`
+	Author      string `yaml:"author"`
+	Homepage    string `yaml:"homepage"`
+}
+
+// Pack is an installed cheatsheet pack
+type Pack struct {
+	PackManifest
+	Dir     string
+	Entries []Entry
+}
+
+// PacksDir returns the directory cheatsheet packs are installed to
+func PacksDir() (string, error) {
+	dataDir, err := config.GetDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, packsDirName), nil
+}
+
+// FetchPack reads a pack tarball and its minisign signature from a URL or
+// local file. A bare name is looked up in the pack index. sig is nil when
+// the pack has no signature beside it.
+func FetchPack(ctx context.Context, source string) (data, sig []byte, err error) {
+	if packNameRe.MatchString(source) {
+		if _, err := os.Stat(source); err != nil {
+			url, sigURL, err := resolvePack(ctx, source)
+			if err != nil {
+				return nil, nil, err
+			}
+			return fetchSigned(ctx, url, sigURL)
+		}
+	}
+	if strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "http://") {
+		return fetchSigned(ctx, source, source+sigExt)
+	}
+
+	if data, err = readLimited(os.Open(source)); err != nil {
+		return nil, nil, err
+	}
+	sig, err = os.ReadFile(source + sigExt)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, nil, err
+	}
+	return data, sig, nil
+}
+
+// fetchSigned downloads a pack and, if there is one, its signature
+func fetchSigned(ctx context.Context, url, sigURL string) (data, sig []byte, err error) {
+	if data, err = fetchURL(ctx, url); err != nil {
+		return nil, nil, err
+	}
+	sig, err = fetchURL(ctx, sigURL)
+	if err != nil && !errors.Is(err, errNotFound) {
+		return nil, nil, fmt.Errorf("failed to fetch signature: %w", err)
+	}
+	return data, sig, nil
+}
+
+// resolvePack finds a pack's tarball and signature URLs in the pack index
+func resolvePack(ctx context.Context, name string) (url, sigURL string, err error) {
+	data, err := fetchURL(ctx, DefaultPackIndex)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to fetch the pack index: %w", err)
+	}
+
+	var index struct {
+		Packs []struct {
+			Name string `json:"name"`
+			URL  string `json:"url"`
+			Sig  string `json:"sig"`
+		} `json:"packs"`
+	}
+	if err := json.Unmarshal(data, &index); err != nil {
+		return "", "", fmt.Errorf("invalid pack index: %w", err)
+	}
+	for _, p := range index.Packs {
+		if p.Name == name {
+			if p.Sig == "" {
+				p.Sig = p.URL + sigExt
+			}
+			return p.URL, p.Sig, nil
+		}
+	}
+	return "", "", fmt.Errorf("no pack named %q in the index", name)
+}
+
+// VerifyPack checks a pack's minisign signature against the pinned keys and
+// returns the signature's trusted comment
+func VerifyPack(data, sig []byte) (string, error) {
+	if len(sig) == 0 {
+		return "", ErrUnsigned
+	}
+	keys := make([]signature.PublicKey, 0, len(trustedPackKeys))
+	for _, s := range trustedPackKeys {
+		key, err := signature.ParsePublicKey(s)
+		if err != nil {
+			return "", err
+		}
+		keys = append(keys, key)
+	}
+	return signature.Verify(keys, data, sig)
+}
+
+// errNotFound is returned by fetchURL for a 404
+var errNotFound = errors.New("not found")
+
+// fetchURL downloads a URL, refusing anything over maxPackSize
+func fetchURL(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, errNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("download failed with status: %s", resp.Status)
+	}
+	return readLimited(resp.Body, nil)
+}
+
+// readLimited reads all of r, up to maxPackSize
+func readLimited(r io.ReadCloser, err error) ([]byte, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(io.LimitReader(r, maxPackSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxPackSize {
+		return nil, fmt.Errorf("pack is larger than %d MB", maxPackSize>>20)
+	}
+	return data, nil
+}
+
+// ParsePack unpacks and validates a pack tarball (.tar.gz) holding a
+// pack.yaml manifest and YAML files of entries in the knowledge base format
+func ParsePack(data []byte) (*Pack, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("not a gzipped tarball: %w", err)
+	}
+	defer gz.Close()
+
+	var (
+		manifest *PackManifest
+		entries  []Entry
+		total    int64
+	)
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid tarball: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		// Packs are often tarred from a directory, so accept one level of
+		// nesting; nothing is written to disk under these names
+		name := path.Clean(strings.TrimPrefix(hdr.Name, "./"))
+		if i := strings.IndexByte(name, '/'); i >= 0 && !strings.Contains(name[i+1:], "/") {
+			name = name[i+1:]
+		}
+		ext := path.Ext(name)
+		if strings.Contains(name, "/") || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+
+		total += hdr.Size
+		if total > maxPackSize {
+			return nil, fmt.Errorf("pack unpacks to more than %d MB", maxPackSize>>20)
+		}
+		body, err := io.ReadAll(io.LimitReader(tr, hdr.Size))
+		if err != nil {
+			return nil, fmt.Errorf("invalid tarball: %w", err)
+		}
+
+		if name == manifestFile {
+			manifest = &PackManifest{}
+			if err := decodeStrict(body, manifest); err != nil {
+				return nil, fmt.Errorf("%s: %w", manifestFile, err)
+			}
+			continue
+		}
+		var found []Entry
+		if err := decodeStrict(body, &found); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		entries = append(entries, found...)
+	}
+
+	if manifest == nil {
+		return nil, fmt.Errorf("pack has no %s", manifestFile)
+	}
+	pack := &Pack{PackManifest: *manifest, Entries: entries}
+	if err := pack.Validate(); err != nil {
+		return nil, err
+	}
+	return pack, nil
+}
+
+// decodeStrict decodes YAML, rejecting fields the schema doesn't have
+func decodeStrict(data []byte, v any) error {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(v); err != nil && err != io.EOF {
+		return err
+	}
+	return nil
+}
+
+// Validate checks a pack against the schema: a well-formed name and
+// version, and entries with unique IDs, a known topic, at least one
+// question, a command, and an explanation
+func (p *Pack) Validate() error {
+	var problems []string
+	if !packNameRe.MatchString(p.Name) {
+		problems = append(problems, fmt.Sprintf("name %q must be lowercase letters, digits, and dashes", p.Name))
+	}
+	if p.Version == "" {
+		problems = append(problems, "version is required")
+	}
+	if len(p.Entries) == 0 {
+		problems = append(problems, "pack has no entries")
+	}
+
+	seen := map[string]bool{}
+	for i, e := range p.Entries {
+		where := fmt.Sprintf("entry %d", i+1)
+		if e.ID != "" {
+			where = fmt.Sprintf("entry %q", e.ID)
+		}
+		switch {
+		case e.ID == "":
+			problems = append(problems, where+": id is required")
+		case seen[e.ID]:
+			problems = append(problems, where+": duplicate id")
+		}
+		seen[e.ID] = true
+		if !topics[e.Topic] {
+			problems = append(problems, fmt.Sprintf("%s: topic %q must be vim, tmux, or shell", where, e.Topic))
+		}
+		if len(e.Questions) == 0 {
+			problems = append(problems, where+": at least one question is required")
+		}
+		if strings.TrimSpace(e.Command) == "" {
+			problems = append(problems, where+": command is required")
+		}
+		if strings.TrimSpace(e.Explanation) == "" {
+			problems = append(problems, where+": explanation is required")
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid pack:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// InstallPack writes a validated pack to the packs directory, replacing any
+// installed version of it
+func InstallPack(pack *Pack) error {
+	dir, err := PacksDir()
+	if err != nil {
+		return err
+	}
+
+	manifest, err := yaml.Marshal(pack.PackManifest)
+	if err != nil {
+		return err
+	}
+	entries, err := yaml.Marshal(pack.Entries)
+	if err != nil {
+		return err
+	}
+
+	// Stage the pack beside its final place so replacing it is one rename
+	dest := filepath.Join(dir, pack.Name)
+	staging := dest + ".tmp"
+	os.RemoveAll(staging)
+	if err := config.WriteFile(filepath.Join(staging, manifestFile), manifest); err != nil {
+		return err
+	}
+	if err := config.WriteFile(filepath.Join(staging, entriesFile), entries); err != nil {
+		os.RemoveAll(staging)
+		return err
+	}
+	if err := os.RemoveAll(dest); err != nil {
+		os.RemoveAll(staging)
+		return err
+	}
+	if err := os.Rename(staging, dest); err != nil {
+		os.RemoveAll(staging)
+		return err
+	}
+	pack.Dir = dest
+	return nil
+}
+
+// RemovePack uninstalls a pack
+func RemovePack(name string) error {
+	if !packNameRe.MatchString(name) {
+		return fmt.Errorf("invalid pack name: %s", name)
+	}
+	dir, err := PacksDir()
+	if err != nil {
+		return err
+	}
+	dest := filepath.Join(dir, name)
+	if _, err := os.Stat(filepath.Join(dest, manifestFile)); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("no pack named %q is installed", name)
+		}
+		return err
+	}
+	return os.RemoveAll(dest)
+}
+
+// InstalledPacks returns the installed packs, by name. Packs that can't be
+// read are left out and reported in the error.
+func InstalledPacks() ([]*Pack, error) {
+	dir, err := PacksDir()
+	if err != nil {
+		return nil, err
+	}
+	dirs, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var (
+		packs []*Pack
+		errs  []error
+	)
+	for _, d := range dirs {
+		if !d.IsDir() || !packNameRe.MatchString(d.Name()) {
+			continue
+		}
+		pack, err := loadPack(filepath.Join(dir, d.Name()))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("pack %s: %w", d.Name(), err))
+			continue
+		}
+		packs = append(packs, pack)
+	}
+	sort.Slice(packs, func(i, j int) bool { return packs[i].Name < packs[j].Name })
+	return packs, errors.Join(errs...)
+}
+
+// loadPack reads an installed pack
+func loadPack(dir string) (*Pack, error) {
+	manifest, err := os.ReadFile(filepath.Join(dir, manifestFile))
+	if err != nil {
+		return nil, err
+	}
+	pack := &Pack{Dir: dir}
+	if err := yaml.Unmarshal(manifest, &pack.PackManifest); err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadFile(filepath.Join(dir, entriesFile))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(entries, &pack.Entries); err != nil {
+		return nil, err
+	}
+	return pack, nil
+}
@@ -0,0 +1,362 @@
+package knowledge
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PluginCapability describes a task a Neovim plugin handles, and the
+// plugin-specific way of doing it
+type PluginCapability struct {
+	Plugin      string   // repo name as detected by the parser, e.g. "telescope.nvim"
+	Keywords    []string // phrases in the question or answer that trigger the suggestion
+	Replaces    []string // built-in commands the plugin supersedes
+	Suggestion  string   // the plugin's way of doing it
+	Description string
+}
+
+// pluginCapabilities maps popular plugins to the built-in workflows they replace
+var pluginCapabilities = []PluginCapability{
+	// Fuzzy finders
+	{
+		Plugin:      "telescope.nvim",
+		Keywords:    []string{"grep", "search text", "search in files", "search project", "search all files"},
+		Replaces:    []string{":vimgrep", ":grep"},
+		Suggestion:  ":Telescope live_grep",
+		Description: "fuzzy search text across the project",
+	},
+	{
+		Plugin:      "telescope.nvim",
+		Keywords:    []string{"find file", "open file", "find files", "search file"},
+		Replaces:    []string{":find", ":e "},
+		Suggestion:  ":Telescope find_files",
+		Description: "fuzzy find files by name",
+	},
+	{
+		Plugin:      "telescope.nvim",
+		Keywords:    []string{"switch buffer", "list buffers", "open buffers"},
+		Replaces:    []string{":ls", ":b "},
+		Suggestion:  ":Telescope buffers",
+		Description: "fuzzy pick an open buffer",
+	},
+	{
+		Plugin:      "telescope.nvim",
+		Keywords:    []string{"search help", "help tags", "vim documentation"},
+		Replaces:    []string{":help", ":h "},
+		Suggestion:  ":Telescope help_tags",
+		Description: "fuzzy search help tags",
+	},
+	{
+		Plugin:      "fzf-lua",
+		Keywords:    []string{"grep", "search text", "search in files", "search project"},
+		Replaces:    []string{":vimgrep", ":grep"},
+		Suggestion:  ":FzfLua live_grep",
+		Description: "fuzzy search text across the project",
+	},
+	{
+		Plugin:      "fzf-lua",
+		Keywords:    []string{"find file", "open file", "find files"},
+		Replaces:    []string{":find", ":e "},
+		Suggestion:  ":FzfLua files",
+		Description: "fuzzy find files by name",
+	},
+	{
+		Plugin:      "fzf-lua",
+		Keywords:    []string{"switch buffer", "list buffers", "open buffers"},
+		Replaces:    []string{":ls", ":b "},
+		Suggestion:  ":FzfLua buffers",
+		Description: "fuzzy pick an open buffer",
+	},
+
+	// File navigation
+	{
+		Plugin:      "harpoon",
+		Keywords:    []string{"mark file", "bookmark", "jump between files", "switch file", "alternate file", "favorite file"},
+		Replaces:    []string{"Ctrl-^", ":b#", "m{A-Z}"},
+		Suggestion:  `require("harpoon"):list():add() / harpoon.ui:toggle_quick_menu()`,
+		Description: "mark files and jump between them by slot",
+	},
+	{
+		Plugin:      "oil.nvim",
+		Keywords:    []string{"file explorer", "explore", "directory", "rename file", "netrw", "file browser"},
+		Replaces:    []string{":Explore", ":Ex", ":Lexplore"},
+		Suggestion:  ":Oil",
+		Description: "edit the directory as a buffer (rename/move/delete by editing lines)",
+	},
+	{
+		Plugin:      "nvim-tree.lua",
+		Keywords:    []string{"file tree", "file explorer", "sidebar", "netrw"},
+		Replaces:    []string{":Explore", ":Ex", ":Lexplore"},
+		Suggestion:  ":NvimTreeToggle",
+		Description: "toggle the file tree sidebar",
+	},
+	{
+		Plugin:      "neo-tree.nvim",
+		Keywords:    []string{"file tree", "file explorer", "sidebar", "netrw"},
+		Replaces:    []string{":Explore", ":Ex", ":Lexplore"},
+		Suggestion:  ":Neotree toggle",
+		Description: "toggle the file tree sidebar",
+	},
+
+	// Motions
+	{
+		Plugin:      "flash.nvim",
+		Keywords:    []string{"jump to word", "jump to character", "jump anywhere", "move to word", "go to character"},
+		Replaces:    []string{"f{char}", "t{char}", "/"},
+		Suggestion:  "s{chars} then the label",
+		Description: "jump anywhere on screen with labeled search",
+	},
+	{
+		Plugin:      "leap.nvim",
+		Keywords:    []string{"jump to word", "jump to character", "jump anywhere", "move to word", "go to character"},
+		Replaces:    []string{"f{char}", "t{char}", "/"},
+		Suggestion:  "s{char}{char} then the label",
+		Description: "jump anywhere on screen with two-character search",
+	},
+	{
+		Plugin:      "hop.nvim",
+		Keywords:    []string{"jump to word", "jump anywhere", "move to word"},
+		Replaces:    []string{"f{char}", "w", "/"},
+		Suggestion:  ":HopWord",
+		Description: "jump to any word on screen by label",
+	},
+
+	// Editing
+	{
+		Plugin:      "nvim-surround",
+		Keywords:    []string{"surround", "wrap in quotes", "change quotes", "delete quotes", "parentheses", "brackets around"},
+		Suggestion:  `ys{motion}{char} / cs{old}{new} / ds{char}`,
+		Description: "add, change, or delete surrounding pairs",
+	},
+	{
+		Plugin:      "vim-surround",
+		Keywords:    []string{"surround", "wrap in quotes", "change quotes", "delete quotes"},
+		Suggestion:  `ys{motion}{char} / cs{old}{new} / ds{char}`,
+		Description: "add, change, or delete surrounding pairs",
+	},
+	{
+		Plugin:      "Comment.nvim",
+		Keywords:    []string{"comment"},
+		Suggestion:  "gcc (line) / gc{motion}",
+		Description: "toggle comments",
+	},
+	{
+		Plugin:      "grug-far.nvim",
+		Keywords:    []string{"replace across", "replace in all files", "project-wide replace", "search and replace in project"},
+		Replaces:    []string{":cdo", ":argdo"},
+		Suggestion:  ":GrugFar",
+		Description: "interactive search and replace across the project",
+	},
+	{
+		Plugin:      "nvim-spectre",
+		Keywords:    []string{"replace across", "replace in all files", "project-wide replace", "search and replace in project"},
+		Replaces:    []string{":cdo", ":argdo"},
+		Suggestion:  `:lua require("spectre").toggle()`,
+		Description: "interactive search and replace across the project",
+	},
+	{
+		Plugin:      "conform.nvim",
+		Keywords:    []string{"format file", "format code", "formatter", "autoformat"},
+		Replaces:    []string{"gg=G", "gq"},
+		Suggestion:  `:lua require("conform").format()`,
+		Description: "format with the configured external formatter",
+	},
+	{
+		Plugin:      "undotree",
+		Keywords:    []string{"undo history", "undo tree", "undo branch"},
+		Replaces:    []string{":undolist", "g-"},
+		Suggestion:  ":UndotreeToggle",
+		Description: "browse the undo tree visually",
+	},
+
+	// Git
+	{
+		Plugin:      "vim-fugitive",
+		Keywords:    []string{"git status", "git commit", "git blame", "git diff"},
+		Suggestion:  ":Git / :Git blame / :Gdiffsplit",
+		Description: "run git from inside Neovim",
+	},
+	{
+		Plugin:      "gitsigns.nvim",
+		Keywords:    []string{"hunk", "git blame", "changed lines", "git diff"},
+		Suggestion:  ":Gitsigns preview_hunk / :Gitsigns blame_line",
+		Description: "inspect and stage hunks inline",
+	},
+	{
+		Plugin:      "lazygit.nvim",
+		Keywords:    []string{"git status", "git commit", "stage changes"},
+		Suggestion:  ":LazyGit",
+		Description: "open lazygit in a floating window",
+	},
+
+	// Diagnostics, terminals, debugging
+	{
+		Plugin:      "trouble.nvim",
+		Keywords:    []string{"diagnostic", "quickfix", "errors", "warnings"},
+		Replaces:    []string{":copen", ":lopen"},
+		Suggestion:  ":Trouble diagnostics toggle",
+		Description: "list diagnostics in a navigable panel",
+	},
+	{
+		Plugin:      "toggleterm.nvim",
+		Keywords:    []string{"terminal", "shell inside"},
+		Replaces:    []string{":terminal", ":term"},
+		Suggestion:  ":ToggleTerm",
+		Description: "toggle a persistent floating terminal",
+	},
+	{
+		Plugin:      "nvim-dap",
+		Keywords:    []string{"debug", "breakpoint", "step over", "step into"},
+		Suggestion:  ":DapToggleBreakpoint / :DapContinue",
+		Description: "set breakpoints and drive the debugger",
+	},
+	{
+		Plugin:      "which-key.nvim",
+		Keywords:    []string{"what keymaps", "list keymaps", "keybindings", "forgot key", "leader key"},
+		Replaces:    []string{":map", ":nmap"},
+		Suggestion:  ":WhichKey",
+		Description: "browse available keymaps in a popup",
+	},
+	{
+		Plugin:      "vim-tmux-navigator",
+		Keywords:    []string{"between vim and tmux", "navigate panes", "move between splits", "switch pane"},
+		Replaces:    []string{"Ctrl-w h", "Ctrl-w l"},
+		Suggestion:  "Ctrl-h / Ctrl-j / Ctrl-k / Ctrl-l",
+		Description: "move across Neovim splits and tmux panes with the same keys",
+	},
+}
+
+// neovimWords mark a question as being about Neovim, so its plugins apply
+var neovimWords = []string{
+	"vim", "nvim", "neovim", "vi", "lua", "buffer", "buffers", "split", "splits",
+	"leader", "quickfix", "netrw", "telescope", "lazyvim", "vimrc", "init.lua",
+}
+
+// neovimQuestion reports whether a question, or the command suggested for
+// it, is about Neovim rather than the shell or tmux
+func neovimQuestion(query, command string) bool {
+	command = strings.TrimSpace(command)
+	if strings.HasPrefix(command, ":") || strings.HasPrefix(strings.ToLower(command), "<leader>") {
+		return true
+	}
+	query = strings.ToLower(query)
+	for _, w := range neovimWords {
+		if containsWord(query, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsWord reports whether phrase appears in text as whole words, so
+// "grep" doesn't match "ripgrep" nor "comment" "comments"
+func containsWord(text, phrase string) bool {
+	isWord := func(r byte) bool {
+		return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_'
+	}
+	for start := 0; ; {
+		i := strings.Index(text[start:], phrase)
+		if i < 0 {
+			return false
+		}
+		i += start
+		end := i + len(phrase)
+		if (i == 0 || !isWord(text[i-1])) && (end == len(text) || !isWord(text[end])) {
+			return true
+		}
+		start = i + 1
+	}
+}
+
+// SuggestForPlugins returns plugin-specific alternatives for a Neovim
+// question and the command suggested for it, limited to plugins the user
+// has installed
+func SuggestForPlugins(plugins []string, query, command string, limit int) []string {
+	if !neovimQuestion(query, command) {
+		return nil
+	}
+	installed := make(map[string]bool, len(plugins))
+	for _, p := range plugins {
+		installed[normalizePluginName(p)] = true
+	}
+
+	text := strings.ToLower(query + "\n" + command)
+	command = strings.ToLower(strings.TrimSpace(command))
+	var suggestions []string
+	seen := make(map[string]bool)
+
+	for _, capability := range pluginCapabilities {
+		if len(suggestions) >= limit {
+			break
+		}
+		if !installed[normalizePluginName(capability.Plugin)] || seen[capability.Suggestion] {
+			continue
+		}
+		if !capability.matches(text, command) {
+			continue
+		}
+
+		seen[capability.Suggestion] = true
+		suggestion := fmt.Sprintf("%s — %s (%s)", capability.Suggestion, capability.Description, capability.Plugin)
+		if len(capability.Replaces) > 0 {
+			suggestion += fmt.Sprintf(", instead of %s", capability.Replaces[0])
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+
+	return suggestions
+}
+
+// PluginCommand returns the Ex command, without its colon, an installed
+// plugin offers for a task, and the plugin, or "" when none of them has a
+// single command for it
+func PluginCommand(plugins []string, task string) (command, plugin string) {
+	installed := make(map[string]bool, len(plugins))
+	for _, p := range plugins {
+		installed[normalizePluginName(p)] = true
+	}
+
+	text := strings.ToLower(task)
+	for _, capability := range pluginCapabilities {
+		if !installed[normalizePluginName(capability.Plugin)] || !capability.matches(text, "") {
+			continue
+		}
+		if strings.HasPrefix(capability.Suggestion, ":") && !strings.Contains(capability.Suggestion, " / ") {
+			return strings.TrimPrefix(capability.Suggestion, ":"), capability.Plugin
+		}
+	}
+	return "", ""
+}
+
+// matches reports whether the capability is relevant to the lowercased
+// question/answer text, by whole words, or the suggested command
+func (pc PluginCapability) matches(text, command string) bool {
+	for _, kw := range pc.Keywords {
+		if containsWord(text, strings.ToLower(kw)) {
+			return true
+		}
+	}
+	for _, replaced := range pc.Replaces {
+		replaced = strings.ToLower(replaced)
+		// Ex commands can appear anywhere in the command; short normal-mode
+		// keys like "/" or "w" only count as an exact match
+		if strings.HasPrefix(replaced, ":") {
+			if strings.Contains(command, replaced) {
+				return true
+			}
+		} else if command == replaced {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizePluginName makes plugin names comparable regardless of case and
+// the common .nvim/.lua/.vim suffixes
+func normalizePluginName(name string) string {
+	name = strings.ToLower(name)
+	for _, suffix := range []string{".nvim", ".lua", ".vim", "-nvim"} {
+		name = strings.TrimSuffix(name, suffix)
+	}
+	return name
+}
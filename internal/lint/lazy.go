@@ -0,0 +1,101 @@
+package lint
+
+import (
+	"fmt"
+	"slices"
+	"unicode"
+
+	"github.com/cliq-cli/cliq/internal/parser"
+)
+
+// lazyFields are the fields of a lazy.nvim plugin spec
+var lazyFields = map[string]bool{
+	"dir": true, "url": true, "name": true, "dev": true, "lazy": true,
+	"enabled": true, "cond": true, "dependencies": true, "init": true,
+	"opts": true, "opts_extend": true, "config": true, "main": true,
+	"build": true, "branch": true, "tag": true, "commit": true,
+	"version": true, "pin": true, "submodules": true, "event": true,
+	"cmd": true, "ft": true, "keys": true, "module": true, "priority": true,
+	"optional": true, "specs": true, "import": true, "virtual": true,
+}
+
+// packerFields maps fields from packer.nvim and vim-plug specs, often
+// carried over by mistake, to their lazy.nvim equivalents
+var packerFields = map[string]string{
+	"requires": "dependencies",
+	"wants":    "dependencies",
+	"after":    "dependencies",
+	"run":      "build",
+	"as":       "name",
+	"disable":  "enabled = false",
+	"opt":      "lazy",
+	"setup":    "init",
+	"rtp":      "dir, or a spec per subdirectory",
+	"do":       "build",
+	"on":       "cmd or keys",
+	"for":      "ft",
+}
+
+// lazyKinds are the kinds of value fields must have
+var lazyKinds = map[string][]string{
+	"lazy":       {"boolean", "function"},
+	"enabled":    {"boolean", "function"},
+	"dev":        {"boolean"},
+	"pin":        {"boolean"},
+	"optional":   {"boolean"},
+	"submodules": {"boolean"},
+	"priority":   {"number"},
+	"event":      {"string", "table", "function"},
+	"cmd":        {"string", "table", "function"},
+	"ft":         {"string", "table", "function"},
+	"keys":       {"string", "table", "function"},
+	"opts":       {"table", "function"},
+	"config":     {"function", "boolean", "string"},
+	"build":      {"string", "function", "table", "boolean"},
+}
+
+// lazySpecs reports spec fields lazy.nvim doesn't know, and values of the
+// wrong kind, such as lazy = "true"
+func lazySpecs(nvim *parser.NvimConfig) []Problem {
+	var problems []Problem
+	for _, spec := range nvim.LazySpecs {
+		problem := func(level Level, format string, args ...any) {
+			problems = append(problems, Problem{
+				Level:   level,
+				Tool:    "nvim",
+				File:    spec.Source,
+				Message: spec.Repo + ": " + fmt.Sprintf(format, args...),
+			})
+		}
+
+		for _, f := range spec.Fields {
+			if !lazyFields[f.Name] {
+				if instead, ok := packerFields[f.Name]; ok {
+					problem(Error, "%s is a packer.nvim field; lazy.nvim uses %s", f.Name, instead)
+				} else {
+					problem(Warning, "lazy.nvim has no %s field, so it's ignored", f.Name)
+				}
+				continue
+			}
+
+			kinds, ok := lazyKinds[f.Name]
+			if f.Kind == "name" && slices.Contains(kinds, "string") && unicode.IsUpper(rune(f.Value[0])) {
+				// event = VeryLazy reads an unset global rather than naming the event
+				problem(Error, "%s = %s reads a variable, which is nil; quote it: %s = %q", f.Name, f.Value, f.Name, f.Value)
+				continue
+			}
+			if !ok || f.Kind == "expression" || f.Kind == "name" || f.Kind == "nil" || slices.Contains(kinds, f.Kind) {
+				continue
+			}
+			switch {
+			case f.Name == "config" && f.Kind == "table":
+				problem(Warning, "config is a table; pass options as opts, and lazy.nvim calls setup with them")
+			case f.Kind == "string" && (f.Value == "true" || f.Value == "false"):
+				problem(Error, "%s = %q is a string, which is always true; write %s = %s", f.Name, f.Value, f.Name, f.Value)
+			default:
+				problem(Error, "%s should be a %s, not a %s", f.Name, kinds[0], f.Kind)
+			}
+		}
+	}
+	return problems
+}
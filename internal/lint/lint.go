@@ -0,0 +1,140 @@
+// Package lint flags common mistakes in Neovim and tmux configs that the
+// parsers can see: tmux options that newer versions removed, conflicting
+// mouse settings, keymaps defined twice or without a description, and
+// lazy.nvim specs with fields lazy.nvim doesn't know.
+package lint
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/cliq-cli/cliq/internal/keyaudit"
+	"github.com/cliq-cli/cliq/internal/parser"
+)
+
+// Level ranks a problem
+type Level int
+
+const (
+	// Info problems are suggestions
+	Info Level = iota
+	// Warning problems are likely mistakes
+	Warning
+	// Error problems break the config, or part of it
+	Error
+)
+
+// String names the level for reports
+func (l Level) String() string {
+	switch l {
+	case Error:
+		return "error"
+	case Warning:
+		return "warning"
+	}
+	return "info"
+}
+
+// MarshalText writes the level's name, for JSON reports
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// Problem is one thing lint found
+type Problem struct {
+	Level   Level  `json:"level"`
+	Tool    string `json:"tool"` // nvim or tmux
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Message string `json:"message"`
+}
+
+// Location is where the problem is, as file:line or file
+func (p Problem) Location() string {
+	if p.Line > 0 {
+		return fmt.Sprintf("%s:%d", p.File, p.Line)
+	}
+	return p.File
+}
+
+// Lint checks either config, which may be nil. tmuxVersion is the version
+// tmux -V reports, or "" if unknown, which reports removed options as
+// warnings rather than errors. Problems are returned most severe first.
+func Lint(nvim *parser.NvimConfig, tmux *parser.TmuxConfig, tmuxVersion string) []Problem {
+	var problems []Problem
+	if nvim != nil {
+		problems = append(problems, nvimDuplicates(nvim)...)
+		problems = append(problems, nvimMissingDesc(nvim)...)
+		problems = append(problems, lazySpecs(nvim)...)
+	}
+	if tmux != nil {
+		problems = append(problems, tmuxProblems(tmux, tmuxVersion)...)
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].Level > problems[j].Level
+	})
+	return problems
+}
+
+// nvimDuplicates reports keys mapped more than once, as the keymap audit
+// finds them
+func nvimDuplicates(nvim *parser.NvimConfig) []Problem {
+	var problems []Problem
+	for _, f := range keyaudit.NvimDuplicates(nvim) {
+		level := Info
+		if f.Severity >= keyaudit.Medium {
+			level = Warning
+		}
+		problems = append(problems, Problem{
+			Level:   level,
+			Tool:    "nvim",
+			Message: fmt.Sprintf("%s [%s] %s", f.Keys, f.Mode, f.Message),
+		})
+	}
+	return problems
+}
+
+// nvimMissingDesc reports, per file, the keymaps made without a desc,
+// which which-key and :map show as bare commands
+func nvimMissingDesc(nvim *parser.NvimConfig) []Problem {
+	missing := map[string][]string{}
+	var files []string
+	for _, km := range nvim.Keymaps {
+		if km.Description != "" || km.Distro != "" || km.Source == "" {
+			continue
+		}
+		if missing[km.Source] == nil {
+			files = append(files, km.Source)
+		}
+		missing[km.Source] = append(missing[km.Source], km.Lhs)
+	}
+
+	var problems []Problem
+	for _, file := range files {
+		keys := missing[file]
+		problems = append(problems, Problem{
+			Level:   Info,
+			Tool:    "nvim",
+			File:    file,
+			Message: fmt.Sprintf("%d keymap(s) without desc: %s", len(keys), listKeys(keys)),
+		})
+	}
+	return problems
+}
+
+// listKeys lists up to five keys, then how many more there are
+func listKeys(keys []string) string {
+	const shown = 5
+	s := ""
+	for i, k := range keys {
+		if i == shown {
+			return s + fmt.Sprintf(", and %d more", len(keys)-shown)
+		}
+		if i > 0 {
+			s += ", "
+		}
+		s += k
+	}
+	return s
+}
@@ -0,0 +1,148 @@
+package lint
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/cliq-cli/cliq/internal/parser"
+)
+
+// tmuxRemoval is tmux syntax that a release removed
+type tmuxRemoval struct {
+	re      *regexp.Regexp
+	removed string // the tmux version that removed it
+	message string
+}
+
+var tmuxRemovals = []tmuxRemoval{
+	{
+		regexp.MustCompile(`^set(?:-option|-window-option|w)?\s+(?:-\w+\s+)*(mode-mouse|mouse-select-pane|mouse-resize-pane|mouse-select-window|mouse-utf8)\b`),
+		"2.1", "%s was replaced by the single mouse option: set -g mouse on",
+	},
+	{
+		regexp.MustCompile(`^set(?:-option|-window-option|w)?\s+(?:-\w+\s+)*((?:status-)?utf8)\b`),
+		"2.2", "%s is gone; tmux always uses UTF-8 when the terminal does, so delete the line",
+	},
+	{
+		regexp.MustCompile(`^(?:bind|bind-key|unbind|unbind-key)\s+(?:-\w+\s+)*-t\s+((?:vi|emacs)-copy)\b`),
+		"2.4", "-t %s tables became -T copy-mode-vi and -T copy-mode, with commands run through send -X",
+	},
+	{
+		regexp.MustCompile(`^set(?:-option|-window-option|w)?\s+(?:-\w+\s+)*((?:window-status(?:-current|-activity|-bell|-last)?|pane(?:-active)?-border|message(?:-command)?|status-left|status-right|mode)-(?:fg|bg|attr))\b`),
+		"2.9", "%s was folded into the matching -style option, such as set -g status-left-style fg=blue",
+	},
+}
+
+var (
+	// tmuxMouseRe matches setting the mouse option, with its value if any
+	tmuxMouseRe = regexp.MustCompile(`^set(?:-option)?\s+(?:-\w+\s+)*mouse(?:\s+(\S+))?\s*$`)
+	// tmuxReattachRe matches the macOS clipboard workaround tmux 2.6 made
+	// unnecessary
+	tmuxReattachRe = regexp.MustCompile(`reattach-to-user-namespace`)
+)
+
+// tmuxProblems checks the lines of the tmux config and the files it
+// includes
+func tmuxProblems(tmux *parser.TmuxConfig, version string) []Problem {
+	var problems []Problem
+	type mouseSetting struct {
+		value string
+		file  string
+		line  int
+	}
+	var mouse []mouseSetting
+
+	for _, file := range append([]string{tmux.ConfigPath}, tmux.Includes...) {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		for n, line := range strings.Split(string(content), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			problem := func(level Level, message string) {
+				problems = append(problems, Problem{Level: level, Tool: "tmux", File: file, Line: n + 1, Message: message})
+			}
+
+			for _, r := range tmuxRemovals {
+				m := r.re.FindStringSubmatch(line)
+				if m == nil {
+					continue
+				}
+				message := fmt.Sprintf(r.message, m[1])
+				switch {
+				case version == "":
+					problem(Warning, fmt.Sprintf("removed in tmux %s: %s", r.removed, message))
+				case versionAtLeast(version, r.removed):
+					problem(Error, fmt.Sprintf("removed in tmux %s, so your tmux %s rejects it: %s", r.removed, version, message))
+				default:
+					problem(Info, fmt.Sprintf("removed in tmux %s, after your %s: %s", r.removed, version, message))
+				}
+			}
+
+			if m := tmuxMouseRe.FindStringSubmatch(line); m != nil {
+				mouse = append(mouse, mouseSetting{value: m[1], file: file, line: n + 1})
+				if m[1] == "" {
+					problem(Warning, "set mouse without a value toggles it, so every reload of the config flips the mouse on or off; write set -g mouse on")
+				}
+			}
+			if tmuxReattachRe.MatchString(line) && (version == "" || versionAtLeast(version, "2.6")) {
+				problem(Info, "reattach-to-user-namespace isn't needed for the clipboard since tmux 2.6")
+			}
+		}
+	}
+
+	// The mouse set both on and off: only the last one counts
+	var first *mouseSetting
+	for i, m := range mouse {
+		if m.value == "" {
+			continue
+		}
+		if first == nil {
+			first = &mouse[i]
+			continue
+		}
+		if m.value == first.value {
+			continue
+		}
+		problems = append(problems, Problem{
+			Level: Warning,
+			Tool:  "tmux",
+			File:  m.file,
+			Line:  m.line,
+			Message: fmt.Sprintf("mouse is set to %s here but %s at %s:%d; the one read last wins",
+				m.value, first.value, first.file, first.line),
+		})
+		break
+	}
+	return problems
+}
+
+// versionAtLeast reports whether the tmux version, such as 3.3a or
+// next-3.5, is at least the version least
+func versionAtLeast(version, least string) bool {
+	major, minor, ok := parseVersion(version)
+	wantMajor, wantMinor, _ := parseVersion(least)
+	if !ok {
+		return true
+	}
+	return major > wantMajor || (major == wantMajor && minor >= wantMinor)
+}
+
+var versionRe = regexp.MustCompile(`(\d+)\.(\d+)`)
+
+// parseVersion reads the major and minor numbers of a tmux version
+func parseVersion(version string) (major, minor int, ok bool) {
+	m := versionRe.FindStringSubmatch(version)
+	if m == nil {
+		return 0, 0, false
+	}
+	major, _ = strconv.Atoi(m[1])
+	minor, _ = strconv.Atoi(m[2])
+	return major, minor, true
+}
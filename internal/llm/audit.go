@@ -0,0 +1,171 @@
+package llm
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cliq-cli/cliq/internal/config"
+)
+
+// AuditRecord is one prompt sent to a backend and what came back, as
+// written to the audit log
+type AuditRecord struct {
+	Time       time.Time `json:"time"`
+	Backend    string    `json:"backend"`
+	URL        string    `json:"url,omitempty"`
+	Model      string    `json:"model,omitempty"`
+	Remote     bool      `json:"remote"`
+	Prompt     string    `json:"prompt"`
+	Response   string    `json:"response,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	DurationMS int64     `json:"duration_ms"`
+}
+
+// auditSettings is history.audit_log: off, remote, or all, and which
+// questions are kept out of it
+type auditSettings struct {
+	mode    string
+	exclude func(question string) bool
+}
+
+// auditPolicy is the auditSettings SetAuditLog set for the process
+var auditPolicy auditSettings
+
+// auditMu serialises appends to the audit log within a process
+var auditMu sync.Mutex
+
+// SetAuditLog turns on the audit log: with remote, every prompt sent to a
+// backend off this machine is recorded with its response, and with all,
+// those sent to local backends too. Questions exclude reports true for
+// aren't recorded. Any other mode turns it off.
+func SetAuditLog(mode string, exclude func(question string) bool) {
+	auditPolicy.mode = mode
+	auditPolicy.exclude = exclude
+}
+
+// WithAuditLog returns a copy of the client that audits as SetAuditLog
+// would, leaving the original and the process's policy untouched
+func (c *Client) WithAuditLog(mode string, exclude func(question string) bool) *Client {
+	clone := *c
+	clone.auditLog = &auditSettings{mode: mode, exclude: exclude}
+	return &clone
+}
+
+// AuditLogPath returns the path of the audit log
+func AuditLogPath() (string, error) {
+	dataDir, err := config.GetDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, "audit.jsonl"), nil
+}
+
+// PruneAuditLog drops records older than maxAge from the audit log and
+// keeps at most the last maxEntries, as history retention does for the
+// other history files. 0 leaves either unlimited.
+func PruneAuditLog(maxEntries int, maxAge time.Duration) error {
+	if maxEntries <= 0 && maxAge <= 0 {
+		return nil
+	}
+	path, err := AuditLogPath()
+	if err != nil {
+		return err
+	}
+
+	auditMu.Lock()
+	defer auditMu.Unlock()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		var rec AuditRecord
+		if json.Unmarshal([]byte(line), &rec) == nil && maxAge > 0 && !rec.Time.IsZero() && time.Since(rec.Time) > maxAge {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	if maxEntries > 0 && len(kept) > maxEntries {
+		kept = kept[len(kept)-maxEntries:]
+	}
+	if len(kept) == len(lines) {
+		return nil
+	}
+	if len(kept) == 0 {
+		return os.Remove(path)
+	}
+	return config.WriteFile(path, []byte(strings.Join(kept, "\n")+"\n"))
+}
+
+// audit records a query on the client in the audit log, if it's on. The
+// prompt is logged as the backend received it, in the model's chat
+// template for llama.cpp. The offline knowledge base sends nothing, so it
+// isn't logged.
+func (c *Client) audit(prompt string, start time.Time, resp string, err error) {
+	policy := auditPolicy
+	if c.auditLog != nil {
+		policy = *c.auditLog
+	}
+	remote := c.IsRemote()
+	switch {
+	case c.backend == "offline":
+		return
+	case policy.mode == "all":
+	case policy.mode == "remote" && remote:
+	default:
+		return
+	}
+	if policy.exclude != nil && policy.exclude(extractQuestion(prompt)) {
+		return
+	}
+
+	rec := AuditRecord{
+		Time:       start,
+		Backend:    c.backend,
+		URL:        c.serverURL,
+		Model:      c.GetModel(),
+		Remote:     remote,
+		Prompt:     prompt,
+		Response:   resp,
+		DurationMS: time.Since(start).Milliseconds(),
+	}
+	if c.backend != "ollama" {
+		rec.Prompt, _, _ = c.preparePrompt(prompt)
+	}
+	if strings.HasPrefix(c.backend, "llama-cli:") {
+		rec.Backend = "llama-cli"
+	}
+	if err != nil {
+		rec.Error = err.Error()
+	}
+
+	path, pathErr := AuditLogPath()
+	if pathErr != nil {
+		return
+	}
+	data, jsonErr := json.Marshal(rec)
+	if jsonErr != nil {
+		return
+	}
+
+	auditMu.Lock()
+	defer auditMu.Unlock()
+	if os.MkdirAll(filepath.Dir(path), config.DirPerm) != nil {
+		return
+	}
+	f, openErr := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, config.FilePerm)
+	if openErr != nil {
+		return
+	}
+	defer f.Close()
+	f.Write(append(data, '\n'))
+}
@@ -0,0 +1,27 @@
+package llm
+
+import "net/http"
+
+// KeyedBackends are the backends that can take an API key, sent as a
+// bearer token: llama-server started with --api-key, and ollama behind a
+// proxy that checks one
+var KeyedBackends = []string{"llama-server", "ollama"}
+
+// apiKey finds the API key for a backend, "" for none
+var apiKey func(backend string) string
+
+// SetAPIKeys sets how the API key for a backend is found; lookup returns
+// "" when there is none
+func SetAPIKeys(lookup func(backend string) string) {
+	apiKey = lookup
+}
+
+// authorize adds the backend's API key, if it has one, to a request to it
+func authorize(req *http.Request, backend string) {
+	if apiKey == nil {
+		return
+	}
+	if key := apiKey(backend); key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+}
@@ -0,0 +1,126 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// BackendChoice is a backend and model the client can be switched to
+type BackendChoice struct {
+	Backend   string
+	ServerURL string
+	Model     string
+}
+
+// Label describes the choice for display
+func (b BackendChoice) Label() string {
+	name := b.Backend
+	if strings.HasPrefix(name, "llama-cli:") {
+		name = "llama-cli"
+	} else if isManaged(name) {
+		name = "llama-server (managed)"
+	} else if IsPlugin(name) {
+		name = PluginName(name)
+	}
+	if b.Model == "" {
+		return name
+	}
+	return name + " · " + b.Model
+}
+
+// ListBackends returns every backend and model available right now: the
+// running llama-server, each model pulled into ollama, llama-cli with the
+// local model file, each model of the backend plugins in PATH, and the
+// offline knowledge base
+func ListBackends(ctx context.Context, modelPath string) []BackendChoice {
+	var choices []BackendChoice
+
+	if url := checkLlamaServer(); url != "" {
+		choices = append(choices, BackendChoice{Backend: "llama-server", ServerURL: url, Model: filepath.Base(modelPath)})
+	}
+
+	if models, err := ListOllamaModels(ctx); err == nil {
+		for _, model := range models {
+			choices = append(choices, BackendChoice{Backend: "ollama", ServerURL: ollamaURL(), Model: model})
+		}
+	}
+
+	if _, err := os.Stat(modelPath); err == nil {
+		for _, name := range []string{"llama-cli", "llama"} {
+			if path, err := exec.LookPath(name); err == nil {
+				choices = append(choices, BackendChoice{Backend: "llama-cli:" + path, Model: filepath.Base(modelPath)})
+				break
+			}
+		}
+	}
+
+	choices = append(choices, pluginChoices(ctx)...)
+	return append(choices, BackendChoice{Backend: "offline"})
+}
+
+// ListOllamaModels returns the models pulled into the ollama server
+func ListOllamaModels(ctx context.Context) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ollamaURL()+"/api/tags", nil)
+	if err != nil {
+		return nil, err
+	}
+	authorize(req, "ollama")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ollama returned %s", resp.Status)
+	}
+
+	var result struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	models := make([]string, 0, len(result.Models))
+	for _, m := range result.Models {
+		models = append(models, m.Name)
+	}
+	return models, nil
+}
+
+// Choice returns the client's current backend and model
+func (c *Client) Choice() BackendChoice {
+	choice := BackendChoice{Backend: c.backend, ServerURL: c.serverURL}
+	if c.backend != "offline" {
+		choice.Model = c.GetModel()
+	}
+	return choice
+}
+
+// WithBackend returns a copy of the client that uses the given backend and
+// model, leaving the original untouched
+func (c *Client) WithBackend(choice BackendChoice) *Client {
+	clone := *c
+	clone.backend = choice.Backend
+	clone.serverURL = choice.ServerURL
+	switch {
+	case choice.Backend == "ollama":
+		clone.ollamaModel = choice.Model
+	case IsPlugin(choice.Backend):
+		clone.pluginModel = choice.Model
+	}
+	return &clone
+}
@@ -2,13 +2,16 @@ package llm
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -18,12 +21,35 @@ type Client struct {
 	ollamaModel string
 	temperature float64
 	maxTokens   int
-	backend     string // "llama-server", "ollama", "llama-cli"
+	sampling    SamplingOptions
+	backend     string // "llama-server", "ollama", "llama-cli", "plugin", "offline"
 	serverURL   string
+	// pluginModel is the model a backend plugin is asked for, "" for its
+	// default
+	pluginModel string
+
+	// session is the warm llama-server StartSession runs for llama-cli;
+	// keepAlive is how long ollama keeps the model loaded in one
+	session   *session
+	keepAlive string
+
+	// attempts are the tries the last query took, for Attempts
+	attempts []Attempt
+
+	// fallback and auditLog override the process's fallback and audit
+	// policies when set, by WithFallback and WithAuditLog
+	fallback *fallbackSettings
+	auditLog *auditSettings
 }
 
-// NewClient creates a new LLM client and auto-detects the best available backend
+// NewClient creates a new LLM client and auto-detects the best available backend.
+// When no backend is available the client answers from the offline knowledge base.
 func NewClient(modelPath string, ollamaModel string, temperature float64, maxTokens int) (*Client, error) {
+	// The environment overrides the configured ollama model
+	if env := os.Getenv("CLIQ_OLLAMA_MODEL"); env != "" {
+		ollamaModel = env
+	}
+
 	client := &Client{
 		modelPath:   modelPath,
 		ollamaModel: ollamaModel,
@@ -31,47 +57,95 @@ func NewClient(modelPath string, ollamaModel string, temperature float64, maxTok
 		maxTokens:   maxTokens,
 	}
 
+	// A plugin set in model.backend is used rather than detecting one,
+	// unless it's cooling down after failing
+	if pluginPolicy.name != "" && backendHealthy(pluginPolicy.name) {
+		client.backend = pluginBackend(pluginPolicy.name)
+		client.pluginModel = pluginPolicy.model
+		return client, nil
+	}
+
 	// Try to detect the best available backend
 	backend, serverURL := detectBackend(modelPath)
 	client.backend = backend
 	client.serverURL = serverURL
 
 	if backend == "" {
-		return nil, fmt.Errorf("no LLM backend available. Please install one of:\n" +
-			"  1. ollama (recommended): https://ollama.ai\n" +
-			"  2. llama.cpp server: https://github.com/ggerganov/llama.cpp\n" +
-			"  3. llama-cli from llama.cpp")
+		client.backend = "offline"
 	}
 
 	return client, nil
 }
 
-// detectBackend finds the best available LLM backend
+// NewLlamaCLIClient creates a client that runs modelPath with the llama-cli
+// binary, skipping backend detection
+func NewLlamaCLIClient(modelPath string, temperature float64, maxTokens int) (*Client, error) {
+	for _, name := range []string{"llama-cli", "llama"} {
+		if path, err := exec.LookPath(name); err == nil {
+			return &Client{
+				modelPath:   modelPath,
+				temperature: temperature,
+				maxTokens:   maxTokens,
+				backend:     "llama-cli:" + path,
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf("llama-cli not found in PATH")
+}
+
+// detectBackend finds the best available LLM backend. Backends cooling down
+// after repeated failures are skipped in favour of the next one.
 func detectBackend(modelPath string) (backend string, serverURL string) {
+	// The running servers are probed at once, so a cold start waits for
+	// one timeout rather than each in turn
+	var (
+		llamaServerURL string
+		ollamaRunning  bool
+		wg             sync.WaitGroup
+	)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		if backendHealthy("llama-server") {
+			llamaServerURL = checkLlamaServer()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		// ollama may be remote when OLLAMA_HOST is set
+		if _, err := exec.LookPath("ollama"); (err == nil || os.Getenv("OLLAMA_HOST") != "") && backendHealthy("ollama") {
+			ollamaRunning = checkOllamaRunning()
+		}
+	}()
+	wg.Wait()
+
 	// 1. Check if llama-server is running
-	if url := checkLlamaServer(); url != "" {
-		return "llama-server", url
+	if llamaServerURL != "" {
+		return "llama-server", llamaServerURL
 	}
 
-	// 2. Check for ollama
-	if _, err := exec.LookPath("ollama"); err == nil {
-		// Check if ollama is running
-		if checkOllamaRunning() {
-			return "ollama", "http://localhost:11434"
+	// 2. Start llama-server and keep it running, if cliq manages it
+	if managedIdle > 0 && backendHealthy("llama-server-managed") {
+		if path, err := exec.LookPath("llama-server"); err == nil {
+			if _, err := os.Stat(modelPath); err == nil {
+				return "llama-server-managed:" + path, ""
+			}
 		}
 	}
 
-	// 3. Check for llama-cli
-	if path, err := exec.LookPath("llama-cli"); err == nil {
-		if _, err := os.Stat(modelPath); err == nil {
-			return "llama-cli:" + path, ""
-		}
+	// 3. Check if ollama is running
+	if ollamaRunning {
+		return "ollama", ollamaURL()
 	}
 
-	// 4. Check for llama (older name)
-	if path, err := exec.LookPath("llama"); err == nil {
-		if _, err := os.Stat(modelPath); err == nil {
-			return "llama-cli:" + path, ""
+	// 4. Check for llama-cli, or llama (its older name)
+	if backendHealthy("llama-cli") {
+		for _, name := range []string{"llama-cli", "llama"} {
+			if path, err := exec.LookPath(name); err == nil {
+				if _, err := os.Stat(modelPath); err == nil {
+					return "llama-cli:" + path, ""
+				}
+			}
 		}
 	}
 
@@ -95,28 +169,66 @@ func CheckOllamaRunning() bool {
 	return checkOllamaRunning()
 }
 
-// checkLlamaServer checks if llama-server is running on common ports
+// checkLlamaServer checks if llama-server is running at CLIQ_LLAMA_SERVER_URL,
+// or otherwise on common local ports
 func checkLlamaServer() string {
-	ports := []string{"8080", "8000", "5000"}
+	urls := []string{"http://localhost:8080", "http://localhost:8000", "http://localhost:5000"}
+	if env := os.Getenv("CLIQ_LLAMA_SERVER_URL"); env != "" {
+		urls = []string{withScheme(env)}
+	}
 	client := &http.Client{Timeout: 500 * time.Millisecond}
 
-	for _, port := range ports {
-		url := fmt.Sprintf("http://localhost:%s/health", port)
-		resp, err := client.Get(url)
-		if err == nil {
-			resp.Body.Close()
-			if resp.StatusCode == 200 {
-				return fmt.Sprintf("http://localhost:%s", port)
+	// Probe the ports together, preferring them in order
+	healthy := make([]bool, len(urls))
+	var wg sync.WaitGroup
+	for i, url := range urls {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Get(url + "/health")
+			if err == nil {
+				resp.Body.Close()
+				healthy[i] = resp.StatusCode == 200
 			}
+		}()
+	}
+	wg.Wait()
+
+	for i, url := range urls {
+		if healthy[i] {
+			return url
 		}
 	}
 	return ""
 }
 
+// ollamaURL returns the ollama API address, honouring OLLAMA_HOST like the
+// ollama CLI does
+func ollamaURL() string {
+	if host := os.Getenv("OLLAMA_HOST"); host != "" {
+		return withScheme(host)
+	}
+	return "http://localhost:11434"
+}
+
+// withScheme adds http:// to a host:port address and drops a trailing slash
+func withScheme(addr string) string {
+	addr = strings.TrimRight(addr, "/")
+	if !strings.Contains(addr, "://") {
+		addr = "http://" + addr
+	}
+	return addr
+}
+
 // checkOllamaRunning checks if ollama is running
 func checkOllamaRunning() bool {
+	req, err := http.NewRequest(http.MethodGet, ollamaURL()+"/api/tags", nil)
+	if err != nil {
+		return false
+	}
+	authorize(req, "ollama")
 	client := &http.Client{Timeout: 500 * time.Millisecond}
-	resp, err := client.Get("http://localhost:11434/api/tags")
+	resp, err := client.Do(req)
 	if err == nil {
 		resp.Body.Close()
 		return resp.StatusCode == 200
@@ -126,17 +238,41 @@ func checkOllamaRunning() bool {
 
 // Query sends a prompt to the LLM and returns the response
 func (c *Client) Query(prompt string) (string, error) {
+	return c.QueryContext(context.Background(), prompt)
+}
+
+// QueryContext is like Query but aborts the request, or kills the inference
+// process, when ctx is cancelled. A backend that fails is retried, and then
+// the next available one in the fallback chain is asked.
+func (c *Client) QueryContext(ctx context.Context, prompt string) (string, error) {
+	return c.withFallback(ctx, prompt, func(client *Client) (string, error) {
+		return client.query(ctx, prompt)
+	}, func() bool { return true })
+}
+
+// query sends a prompt to the active backend
+func (c *Client) query(ctx context.Context, prompt string) (string, error) {
+	if warm, err := c.warm(ctx); err != nil {
+		return "", err
+	} else if warm != nil {
+		return warm.query(ctx, prompt)
+	}
+
 	switch {
 	case c.backend == "llama-server":
-		return c.queryLlamaServer(prompt)
+		return c.queryLlamaServer(ctx, prompt)
 	case c.backend == "ollama":
-		return c.queryOllama(prompt)
+		return c.queryOllama(ctx, prompt)
 	case strings.HasPrefix(c.backend, "llama-cli:"):
 		path := strings.TrimPrefix(c.backend, "llama-cli:")
-		return c.queryLlamaCLI(path, prompt)
+		return c.queryLlamaCLI(ctx, path, prompt)
+	case IsPlugin(c.backend):
+		return c.queryPlugin(ctx, strings.TrimPrefix(c.backend, "plugin:"), prompt, nil)
+	case c.backend == "offline":
+		return queryOffline(prompt)
 	case strings.HasPrefix(c.backend, "llama-server-start:"):
-		return "", fmt.Errorf("llama-server is installed but not running.\n" +
-			"Start it with: llama-server -m %s --port 8080\n" +
+		return "", fmt.Errorf("llama-server is installed but not running.\n"+
+			"Start it with: llama-server -m %s --port 8080\n"+
 			"Or use ollama instead: ollama run phi3", c.modelPath)
 	default:
 		return "", fmt.Errorf("no LLM backend configured")
@@ -144,25 +280,37 @@ func (c *Client) Query(prompt string) (string, error) {
 }
 
 // queryLlamaServer queries the llama.cpp server API
-func (c *Client) queryLlamaServer(prompt string) (string, error) {
+func (c *Client) queryLlamaServer(ctx context.Context, prompt string) (string, error) {
+	prompt, answer, stop := c.preparePrompt(prompt)
 	reqBody := map[string]interface{}{
 		"prompt":      prompt,
-		"n_predict":   c.maxTokens,
+		"n_predict":   answer,
 		"temperature": c.temperature,
-		"stop":        []string{"\n\nUser:", "\n\nQuestion:", "```\n\n"},
+		"stop":        serverStops(stop),
 		"stream":      false,
 	}
+	c.addSampling(reqBody)
 
 	jsonBody, err := json.Marshal(reqBody)
 	if err != nil {
 		return "", err
 	}
 
-	resp, err := http.Post(c.serverURL+"/completion", "application/json", bytes.NewBuffer(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.serverURL+"/completion", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	authorize(req, "llama-server")
+
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return "", fmt.Errorf("llama-server request failed: %w", err)
 	}
 	defer resp.Body.Close()
+	if err := statusError("llama-server", resp); err != nil {
+		return "", err
+	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
@@ -180,11 +328,8 @@ func (c *Client) queryLlamaServer(prompt string) (string, error) {
 }
 
 // queryOllama queries the Ollama API
-func (c *Client) queryOllama(prompt string) (string, error) {
+func (c *Client) queryOllama(ctx context.Context, prompt string) (string, error) {
 	model := c.ollamaModel
-	if os.Getenv("CLIQ_OLLAMA_MODEL") != "" {
-		model = os.Getenv("CLIQ_OLLAMA_MODEL")
-	}
 
 	reqBody := map[string]interface{}{
 		"model":  model,
@@ -195,14 +340,25 @@ func (c *Client) queryOllama(prompt string) (string, error) {
 			"num_predict": c.maxTokens,
 		},
 	}
+	c.addSampling(reqBody["options"].(map[string]interface{}))
+	if c.keepAlive != "" {
+		reqBody["keep_alive"] = c.keepAlive
+	}
 
 	jsonBody, err := json.Marshal(reqBody)
 	if err != nil {
 		return "", err
 	}
 
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.serverURL+"/api/generate", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	authorize(req, "ollama")
+
 	client := &http.Client{Timeout: 120 * time.Second}
-	resp, err := client.Post(c.serverURL+"/api/generate", "application/json", bytes.NewBuffer(jsonBody))
+	resp, err := client.Do(req)
 	if err != nil {
 		return "", fmt.Errorf("ollama request failed: %w", err)
 	}
@@ -211,6 +367,9 @@ func (c *Client) queryOllama(prompt string) (string, error) {
 	if resp.StatusCode == 404 {
 		return "", fmt.Errorf("model '%s' not found in ollama. Pull it with: ollama pull %s", model, model)
 	}
+	if err := statusError("ollama", resp); err != nil {
+		return "", err
+	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
@@ -233,34 +392,63 @@ func (c *Client) queryOllama(prompt string) (string, error) {
 }
 
 // queryLlamaCLI uses the llama.cpp CLI for inference
-func (c *Client) queryLlamaCLI(llamaPath, prompt string) (string, error) {
+func (c *Client) queryLlamaCLI(ctx context.Context, llamaPath, prompt string) (string, error) {
+	prompt, answer, _ := c.preparePrompt(prompt)
 	args := []string{
 		"-m", c.modelPath,
 		"-p", prompt,
-		"-n", fmt.Sprintf("%d", c.maxTokens),
+		"-n", fmt.Sprintf("%d", answer),
 		"--temp", fmt.Sprintf("%.2f", c.temperature),
 		"--no-display-prompt",
-		"-c", "4096",
+		"-c", fmt.Sprint(FormatFor(c.modelPath).Context),
 	}
+	args = append(args, c.samplingArgs()...)
+	args = append(args, InferenceFor(c.modelPath).Args()...)
 
-	cmd := exec.Command(llamaPath, args...)
+	cmd := exec.CommandContext(ctx, llamaPath, args...)
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
 	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
 		return "", fmt.Errorf("llama inference failed: %w\nstderr: %s", err, stderr.String())
 	}
 
 	return strings.TrimSpace(stdout.String()), nil
 }
 
-// Close releases resources held by the client
+// Close releases resources held by the client, stopping its warm session
 func (c *Client) Close() error {
+	if c.session != nil {
+		c.session.stop()
+	}
 	return nil
 }
 
+// WithSampling returns a copy of the client that generates with the given
+// temperature and token limit, leaving the original untouched
+func (c *Client) WithSampling(temperature float64, maxTokens int) *Client {
+	clone := *c
+	clone.temperature = temperature
+	clone.maxTokens = maxTokens
+	return &clone
+}
+
 // GetBackend returns the current backend being used
 func (c *Client) GetBackend() string {
 	return c.backend
 }
+
+// GetModel returns the name of the model the current backend runs
+func (c *Client) GetModel() string {
+	switch {
+	case c.backend == "ollama":
+		return c.ollamaModel
+	case IsPlugin(c.backend):
+		return c.pluginModel
+	}
+	return filepath.Base(c.modelPath)
+}
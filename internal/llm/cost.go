@@ -0,0 +1,107 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// Price is what a hosted model charges, in USD per million tokens
+type Price struct {
+	Input  float64
+	Output float64
+}
+
+// prices are approximate list prices for hosted models that can sit behind
+// a remote ollama or llama-server endpoint. Patterns are matched as
+// substrings of the model name, so more specific names come first.
+var prices = []struct {
+	pattern string
+	price   Price
+}{
+	{"gpt-4o-mini", Price{Input: 0.15, Output: 0.60}},
+	{"gpt-4o", Price{Input: 2.50, Output: 10.00}},
+	{"gpt-4.1-nano", Price{Input: 0.10, Output: 0.40}},
+	{"gpt-4.1-mini", Price{Input: 0.40, Output: 1.60}},
+	{"gpt-4.1", Price{Input: 2.00, Output: 8.00}},
+	{"claude-3-5-haiku", Price{Input: 0.80, Output: 4.00}},
+	{"claude-sonnet", Price{Input: 3.00, Output: 15.00}},
+	{"claude-opus", Price{Input: 15.00, Output: 75.00}},
+	{"mistral-small", Price{Input: 0.10, Output: 0.30}},
+	{"mistral-large", Price{Input: 2.00, Output: 6.00}},
+	{"gemini-2.0-flash", Price{Input: 0.10, Output: 0.40}},
+}
+
+// LookupPrice returns the price of a hosted model, if it is known
+func LookupPrice(model string) (Price, bool) {
+	model = strings.ToLower(model)
+	for _, p := range prices {
+		if strings.Contains(model, p.pattern) {
+			return p.price, true
+		}
+	}
+	return Price{}, false
+}
+
+// CostEstimate is the approximate cost of sending a prompt to a backend.
+// Completion tokens assume the full token budget is used, so it is an
+// upper bound.
+type CostEstimate struct {
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+	USD              float64
+	// Priced is false when the model isn't in the price table
+	Priced bool
+}
+
+// String describes the estimate in one line
+func (e CostEstimate) String() string {
+	tokens := fmt.Sprintf("~%d prompt + up to %d completion tokens", e.PromptTokens, e.CompletionTokens)
+	if !e.Priced {
+		return fmt.Sprintf("%s (no price known for %s)", tokens, e.Model)
+	}
+	return fmt.Sprintf("%s, up to $%.4f", tokens, e.USD)
+}
+
+// EstimateCost estimates the tokens, and cost where the model's price is
+// known, of sending prompt to the current backend
+func (c *Client) EstimateCost(prompt string) CostEstimate {
+	est := CostEstimate{
+		Model:            c.GetModel(),
+		PromptTokens:     EstimateTokens(prompt),
+		CompletionTokens: c.maxTokens,
+	}
+	if price, ok := LookupPrice(est.Model); ok {
+		est.Priced = true
+		est.USD = (float64(est.PromptTokens)*price.Input + float64(est.CompletionTokens)*price.Output) / 1_000_000
+	}
+	return est
+}
+
+// IsRemote reports whether the backend runs on another machine, set up with
+// OLLAMA_HOST or CLIQ_LLAMA_SERVER_URL, or is a plugin that says it sends
+// prompts off this one
+func (c *Client) IsRemote() bool {
+	if IsPlugin(c.backend) {
+		path := strings.TrimPrefix(c.backend, "plugin:")
+		info, err := Plugin{Name: PluginName(path), Path: path}.Info(context.Background())
+		return err == nil && info.Remote
+	}
+	if c.serverURL == "" {
+		return false
+	}
+	u, err := url.Parse(c.serverURL)
+	if err != nil {
+		return false
+	}
+
+	host := u.Hostname()
+	if host == "localhost" {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip == nil || !(ip.IsLoopback() || ip.IsUnspecified())
+}
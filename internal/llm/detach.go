@@ -0,0 +1,11 @@
+//go:build !windows
+
+package llm
+
+import "syscall"
+
+// detachedProcess puts a process in its own session, so closing the
+// terminal or Ctrl-C doesn't stop it
+func detachedProcess() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setsid: true}
+}
@@ -0,0 +1,9 @@
+package llm
+
+import "syscall"
+
+// detachedProcess puts a process in its own process group, so Ctrl-C in
+// the console doesn't stop it
+func detachedProcess() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
@@ -1,15 +1,21 @@
 package llm
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/schollz/progressbar/v3"
+
+	"github.com/cliq-cli/cliq/internal/config"
+	"github.com/cliq-cli/cliq/internal/lockfile"
 )
 
 const (
@@ -22,19 +28,54 @@ const (
 	// ModelSize is the approximate size of the model in bytes (2.3GB)
 	ModelSize = 2_300_000_000
 
-	// ExpectedSHA256 is the expected checksum of the model file
-	// This should be updated when the model version changes
-	ExpectedSHA256 = ""
+	// TinyModelURL is a small (~100MB) GGUF model used by 'cliq selftest' to
+	// exercise the full query pipeline without downloading the default model
+	TinyModelURL = "https://huggingface.co/bartowski/SmolLM2-135M-Instruct-GGUF/resolve/main/SmolLM2-135M-Instruct-Q4_K_M.gguf"
 )
 
-// DownloadModel downloads the model from the given URL to the specified path
-func DownloadModel(url, destPath string) error {
+// ModelSHA256 pins the SHA-256 of models cliq downloads, by URL. Each URL
+// built into cliq (DefaultModelURL, TinyModelURL) belongs here, updated
+// whenever the URL changes, so its download is checked against the pinned
+// hash rather than trusted as served.
+var ModelSHA256 = map[string]string{}
+
+// modelSHA256 returns the hash a download from url must match: want when
+// given, else the one pinned for url, else ""
+func modelSHA256(url, want string) string {
+	if want != "" {
+		return strings.ToLower(want)
+	}
+	return ModelSHA256[url]
+}
+
+// DownloadModel downloads the model from the given URL to the specified path.
+// The file must match wantSHA256, or the hash ModelSHA256 pins for url when
+// wantSHA256 is empty, before it's put in place; with neither, the download
+// goes ahead with a warning that it couldn't be verified.
+// Cancelling ctx aborts the download and removes the partial file.
+func DownloadModel(ctx context.Context, url, destPath, wantSHA256 string) error {
 	// Create the destination directory if it doesn't exist
 	dir := filepath.Dir(destPath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
+	if err := os.MkdirAll(dir, config.DirPerm); err != nil {
 		return fmt.Errorf("failed to create directory: %w", err)
 	}
 
+	// Hold a lock while downloading so concurrent runs don't share the temp
+	// file; a lock left behind by a crashed download is reclaimed
+	lock, err := lockfile.Acquire(destPath + ".lock")
+	if err != nil {
+		if errors.Is(err, lockfile.ErrLocked) {
+			return fmt.Errorf("another download is already running: %w", err)
+		}
+		return fmt.Errorf("failed to lock download: %w", err)
+	}
+	defer lock.Release()
+
+	want := modelSHA256(url, wantSHA256)
+	if want == "" {
+		fmt.Fprintf(os.Stderr, "Warning: no checksum is known for %s, so the download can't be verified (pass --sha256 to check it)\n", url)
+	}
+
 	// Create a temporary file for downloading
 	tmpPath := destPath + ".tmp"
 	tmpFile, err := os.Create(tmpPath)
@@ -50,7 +91,11 @@ func DownloadModel(url, destPath string) error {
 	client := &http.Client{}
 
 	// Make the request
-	resp, err := client.Get(url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to download model: %w", err)
+	}
+	resp, err := client.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to download model: %w", err)
 	}
@@ -99,13 +144,13 @@ func DownloadModel(url, destPath string) error {
 	tmpFile.Close()
 
 	// Verify checksum if we have one
-	if ExpectedSHA256 != "" {
+	if want != "" {
 		checksum, err := calculateSHA256(tmpPath)
 		if err != nil {
 			return fmt.Errorf("failed to calculate checksum: %w", err)
 		}
-		if checksum != ExpectedSHA256 {
-			return fmt.Errorf("checksum mismatch: expected %s, got %s", ExpectedSHA256, checksum)
+		if checksum != want {
+			return fmt.Errorf("checksum mismatch: expected %s, got %s", want, checksum)
 		}
 	}
 
@@ -117,8 +162,9 @@ func DownloadModel(url, destPath string) error {
 	return nil
 }
 
-// VerifyModel verifies the model file exists and has the correct checksum
-func VerifyModel(path string) error {
+// VerifyModel verifies the model file exists and, when a hash is known for
+// the URL it was downloaded from, has the correct checksum
+func VerifyModel(path, url string) error {
 	// Check file exists
 	info, err := os.Stat(path)
 	if err != nil {
@@ -134,12 +180,12 @@ func VerifyModel(path string) error {
 	}
 
 	// Verify checksum if we have one
-	if ExpectedSHA256 != "" {
+	if want := ModelSHA256[url]; want != "" {
 		checksum, err := calculateSHA256(path)
 		if err != nil {
 			return fmt.Errorf("failed to calculate checksum: %w", err)
 		}
-		if checksum != ExpectedSHA256 {
+		if checksum != want {
 			return fmt.Errorf("checksum mismatch: model may be corrupted")
 		}
 	}
@@ -0,0 +1,265 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// defaultFallback is the order backends are fallen back to when the one a
+// query was sent to fails, until SetFallback is called: the order
+// detectBackend prefers them in
+var defaultFallback = []string{"llama-server", "ollama", "llama-cli", "offline"}
+
+const (
+	// retryBackoff is the wait before the first retry, doubling for each
+	// one after it up to maxBackoff
+	retryBackoff = 500 * time.Millisecond
+	maxBackoff   = 4 * time.Second
+	// maxRetryAfter caps how long a 429's Retry-After is honoured for
+	maxRetryAfter = 10 * time.Second
+)
+
+// fallbackSettings is model.retries and model.fallback
+type fallbackSettings struct {
+	retries int
+	chain   []string
+}
+
+// fallbackPolicy is the fallbackSettings SetFallback set for the process
+var fallbackPolicy = fallbackSettings{retries: 2, chain: defaultFallback}
+
+// SetFallback sets how many times a query is retried on its backend after
+// a transient failure, and the backends then tried in turn, by name. An
+// empty chain turns falling back off.
+func SetFallback(retries int, chain []string) {
+	fallbackPolicy.retries = max(retries, 0)
+	fallbackPolicy.chain = chain
+}
+
+// WithFallback returns a copy of the client that retries and falls back as
+// SetFallback would, leaving the original and the process's policy
+// untouched
+func (c *Client) WithFallback(retries int, chain []string) *Client {
+	clone := *c
+	clone.fallback = &fallbackSettings{retries: max(retries, 0), chain: chain}
+	return &clone
+}
+
+// fallbackSettings returns the client's own fallback settings, or the
+// process's
+func (c *Client) fallbackSettings() fallbackSettings {
+	if c.fallback != nil {
+		return *c.fallback
+	}
+	return fallbackPolicy
+}
+
+// Attempt is one try of a query on a backend
+type Attempt struct {
+	Backend string
+	Err     error // nil for the attempt that answered
+}
+
+// Attempts returns the tries the client's last query took, in order. The
+// last is the one that answered, unless every try failed.
+func (c *Client) Attempts() []Attempt {
+	return c.attempts
+}
+
+// AnsweredBy returns the backend that answered the client's last query, or
+// "" if none did
+func (c *Client) AnsweredBy() string {
+	if n := len(c.attempts); n > 0 && c.attempts[n-1].Err == nil {
+		return c.attempts[n-1].Backend
+	}
+	return ""
+}
+
+// FellBack reports whether the client's last query was answered by a
+// backend of the fallback chain rather than the client's own
+func (c *Client) FellBack() bool {
+	answered := c.AnsweredBy()
+	return answered != "" && answered != c.backend
+}
+
+// StatusError is an error response from a backend's HTTP API
+type StatusError struct {
+	Backend    string
+	StatusCode int
+	Message    string
+	// RetryAfter is how long the API asked to be left before retrying
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	msg := fmt.Sprintf("%s returned %d %s", e.Backend, e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	if e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden {
+		msg += fmt.Sprintf(" (set its API key with cliq auth set %s)", e.Backend)
+	}
+	return msg
+}
+
+// statusError returns a StatusError for an unsuccessful response, or nil
+func statusError(backend string, resp *http.Response) error {
+	if resp.StatusCode < 400 {
+		return nil
+	}
+	e := &StatusError{Backend: backend, StatusCode: resp.StatusCode}
+	if body, err := io.ReadAll(io.LimitReader(resp.Body, 512)); err == nil {
+		// ollama says {"error": "..."}, and llama-server
+		// {"error": {"message": "..."}}
+		var ollama struct {
+			Error string `json:"error"`
+		}
+		var server struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		switch {
+		case json.Unmarshal(body, &ollama) == nil && ollama.Error != "":
+			e.Message = ollama.Error
+		case json.Unmarshal(body, &server) == nil && server.Error.Message != "":
+			e.Message = server.Error.Message
+		default:
+			e.Message = strings.TrimSpace(string(body))
+		}
+	}
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs > 0 {
+		e.RetryAfter = time.Duration(secs) * time.Second
+	}
+	return e
+}
+
+// transient reports whether an error may not recur on a retry: a refused
+// or dropped connection, a timeout, or the API saying it's overloaded
+func transient(err error) bool {
+	var status *StatusError
+	if errors.As(err, &status) {
+		return status.StatusCode == http.StatusTooManyRequests || status.StatusCode >= 500
+	}
+	var netErr net.Error
+	if transientPluginError(err) {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.ErrUnexpectedEOF) || (errors.As(err, &netErr) && netErr.Timeout())
+}
+
+// backoff is the wait before retry n, counting from 0
+func backoff(n int, err error) time.Duration {
+	var status *StatusError
+	if errors.As(err, &status) && status.RetryAfter > 0 {
+		return min(status.RetryAfter, maxRetryAfter)
+	}
+	return min(retryBackoff<<n, maxBackoff)
+}
+
+// withFallback runs try on the client, retrying it with backoff while it
+// fails transiently, then, if it still does, on each backend of the
+// fallback chain that's available until one answers. An error that isn't
+// transient, such as a model the backend doesn't have, is returned as is. Each backend's outcome is recorded in its
+// health once, however often it was retried, and every try in c.attempts
+// and the audit log.
+// Once retry says no, as when a stream has shown part of an answer, the
+// error is returned as is.
+func (c *Client) withFallback(ctx context.Context, prompt string, try func(*Client) (string, error), retry func() bool) (string, error) {
+	c.attempts = nil
+	attempt := func(client *Client) (string, error) {
+		start := time.Now()
+		resp, err := try(client)
+		client.audit(prompt, start, resp, err)
+		c.attempts = append(c.attempts, Attempt{Backend: client.backend, Err: err})
+		return resp, err
+	}
+
+	policy := c.fallbackSettings()
+	start := time.Now()
+	resp, err := attempt(c)
+	for n := 0; err != nil && n < policy.retries && transient(err) && retry(); n++ {
+		select {
+		case <-time.After(backoff(n, err)):
+		case <-ctx.Done():
+			recordHealth(c.backend, start, ctx.Err())
+			return "", err
+		}
+		resp, err = attempt(c)
+	}
+	recordHealth(c.backend, start, err)
+	if err == nil || ctx.Err() != nil || !transient(err) || !retry() {
+		return resp, err
+	}
+
+	tried := map[string]bool{healthKey(c.backend): true}
+	for _, name := range policy.chain {
+		if tried[name] || !backendHealthy(name) {
+			continue
+		}
+		tried[name] = true
+		choice, ok := c.fallbackChoice(name)
+		if !ok {
+			continue
+		}
+		fallback := c.WithBackend(choice)
+		start := time.Now()
+		resp, fallbackErr := attempt(fallback)
+		recordHealth(fallback.backend, start, fallbackErr)
+		if fallbackErr == nil {
+			return resp, nil
+		}
+		if ctx.Err() != nil || !retry() {
+			return "", fallbackErr
+		}
+	}
+	return "", err
+}
+
+// fallbackChoice returns the named backend, if it's available to fall back
+// to now
+func (c *Client) fallbackChoice(name string) (BackendChoice, bool) {
+	switch name {
+	case "llama-server":
+		if url := checkLlamaServer(); url != "" {
+			return BackendChoice{Backend: "llama-server", ServerURL: url}, true
+		}
+	case "ollama":
+		if checkOllamaRunning() {
+			return BackendChoice{Backend: "ollama", ServerURL: ollamaURL(), Model: c.ollamaModel}, true
+		}
+	case "llama-cli":
+		if _, err := os.Stat(c.modelPath); err != nil {
+			break
+		}
+		for _, bin := range []string{"llama-cli", "llama"} {
+			if path, err := exec.LookPath(bin); err == nil {
+				return BackendChoice{Backend: "llama-cli:" + path}, true
+			}
+		}
+	case "offline":
+		return BackendChoice{Backend: "offline"}, true
+	default:
+		// Any other name is a backend plugin's
+		if path, err := exec.LookPath(PluginPrefix + name); err == nil {
+			choice := BackendChoice{Backend: "plugin:" + path}
+			if name == pluginPolicy.name {
+				choice.Model = pluginPolicy.model
+			}
+			return choice, true
+		}
+	}
+	return BackendChoice{}, false
+}
@@ -0,0 +1,255 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cliq-cli/cliq/internal/config"
+)
+
+// GPU is the graphics hardware llama.cpp can offload the model to
+type GPU struct {
+	// Kind is metal, cuda, or rocm, or "" when there's no usable GPU
+	Kind   string `json:"kind"`
+	Name   string `json:"name,omitempty"`
+	VRAMMB int    `json:"vram_mb,omitempty"`
+}
+
+// String describes the GPU for display
+func (g GPU) String() string {
+	if g.Kind == "" {
+		return "none (CPU only)"
+	}
+	kind := map[string]string{"metal": "Metal", "cuda": "CUDA", "rocm": "ROCm"}[g.Kind]
+	if g.VRAMMB == 0 {
+		return fmt.Sprintf("%s (%s)", g.Name, kind)
+	}
+	return fmt.Sprintf("%s (%s, %.1f GB)", g.Name, kind, float64(g.VRAMMB)/1024)
+}
+
+// gpuProbeTimeout bounds each vendor tool, which can hang when a driver is
+// wedged
+const gpuProbeTimeout = 3 * time.Second
+
+// DetectGPU looks for a Metal, CUDA, or ROCm GPU and how much memory it has.
+// Memory is summed over all the GPUs of the kind found, since llama.cpp
+// splits the model across them.
+func DetectGPU(ctx context.Context) GPU {
+	ctx, cancel := context.WithTimeout(ctx, gpuProbeTimeout)
+	defer cancel()
+
+	if runtime.GOOS == "darwin" && runtime.GOARCH == "arm64" {
+		return detectMetal(ctx)
+	}
+	if g, ok := detectCUDA(ctx); ok {
+		return g
+	}
+	if g, ok := detectROCm(); ok {
+		return g
+	}
+	return GPU{}
+}
+
+// detectMetal describes Apple silicon, whose GPU shares the system memory.
+// macOS lets the GPU use about two thirds of it.
+func detectMetal(ctx context.Context) GPU {
+	g := GPU{Kind: "metal", Name: "Apple silicon"}
+	if out, err := exec.CommandContext(ctx, "sysctl", "-n", "machdep.cpu.brand_string").Output(); err == nil {
+		g.Name = strings.TrimSpace(string(out))
+	}
+	if out, err := exec.CommandContext(ctx, "sysctl", "-n", "hw.memsize").Output(); err == nil {
+		if bytes, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64); err == nil {
+			g.VRAMMB = int(bytes / (1 << 20) * 2 / 3)
+		}
+	}
+	return g
+}
+
+// detectCUDA asks nvidia-smi for the NVIDIA GPUs
+func detectCUDA(ctx context.Context) (GPU, bool) {
+	if _, err := exec.LookPath("nvidia-smi"); err != nil {
+		return GPU{}, false
+	}
+	out, err := exec.CommandContext(ctx, "nvidia-smi", "--query-gpu=name,memory.total", "--format=csv,noheader,nounits").Output()
+	if err != nil {
+		return GPU{}, false
+	}
+	g := GPU{Kind: "cuda"}
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		name, mem, ok := strings.Cut(line, ",")
+		if !ok {
+			continue
+		}
+		if g.Name == "" {
+			g.Name = strings.TrimSpace(name)
+		}
+		if mb, err := strconv.Atoi(strings.TrimSpace(mem)); err == nil {
+			g.VRAMMB += mb
+		}
+	}
+	return g, g.Name != ""
+}
+
+// detectROCm reads the AMD GPUs' memory from sysfs, when ROCm is installed
+// for llama.cpp to use them
+func detectROCm() (GPU, bool) {
+	if _, err := exec.LookPath("rocm-smi"); err != nil {
+		if _, err := os.Stat("/opt/rocm"); err != nil {
+			return GPU{}, false
+		}
+	}
+	cards, _ := filepath.Glob("/sys/class/drm/card[0-9]*/device/mem_info_vram_total")
+	g := GPU{Kind: "rocm", Name: "AMD GPU"}
+	for _, card := range cards {
+		data, err := os.ReadFile(card)
+		if err != nil {
+			continue
+		}
+		if bytes, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64); err == nil {
+			g.VRAMMB += int(bytes / (1 << 20))
+		}
+		if name, err := os.ReadFile(filepath.Join(filepath.Dir(card), "product_name")); err == nil && g.Name == "AMD GPU" {
+			if n := strings.TrimSpace(string(name)); n != "" {
+				g.Name = n
+			}
+		}
+	}
+	return g, g.VRAMMB > 0
+}
+
+// gpuCachePath is where init saves the GPU it found
+func gpuCachePath() (string, error) {
+	dir, err := config.GetCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gpu.json"), nil
+}
+
+// SaveGPU records the GPU for later runs, so they don't probe for it
+func SaveGPU(g GPU) error {
+	path, err := gpuCachePath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return err
+	}
+	return config.WriteFile(path, data)
+}
+
+var (
+	gpuOnce   sync.Once
+	gpuCached GPU
+)
+
+// cachedGPU returns the GPU init found, detecting it now if init hasn't
+func cachedGPU() GPU {
+	gpuOnce.Do(func() {
+		path, err := gpuCachePath()
+		if err != nil {
+			return
+		}
+		if data, err := os.ReadFile(path); err == nil && json.Unmarshal(data, &gpuCached) == nil {
+			return
+		}
+		gpuCached = DetectGPU(context.Background())
+		SaveGPU(gpuCached)
+	})
+	return gpuCached
+}
+
+// Inference is how llama-cli and llama-server run the model
+type Inference struct {
+	GPULayers int // layers offloaded to the GPU; 99 offloads them all
+	Threads   int
+	BatchSize int
+}
+
+// allLayers is more layers than any model cliq runs has, which llama.cpp
+// takes as all of them
+const allLayers = 99
+
+// inferenceOverrides are the settings from the config; a negative
+// GPULayers, or zero Threads or BatchSize, is chosen automatically
+var inferenceOverrides = Inference{GPULayers: -1}
+
+// SetInference overrides the tuned settings with the config's. A negative
+// gpuLayers, or zero threads or batchSize, keeps the tuned value.
+func SetInference(gpuLayers, threads, batchSize int) {
+	inferenceOverrides = Inference{GPULayers: gpuLayers, Threads: threads, BatchSize: batchSize}
+}
+
+// TuneInference picks settings for running a model of modelSize bytes and
+// layers layers on the GPU: every layer offloaded when the model fits in
+// its memory, with room for the context, and otherwise as many as fit. An
+// unknown layer count is taken as the 32 of a 7B model. Threads are the
+// physical cores, and batches are larger on a GPU, where prompt processing
+// is cheap.
+func TuneInference(g GPU, modelSize int64, layers int) Inference {
+	in := Inference{Threads: runtime.NumCPU(), BatchSize: 512}
+	if runtime.GOARCH == "amd64" && in.Threads > 1 {
+		// Count physical cores rather than hyperthreads
+		in.Threads /= 2
+	}
+
+	if g.Kind != "" {
+		in.BatchSize = 1024
+		// The context and compute buffers take about a gigabyte
+		usable := int64(g.VRAMMB-1024) << 20
+		switch {
+		case g.VRAMMB == 0 || modelSize == 0 || usable >= modelSize:
+			in.GPULayers = allLayers
+		case usable > 0:
+			if layers == 0 {
+				layers = 32
+			}
+			in.GPULayers = int(int64(layers) * usable / modelSize)
+		}
+	}
+	return in
+}
+
+// InferenceFor returns the settings llama-cli and llama-server run
+// modelPath with: tuned for this machine's GPU, with the config's overrides
+func InferenceFor(modelPath string) Inference {
+	var size int64
+	if info, err := os.Stat(modelPath); err == nil {
+		size = info.Size()
+	}
+	var layers int
+	if m := modelMetadata(modelPath); m != nil {
+		layers = m.BlockCount
+	}
+	in := TuneInference(cachedGPU(), size, layers)
+	o := inferenceOverrides
+	if o.GPULayers >= 0 {
+		in.GPULayers = o.GPULayers
+	}
+	if o.Threads > 0 {
+		in.Threads = o.Threads
+	}
+	if o.BatchSize > 0 {
+		in.BatchSize = o.BatchSize
+	}
+	return in
+}
+
+// Args returns the settings as llama-cli and llama-server flags
+func (in Inference) Args() []string {
+	return []string{
+		"-ngl", strconv.Itoa(in.GPULayers),
+		"-t", strconv.Itoa(in.Threads),
+		"-b", strconv.Itoa(in.BatchSize),
+	}
+}
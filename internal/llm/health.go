@@ -0,0 +1,211 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cliq-cli/cliq/internal/config"
+)
+
+const (
+	// healthWindow is how many recent queries per backend the error rate
+	// and latency are computed over
+	healthWindow = 20
+	// unhealthyErrors is how many of the last unhealthyOf queries must fail
+	// for a backend to be marked unhealthy
+	unhealthyErrors = 3
+	unhealthyOf     = 5
+	// CoolDown is how long an unhealthy backend is skipped before it's tried
+	// again
+	CoolDown = 5 * time.Minute
+)
+
+// healthSample is the outcome of one query
+type healthSample struct {
+	Time      time.Time `json:"time"`
+	OK        bool      `json:"ok"`
+	LatencyMS int64     `json:"latency_ms"`
+}
+
+// backendHealth is the recorded history of one backend
+type backendHealth struct {
+	Samples        []healthSample `json:"samples"`
+	UnhealthyUntil time.Time      `json:"unhealthy_until,omitempty"`
+	LastError      string         `json:"last_error,omitempty"`
+}
+
+// BackendHealth summarises a backend's recent queries
+type BackendHealth struct {
+	Backend   string
+	Queries   int
+	Errors    int
+	ErrorRate float64
+	// MedianLatency is over the successful queries
+	MedianLatency time.Duration
+	LastError     string
+	// UnhealthyUntil is set while the backend is cooling down
+	UnhealthyUntil time.Time
+}
+
+// Healthy reports whether the backend is usable now
+func (h BackendHealth) Healthy() bool {
+	return time.Now().After(h.UnhealthyUntil)
+}
+
+// healthMu serialises reads and writes of the health file within a process
+var healthMu sync.Mutex
+
+// healthKey names a backend for health tracking, dropping llama-cli's path
+// and naming a plugin as model.backend does
+func healthKey(backend string) string {
+	if IsPlugin(backend) {
+		return PluginName(backend)
+	}
+	name, _, _ := strings.Cut(backend, ":")
+	return name
+}
+
+// backendHealthy reports whether a backend is outside its cool-down
+func backendHealthy(backend string) bool {
+	healthMu.Lock()
+	defer healthMu.Unlock()
+
+	h := loadHealth()[healthKey(backend)]
+	return h == nil || time.Now().After(h.UnhealthyUntil)
+}
+
+// recordHealth records the outcome of a query on a backend, marking it
+// unhealthy for CoolDown once most of its latest queries have failed.
+// Cancelled queries say nothing about the backend and aren't recorded.
+func recordHealth(backend string, start time.Time, err error) {
+	if backend == "offline" || errors.Is(err, context.Canceled) {
+		return
+	}
+
+	healthMu.Lock()
+	defer healthMu.Unlock()
+
+	all := loadHealth()
+	key := healthKey(backend)
+	h := all[key]
+	if h == nil {
+		h = &backendHealth{}
+		all[key] = h
+	}
+
+	h.Samples = append(h.Samples, healthSample{
+		Time:      start,
+		OK:        err == nil,
+		LatencyMS: time.Since(start).Milliseconds(),
+	})
+	if len(h.Samples) > healthWindow {
+		h.Samples = h.Samples[len(h.Samples)-healthWindow:]
+	}
+
+	if err != nil {
+		h.LastError = err.Error()
+		failed := 0
+		recent := h.Samples[max(0, len(h.Samples)-unhealthyOf):]
+		for _, s := range recent {
+			if !s.OK {
+				failed++
+			}
+		}
+		if failed >= unhealthyErrors {
+			h.UnhealthyUntil = time.Now().Add(CoolDown)
+		}
+	} else {
+		h.UnhealthyUntil = time.Time{}
+	}
+
+	saveHealth(all)
+}
+
+// Health returns the recorded health of each backend that has been used,
+// by name
+func Health() []BackendHealth {
+	healthMu.Lock()
+	all := loadHealth()
+	healthMu.Unlock()
+
+	var report []BackendHealth
+	for name, h := range all {
+		r := BackendHealth{
+			Backend:        name,
+			Queries:        len(h.Samples),
+			LastError:      h.LastError,
+			UnhealthyUntil: h.UnhealthyUntil,
+		}
+		var latencies []int64
+		for _, s := range h.Samples {
+			if s.OK {
+				latencies = append(latencies, s.LatencyMS)
+			} else {
+				r.Errors++
+			}
+		}
+		if r.Queries > 0 {
+			r.ErrorRate = float64(r.Errors) / float64(r.Queries)
+		}
+		if len(latencies) > 0 {
+			sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+			r.MedianLatency = time.Duration(latencies[len(latencies)/2]) * time.Millisecond
+		}
+		report = append(report, r)
+	}
+	sort.Slice(report, func(i, j int) bool { return report[i].Backend < report[j].Backend })
+	return report
+}
+
+// ResetHealth forgets a backend's history, ending any cool-down
+func ResetHealth(backend string) error {
+	healthMu.Lock()
+	defer healthMu.Unlock()
+
+	all := loadHealth()
+	delete(all, healthKey(backend))
+	return saveHealth(all)
+}
+
+// loadHealth reads the health file; a missing or damaged file is an empty
+// history
+func loadHealth() map[string]*backendHealth {
+	all := map[string]*backendHealth{}
+	path, err := healthPath()
+	if err != nil {
+		return all
+	}
+	if data, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(data, &all)
+	}
+	return all
+}
+
+// saveHealth writes the health file
+func saveHealth(all map[string]*backendHealth) error {
+	path, err := healthPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(all, "", "  ")
+	if err != nil {
+		return err
+	}
+	return config.WriteFile(path, data)
+}
+
+// healthPath returns the path of the backend health file
+func healthPath() (string, error) {
+	dataDir, err := config.GetDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, "health.json"), nil
+}
@@ -0,0 +1,294 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/cliq-cli/cliq/internal/config"
+	"github.com/cliq-cli/cliq/internal/lockfile"
+)
+
+// managedIdle is how long the llama-server cliq manages may sit unused
+// before it's stopped; 0 means cliq doesn't manage one
+var managedIdle time.Duration
+
+// ManageServer has cliq start llama-server itself when it's installed and
+// no server is running, and keep it for later runs until it's been idle
+// for idle. An idle of 0 turns this off.
+func ManageServer(idle time.Duration) {
+	managedIdle = idle
+}
+
+// ManagedServer describes the llama-server cliq manages. The file it's
+// saved in is touched on every query, so its modification time is when the
+// server was last used.
+type ManagedServer struct {
+	// Pid is the supervising cliq process; ServerPid is llama-server's
+	Pid       int       `json:"pid"`
+	ServerPid int       `json:"server_pid"`
+	URL       string    `json:"url"`
+	Model     string    `json:"model"`
+	Started   time.Time `json:"started"`
+	LastUsed  time.Time `json:"-"`
+}
+
+// managedPaths returns the supervisor's pidfile and the state file
+func managedPaths() (pidfile, state string, err error) {
+	dir, err := config.GetCacheDir()
+	if err != nil {
+		return "", "", err
+	}
+	return filepath.Join(dir, "llama-server.pid"), filepath.Join(dir, "llama-server.json"), nil
+}
+
+// LoadManagedServer returns the managed llama-server, or nil if none is
+// running
+func LoadManagedServer() (*ManagedServer, error) {
+	pidfile, state, err := managedPaths()
+	if err != nil {
+		return nil, err
+	}
+	pid, err := lockfile.ReadPID(pidfile)
+	if err != nil || !lockfile.Alive(pid) {
+		return nil, nil
+	}
+	data, err := os.ReadFile(state)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var s ManagedServer
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(state); err == nil {
+		s.LastUsed = info.ModTime()
+	}
+	return &s, nil
+}
+
+// StopManagedServer asks the supervisor to stop llama-server and exit,
+// reporting whether one was running
+func StopManagedServer() (bool, error) {
+	pidfile, _, err := managedPaths()
+	if err != nil {
+		return false, err
+	}
+	pid, err := lockfile.ReadPID(pidfile)
+	if err != nil || !lockfile.Alive(pid) {
+		return false, nil
+	}
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return false, err
+	}
+	if err := proc.Signal(syscall.SIGTERM); err != nil {
+		if err := proc.Kill(); err != nil {
+			return false, err
+		}
+	}
+	for i := 0; i < 50 && lockfile.Alive(pid); i++ {
+		time.Sleep(100 * time.Millisecond)
+	}
+	return true, nil
+}
+
+// StartManagedServer starts the managed llama-server with modelPath, to
+// stop after idle unused, and returns its URL once it has loaded the model.
+// A server already running modelPath is reused.
+func StartManagedServer(ctx context.Context, modelPath string, idle time.Duration) (string, error) {
+	managedIdle = idle
+	return ensureManagedServer(ctx, modelPath)
+}
+
+// ensureManagedServer returns the URL of the managed llama-server running
+// modelPath, starting it if needed and waiting for it to load the model
+func ensureManagedServer(ctx context.Context, modelPath string) (string, error) {
+	s, err := LoadManagedServer()
+	if err != nil {
+		return "", err
+	}
+	if s != nil && s.Model != modelPath {
+		// Running another model: replace it
+		if _, err := StopManagedServer(); err != nil {
+			return "", fmt.Errorf("failed to stop the llama-server running %s: %w", filepath.Base(s.Model), err)
+		}
+		s = nil
+	}
+	if s == nil {
+		if err := startSupervisor(modelPath); err != nil {
+			return "", err
+		}
+	}
+
+	_, state, _ := managedPaths()
+	client := &http.Client{Timeout: time.Second}
+	deadline := time.Now().Add(sessionLoadTimeout)
+	for time.Now().Before(deadline) {
+		if s, err := LoadManagedServer(); err == nil && s != nil && s.URL != "" {
+			resp, err := client.Get(s.URL + "/health")
+			if err == nil {
+				resp.Body.Close()
+				if resp.StatusCode == http.StatusOK {
+					now := time.Now()
+					os.Chtimes(state, now, now)
+					return s.URL, nil
+				}
+			}
+		} else if s == nil && !supervisorStarting() {
+			return "", fmt.Errorf("llama-server exited while loading %s (see %s)", filepath.Base(modelPath), managedLogPath())
+		}
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(250 * time.Millisecond):
+		}
+	}
+	return "", fmt.Errorf("llama-server didn't load %s within %s", filepath.Base(modelPath), sessionLoadTimeout)
+}
+
+// supervisorStarting reports whether a supervisor holds the pidfile, though
+// it may not have written the state file yet
+func supervisorStarting() bool {
+	pidfile, _, err := managedPaths()
+	if err != nil {
+		return false
+	}
+	pid, err := lockfile.ReadPID(pidfile)
+	return err == nil && lockfile.Alive(pid)
+}
+
+// startSupervisor runs "cliq server run" in the background to start and
+// watch over llama-server. It outlives this process.
+func startSupervisor(modelPath string) error {
+	self, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(self, "server", "run", "--model", modelPath, "--idle", managedIdle.String())
+	cmd.SysProcAttr = detachedProcess()
+	if log, err := os.OpenFile(managedLogPath(), os.O_WRONLY|os.O_CREATE|os.O_APPEND, config.FilePerm); err == nil {
+		cmd.Stdout = log
+		cmd.Stderr = log
+		defer log.Close()
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start llama-server: %w", err)
+	}
+	// Not waited for: it runs on after this process exits
+	cmd.Process.Release()
+
+	// Give it a moment to take the pidfile, so the wait sees it starting
+	pidfile, _, _ := managedPaths()
+	for i := 0; i < 20; i++ {
+		if _, err := os.Stat(pidfile); err == nil {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return nil
+}
+
+// managedLogPath is the file the supervisor and llama-server log to
+func managedLogPath() string {
+	dir, _ := config.GetCacheDir()
+	return filepath.Join(dir, "llama-server.log")
+}
+
+// RunManagedServer starts llama-server with modelPath on a free port and
+// watches over it until it has been idle for idle, ctx is done, or it
+// exits. It's what "cliq server run" does.
+func RunManagedServer(ctx context.Context, modelPath string, idle time.Duration) error {
+	pidfile, state, err := managedPaths()
+	if err != nil {
+		return err
+	}
+	lock, err := lockfile.Acquire(pidfile)
+	if errors.Is(err, lockfile.ErrLocked) {
+		return fmt.Errorf("cliq already manages a llama-server: %w", err)
+	} else if err != nil {
+		return err
+	}
+	defer lock.Release()
+	defer os.Remove(state)
+
+	path, err := exec.LookPath("llama-server")
+	if err != nil {
+		return fmt.Errorf("llama-server not found in PATH")
+	}
+	port, err := freePort()
+	if err != nil {
+		return err
+	}
+
+	args := []string{"-m", modelPath, "--host", "127.0.0.1", "--port", fmt.Sprint(port), "-c", fmt.Sprint(FormatFor(modelPath).Context)}
+	server := exec.Command(path, append(args, InferenceFor(modelPath).Args()...)...)
+	server.Stdout = os.Stdout
+	server.Stderr = os.Stderr
+	if err := server.Start(); err != nil {
+		return fmt.Errorf("failed to start llama-server: %w", err)
+	}
+	exited := make(chan error, 1)
+	go func() { exited <- server.Wait() }()
+
+	s := ManagedServer{
+		Pid:       os.Getpid(),
+		ServerPid: server.Process.Pid,
+		URL:       fmt.Sprintf("http://127.0.0.1:%d", port),
+		Model:     modelPath,
+		Started:   time.Now(),
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err == nil {
+		err = config.WriteFile(state, data)
+	}
+	if err != nil {
+		server.Process.Kill()
+		return err
+	}
+	fmt.Printf("llama-server running %s on %s (pid %d)\n", filepath.Base(modelPath), s.URL, s.ServerPid)
+
+	// Check for idleness often enough that the server stops within about a
+	// tenth of the idle time after it
+	tick := idle / 10
+	if tick < time.Second {
+		tick = time.Second
+	}
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+	for {
+		select {
+		case err := <-exited:
+			return fmt.Errorf("llama-server exited: %v", err)
+		case <-ctx.Done():
+			fmt.Println("stopping llama-server")
+			server.Process.Kill()
+			<-exited
+			return nil
+		case <-ticker.C:
+			info, err := os.Stat(state)
+			if err != nil || time.Since(info.ModTime()) < idle {
+				continue
+			}
+			fmt.Printf("stopping llama-server after %s idle\n", idle)
+			server.Process.Kill()
+			<-exited
+			return nil
+		}
+	}
+}
+
+// isManaged reports whether the backend is the llama-server cliq manages
+func isManaged(backend string) bool {
+	return strings.HasPrefix(backend, "llama-server-managed:")
+}
@@ -0,0 +1,28 @@
+package llm
+
+import (
+	"fmt"
+
+	"github.com/cliq-cli/cliq/internal/knowledge"
+)
+
+// queryOffline answers from the built-in knowledge base when no LLM backend
+// is available
+func queryOffline(prompt string) (string, error) {
+	kb, err := knowledge.Load()
+	if err != nil {
+		return "", err
+	}
+
+	question := extractQuestion(prompt)
+	answer, ok := kb.Answer(question)
+	if !ok {
+		return "", fmt.Errorf("no LLM backend available and the offline knowledge base has no answer for %q.\n"+
+			"For full answers, install one of:\n"+
+			"  1. ollama (recommended): https://ollama.ai\n"+
+			"  2. llama.cpp server: https://github.com/ggerganov/llama.cpp\n"+
+			"  3. llama-cli from llama.cpp", question)
+	}
+
+	return answer, nil
+}
@@ -0,0 +1,342 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PluginPrefix starts the name of a backend plugin's binary: a plugin
+// called llamafile is cliq-backend-llamafile in PATH
+const PluginPrefix = "cliq-backend-"
+
+// PluginProtocol is the version of the protocol cliq speaks to plugins.
+// cliq runs the plugin for each request and writes the request to its
+// stdin as one line of JSON:
+//
+//	{"protocol": 1, "op": "info"}
+//	{"protocol": 1, "op": "generate", "prompt": "...", "model": "...",
+//	 "temperature": 0.7, "max_tokens": 512, "top_p": 0.9, "top_k": 40,
+//	 "seed": 1, "stream": true}
+//
+// The plugin answers on stdout with lines of JSON. To info:
+//
+//	{"type": "info", "models": ["..."], "remote": false}
+//
+// and to generate, tokens as they're generated when streaming, then the
+// whole answer, or an error:
+//
+//	{"type": "token", "text": "..."}
+//	{"type": "done", "text": "..."}
+//	{"type": "error", "error": "...", "transient": true}
+//
+// The prompt is sent as ollama gets it, with no chat template applied. A
+// transient error, such as a gateway being down, is retried like one from
+// a backend's API.
+const PluginProtocol = 1
+
+// pluginInfoTimeout bounds the info request that tells whether a plugin
+// can be used
+const pluginInfoTimeout = 2 * time.Second
+
+// builtinBackends are the names plugins can't take
+var builtinBackends = []string{"auto", "llama-server", "ollama", "llama-cli", "offline"}
+
+// pluginPolicy is the plugin model.backend names and model.plugin_model
+var pluginPolicy struct {
+	name, model string
+}
+
+// SetPlugin sets the backend plugin queries are sent to, by name, and the
+// model it's asked for ("" for the plugin's default). A built-in backend's
+// name leaves the backend detected as usual; plugins are then only used
+// when fallen back to or chosen.
+func SetPlugin(name, model string) {
+	if slices.Contains(builtinBackends, name) {
+		name = ""
+	}
+	pluginPolicy.name, pluginPolicy.model = name, model
+}
+
+// PluginConfigured reports whether queries are sent to a plugin
+func PluginConfigured() bool {
+	return pluginPolicy.name != ""
+}
+
+// Plugin is a backend plugin found in PATH
+type Plugin struct {
+	Name string
+	Path string
+}
+
+// PluginInfo is what a plugin says about itself
+type PluginInfo struct {
+	// Models are the models it can be asked for, the first its default
+	Models []string `json:"models"`
+	// Remote is whether it sends prompts off this machine
+	Remote bool `json:"remote"`
+}
+
+// FindPlugins returns the backend plugins in PATH, by name. One earlier in
+// PATH hides another of the same name, as for any command.
+func FindPlugins() []Plugin {
+	var plugins []Plugin
+	seen := map[string]bool{}
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if !strings.HasPrefix(e.Name(), PluginPrefix) {
+				continue
+			}
+			path, err := exec.LookPath(filepath.Join(dir, e.Name()))
+			if err != nil {
+				continue
+			}
+			name := PluginName(path)
+			if name == "" || seen[name] || slices.Contains(builtinBackends, name) {
+				continue
+			}
+			seen[name] = true
+			plugins = append(plugins, Plugin{Name: name, Path: path})
+		}
+	}
+	slices.SortFunc(plugins, func(a, b Plugin) int { return strings.Compare(a.Name, b.Name) })
+	return plugins
+}
+
+// PluginName returns the name of the plugin whose binary is at path, or
+// that a backend is
+func PluginName(path string) string {
+	base := strings.TrimSuffix(filepath.Base(strings.TrimPrefix(path, "plugin:")), ".exe")
+	return strings.TrimPrefix(base, PluginPrefix)
+}
+
+// IsPlugin reports whether a backend is a plugin
+func IsPlugin(backend string) bool {
+	return strings.HasPrefix(backend, "plugin:")
+}
+
+// pluginBackend returns the backend for the named plugin. One that isn't
+// in PATH is still returned, so querying it fails and is fallen back from
+// rather than another backend being used unasked.
+func pluginBackend(name string) string {
+	if path, err := exec.LookPath(PluginPrefix + name); err == nil {
+		return "plugin:" + path
+	}
+	return "plugin:" + PluginPrefix + name
+}
+
+// pluginInfos caches what each plugin said to info, by path, so it's asked
+// once a run
+var pluginInfos = struct {
+	sync.Mutex
+	found map[string]PluginInfo
+}{found: map[string]PluginInfo{}}
+
+// Info asks the plugin about itself, which also tells whether it works
+func (p Plugin) Info(ctx context.Context) (PluginInfo, error) {
+	pluginInfos.Lock()
+	info, ok := pluginInfos.found[p.Path]
+	pluginInfos.Unlock()
+	if ok {
+		return info, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, pluginInfoTimeout)
+	defer cancel()
+	err := runPlugin(ctx, p.Path, pluginRequest{Op: "info"}, func(msg pluginMessage) error {
+		if msg.Type == "info" {
+			info = PluginInfo{Models: msg.Models, Remote: msg.Remote}
+			ok = true
+		}
+		return nil
+	})
+	if err != nil {
+		return PluginInfo{}, err
+	}
+	if !ok {
+		return PluginInfo{}, fmt.Errorf("%s didn't answer info", p.Name)
+	}
+
+	pluginInfos.Lock()
+	pluginInfos.found[p.Path] = info
+	pluginInfos.Unlock()
+	return info, nil
+}
+
+// PluginError is an error a plugin reported
+type PluginError struct {
+	Plugin  string
+	Message string
+	// Transient is whether the plugin said a retry may succeed
+	Transient bool
+}
+
+func (e *PluginError) Error() string {
+	return e.Plugin + ": " + e.Message
+}
+
+// pluginRequest is the line of JSON a plugin reads from stdin
+type pluginRequest struct {
+	Protocol    int     `json:"protocol"`
+	Op          string  `json:"op"`
+	Prompt      string  `json:"prompt,omitempty"`
+	Model       string  `json:"model,omitempty"`
+	Temperature float64 `json:"temperature"`
+	MaxTokens   int     `json:"max_tokens,omitempty"`
+	TopP        float64 `json:"top_p,omitempty"`
+	TopK        int     `json:"top_k,omitempty"`
+	Seed        *int    `json:"seed,omitempty"`
+	Stream      bool    `json:"stream,omitempty"`
+}
+
+// pluginMessage is a line of JSON a plugin writes to stdout
+type pluginMessage struct {
+	Type      string   `json:"type"`
+	Text      string   `json:"text"`
+	Models    []string `json:"models"`
+	Remote    bool     `json:"remote"`
+	Error     string   `json:"error"`
+	Transient bool     `json:"transient"`
+}
+
+// runPlugin sends a request to the plugin at path and calls handle with
+// each message it answers, stopping at the first error handle returns. A
+// plugin that exits unsuccessfully is an error, with what it wrote to
+// stderr.
+func runPlugin(ctx context.Context, path string, req pluginRequest, handle func(pluginMessage) error) error {
+	req.Protocol = PluginProtocol
+	line, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, path)
+	cmd.Stdin = bytes.NewReader(append(line, '\n'))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to run backend plugin: %w", err)
+	}
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	var handleErr error
+	for handleErr == nil && scanner.Scan() {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		var msg pluginMessage
+		if err := json.Unmarshal(text, &msg); err != nil {
+			handleErr = fmt.Errorf("%s sent something that isn't JSON: %.80s", PluginName(path), text)
+			break
+		}
+		handleErr = handle(msg)
+	}
+	if handleErr != nil {
+		// Anything more the plugin writes would go unread
+		cmd.Process.Kill()
+	}
+	waitErr := cmd.Wait()
+
+	switch {
+	case ctx.Err() != nil:
+		return ctx.Err()
+	case handleErr != nil:
+		return handleErr
+	case waitErr != nil:
+		return fmt.Errorf("backend plugin %s failed: %w\nstderr: %s", PluginName(path), waitErr, stderr.String())
+	}
+	return nil
+}
+
+// queryPlugin asks the plugin at path to answer a prompt, calling onToken
+// with the answer as it's generated when onToken isn't nil
+func (c *Client) queryPlugin(ctx context.Context, path, prompt string, onToken TokenFunc) (string, error) {
+	req := pluginRequest{
+		Op:          "generate",
+		Prompt:      prompt,
+		Model:       c.pluginModel,
+		Temperature: c.temperature,
+		MaxTokens:   c.maxTokens,
+		TopP:        c.sampling.TopP,
+		TopK:        c.sampling.TopK,
+		Seed:        c.sampling.Seed,
+		Stream:      onToken != nil,
+	}
+
+	var sb strings.Builder
+	done := false
+	err := runPlugin(ctx, path, req, func(msg pluginMessage) error {
+		switch msg.Type {
+		case "token":
+			sb.WriteString(msg.Text)
+			if onToken != nil && msg.Text != "" {
+				onToken(msg.Text)
+			}
+		case "done":
+			// A plugin that doesn't stream sends the whole answer here
+			if sb.Len() == 0 {
+				sb.WriteString(msg.Text)
+				if onToken != nil && msg.Text != "" {
+					onToken(msg.Text)
+				}
+			}
+			done = true
+		case "error":
+			return &PluginError{Plugin: PluginName(path), Message: msg.Error, Transient: msg.Transient}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if !done {
+		return "", fmt.Errorf("%s exited without finishing its answer", PluginName(path))
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
+
+// transientPluginError reports whether err is a plugin saying a retry may
+// succeed
+func transientPluginError(err error) bool {
+	var pluginErr *PluginError
+	return errors.As(err, &pluginErr) && pluginErr.Transient
+}
+
+// pluginChoices returns a choice for each model of each plugin that
+// answers info, or one for its default model when it lists none
+func pluginChoices(ctx context.Context) []BackendChoice {
+	var choices []BackendChoice
+	for _, p := range FindPlugins() {
+		info, err := p.Info(ctx)
+		if err != nil {
+			continue
+		}
+		if len(info.Models) == 0 {
+			choices = append(choices, BackendChoice{Backend: "plugin:" + p.Path})
+		}
+		for _, model := range info.Models {
+			choices = append(choices, BackendChoice{Backend: "plugin:" + p.Path, Model: model})
+		}
+	}
+	return choices
+}
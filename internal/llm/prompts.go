@@ -2,9 +2,16 @@ package llm
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+	"unicode"
 
+	"github.com/cliq-cli/cliq/internal/keyboard"
+	"github.com/cliq-cli/cliq/internal/locale"
 	"github.com/cliq-cli/cliq/internal/parser"
+	"github.com/cliq-cli/cliq/internal/project"
+	"github.com/cliq-cli/cliq/internal/terminal"
+	"github.com/cliq-cli/cliq/internal/toolchain"
 )
 
 // SystemPrompt is the base system prompt for the LLM
@@ -82,6 +89,7 @@ Text processing:
 - sed 's/old/new/g' = replace all occurrences
 - sed -i '' 's/old/new/g' = in-place edit (macOS)
 - sed -i 's/old/new/g' = in-place edit (Linux)
+- (Get-Content f) -replace 'old','new' | Set-Content f = in-place edit (PowerShell on Windows)
 - cut -d',' -f2 = extract 2nd field with delimiter
 - sort | uniq = sort and remove duplicates
 - sort | uniq -c = count occurrences
@@ -230,7 +238,7 @@ Related: find . -type f (files only), find . -exec cmd {} \; (run command on eac
 Q: replace text in a file in place
 Command: sed -i '' 's/old/new/g' file.txt
 Explanation: -i '' edits in place (macOS syntax), s/old/new/g replaces all occurrences.
-Alternatives: sed -i 's/old/new/g' file.txt (Linux syntax, no '' needed)
+Alternatives: sed -i 's/old/new/g' file.txt (Linux syntax, no '' needed), (Get-Content file.txt) -replace 'old','new' | Set-Content file.txt (PowerShell)
 Related: sed 's/old/new/' (first occurrence only), sed -n '10,20p' (print lines 10-20)
 
 Q: count occurrences of each line
@@ -257,19 +265,230 @@ Explanation: jq is a JSON processor, .fieldname extracts that key from the JSON
 Alternatives: jq -r '.fieldname' (raw output, no quotes)
 Related: jq '.[]' (iterate array), jq '.users[].name' (nested extraction)`
 
+const (
+	// questionMarker introduces the user's question at the end of a prompt
+	questionMarker = "User Question: "
+	// responseMarker ends the prompt, cueing the model to answer
+	responseMarker = "\n\nResponse:"
+	// followUpMarker introduces the question a follow-up follows up on
+	followUpMarker = "\nThe user's question follows up on their previous one, which you answered:\n"
+)
+
+// PromptContext holds what is known about the user's setup for a prompt
+type PromptContext struct {
+	Nvim *parser.NvimConfig
+	Tmux *parser.TmuxConfig
+
+	// Shell holds the aliases and functions from the user's shell startup
+	// files
+	Shell *parser.ShellConfig
+
+	// Pinned items are always included, regardless of relevance
+	Pinned []string
+
+	// Learned holds the user's own answers to questions similar to this one
+	Learned []string
+
+	// References are the documentation excerpts retrieved for this question,
+	// which the answer cites as [1], [2], ...
+	References []Reference
+
+	// Layout is the user's keyboard layout, nil for US QWERTY
+	Layout *keyboard.Layout
+
+	// Terminal describes the terminal emulator and multiplexer in use
+	Terminal *terminal.Capabilities
+
+	// Style is the response style: concise (default), detailed, or minimal
+	Style string
+
+	// Locale is how the user's locale writes dates and numbers, nil for C
+	Locale *locale.Locale
+
+	// KeyNotation is how keybindings are written in answers: vim, ctrl,
+	// caret, or empty to keep the model's
+	KeyNotation string
+
+	// Project is the context pack of the project cliq is run in, if any
+	Project string
+
+	// Tasks are the project's Makefile, justfile, and package.json tasks
+	Tasks []project.Task
+
+	// Toolchain describes the version managers and version files in effect
+	Toolchain *toolchain.Toolchain
+
+	// Commands are what the user's hooks.before commands printed
+	Commands []CommandOutput
+
+	// FollowUp is the previous question and answer, when the question
+	// follows up on it
+	FollowUp *Exchange
+}
+
+// Exchange is a question the user asked and the answer they got
+type Exchange struct {
+	Query       string
+	Command     string
+	Explanation string
+}
+
+// CommandOutput is what a command printed
+type CommandOutput struct {
+	Command string
+	Output  string
+}
+
 // BuildPrompt constructs the full prompt including user configuration context
-func BuildPrompt(query string, nvimCfg *parser.NvimConfig, tmuxCfg *parser.TmuxConfig) string {
+func BuildPrompt(query string, pctx *PromptContext) string {
 	var sb strings.Builder
 
 	sb.WriteString(SystemPrompt)
 	sb.WriteString("\n\n")
 
+	if pctx == nil {
+		pctx = &PromptContext{}
+	}
+	nvimCfg, tmuxCfg := pctx.Nvim, pctx.Tmux
+
+	// Pinned context always goes in, ahead of anything relevance-scored
+	if len(pctx.Pinned) > 0 {
+		sb.WriteString("Always keep in mind about the user:\n")
+		for _, line := range pctx.Pinned {
+			sb.WriteString("- ")
+			sb.WriteString(line)
+			sb.WriteString("\n")
+		}
+		sb.WriteString("\n")
+	}
+
+	// User-taught answers take priority over the model's own knowledge
+	if len(pctx.Learned) > 0 {
+		sb.WriteString("The user has taught you these answers; prefer them when they fit the question:\n")
+		for _, line := range pctx.Learned {
+			sb.WriteString("- ")
+			sb.WriteString(line)
+			sb.WriteString("\n")
+		}
+		sb.WriteString("\n")
+	}
+
+	// Retrieved documentation grounds the answer; citing it lets the reader
+	// check the source
+	if len(pctx.References) > 0 {
+		sb.WriteString("Documentation excerpts. When a sentence of your explanation relies on one, end the sentence with its number in brackets, like [1]:\n")
+		for i, ref := range pctx.References {
+			sb.WriteString(fmt.Sprintf("[%d] (%s) %s\n", i+1, ref.Source, ref.Excerpt))
+		}
+		sb.WriteString("\n")
+	}
+
+	// A project's own notes answer "how do I ... here" questions
+	if pctx.Project != "" {
+		sb.WriteString("The user is working in a project whose maintainers describe it as follows; use it for questions about this project:\n")
+		sb.WriteString(pctx.Project)
+		sb.WriteString("\n\n")
+	}
+
+	// The user's own commands describe what cliq can't see, such as the
+	// cluster kubectl points at
+	if len(pctx.Commands) > 0 {
+		sb.WriteString("The user's environment right now, as these commands print it:\n")
+		for _, c := range pctx.Commands {
+			sb.WriteString("$ ")
+			sb.WriteString(c.Command)
+			sb.WriteString("\n")
+			sb.WriteString(c.Output)
+			sb.WriteString("\n")
+		}
+		sb.WriteString("\n")
+	}
+
+	// "How do I test this" is answered from the project's own tasks; the
+	// command is filled in from the task list afterwards, so the model only
+	// has to explain it
+	if best, others, ok := project.MatchTask(query, pctx.Tasks); ok {
+		sb.WriteString("This project defines these tasks; answer with them, not generic commands:\n")
+		for _, t := range append([]project.Task{best}, others...) {
+			sb.WriteString("- ")
+			sb.WriteString(t.String())
+			sb.WriteString("\n")
+		}
+		sb.WriteString("\n")
+	}
+
+	// The few-shot examples are concise, so only other styles need saying
+	switch pctx.Style {
+	case "detailed":
+		sb.WriteString("Style: give a thorough explanation, with an example and related commands.\n\n")
+	case "minimal":
+		sb.WriteString("Style: give only the command and a one-line explanation.\n\n")
+	}
+
+	// Keyboard layout changes which suggestions are ergonomic
+	if pctx.Layout != nil && pctx.Layout.Advice != "" {
+		sb.WriteString(fmt.Sprintf("Keyboard layout: %s. %s\n\n", pctx.Layout.Name, pctx.Layout.Advice))
+	}
+
+	// Terminal capabilities matter for key, color, and clipboard questions
+	if pctx.Terminal != nil && isTerminalQuery(query) {
+		sb.WriteString("User's Terminal:\n")
+		for _, line := range pctx.Terminal.Lines() {
+			sb.WriteString("- ")
+			sb.WriteString(line)
+			sb.WriteString("\n")
+		}
+		sb.WriteString("\n")
+	}
+
+	// Version managers decide which node or python actually runs
+	if pctx.Toolchain != nil && !pctx.Toolchain.IsEmpty() && isToolchainQuery(query) {
+		sb.WriteString("User's Toolchain:\n")
+		for _, line := range pctx.Toolchain.Lines() {
+			sb.WriteString("- ")
+			sb.WriteString(line)
+			sb.WriteString("\n")
+		}
+		sb.WriteString("\n")
+	}
+
+	// Aliases the user already has may answer the question outright, and
+	// commands have to work in the shell they actually run
+	aliases := pctx.Shell.RelevantAliases(query, 5)
+	functions := pctx.Shell.RelevantFunctions(query, 3)
+	if len(aliases) > 0 || len(functions) > 0 || (pctx.Shell != nil && pctx.Shell.Name != "") {
+		sb.WriteString("User's Shell:\n")
+		if name := pctx.Shell.Name; name == "fish" {
+			sb.WriteString("- Shell: fish. Give commands in fish syntax, not bash syntax.\n")
+		} else if name == "powershell" {
+			sb.WriteString("- Shell: PowerShell on Windows. Give PowerShell commands (Get-ChildItem for ls and find, Select-String for grep, Get-Content for cat and tail) unless a Unix tool is asked for.\n")
+		} else if name != "" {
+			sb.WriteString(fmt.Sprintf("- Shell: %s\n", name))
+		}
+		for _, a := range aliases {
+			sb.WriteString(fmt.Sprintf("- alias %s='%s'\n", a.Name, a.Expansion))
+		}
+		for _, f := range functions {
+			sb.WriteString(fmt.Sprintf("- function %s\n", f.Name))
+		}
+		if len(aliases) > 0 || len(functions) > 0 {
+			sb.WriteString("If one of these already does what is asked, say so, e.g. \"you already have `gs` aliased to `git status`\".\n")
+		}
+		sb.WriteString("\n")
+	}
+
 	// Add configuration context if available
 	if nvimCfg != nil || tmuxCfg != nil {
 		sb.WriteString("User's Configuration:\n")
 
 		if nvimCfg != nil {
 			sb.WriteString(fmt.Sprintf("- Leader key: %s\n", formatLeaderKey(nvimCfg.Leader)))
+			if nvimCfg.LocalLeader != "" {
+				sb.WriteString(fmt.Sprintf("- Local leader key: %s\n", formatLeaderKey(nvimCfg.LocalLeader)))
+			}
+			if nvimCfg.Distro != "" {
+				sb.WriteString(fmt.Sprintf("- Distribution: %s (keymaps marked [%s] are its defaults, which the user hasn't changed)\n", nvimCfg.Distro, nvimCfg.Distro))
+			}
 
 			if len(nvimCfg.Plugins) > 0 {
 				sb.WriteString("- Detected plugins: ")
@@ -281,6 +500,9 @@ func BuildPrompt(query string, nvimCfg *parser.NvimConfig, tmuxCfg *parser.TmuxC
 				}
 				sb.WriteString(strings.Join(plugins, ", "))
 				sb.WriteString("\n")
+				if managers := nvimCfg.PluginManagers(); len(managers) > 0 {
+					sb.WriteString(fmt.Sprintf("- Plugin manager: %s\n", strings.Join(managers, ", ")))
+				}
 			}
 
 			// Add relevant keymaps (limit to avoid token overflow)
@@ -288,10 +510,48 @@ func BuildPrompt(query string, nvimCfg *parser.NvimConfig, tmuxCfg *parser.TmuxC
 			if len(relevantKeymaps) > 0 {
 				sb.WriteString("- Custom keymaps:\n")
 				for _, km := range relevantKeymaps {
-					sb.WriteString(fmt.Sprintf("  [%s] %s -> %s", km.Mode, km.Lhs, km.Rhs))
+					sb.WriteString(fmt.Sprintf("  [%s] %s -> %s", km.Mode, keymapKeys(km), km.Rhs))
 					if km.Description != "" {
 						sb.WriteString(fmt.Sprintf(" (%s)", km.Description))
 					}
+					if km.Plugin != "" {
+						sb.WriteString(fmt.Sprintf(" [%s]", km.Plugin))
+					}
+					sb.WriteString("\n")
+				}
+			}
+
+			// Options the question names, so "is relativenumber on?" has
+			// an answer
+			if options := findRelevantOptionsForQuery(query, nvimCfg.Options, 10); len(options) > 0 {
+				sb.WriteString("- Options set in config (others are at Neovim's defaults):\n")
+				for _, name := range options {
+					sb.WriteString(fmt.Sprintf("  %s = %s\n", name, nvimCfg.Options[name]))
+				}
+			}
+
+			if commands := findRelevantUserCommandsForQuery(query, nvimCfg.UserCommands, 5); len(commands) > 0 {
+				sb.WriteString("- Custom commands:\n")
+				for _, uc := range commands {
+					sb.WriteString(fmt.Sprintf("  :%s -> %s", uc.Name, uc.Command))
+					if uc.Description != "" {
+						sb.WriteString(fmt.Sprintf(" (%s)", uc.Description))
+					}
+					sb.WriteString("\n")
+				}
+			}
+
+			if autocmds := findRelevantAutocmdsForQuery(query, nvimCfg.Autocmds, 5); len(autocmds) > 0 {
+				sb.WriteString("- Autocommands:\n")
+				for _, ac := range autocmds {
+					pattern := ac.Pattern
+					if pattern == "" {
+						pattern = "*"
+					}
+					sb.WriteString(fmt.Sprintf("  %s %s -> %s", strings.Join(ac.Events, ","), pattern, ac.Command))
+					if ac.Description != "" {
+						sb.WriteString(fmt.Sprintf(" (%s)", ac.Description))
+					}
 					sb.WriteString("\n")
 				}
 			}
@@ -299,32 +559,175 @@ func BuildPrompt(query string, nvimCfg *parser.NvimConfig, tmuxCfg *parser.TmuxC
 
 		if tmuxCfg != nil {
 			sb.WriteString(fmt.Sprintf("- Tmux prefix: %s\n", tmuxCfg.Prefix))
+			if tmuxCfg.Session != nil && strings.Contains(strings.ToLower(query), "tmux") {
+				sb.WriteString(fmt.Sprintf("- Current tmux %s\n", tmuxCfg.Session.Describe()))
+			}
+
+			if len(tmuxCfg.Plugins) > 0 {
+				plugins := make([]string, 0, len(tmuxCfg.Plugins))
+				for _, p := range tmuxCfg.Plugins {
+					if p.About != "" && strings.Contains(strings.ToLower(query), "tmux") {
+						plugins = append(plugins, fmt.Sprintf("%s (%s)", p.Name, p.About))
+					} else {
+						plugins = append(plugins, p.Name)
+					}
+				}
+				sb.WriteString(fmt.Sprintf("- Tmux plugins: %s\n", strings.Join(plugins, ", ")))
+			}
 
 			// Add relevant tmux keymaps
 			if strings.Contains(strings.ToLower(query), "tmux") && len(tmuxCfg.Keymaps) > 0 {
 				sb.WriteString("- Custom tmux bindings:\n")
-				count := 0
-				for _, km := range tmuxCfg.Keymaps {
-					if count >= 5 {
-						break
+				for _, km := range findRelevantTmuxKeymapsForQuery(query, tmuxCfg.Keymaps, 5) {
+					key := km.Key
+					switch km.Table {
+					case "prefix":
+					case "root":
+						key += " (no prefix)"
+					default:
+						key += fmt.Sprintf(" (%s)", km.Table)
+					}
+					sb.WriteString(fmt.Sprintf("  %s -> %s", key, km.Command))
+					if km.Description != "" && km.Plugin != "" {
+						sb.WriteString(fmt.Sprintf(" (%s)", km.Description))
+					}
+					if km.Condition != "" {
+						sb.WriteString(fmt.Sprintf(" (only if %s)", km.Condition))
 					}
-					sb.WriteString(fmt.Sprintf("  %s -> %s\n", km.Key, km.Command))
-					count++
+					sb.WriteString("\n")
 				}
 			}
 		}
 
-		sb.WriteString("\nWhen relevant, mention the user's custom keybindings in your response.\n")
+		sb.WriteString("\nWhen relevant, mention the user's custom keybindings and commands in your response.\n")
+	}
+
+	// A follow-up such as "what about for a visual selection" only makes
+	// sense next to what it follows up on
+	if f := pctx.FollowUp; f != nil {
+		sb.WriteString(followUpMarker)
+		sb.WriteString("Previous question: ")
+		sb.WriteString(f.Query)
+		sb.WriteString("\n")
+		if f.Command != "" {
+			sb.WriteString("Command: ")
+			sb.WriteString(f.Command)
+			sb.WriteString("\n")
+		}
+		if f.Explanation != "" {
+			sb.WriteString("Explanation: ")
+			sb.WriteString(f.Explanation)
+			sb.WriteString("\n")
+		}
+		sb.WriteString("Answer the new question in light of it.\n")
 	}
 
 	sb.WriteString("\n")
-	sb.WriteString("User Question: ")
+	sb.WriteString(questionMarker)
 	sb.WriteString(query)
-	sb.WriteString("\n\nResponse:")
+	sb.WriteString(responseMarker)
 
 	return sb.String()
 }
 
+// keymapKeys returns the keys a keymap is pressed with, its lhs with the
+// leader resolved when it has been
+func keymapKeys(km parser.Keymap) string {
+	if km.Keys != "" {
+		return km.Keys
+	}
+	return km.Lhs
+}
+
+// keptExamples is how many few-shot examples fitPrompt keeps while it
+// drops the fundamentals sections
+const keptExamples = 3
+
+// systemPromptCuts are the parts of SystemPrompt fitPrompt drops, in
+// order: the later examples, the fundamentals sections, which the model
+// mostly knows anyway, then the first examples. The rules and response
+// format are always kept.
+var systemPromptCuts = func() []string {
+	var examples []string
+	start := strings.Index(SystemPrompt, "=== EXAMPLES ===")
+	body := SystemPrompt[start:]
+	for {
+		i := strings.Index(body, "\nQ: ")
+		if i < 0 {
+			break
+		}
+		end := strings.Index(body[i+1:], "\nQ: ")
+		if end < 0 {
+			examples = append(examples, body[i:])
+			break
+		}
+		examples = append(examples, body[i:i+1+end])
+		body = body[i+1+end:]
+	}
+
+	var cuts []string
+	for i := len(examples) - 1; i >= keptExamples; i-- {
+		cuts = append(cuts, examples[i])
+	}
+	for _, heading := range []string{"=== UNIX SHELL FUNDAMENTALS ===", "=== TMUX FUNDAMENTALS ===", "=== VIM/NEOVIM FUNDAMENTALS ==="} {
+		i := strings.Index(SystemPrompt, heading)
+		end := strings.Index(SystemPrompt[i+len(heading):], "=== ")
+		cuts = append(cuts, SystemPrompt[i:i+len(heading)+end])
+	}
+	for i := min(keptExamples, len(examples)) - 1; i >= 0; i-- {
+		cuts = append(cuts, examples[i])
+	}
+	return cuts
+}()
+
+// fitPrompt shortens a prompt built by BuildPrompt to about budget tokens
+// by dropping parts of SystemPrompt, keeping the user's context and
+// question whole
+func fitPrompt(prompt string, budget int) string {
+	over := EstimateTokens(prompt) - budget
+	if over <= 0 || !strings.HasPrefix(prompt, SystemPrompt) {
+		return prompt
+	}
+	system := SystemPrompt
+	for _, cut := range systemPromptCuts {
+		if over <= 0 {
+			break
+		}
+		system = strings.Replace(system, cut, "", 1)
+		over -= EstimateTokens(cut)
+	}
+	return system + prompt[len(SystemPrompt):]
+}
+
+// trimContext shortens a prompt built by BuildPrompt to about budget tokens
+// by cutting off the end of the user's context, for when dropping parts of
+// SystemPrompt with fitPrompt wasn't enough. The question, and the one it
+// follows up on, are kept whole.
+func trimContext(prompt string, budget int) string {
+	over := EstimateTokens(prompt) - budget
+	end := strings.LastIndex(prompt, questionMarker)
+	if over <= 0 || end < 0 {
+		return prompt
+	}
+	if i := strings.LastIndex(prompt[:end], followUpMarker); i >= 0 {
+		end = i
+	}
+	context := prompt[:end]
+	context = strings.ToValidUTF8(context[:len(context)-min(over*4+4, len(context))], "")
+	return context + "…\n" + prompt[end:]
+}
+
+// extractQuestion recovers the user's question from a prompt built by BuildPrompt
+func extractQuestion(prompt string) string {
+	idx := strings.LastIndex(prompt, questionMarker)
+	if idx < 0 {
+		return prompt
+	}
+	question := prompt[idx+len(questionMarker):]
+	question = strings.TrimSuffix(question, responseMarker)
+	return strings.TrimSpace(question)
+}
+
 // formatLeaderKey formats the leader key for display
 func formatLeaderKey(leader string) string {
 	switch leader {
@@ -356,12 +759,14 @@ func findRelevantKeymapsForQuery(query string, keymaps []parser.Keymap, limit in
 
 		desc := strings.ToLower(km.Description)
 		rhs := strings.ToLower(km.Rhs)
-		lhs := strings.ToLower(km.Lhs)
+		lhs := strings.ToLower(km.Lhs + " " + km.Keys)
+		plugin := strings.ToLower(km.Plugin)
 
 		for _, keyword := range keywords {
 			if strings.Contains(desc, keyword) ||
 				strings.Contains(rhs, keyword) ||
-				strings.Contains(lhs, keyword) {
+				strings.Contains(lhs, keyword) ||
+				strings.Contains(plugin, keyword) {
 				relevant = append(relevant, km)
 				break
 			}
@@ -371,6 +776,148 @@ func findRelevantKeymapsForQuery(query string, keymaps []parser.Keymap, limit in
 	return relevant
 }
 
+// findRelevantTmuxKeymapsForQuery returns up to limit tmux bindings, those
+// matching the query's keywords first. "tmux" itself is in every plugin's
+// name, so it doesn't count.
+func findRelevantTmuxKeymapsForQuery(query string, keymaps []parser.TmuxKeymap, limit int) []parser.TmuxKeymap {
+	var keywords []string
+	for _, keyword := range extractQueryKeywords(strings.ToLower(query)) {
+		if len(keyword) >= 3 && keyword != "tmux" {
+			keywords = append(keywords, keyword)
+		}
+	}
+
+	var relevant, rest []parser.TmuxKeymap
+	for _, km := range keymaps {
+		text := strings.ToLower(km.Description + " " + km.Command + " " + km.Plugin)
+		match := false
+		for _, keyword := range keywords {
+			if strings.Contains(text, keyword) {
+				match = true
+				break
+			}
+		}
+		if match {
+			relevant = append(relevant, km)
+		} else {
+			rest = append(rest, km)
+		}
+	}
+
+	relevant = append(relevant, rest...)
+	if len(relevant) > limit {
+		relevant = relevant[:limit]
+	}
+	return relevant
+}
+
+// optionStopWords are query words too common to pick out options by, like
+// the "show" in showmode
+var optionStopWords = map[string]bool{
+	"show": true, "line": true, "mode": true, "have": true, "what": true,
+	"when": true, "with": true, "file": true, "make": true, "does": true,
+}
+
+// findRelevantOptionsForQuery returns the names of the options the query
+// mentions, in full ("relativenumber") or by a word of their name ("tabs"
+// for tabstop and expandtab); every option if the query asks about options
+// or settings
+func findRelevantOptionsForQuery(query string, options map[string]string, limit int) []string {
+	query = strings.ToLower(query)
+	compact := strings.NewReplacer(" ", "", "-", "", "_", "").Replace(query)
+	all := strings.Contains(query, "option") || strings.Contains(query, "setting")
+
+	var words []string
+	for _, word := range strings.FieldsFunc(query, func(r rune) bool { return r < 'a' || r > 'z' }) {
+		if len(word) >= 4 && !optionStopWords[word] {
+			words = append(words, strings.TrimSuffix(word, "s"))
+		}
+	}
+
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var relevant []string
+	for _, name := range names {
+		if len(relevant) >= limit {
+			break
+		}
+		match := all || len(name) >= 4 && strings.Contains(compact, name)
+		for _, word := range words {
+			if strings.HasPrefix(name, word) || strings.HasSuffix(name, word) {
+				match = true
+			}
+		}
+		if match {
+			relevant = append(relevant, name)
+		}
+	}
+	return relevant
+}
+
+// findRelevantUserCommandsForQuery finds user commands the query names, like
+// :Format for "how do I format this file", or whose description or command
+// matches its keywords
+func findRelevantUserCommandsForQuery(query string, commands []parser.UserCommand, limit int) []parser.UserCommand {
+	query = strings.ToLower(query)
+	keywords := extractQueryKeywords(query)
+	all := strings.Contains(query, "command")
+
+	var relevant []parser.UserCommand
+	for _, uc := range commands {
+		if len(relevant) >= limit {
+			break
+		}
+		name := strings.ToLower(uc.Name)
+		text := strings.ToLower(uc.Description + " " + uc.Command)
+		match := all || len(name) >= 3 && strings.Contains(query, name)
+		for _, keyword := range keywords {
+			if len(keyword) >= 3 && strings.Contains(text, keyword) {
+				match = true
+			}
+		}
+		if match {
+			relevant = append(relevant, uc)
+		}
+	}
+	return relevant
+}
+
+// findRelevantAutocmdsForQuery finds autocommands on events the query names,
+// or whose description or command matches its keywords; every one if the
+// query asks about autocommands or what happens automatically
+func findRelevantAutocmdsForQuery(query string, autocmds []parser.Autocmd, limit int) []parser.Autocmd {
+	query = strings.ToLower(query)
+	keywords := extractQueryKeywords(query)
+	all := strings.Contains(query, "autocmd") || strings.Contains(query, "autocommand") || strings.Contains(query, "automatic")
+
+	var relevant []parser.Autocmd
+	for _, ac := range autocmds {
+		if len(relevant) >= limit {
+			break
+		}
+		text := strings.ToLower(ac.Description + " " + ac.Command)
+		match := all
+		for _, event := range ac.Events {
+			if strings.Contains(query, strings.ToLower(event)) {
+				match = true
+			}
+		}
+		for _, keyword := range keywords {
+			if len(keyword) >= 3 && strings.Contains(text, keyword) {
+				match = true
+			}
+		}
+		if match {
+			relevant = append(relevant, ac)
+		}
+	}
+	return relevant
+}
+
 // extractQueryKeywords extracts relevant keywords from the query
 func extractQueryKeywords(query string) []string {
 	// Map of query terms to vim/tmux/unix keywords
@@ -394,6 +941,7 @@ func extractQueryKeywords(query string) []string {
 		"lsp":        {"lsp", "diagnostic", "definition", "reference", "hover"},
 		"telescope":  {"telescope", "find_files", "grep", "fuzzy"},
 		"comment":    {"comment", "gcc", "gc"},
+		"format":     {"format", "formatter", "conform", "prettier"},
 		"indent":     {"indent", ">>", "<<", "="},
 		"visual":     {"visual", "v", "V", "select"},
 		"tmux":       {"tmux", "prefix", "pane", "session"},
@@ -436,3 +984,85 @@ func extractQueryKeywords(query string) []string {
 
 	return keywords
 }
+
+// terminalKeywords mark questions whose answer depends on the terminal stack
+var terminalKeywords = []string{
+	"ctrl", "shift", "alt", "meta", "modifier", "key", "color", "colour",
+	"truecolor", "24-bit", "256", "clipboard", "copy", "paste", "yank", "osc",
+	"terminal", "term", "emulator", "kitty", "wezterm", "alacritty", "iterm",
+	"ghostty", "undercurl", "italic", "escape",
+}
+
+// toolchainWords mark questions about tool versions and PATH, whose answer
+// depends on the version managers in use. They are whole words, and name
+// the managers and runtimes themselves rather than words like "version" or
+// "path" that vim and tmux questions use as well.
+var toolchainWords = map[string]bool{
+	"shim": true, "shims": true, "direnv": true, ".envrc": true, "envrc": true,
+	"mise": true, "asdf": true, ".tool-versions": true, "tool-versions": true,
+	"nvm": true, "fnm": true, "volta": true, "pyenv": true, "rbenv": true,
+	"goenv": true, "toolchain": true, "node": true, "npm": true,
+	"python": true, "python3": true, "pip": true, "ruby": true,
+}
+
+// isToolchainQuery reports whether the query is likely affected by version
+// managers and PATH layering
+func isToolchainQuery(query string) bool {
+	query = strings.ToLower(query)
+	if strings.Contains(query, "$path") {
+		return true
+	}
+	words := strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '.' && r != '-'
+	})
+	for _, w := range words {
+		if toolchainWords[strings.TrimRight(w, ".-")] {
+			return true
+		}
+	}
+	return false
+}
+
+// isTerminalQuery reports whether the query is likely affected by terminal capabilities
+func isTerminalQuery(query string) bool {
+	query = strings.ToLower(query)
+	for _, kw := range terminalKeywords {
+		if strings.Contains(query, kw) {
+			return true
+		}
+	}
+	return false
+}
+
+// nvimWords and tmuxWords name one tool so clearly that a question using
+// them, and none of the other's, doesn't need the other's config
+var (
+	nvimWords = map[string]bool{
+		"vim": true, "nvim": true, "neovim": true, "lua": true, "lazyvim": true,
+		"nvchad": true, "astronvim": true, "lunarvim": true, "telescope": true,
+		"treesitter": true, "lsp": true, "leader": true, "buffer": true,
+		"buffers": true, "quickfix": true, "netrw": true, "vimrc": true,
+	}
+	tmuxWords = map[string]bool{
+		"tmux": true, "pane": true, "panes": true, "prefix": true, "tpm": true,
+		"tmux.conf": true, "detach": true, "attach": true, "byobu": true,
+	}
+)
+
+// ConfigNeeds reports which configs a question may need as context. Both
+// are needed unless the question names only one of the two tools, so a
+// question about neither still gets both.
+func ConfigNeeds(query string) (nvim, tmux bool) {
+	words := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '.'
+	})
+	for _, w := range words {
+		w = strings.TrimRight(w, ".")
+		nvim = nvim || nvimWords[w]
+		tmux = tmux || tmuxWords[w]
+	}
+	if nvim == tmux {
+		return true, true
+	}
+	return nvim, tmux
+}
@@ -0,0 +1,35 @@
+package llm
+
+import (
+	"github.com/cliq-cli/cliq/internal/knowledge"
+)
+
+// maxReferences is how many knowledge base entries are retrieved per question
+const maxReferences = 3
+
+// Reference is a knowledge base entry retrieved for a question, which the
+// answer can cite by its number
+type Reference struct {
+	// Source is the :help tag or man page the entry is based on
+	Source string
+	// Excerpt is the entry's command and explanation
+	Excerpt string
+}
+
+// Retrieve returns the knowledge base entries that confidently match the
+// query and name their source, best match first
+func Retrieve(query string) []Reference {
+	kb, err := knowledge.Load()
+	if err != nil {
+		return nil
+	}
+
+	var refs []Reference
+	for _, m := range kb.Search(query, maxReferences) {
+		if m.Score < knowledge.MinScore || m.Entry.Source == "" {
+			continue
+		}
+		refs = append(refs, Reference{Source: m.Entry.Source, Excerpt: m.Entry.Excerpt(query)})
+	}
+	return refs
+}
@@ -0,0 +1,50 @@
+package llm
+
+import "strconv"
+
+// SamplingOptions are the sampling settings beyond the temperature and
+// token limit. Zero TopP and TopK keep the backend's defaults, and a nil
+// Seed samples at random; with a seed, the same prompt to the same model
+// gets the same answer.
+type SamplingOptions struct {
+	TopP float64
+	TopK int
+	Seed *int
+}
+
+// WithSamplingOptions returns a copy of the client that samples with the
+// options, leaving the original untouched
+func (c *Client) WithSamplingOptions(o SamplingOptions) *Client {
+	clone := *c
+	clone.sampling = o
+	return &clone
+}
+
+// addSampling sets the options in a request to llama-server, or in the
+// options of one to ollama, which name them alike
+func (c *Client) addSampling(params map[string]interface{}) {
+	if c.sampling.TopP > 0 {
+		params["top_p"] = c.sampling.TopP
+	}
+	if c.sampling.TopK > 0 {
+		params["top_k"] = c.sampling.TopK
+	}
+	if c.sampling.Seed != nil {
+		params["seed"] = *c.sampling.Seed
+	}
+}
+
+// samplingArgs returns the options as llama-cli flags
+func (c *Client) samplingArgs() []string {
+	var args []string
+	if c.sampling.TopP > 0 {
+		args = append(args, "--top-p", strconv.FormatFloat(c.sampling.TopP, 'g', -1, 64))
+	}
+	if c.sampling.TopK > 0 {
+		args = append(args, "--top-k", strconv.Itoa(c.sampling.TopK))
+	}
+	if c.sampling.Seed != nil {
+		args = append(args, "--seed", strconv.Itoa(*c.sampling.Seed))
+	}
+	return args
+}
@@ -0,0 +1,254 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cliq-cli/cliq/internal/config"
+)
+
+// sessionLoadTimeout bounds how long the warm llama-server may take to load
+// the model
+const sessionLoadTimeout = 3 * time.Minute
+
+// ollamaKeepAlive is how long ollama keeps the model loaded after a query
+// in a warm session; its own default is five minutes
+const ollamaKeepAlive = "30m"
+
+// session is a llama-server child process that keeps the model loaded
+// between queries, so each one doesn't pay for loading it as llama-cli does
+type session struct {
+	cmd   *exec.Cmd
+	url   string
+	ready chan struct{} // closed once the server answers /health
+	done  chan struct{} // closed once the process has exited
+
+	mu  sync.Mutex
+	err error // why the server stopped, or never started
+}
+
+// StartSession keeps the model loaded between queries, for interactive
+// mode. For llama-cli, it starts llama-server on a free local port in the
+// background; queries use llama-cli until the server has loaded the model,
+// then the server. For ollama, queries ask ollama to keep the model loaded,
+// and it's loaded now rather than on the first question. Close stops the
+// session.
+func (c *Client) StartSession(ctx context.Context) error {
+	switch {
+	case c.backend == "ollama":
+		c.keepAlive = ollamaKeepAlive
+		go c.preloadOllama(ctx)
+		return nil
+	case isManaged(c.backend):
+		// The managed llama-server outlives the session; start it now so
+		// the first question doesn't wait for the model to load
+		go ensureManagedServer(ctx, c.modelPath)
+		return nil
+	case !c.usesLocalModel():
+		// llama-server already keeps its model loaded, and offline has none
+		return nil
+	}
+
+	path, err := exec.LookPath("llama-server")
+	if err != nil {
+		return fmt.Errorf("llama-server not found in PATH, so each query loads the model with llama-cli")
+	}
+	port, err := freePort()
+	if err != nil {
+		return err
+	}
+
+	s := &session{
+		url:   fmt.Sprintf("http://127.0.0.1:%d", port),
+		ready: make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	args := []string{
+		"-m", c.modelPath,
+		"--host", "127.0.0.1",
+		"--port", fmt.Sprint(port),
+		"-c", fmt.Sprint(FormatFor(c.modelPath).Context),
+	}
+	s.cmd = exec.Command(path, append(args, InferenceFor(c.modelPath).Args()...)...)
+	if log, err := sessionLog(); err == nil {
+		s.cmd.Stdout = log
+		s.cmd.Stderr = log
+	}
+	if err := s.cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start llama-server: %w", err)
+	}
+	c.session = s
+
+	go func() {
+		err := s.cmd.Wait()
+		s.fail(fmt.Errorf("llama-server exited: %v", err))
+		close(s.done)
+	}()
+	go s.waitReady(ctx)
+	return nil
+}
+
+// usesLocalModel reports whether the backend runs the model file itself,
+// loading it for every query
+func (c *Client) usesLocalModel() bool {
+	return strings.HasPrefix(c.backend, "llama-cli:") || strings.HasPrefix(c.backend, "llama-server-start:")
+}
+
+// warm returns a copy of the client that queries the warm llama-server,
+// once the session's server has loaded the model. With llama-server-start,
+// which has no llama-cli to fall back on, it waits for the model to load.
+func (c *Client) warm(ctx context.Context) (*Client, error) {
+	if isManaged(c.backend) {
+		url, err := ensureManagedServer(ctx, c.modelPath)
+		if err != nil {
+			return nil, err
+		}
+		clone := *c
+		clone.backend = "llama-server"
+		clone.serverURL = url
+		return &clone, nil
+	}
+
+	s := c.session
+	if s == nil || !c.usesLocalModel() {
+		return nil, nil
+	}
+	if strings.HasPrefix(c.backend, "llama-server-start:") {
+		select {
+		case <-s.ready:
+		case <-s.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	select {
+	case <-s.ready:
+		if s.failed() != nil {
+			return nil, nil
+		}
+		clone := *c
+		clone.backend = "llama-server"
+		clone.serverURL = s.url
+		clone.session = nil
+		return &clone, nil
+	default:
+		return nil, nil
+	}
+}
+
+// waitReady polls the server until it has loaded the model
+func (s *session) waitReady(ctx context.Context) {
+	client := &http.Client{Timeout: time.Second}
+	deadline := time.Now().Add(sessionLoadTimeout)
+	for time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			s.stop()
+			return
+		case <-s.done:
+			return
+		case <-time.After(250 * time.Millisecond):
+		}
+		// llama-server answers 503 while the model loads
+		resp, err := client.Get(s.url + "/health")
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				close(s.ready)
+				return
+			}
+		}
+	}
+	s.fail(fmt.Errorf("llama-server didn't load the model within %s", sessionLoadTimeout))
+	s.stop()
+}
+
+// stop kills the server and waits for it to exit
+func (s *session) stop() {
+	if s.cmd.Process != nil {
+		s.cmd.Process.Kill()
+	}
+	<-s.done
+}
+
+func (s *session) fail(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+func (s *session) failed() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// SessionStatus describes the warm session, or returns "" without one
+func (c *Client) SessionStatus() string {
+	if c.keepAlive != "" {
+		return "ollama keeps the model loaded"
+	}
+	s := c.session
+	if s == nil {
+		return ""
+	}
+	select {
+	case <-s.ready:
+		if err := s.failed(); err != nil {
+			return err.Error()
+		}
+		return "model loaded in llama-server"
+	case <-s.done:
+		return s.failed().Error()
+	default:
+		return "loading the model into llama-server..."
+	}
+}
+
+// preloadOllama asks ollama to load the model, so the first question
+// doesn't wait for it
+func (c *Client) preloadOllama(ctx context.Context) {
+	body := fmt.Sprintf(`{"model":%q,"keep_alive":%q}`, c.ollamaModel, c.keepAlive)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.serverURL+"/api/generate", strings.NewReader(body))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	authorize(req, "ollama")
+	if resp, err := http.DefaultClient.Do(req); err == nil {
+		resp.Body.Close()
+	}
+}
+
+// freePort finds a local TCP port nothing listens on
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+// sessionLog opens the file the warm llama-server logs to
+func sessionLog() (*os.File, error) {
+	dir, err := config.GetCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, config.DirPerm); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(filepath.Join(dir, "llama-server.log"), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, config.FilePerm)
+}
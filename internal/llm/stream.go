@@ -0,0 +1,279 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// TokenFunc is called with each chunk of text as the backend generates it
+type TokenFunc func(token string)
+
+// QueryStream sends a prompt to the LLM and calls onToken for every generated
+// chunk. The full response is returned once generation has finished.
+func (c *Client) QueryStream(prompt string, onToken TokenFunc) (string, error) {
+	return c.QueryStreamContext(context.Background(), prompt, onToken)
+}
+
+// QueryStreamContext is like QueryStream but stops generation when ctx is
+// cancelled. A failed backend is retried or fallen back from like in
+// QueryContext, until part of an answer has been streamed.
+func (c *Client) QueryStreamContext(ctx context.Context, prompt string, onToken TokenFunc) (string, error) {
+	streamed := false
+	return c.withFallback(ctx, prompt, func(client *Client) (string, error) {
+		return client.queryStream(ctx, prompt, func(token string) {
+			streamed = true
+			onToken(token)
+		})
+	}, func() bool { return !streamed })
+}
+
+// queryStream streams a prompt's answer from the active backend
+func (c *Client) queryStream(ctx context.Context, prompt string, onToken TokenFunc) (string, error) {
+	if warm, err := c.warm(ctx); err != nil {
+		return "", err
+	} else if warm != nil {
+		return warm.queryStream(ctx, prompt, onToken)
+	}
+
+	switch {
+	case c.backend == "llama-server":
+		return c.streamLlamaServer(ctx, prompt, onToken)
+	case c.backend == "ollama":
+		return c.streamOllama(ctx, prompt, onToken)
+	case strings.HasPrefix(c.backend, "llama-cli:"):
+		path := strings.TrimPrefix(c.backend, "llama-cli:")
+		return c.streamLlamaCLI(ctx, path, prompt, onToken)
+	case IsPlugin(c.backend):
+		return c.queryPlugin(ctx, strings.TrimPrefix(c.backend, "plugin:"), prompt, onToken)
+	default:
+		// Backends without streaming support deliver the whole answer at once
+		resp, err := c.query(ctx, prompt)
+		if err != nil {
+			return "", err
+		}
+		onToken(resp)
+		return resp, nil
+	}
+}
+
+// streamLlamaServer streams tokens from the llama.cpp server API (server-sent events)
+func (c *Client) streamLlamaServer(ctx context.Context, prompt string, onToken TokenFunc) (string, error) {
+	prompt, answer, stop := c.preparePrompt(prompt)
+	reqBody := map[string]interface{}{
+		"prompt":      prompt,
+		"n_predict":   answer,
+		"temperature": c.temperature,
+		"stop":        serverStops(stop),
+		"stream":      true,
+	}
+	c.addSampling(reqBody)
+
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.serverURL+"/completion", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	authorize(req, "llama-server")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("llama-server request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if err := statusError("llama-server", resp); err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+
+		var chunk struct {
+			Content string `json:"content"`
+			Stop    bool   `json:"stop"`
+		}
+		if err := json.Unmarshal([]byte(strings.TrimSpace(strings.TrimPrefix(line, "data:"))), &chunk); err != nil {
+			return "", fmt.Errorf("failed to parse response: %w", err)
+		}
+
+		if chunk.Content != "" {
+			sb.WriteString(chunk.Content)
+			onToken(chunk.Content)
+		}
+		if chunk.Stop {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("llama-server stream interrupted: %w", err)
+	}
+
+	return strings.TrimSpace(sb.String()), nil
+}
+
+// streamOllama streams tokens from the Ollama API (newline-delimited JSON)
+func (c *Client) streamOllama(ctx context.Context, prompt string, onToken TokenFunc) (string, error) {
+	model := c.ollamaModel
+
+	reqBody := map[string]interface{}{
+		"model":  model,
+		"prompt": prompt,
+		"stream": true,
+		"options": map[string]interface{}{
+			"temperature": c.temperature,
+			"num_predict": c.maxTokens,
+		},
+	}
+	c.addSampling(reqBody["options"].(map[string]interface{}))
+	if c.keepAlive != "" {
+		reqBody["keep_alive"] = c.keepAlive
+	}
+
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.serverURL+"/api/generate", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	authorize(req, "ollama")
+
+	client := &http.Client{Timeout: 120 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("ollama request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 404 {
+		return "", fmt.Errorf("model '%s' not found in ollama. Pull it with: ollama pull %s", model, model)
+	}
+	if err := statusError("ollama", resp); err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	decoder := json.NewDecoder(resp.Body)
+
+	for {
+		var chunk struct {
+			Response string `json:"response"`
+			Done     bool   `json:"done"`
+			Error    string `json:"error"`
+		}
+		if err := decoder.Decode(&chunk); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return "", fmt.Errorf("failed to parse response: %w", err)
+		}
+
+		if chunk.Error != "" {
+			return "", fmt.Errorf("ollama error: %s", chunk.Error)
+		}
+		if chunk.Response != "" {
+			sb.WriteString(chunk.Response)
+			onToken(chunk.Response)
+		}
+		if chunk.Done {
+			break
+		}
+	}
+
+	return strings.TrimSpace(sb.String()), nil
+}
+
+// streamLlamaCLI streams stdout of the llama.cpp CLI as it is produced
+func (c *Client) streamLlamaCLI(ctx context.Context, llamaPath, prompt string, onToken TokenFunc) (string, error) {
+	prompt, answer, _ := c.preparePrompt(prompt)
+	args := []string{
+		"-m", c.modelPath,
+		"-p", prompt,
+		"-n", fmt.Sprintf("%d", answer),
+		"--temp", fmt.Sprintf("%.2f", c.temperature),
+		"--no-display-prompt",
+		"-c", fmt.Sprint(FormatFor(c.modelPath).Context),
+	}
+	args = append(args, c.samplingArgs()...)
+	args = append(args, InferenceFor(c.modelPath).Args()...)
+
+	cmd := exec.CommandContext(ctx, llamaPath, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return "", err
+	}
+	if err := cmd.Start(); err != nil {
+		return "", fmt.Errorf("llama inference failed: %w", err)
+	}
+
+	var sb strings.Builder
+	buf := make([]byte, 256)
+	for {
+		n, readErr := stdout.Read(buf)
+		if n > 0 {
+			chunk := string(buf[:n])
+			sb.WriteString(chunk)
+			onToken(chunk)
+		}
+		if readErr != nil {
+			break
+		}
+	}
+
+	if err := cmd.Wait(); err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return "", fmt.Errorf("llama inference failed: %w\nstderr: %s", err, stderr.String())
+	}
+
+	return strings.TrimSpace(sb.String()), nil
+}
+
+// EstimateTokens approximates the number of tokens in a piece of text.
+// Backends that stream one token per chunk don't need this, but llama-cli
+// and plugin output arrives in arbitrary chunks, and backends that don't
+// stream deliver the whole answer as one.
+func EstimateTokens(text string) int {
+	// ~4 characters per token is the usual rule of thumb for English text
+	n := (len(text) + 3) / 4
+	if n == 0 && text != "" {
+		n = 1
+	}
+	return n
+}
+
+// CountTokens returns how many tokens a streamed chunk represents for the
+// active backend
+func (c *Client) CountTokens(chunk string) int {
+	// ollama and llama-server stream one token per chunk
+	if c.backend == "ollama" || c.backend == "llama-server" {
+		return 1
+	}
+	return EstimateTokens(chunk)
+}
@@ -0,0 +1,261 @@
+package llm
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cliq-cli/cliq/internal/config"
+	"github.com/cliq-cli/cliq/internal/gguf"
+)
+
+// ChatTemplate wraps a prompt in the turn markers a model was trained on.
+// llama-cli and llama-server's /completion take the prompt as raw text, so
+// without them an instruction-tuned model sees no question to answer.
+type ChatTemplate struct {
+	Name string
+	// Format formats the system text and the user's message
+	Format func(system, user string) string
+	// Stop is the token that ends the model's turn
+	Stop string
+}
+
+// chatTemplates are the templates cliq knows, each with the marker that
+// identifies it in a GGUF's tokenizer.chat_template
+var chatTemplates = []struct {
+	marker   string
+	template ChatTemplate
+}{
+	{"<|start_header_id|>", ChatTemplate{
+		Name: "llama3",
+		Format: func(system, user string) string {
+			return "<|begin_of_text|><|start_header_id|>system<|end_header_id|>\n\n" + system + "<|eot_id|>" +
+				"<|start_header_id|>user<|end_header_id|>\n\n" + user + "<|eot_id|>" +
+				"<|start_header_id|>assistant<|end_header_id|>\n\n"
+		},
+		Stop: "<|eot_id|>",
+	}},
+	{"<|im_start|>", ChatTemplate{
+		Name: "chatml",
+		Format: func(system, user string) string {
+			return "<|im_start|>system\n" + system + "<|im_end|>\n" +
+				"<|im_start|>user\n" + user + "<|im_end|>\n" +
+				"<|im_start|>assistant\n"
+		},
+		Stop: "<|im_end|>",
+	}},
+	{"<start_of_turn>", ChatTemplate{
+		Name: "gemma",
+		// Gemma has no system turn
+		Format: func(system, user string) string {
+			return "<start_of_turn>user\n" + system + "\n\n" + user + "<end_of_turn>\n<start_of_turn>model\n"
+		},
+		Stop: "<end_of_turn>",
+	}},
+	{"<|assistant|>", ChatTemplate{
+		Name: "phi3",
+		Format: func(system, user string) string {
+			return "<|system|>\n" + system + "<|end|>\n<|user|>\n" + user + "<|end|>\n<|assistant|>\n"
+		},
+		Stop: "<|end|>",
+	}},
+	{"[INST]", ChatTemplate{
+		Name: "mistral",
+		// Mistral has no system turn either; it goes ahead of the question
+		Format: func(system, user string) string {
+			return "<s>[INST] " + system + "\n\n" + user + " [/INST]"
+		},
+		Stop: "</s>",
+	}},
+}
+
+// architectureTemplates name the template of models whose GGUF has none
+var architectureTemplates = map[string]string{
+	"phi3":   "phi3",
+	"gemma":  "gemma",
+	"gemma2": "gemma",
+	"gemma3": "gemma",
+	"qwen2":  "chatml",
+}
+
+// templateNamed returns the template with the name, or nil
+func templateNamed(name string) *ChatTemplate {
+	for _, t := range chatTemplates {
+		if t.template.Name == name {
+			return &t.template
+		}
+	}
+	return nil
+}
+
+// detectTemplate picks the template for the model, or nil to send prompts
+// raw
+func detectTemplate(m *gguf.Metadata) *ChatTemplate {
+	if m == nil {
+		return nil
+	}
+	for _, t := range chatTemplates {
+		if strings.Contains(m.ChatTemplate, t.marker) {
+			return &t.template
+		}
+	}
+	return templateNamed(architectureTemplates[m.Architecture])
+}
+
+// defaultContext is the context window when the model's is unknown, and
+// maxContext caps it: longer windows cost memory for no gain on prompts
+// this size
+const (
+	defaultContext = 4096
+	maxContext     = 8192
+)
+
+// promptOverrides are model.chat_template and model.context_length
+var promptOverrides struct {
+	template string
+	context  int
+}
+
+// SetPromptFormat overrides the chat template and context window read from
+// the model. A template of "" or auto uses the model's, and raw sends
+// prompts unformatted; a context of 0 uses the model's.
+func SetPromptFormat(template string, contextLength int) {
+	promptOverrides.template = template
+	promptOverrides.context = contextLength
+}
+
+// ModelFormat is how cliq prompts a GGUF model
+type ModelFormat struct {
+	Metadata *gguf.Metadata // nil when the file couldn't be read
+	Template *ChatTemplate  // nil to prompt raw
+	Context  int            // the context window cliq runs the model with
+}
+
+// FormatFor returns how to prompt the model at modelPath, from its GGUF
+// metadata and the config's overrides
+func FormatFor(modelPath string) ModelFormat {
+	f := ModelFormat{Metadata: modelMetadata(modelPath), Context: defaultContext}
+	switch name := promptOverrides.template; name {
+	case "", "auto":
+		f.Template = detectTemplate(f.Metadata)
+	case "raw":
+	default:
+		// An unknown name falls back to the model's own
+		if f.Template = templateNamed(name); f.Template == nil {
+			f.Template = detectTemplate(f.Metadata)
+		}
+	}
+
+	if f.Metadata != nil && f.Metadata.ContextLength > 0 {
+		f.Context = min(f.Metadata.ContextLength, maxContext)
+	}
+	if promptOverrides.context > 0 {
+		f.Context = promptOverrides.context
+	}
+	return f
+}
+
+// ggufCacheEntry is a model's metadata, valid while the file is unchanged
+type ggufCacheEntry struct {
+	Size     int64          `json:"size"`
+	ModTime  time.Time      `json:"mod_time"`
+	Metadata *gguf.Metadata `json:"metadata"`
+}
+
+var (
+	metadataMu    sync.Mutex
+	metadataCache = map[string]*gguf.Metadata{}
+)
+
+// modelMetadata reads the model's GGUF header, or nil if it can't be read.
+// Reading it means skipping past the vocabulary, so it's kept on disk until
+// the file changes.
+func modelMetadata(modelPath string) *gguf.Metadata {
+	metadataMu.Lock()
+	defer metadataMu.Unlock()
+	if m, ok := metadataCache[modelPath]; ok {
+		return m
+	}
+
+	info, err := os.Stat(modelPath)
+	if err != nil {
+		return nil
+	}
+	var cached map[string]ggufCacheEntry
+	cachePath := ""
+	if dir, err := config.GetCacheDir(); err == nil {
+		cachePath = filepath.Join(dir, "gguf.json")
+		if data, err := os.ReadFile(cachePath); err == nil {
+			json.Unmarshal(data, &cached)
+		}
+	}
+	if e, ok := cached[modelPath]; ok && e.Size == info.Size() && e.ModTime.Equal(info.ModTime()) {
+		metadataCache[modelPath] = e.Metadata
+		return e.Metadata
+	}
+
+	m, err := gguf.Read(modelPath)
+	if err != nil {
+		m = nil
+	}
+	metadataCache[modelPath] = m
+	if cachePath != "" {
+		if cached == nil {
+			cached = map[string]ggufCacheEntry{}
+		}
+		cached[modelPath] = ggufCacheEntry{Size: info.Size(), ModTime: info.ModTime(), Metadata: m}
+		if data, err := json.MarshalIndent(cached, "", "  "); err == nil {
+			config.WriteFile(cachePath, data)
+		}
+	}
+	return m
+}
+
+// minAnswerTokens is the least room left for the answer when a long prompt
+// crowds the context window; the user's context is trimmed to make it
+const minAnswerTokens = 128
+
+// preparePrompt fits a prompt built by BuildPrompt into the model's context
+// window, leaving room for the answer, and wraps it in the model's chat
+// template. It returns the prompt, how many tokens the answer may have, and
+// the template's stop token, if any.
+func (c *Client) preparePrompt(prompt string) (string, int, string) {
+	f := FormatFor(c.modelPath)
+	answer := min(c.maxTokens, f.Context/2)
+	prompt = fitPrompt(prompt, f.Context-answer)
+	if f.Context-EstimateTokens(prompt) < minAnswerTokens {
+		prompt = trimContext(prompt, f.Context-minAnswerTokens)
+	}
+	// Only a question too long for the context leaves less than
+	// minAnswerTokens now, and the backend reports that
+	answer = min(answer, max(f.Context-EstimateTokens(prompt), minAnswerTokens))
+
+	if f.Template == nil {
+		return prompt, answer, ""
+	}
+	system, user := splitPrompt(prompt)
+	return f.Template.Format(system, user), answer, f.Template.Stop
+}
+
+// serverStops are the stop strings for llama-server: the markers of a
+// model running on past its answer, and the template's end of turn
+func serverStops(stop string) []string {
+	stops := []string{"\n\nUser:", "\n\nQuestion:", "```\n\n"}
+	if stop != "" {
+		stops = append(stops, stop)
+	}
+	return stops
+}
+
+// splitPrompt separates a prompt built by BuildPrompt into its system text
+// and the user's question
+func splitPrompt(prompt string) (system, user string) {
+	idx := strings.LastIndex(prompt, questionMarker)
+	if idx < 0 {
+		return "", prompt
+	}
+	return strings.TrimSpace(prompt[:idx]), extractQuestion(prompt)
+}
@@ -0,0 +1,282 @@
+// Package locale formats dates, times, and numbers the way the user's
+// locale (LC_TIME, LC_NUMERIC, LANG) writes them, so examples in answers
+// match what their own commands print.
+package locale
+
+import (
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Locale describes how the user's locale writes dates and numbers
+type Locale struct {
+	// Name is the locale as set in the environment, e.g. de_DE.UTF-8
+	Name string
+
+	// DateLayout and TimeLayout are Go layouts for date(1)'s %x and %X
+	DateLayout string
+	TimeLayout string
+
+	// Decimal and Thousands are the number separators
+	Decimal   string
+	Thousands string
+}
+
+// dateLayouts are the %x layouts of common locales, by language_REGION and
+// then by language
+var dateLayouts = map[string]string{
+	"en_US": "01/02/2006",
+	"en_CA": "2006-01-02",
+	"en_AU": "02/01/06",
+	"en":    "02/01/2006",
+	"de":    "02.01.2006",
+	"fr":    "02/01/2006",
+	"fr_CA": "2006-01-02",
+	"es":    "02/01/06",
+	"it":    "02/01/2006",
+	"pt":    "02/01/2006",
+	"nl":    "02-01-2006",
+	"sv":    "2006-01-02",
+	"da":    "02-01-2006",
+	"nb":    "02.01.2006",
+	"fi":    "02.01.2006",
+	"pl":    "02.01.2006",
+	"cs":    "02.01.2006",
+	"ru":    "02.01.2006",
+	"uk":    "02.01.06",
+	"tr":    "02-01-2006",
+	"ja":    "2006年01月02日",
+	"zh":    "2006年01月02日",
+	"ko":    "2006년 01월 02일",
+}
+
+// commaDecimal are the languages that write 1,5 rather than 1.5
+var commaDecimal = map[string]string{
+	// language: thousands separator
+	"de": ".", "es": ".", "it": ".", "pt": ".", "nl": ".", "da": ".", "tr": ".",
+	"fr": " ", "ru": " ", "uk": " ", "pl": " ", "cs": " ", "sv": " ", "nb": " ", "fi": " ",
+}
+
+// Detect reads the locale from the environment. It returns nil for the C
+// and POSIX locales, or when none is set, since those have no preferences
+// worth applying.
+func Detect() *Locale {
+	timeName := envLocale("LC_TIME")
+	if timeName == "" {
+		return nil
+	}
+	numName := envLocale("LC_NUMERIC")
+	if numName == "" {
+		numName = timeName
+	}
+
+	loc := &Locale{
+		Name:       timeName,
+		DateLayout: "01/02/2006",
+		TimeLayout: "15:04:05",
+		Decimal:    ".",
+		Thousands:  ",",
+	}
+
+	lang, region := split(timeName)
+	if layout, ok := dateLayouts[lang+"_"+region]; ok {
+		loc.DateLayout = layout
+	} else if layout, ok := dateLayouts[lang]; ok {
+		loc.DateLayout = layout
+	}
+	if lang == "en" && region == "US" {
+		loc.TimeLayout = "03:04:05 PM"
+	}
+
+	numLang, _ := split(numName)
+	if thousands, ok := commaDecimal[numLang]; ok {
+		loc.Decimal, loc.Thousands = ",", thousands
+	}
+	return loc
+}
+
+// envLocale returns the locale in effect for a category, following POSIX
+// precedence: LC_ALL, then the category, then LANG
+func envLocale(category string) string {
+	for _, name := range []string{"LC_ALL", category, "LANG"} {
+		if v := os.Getenv(name); v != "" {
+			if v == "C" || v == "POSIX" || strings.HasPrefix(v, "C.") {
+				return ""
+			}
+			return v
+		}
+	}
+	return ""
+}
+
+// split splits a locale name like de_DE.UTF-8@euro into language and region
+func split(name string) (lang, region string) {
+	name, _, _ = strings.Cut(name, ".")
+	name, _, _ = strings.Cut(name, "@")
+	lang, region, _ = strings.Cut(name, "_")
+	return strings.ToLower(lang), strings.ToUpper(region)
+}
+
+// Date formats a date the way the locale's %x does
+func (l *Locale) Date(t time.Time) string {
+	return t.Format(l.DateLayout)
+}
+
+// Number formats a decimal number with the locale's separators
+func (l *Locale) Number(s string) string {
+	intPart, frac, hasFrac := strings.Cut(s, ".")
+	if len(intPart) > 4 {
+		var sb strings.Builder
+		for i, r := range intPart {
+			if i > 0 && (len(intPart)-i)%3 == 0 {
+				sb.WriteString(l.Thousands)
+			}
+			sb.WriteRune(r)
+		}
+		intPart = sb.String()
+	}
+	if hasFrac {
+		return intPart + l.Decimal + frac
+	}
+	return intPart
+}
+
+var (
+	// 2024-01-15, not inside a longer run of digits or dashes
+	isoDateRe = regexp.MustCompile(`\b(\d{4})-(\d{2})-(\d{2})\b`)
+	// 1.5 GB, 2.25MiB, 100 KB
+	sizeRe = regexp.MustCompile(`\b(\d+(?:\.\d+)?)(\s?)([KMGTP]i?B|bytes)\b`)
+	// `code` spans, which are left as written
+	codeSpanRe = regexp.MustCompile("`[^`]*`")
+)
+
+// Prose rewrites the ISO dates and sizes in explanatory text to the
+// locale's notation, leaving `code` spans alone since they may be typed
+func (l *Locale) Prose(text string) string {
+	if l == nil || text == "" {
+		return text
+	}
+
+	var sb strings.Builder
+	last := 0
+	for _, loc := range codeSpanRe.FindAllStringIndex(text, -1) {
+		sb.WriteString(l.prose(text[last:loc[0]]))
+		sb.WriteString(text[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	sb.WriteString(l.prose(text[last:]))
+	return sb.String()
+}
+
+// prose rewrites a piece of text without code spans
+func (l *Locale) prose(text string) string {
+	text = isoDateRe.ReplaceAllStringFunc(text, func(match string) string {
+		t, err := time.Parse("2006-01-02", match)
+		if err != nil {
+			return match
+		}
+		return l.Date(t)
+	})
+	return sizeRe.ReplaceAllStringFunc(text, func(match string) string {
+		m := sizeRe.FindStringSubmatch(match)
+		return l.Number(m[1]) + m[2] + m[3]
+	})
+}
+
+// Strftime formats t like date(1) with a +FORMAT, for the conversions that
+// commonly appear in examples. Unknown conversions are kept as written.
+func (l *Locale) Strftime(format string, t time.Time) string {
+	var sb strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' || i == len(format)-1 {
+			sb.WriteByte(format[i])
+			continue
+		}
+		i++
+		switch format[i] {
+		case 'Y':
+			sb.WriteString(strconv.Itoa(t.Year()))
+		case 'y':
+			sb.WriteString(t.Format("06"))
+		case 'm':
+			sb.WriteString(t.Format("01"))
+		case 'd':
+			sb.WriteString(t.Format("02"))
+		case 'e':
+			sb.WriteString(t.Format("_2"))
+		case 'H':
+			sb.WriteString(t.Format("15"))
+		case 'I':
+			sb.WriteString(t.Format("03"))
+		case 'M':
+			sb.WriteString(t.Format("04"))
+		case 'S':
+			sb.WriteString(t.Format("05"))
+		case 'p':
+			sb.WriteString(t.Format("PM"))
+		case 'F':
+			sb.WriteString(t.Format("2006-01-02"))
+		case 'T':
+			sb.WriteString(t.Format("15:04:05"))
+		case 'R':
+			sb.WriteString(t.Format("15:04"))
+		case 'D':
+			sb.WriteString(t.Format("01/02/06"))
+		case 'x':
+			sb.WriteString(l.Date(t))
+		case 'X':
+			sb.WriteString(t.Format(l.TimeLayout))
+		case 'b', 'h':
+			sb.WriteString(t.Format("Jan"))
+		case 'B':
+			sb.WriteString(t.Format("January"))
+		case 'a':
+			sb.WriteString(t.Format("Mon"))
+		case 'A':
+			sb.WriteString(t.Format("Monday"))
+		case 'j':
+			sb.WriteString(t.Format("002"))
+		case 'Z':
+			sb.WriteString(t.Format("MST"))
+		case 'z':
+			sb.WriteString(t.Format("-0700"))
+		case 's':
+			sb.WriteString(strconv.FormatInt(t.Unix(), 10))
+		case 'n':
+			sb.WriteByte('\n')
+		case 't':
+			sb.WriteByte('\t')
+		case '%':
+			sb.WriteByte('%')
+		default:
+			sb.WriteByte('%')
+			sb.WriteByte(format[i])
+		}
+	}
+	return sb.String()
+}
+
+// dateCommandRe finds a date invocation with a +FORMAT argument
+var dateCommandRe = regexp.MustCompile(`(?:^|[\s;|&(])date\b[^|;&]*?\s\+(?:'([^']*)'|"([^"]*)"|(\S+))`)
+
+// DateExample returns what a date +FORMAT command prints right now in the
+// user's locale, or "" when command has none. localized reports whether the
+// format uses the locale's own date or time notation.
+func (l *Locale) DateExample(command string) (example string, localized bool) {
+	if l == nil {
+		return "", false
+	}
+	m := dateCommandRe.FindStringSubmatch(command)
+	if m == nil {
+		return "", false
+	}
+	format := m[1] + m[2] + m[3]
+	if !strings.Contains(format, "%") {
+		return "", false
+	}
+	localized = strings.Contains(format, "%x") || strings.Contains(format, "%X")
+	return l.Strftime(format, time.Now()), localized
+}
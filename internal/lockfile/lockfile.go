@@ -0,0 +1,147 @@
+package lockfile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/cliq-cli/cliq/internal/config"
+)
+
+// ErrLocked is returned by Acquire when a live process holds the lock
+var ErrLocked = errors.New("locked by another process")
+
+// writeGrace is how long a new lock may go without a pid in it; the pid is
+// written right after the file is created, so a younger empty lock is
+// still being taken rather than left behind
+const writeGrace = 5 * time.Second
+
+// Lock is a pidfile held by the current process
+type Lock struct {
+	path string
+}
+
+// Acquire takes the lock at path by writing this process's pid to it.
+// A lock left behind by a process that is no longer running is reclaimed.
+// When several processes find the same stale lock, only one of them
+// removes it, and none removes a lock another has just taken in its place.
+func Acquire(path string) (*Lock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), config.DirPerm); err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; attempt < 3; attempt++ {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, config.FilePerm)
+		if err == nil {
+			_, err = fmt.Fprintf(f, "%d\n", os.Getpid())
+			f.Close()
+			if err != nil {
+				os.Remove(path)
+				return nil, err
+			}
+			return &Lock{path: path}, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+
+		if pid, held := holder(path); held {
+			return nil, fmt.Errorf("%w (pid %d)", ErrLocked, pid)
+		}
+		pid, held, err := reclaim(path)
+		if err != nil {
+			return nil, err
+		}
+		if held {
+			return nil, fmt.Errorf("%w (pid %d)", ErrLocked, pid)
+		}
+	}
+
+	return nil, fmt.Errorf("could not acquire lock %s", path)
+}
+
+// holder reports whether the lock at path is held, and by which pid. A lock
+// that is still being written counts as held.
+func holder(path string) (int, bool) {
+	pid, err := ReadPID(path)
+	if err == nil {
+		return pid, Alive(pid)
+	}
+	info, statErr := os.Stat(path)
+	return 0, statErr == nil && time.Since(info.ModTime()) < writeGrace
+}
+
+// reclaim removes the stale lock at path. Only the process that creates
+// the path.reclaim guard may remove it, and it checks the lock again once
+// it has the guard, so a lock another process has reclaimed and taken in
+// the meantime is left alone. It reports whether the lock is held after
+// all, or being reclaimed by another process, and by which pid.
+func reclaim(path string) (int, bool, error) {
+	guard := path + ".reclaim"
+	f, err := os.OpenFile(guard, os.O_WRONLY|os.O_CREATE|os.O_EXCL, config.FilePerm)
+	if err != nil {
+		if !os.IsExist(err) {
+			return 0, false, err
+		}
+		if pid, held := holder(guard); held {
+			return pid, true, nil
+		}
+		// left behind by a process that crashed while reclaiming
+		os.Remove(guard)
+		return 0, false, nil
+	}
+	fmt.Fprintf(f, "%d\n", os.Getpid())
+	f.Close()
+	defer os.Remove(guard)
+
+	if pid, held := holder(path); held {
+		return pid, true, nil
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return 0, false, err
+	}
+	return 0, false, nil
+}
+
+// Release removes the lock
+func (l *Lock) Release() error {
+	if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// ReadPID reads the pid stored in a lock or pidfile
+func ReadPID(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return 0, fmt.Errorf("invalid pid in %s", path)
+	}
+	return pid, nil
+}
+
+// Alive reports whether a process with the given pid is running
+func Alive(pid int) bool {
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	// Windows has no signal 0, but only finds processes that exist
+	if runtime.GOOS == "windows" {
+		proc.Release()
+		return true
+	}
+	err = proc.Signal(syscall.Signal(0))
+	// EPERM means the process exists but belongs to someone else
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,115 @@
+package lockfile
+
+import (
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// staleTmpAge is how old a .tmp file must be to count as left behind; a
+// younger one may be a write another process is still making, without a
+// lock to show for it
+const staleTmpAge = time.Hour
+
+// Stale is a leftover file from a process that crashed or was killed
+type Stale struct {
+	Path   string
+	Reason string
+}
+
+// FindStale scans dirs for files left behind by crashed processes:
+// locks and pidfiles whose process is gone, sockets nothing listens on,
+// and .tmp files over an hour old from downloads or writes that never
+// finished. Only locks and pidfiles whose name matches one of the locks
+// patterns (as in filepath.Match) are considered, so ones that other
+// programs keep are never reported.
+// Missing directories are skipped.
+func FindStale(locks []string, dirs ...string) ([]Stale, error) {
+	var stale []Stale
+
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+
+			if reason := staleReason(path, d, locks); reason != "" {
+				stale = append(stale, Stale{Path: path, Reason: reason})
+			}
+			return nil
+		})
+		if err != nil {
+			return stale, err
+		}
+	}
+
+	return stale, nil
+}
+
+// Clean removes the given stale files, returning the first error
+func Clean(stale []Stale) error {
+	var firstErr error
+	for _, s := range stale {
+		if err := os.Remove(s.Path); err != nil && !os.IsNotExist(err) && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// staleReason explains why a file is stale, or returns "" if it isn't
+func staleReason(path string, d fs.DirEntry, locks []string) string {
+	switch {
+	case strings.HasSuffix(path, ".lock"), strings.HasSuffix(path, ".pid"):
+		if !matchesAny(filepath.Base(path), locks) {
+			return ""
+		}
+		pid, held := holder(path)
+		if held {
+			return ""
+		}
+		if pid == 0 {
+			return "unreadable pidfile"
+		}
+		return "process is not running"
+
+	case d.Type()&fs.ModeSocket != 0:
+		conn, err := net.DialTimeout("unix", path, 200*time.Millisecond)
+		if err != nil {
+			return "nothing is listening"
+		}
+		conn.Close()
+
+	case strings.HasSuffix(path, ".tmp"):
+		lockPath := strings.TrimSuffix(path, ".tmp") + ".lock"
+		if pid, err := ReadPID(lockPath); err == nil && Alive(pid) {
+			return ""
+		}
+		info, err := d.Info()
+		if err != nil || time.Since(info.ModTime()) < staleTmpAge {
+			return ""
+		}
+		return "unfinished download or write"
+	}
+
+	return ""
+}
+
+// matchesAny reports whether name matches one of patterns
+func matchesAny(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package metrics
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cliq-cli/cliq/internal/config"
+)
+
+// Record represents a single query performance sample. TokensPerSec is
+// left out for an answer that arrived all at once.
+type Record struct {
+	Time         time.Time `json:"time"`
+	Backend      string    `json:"backend"`
+	Model        string    `json:"model,omitempty"`
+	Tokens       int       `json:"tokens"`
+	DurationMS   int64     `json:"duration_ms"`
+	TokensPerSec float64   `json:"tokens_per_sec,omitempty"`
+}
+
+// Meter tracks generation throughput while a response is streaming
+type Meter struct {
+	start  time.Time
+	tokens int
+	chunks int
+	// firstToken is how long the first token took, 0 until it arrives
+	firstToken time.Duration
+}
+
+// NewMeter creates a meter starting now
+func NewMeter() *Meter {
+	return &Meter{start: time.Now()}
+}
+
+// Add records n newly generated tokens
+func (m *Meter) Add(n int) {
+	if m.firstToken == 0 {
+		m.firstToken = m.Elapsed()
+	}
+	m.tokens += n
+	m.chunks++
+}
+
+// Streamed reports whether the answer arrived in more than one chunk, so
+// that its generation could be timed
+func (m *Meter) Streamed() bool {
+	return m.chunks > 1
+}
+
+// Tokens returns the number of tokens seen so far
+func (m *Meter) Tokens() int {
+	return m.tokens
+}
+
+// Elapsed returns the time since the meter was started
+func (m *Meter) Elapsed() time.Duration {
+	return time.Since(m.start)
+}
+
+// FirstToken returns how long the first token took, 0 before it arrives
+func (m *Meter) FirstToken() time.Duration {
+	return m.firstToken
+}
+
+// TokensPerSec returns the average generation speed so far, timed from the
+// first token so the wait for the prompt to be read isn't counted. It's 0
+// until the answer has streamed, as one that arrives all at once can't be
+// timed.
+func (m *Meter) TokensPerSec() float64 {
+	if !m.Streamed() {
+		return 0
+	}
+	secs := (m.Elapsed() - m.firstToken).Seconds()
+	if secs <= 0 {
+		return 0
+	}
+	return float64(m.tokens) / secs
+}
+
+// Record builds a metrics record from the meter's current state
+func (m *Meter) Record(backend, model string) Record {
+	return Record{
+		Time:         m.start,
+		Backend:      backend,
+		Model:        model,
+		Tokens:       m.tokens,
+		DurationMS:   m.Elapsed().Milliseconds(),
+		TokensPerSec: m.TokensPerSec(),
+	}
+}
+
+// Append adds a record to the metrics log on disk
+func Append(rec Record) error {
+	metricsPath, err := getMetricsPath()
+	if err != nil {
+		return err
+	}
+
+	// Ensure data directory exists
+	if err := os.MkdirAll(filepath.Dir(metricsPath), config.DirPerm); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(metricsPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, config.FilePerm)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// getMetricsPath returns the full path to the metrics log
+func getMetricsPath() (string, error) {
+	dataDir, err := config.GetDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, "metrics.jsonl"), nil
+}
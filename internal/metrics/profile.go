@@ -0,0 +1,40 @@
+package metrics
+
+import "time"
+
+// Phase is a named step of cliq's startup or query pipeline
+type Phase struct {
+	Name     string
+	Duration time.Duration
+}
+
+// Profile records how long each phase of a run takes. A nil *Profile is
+// valid and records nothing, so callers can pass one unconditionally.
+type Profile struct {
+	start  time.Time
+	last   time.Time
+	Phases []Phase
+}
+
+// NewProfile creates a profile whose first phase starts at the given time
+func NewProfile(start time.Time) *Profile {
+	return &Profile{start: start, last: start}
+}
+
+// Mark ends the current phase, recording the time since the previous mark
+func (p *Profile) Mark(name string) {
+	if p == nil {
+		return
+	}
+	now := time.Now()
+	p.Phases = append(p.Phases, Phase{Name: name, Duration: now.Sub(p.last)})
+	p.last = now
+}
+
+// Total returns the time from the start of the profile to the last mark
+func (p *Profile) Total() time.Duration {
+	if p == nil {
+		return 0
+	}
+	return p.last.Sub(p.start)
+}
@@ -1,26 +1,45 @@
 package parser
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/cliq-cli/cliq/internal/config"
 )
 
-// Cache represents cached configuration data
+// cacheVersion is the format of the cache, raised whenever what the
+// parsers record changes, such as when keymaps gained their Keys, so a
+// cache written before isn't used for its missing fields
+const cacheVersion = 2
+
+// Cache represents cached configuration data. Each NVIM_APPNAME profile
+// has its own, since each has its own Neovim config.
 type Cache struct {
+	// Profile is the NVIM_APPNAME profile the cache belongs to, empty for
+	// the default Neovim config
+	Profile      string                 `json:"profile,omitempty"`
 	NvimConfig   *NvimConfig            `json:"nvim_config,omitempty"`
 	TmuxConfig   *TmuxConfig            `json:"tmux_config,omitempty"`
 	LastParsed   time.Time              `json:"last_parsed"`
 	ConfigHashes map[string]string      `json:"config_hashes,omitempty"`
 	Metadata     map[string]interface{} `json:"metadata,omitempty"`
+
+	// Version is the cacheVersion the cache was written with
+	Version int `json:"version"`
 }
 
-// LoadCache loads the cache from disk
-func LoadCache() (*Cache, error) {
-	cachePath, err := getCachePath()
+// LoadCache loads the cache of an NVIM_APPNAME profile, or of the default
+// config when profile is empty, from disk
+func LoadCache(profile string) (*Cache, error) {
+	cachePath, err := CachePath(profile)
 	if err != nil {
 		return nil, err
 	}
@@ -29,6 +48,7 @@ func LoadCache() (*Cache, error) {
 	if err != nil {
 		if os.IsNotExist(err) {
 			return &Cache{
+				Profile:      profile,
 				ConfigHashes: make(map[string]string),
 				Metadata:     make(map[string]interface{}),
 			}, nil
@@ -40,6 +60,7 @@ func LoadCache() (*Cache, error) {
 	if err := json.Unmarshal(data, &cache); err != nil {
 		return nil, err
 	}
+	cache.Profile = profile
 
 	// Initialize maps if nil
 	if cache.ConfigHashes == nil {
@@ -54,31 +75,33 @@ func LoadCache() (*Cache, error) {
 
 // Save saves the cache to disk
 func (c *Cache) Save() error {
-	cachePath, err := getCachePath()
+	cachePath, err := CachePath(c.Profile)
 	if err != nil {
 		return err
 	}
 
-	// Ensure cache directory exists
-	dir := filepath.Dir(cachePath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return err
-	}
-
-	// Update last parsed time
+	// Update last parsed time, and record the files it was parsed from
+	c.Version = cacheVersion
 	c.LastParsed = time.Now()
+	c.ConfigHashes = make(map[string]string)
+	for _, file := range c.SourceFiles() {
+		if hash, err := hashFile(file); err == nil {
+			c.ConfigHashes[file] = hash
+		}
+	}
 
 	data, err := json.MarshalIndent(c, "", "  ")
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(cachePath, data, 0644)
+	return config.WriteFile(cachePath, data)
 }
 
-// IsStale checks if the cache is older than the specified TTL
+// IsStale checks if the cache is older than the specified TTL, or was
+// written in another format
 func (c *Cache) IsStale(ttlHours int) bool {
-	if c.LastParsed.IsZero() {
+	if c.LastParsed.IsZero() || c.Outdated() {
 		return true
 	}
 
@@ -86,8 +109,17 @@ func (c *Cache) IsStale(ttlHours int) bool {
 	return time.Since(c.LastParsed) > ttl
 }
 
+// Outdated reports whether the cache was written in another format than
+// this version of cliq's, by an older cliq or a newer one
+func (c *Cache) Outdated() bool {
+	return !c.LastParsed.IsZero() && c.Version != cacheVersion
+}
+
 // NeedsRefresh checks if any source config files have been modified since the cache was created
 func (c *Cache) NeedsRefresh() bool {
+	if c.Outdated() {
+		return true
+	}
 	if c.NvimConfig != nil && c.NvimConfig.ConfigPath != "" {
 		if modified, _ := isFileModifiedSince(c.NvimConfig.ConfigPath, c.LastParsed); modified {
 			return true
@@ -110,7 +142,7 @@ func (c *Cache) Clear() error {
 	c.ConfigHashes = make(map[string]string)
 	c.LastParsed = time.Time{}
 
-	cachePath, err := getCachePath()
+	cachePath, err := CachePath(c.Profile)
 	if err != nil {
 		return err
 	}
@@ -140,12 +172,104 @@ func (c *Cache) GetSummary() map[string]interface{} {
 	return summary
 }
 
-// getCachePath returns the full path to the cache file
-func getCachePath() (string, error) {
+// CachedFile is a config file the cache was parsed from, as it is now and
+// as it was when the cache was saved
+type CachedFile struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size,omitempty"`
+	ModTime time.Time `json:"mod_time,omitempty"`
+	// Hash is the file's SHA-256 now, empty if it's gone; Cached is its
+	// SHA-256 when the cache was saved, empty if it's new since
+	Hash   string `json:"hash,omitempty"`
+	Cached string `json:"cached,omitempty"`
+}
+
+// Changed reports whether the file differs from when the cache was saved
+func (f CachedFile) Changed() bool {
+	return f.Hash != f.Cached
+}
+
+// SourceFiles lists the config files the cached configs come from: the
+// Lua and Vimscript files in the Neovim config directory, and tmux.conf
+// with the files it sources
+func (c *Cache) SourceFiles() []string {
+	var files []string
+	if c.NvimConfig != nil && c.NvimConfig.ConfigPath != "" {
+		root := c.NvimConfig.ConfigPath
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if d.IsDir() {
+				if path != root && strings.HasPrefix(d.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if ext := filepath.Ext(path); ext == ".lua" || ext == ".vim" {
+				files = append(files, path)
+			}
+			return nil
+		})
+	}
+	if c.TmuxConfig != nil && c.TmuxConfig.ConfigPath != "" {
+		files = append(files, c.TmuxConfig.ConfigPath)
+		files = append(files, c.TmuxConfig.Includes...)
+	}
+	return files
+}
+
+// Files compares the source files on disk with the hashes recorded when
+// the cache was saved, including recorded files that have since gone
+func (c *Cache) Files() []CachedFile {
+	seen := map[string]bool{}
+	var files []CachedFile
+	for _, path := range c.SourceFiles() {
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		f := CachedFile{Path: path, Cached: c.ConfigHashes[path]}
+		if info, err := os.Stat(path); err == nil {
+			f.Size = info.Size()
+			f.ModTime = info.ModTime()
+		}
+		f.Hash, _ = hashFile(path)
+		files = append(files, f)
+	}
+	for path, hash := range c.ConfigHashes {
+		if !seen[path] {
+			files = append(files, CachedFile{Path: path, Cached: hash})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files
+}
+
+// hashFile returns the hex SHA-256 of a file
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// CachePath returns the full path to the cache file of an NVIM_APPNAME
+// profile, or of the default config when profile is empty
+func CachePath(profile string) (string, error) {
 	cacheDir, err := config.GetCacheDir()
 	if err != nil {
 		return "", err
 	}
+	if profile != "" {
+		return filepath.Join(cacheDir, "config-cache-"+filepath.Base(profile)+".json"), nil
+	}
 	return filepath.Join(cacheDir, "config-cache.json"), nil
 }
 
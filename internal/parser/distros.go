@@ -0,0 +1,193 @@
+package parser
+
+import (
+	_ "embed"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Neovim distributions cliq knows the defaults of
+const (
+	DistroLazyVim   = "LazyVim"
+	DistroNvChad    = "NvChad"
+	DistroAstroNvim = "AstroNvim"
+	DistroLunarVim  = "LunarVim"
+	DistroKickstart = "kickstart"
+)
+
+//go:embed data/distros.yaml
+var distrosData []byte
+
+// Distro is a Neovim distribution's leader conventions and default keymaps
+type Distro struct {
+	Name        string `yaml:"name"`
+	Leader      string `yaml:"leader"`
+	LocalLeader string `yaml:"localleader"`
+	Keymaps     []struct {
+		Lhs         string `yaml:"lhs"`
+		Mode        string `yaml:"mode"`
+		Description string `yaml:"desc"`
+	} `yaml:"keymaps"`
+}
+
+// Distros returns the bundled knowledge of the distributions cliq detects
+func Distros() ([]Distro, error) {
+	var distros []Distro
+	if err := yaml.Unmarshal(distrosData, &distros); err != nil {
+		return nil, err
+	}
+	return distros, nil
+}
+
+// lockDistros maps plugins in lazy-lock.json to the distribution that
+// brings them in
+var lockDistros = map[string]string{
+	"LazyVim":   DistroLazyVim,
+	"NvChad":    DistroNvChad,
+	"base46":    DistroNvChad,
+	"AstroNvim": DistroAstroNvim,
+	"astrocore": DistroAstroNvim,
+	"astroui":   DistroAstroNvim,
+}
+
+// DetectDistro names the distribution the config at configPath is built on,
+// from the plugins pinned in lazy-lock.json or files only that distribution
+// has, or returns "" for a config of the user's own
+func DetectDistro(configPath string) string {
+	if content, err := os.ReadFile(filepath.Join(configPath, "lazy-lock.json")); err == nil {
+		var lock map[string]json.RawMessage
+		if json.Unmarshal(content, &lock) == nil {
+			for plugin := range lock {
+				if distro := lockDistros[plugin]; distro != "" {
+					return distro
+				}
+			}
+		}
+	}
+
+	contains := func(rel, s string) bool {
+		content, err := os.ReadFile(filepath.Join(configPath, rel))
+		return err == nil && strings.Contains(string(content), s)
+	}
+	exists := func(rel string) bool {
+		_, err := os.Stat(filepath.Join(configPath, rel))
+		return err == nil
+	}
+
+	switch {
+	case contains(filepath.Join("lua", "config", "lazy.lua"), "LazyVim/LazyVim"):
+		return DistroLazyVim
+	case exists(filepath.Join("lua", "chadrc.lua")), exists(filepath.Join("lua", "custom", "chadrc.lua")),
+		contains("init.lua", "NvChad/NvChad"):
+		return DistroNvChad
+	case exists(filepath.Join("lua", "community.lua")), contains(filepath.Join("lua", "lazy_setup.lua"), "AstroNvim/AstroNvim"),
+		contains("init.lua", "AstroNvim/AstroNvim"):
+		return DistroAstroNvim
+	case contains("config.lua", "lvim."), filepath.Base(configPath) == "lvim":
+		return DistroLunarVim
+	case contains("init.lua", "kickstart"):
+		return DistroKickstart
+	}
+	return ""
+}
+
+var (
+	lvimLeaderRe = regexp.MustCompile(`lvim\.leader\s*=\s*["']([^"']+)["']`)
+	lvimKeyRe    = regexp.MustCompile(`lvim\.keys\.(\w+)_mode\[\s*["']([^"']+)["']\s*\]\s*=\s*["']([^"']+)["']`)
+)
+
+// lvimModes maps LunarVim's lvim.keys tables to mode letters
+var lvimModes = map[string]string{
+	"normal": "n", "insert": "i", "visual": "v", "visual_block": "x",
+	"term": "t", "command": "c",
+}
+
+// parseLunarVimConfig reads LunarVim's config.lua, which sets the leader
+// and keymaps through the lvim table rather than vim.g and vim.keymap
+func (cfg *NvimConfig) parseLunarVimConfig(filePath string) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return
+	}
+	text := string(content)
+
+	if m := lvimLeaderRe.FindStringSubmatch(text); m != nil {
+		cfg.Leader = m[1]
+		if m[1] == "space" {
+			cfg.Leader = " "
+		}
+	}
+	for _, m := range lvimKeyRe.FindAllStringSubmatch(text, -1) {
+		mode, ok := lvimModes[m[1]]
+		if !ok {
+			continue
+		}
+		cfg.Keymaps = append(cfg.Keymaps, Keymap{Mode: mode, Lhs: m[2], Rhs: m[3], Source: filePath})
+	}
+
+	cfg.parseLuaConfig(filePath)
+}
+
+// applyDistro merges the detected distribution's leader and default
+// keymaps into the config. An explicitly set leader wins, as does any
+// keymap the user binds to the same keys in an overlapping mode.
+func (cfg *NvimConfig) applyDistro() {
+	if cfg.Distro == "" {
+		return
+	}
+	distros, err := Distros()
+	if err != nil {
+		return
+	}
+
+	for _, d := range distros {
+		if d.Name != cfg.Distro {
+			continue
+		}
+		if cfg.Leader == "\\" && d.Leader != "" {
+			cfg.Leader = d.Leader
+		}
+		if cfg.LocalLeader == "" {
+			cfg.LocalLeader = d.LocalLeader
+		}
+
+		user := cfg.Keymaps
+		for _, km := range d.Keymaps {
+			mode := km.Mode
+			if mode == "" {
+				mode = "n"
+			}
+			if rebound(user, mode, km.Lhs) {
+				continue
+			}
+			cfg.Keymaps = append(cfg.Keymaps, Keymap{
+				Mode:        mode,
+				Lhs:         km.Lhs,
+				Rhs:         pluginRhs(d.Name),
+				Description: km.Description,
+				Distro:      d.Name,
+			})
+		}
+		return
+	}
+}
+
+// keyNameRe matches the <...> key names in an lhs, which are case
+// insensitive, unlike the keys between them
+var keyNameRe = regexp.MustCompile(`<[^<>]+>`)
+
+// rebound reports whether any of keymaps binds lhs in one of modes
+func rebound(keymaps []Keymap, modes, lhs string) bool {
+	lhs = keyNameRe.ReplaceAllStringFunc(lhs, strings.ToLower)
+	for _, km := range keymaps {
+		if strings.ContainsAny(km.Mode, modes) && keyNameRe.ReplaceAllStringFunc(km.Lhs, strings.ToLower) == lhs {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,300 @@
+package parser
+
+import (
+	"strings"
+)
+
+// luaToken is a token of Lua source, as far as reading plugin specs needs:
+// strings, identifiers, and the punctuation of table constructors
+type luaToken struct {
+	// kind is 's' for a string, 'i' for an identifier or keyword, 'n' for
+	// a number, one of { } = , for that punctuation, and 'o' for anything
+	// else
+	kind byte
+	text string
+}
+
+// luaTokens splits Lua source into tokens, dropping comments
+func luaTokens(src string) []luaToken {
+	var tokens []luaToken
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case strings.HasPrefix(src[i:], "--"):
+			i += 2
+			if level, ok := longBracket(src[i:]); ok {
+				i = skipLongBracket(src, i, level)
+			} else if end := strings.IndexByte(src[i:], '\n'); end >= 0 {
+				i += end
+			} else {
+				i = len(src)
+			}
+		case c == '"' || c == '\'':
+			var sb strings.Builder
+			j := i + 1
+			for ; j < len(src) && src[j] != c && src[j] != '\n'; j++ {
+				if src[j] == '\\' && j+1 < len(src) {
+					j++
+				}
+				sb.WriteByte(src[j])
+			}
+			tokens = append(tokens, luaToken{'s', sb.String()})
+			i = j + 1
+		case c == '[':
+			if level, ok := longBracket(src[i:]); ok {
+				start := i + level + 2
+				end := skipLongBracket(src, i, level)
+				tokens = append(tokens, luaToken{'s', src[start:max(start, end-level-2)]})
+				i = end
+			} else {
+				tokens = append(tokens, luaToken{'o', "["})
+				i++
+			}
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+			j := i + 1
+			for j < len(src) && (src[j] == '_' || src[j] == '.' || src[j] >= 'a' && src[j] <= 'z' || src[j] >= 'A' && src[j] <= 'Z' || src[j] >= '0' && src[j] <= '9') {
+				j++
+			}
+			tokens = append(tokens, luaToken{'i', src[i:j]})
+			i = j
+		case c >= '0' && c <= '9':
+			j := i + 1
+			for j < len(src) && (src[j] == '.' || src[j] == 'x' || src[j] == 'X' || src[j] >= '0' && src[j] <= '9' || src[j] >= 'a' && src[j] <= 'f' || src[j] >= 'A' && src[j] <= 'F') {
+				j++
+			}
+			tokens = append(tokens, luaToken{'n', src[i:j]})
+			i = j
+		case c == '{' || c == '}' || c == ',':
+			tokens = append(tokens, luaToken{c, string(c)})
+			i++
+		case c == ';':
+			tokens = append(tokens, luaToken{',', ","})
+			i++
+		case c == '=':
+			if strings.HasPrefix(src[i:], "==") {
+				tokens = append(tokens, luaToken{'o', "=="})
+				i += 2
+			} else {
+				tokens = append(tokens, luaToken{'=', "="})
+				i++
+			}
+		default:
+			tokens = append(tokens, luaToken{'o', string(c)})
+			i++
+		}
+	}
+	return tokens
+}
+
+// longBracket reports whether s opens a long bracket, [[ or [==[, and its
+// level (the number of =)
+func longBracket(s string) (int, bool) {
+	if !strings.HasPrefix(s, "[") {
+		return 0, false
+	}
+	level := 1
+	for level < len(s) && s[level] == '=' {
+		level++
+	}
+	if level < len(s) && s[level] == '[' {
+		return level - 1, true
+	}
+	return 0, false
+}
+
+// skipLongBracket returns the index just past the long bracket of level
+// opened at src[i]
+func skipLongBracket(src string, i, level int) int {
+	closing := "]" + strings.Repeat("=", level) + "]"
+	if end := strings.Index(src[i:], closing); end >= 0 {
+		return i + end + len(closing)
+	}
+	return len(src)
+}
+
+// extractLazyKeys extracts the keymaps lazy.nvim plugin specs declare in
+// their keys tables, such as
+//
+//	keys = { { "<leader>ff", "<cmd>Telescope find_files<cr>", desc = "Find files" } }
+//
+// Each keymap records the plugin whose spec declares it.
+func (cfg *NvimConfig) extractLazyKeys(content, source string) {
+	tokens := luaTokens(content)
+	for i := 0; i+2 < len(tokens); i++ {
+		if tokens[i].kind != 'i' || tokens[i].text != "keys" || tokens[i+1].kind != '=' || tokens[i+2].kind != '{' {
+			continue
+		}
+		// Only a plugin spec's keys; options tables have keys fields too
+		plugin := specPlugin(tokens, i)
+		if plugin == "" {
+			continue
+		}
+
+		end := matchingBrace(tokens, i+2)
+		for _, entry := range tableFields(tokens[i+3 : end]) {
+			if km, ok := lazyKeymap(entry, plugin, source); ok {
+				cfg.Keymaps = append(cfg.Keymaps, km)
+			}
+		}
+		i = end
+	}
+}
+
+// specPlugin returns the plugin named by the spec enclosing tokens[i]: the
+// repository string that starts the table, without its owner
+func specPlugin(tokens []luaToken, i int) string {
+	depth := 0
+	for j := i - 1; j >= 0; j-- {
+		switch tokens[j].kind {
+		case '}':
+			depth++
+		case '{':
+			if depth > 0 {
+				depth--
+				continue
+			}
+			if j+1 < len(tokens) && tokens[j+1].kind == 's' {
+				if _, name, ok := strings.Cut(tokens[j+1].text, "/"); ok {
+					return name
+				}
+			}
+			return ""
+		}
+	}
+	return ""
+}
+
+// matchingBrace returns the index of the } closing the { at tokens[open],
+// or len(tokens) if it is never closed
+func matchingBrace(tokens []luaToken, open int) int {
+	depth := 0
+	for j := open; j < len(tokens); j++ {
+		switch tokens[j].kind {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return j
+			}
+		}
+	}
+	return len(tokens)
+}
+
+// tableFields splits the tokens inside a table constructor into its fields
+func tableFields(tokens []luaToken) [][]luaToken {
+	var fields [][]luaToken
+	depth, start := 0, 0
+	for j, t := range tokens {
+		switch {
+		case t.kind == '{' || t.text == "(":
+			depth++
+		case t.kind == '}' || t.text == ")":
+			depth--
+		case t.kind == ',':
+			if depth == 0 {
+				fields = append(fields, tokens[start:j])
+				start = j + 1
+			}
+		}
+	}
+	if start < len(tokens) {
+		fields = append(fields, tokens[start:])
+	}
+	return fields
+}
+
+// lazyKeymap reads one entry of a keys table: either a bare lhs string or a
+// table of lhs, optional rhs, and fields like desc and mode
+func lazyKeymap(entry []luaToken, plugin, source string) (Keymap, bool) {
+	km := Keymap{Mode: "n", Source: source, Plugin: plugin}
+	if len(entry) == 0 {
+		return km, false
+	}
+
+	// A bare lhs loads the plugin, whose own mapping then runs
+	if entry[0].kind == 's' && len(entry) == 1 {
+		km.Lhs = entry[0].text
+		km.Rhs = pluginRhs(plugin)
+		return km, true
+	}
+	if entry[0].kind != '{' {
+		return km, false
+	}
+
+	var positional [][]luaToken
+	for _, field := range tableFields(entry[1:matchingBrace(entry, 0)]) {
+		if len(field) == 0 {
+			continue
+		}
+		if len(field) >= 3 && field[0].kind == 'i' && field[1].kind == '=' {
+			value := field[2:]
+			switch field[0].text {
+			case "desc":
+				if value[0].kind == 's' {
+					km.Description = value[0].text
+				}
+			case "mode":
+				km.Mode = luaModes(value)
+			}
+			continue
+		}
+		positional = append(positional, field)
+	}
+
+	if len(positional) == 0 || positional[0][0].kind != 's' {
+		return km, false
+	}
+	km.Lhs = positional[0][0].text
+	km.Rhs = pluginRhs(plugin)
+	if len(positional) > 1 {
+		km.Rhs = luaRhs(positional[1])
+	}
+	return km, km.Lhs != ""
+}
+
+// luaModes reads a mode field, "v" or { "n", "v" }, into a mode string
+// like "nv"
+func luaModes(value []luaToken) string {
+	var modes strings.Builder
+	for _, t := range value {
+		if t.kind == 's' {
+			modes.WriteString(t.text)
+		}
+	}
+	if modes.Len() == 0 {
+		return "n"
+	}
+	return modes.String()
+}
+
+// luaRhs describes a keymap's rhs the way extractKeymapsFromLua does: a
+// string's contents, [function], or a bracketed function reference
+func luaRhs(value []luaToken) string {
+	switch {
+	case value[0].kind == 's' && len(value) == 1:
+		return value[0].text
+	case value[0].kind == 'i' && value[0].text == "function":
+		return "[function]"
+	}
+	var sb strings.Builder
+	for _, t := range value {
+		if t.kind == 's' {
+			sb.WriteString(`"` + t.text + `"`)
+		} else {
+			sb.WriteString(t.text)
+		}
+	}
+	return "[" + sb.String() + "]"
+}
+
+// pluginRhs is the rhs of a keymap whose action the plugin defines
+func pluginRhs(plugin string) string {
+	if plugin == "" {
+		return "[plugin]"
+	}
+	return "[" + plugin + "]"
+}
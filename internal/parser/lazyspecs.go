@@ -0,0 +1,83 @@
+package parser
+
+import "regexp"
+
+// LazySpec is a lazy.nvim plugin spec as written: a table that starts with
+// the plugin's repository, and its name = value fields
+type LazySpec struct {
+	Repo   string // such as nvim-telescope/telescope.nvim
+	Source string // File where declared
+	Fields []LazyField
+}
+
+// LazyField is one name = value field of a spec
+type LazyField struct {
+	Name string
+	// Kind is what the value is: string, number, boolean, nil, table,
+	// function, name for a lone variable, or expression for anything else
+	Kind string
+	// Value is the value's text, for all but tables and functions
+	Value string
+}
+
+// lazyRepoRe matches a plugin's repository as a spec starts with, owner/repo
+var lazyRepoRe = regexp.MustCompile(`^[\w.-]+/[\w.-]+$`)
+
+// extractLazySpecs records the plugin specs in a lazy.nvim spec file. The
+// tables in a spec's keys and dependencies are skipped: a key's table, such
+// as { "<leader>/", ..., desc = "..." }, isn't a spec, and a dependency's
+// is only checked where it's declared as a plugin of its own.
+func (cfg *NvimConfig) extractLazySpecs(content, source string) {
+	tokens := luaTokens(content)
+	for i := 0; i+2 < len(tokens); i++ {
+		if t := tokens[i]; t.kind == 'i' && (t.text == "keys" || t.text == "dependencies") &&
+			tokens[i+1].kind == '=' && tokens[i+2].kind == '{' {
+			i = matchingBrace(tokens, i+2)
+			continue
+		}
+		if tokens[i].kind != '{' || tokens[i+1].kind != 's' || !lazyRepoRe.MatchString(tokens[i+1].text) {
+			continue
+		}
+		if next := tokens[i+2].kind; next != ',' && next != '}' {
+			continue
+		}
+
+		spec := LazySpec{Repo: tokens[i+1].text, Source: source}
+		for _, field := range tableFields(tokens[i+1 : matchingBrace(tokens, i)]) {
+			if len(field) >= 3 && field[0].kind == 'i' && field[1].kind == '=' {
+				spec.Fields = append(spec.Fields, lazyField(field[0].text, field[2:]))
+			}
+		}
+		cfg.LazySpecs = append(cfg.LazySpecs, spec)
+	}
+}
+
+// lazyField describes a field's value
+func lazyField(name string, value []luaToken) LazyField {
+	f := LazyField{Name: name, Kind: "expression"}
+	t := value[0]
+	switch {
+	case t.kind == 's':
+		f.Kind = "string"
+	case t.kind == 'n':
+		f.Kind = "number"
+	case t.kind == '{':
+		f.Kind = "table"
+	case t.text == "true" || t.text == "false":
+		f.Kind = "boolean"
+	case t.text == "nil":
+		f.Kind = "nil"
+	case t.text == "function":
+		f.Kind = "function"
+	case t.kind == 'i':
+		f.Kind = "name"
+	}
+	if f.Kind != "table" && f.Kind != "function" {
+		f.Value = t.text
+	}
+	if len(value) > 1 && f.Kind != "table" && f.Kind != "function" {
+		// An expression such as vim.fn.has("mac") == 1
+		f.Kind = "expression"
+	}
+	return f
+}
@@ -15,15 +15,37 @@ type NvimConfig struct {
 	Keymaps    []Keymap
 	Plugins    []Plugin
 	ConfigPath string
+
+	// Distro is the distribution the config is built on, such as LazyVim,
+	// whose default keymaps are merged into Keymaps; LocalLeader is the
+	// <localleader> it sets
+	Distro      string
+	LocalLeader string
+
+	// Options maps the options the config sets, by full name, to their
+	// values; options it leaves alone are at Neovim's defaults
+	Options      map[string]string
+	Autocmds     []Autocmd
+	UserCommands []UserCommand
+
+	// LazySpecs are the lazy.nvim plugin specs in lua/plugins
+	LazySpecs []LazySpec
+
+	// parsed holds the Lua files already read, so require cycles and files
+	// reached more than one way are parsed once
+	parsed map[string]bool
 }
 
 // Keymap represents a Neovim keymap
 type Keymap struct {
 	Mode        string // "n", "v", "i", etc.
 	Lhs         string // Key combination
+	Keys        string // Lhs with <leader> and <localleader> resolved
 	Rhs         string // Command
 	Description string
 	Source      string // File where defined
+	Plugin      string // Plugin whose lazy.nvim spec declares it, if any
+	Distro      string // Distribution whose default it is, if any
 }
 
 // Plugin represents a Neovim plugin
@@ -31,6 +53,8 @@ type Plugin struct {
 	Name    string
 	Enabled bool
 	Config  map[string]interface{}
+	// Manager is the plugin manager declaring it, such as lazy.nvim
+	Manager string
 }
 
 // ParseNvimConfig parses the Neovim configuration directory
@@ -40,6 +64,7 @@ func ParseNvimConfig(configPath string) (*NvimConfig, error) {
 		Leader:     "\\", // Default leader
 		Keymaps:    []Keymap{},
 		Plugins:    []Plugin{},
+		Options:    map[string]string{},
 	}
 
 	// Check for init.lua
@@ -73,12 +98,10 @@ func ParseNvimConfig(configPath string) (*NvimConfig, error) {
 		}
 	}
 
-	// Detect LazyVim and set Space as leader if not explicitly set
-	lazyLua := filepath.Join(configPath, "lua", "config", "lazy.lua")
-	if content, err := os.ReadFile(lazyLua); err == nil {
-		if strings.Contains(string(content), "LazyVim/LazyVim") && cfg.Leader == "\\" {
-			cfg.Leader = " " // LazyVim default is Space
-		}
+	// LunarVim keeps the user's settings in config.lua
+	cfg.Distro = DetectDistro(configPath)
+	if cfg.Distro == DistroLunarVim {
+		cfg.parseLunarVimConfig(filepath.Join(configPath, "config.lua"))
 	}
 
 	// Check for lazy.nvim plugin specs
@@ -100,11 +123,24 @@ func ParseNvimConfig(configPath string) (*NvimConfig, error) {
 		}
 	}
 
+	// packer.nvim, vim-plug, paq-nvim, and rocks.nvim
+	cfg.parsePluginManagers(configPath)
+
+	// Distribution defaults last, so the user's own keymaps take their place
+	cfg.applyDistro()
+
+	// Now that the leader is known, resolve it in every keymap
+	cfg.expandLeader()
+
 	return cfg, nil
 }
 
-// parseLuaConfig parses a Lua configuration file
+// parseLuaConfig parses a Lua configuration file and the modules it
+// requires from the config's lua/ directory
 func (cfg *NvimConfig) parseLuaConfig(filePath string) error {
+	if !cfg.markParsed(filePath) {
+		return nil
+	}
 	content, err := os.ReadFile(filePath)
 	if err != nil {
 		return err
@@ -117,13 +153,73 @@ func (cfg *NvimConfig) parseLuaConfig(filePath string) error {
 
 	// Extract keymaps using regex (safer than executing Lua)
 	cfg.extractKeymapsFromLua(text, filePath)
+	cfg.extractLazyKeys(text, filePath)
+
+	// Extract options, autocommands, and user commands
+	cfg.extractSettingsFromLua(text, filePath)
 
 	// Try to parse with gopher-lua for more complex extractions
 	cfg.parseLuaWithInterpreter(text)
 
+	for _, module := range requiredModules(text) {
+		if path := cfg.resolveModule(module); path != "" {
+			cfg.parseLuaConfig(path)
+		}
+	}
+
 	return nil
 }
 
+// markParsed records filePath as parsed, reporting false if it already was
+func (cfg *NvimConfig) markParsed(filePath string) bool {
+	if cfg.parsed == nil {
+		cfg.parsed = make(map[string]bool)
+	}
+	filePath = filepath.Clean(filePath)
+	if cfg.parsed[filePath] {
+		return false
+	}
+	cfg.parsed[filePath] = true
+	return true
+}
+
+// requiredModules returns the modules Lua source requires, through
+// require("mod"), require "mod", or pcall(require, "mod")
+func requiredModules(text string) []string {
+	tokens := luaTokens(text)
+	var modules []string
+	for i, t := range tokens {
+		if t.kind != 'i' || t.text != "require" {
+			continue
+		}
+		j := i + 1
+		if j < len(tokens) && (tokens[j].text == "(" || tokens[j].kind == ',') {
+			j++
+		}
+		if j < len(tokens) && tokens[j].kind == 's' {
+			modules = append(modules, tokens[j].text)
+		}
+	}
+	return modules
+}
+
+// resolveModule finds the file a module name refers to under the config's
+// lua/ directory, the way Neovim's loader does: lua/a/b.lua, then
+// lua/a/b/init.lua. Modules from plugins resolve to nothing.
+func (cfg *NvimConfig) resolveModule(module string) string {
+	parts := strings.FieldsFunc(module, func(r rune) bool { return r == '.' || r == '/' })
+	if len(parts) == 0 {
+		return ""
+	}
+	base := filepath.Join(append([]string{cfg.ConfigPath, "lua"}, parts...)...)
+	for _, path := range []string{base + ".lua", filepath.Join(base, "init.lua")} {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
 // extractLeaderFromLua extracts the leader key setting from Lua code
 func (cfg *NvimConfig) extractLeaderFromLua(content string) {
 	// Pattern: vim.g.mapleader = "..."
@@ -270,6 +366,9 @@ func (cfg *NvimConfig) parseVimConfig(filePath string) error {
 	text := string(content)
 	lines := strings.Split(text, "\n")
 
+	// Extract options, autocommands, and user commands
+	cfg.extractSettingsFromVim(text, filePath)
+
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
 
@@ -278,6 +377,16 @@ func (cfg *NvimConfig) parseVimConfig(filePath string) error {
 			continue
 		}
 
+		// lua require("config") hands over to a Lua module
+		if strings.HasPrefix(line, "lua ") {
+			for _, module := range requiredModules(line) {
+				if path := cfg.resolveModule(module); path != "" {
+					cfg.parseLuaConfig(path)
+				}
+			}
+			continue
+		}
+
 		// Extract leader key
 		if strings.Contains(line, "mapleader") {
 			pattern := `let\s+(?:g:)?mapleader\s*=\s*["'](.+?)["']`
@@ -333,6 +442,7 @@ func (cfg *NvimConfig) parseLazyPlugins(pluginDir string) {
 		}
 
 		text := string(content)
+		cfg.extractLazySpecs(text, filePath)
 
 		// Extract plugin names from lazy.nvim format
 		// Pattern: "username/repo-name" or 'username/repo-name'
@@ -349,13 +459,55 @@ func (cfg *NvimConfig) parseLazyPlugins(pluginDir string) {
 					plugin := Plugin{
 						Name:    parts[1],
 						Enabled: !strings.Contains(text, "enabled = false"),
+						Manager: ManagerLazy,
 					}
 					cfg.Plugins = append(cfg.Plugins, plugin)
 				}
 			}
 		}
 
-		// Also extract keymaps from plugin configs
-		cfg.extractKeymapsFromLua(text, filePath)
+		// Also extract keymaps from plugin configs, unless a require already
+		// did
+		if cfg.markParsed(filePath) {
+			cfg.extractKeymapsFromLua(text, filePath)
+			cfg.extractLazyKeys(text, filePath)
+			cfg.extractSettingsFromLua(text, filePath)
+		}
+	}
+}
+
+// leaderRe matches <leader> and <localleader> in an lhs, which Vim reads
+// case-insensitively
+var leaderRe = regexp.MustCompile(`(?i)<(local)?leader>`)
+
+// expandLeader fills in each keymap's Keys, the keys actually pressed
+func (cfg *NvimConfig) expandLeader() {
+	for i := range cfg.Keymaps {
+		cfg.Keymaps[i].Keys = cfg.ExpandLeader(cfg.Keymaps[i].Lhs)
+	}
+}
+
+// ExpandLeader replaces <leader> and <localleader> in lhs with the keys they
+// stand for, so <leader>ff with Space as leader becomes <Space>ff. An unset
+// local leader is a backslash, as in Vim.
+func (cfg *NvimConfig) ExpandLeader(lhs string) string {
+	return leaderRe.ReplaceAllStringFunc(lhs, func(m string) string {
+		if len(m) > len("<leader>") {
+			return leaderKeys(cfg.LocalLeader)
+		}
+		return leaderKeys(cfg.Leader)
+	})
+}
+
+// leaderKeys writes a leader the way an lhs would spell it
+func leaderKeys(leader string) string {
+	switch leader {
+	case "":
+		return "\\"
+	case " ":
+		return "<Space>"
+	case "\t":
+		return "<Tab>"
 	}
+	return leader
 }
@@ -0,0 +1,423 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Autocmd represents an autocommand the config defines
+type Autocmd struct {
+	Events      []string // BufWritePre, FileType, ...
+	Pattern     string   // File pattern or filetype, empty for all
+	Command     string   // Ex command, or [function] for a Lua callback
+	Description string
+	Group       string
+	Source      string // File where defined
+}
+
+// UserCommand represents a user-defined Ex command, such as :Format
+type UserCommand struct {
+	Name        string
+	Command     string // Ex command, or [function] for a Lua callback
+	Description string
+	Source      string // File where defined
+}
+
+// optionAbbrevs maps the short names of common options to their full names
+var optionAbbrevs = map[string]string{
+	"ai": "autoindent", "cb": "clipboard", "cc": "colorcolumn",
+	"cul": "cursorline", "enc": "encoding", "et": "expandtab",
+	"fdm": "foldmethod", "fen": "foldenable", "fenc": "fileencoding",
+	"ff": "fileformat", "hid": "hidden", "hls": "hlsearch",
+	"ic": "ignorecase", "is": "incsearch", "lbr": "linebreak",
+	"ls": "laststatus", "nu": "number", "rnu": "relativenumber",
+	"ru": "ruler", "sb": "splitbelow", "sc": "showcmd",
+	"scl": "signcolumn", "scs": "smartcase", "si": "smartindent",
+	"siso": "sidescrolloff", "smd": "showmode", "so": "scrolloff",
+	"spr": "splitright", "stal": "showtabline", "sts": "softtabstop",
+	"sw": "shiftwidth", "swf": "swapfile", "tgc": "termguicolors",
+	"tm": "timeoutlen", "ts": "tabstop", "tw": "textwidth",
+	"udf": "undofile", "ut": "updatetime",
+}
+
+// OptionName returns the full name of an option given by its short name
+func OptionName(name string) string {
+	if full, ok := optionAbbrevs[name]; ok {
+		return full
+	}
+	return name
+}
+
+// Option returns the value the config sets an option to, by its full or
+// short name; booleans are "true" or "false"
+func (cfg *NvimConfig) Option(name string) (string, bool) {
+	value, ok := cfg.Options[OptionName(name)]
+	return value, ok
+}
+
+// setOption records an option's value, the last assignment winning as it
+// does in Neovim
+func (cfg *NvimConfig) setOption(name, value string) {
+	if cfg.Options == nil {
+		cfg.Options = map[string]string{}
+	}
+	cfg.Options[OptionName(name)] = value
+}
+
+// luaOptionTables are the Lua tables options are set through
+var luaOptionTables = []string{"vim.opt", "vim.o", "vim.wo", "vim.bo", "vim.go", "vim.opt_local", "vim.opt_global"}
+
+// luaSettingCalls are the functions defining autocommands and user commands,
+// and vim.cmd, whose Vimscript may set options too
+var luaSettingCalls = map[string]string{
+	"vim.api.nvim_create_autocmd":          "autocmd",
+	"vim.api.nvim_create_user_command":     "command",
+	"vim.api.nvim_buf_create_user_command": "bufcommand",
+	"vim.cmd":                              "cmd",
+	"vim.api.nvim_command":                 "cmd",
+	"vim.api.nvim_exec":                    "cmd",
+	"vim.api.nvim_exec2":                   "cmd",
+}
+
+// extractSettingsFromLua extracts options set through vim.opt and friends,
+// nvim_create_autocmd calls, and nvim_create_user_command calls. Locals
+// aliasing them, like `local opt = vim.opt`, are followed.
+func (cfg *NvimConfig) extractSettingsFromLua(content, source string) {
+	tokens := luaTokens(content)
+
+	prefixes := map[string]bool{}
+	for _, table := range luaOptionTables {
+		prefixes[table+"."] = true
+	}
+	calls := map[string]string{}
+	for name, kind := range luaSettingCalls {
+		calls[name] = kind
+	}
+	groups := map[string]string{}
+
+	// local X = Y, where Y is an option table, a setting function or a
+	// table holding them, or an augroup
+	for i := 0; i+3 < len(tokens); i++ {
+		if tokens[i].text != "local" || tokens[i+1].kind != 'i' || tokens[i+2].kind != '=' || tokens[i+3].kind != 'i' {
+			continue
+		}
+		alias, target := tokens[i+1].text, tokens[i+3].text
+		if prefixes[target+"."] {
+			prefixes[alias+"."] = true
+		}
+		for name, kind := range luaSettingCalls {
+			if name == target {
+				calls[alias] = kind
+			} else if rest, ok := strings.CutPrefix(name, target+"."); ok {
+				calls[alias+"."+rest] = kind
+			}
+		}
+		if strings.HasSuffix(target, "nvim_create_augroup") && i+5 < len(tokens) && tokens[i+4].text == "(" && tokens[i+5].kind == 's' {
+			groups[alias] = tokens[i+5].text
+		}
+	}
+
+	for i := 0; i+1 < len(tokens); i++ {
+		t := tokens[i]
+		if t.kind != 'i' {
+			continue
+		}
+
+		// vim.opt.relativenumber = true
+		if tokens[i+1].kind == '=' && i+2 < len(tokens) {
+			if dot := strings.LastIndexByte(t.text, '.'); dot > 0 && prefixes[t.text[:dot+1]] {
+				if value, ok := luaValue(tokens[i+2:]); ok {
+					cfg.setOption(t.text[dot+1:], value)
+				}
+			}
+			continue
+		}
+
+		kind, ok := calls[t.text]
+		if !ok {
+			continue
+		}
+
+		// vim.cmd [[set nu]] takes its argument without parentheses
+		if kind == "cmd" && tokens[i+1].kind == 's' {
+			cfg.extractSettingsFromVim(tokens[i+1].text, source)
+			continue
+		}
+		if tokens[i+1].text != "(" {
+			continue
+		}
+		end := matchingParen(tokens, i+1)
+		args := tableFields(tokens[i+2 : end])
+		switch kind {
+		case "cmd":
+			if len(args) > 0 && len(args[0]) == 1 && args[0][0].kind == 's' {
+				cfg.extractSettingsFromVim(args[0][0].text, source)
+			}
+		case "autocmd":
+			if ac, ok := luaAutocmd(args, groups, source); ok {
+				cfg.Autocmds = append(cfg.Autocmds, ac)
+			}
+		case "command", "bufcommand":
+			if kind == "bufcommand" && len(args) > 0 {
+				args = args[1:]
+			}
+			if uc, ok := luaUserCommand(args, source); ok {
+				cfg.UserCommands = append(cfg.UserCommands, uc)
+			}
+		}
+		i = end
+	}
+}
+
+// luaValue reads the value assigned to an option: a string, number,
+// boolean, or a list of strings, which is joined with commas as :set
+// would show it
+func luaValue(tokens []luaToken) (string, bool) {
+	switch t := tokens[0]; {
+	case t.kind == 's' || t.kind == 'n':
+		return t.text, true
+	case t.kind == 'i' && (t.text == "true" || t.text == "false"):
+		return t.text, true
+	case t.text == "-" && len(tokens) > 1 && tokens[1].kind == 'n':
+		return "-" + tokens[1].text, true
+	case t.kind == '{':
+		var items []string
+		for _, field := range tableFields(tokens[1:matchingBrace(tokens, 0)]) {
+			if len(field) == 1 && field[0].kind == 's' {
+				items = append(items, field[0].text)
+			}
+		}
+		return strings.Join(items, ","), true
+	}
+	return "", false
+}
+
+// matchingParen returns the index of the ) closing the ( at tokens[open],
+// or len(tokens) if it is never closed
+func matchingParen(tokens []luaToken, open int) int {
+	depth := 0
+	for j := open; j < len(tokens); j++ {
+		switch tokens[j].text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+			if depth == 0 {
+				return j
+			}
+		}
+	}
+	return len(tokens)
+}
+
+// namedFields returns the name = value fields of a table constructor
+func namedFields(value []luaToken) map[string][]luaToken {
+	fields := map[string][]luaToken{}
+	if len(value) == 0 || value[0].kind != '{' {
+		return fields
+	}
+	for _, field := range tableFields(value[1:matchingBrace(value, 0)]) {
+		if len(field) >= 3 && field[0].kind == 'i' && field[1].kind == '=' {
+			fields[field[0].text] = field[2:]
+		}
+	}
+	return fields
+}
+
+// luaStrings reads a string or a list of strings
+func luaStrings(value []luaToken) []string {
+	var items []string
+	for _, t := range value {
+		if t.kind == 's' {
+			items = append(items, t.text)
+		}
+	}
+	return items
+}
+
+// luaAutocmd reads the arguments of nvim_create_autocmd: the events, and a
+// table with the pattern, the command or callback, desc, and group
+func luaAutocmd(args [][]luaToken, groups map[string]string, source string) (Autocmd, bool) {
+	ac := Autocmd{Source: source}
+	if len(args) < 2 || len(args[0]) == 0 {
+		return ac, false
+	}
+	ac.Events = luaStrings(args[0])
+	if len(ac.Events) == 0 {
+		return ac, false
+	}
+
+	opts := namedFields(args[1])
+	ac.Pattern = strings.Join(luaStrings(opts["pattern"]), ",")
+	if desc := luaStrings(opts["desc"]); len(desc) == 1 {
+		ac.Description = desc[0]
+	}
+	if cmd, ok := opts["command"]; ok && len(cmd) == 1 && cmd[0].kind == 's' {
+		ac.Command = cmd[0].text
+	} else if callback, ok := opts["callback"]; ok {
+		ac.Command = luaRhs(callback)
+	}
+	if group, ok := opts["group"]; ok {
+		switch {
+		case group[0].kind == 's':
+			ac.Group = group[0].text
+		case groups[group[0].text] != "":
+			ac.Group = groups[group[0].text]
+		default:
+			// An inline call, like vim.api.nvim_create_augroup("Name", ...)
+			if names := luaStrings(group); len(names) > 0 && len(group) > 1 && group[1].text == "(" {
+				ac.Group = names[0]
+			}
+		}
+	}
+	return ac, true
+}
+
+// luaUserCommand reads the arguments of nvim_create_user_command: the name,
+// the command or callback, and a table of options such as desc
+func luaUserCommand(args [][]luaToken, source string) (UserCommand, bool) {
+	uc := UserCommand{Source: source}
+	if len(args) < 2 || len(args[0]) != 1 || args[0][0].kind != 's' || len(args[1]) == 0 {
+		return uc, false
+	}
+	uc.Name = args[0][0].text
+	uc.Command = luaRhs(args[1])
+	if len(args) > 2 {
+		if desc := luaStrings(namedFields(args[2])["desc"]); len(desc) == 1 {
+			uc.Description = desc[0]
+		}
+	}
+	return uc, uc.Name != ""
+}
+
+var (
+	vimSetRe     = regexp.MustCompile(`^(?:se|set|setl|setlocal|setg|setglobal)\s+(.+)$`)
+	vimAugroupRe = regexp.MustCompile(`^aug(?:roup)?!?\s+(\S+)`)
+	vimAutocmdRe = regexp.MustCompile(`^au(?:tocmd)?!?\s+(.+)$`)
+	vimCommandRe = regexp.MustCompile(`^com(?:mand)?!?\s+(.+)$`)
+
+	// vimEventRe matches autocommand event names, to tell them from the
+	// group an autocmd line may name first
+	vimEventRe = regexp.MustCompile(`^(?:Buf|Win|File|Vim|Insert|Cursor|Text|Term|Cmd|Color|Focus|Lsp|User|Mode|Option|QuickFix|Search|Shell|Signal|Spell|Swap|Tab|Diagnostic|Recording|Menu|Remote|Source|Stdin|Chan|Complete|Dir|Encoding|Exit|Func|UI|Safe)[A-Za-z]*$`)
+)
+
+// extractSettingsFromVim extracts :set options, autocommands, and :command
+// definitions from Vimscript, whether a .vim file or a vim.cmd string
+func (cfg *NvimConfig) extractSettingsFromVim(content, source string) {
+	group := ""
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "\"") {
+			continue
+		}
+
+		if m := vimAugroupRe.FindStringSubmatch(line); m != nil {
+			group = m[1]
+			if strings.EqualFold(group, "END") {
+				group = ""
+			}
+			continue
+		}
+		if m := vimSetRe.FindStringSubmatch(line); m != nil {
+			cfg.setVimOptions(m[1])
+			continue
+		}
+		if m := vimAutocmdRe.FindStringSubmatch(line); m != nil {
+			if ac, ok := vimAutocmd(m[1], group, source); ok {
+				cfg.Autocmds = append(cfg.Autocmds, ac)
+			}
+			continue
+		}
+		if m := vimCommandRe.FindStringSubmatch(line); m != nil {
+			if uc, ok := vimUserCommand(m[1], source); ok {
+				cfg.UserCommands = append(cfg.UserCommands, uc)
+			}
+		}
+	}
+}
+
+// setVimOptions reads the arguments of :set: option, nooption,
+// option=value, and option:value. Appending with += and toggling with ! or
+// inv depend on the current value, so they are left out.
+func (cfg *NvimConfig) setVimOptions(args string) {
+	if i := strings.Index(args, ` "`); i >= 0 {
+		args = args[:i]
+	}
+	for _, arg := range strings.Fields(args) {
+		if name, value, ok := strings.Cut(arg, "="); ok {
+			if strings.HasSuffix(name, "+") || strings.HasSuffix(name, "-") || strings.HasSuffix(name, "^") {
+				continue
+			}
+			cfg.setOption(name, value)
+			continue
+		}
+		if name, value, ok := strings.Cut(arg, ":"); ok {
+			cfg.setOption(name, value)
+			continue
+		}
+		if strings.ContainsAny(arg, "!&?<") || strings.HasPrefix(arg, "inv") || arg == "all" {
+			continue
+		}
+		if name, ok := strings.CutPrefix(arg, "no"); ok {
+			cfg.setOption(name, "false")
+			continue
+		}
+		cfg.setOption(arg, "true")
+	}
+}
+
+// vimAutocmd reads the arguments of :autocmd: an optional group, the
+// events, the pattern, and the command
+func vimAutocmd(args, group, source string) (Autocmd, bool) {
+	fields := strings.Fields(args)
+	if len(fields) > 0 && !isVimEvents(fields[0]) {
+		group = fields[0]
+		fields = fields[1:]
+	}
+	// ++once and ++nested modify the command
+	var command []string
+	for i, f := range fields {
+		if i >= 2 && !strings.HasPrefix(f, "++") {
+			command = fields[i:]
+			break
+		}
+	}
+	if len(fields) < 3 || !isVimEvents(fields[0]) || len(command) == 0 {
+		return Autocmd{}, false
+	}
+	return Autocmd{
+		Events:  strings.Split(fields[0], ","),
+		Pattern: fields[1],
+		Command: strings.Join(command, " "),
+		Group:   group,
+		Source:  source,
+	}, true
+}
+
+// isVimEvents reports whether s is a comma-separated list of autocommand
+// events
+func isVimEvents(s string) bool {
+	for _, event := range strings.Split(s, ",") {
+		if event != "*" && !vimEventRe.MatchString(event) {
+			return false
+		}
+	}
+	return true
+}
+
+// vimUserCommand reads the arguments of :command: attributes like -nargs=1,
+// the name, and the replacement text
+func vimUserCommand(args, source string) (UserCommand, bool) {
+	fields := strings.Fields(args)
+	for len(fields) > 0 && strings.HasPrefix(fields[0], "-") {
+		fields = fields[1:]
+	}
+	if len(fields) < 2 || fields[0][0] < 'A' || fields[0][0] > 'Z' {
+		return UserCommand{}, false
+	}
+	return UserCommand{
+		Name:    fields[0],
+		Command: strings.Join(fields[1:], " "),
+		Source:  source,
+	}, true
+}
@@ -0,0 +1,188 @@
+package parser
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// Plugin managers, as recorded in Plugin.Manager
+const (
+	ManagerLazy   = "lazy.nvim"
+	ManagerPacker = "packer.nvim"
+	ManagerPlug   = "vim-plug"
+	ManagerPaq    = "paq-nvim"
+	ManagerRocks  = "rocks.nvim"
+)
+
+// maxPluginFiles bounds how many config files are searched for plugin
+// declarations
+const maxPluginFiles = 200
+
+var (
+	// use 'owner/repo', use { "owner/repo", ... }, use({ 'owner/repo' })
+	packerUseRe = regexp.MustCompile(`^use\s*\(?\s*\{?\s*["']([\w.-]+/[\w.-]+)["']`)
+	// Plug 'owner/repo', Plug('owner/repo')
+	plugRe = regexp.MustCompile(`^Plug\s*\(?\s*["']([\w.-]+/[\w.-]+)["']`)
+	// require("paq") { ... }, require "paq" { ... }
+	paqRequireRe = regexp.MustCompile(`require\s*\(?\s*["']paq["']`)
+	// "owner/repo" anywhere in a line
+	repoRe = regexp.MustCompile(`["']([\w.-]+/[\w.-]+)["']`)
+	// use {, with the spec's repository on the next line
+	packerOpenRe = regexp.MustCompile(`^use\s*\(?\s*\{?\s*$`)
+	// disable = true in a packer spec
+	packerDisableRe = regexp.MustCompile(`\bdisable\s*=\s*true`)
+)
+
+// parsePluginManagers finds plugins declared for packer.nvim, vim-plug,
+// paq-nvim, and rocks.nvim, which keep their specs in the config files
+// themselves rather than in a directory of specs like lazy.nvim
+func (cfg *NvimConfig) parsePluginManagers(configPath string) {
+	for _, file := range pluginConfigFiles(configPath) {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		cfg.parsePluginDeclarations(string(content), strings.HasSuffix(file, ".vim"))
+	}
+
+	cfg.parseRocksToml(filepath.Join(configPath, "rocks.toml"))
+}
+
+// pluginConfigFiles returns init.lua, init.vim, and the Lua and Vimscript
+// files under lua/ and plugin/, up to maxPluginFiles
+func pluginConfigFiles(configPath string) []string {
+	files := []string{
+		filepath.Join(configPath, "init.lua"),
+		filepath.Join(configPath, "init.vim"),
+	}
+	for _, dir := range []string{"lua", "plugin"} {
+		filepath.WalkDir(filepath.Join(configPath, dir), func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if len(files) >= maxPluginFiles {
+				return filepath.SkipAll
+			}
+			if !d.IsDir() && (strings.HasSuffix(path, ".lua") || strings.HasSuffix(path, ".vim")) {
+				files = append(files, path)
+			}
+			return nil
+		})
+	}
+	return files
+}
+
+// parsePluginDeclarations extracts the plugins a file declares with packer's
+// use, vim-plug's Plug, or a paq table
+func (cfg *NvimConfig) parsePluginDeclarations(text string, vimscript bool) {
+	comment := "--"
+	if vimscript {
+		comment = "\""
+	}
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, comment) {
+			lines = append(lines, line)
+		}
+	}
+
+	for i, line := range lines {
+		if m := plugRe.FindStringSubmatch(line); m != nil {
+			cfg.addPlugin(m[1], ManagerPlug, true)
+			continue
+		}
+		if vimscript {
+			continue
+		}
+		if packerOpenRe.MatchString(line) && i+1 < len(lines) {
+			line += " " + lines[i+1]
+		}
+		if m := packerUseRe.FindStringSubmatch(line); m != nil {
+			cfg.addPlugin(m[1], ManagerPacker, !packerDisableRe.MatchString(packerSpec(lines[i:])))
+		}
+	}
+
+	// paq takes one table listing every plugin
+	if loc := paqRequireRe.FindStringIndex(text); loc != nil && !vimscript {
+		for _, line := range strings.Split(text[loc[1]:], "\n") {
+			if strings.HasPrefix(strings.TrimSpace(line), comment) {
+				continue
+			}
+			for _, m := range repoRe.FindAllStringSubmatch(line, -1) {
+				cfg.addPlugin(m[1], ManagerPaq, true)
+			}
+		}
+	}
+}
+
+// packerSpec returns the text of the use statement starting at lines[0],
+// up to the line before the next use
+func packerSpec(lines []string) string {
+	end := len(lines)
+	for i := 1; i < len(lines); i++ {
+		if packerUseRe.MatchString(lines[i]) || packerOpenRe.MatchString(lines[i]) {
+			end = i
+			break
+		}
+	}
+	return strings.Join(lines[:end], "\n")
+}
+
+// parseRocksToml reads the plugins rocks.nvim installs from rocks.toml; they
+// are luarocks packages, named without an owner
+func (cfg *NvimConfig) parseRocksToml(path string) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	var manifest struct {
+		Plugins map[string]interface{} `toml:"plugins"`
+	}
+	if err := toml.Unmarshal(content, &manifest); err != nil {
+		return
+	}
+
+	names := make([]string, 0, len(manifest.Plugins))
+	for name := range manifest.Plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		cfg.addPlugin(name, ManagerRocks, true)
+	}
+}
+
+// addPlugin records a plugin by its repository name, unless it was already
+// found
+func (cfg *NvimConfig) addPlugin(repo, manager string, enabled bool) {
+	name := repo
+	if _, after, ok := strings.Cut(repo, "/"); ok {
+		name = after
+	}
+	for _, p := range cfg.Plugins {
+		if p.Name == name {
+			return
+		}
+	}
+	cfg.Plugins = append(cfg.Plugins, Plugin{Name: name, Enabled: enabled, Manager: manager})
+}
+
+// PluginManagers returns the plugin managers whose plugins were found
+func (cfg *NvimConfig) PluginManagers() []string {
+	var managers []string
+	seen := map[string]bool{}
+	for _, p := range cfg.Plugins {
+		if p.Manager != "" && !seen[p.Manager] {
+			seen[p.Manager] = true
+			managers = append(managers, p.Manager)
+		}
+	}
+	return managers
+}
@@ -0,0 +1,388 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ShellConfig represents the aliases and functions defined in the user's
+// shell startup files
+type ShellConfig struct {
+	// Name is the shell the user runs, such as zsh or fish, empty if unknown
+	Name      string
+	Aliases   []ShellAlias
+	Functions []ShellFunction
+	Files     []string
+}
+
+// ShellAlias represents an alias such as alias gs='git status'. Fish
+// abbreviations are kept as aliases too.
+type ShellAlias struct {
+	Name      string
+	Expansion string
+	Source    string // File where defined
+	// Shell is the shell whose startup files define the alias: bash, zsh,
+	// fish, or empty for a file any POSIX shell could source
+	Shell string
+}
+
+// ShellFunction represents a shell function; only its name is kept
+type ShellFunction struct {
+	Name   string
+	Source string // File where defined
+	Shell  string // As for ShellAlias
+}
+
+var (
+	// name() {, function name {, function name() {
+	shellFuncRe = regexp.MustCompile(`^(?:function\s+([\w.:-]+)\s*(?:\(\))?|([\w.:-]+)\s*\(\))\s*\{?`)
+	// source ~/.aliases, . "$HOME/.aliases", [ -f ~/.aliases ] && . ~/.aliases
+	shellSourceRe = regexp.MustCompile(`(?:^|[;&|]\s*|\bthen\s+)(?:source|\.)\s+([^\s;]+)`)
+)
+
+// StartupShell returns the shell that reads a startup file, judging by its
+// name: zsh for .zshrc, bash for .bashrc, fish for config.fish, and empty
+// for files like .aliases that any POSIX shell could source
+func StartupShell(path string) string {
+	base := filepath.Base(path)
+	switch {
+	case strings.HasSuffix(base, ".fish"):
+		return "fish"
+	case strings.HasPrefix(base, ".zsh"), strings.HasPrefix(base, ".zprofile"), strings.HasPrefix(base, ".zlogin"):
+		return "zsh"
+	case strings.HasPrefix(base, ".bash"):
+		return "bash"
+	}
+	return ""
+}
+
+// maxSourceDepth bounds how deeply sourced files are followed
+const maxSourceDepth = 3
+
+// ParseShellConfig parses shell startup files such as .zshrc and .bashrc,
+// following the files they source. Missing files are skipped; an error is
+// returned only when none of paths could be read.
+func ParseShellConfig(paths ...string) (*ShellConfig, error) {
+	cfg := &ShellConfig{}
+	seen := map[string]bool{}
+
+	var firstErr error
+	for _, path := range paths {
+		if err := cfg.parseFile(path, StartupShell(path), seen, 0); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if len(cfg.Files) == 0 && firstErr != nil {
+		return nil, firstErr
+	}
+	return cfg, nil
+}
+
+// parseFile parses one startup file and, up to maxSourceDepth, the files it
+// sources. Sourced files belong to the shell of the file sourcing them.
+func (cfg *ShellConfig) parseFile(path, shell string, seen map[string]bool, depth int) error {
+	if seen[path] {
+		return nil
+	}
+	seen[path] = true
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	cfg.Files = append(cfg.Files, path)
+	if shell == "fish" && filepath.Base(path) == "config.fish" {
+		cfg.addFishFunctions(filepath.Join(filepath.Dir(path), "functions"))
+	}
+
+	for _, line := range joinContinuations(strings.Split(string(content), "\n")) {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "alias "):
+			cfg.extractAliases(line, path, shell)
+		case shell == "fish" && strings.HasPrefix(line, "abbr "):
+			cfg.extractAbbr(line, path)
+		case shellSourceRe.MatchString(line):
+			if depth >= maxSourceDepth {
+				continue
+			}
+			m := shellSourceRe.FindStringSubmatch(line)
+			if sourced := expandShellPath(m[1], filepath.Dir(path)); sourced != "" {
+				cfg.parseFile(sourced, shell, seen, depth+1)
+			}
+		default:
+			if m := shellFuncRe.FindStringSubmatch(line); m != nil {
+				name := m[1] + m[2]
+				if name != "" && !strings.HasPrefix(name, "_") {
+					cfg.Functions = append(cfg.Functions, ShellFunction{Name: name, Source: path, Shell: shell})
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// joinContinuations joins lines ending in a backslash with the next
+func joinContinuations(lines []string) []string {
+	var joined []string
+	var current strings.Builder
+	for _, line := range lines {
+		if strings.HasSuffix(line, "\\") {
+			current.WriteString(strings.TrimSuffix(line, "\\"))
+			continue
+		}
+		current.WriteString(line)
+		joined = append(joined, current.String())
+		current.Reset()
+	}
+	if current.Len() > 0 {
+		joined = append(joined, current.String())
+	}
+	return joined
+}
+
+// extractAliases parses an alias line, which may define several aliases and
+// take zsh's -g/-s flags. Fish also accepts alias name 'expansion'.
+func (cfg *ShellConfig) extractAliases(line, source, shell string) {
+	var words []string
+	for _, word := range shellWords(strings.TrimPrefix(line, "alias ")) {
+		if !strings.HasPrefix(word, "-") {
+			words = append(words, word)
+		}
+	}
+	if shell == "fish" && len(words) >= 2 && !strings.Contains(words[0], "=") {
+		cfg.Aliases = append(cfg.Aliases, ShellAlias{Name: words[0], Expansion: strings.Join(words[1:], " "), Source: source, Shell: shell})
+		return
+	}
+
+	for _, word := range words {
+		name, expansion, ok := strings.Cut(word, "=")
+		if !ok || name == "" {
+			continue
+		}
+		cfg.Aliases = append(cfg.Aliases, ShellAlias{Name: name, Expansion: expansion, Source: source, Shell: shell})
+	}
+}
+
+// abbrArgFlags are abbr options that take an argument
+var abbrArgFlags = map[string]bool{
+	"--position": true, "-p": true, "--function": true, "-f": true,
+	"--regex": true, "-r": true, "--command": true, "-c": true,
+}
+
+// extractAbbr parses a fish abbreviation: abbr [-a] [options] name expansion
+func (cfg *ShellConfig) extractAbbr(line, source string) {
+	words := shellWords(strings.TrimPrefix(line, "abbr "))
+	var args []string
+	for i := 0; i < len(words); i++ {
+		switch {
+		case words[i] == "--":
+			args = append(args, words[i+1:]...)
+			i = len(words)
+		case strings.HasPrefix(words[i], "-"):
+			if abbrArgFlags[words[i]] {
+				i++
+			}
+		default:
+			args = append(args, words[i])
+		}
+	}
+	if len(args) < 2 {
+		return
+	}
+	cfg.Aliases = append(cfg.Aliases, ShellAlias{Name: args[0], Expansion: strings.Join(args[1:], " "), Source: source, Shell: "fish"})
+}
+
+// addFishFunctions records the functions fish autoloads from dir, one per
+// file named after the function
+func (cfg *ShellConfig) addFishFunctions(dir string) {
+	files, _ := filepath.Glob(filepath.Join(dir, "*.fish"))
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".fish")
+		if !strings.HasPrefix(name, "_") && !strings.HasPrefix(name, "fish_") {
+			cfg.Functions = append(cfg.Functions, ShellFunction{Name: name, Source: file, Shell: "fish"})
+		}
+	}
+}
+
+// shellWords splits s into words the way the shell does for quoting, stopping
+// at a comment or command separator
+func shellWords(s string) []string {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote byte
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else if c == '\\' && quote == '"' && i+1 < len(s) {
+				i++
+				word.WriteByte(s[i])
+			} else {
+				word.WriteByte(c)
+			}
+		case c == '\'' || c == '"':
+			quote = c
+			inWord = true
+		case c == '\\' && i+1 < len(s):
+			i++
+			word.WriteByte(s[i])
+			inWord = true
+		case c == ' ' || c == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		case c == ';' || c == '&' || c == '|' || (c == '#' && !inWord):
+			if inWord {
+				words = append(words, word.String())
+			}
+			return words
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words
+}
+
+// expandShellPath expands ~ and $HOME in a sourced path, resolving relative
+// paths against dir. Paths using other variables are skipped.
+func expandShellPath(path, dir string) string {
+	path = strings.Trim(path, `"'`)
+	home, _ := os.UserHomeDir()
+	switch {
+	case strings.HasPrefix(path, "~/"):
+		path = filepath.Join(home, path[2:])
+	case strings.HasPrefix(path, "$HOME/"), strings.HasPrefix(path, "${HOME}/"):
+		_, rest, _ := strings.Cut(path, "/")
+		path = filepath.Join(home, rest)
+	}
+	if strings.ContainsAny(path, "$`*") {
+		return ""
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	return path
+}
+
+// RelevantAliases returns up to limit aliases whose name or expansion shares
+// words with the query, best matches first
+func (cfg *ShellConfig) RelevantAliases(query string, limit int) []ShellAlias {
+	if cfg == nil {
+		return nil
+	}
+	words := queryWords(query)
+
+	type scored struct {
+		alias ShellAlias
+		score int
+	}
+	var matches []scored
+	for _, a := range cfg.Aliases {
+		score := 0
+		if words[strings.ToLower(a.Name)] {
+			score += 2
+		}
+		for _, w := range strings.Fields(strings.ToLower(a.Expansion)) {
+			if words[strings.Trim(w, "-")] {
+				score++
+			}
+		}
+		if score > 0 {
+			matches = append(matches, scored{a, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+
+	var relevant []ShellAlias
+	for _, m := range matches {
+		if len(relevant) >= limit {
+			break
+		}
+		relevant = append(relevant, m.alias)
+	}
+	return relevant
+}
+
+// RelevantFunctions returns up to limit functions whose name contains a word
+// of the query
+func (cfg *ShellConfig) RelevantFunctions(query string, limit int) []ShellFunction {
+	if cfg == nil {
+		return nil
+	}
+	var relevant []ShellFunction
+	for _, f := range cfg.Functions {
+		if len(relevant) >= limit {
+			break
+		}
+		name := strings.ToLower(f.Name)
+		for w := range queryWords(query) {
+			if len(w) >= 3 && strings.Contains(name, w) {
+				relevant = append(relevant, f)
+				break
+			}
+		}
+	}
+	return relevant
+}
+
+// AliasFor returns the alias that command is, or starts with, the expansion
+// of; the longest expansion wins
+func (cfg *ShellConfig) AliasFor(command string) (ShellAlias, bool) {
+	if cfg == nil {
+		return ShellAlias{}, false
+	}
+	command = strings.Join(strings.Fields(command), " ")
+
+	var best ShellAlias
+	for _, a := range cfg.Aliases {
+		expansion := strings.Join(strings.Fields(a.Expansion), " ")
+		// A bare command name isn't worth pointing out
+		if !strings.Contains(expansion, " ") || len(expansion) <= len(best.Expansion) {
+			continue
+		}
+		if command == expansion || strings.HasPrefix(command, expansion+" ") {
+			best = a
+			best.Expansion = expansion
+		}
+	}
+	return best, best.Name != ""
+}
+
+// queryStopWords are words too common in questions to say anything about
+// which alias is meant
+var queryStopWords = map[string]bool{
+	"how": true, "do": true, "does": true, "i": true, "a": true, "an": true, "the": true,
+	"to": true, "in": true, "of": true, "on": true, "for": true, "my": true, "is": true,
+	"what": true, "with": true, "and": true, "or": true, "can": true, "it": true, "me": true,
+}
+
+// queryWords returns the lowercased words of query, without stop words
+func queryWords(query string) map[string]bool {
+	words := map[string]bool{}
+	for _, w := range strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_')
+	}) {
+		if !queryStopWords[w] {
+			words[w] = true
+		}
+	}
+	return words
+}
@@ -1,7 +1,6 @@
 package parser
 
 import (
-	"os"
 	"regexp"
 	"strings"
 )
@@ -12,6 +11,22 @@ type TmuxConfig struct {
 	Keymaps    []TmuxKeymap
 	ConfigPath string
 	Options    map[string]string
+	Plugins    []TmuxPlugin
+	// Includes lists the files read through source-file
+	Includes []string
+
+	// Live is set when the prefix, options, and bindings come from the
+	// running tmux server; Session is the session cliq was run in
+	Live    bool
+	Session *TmuxSession
+
+	// Parsing state: the files read, the file being read and its include
+	// depth, and the %if and if-shell conditions in effect
+	read       map[string]bool
+	file       string
+	depth      int
+	conditions []string
+	tpmRun     bool
 }
 
 // TmuxKeymap represents a tmux key binding
@@ -20,15 +35,14 @@ type TmuxKeymap struct {
 	Command     string
 	Description string
 	Table       string // key table (prefix, root, copy-mode, etc.)
+	Plugin      string // TPM plugin that adds it, if any
+	Condition   string // if-shell or %if condition it's bound under, if any
+	Source      string // File where bound
 }
 
-// ParseTmuxConfig parses a tmux configuration file
+// ParseTmuxConfig parses a tmux configuration file and the files it
+// includes with source-file
 func ParseTmuxConfig(configPath string) (*TmuxConfig, error) {
-	content, err := os.ReadFile(configPath)
-	if err != nil {
-		return nil, err
-	}
-
 	cfg := &TmuxConfig{
 		ConfigPath: configPath,
 		Prefix:     "C-b", // Default tmux prefix
@@ -36,24 +50,13 @@ func ParseTmuxConfig(configPath string) (*TmuxConfig, error) {
 		Options:    make(map[string]string),
 	}
 
-	text := string(content)
-	lines := strings.Split(text, "\n")
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-
-		// Skip empty lines and comments
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
-
-		// Handle line continuations
-		for strings.HasSuffix(line, "\\") {
-			line = strings.TrimSuffix(line, "\\")
-		}
+	if err := cfg.parseFile(configPath, 0); err != nil {
+		return nil, err
+	}
 
-		// Parse the line
-		cfg.parseLine(line)
+	// Bindings of TPM plugins, if the config never runs TPM itself
+	if !cfg.tpmRun {
+		cfg.applyPlugins()
 	}
 
 	return cfg, nil
@@ -61,6 +64,16 @@ func ParseTmuxConfig(configPath string) (*TmuxConfig, error) {
 
 // parseLine parses a single line of tmux configuration
 func (cfg *TmuxConfig) parseLine(line string) {
+	// Follow includes, and read conditional commands
+	if m := tmuxSourceRe.FindStringSubmatch(line); m != nil {
+		cfg.sourceFile(m[1])
+		return
+	}
+	if m := tmuxIfRe.FindStringSubmatch(line); m != nil {
+		cfg.ifShell(m[1])
+		return
+	}
+
 	// Extract prefix key
 	if strings.Contains(line, "prefix") {
 		cfg.extractPrefix(line)
@@ -76,6 +89,15 @@ func (cfg *TmuxConfig) parseLine(line string) {
 		strings.HasPrefix(line, "set-window-option") || strings.HasPrefix(line, "setw ") {
 		cfg.extractOption(line)
 	}
+
+	// Extract TPM plugins, whose bindings are made where TPM is run
+	if strings.Contains(line, "@plugin") {
+		cfg.extractPlugin(line)
+	}
+	if tmuxRunTPMRe.MatchString(line) && !cfg.tpmRun {
+		cfg.tpmRun = true
+		cfg.applyPlugins()
+	}
 }
 
 // extractPrefix extracts the prefix key setting
@@ -106,7 +128,9 @@ func (cfg *TmuxConfig) extractBinding(line string) {
 	line = regexp.MustCompile(`^bind(?:-key)?\s+`).ReplaceAllString(line, "")
 
 	km := TmuxKeymap{
-		Table: "prefix", // default table
+		Table:     "prefix", // default table
+		Condition: cfg.condition(),
+		Source:    cfg.file,
 	}
 
 	// Check for key table specification
@@ -129,8 +153,8 @@ func (cfg *TmuxConfig) extractBinding(line string) {
 	line = strings.Replace(line, " -r ", " ", -1)
 	line = strings.TrimPrefix(line, "-r ")
 
-	// Remove other flags
-	line = regexp.MustCompile(`-[cnt]\s+`).ReplaceAllString(line, "")
+	// Remove other flags, but not the -c of a key like C-c
+	line = regexp.MustCompile(`(?:^|\s)-[cnt]\s+`).ReplaceAllString(line, " ")
 
 	// Now parse the remaining: key command [args]
 	parts := strings.SplitN(strings.TrimSpace(line), " ", 2)
@@ -141,6 +165,13 @@ func (cfg *TmuxConfig) extractBinding(line string) {
 	km.Key = parts[0]
 	km.Command = strings.TrimSpace(parts[1])
 
+	// A { } block binds the commands in it, one per line
+	if strings.HasPrefix(km.Command, "{") {
+		if args := tmuxArgs(km.Command); len(args) > 0 {
+			km.Command = strings.Join(tmuxCommands(args[0]), " \\; ")
+		}
+	}
+
 	// Try to generate a description from the command
 	km.Description = describeCommand(km.Command)
 
@@ -0,0 +1,269 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// maxTmuxIncludeDepth bounds how deeply source-file includes are followed
+const maxTmuxIncludeDepth = 10
+
+var (
+	tmuxSourceRe = regexp.MustCompile(`^source(?:-file)?\s+(.+)$`)
+	tmuxIfRe     = regexp.MustCompile(`(?s)^if(?:-shell)?\s+(.+)$`)
+)
+
+// parseFile parses one tmux config file, following its source-file
+// includes
+func (cfg *TmuxConfig) parseFile(path string, depth int) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if cfg.read == nil {
+		cfg.read = map[string]bool{}
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		if cfg.read[abs] {
+			return nil
+		}
+		cfg.read[abs] = true
+	}
+	if depth > 0 {
+		cfg.Includes = append(cfg.Includes, path)
+	}
+
+	file, fileDepth := cfg.file, cfg.depth
+	cfg.file, cfg.depth = path, depth
+	defer func() { cfg.file, cfg.depth = file, fileDepth }()
+
+	for _, line := range tmuxLines(string(content)) {
+		if cfg.directive(line) {
+			continue
+		}
+		cfg.parseLine(line)
+	}
+	return nil
+}
+
+// tmuxLines splits a config into its commands, joining lines continued with
+// a trailing backslash and the lines of a { } block, and dropping blank
+// lines and comments
+func tmuxLines(text string) []string {
+	var lines []string
+	var pending strings.Builder
+	depth := 0
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if pending.Len() == 0 && (line == "" || strings.HasPrefix(line, "#")) {
+			continue
+		}
+
+		if strings.HasSuffix(line, "\\") && !strings.HasSuffix(line, "\\\\") {
+			pending.WriteString(strings.TrimSuffix(line, "\\"))
+			pending.WriteString(" ")
+			continue
+		}
+		pending.WriteString(line)
+		if depth += braceDepth(line); depth > 0 {
+			pending.WriteString("\n")
+			continue
+		}
+
+		lines = append(lines, strings.TrimSpace(pending.String()))
+		pending.Reset()
+		depth = 0
+	}
+	if pending.Len() > 0 {
+		lines = append(lines, strings.TrimSpace(pending.String()))
+	}
+	return lines
+}
+
+// braceDepth returns how many more { than } a line opens, outside quotes
+// and #{} formats
+func braceDepth(line string) int {
+	depth, formats := 0, 0
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '#' && i+1 < len(line) && line[i+1] == '{':
+			formats++
+			i++
+		case c == '{':
+			depth++
+		case c == '}':
+			if formats > 0 {
+				formats--
+			} else {
+				depth--
+			}
+		}
+	}
+	return depth
+}
+
+// directive handles %if, %elif, %else, and %endif, reporting whether line
+// was one. Commands between them are recorded as conditional.
+func (cfg *TmuxConfig) directive(line string) bool {
+	if !strings.HasPrefix(line, "%") {
+		return false
+	}
+	word, expr, _ := strings.Cut(line, " ")
+	expr = strings.Trim(strings.TrimSpace(expr), `"'`)
+	last := len(cfg.conditions) - 1
+	switch word {
+	case "%if":
+		cfg.conditions = append(cfg.conditions, expr)
+	case "%elif":
+		if last >= 0 {
+			cfg.conditions[last] = expr
+		}
+	case "%else":
+		if last >= 0 {
+			cfg.conditions[last] = "not " + cfg.conditions[last]
+		}
+	case "%endif":
+		if last >= 0 {
+			cfg.conditions = cfg.conditions[:last]
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// condition describes the conditions the command being parsed runs under
+func (cfg *TmuxConfig) condition() string {
+	return strings.Join(cfg.conditions, " and ")
+}
+
+// ifShell parses if-shell [-bF] [-t target] condition command [command],
+// reading the commands as conditional on the condition or its negation
+func (cfg *TmuxConfig) ifShell(args string) {
+	fields := tmuxArgs(args)
+	for len(fields) > 0 && strings.HasPrefix(fields[0], "-") {
+		if fields[0] == "-t" && len(fields) > 1 {
+			fields = fields[1:]
+		}
+		fields = fields[1:]
+	}
+	if len(fields) < 2 {
+		return
+	}
+
+	cond := fields[0]
+	branches := []string{cond, "not " + cond}
+	for i, commands := range fields[1:min(len(fields), 3)] {
+		cfg.conditions = append(cfg.conditions, branches[i])
+		for _, command := range tmuxCommands(commands) {
+			cfg.parseLine(command)
+		}
+		cfg.conditions = cfg.conditions[:len(cfg.conditions)-1]
+	}
+}
+
+// sourceFile follows source-file [-qnv] path ..., where paths may use ~,
+// environment variables, and globs, and are relative to the including file
+func (cfg *TmuxConfig) sourceFile(args string) {
+	if cfg.depth >= maxTmuxIncludeDepth {
+		return
+	}
+	home, _ := os.UserHomeDir()
+	for _, arg := range tmuxArgs(args) {
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+		if arg == "~" || strings.HasPrefix(arg, "~/") {
+			arg = filepath.Join(home, arg[1:])
+		}
+		arg = os.ExpandEnv(arg)
+		if !filepath.IsAbs(arg) && cfg.file != "" {
+			arg = filepath.Join(filepath.Dir(cfg.file), arg)
+		}
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			continue
+		}
+		for _, match := range matches {
+			cfg.parseFile(match, cfg.depth+1)
+		}
+	}
+}
+
+// tmuxArgs splits command arguments the way tmux does: words, 'literal' and
+// "escaped" strings, and { } blocks, whose contents are one argument
+func tmuxArgs(s string) []string {
+	var args []string
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '\'':
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end < 0 {
+				end = len(s) - i - 1
+			}
+			args = append(args, s[i+1:i+1+end])
+			i += end + 2
+		case c == '"':
+			var sb strings.Builder
+			j := i + 1
+			for ; j < len(s) && s[j] != '"'; j++ {
+				if s[j] == '\\' && j+1 < len(s) {
+					j++
+				}
+				sb.WriteByte(s[j])
+			}
+			args = append(args, sb.String())
+			i = j + 1
+		case c == '{':
+			depth, j := 0, i
+			for ; j < len(s); j++ {
+				if s[j] == '{' {
+					depth++
+				} else if s[j] == '}' {
+					if depth--; depth == 0 {
+						break
+					}
+				}
+			}
+			args = append(args, strings.TrimSpace(s[i+1:min(j, len(s))]))
+			i = j + 1
+		default:
+			j := i
+			for j < len(s) && s[j] != ' ' && s[j] != '\t' && s[j] != '\n' {
+				j++
+			}
+			args = append(args, s[i:j])
+			i = j
+		}
+	}
+	return args
+}
+
+// tmuxCommands splits the commands given to if-shell, one per line or
+// separated by a lone ;
+func tmuxCommands(s string) []string {
+	var commands []string
+	for _, line := range strings.Split(s, "\n") {
+		for _, command := range strings.Split(line, " ; ") {
+			if command = strings.TrimSpace(command); command != "" && !strings.HasPrefix(command, "#") {
+				commands = append(commands, command)
+			}
+		}
+	}
+	return commands
+}
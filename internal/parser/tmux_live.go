@@ -0,0 +1,198 @@
+package parser
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// liveTimeout bounds each query of the running tmux server
+const liveTimeout = time.Second
+
+// TmuxSession describes the tmux session cliq was run in
+type TmuxSession struct {
+	Name    string
+	Windows int
+	// Window is the current window's index and name, e.g. "2:editor"
+	Window string
+	Panes  int
+	Layout string
+}
+
+// sessionFormat is what display-message -p prints for TmuxSession, fields
+// separated by tabs
+const sessionFormat = "#{session_name}\t#{session_windows}\t#{window_index}:#{window_name}\t#{window_panes}\t#{window_layout}"
+
+// TmuxRunning reports whether cliq is running inside tmux, where the
+// server can be asked for its state
+func TmuxRunning() bool {
+	if os.Getenv("TMUX") == "" {
+		return false
+	}
+	_, err := exec.LookPath("tmux")
+	return err == nil
+}
+
+// ParseLiveTmux asks the running tmux server for its prefix, options, prefix
+// table bindings, and current session. Unlike the config file, this is what
+// tmux actually uses, including its defaults and bindings made at runtime.
+func ParseLiveTmux(ctx context.Context) (*TmuxConfig, error) {
+	cfg := &TmuxConfig{
+		Prefix:  "C-b",
+		Keymaps: []TmuxKeymap{},
+		Options: make(map[string]string),
+		Live:    true,
+	}
+
+	options, err := tmuxOutput(ctx, "show-options", "-g")
+	if err != nil {
+		return nil, err
+	}
+	cfg.parseLiveOptions(options)
+	if prefix := cfg.Options["prefix"]; prefix != "" {
+		cfg.Prefix = prefix
+	}
+
+	if keys, err := tmuxOutput(ctx, "list-keys", "-T", "prefix"); err == nil {
+		cfg.parseLiveKeys(keys)
+	}
+
+	if session, err := tmuxOutput(ctx, "display-message", "-p", sessionFormat); err == nil {
+		cfg.Session = parseSession(session)
+	}
+
+	return cfg, nil
+}
+
+// tmuxOutput runs a tmux command and returns its output
+func tmuxOutput(ctx context.Context, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, liveTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, "tmux", args...).Output()
+	if err != nil {
+		return "", fmt.Errorf("tmux %s: %w", args[0], err)
+	}
+	return string(out), nil
+}
+
+// parseLiveOptions parses show-options output: one "name value" per line
+func (cfg *TmuxConfig) parseLiveOptions(out string) {
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		name, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), " ")
+		if !ok {
+			continue
+		}
+		cfg.Options[name] = strings.Trim(strings.TrimSpace(value), "\"'")
+	}
+}
+
+// parseLiveKeys parses list-keys output, where each line is a bind-key
+// command: bind-key [-r] -T table key command [arguments]
+func (cfg *TmuxConfig) parseLiveKeys(out string) {
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 || fields[0] != "bind-key" {
+			continue
+		}
+
+		km := TmuxKeymap{Table: "prefix"}
+		i := 1
+		for ; i < len(fields) && strings.HasPrefix(fields[i], "-") && len(fields[i]) == 2; i++ {
+			if fields[i] == "-T" && i+1 < len(fields) {
+				i++
+				km.Table = fields[i]
+			}
+		}
+		if i+1 >= len(fields) {
+			continue
+		}
+
+		// tmux escapes keys that are special to its parser, like \; and \"
+		km.Key = strings.TrimPrefix(fields[i], "\\")
+		km.Command = strings.Join(fields[i+1:], " ")
+		km.Description = describeCommand(km.Command)
+		cfg.Keymaps = append(cfg.Keymaps, km)
+	}
+}
+
+// parseSession parses display-message output in sessionFormat
+func parseSession(out string) *TmuxSession {
+	fields := strings.Split(strings.TrimSpace(out), "\t")
+	if len(fields) != 5 || fields[0] == "" {
+		return nil
+	}
+	windows, _ := strconv.Atoi(fields[1])
+	panes, _ := strconv.Atoi(fields[3])
+	return &TmuxSession{
+		Name:    fields[0],
+		Windows: windows,
+		Window:  fields[2],
+		Panes:   panes,
+		Layout:  fields[4],
+	}
+}
+
+// MergeLive combines a parsed config file with the running server's state.
+// The server is authoritative for the prefix and options; bindings from the
+// file come first, updated to what the server has bound to the same key,
+// followed by the server's other bindings. Either argument may be nil.
+func MergeLive(file, live *TmuxConfig) *TmuxConfig {
+	if live == nil {
+		return file
+	}
+	if file == nil {
+		return live
+	}
+
+	merged := &TmuxConfig{
+		Prefix:     live.Prefix,
+		ConfigPath: file.ConfigPath,
+		Options:    make(map[string]string, len(file.Options)+len(live.Options)),
+		Plugins:    file.Plugins,
+		Includes:   file.Includes,
+		Session:    live.Session,
+		Live:       true,
+	}
+	for name, value := range file.Options {
+		merged.Options[name] = value
+	}
+	for name, value := range live.Options {
+		merged.Options[name] = value
+	}
+
+	bound := make(map[string]TmuxKeymap, len(live.Keymaps))
+	for _, km := range live.Keymaps {
+		bound[km.Table+" "+km.Key] = km
+	}
+	seen := map[string]bool{}
+	for _, km := range file.Keymaps {
+		id := km.Table + " " + km.Key
+		if current, ok := bound[id]; ok {
+			km.Command = current.Command
+			km.Description = current.Description
+		}
+		seen[id] = true
+		merged.Keymaps = append(merged.Keymaps, km)
+	}
+	for _, km := range live.Keymaps {
+		if !seen[km.Table+" "+km.Key] {
+			merged.Keymaps = append(merged.Keymaps, km)
+		}
+	}
+
+	return merged
+}
+
+// Describe summarises the session for a prompt, e.g. "session work, window
+// 2:editor of 3, 2 panes"
+func (s *TmuxSession) Describe() string {
+	return fmt.Sprintf("session %s, window %s of %d, %d panes (layout %s)", s.Name, s.Window, s.Windows, s.Panes, s.Layout)
+}
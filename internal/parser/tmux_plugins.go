@@ -0,0 +1,119 @@
+package parser
+
+import (
+	_ "embed"
+	"path"
+	"regexp"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// TmuxPlugin is a plugin declared for TPM, the tmux plugin manager
+type TmuxPlugin struct {
+	Name string // Repository name, such as tmux-resurrect
+	Repo string // As declared, such as tmux-plugins/tmux-resurrect
+	// About says what the plugin does, for plugins cliq knows
+	About string
+}
+
+//go:embed data/tmux_plugins.yaml
+var tmuxPluginsData []byte
+
+// tmuxPluginInfo is what cliq knows of a plugin: what it does and the
+// bindings it adds
+type tmuxPluginInfo struct {
+	Name     string `yaml:"name"`
+	About    string `yaml:"about"`
+	Bindings []struct {
+		Key         string `yaml:"key"`
+		Table       string `yaml:"table"`
+		Description string `yaml:"desc"`
+		// Option names the plugin option that changes the key
+		Option string `yaml:"option"`
+	} `yaml:"bindings"`
+}
+
+var (
+	// tmuxPluginRe matches a plugin declaration, set -g @plugin 'owner/repo'
+	tmuxPluginRe = regexp.MustCompile(`^(?:set-option|set)\s+(?:-\w+\s+)*@plugin\s+["']?([^"'\s]+)["']?`)
+	// tmuxRunTPMRe matches the line running TPM, run '~/.tmux/plugins/tpm/tpm'
+	tmuxRunTPMRe = regexp.MustCompile(`^run(?:-shell)?\s+(?:-b\s+)?["']?\S*tpm/tpm\b`)
+)
+
+// extractPlugin records a plugin declared with @plugin
+func (cfg *TmuxConfig) extractPlugin(line string) {
+	m := tmuxPluginRe.FindStringSubmatch(line)
+	if m == nil {
+		return
+	}
+	repo := m[1]
+	// owner/repo#branch, or a full git URL
+	name, _, _ := strings.Cut(repo, "#")
+	name = strings.TrimSuffix(path.Base(strings.ReplaceAll(name, ":", "/")), ".git")
+	for _, p := range cfg.Plugins {
+		if p.Name == name {
+			return
+		}
+	}
+	cfg.Plugins = append(cfg.Plugins, TmuxPlugin{Name: name, Repo: repo})
+}
+
+// applyPlugins adds the bindings of the plugins cliq knows. They are made
+// when TPM is run, usually at the end of tmux.conf, so a plugin's binding
+// replaces one the config made before for the same key.
+func (cfg *TmuxConfig) applyPlugins() {
+	if len(cfg.Plugins) == 0 {
+		return
+	}
+	var known []tmuxPluginInfo
+	if err := yaml.Unmarshal(tmuxPluginsData, &known); err != nil {
+		return
+	}
+
+	for i, p := range cfg.Plugins {
+		for _, info := range known {
+			if info.Name != p.Name {
+				continue
+			}
+			cfg.Plugins[i].About = info.About
+			for _, b := range info.Bindings {
+				km := TmuxKeymap{
+					Key:         b.Key,
+					Command:     pluginRhs(p.Name),
+					Description: b.Description,
+					Table:       b.Table,
+					Plugin:      p.Name,
+				}
+				if km.Table == "" {
+					km.Table = "prefix"
+				}
+				if key := cfg.Options[b.Option]; b.Option != "" && key != "" {
+					km.Key = key
+				}
+				cfg.bindPlugin(km)
+			}
+		}
+	}
+}
+
+// bindPlugin adds a plugin's binding, replacing the config's own binding of
+// the same key
+func (cfg *TmuxConfig) bindPlugin(km TmuxKeymap) {
+	for i, existing := range cfg.Keymaps {
+		if existing.Table == km.Table && existing.Key == km.Key {
+			cfg.Keymaps[i] = km
+			return
+		}
+	}
+	cfg.Keymaps = append(cfg.Keymaps, km)
+}
+
+// PluginNames returns the names of the declared plugins
+func (cfg *TmuxConfig) PluginNames() []string {
+	names := make([]string, 0, len(cfg.Plugins))
+	for _, p := range cfg.Plugins {
+		names = append(names, p.Name)
+	}
+	return names
+}
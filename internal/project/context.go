@@ -0,0 +1,121 @@
+// Package project finds what cliq knows about the project in the current
+// directory, such as a context pack shipped in the repository.
+package project
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ContextFile is where a project keeps its context pack, relative to the
+// project root
+const ContextFile = ".cliq/context.md"
+
+// maxContextBytes caps how much of a context pack goes into a prompt so a
+// long file can't crowd out the question
+const maxContextBytes = 4096
+
+// Context is a project's context pack: build commands, conventions, and key
+// scripts the maintainers want cliq to know about. It comes from whoever
+// wrote the repository, so it only goes into prompts once the user has
+// trusted it.
+type Context struct {
+	// Root is the directory containing .cliq/
+	Root string
+	// Path is the context file itself
+	Path string
+	// Text is the file's contents, trimmed to maxContextBytes
+	Text string
+	// Truncated reports whether Text was cut short
+	Truncated bool
+	// SHA256 is the hex SHA-256 of the whole file, which trusting the
+	// project records, so an edited file must be trusted again
+	SHA256 string
+}
+
+// FindContext looks for a context pack in dir and its parents, stopping at
+// the repository root or the home directory. It returns nil, nil when there
+// is none.
+func FindContext(dir string) (*Context, error) {
+	path, err := FindUp(dir, ContextFile)
+	if err != nil || path == "" {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return newContext(filepath.Dir(filepath.Dir(path)), path, data), nil
+}
+
+// FindUp looks for name, a path relative to a project root, in dir and its
+// parents, stopping at the repository root or the home directory. It
+// returns "" when there is none.
+func FindUp(dir, name string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	home, _ := os.UserHomeDir()
+
+	for {
+		path := filepath.Join(dir, name)
+		_, err := os.Stat(path)
+		if err == nil {
+			return path, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+
+		// Don't pick up a file from outside the repository we're in
+		if isRepoRoot(dir) || dir == home {
+			return "", nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// ReadContextFile reads a file a project's config names as extra context,
+// trimmed as a context pack is
+func ReadContextFile(path string) (*Context, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return newContext(filepath.Dir(path), path, data), nil
+}
+
+// newContext builds a Context from a context file's contents
+func newContext(root, path string, data []byte) *Context {
+	text := strings.TrimSpace(string(data))
+	truncated := false
+	if len(text) > maxContextBytes {
+		text = text[:maxContextBytes]
+		// Cut at a line boundary rather than mid-word
+		if i := strings.LastIndex(text, "\n"); i > 0 {
+			text = text[:i]
+		}
+		truncated = true
+	}
+	sum := sha256.Sum256(data)
+	return &Context{Root: root, Path: path, Text: text, Truncated: truncated, SHA256: hex.EncodeToString(sum[:])}
+}
+
+// isRepoRoot reports whether dir is the top of a version-controlled tree
+func isRepoRoot(dir string) bool {
+	for _, vcs := range []string{".git", ".hg", ".jj", ".svn"} {
+		if _, err := os.Stat(filepath.Join(dir, vcs)); err == nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,368 @@
+package project
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Task is a build, test, or other task a project defines in a Makefile,
+// justfile, or package.json
+type Task struct {
+	// Runner is the tool that runs the task: make, just, npm, yarn, pnpm,
+	// or bun
+	Runner string
+	Name   string
+	// Description is the task's doc comment, or for package.json scripts
+	// the script itself
+	Description string
+	// Source is the file the task was found in
+	Source string
+}
+
+// Command returns the shell command that runs the task
+func (t Task) Command() string {
+	switch t.Runner {
+	case "npm":
+		// npm has shorthands for the lifecycle scripts
+		if t.Name == "test" || t.Name == "start" {
+			return "npm " + t.Name
+		}
+		return "npm run " + t.Name
+	case "bun":
+		return "bun run " + t.Name
+	default:
+		return t.Runner + " " + t.Name
+	}
+}
+
+// String describes the task for a prompt or listing
+func (t Task) String() string {
+	if t.Description == "" {
+		return t.Command()
+	}
+	return t.Command() + " — " + t.Description
+}
+
+var (
+	makefileNames = []string{"GNUmakefile", "makefile", "Makefile"}
+	justfileNames = []string{"justfile", "Justfile", ".justfile"}
+
+	makeTargetRe  = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9_./-]*)\s*:([^=]|$)`)
+	justRecipeRe  = regexp.MustCompile(`^@?([A-Za-z][A-Za-z0-9_-]*)(\s[^:]*)?:(\s|$)`)
+	trailingDocRe = regexp.MustCompile(`\s##?\s*(.+)$`)
+)
+
+// FindTasks looks for task files in dir and its parents, up to the
+// repository root, and returns the tasks from the first directory that has
+// any
+func FindTasks(dir string) ([]Task, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	home, _ := os.UserHomeDir()
+
+	for {
+		tasks, err := tasksIn(dir)
+		if err != nil || len(tasks) > 0 {
+			return tasks, err
+		}
+		if isRepoRoot(dir) || dir == home {
+			return nil, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+// tasksIn parses the task files in one directory
+func tasksIn(dir string) ([]Task, error) {
+	var tasks []Task
+
+	for _, name := range makefileNames {
+		path := filepath.Join(dir, name)
+		found, err := parseMakefile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		// make reads only the first of these it finds
+		tasks = append(tasks, found...)
+		break
+	}
+
+	for _, name := range justfileNames {
+		path := filepath.Join(dir, name)
+		found, err := parseJustfile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		tasks = append(tasks, found...)
+		break
+	}
+
+	found, err := parsePackageJSON(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	tasks = append(tasks, found...)
+
+	return tasks, nil
+}
+
+// parseMakefile returns a Makefile's explicit targets. A target's
+// description comes from a comment on the line above ("## build: Build the
+// binary") or after it ("build: ## Build the binary").
+func parseMakefile(path string) ([]Task, error) {
+	return parseRecipes(path, "make", makeTargetRe)
+}
+
+// parseJustfile returns a justfile's public recipes, described by the
+// comment above each one
+func parseJustfile(path string) ([]Task, error) {
+	return parseRecipes(path, "just", justRecipeRe)
+}
+
+// parseRecipes scans a Makefile-like file for rule lines matching re, whose
+// first group is the task name
+func parseRecipes(path, runner string, re *regexp.Regexp) ([]Task, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var tasks []Task
+	seen := map[string]bool{}
+	comment := ""
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(line, "#") {
+			comment = strings.TrimSpace(strings.TrimLeft(trimmed, "#"))
+			continue
+		}
+
+		m := re.FindStringSubmatch(line)
+		if m == nil || strings.Contains(line, ":=") {
+			if trimmed == "" || !strings.HasPrefix(line, "\t") && !strings.HasPrefix(line, " ") {
+				comment = ""
+			}
+			continue
+		}
+
+		name := m[1]
+		// Private justfile recipes start with an underscore; file targets
+		// and pattern rules aren't tasks
+		if seen[name] || strings.HasPrefix(name, "_") || strings.ContainsAny(name, "%$/") || strings.Contains(name, ".") {
+			comment = ""
+			continue
+		}
+		seen[name] = true
+
+		desc := comment
+		if doc := trailingDocRe.FindStringSubmatch(line); doc != nil {
+			desc = strings.TrimSpace(doc[1])
+		}
+		// "## build: Build the binary" repeats the name
+		desc = strings.TrimSpace(strings.TrimPrefix(desc, name+":"))
+
+		tasks = append(tasks, Task{Runner: runner, Name: name, Description: desc, Source: path})
+		comment = ""
+	}
+	return tasks, scanner.Err()
+}
+
+// parsePackageJSON returns the scripts in dir's package.json, run with the
+// package manager whose lockfile is present
+func parsePackageJSON(dir string) ([]Task, error) {
+	path := filepath.Join(dir, "package.json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var pkg struct {
+		Scripts map[string]string `json:"scripts"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, err
+	}
+
+	runner := "npm"
+	for _, lock := range []struct{ file, runner string }{
+		{"pnpm-lock.yaml", "pnpm"},
+		{"yarn.lock", "yarn"},
+		{"bun.lockb", "bun"},
+		{"bun.lock", "bun"},
+	} {
+		if _, err := os.Stat(filepath.Join(dir, lock.file)); err == nil {
+			runner = lock.runner
+			break
+		}
+	}
+
+	names := make([]string, 0, len(pkg.Scripts))
+	for name := range pkg.Scripts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	tasks := make([]Task, 0, len(names))
+	for _, name := range names {
+		tasks = append(tasks, Task{Runner: runner, Name: name, Description: pkg.Scripts[name], Source: path})
+	}
+	return tasks, nil
+}
+
+// taskIntents maps words in a question to the task names that usually do
+// that job, most likely first. More specific intents come first so "run the
+// tests" is about testing, not running.
+var taskIntents = []struct {
+	words []string
+	names []string
+}{
+	{[]string{"coverage"}, []string{"test-cover", "coverage", "cover"}},
+	{[]string{"test", "tests", "testing"}, []string{"test", "tests", "check", "spec"}},
+	{[]string{"lint", "linter", "linting"}, []string{"lint", "vet", "check"}},
+	{[]string{"format", "formatting", "fmt"}, []string{"fmt", "format"}},
+	{[]string{"bench", "benchmark", "benchmarks"}, []string{"bench", "benchmark"}},
+	{[]string{"clean"}, []string{"clean"}},
+	{[]string{"build", "compile"}, []string{"build", "compile", "all", "dist"}},
+	{[]string{"install", "setup", "bootstrap", "dependencies", "deps"}, []string{"install", "setup", "bootstrap", "deps"}},
+	{[]string{"release", "deploy", "publish"}, []string{"release", "deploy", "publish"}},
+	{[]string{"run", "start", "serve", "launch"}, []string{"run", "start", "dev", "serve"}},
+}
+
+// runnerWords name the tools tasks are run with. A question is only taken
+// to be about the project's tasks when it names one, since words like
+// "run", "clean", and "format" come up as often in editor and terminal
+// questions.
+var runnerWords = map[string]string{
+	"make": "make", "just": "just", "npm": "npm", "yarn": "yarn", "pnpm": "pnpm", "bun": "bun",
+}
+
+// MatchTask picks the task that answers a question naming its runner, like
+// "how do I run the tests with make" or "what does npm run lint do", with
+// other matching tasks as alternatives. A task named right after its
+// runner is picked over the ones the question's intent suggests. ok is
+// false when the question names no runner or no task fits.
+func MatchTask(query string, tasks []Task) (best Task, others []Task, ok bool) {
+	if len(tasks) == 0 {
+		return Task{}, nil, false
+	}
+
+	fields := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == ':' || r == '-' || r == '_')
+	})
+	words := map[string]bool{}
+	runners := map[string]bool{}
+	for _, w := range fields {
+		words[w] = true
+		if r, ok := runnerWords[w]; ok {
+			runners[r] = true
+		}
+	}
+	if len(runners) == 0 {
+		return Task{}, nil, false
+	}
+	var named []Task
+	for _, t := range tasks {
+		if runners[t.Runner] {
+			named = append(named, t)
+		}
+	}
+	tasks = named
+
+	// "make lint" or "npm run build" names the task itself
+	for i, w := range fields {
+		if runnerWords[w] == "" {
+			continue
+		}
+		next := fields[i+1:]
+		if len(next) > 0 && next[0] == "run" {
+			next = next[1:]
+		}
+		if len(next) == 0 {
+			continue
+		}
+		for _, t := range tasks {
+			if t.Runner == runnerWords[w] && t.Name == next[0] {
+				return t, nil, true
+			}
+		}
+	}
+
+	for _, intent := range taskIntents {
+		if !hasAny(words, intent.words) {
+			continue
+		}
+
+		var matches []Task
+		seen := map[Task]bool{}
+		// Exact names first, in order of likelihood, then variants such as
+		// test:unit or test-integration
+		for _, name := range intent.names {
+			for _, t := range tasks {
+				if t.Name == name && !seen[t] {
+					matches = append(matches, t)
+					seen[t] = true
+				}
+			}
+		}
+		for _, name := range intent.names {
+			for _, t := range tasks {
+				if !seen[t] && isVariant(t.Name, name) {
+					matches = append(matches, t)
+					seen[t] = true
+				}
+			}
+		}
+		if len(matches) > 0 {
+			if len(matches) > 4 {
+				matches = matches[:4]
+			}
+			return matches[0], matches[1:], true
+		}
+	}
+	return Task{}, nil, false
+}
+
+// hasAny reports whether any of the candidates is in words
+func hasAny(words map[string]bool, candidates []string) bool {
+	for _, c := range candidates {
+		if words[c] {
+			return true
+		}
+	}
+	return false
+}
+
+// isVariant reports whether a task name is a variant of base, like
+// test:unit, test-race, or test_all
+func isVariant(name, base string) bool {
+	if !strings.HasPrefix(name, base) || len(name) == len(base) {
+		return false
+	}
+	switch name[len(base)] {
+	case ':', '-', '_':
+		return true
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package response
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Citation is a documentation source the explanation cites with a [n]
+// marker
+type Citation struct {
+	Marker int `json:"marker"`
+	// Source is the :help tag or man page cited
+	Source string `json:"source"`
+	// Excerpt is the part of the documentation the answer drew on
+	Excerpt string `json:"excerpt,omitempty"`
+}
+
+// citationRe matches a [n] footnote marker
+var citationRe = regexp.MustCompile(`\[(\d{1,2})\]`)
+
+// Cite resolves the [n] markers in the explanation against refs, where
+// refs[i] is what [i+1] referred to in the prompt. Cited references are
+// renumbered in order of first use, and markers that match no reference are
+// dropped.
+func (r *Response) Cite(refs []Citation) {
+	r.Citations = nil
+	if len(refs) == 0 || r.Explanation == "" {
+		return
+	}
+
+	renumbered := map[int]int{}
+	r.Explanation = replaceMarkers(r.Explanation, func(n int) string {
+		if n < 1 || n > len(refs) {
+			return ""
+		}
+		marker, ok := renumbered[n]
+		if !ok {
+			marker = len(r.Citations) + 1
+			renumbered[n] = marker
+			ref := refs[n-1]
+			ref.Marker = marker
+			r.Citations = append(r.Citations, ref)
+		}
+		return fmt.Sprintf("[%d]", marker)
+	})
+}
+
+// replaceMarkers replaces each [n] marker in text with repl(n); an empty
+// replacement drops the marker along with the space before it. Markers
+// inside `code` or straight after an identifier, as in arr[1], aren't
+// markers and are left alone.
+func replaceMarkers(text string, repl func(n int) string) string {
+	var sb strings.Builder
+	last := 0
+	for _, loc := range citationRe.FindAllStringSubmatchIndex(text, -1) {
+		if inCodeSpan(text, loc[0]) || loc[0] > 0 && isIdentByte(text[loc[0]-1]) {
+			continue
+		}
+		n, _ := strconv.Atoi(text[loc[2]:loc[3]])
+
+		start := loc[0]
+		replacement := repl(n)
+		if replacement == "" && start > last && text[start-1] == ' ' {
+			start--
+		}
+		sb.WriteString(text[last:start])
+		sb.WriteString(replacement)
+		last = loc[1]
+	}
+	sb.WriteString(text[last:])
+	return sb.String()
+}
+
+// inCodeSpan reports whether offset i of text falls inside a `code` span
+func inCodeSpan(text string, i int) bool {
+	return strings.Count(text[:i], "`")%2 == 1
+}
+
+// isIdentByte reports whether b can be part of an identifier
+func isIdentByte(b byte) bool {
+	return b == '_' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9'
+}
+
+// markdownFootnotes rewrites the [n] markers in text as markdown footnote
+// references
+func (r *Response) markdownFootnotes(text string) string {
+	if len(r.Citations) == 0 {
+		return text
+	}
+	return replaceMarkers(text, func(n int) string {
+		if n < 1 || n > len(r.Citations) {
+			return fmt.Sprintf("[%d]", n)
+		}
+		return fmt.Sprintf("[^%d]", n)
+	})
+}
@@ -2,21 +2,30 @@ package response
 
 import (
 	"encoding/json"
+	"fmt"
 	"regexp"
 	"strings"
 )
 
 // Response represents a parsed LLM response
 type Response struct {
-	Query        string   `json:"query,omitempty"`
-	Command      string   `json:"command"`
-	Explanation  string   `json:"explanation"`
-	Alternatives []string `json:"alternatives,omitempty"`
-	UserKeymaps  []string `json:"user_keymaps,omitempty"`
-	Related      []string `json:"related,omitempty"`
-	Tips         []string `json:"tips,omitempty"`
-	TmuxPrefix   string   `json:"tmux_prefix,omitempty"`
-	Raw          string   `json:"-"`
+	Query        string     `json:"query,omitempty"`
+	Command      string     `json:"command"`
+	Explanation  string     `json:"explanation"`
+	Alternatives []string   `json:"alternatives,omitempty"`
+	UserKeymaps  []string   `json:"user_keymaps,omitempty"`
+	WithPlugins  []string   `json:"with_plugins,omitempty"`
+	Related      []string   `json:"related,omitempty"`
+	Tips         []string   `json:"tips,omitempty"`
+	TmuxPrefix   string     `json:"tmux_prefix,omitempty"`
+	LayoutNotes  []string   `json:"layout_notes,omitempty"`
+	ShellNotes   []string   `json:"shell_notes,omitempty"`
+	Citations    []Citation `json:"citations,omitempty"`
+	Raw          string     `json:"-"`
+
+	// KeyNotation is how keybindings in the prose are written when the
+	// answer is shown: vim, ctrl, or caret, or empty for the model's own
+	KeyNotation string `json:"-"`
 }
 
 // Parse parses the LLM output into a structured Response
@@ -151,6 +160,7 @@ func (r *Response) ToJSON() (string, error) {
 
 // ToMarkdown returns the response as markdown
 func (r *Response) ToMarkdown() string {
+	r = r.displayed()
 	var sb strings.Builder
 
 	if r.Command != "" {
@@ -162,7 +172,7 @@ func (r *Response) ToMarkdown() string {
 
 	if r.Explanation != "" {
 		sb.WriteString("## Explanation\n\n")
-		sb.WriteString(r.Explanation)
+		sb.WriteString(r.markdownFootnotes(r.Explanation))
 		sb.WriteString("\n\n")
 	}
 
@@ -176,6 +186,16 @@ func (r *Response) ToMarkdown() string {
 		sb.WriteString("\n")
 	}
 
+	if len(r.ShellNotes) > 0 {
+		sb.WriteString("## In Your Shell\n\n")
+		for _, note := range r.ShellNotes {
+			sb.WriteString("- ")
+			sb.WriteString(note)
+			sb.WriteString("\n")
+		}
+		sb.WriteString("\n")
+	}
+
 	if len(r.UserKeymaps) > 0 {
 		sb.WriteString("## Your Keymaps\n\n")
 		for _, km := range r.UserKeymaps {
@@ -186,6 +206,26 @@ func (r *Response) ToMarkdown() string {
 		sb.WriteString("\n")
 	}
 
+	if len(r.WithPlugins) > 0 {
+		sb.WriteString("## With Your Plugins\n\n")
+		for _, p := range r.WithPlugins {
+			sb.WriteString("- ")
+			sb.WriteString(p)
+			sb.WriteString("\n")
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(r.LayoutNotes) > 0 {
+		sb.WriteString("## On Your Keyboard\n\n")
+		for _, note := range r.LayoutNotes {
+			sb.WriteString("- `")
+			sb.WriteString(note)
+			sb.WriteString("`\n")
+		}
+		sb.WriteString("\n")
+	}
+
 	if len(r.Related) > 0 {
 		sb.WriteString("## Related\n\n")
 		for _, rel := range r.Related {
@@ -203,6 +243,11 @@ func (r *Response) ToMarkdown() string {
 			sb.WriteString(tip)
 			sb.WriteString("\n")
 		}
+		sb.WriteString("\n")
+	}
+
+	for _, c := range r.Citations {
+		sb.WriteString(fmt.Sprintf("[^%d]: %s\n", c.Marker, c.Source))
 	}
 
 	return sb.String()
@@ -210,6 +255,7 @@ func (r *Response) ToMarkdown() string {
 
 // ToText returns the response as formatted plain text with styling
 func (r *Response) ToText() string {
+	r = r.displayed()
 	// If we have the raw output and couldn't parse it well, return it directly
 	if r.Command == "" && r.Explanation == "" && r.Raw != "" {
 		return r.Raw
@@ -218,3 +264,23 @@ func (r *Response) ToText() string {
 	// Use styled rendering
 	return RenderResponse(r)
 }
+
+// ToPlain returns the response as plain text without styling or icons, for
+// output that isn't going to a terminal
+func (r *Response) ToPlain() string {
+	r = r.displayed()
+	if r.Command == "" && r.Explanation == "" && r.Raw != "" {
+		return r.Raw
+	}
+	return RenderSimple(r)
+}
+
+// ToTUI returns the response styled for the interactive viewport, rendering
+// markdown in the model's output and wrapping prose to width
+func (r *Response) ToTUI(width int) string {
+	r = r.displayed()
+	if r.Command == "" && r.Explanation == "" && r.Raw != "" {
+		return RenderMarkdown(r.Raw, width)
+	}
+	return RenderResponseMarkdown(r, width)
+}
@@ -0,0 +1,199 @@
+package response
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Styles for markdown rendering
+var (
+	// CodeBlockStyle frames fenced code blocks
+	CodeBlockStyle = lipgloss.NewStyle().
+			BorderStyle(lipgloss.NormalBorder()).
+			BorderLeft(true).
+			BorderForeground(lipgloss.Color("238")).
+			PaddingLeft(1)
+
+	// CodeStyle for inline code
+	CodeStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("42"))
+
+	// FlagStyle for command-line flags in code
+	FlagStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("141"))
+
+	// StringStyle for quoted strings in code
+	StringStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("214"))
+
+	// BoldStyle for **strong** text
+	BoldStyle = lipgloss.NewStyle().Bold(true)
+)
+
+var (
+	headingRe     = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	bulletRe      = regexp.MustCompile(`^(\s*)[-*+•]\s+(.*)$`)
+	numberedRe    = regexp.MustCompile(`^(\s*)(\d+)[.)]\s+(.*)$`)
+	inlineCodeRe  = regexp.MustCompile("`([^`]+)`")
+	boldRe        = regexp.MustCompile(`\*\*([^*]+)\*\*`)
+	codeTokenRe   = regexp.MustCompile(`"[^"]*"?|'[^']*'?|<[^<>\s]+>|\S+|\s+`)
+	commentPrefix = []string{"#", "-- ", "//", "\" "}
+)
+
+// RenderMarkdown renders markdown from the model with terminal styling:
+// headings, lists, quotes, inline code, and fenced code blocks with light
+// syntax highlighting. Prose is wrapped to width when width is positive.
+// An unterminated code fence, as seen mid-stream, is rendered as code.
+func RenderMarkdown(text string, width int) string {
+	var out []string
+	var para []string
+	var code []string
+	inCode := false
+
+	flushPara := func() {
+		if len(para) == 0 {
+			return
+		}
+		out = append(out, wrap(renderInline(strings.Join(para, " ")), width))
+		para = nil
+	}
+	flushCode := func() {
+		if len(code) == 0 {
+			return
+		}
+		for i, line := range code {
+			code[i] = highlightCode(line)
+		}
+		out = append(out, CodeBlockStyle.Render(strings.Join(code, "\n")))
+		code = nil
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "```") {
+			if inCode {
+				flushCode()
+			} else {
+				flushPara()
+			}
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			code = append(code, line)
+			continue
+		}
+
+		switch {
+		case trimmed == "":
+			flushPara()
+			out = append(out, "")
+		case headingRe.MatchString(trimmed):
+			flushPara()
+			out = append(out, SectionStyle.Render(headingRe.FindStringSubmatch(trimmed)[2]))
+		case bulletRe.MatchString(line):
+			flushPara()
+			m := bulletRe.FindStringSubmatch(line)
+			out = append(out, wrapItem(m[1]+"  "+DimStyle.Render(IconBullet)+" ", renderInline(m[2]), width))
+		case numberedRe.MatchString(line):
+			flushPara()
+			m := numberedRe.FindStringSubmatch(line)
+			out = append(out, wrapItem(m[1]+"  "+DimStyle.Render(m[2]+".")+" ", renderInline(m[3]), width))
+		case strings.HasPrefix(trimmed, ">"):
+			flushPara()
+			out = append(out, TipStyle.Render(strings.TrimSpace(strings.TrimPrefix(trimmed, ">"))))
+		default:
+			para = append(para, trimmed)
+		}
+	}
+	flushPara()
+	flushCode()
+
+	return collapseBlankLines(strings.Join(out, "\n"))
+}
+
+// renderInline styles `code` spans and **bold** text within a line
+func renderInline(text string) string {
+	text = inlineCodeRe.ReplaceAllStringFunc(text, func(s string) string {
+		return CodeStyle.Render(s[1 : len(s)-1])
+	})
+	return boldRe.ReplaceAllStringFunc(text, func(s string) string {
+		return BoldStyle.Render(s[2 : len(s)-2])
+	})
+}
+
+// highlightCode applies shell-style highlighting to one line of code:
+// the command name, flags, quoted strings, key notation, and comments
+func highlightCode(line string) string {
+	trimmed := strings.TrimSpace(line)
+	for _, prefix := range commentPrefix {
+		if strings.HasPrefix(trimmed, prefix) {
+			return DimStyle.Render(line)
+		}
+	}
+
+	var sb strings.Builder
+	first := true
+	for _, tok := range codeTokenRe.FindAllString(line, -1) {
+		switch {
+		case strings.TrimSpace(tok) == "":
+			sb.WriteString(tok)
+			continue
+		case tok[0] == '"' || tok[0] == '\'':
+			sb.WriteString(StringStyle.Render(tok))
+		case tok[0] == '<' && strings.HasSuffix(tok, ">"):
+			sb.WriteString(KeymapStyle.Render(tok))
+		case first:
+			sb.WriteString(CommandStyle.Render(tok))
+		case tok[0] == '-' && len(tok) > 1:
+			sb.WriteString(FlagStyle.Render(tok))
+		case tok == "|" || tok == "&&" || tok == "||" || tok == ";":
+			sb.WriteString(DimStyle.Render(tok))
+			first = true
+			continue
+		default:
+			sb.WriteString(tok)
+		}
+		first = false
+	}
+	return sb.String()
+}
+
+// wrap wraps styled text to width, leaving it untouched when width is unset
+func wrap(text string, width int) string {
+	if width <= 0 {
+		return text
+	}
+	return lipgloss.NewStyle().Width(width).Render(text)
+}
+
+// wrapItem wraps a list item so continuation lines align with its text
+func wrapItem(marker, text string, width int) string {
+	indent := lipgloss.Width(marker)
+	if width <= indent {
+		return marker + text
+	}
+	body := lipgloss.NewStyle().Width(width - indent).Render(text)
+	return lipgloss.JoinHorizontal(lipgloss.Top, marker, body)
+}
+
+// collapseBlankLines squeezes runs of blank lines down to one
+func collapseBlankLines(text string) string {
+	var out []string
+	blank := false
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == "" {
+			if blank {
+				continue
+			}
+			blank = true
+		} else {
+			blank = false
+		}
+		out = append(out, line)
+	}
+	return strings.Trim(strings.Join(out, "\n"), "\n")
+}
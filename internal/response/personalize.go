@@ -0,0 +1,156 @@
+package response
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/cliq-cli/cliq/internal/keynotation"
+	"github.com/cliq-cli/cliq/internal/locale"
+)
+
+// DefaultTmuxPrefix is the prefix key tmux uses unless configured otherwise
+const DefaultTmuxPrefix = "C-b"
+
+var (
+	// "prefix + %" / "prefix %" style notation used in answers
+	prefixNotationRe = regexp.MustCompile(`(?i)\bprefix\s*\+\s*`)
+	// Statements about the default prefix, e.g. "Default prefix is Ctrl-b."
+	defaultPrefixRe = regexp.MustCompile(`(?i)\b(?:the\s+)?default prefix (?:is\s+)?(?:Ctrl[-+]b|C-b)\b`)
+	// Literal mentions of the default prefix key
+	defaultPrefixKeyRe = regexp.MustCompile(`(?i)\b(?:Ctrl[-+]b|C-b)\b`)
+	// <leader> / <Leader> in keybinding notation
+	leaderRe = regexp.MustCompile(`(?i)<leader>`)
+)
+
+// Personalize rewrites generic keybinding notation in the response's prose
+// to use the user's actual tmux prefix and Neovim leader key. Empty values
+// leave the corresponding notation untouched. The command, alternatives,
+// and raw answer are left as the model wrote them, since they're copied
+// and run.
+func (r *Response) Personalize(tmuxPrefix, leader string) {
+	rewrite := func(text string) string {
+		return personalizeText(text, tmuxPrefix, leader)
+	}
+
+	r.Explanation = rewrite(r.Explanation)
+	for i := range r.Related {
+		r.Related[i] = rewrite(r.Related[i])
+	}
+	for i := range r.Tips {
+		r.Tips[i] = rewrite(r.Tips[i])
+	}
+}
+
+// displayed returns the response as it's shown: with every keybinding in
+// the prose, including the user's keymaps and prefix, written in its
+// KeyNotation (see package keynotation). The command is left as the model
+// wrote it, since it's copied and run, and r itself isn't changed.
+func (r *Response) displayed() *Response {
+	notation := r.KeyNotation
+	if notation == "" {
+		return r
+	}
+	normalizeAll := func(texts []string, normalize func(text, notation string) string) []string {
+		out := make([]string, len(texts))
+		for i, text := range texts {
+			out[i] = normalize(text, notation)
+		}
+		return out
+	}
+
+	d := *r
+	d.Explanation = keynotation.Normalize(r.Explanation, notation)
+	d.Raw = keynotation.Normalize(r.Raw, notation)
+	d.TmuxPrefix = keynotation.Normalize(r.TmuxPrefix, notation)
+	// Alternatives and related entries start with a command
+	d.Alternatives = normalizeAll(r.Alternatives, keynotation.NormalizeCommand)
+	d.Related = normalizeAll(r.Related, keynotation.NormalizeCommand)
+	d.Tips = normalizeAll(r.Tips, keynotation.Normalize)
+	d.UserKeymaps = normalizeAll(r.UserKeymaps, keynotation.Normalize)
+	d.WithPlugins = normalizeAll(r.WithPlugins, keynotation.Normalize)
+	d.LayoutNotes = normalizeAll(r.LayoutNotes, keynotation.Normalize)
+	return &d
+}
+
+// Localize writes the dates and sizes in the response's prose the way the
+// user's locale does, and for date +FORMAT commands adds what the command
+// prints right now. A nil locale leaves the response as it is.
+func (r *Response) Localize(loc *locale.Locale) {
+	if loc == nil {
+		return
+	}
+
+	r.Explanation = loc.Prose(r.Explanation)
+	for i := range r.Tips {
+		r.Tips[i] = loc.Prose(r.Tips[i])
+	}
+
+	if example, localized := loc.DateExample(r.Command); example != "" {
+		note := fmt.Sprintf("Right now this prints `%s`.", example)
+		if localized {
+			note = fmt.Sprintf("In your locale (%s) this prints `%s` right now.", loc.Name, example)
+		}
+		if r.Explanation == "" {
+			r.Explanation = note
+		} else {
+			r.Explanation += "\n\n" + note
+		}
+	}
+}
+
+// personalizeText applies prefix and leader substitutions to a single string
+func personalizeText(text, tmuxPrefix, leader string) string {
+	if text == "" {
+		return text
+	}
+
+	if tmuxPrefix != "" && tmuxPrefix != DefaultTmuxPrefix {
+		prefix := DisplayTmuxKey(tmuxPrefix)
+		text = defaultPrefixRe.ReplaceAllStringFunc(text, func(match string) string {
+			if match[0] >= 'A' && match[0] <= 'Z' {
+				return "Your prefix is " + prefix
+			}
+			return "your prefix is " + prefix
+		})
+		text = prefixNotationRe.ReplaceAllString(text, prefix+" + ")
+
+		// Ctrl-b is also a Vim motion (page up), so only rewrite literal
+		// mentions when the text is clearly about tmux
+		lower := strings.ToLower(text)
+		if strings.Contains(lower, "tmux") || strings.Contains(lower, strings.ToLower(prefix)+" + ") {
+			text = defaultPrefixKeyRe.ReplaceAllString(text, prefix)
+		}
+	}
+
+	if leader != "" && leader != "\\" {
+		text = leaderRe.ReplaceAllString(text, DisplayLeaderKey(leader))
+	}
+
+	return text
+}
+
+// DisplayTmuxKey converts tmux key notation (C-a, M-x) into the friendlier
+// form used in answers (Ctrl-a, Alt-x)
+func DisplayTmuxKey(key string) string {
+	switch {
+	case strings.HasPrefix(key, "C-"):
+		return "Ctrl-" + strings.TrimPrefix(key, "C-")
+	case strings.HasPrefix(key, "M-"):
+		return "Alt-" + strings.TrimPrefix(key, "M-")
+	default:
+		return key
+	}
+}
+
+// DisplayLeaderKey converts a leader key value into keybinding notation
+func DisplayLeaderKey(leader string) string {
+	switch leader {
+	case " ":
+		return "<Space>"
+	case "":
+		return "\\"
+	default:
+		return leader
+	}
+}
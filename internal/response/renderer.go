@@ -1,9 +1,12 @@
 package response
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+
+	"github.com/cliq-cli/cliq/internal/theme"
 )
 
 // Styles for terminal rendering
@@ -43,16 +46,78 @@ var (
 	IconRelated = "🔗"
 	// IconUser is the icon for user-specific info
 	IconUser = "📍"
+	// IconPlugin is the icon for plugin-specific suggestions
+	IconPlugin = "🧩"
+	// IconKeyboard is the icon for keyboard layout notes
+	IconKeyboard = "⌨️"
+	// IconBullet marks list items
+	IconBullet = "•"
 )
 
+// SetIcons switches the icon set: "emoji" (the default), "ascii" for
+// terminals and logs that can't show emoji, or "none"
+func SetIcons(set string) {
+	switch set {
+	case "ascii":
+		IconCommand, IconTip, IconRelated = ">", "*", "="
+		IconUser, IconPlugin, IconKeyboard = "@", "+", "#"
+		IconBullet = "-"
+	case "none":
+		IconCommand, IconTip, IconRelated = "", "", ""
+		IconUser, IconPlugin, IconKeyboard = "", "", ""
+		IconBullet = "-"
+	default:
+		IconCommand, IconTip, IconRelated = "💡", "💬", "🔗"
+		IconUser, IconPlugin, IconKeyboard = "📍", "🧩", "⌨️"
+		IconBullet = "•"
+	}
+}
+
+// writeIcon writes a section's icon and the space after it, or nothing when
+// icons are off
+func writeIcon(sb *strings.Builder, icon string) {
+	if icon != "" {
+		sb.WriteString(icon)
+		sb.WriteString(" ")
+	}
+}
+
+// ApplyTheme restyles response rendering with the theme's colors
+func ApplyTheme(t theme.Theme) {
+	CommandStyle = CommandStyle.Foreground(lipgloss.Color(t.Accent))
+	CodeStyle = CodeStyle.Foreground(lipgloss.Color(t.Accent))
+	SectionStyle = SectionStyle.Foreground(lipgloss.Color(t.Heading))
+	ExplanationStyle = ExplanationStyle.Foreground(lipgloss.Color(t.Text))
+	TipStyle = TipStyle.Foreground(lipgloss.Color(t.Tip))
+	KeymapStyle = KeymapStyle.Foreground(lipgloss.Color(t.Keymap))
+	DimStyle = DimStyle.Foreground(lipgloss.Color(t.Dim))
+	CodeBlockStyle = CodeBlockStyle.BorderForeground(lipgloss.Color(t.Border))
+	FlagStyle = FlagStyle.Foreground(lipgloss.Color(t.Flag))
+	StringStyle = StringStyle.Foreground(lipgloss.Color(t.String))
+}
+
 // RenderResponse renders a response with terminal styling
 func RenderResponse(resp *Response) string {
+	return renderResponse(resp, func(text string) string {
+		return ExplanationStyle.Render(text)
+	})
+}
+
+// RenderResponseMarkdown renders a response like RenderResponse, but treats
+// the explanation as markdown wrapped to width
+func RenderResponseMarkdown(resp *Response, width int) string {
+	return renderResponse(resp, func(text string) string {
+		return RenderMarkdown(text, width)
+	})
+}
+
+// renderResponse renders a response, styling the explanation with explain
+func renderResponse(resp *Response, explain func(string) string) string {
 	var sb strings.Builder
 
 	// Command section
 	if resp.Command != "" {
-		sb.WriteString(IconCommand)
-		sb.WriteString(" ")
+		writeIcon(&sb, IconCommand)
 		sb.WriteString(SectionStyle.Render("Command"))
 		sb.WriteString("\n\n")
 		sb.WriteString("  ")
@@ -62,7 +127,7 @@ func RenderResponse(resp *Response) string {
 
 	// Explanation section
 	if resp.Explanation != "" {
-		sb.WriteString(ExplanationStyle.Render(resp.Explanation))
+		sb.WriteString(explain(resp.Explanation))
 		sb.WriteString("\n\n")
 	}
 
@@ -72,7 +137,7 @@ func RenderResponse(resp *Response) string {
 		sb.WriteString("\n")
 		for _, alt := range resp.Alternatives {
 			sb.WriteString("  ")
-			sb.WriteString(DimStyle.Render("•"))
+			sb.WriteString(DimStyle.Render(IconBullet))
 			sb.WriteString(" ")
 			sb.WriteString(alt)
 			sb.WriteString("\n")
@@ -80,10 +145,24 @@ func RenderResponse(resp *Response) string {
 		sb.WriteString("\n")
 	}
 
+	// How the user's shell resolves the command's names
+	if len(resp.ShellNotes) > 0 {
+		writeIcon(&sb, IconUser)
+		sb.WriteString(SectionStyle.Render("In your shell:"))
+		sb.WriteString("\n")
+		for _, note := range resp.ShellNotes {
+			sb.WriteString("  ")
+			sb.WriteString(DimStyle.Render(IconBullet))
+			sb.WriteString(" ")
+			sb.WriteString(note)
+			sb.WriteString("\n")
+		}
+		sb.WriteString("\n")
+	}
+
 	// User keymaps section
 	if len(resp.UserKeymaps) > 0 {
-		sb.WriteString(IconUser)
-		sb.WriteString(" ")
+		writeIcon(&sb, IconUser)
 		sb.WriteString(SectionStyle.Render("In your setup:"))
 		sb.WriteString("\n")
 		for _, km := range resp.UserKeymaps {
@@ -94,6 +173,19 @@ func RenderResponse(resp *Response) string {
 		sb.WriteString("\n")
 	}
 
+	// Plugin-specific alternatives
+	if len(resp.WithPlugins) > 0 {
+		writeIcon(&sb, IconPlugin)
+		sb.WriteString(SectionStyle.Render("With your plugins:"))
+		sb.WriteString("\n")
+		for _, p := range resp.WithPlugins {
+			sb.WriteString("  ")
+			sb.WriteString(KeymapStyle.Render(p))
+			sb.WriteString("\n")
+		}
+		sb.WriteString("\n")
+	}
+
 	// Tmux prefix note
 	if resp.TmuxPrefix != "" {
 		sb.WriteString(DimStyle.Render("(Your tmux prefix: "))
@@ -102,15 +194,27 @@ func RenderResponse(resp *Response) string {
 		sb.WriteString("\n\n")
 	}
 
+	// Keyboard layout keystrokes
+	if len(resp.LayoutNotes) > 0 {
+		writeIcon(&sb, IconKeyboard)
+		sb.WriteString(SectionStyle.Render("On your keyboard:"))
+		sb.WriteString("\n")
+		for _, note := range resp.LayoutNotes {
+			sb.WriteString("  ")
+			sb.WriteString(KeymapStyle.Render(note))
+			sb.WriteString("\n")
+		}
+		sb.WriteString("\n")
+	}
+
 	// Related commands section
 	if len(resp.Related) > 0 {
-		sb.WriteString(IconRelated)
-		sb.WriteString(" ")
+		writeIcon(&sb, IconRelated)
 		sb.WriteString(SectionStyle.Render("Related:"))
 		sb.WriteString("\n")
 		for _, rel := range resp.Related {
 			sb.WriteString("  ")
-			sb.WriteString(DimStyle.Render("•"))
+			sb.WriteString(DimStyle.Render(IconBullet))
 			sb.WriteString(" ")
 			sb.WriteString(rel)
 			sb.WriteString("\n")
@@ -120,14 +224,24 @@ func RenderResponse(resp *Response) string {
 
 	// Tips section
 	if len(resp.Tips) > 0 {
-		sb.WriteString(IconTip)
-		sb.WriteString(" ")
+		writeIcon(&sb, IconTip)
 		sb.WriteString(SectionStyle.Render("Tip:"))
 		sb.WriteString(" ")
 		sb.WriteString(TipStyle.Render(resp.Tips[0]))
 		sb.WriteString("\n")
 	}
 
+	// Sources the explanation's [n] markers refer to
+	if len(resp.Citations) > 0 {
+		if len(resp.Tips) > 0 {
+			sb.WriteString("\n")
+		}
+		for _, c := range resp.Citations {
+			sb.WriteString(DimStyle.Render(fmt.Sprintf("[%d] %s", c.Marker, c.Source)))
+			sb.WriteString("\n")
+		}
+	}
+
 	return sb.String()
 }
 
@@ -156,6 +270,16 @@ func RenderSimple(resp *Response) string {
 		sb.WriteString("\n")
 	}
 
+	if len(resp.ShellNotes) > 0 {
+		sb.WriteString("In your shell:\n")
+		for _, note := range resp.ShellNotes {
+			sb.WriteString("  - ")
+			sb.WriteString(note)
+			sb.WriteString("\n")
+		}
+		sb.WriteString("\n")
+	}
+
 	if len(resp.UserKeymaps) > 0 {
 		sb.WriteString("In your setup:\n")
 		for _, km := range resp.UserKeymaps {
@@ -166,6 +290,26 @@ func RenderSimple(resp *Response) string {
 		sb.WriteString("\n")
 	}
 
+	if len(resp.WithPlugins) > 0 {
+		sb.WriteString("With your plugins:\n")
+		for _, p := range resp.WithPlugins {
+			sb.WriteString("  - ")
+			sb.WriteString(p)
+			sb.WriteString("\n")
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(resp.LayoutNotes) > 0 {
+		sb.WriteString("On your keyboard:\n")
+		for _, note := range resp.LayoutNotes {
+			sb.WriteString("  - ")
+			sb.WriteString(note)
+			sb.WriteString("\n")
+		}
+		sb.WriteString("\n")
+	}
+
 	if len(resp.Related) > 0 {
 		sb.WriteString("Related:\n")
 		for _, rel := range resp.Related {
@@ -182,5 +326,14 @@ func RenderSimple(resp *Response) string {
 		sb.WriteString("\n")
 	}
 
+	if len(resp.Citations) > 0 {
+		if len(resp.Tips) > 0 {
+			sb.WriteString("\n")
+		}
+		for _, c := range resp.Citations {
+			sb.WriteString(fmt.Sprintf("[%d] %s\n", c.Marker, c.Source))
+		}
+	}
+
 	return sb.String()
 }
@@ -0,0 +1,424 @@
+// Package shelltype works out what a command name means in the user's
+// shell, the way `type` would: an alias, a function, a builtin, or a program
+// on PATH. The same name can mean different things in bash, zsh, and fish,
+// and an alias can quietly change what a suggested command does.
+package shelltype
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/cliq-cli/cliq/internal/parser"
+)
+
+// Kind is what a name resolves to, named as `type` names it
+type Kind string
+
+const (
+	NotFound Kind = ""
+	Alias    Kind = "alias"
+	Function Kind = "function"
+	Keyword  Kind = "keyword"
+	Builtin  Kind = "builtin"
+	File     Kind = "file"
+)
+
+// Resolution is what a name resolves to in one shell
+type Resolution struct {
+	Name string
+	Kind Kind
+	// Expansion is an alias's expansion
+	Expansion string
+	// Source is the startup file defining an alias or function
+	Source string
+	// Path is the program PATH finds for the name, set even when an alias,
+	// function, or builtin shadows it
+	Path string
+}
+
+// shells lists the builtins and reserved words of each shell cliq knows
+var shells = map[string]struct {
+	keywords []string
+	builtins []string
+}{
+	"bash": {
+		keywords: []string{"if", "then", "else", "elif", "fi", "case", "esac", "for", "select", "while", "until", "do", "done", "in", "function", "time", "{", "}", "!", "[[", "]]", "coproc"},
+		builtins: []string{".", ":", "[", "alias", "bg", "bind", "break", "builtin", "caller", "cd", "command", "compgen", "complete", "compopt", "continue", "declare", "dirs", "disown", "echo", "enable", "eval", "exec", "exit", "export", "false", "fc", "fg", "getopts", "hash", "help", "history", "jobs", "kill", "let", "local", "logout", "mapfile", "popd", "printf", "pushd", "pwd", "read", "readarray", "readonly", "return", "set", "shift", "shopt", "source", "suspend", "test", "times", "trap", "true", "type", "typeset", "ulimit", "umask", "unalias", "unset", "wait"},
+	},
+	"zsh": {
+		keywords: []string{"if", "then", "else", "elif", "fi", "case", "esac", "for", "foreach", "select", "while", "until", "repeat", "do", "done", "end", "in", "function", "time", "nocorrect", "{", "}", "!", "[[", "]]", "coproc"},
+		builtins: []string{".", ":", "[", "alias", "autoload", "bg", "bindkey", "break", "builtin", "bye", "cd", "chdir", "command", "compdef", "continue", "declare", "dirs", "disable", "disown", "echo", "emulate", "enable", "eval", "exec", "exit", "export", "false", "fc", "fg", "float", "functions", "getopts", "hash", "history", "integer", "jobs", "kill", "let", "limit", "local", "logout", "noglob", "popd", "print", "printf", "pushd", "pwd", "r", "read", "readonly", "rehash", "return", "sched", "set", "setopt", "shift", "source", "suspend", "test", "times", "trap", "true", "type", "typeset", "ulimit", "umask", "unalias", "unfunction", "unhash", "unlimit", "unset", "unsetopt", "vared", "wait", "whence", "where", "which", "zcompile", "zle", "zmodload", "zparseopts", "zstyle"},
+	},
+	"fish": {
+		// fish has no reserved words in the POSIX sense; `type` calls them
+		// builtins, and the functions fish ships behave like them too
+		builtins: []string{".", ":", "[", "abbr", "alias", "and", "argparse", "begin", "bg", "bind", "block", "break", "builtin", "case", "cd", "command", "commandline", "complete", "contains", "continue", "count", "dirh", "dirs", "disown", "echo", "else", "emit", "end", "eval", "exec", "exit", "export", "false", "fg", "fish_add_path", "for", "funced", "funcsave", "function", "functions", "help", "history", "if", "jobs", "math", "nextd", "not", "or", "path", "popd", "prevd", "printf", "pushd", "pwd", "random", "read", "realpath", "return", "set", "set_color", "source", "status", "string", "switch", "test", "time", "true", "type", "ulimit", "umask", "vared", "wait", "while"},
+	},
+	"sh": {
+		keywords: []string{"if", "then", "else", "elif", "fi", "case", "esac", "for", "while", "until", "do", "done", "in", "{", "}", "!"},
+		builtins: []string{".", ":", "[", "alias", "bg", "break", "cd", "command", "continue", "echo", "eval", "exec", "exit", "export", "false", "fg", "getopts", "hash", "jobs", "kill", "local", "printf", "pwd", "read", "readonly", "return", "set", "shift", "test", "times", "trap", "true", "type", "ulimit", "umask", "unalias", "unset", "wait"},
+	},
+}
+
+// Detect returns the user's shell from $SHELL, with dash and ash counted as
+// sh. It returns "" when $SHELL is unset or names a shell cliq doesn't know,
+// except on Windows, where only Git Bash and the like set it: there it's
+// "powershell", which a Resolver has no notes for.
+func Detect() string {
+	shell := os.Getenv("SHELL")
+	if shell == "" && runtime.GOOS == "windows" {
+		return "powershell"
+	}
+	name := filepath.Base(shell)
+	switch name {
+	case "dash", "ash":
+		name = "sh"
+	}
+	if _, ok := shells[name]; !ok {
+		return ""
+	}
+	return name
+}
+
+// Resolver resolves names the way one shell would, using the aliases and
+// functions from the user's startup files
+type Resolver struct {
+	shell    string
+	cfg      *parser.ShellConfig
+	lookPath func(string) (string, error)
+}
+
+// New returns a Resolver for shell, one of the names Detect returns. cfg may
+// be nil.
+func New(shell string, cfg *parser.ShellConfig) *Resolver {
+	return &Resolver{shell: shell, cfg: cfg, lookPath: exec.LookPath}
+}
+
+// Type resolves name in the resolver's shell, in the order the shell looks
+// things up: aliases, reserved words, functions, builtins, then PATH
+func (r *Resolver) Type(name string) Resolution {
+	res := Resolution{Name: name}
+	if path, err := r.lookPath(name); err == nil {
+		res.Path = path
+	}
+
+	switch {
+	case r.alias(name, &res):
+		res.Kind = Alias
+	case contains(shells[r.shell].keywords, name):
+		res.Kind = Keyword
+	case r.function(name, &res):
+		res.Kind = Function
+	case contains(shells[r.shell].builtins, name):
+		res.Kind = Builtin
+	case res.Path != "":
+		res.Kind = File
+	}
+	return res
+}
+
+// alias looks name up among the aliases this shell reads
+func (r *Resolver) alias(name string, res *Resolution) bool {
+	if r.cfg == nil {
+		return false
+	}
+	for _, a := range r.cfg.Aliases {
+		if a.Name == name && r.reads(a.Shell) {
+			res.Expansion = a.Expansion
+			res.Source = a.Source
+			return true
+		}
+	}
+	return false
+}
+
+// function looks name up among the functions this shell reads
+func (r *Resolver) function(name string, res *Resolution) bool {
+	if r.cfg == nil {
+		return false
+	}
+	for _, f := range r.cfg.Functions {
+		if f.Name == name && r.reads(f.Shell) {
+			res.Source = f.Source
+			return true
+		}
+	}
+	return false
+}
+
+// reads reports whether this shell reads the startup files of shell, where
+// "" is a file any POSIX shell could source
+func (r *Resolver) reads(shell string) bool {
+	if shell == "" {
+		return r.shell != "fish"
+	}
+	return shell == r.shell
+}
+
+// differingBuiltins are builtins whose options differ from the program of
+// the same name, with the shells where that commonly bites: GNU time's -v
+// and -f, echo -e in dash, zsh's which printing function bodies
+var differingBuiltins = map[string][]string{
+	"time":     {"bash", "zsh", "fish"},
+	"echo":     {"sh"},
+	"which":    {"zsh"},
+	"realpath": {"fish"},
+}
+
+// bashSyntax are constructs fish doesn't accept, with what they are
+var bashSyntax = []struct {
+	token, what string
+}{
+	{"[[", "[[ ]] tests"},
+	{"<(", "process substitution"},
+	{"$((", "arithmetic expansion"},
+	{"${", "${var} expansion"},
+	{"; then", "if/then/fi"},
+	{"; do", "do/done loops"},
+	{"<<", "heredocs"},
+}
+
+// maxNotes bounds the notes Conflicts returns
+const maxNotes = 3
+
+// Conflicts explains where command would behave differently in the user's
+// shell than it reads: names their aliases or functions shadow, builtins
+// standing in for programs with different options, and names or syntax that
+// only another shell has. Commands whose first word isn't known to any
+// shell, such as Neovim keys, get no notes.
+func (r *Resolver) Conflicts(command string) []string {
+	if _, ok := shells[r.shell]; !ok {
+		return nil
+	}
+	segments := splitCommand(command)
+	if len(segments) == 0 || !r.known(segments[0].name, len(strings.Fields(command)) > 1) {
+		return nil
+	}
+
+	var notes []string
+	fishSyntax := false
+	if r.shell == "fish" {
+		var used []string
+		for _, s := range bashSyntax {
+			if strings.Contains(command, s.token) {
+				used = append(used, s.what)
+			}
+		}
+		if len(used) > 0 {
+			fishSyntax = true
+			notes = append(notes, fmt.Sprintf("This uses bash syntax fish doesn't accept (%s); run it with bash -c '...'.", strings.Join(used, ", ")))
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, seg := range segments {
+		if seen[seg.name] {
+			continue
+		}
+		seen[seg.name] = true
+		if note := r.explain(seg, fishSyntax); note != "" {
+			notes = append(notes, note)
+		}
+		if len(notes) >= maxNotes {
+			break
+		}
+	}
+	return notes
+}
+
+// known reports whether name means anything to the user's shell, which is
+// what tells a shell command apart from editor keys. Names only another
+// shell knows count when they take arguments; a lone `r` is more likely a
+// Neovim key than zsh's builtin.
+func (r *Resolver) known(name string, withArgs bool) bool {
+	if r.Type(name).Kind != NotFound {
+		return true
+	}
+	if !withArgs {
+		return false
+	}
+	_, kind := r.elsewhere(name)
+	return kind != NotFound
+}
+
+// explain returns a note about one command in the pipeline, or ""
+func (r *Resolver) explain(seg segment, fishSyntax bool) string {
+	res := r.Type(seg.name)
+	bypass := "command " + seg.name
+	if res.Path == "" {
+		bypass = "builtin " + seg.name
+	}
+
+	// sudo and friends run programs; the shell's aliases and functions
+	// never reach them
+	if seg.wrapper != "" && (res.Kind == Alias || res.Kind == Function) {
+		if res.Path == "" {
+			return fmt.Sprintf("`%s %s` won't work: `%s` is your %s, and %s only runs programs.", seg.wrapper, seg.name, seg.name, res.Kind, seg.wrapper)
+		}
+		return fmt.Sprintf("`%s %s` runs %s, not your %s `%s`.", seg.wrapper, seg.name, res.Path, res.Kind, seg.name)
+	}
+
+	switch res.Kind {
+	case Alias:
+		shadowed := res.Path != "" || contains(shells[r.shell].builtins, seg.name)
+		if !shadowed {
+			return ""
+		}
+		target, _, _ := strings.Cut(res.Expansion, " ")
+		if target == seg.name {
+			return fmt.Sprintf("`%s` is your alias for `%s` (%s), so those options apply too; `%s` runs it without them.", seg.name, res.Expansion, shortPath(res.Source), bypass)
+		}
+		return fmt.Sprintf("`%s` is your alias for `%s` (%s), so this runs %s rather than %s; `%s` runs %s itself.", seg.name, res.Expansion, shortPath(res.Source), target, seg.name, bypass, seg.name)
+
+	case Function:
+		switch {
+		case contains(shells[r.shell].builtins, seg.name):
+			return fmt.Sprintf("`%s` is a function from %s, not the %s builtin; `builtin %s` skips it.", seg.name, shortPath(res.Source), r.shell, seg.name)
+		case res.Path != "":
+			return fmt.Sprintf("`%s` is a function from %s that wraps %s; `%s` skips it.", seg.name, shortPath(res.Source), res.Path, bypass)
+		}
+
+	case Builtin, Keyword:
+		if res.Path != "" && seg.hasOptions && contains(differingBuiltins[seg.name], r.shell) {
+			return fmt.Sprintf("`%s` runs the %s %s, not %s, and their options differ; `%s` runs %s.", seg.name, r.shell, res.Kind, res.Path, bypass, res.Path)
+		}
+
+	case NotFound:
+		if strings.Contains(seg.name, "/") {
+			return ""
+		}
+		note, kind := r.elsewhere(seg.name)
+		switch {
+		case kind == Keyword && fishSyntax:
+			// Already covered by the note about bash syntax
+			return ""
+		case kind != NotFound:
+			return note
+		}
+		return fmt.Sprintf("`%s` isn't installed (not found on your PATH).", seg.name)
+	}
+	return ""
+}
+
+// elsewhere explains what a name the user's shell doesn't know is in
+// another shell, such as a bash builtin or an alias in ~/.bashrc. The kind
+// is NotFound when no shell knows it.
+func (r *Resolver) elsewhere(name string) (string, Kind) {
+	for _, shell := range []string{"bash", "zsh", "fish", "sh"} {
+		if shell == r.shell {
+			continue
+		}
+		res := (&Resolver{shell: shell, cfg: r.cfg, lookPath: r.lookPath}).Type(name)
+		switch res.Kind {
+		case Alias, Function:
+			return fmt.Sprintf("`%s` is %s %s in %s, which %s doesn't read.", name, article(res.Kind), res.Kind, shortPath(res.Source), r.shell), res.Kind
+		case Builtin, Keyword:
+			return fmt.Sprintf("`%s` is a %s %s; %s doesn't have it.", name, shell, res.Kind, r.shell), res.Kind
+		}
+	}
+	return "", NotFound
+}
+
+// article returns "an" or "a" for kind
+func article(kind Kind) string {
+	if kind == Alias {
+		return "an"
+	}
+	return "a"
+}
+
+// segment is one simple command of a pipeline or list
+type segment struct {
+	name string
+	// wrapper is sudo, doas, or env when the command runs under one
+	wrapper    string
+	hasOptions bool
+}
+
+// wrappers run the program named after them, skipping the shell's aliases
+// and functions
+var wrappers = map[string]bool{"sudo": true, "doas": true, "env": true, "nohup": true, "xargs": true}
+
+// splitCommand splits command at unquoted |, &, ;, and parentheses, and
+// returns the command each part runs. Variable assignments before a command
+// are skipped, and commands run with `command` or a leading backslash are
+// left out, since they already bypass aliases and functions.
+func splitCommand(command string) []segment {
+	var parts []string
+	var current strings.Builder
+	var quote byte
+	for i := 0; i < len(command); i++ {
+		c := command[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+			current.WriteByte(c)
+		case c == '\'' || c == '"':
+			quote = c
+			current.WriteByte(c)
+		case c == '|' || c == '&' || c == ';' || c == '(' || c == ')' || c == '\n' || c == '`':
+			parts = append(parts, current.String())
+			current.Reset()
+		default:
+			current.WriteByte(c)
+		}
+	}
+	parts = append(parts, current.String())
+
+	var segments []segment
+	for _, part := range parts {
+		words := strings.Fields(part)
+		for len(words) > 0 && strings.Contains(words[0], "=") && !strings.HasPrefix(words[0], "=") {
+			words = words[1:]
+		}
+		if len(words) == 0 || words[0] == "command" || words[0] == "builtin" || strings.HasPrefix(words[0], "\\") {
+			continue
+		}
+
+		var seg segment
+		if wrappers[words[0]] {
+			seg.wrapper = words[0]
+			words = words[1:]
+			for len(words) > 0 && (strings.HasPrefix(words[0], "-") || strings.Contains(words[0], "=")) {
+				words = words[1:]
+			}
+			if len(words) == 0 {
+				continue
+			}
+		}
+		if strings.HasPrefix(words[0], "$") || strings.HasPrefix(words[0], "<") || strings.HasPrefix(words[0], ">") {
+			continue
+		}
+		seg.name = strings.Trim(words[0], `"'`)
+		for _, w := range words[1:] {
+			if strings.HasPrefix(w, "-") {
+				seg.hasOptions = true
+			}
+		}
+		segments = append(segments, seg)
+	}
+	return segments
+}
+
+// shortPath abbreviates the home directory in path to ~
+func shortPath(path string) string {
+	if home, err := os.UserHomeDir(); err == nil && strings.HasPrefix(path, home+string(filepath.Separator)) {
+		return "~" + path[len(home):]
+	}
+	return path
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
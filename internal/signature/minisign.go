@@ -0,0 +1,114 @@
+// Package signature verifies minisign signatures
+// (https://jedisct1.github.io/minisign/) on content cliq downloads, such as
+// cheatsheet packs.
+package signature
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+const (
+	// algLegacy signs the message itself; algHashed, minisign's default,
+	// signs its BLAKE2b-512 hash
+	algLegacy = "Ed"
+	algHashed = "ED"
+
+	trustedPrefix = "trusted comment: "
+)
+
+var (
+	// ErrUntrustedKey is returned when a signature was made by a key that
+	// isn't trusted
+	ErrUntrustedKey = errors.New("signed by an untrusted key")
+	// ErrBadSignature is returned when the signature doesn't match the content
+	ErrBadSignature = errors.New("signature doesn't match")
+)
+
+// PublicKey is a minisign public key
+type PublicKey struct {
+	ID  [8]byte
+	Key ed25519.PublicKey
+}
+
+// String returns the key's ID the way minisign prints it
+func (k PublicKey) String() string {
+	id := k.ID
+	// minisign shows the little-endian ID as a number
+	for i, j := 0, len(id)-1; i < j; i, j = i+1, j-1 {
+		id[i], id[j] = id[j], id[i]
+	}
+	return strings.ToUpper(hex.EncodeToString(id[:]))
+}
+
+// ParsePublicKey parses a public key in minisign's base64 form, with or
+// without its "untrusted comment" line
+func ParsePublicKey(s string) (PublicKey, error) {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[len(lines)-1]))
+	if err != nil || len(data) != 2+8+ed25519.PublicKeySize || string(data[:2]) != algLegacy {
+		return PublicKey{}, errors.New("invalid minisign public key")
+	}
+
+	var k PublicKey
+	copy(k.ID[:], data[2:10])
+	k.Key = ed25519.PublicKey(data[10:])
+	return k, nil
+}
+
+// Verify checks a .minisig signature of message against the trusted keys
+// and returns the signature's trusted comment
+func Verify(keys []PublicKey, message, sig []byte) (string, error) {
+	lines := strings.Split(strings.TrimSpace(strings.ReplaceAll(string(sig), "\r\n", "\n")), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[2], trustedPrefix) {
+		return "", errors.New("invalid minisign signature file")
+	}
+
+	data, err := base64.StdEncoding.DecodeString(lines[1])
+	if err != nil || len(data) != 2+8+ed25519.SignatureSize {
+		return "", errors.New("invalid minisign signature")
+	}
+	alg, sigBytes := string(data[:2]), data[10:]
+
+	var key *PublicKey
+	for i := range keys {
+		if bytes.Equal(keys[i].ID[:], data[2:10]) {
+			key = &keys[i]
+			break
+		}
+	}
+	if key == nil {
+		return "", ErrUntrustedKey
+	}
+
+	signed := message
+	switch alg {
+	case algHashed:
+		sum := blake2b.Sum512(message)
+		signed = sum[:]
+	case algLegacy:
+	default:
+		return "", fmt.Errorf("unsupported signature algorithm %q", alg)
+	}
+	if !ed25519.Verify(key.Key, signed, sigBytes) {
+		return "", ErrBadSignature
+	}
+
+	// The global signature covers the trusted comment, so it can't be edited
+	comment := strings.TrimPrefix(lines[2], trustedPrefix)
+	global, err := base64.StdEncoding.DecodeString(lines[3])
+	if err != nil || len(global) != ed25519.SignatureSize {
+		return "", errors.New("invalid minisign signature")
+	}
+	if !ed25519.Verify(key.Key, append(append([]byte{}, sigBytes...), comment...), global) {
+		return "", fmt.Errorf("%w: trusted comment was altered", ErrBadSignature)
+	}
+	return comment, nil
+}
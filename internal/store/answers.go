@@ -0,0 +1,127 @@
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// answersFile caches the model's answers, keyed by question and setup
+const answersFile = "answers.json"
+
+// maxAnswers caps the answer cache; the oldest answers go first
+const maxAnswers = 500
+
+// CachedAnswer is a model answer kept for when the question is asked again
+type CachedAnswer struct {
+	Question string    `json:"question"`
+	Answer   string    `json:"answer"`
+	Created  time.Time `json:"created"`
+	Hits     int       `json:"hits,omitempty"`
+}
+
+// AnswerCache holds model answers by a key of the normalized question and a
+// fingerprint of the setup it was answered for, so a change to the config,
+// model, or sampling settings asks the model afresh
+type AnswerCache struct {
+	Answers map[string]CachedAnswer `json:"answers"`
+}
+
+// LoadAnswerCache loads the answer cache from disk
+func LoadAnswerCache() (*AnswerCache, error) {
+	c := &AnswerCache{}
+	if err := readJSON(answersFile, c); err != nil {
+		return nil, err
+	}
+	if c.Answers == nil {
+		c.Answers = map[string]CachedAnswer{}
+	}
+	return c, nil
+}
+
+// Save saves the answer cache to disk
+func (c *AnswerCache) Save() error {
+	return writeJSON(answersFile, c)
+}
+
+// Get returns the answer to question for the setup fingerprint, unless it's
+// older than ttl (0 keeps answers forever), and counts the hit
+func (c *AnswerCache) Get(question, fingerprint string, ttl time.Duration) (string, bool) {
+	key := answerKey(question, fingerprint)
+	a, ok := c.Answers[key]
+	if !ok || (ttl > 0 && time.Since(a.Created) > ttl) {
+		return "", false
+	}
+	a.Hits++
+	c.Answers[key] = a
+	return a.Answer, true
+}
+
+// Put records the answer to question for the setup fingerprint, dropping
+// expired answers and the oldest past the cap
+func (c *AnswerCache) Put(question, fingerprint, answer string, ttl time.Duration) {
+	c.Answers[answerKey(question, fingerprint)] = CachedAnswer{
+		Question: question,
+		Answer:   answer,
+		Created:  time.Now(),
+	}
+
+	for key, a := range c.Answers {
+		if ttl > 0 && time.Since(a.Created) > ttl {
+			delete(c.Answers, key)
+		}
+	}
+	if len(c.Answers) <= maxAnswers {
+		return
+	}
+	keys := make([]string, 0, len(c.Answers))
+	for key := range c.Answers {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return c.Answers[keys[i]].Created.Before(c.Answers[keys[j]].Created)
+	})
+	for _, key := range keys[:len(keys)-maxAnswers] {
+		delete(c.Answers, key)
+	}
+}
+
+// Clear drops every cached answer
+func (c *AnswerCache) Clear() {
+	c.Answers = map[string]CachedAnswer{}
+}
+
+// fillerWords change how a question is phrased but not what it asks
+var fillerWords = map[string]bool{
+	"a": true, "an": true, "the": true, "please": true, "how": true,
+	"do": true, "does": true, "i": true, "can": true, "you": true,
+	"to": true, "is": true, "there": true, "way": true, "me": true,
+}
+
+// NormalizeQuestion reduces a question to the words that carry its
+// meaning, so "How do I delete a line?" and "delete line" are the same
+// question
+func NormalizeQuestion(question string) string {
+	words := strings.FieldsFunc(strings.ToLower(question), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '<' && r != '>'
+	})
+	kept := words[:0]
+	for _, w := range words {
+		if !fillerWords[w] {
+			kept = append(kept, w)
+		}
+	}
+	if len(kept) == 0 {
+		kept = words
+	}
+	return strings.Join(kept, " ")
+}
+
+// answerKey hashes the normalized question with the setup fingerprint
+func answerKey(question, fingerprint string) string {
+	sum := sha256.Sum256([]byte(NormalizeQuestion(question) + "\x00" + fingerprint))
+	return hex.EncodeToString(sum[:])
+}
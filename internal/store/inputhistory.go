@@ -0,0 +1,167 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cliq-cli/cliq/internal/config"
+)
+
+// inputHistoryFile holds one query per line, oldest first, like a shell
+// history. As in bash, a "#<unix time>" line before a query records when it
+// was asked.
+const inputHistoryFile = "input_history"
+
+// InputHistory is the list of queries typed into the interactive TUI
+type InputHistory struct {
+	Entries []string
+	// Times holds when each entry was asked, zero for entries written
+	// before times were recorded
+	Times []time.Time
+
+	// Retention decides which queries are recorded and how many are kept
+	Retention Retention
+}
+
+// LoadInputHistory loads the input history from disk
+func LoadInputHistory() (*InputHistory, error) {
+	h := &InputHistory{}
+
+	data, err := readFile(inputHistoryFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return h, nil
+		}
+		return nil, err
+	}
+
+	var asked time.Time
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "#") {
+			if sec, err := strconv.ParseInt(line[1:], 10, 64); err == nil {
+				asked = time.Unix(sec, 0)
+				continue
+			}
+		}
+		if line != "" {
+			h.Entries = append(h.Entries, line)
+			h.Times = append(h.Times, asked)
+			asked = time.Time{}
+		}
+	}
+	return h, scanner.Err()
+}
+
+// Add records a query, skipping an immediate repeat of the previous one and
+// queries the retention policy excludes, and appends it to the history file
+func (h *InputHistory) Add(query string) error {
+	query = strings.Join(strings.Fields(query), " ")
+	if query == "" || (len(h.Entries) > 0 && h.Entries[len(h.Entries)-1] == query) || h.Retention.Excludes(query) {
+		return nil
+	}
+	now := time.Now()
+	h.Entries = append(h.Entries, query)
+	h.Times = append(h.Times, now)
+
+	// Rewrite the file once it grows well past the limit
+	if limit := h.Retention.MaxEntries; limit > 0 && len(h.Entries) > limit+limit/10 {
+		h.Entries = h.Entries[len(h.Entries)-limit:]
+		h.Times = h.Times[len(h.Times)-limit:]
+		return h.save()
+	}
+	// A sealed file can't be appended to
+	if sealing {
+		return h.save()
+	}
+
+	path, err := getStorePath(inputHistoryFile)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), config.DirPerm); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, config.FilePerm)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintf(f, "#%d\n%s\n", now.Unix(), query)
+	return err
+}
+
+// Prune drops queries that the retention policy no longer allows and
+// rewrites the history file if any were dropped
+func (h *InputHistory) Prune() error {
+	now := time.Now()
+	var entries []string
+	var times []time.Time
+	for i, entry := range h.Entries {
+		if !h.Retention.expired(h.Times[i], now) && !h.Retention.Excludes(entry) {
+			entries = append(entries, entry)
+			times = append(times, h.Times[i])
+		}
+	}
+	if limit := h.Retention.MaxEntries; limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+		times = times[len(times)-limit:]
+	}
+	if len(entries) == len(h.Entries) {
+		return nil
+	}
+	h.Entries, h.Times = entries, times
+	return h.save()
+}
+
+// save rewrites the history file with the current entries
+func (h *InputHistory) save() error {
+	var sb strings.Builder
+	for i, entry := range h.Entries {
+		if !h.Times[i].IsZero() {
+			fmt.Fprintf(&sb, "#%d\n", h.Times[i].Unix())
+		}
+		sb.WriteString(entry)
+		sb.WriteString("\n")
+	}
+	return writeFile(inputHistoryFile, []byte(sb.String()))
+}
+
+// Search returns the distinct queries that fuzzily match pattern, most
+// recent first. Every character of pattern must appear in order.
+func (h *InputHistory) Search(pattern string) []string {
+	pattern = strings.ToLower(pattern)
+	seen := make(map[string]bool)
+
+	var matches []string
+	for i := len(h.Entries) - 1; i >= 0; i-- {
+		entry := h.Entries[i]
+		if seen[entry] || !fuzzyMatch(strings.ToLower(entry), pattern) {
+			continue
+		}
+		seen[entry] = true
+		matches = append(matches, entry)
+	}
+	return matches
+}
+
+// fuzzyMatch reports whether the characters of pattern appear in text in order
+func fuzzyMatch(text, pattern string) bool {
+	for _, r := range pattern {
+		idx := strings.IndexRune(text, r)
+		if idx < 0 {
+			return false
+		}
+		text = text[idx+len(string(r)):]
+	}
+	return true
+}
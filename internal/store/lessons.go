@@ -0,0 +1,70 @@
+package store
+
+import (
+	"strings"
+	"time"
+)
+
+// Lesson is a user-defined answer for a question, added with cliq learn
+type Lesson struct {
+	ID       int       `json:"id"`
+	Question string    `json:"question"`
+	Answer   string    `json:"answer"`
+	Created  time.Time `json:"created"`
+}
+
+// Lessons is the collection of user-defined answers
+type Lessons struct {
+	Items  []Lesson `json:"lessons"`
+	NextID int      `json:"next_id"`
+}
+
+// LoadLessons loads user-defined answers from disk
+func LoadLessons() (*Lessons, error) {
+	lessons := &Lessons{NextID: 1}
+	if err := readJSON("lessons.json", lessons); err != nil {
+		return nil, err
+	}
+	if lessons.NextID < 1 {
+		lessons.NextID = 1
+	}
+	return lessons, nil
+}
+
+// Save saves user-defined answers to disk
+func (l *Lessons) Save() error {
+	return writeJSON("lessons.json", l)
+}
+
+// Add stores an answer for a question and returns it. Teaching the same
+// question again replaces the previous answer.
+func (l *Lessons) Add(question, answer string) Lesson {
+	for i, lesson := range l.Items {
+		if strings.EqualFold(lesson.Question, question) {
+			l.Items[i].Answer = answer
+			l.Items[i].Created = time.Now()
+			return l.Items[i]
+		}
+	}
+
+	lesson := Lesson{
+		ID:       l.NextID,
+		Question: question,
+		Answer:   answer,
+		Created:  time.Now(),
+	}
+	l.NextID++
+	l.Items = append(l.Items, lesson)
+	return lesson
+}
+
+// Remove deletes the lesson with the given ID, reporting whether it existed
+func (l *Lessons) Remove(id int) bool {
+	for i, lesson := range l.Items {
+		if lesson.ID == id {
+			l.Items = append(l.Items[:i], l.Items[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package store
+
+import (
+	"time"
+)
+
+// Pin kinds
+const (
+	PinNote   = "note"
+	PinKeymap = "keymap"
+	PinAlias  = "alias"
+)
+
+// Pin is a piece of context the user wants included in every prompt
+type Pin struct {
+	ID      int       `json:"id"`
+	Kind    string    `json:"kind"`
+	Text    string    `json:"text"`
+	Created time.Time `json:"created"`
+}
+
+// Pins is the collection of pinned context items
+type Pins struct {
+	Items  []Pin `json:"pins"`
+	NextID int   `json:"next_id"`
+}
+
+// LoadPins loads pinned context from disk
+func LoadPins() (*Pins, error) {
+	pins := &Pins{NextID: 1}
+	if err := readJSON("pins.json", pins); err != nil {
+		return nil, err
+	}
+	if pins.NextID < 1 {
+		pins.NextID = 1
+	}
+	return pins, nil
+}
+
+// Save saves pinned context to disk
+func (p *Pins) Save() error {
+	return writeJSON("pins.json", p)
+}
+
+// Add pins a new item and returns it
+func (p *Pins) Add(kind, text string) Pin {
+	pin := Pin{
+		ID:      p.NextID,
+		Kind:    kind,
+		Text:    text,
+		Created: time.Now(),
+	}
+	p.NextID++
+	p.Items = append(p.Items, pin)
+	return pin
+}
+
+// Remove unpins the item with the given ID, reporting whether it existed
+func (p *Pins) Remove(id int) bool {
+	for i, pin := range p.Items {
+		if pin.ID == id {
+			p.Items = append(p.Items[:i], p.Items[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Lines returns the pinned items formatted for inclusion in a prompt
+func (p *Pins) Lines() []string {
+	lines := make([]string, 0, len(p.Items))
+	for _, pin := range p.Items {
+		switch pin.Kind {
+		case PinKeymap:
+			lines = append(lines, "Keymap: "+pin.Text)
+		case PinAlias:
+			lines = append(lines, "Alias: "+pin.Text)
+		default:
+			lines = append(lines, pin.Text)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,69 @@
+package store
+
+import (
+	"github.com/cliq-cli/cliq/internal/response"
+)
+
+// queriesFile holds the questions asked outside the interactive TUI
+const queriesFile = "queries.json"
+
+// Queries is the history of one-shot questions and their answers, which
+// cliq -c follows up on
+type Queries struct {
+	Entries []SessionEntry `json:"entries"`
+}
+
+// LoadQueries loads the one-shot history from disk
+func LoadQueries() (*Queries, error) {
+	q := &Queries{}
+	if err := readJSON(queriesFile, q); err != nil {
+		return nil, err
+	}
+	for _, entry := range q.Entries {
+		if entry.Answer != nil {
+			entry.Answer.Raw = entry.Raw
+		}
+	}
+	return q, nil
+}
+
+// Save saves the one-shot history to disk
+func (q *Queries) Save() error {
+	return writeJSON(queriesFile, q)
+}
+
+// Add records a question and its answer, unless the retention policy
+// excludes it, and drops the entries it no longer allows
+func (q *Queries) Add(query string, answer *response.Response, r Retention) {
+	if r.Excludes(query) {
+		return
+	}
+	s := Session{Entries: q.Entries}
+	s.Add(query, answer)
+	s.Prune(r)
+	q.Entries = s.Entries
+}
+
+// LastExchange returns the most recent question and its answer, asked
+// one-shot or in the interactive TUI, or nil when none was
+func LastExchange() (*SessionEntry, error) {
+	var last *SessionEntry
+	queries, err := LoadQueries()
+	if err != nil {
+		return nil, err
+	}
+	if n := len(queries.Entries); n > 0 {
+		last = &queries.Entries[n-1]
+	}
+
+	session, err := LoadSession()
+	if err != nil {
+		return nil, err
+	}
+	if n := len(session.Entries); n > 0 {
+		if entry := &session.Entries[n-1]; last == nil || entry.Time.After(last.Time) {
+			last = entry
+		}
+	}
+	return last, nil
+}
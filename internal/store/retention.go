@@ -0,0 +1,35 @@
+package store
+
+import (
+	"strings"
+	"time"
+)
+
+// Retention limits what history is recorded and how long it is kept, so
+// queries that reveal project details don't pile up on shared machines
+type Retention struct {
+	// MaxEntries caps each history file; 0 means no limit
+	MaxEntries int
+	// MaxAge drops entries older than this; 0 keeps them forever
+	MaxAge time.Duration
+	// Exclude lists words or phrases; queries mentioning any of them are
+	// never recorded
+	Exclude []string
+}
+
+// Excludes reports whether a query touches an excluded topic
+func (r Retention) Excludes(query string) bool {
+	query = strings.ToLower(query)
+	for _, topic := range r.Exclude {
+		if topic = strings.ToLower(strings.TrimSpace(topic)); topic != "" && strings.Contains(query, topic) {
+			return true
+		}
+	}
+	return false
+}
+
+// expired reports whether an entry recorded at t is past MaxAge. Entries
+// without a time are kept.
+func (r Retention) expired(t time.Time, now time.Time) bool {
+	return r.MaxAge > 0 && !t.IsZero() && now.Sub(t) > r.MaxAge
+}
@@ -0,0 +1,140 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cliq-cli/cliq/internal/response"
+)
+
+// SessionEntry is one question and answer from the interactive TUI
+type SessionEntry struct {
+	Query  string             `json:"query"`
+	Answer *response.Response `json:"answer"`
+	Raw    string             `json:"raw,omitempty"`
+	Time   time.Time          `json:"time"`
+
+	// Branch numbers regenerated answers to the previous question (0 for
+	// the original), and Temperature is what the answer was generated with
+	Branch      int     `json:"branch,omitempty"`
+	Temperature float64 `json:"temperature,omitempty"`
+
+	// Copied is set when the user copied this answer's command, as implicit
+	// feedback on which of several answers was useful
+	Copied bool `json:"copied,omitempty"`
+}
+
+// Session is the interactive TUI history, kept across restarts
+type Session struct {
+	Started time.Time      `json:"started"`
+	Entries []SessionEntry `json:"entries"`
+}
+
+// LoadSession loads the saved interactive session from disk
+func LoadSession() (*Session, error) {
+	session := &Session{Started: time.Now()}
+	if err := readJSON("session.json", session); err != nil {
+		return nil, err
+	}
+
+	// Raw isn't part of the response's JSON form, so restore it separately.
+	// Questions that were still being answered when the TUI exited are
+	// dropped.
+	entries := session.Entries[:0]
+	for _, entry := range session.Entries {
+		if entry.Answer != nil {
+			entry.Answer.Raw = entry.Raw
+			entries = append(entries, entry)
+		}
+	}
+	session.Entries = entries
+	return session, nil
+}
+
+// Save saves the session to disk
+func (s *Session) Save() error {
+	return writeJSON("session.json", s)
+}
+
+// Add records a question and its answer, returning the entry's index. The
+// answer may be nil to reserve the entry while the answer is generated.
+func (s *Session) Add(query string, answer *response.Response) int {
+	entry := SessionEntry{
+		Query:  query,
+		Answer: answer,
+		Time:   time.Now(),
+	}
+	if answer != nil {
+		entry.Raw = answer.Raw
+	}
+	s.Entries = append(s.Entries, entry)
+	return len(s.Entries) - 1
+}
+
+// Remove drops the entry at index i
+func (s *Session) Remove(i int) {
+	if i >= 0 && i < len(s.Entries) {
+		s.Entries = append(s.Entries[:i], s.Entries[i+1:]...)
+	}
+}
+
+// Prune drops entries that the retention policy no longer allows, oldest
+// first, and reports whether any were dropped
+func (s *Session) Prune(r Retention) bool {
+	now := time.Now()
+	kept := s.Entries[:0]
+	for _, entry := range s.Entries {
+		if !r.expired(entry.Time, now) && !r.Excludes(entry.Query) {
+			kept = append(kept, entry)
+		}
+	}
+	if r.MaxEntries > 0 && len(kept) > r.MaxEntries {
+		kept = kept[len(kept)-r.MaxEntries:]
+	}
+
+	pruned := len(kept) != len(s.Entries)
+	s.Entries = kept
+	return pruned
+}
+
+// Clear starts a fresh session
+func (s *Session) Clear() {
+	s.Started = time.Now()
+	s.Entries = nil
+}
+
+// ToMarkdown exports the session as a Markdown document
+func (s *Session) ToMarkdown() string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("# Cliq session — %s\n\n", s.Started.Format("2006-01-02 15:04")))
+
+	for _, entry := range s.Entries {
+		// Questions still being answered when the session was saved
+		if entry.Answer == nil {
+			continue
+		}
+
+		sb.WriteString("---\n\n")
+		sb.WriteString(fmt.Sprintf("## ❯ %s\n\n", entry.Query))
+		sb.WriteString(fmt.Sprintf("*%s*\n\n", entry.Time.Format("2006-01-02 15:04")))
+
+		if entry.Answer.Command == "" && entry.Answer.Explanation == "" {
+			sb.WriteString(entry.Answer.Raw)
+			sb.WriteString("\n\n")
+		} else {
+			// Nest the answer's sections under the question's heading
+			markdown := strings.TrimRight(entry.Answer.ToMarkdown(), "\n")
+			for _, line := range strings.SplitAfter(markdown, "\n") {
+				if strings.HasPrefix(line, "## ") {
+					line = "#" + line
+				}
+				sb.WriteString(line)
+			}
+			sb.WriteString("\n\n")
+		}
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,192 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/cliq-cli/cliq/internal/config"
+	"github.com/cliq-cli/cliq/internal/vault"
+)
+
+// personalFiles are the stores holding history and personal data, which are
+// encrypted when encryption is on
+var personalFiles = []string{"session.json", queriesFile, inputHistoryFile, "lessons.json", "pins.json", answersFile}
+
+// The store key is fetched from keyFunc the first time it's needed, so a
+// passphrase is only asked for by commands that read or write the stores
+var (
+	sealing  bool
+	keyFunc  func() ([]byte, error)
+	keyOnce  sync.Once
+	storeKey []byte
+	keyErr   error
+)
+
+// UseKey sets where the store key comes from. With seal set, personal
+// stores are encrypted when saved; sealed files are decrypted on load either
+// way.
+func UseKey(getKey func() ([]byte, error), seal bool) {
+	keyFunc = getKey
+	sealing = seal
+	keyOnce = sync.Once{}
+	storeKey, keyErr = nil, nil
+}
+
+// Sealing reports whether personal stores are encrypted when saved
+func Sealing() bool {
+	return sealing
+}
+
+// Unlock fetches the store key now, if encryption is on, rather than on
+// first use; a passphrase can then be asked for before a TUI takes the
+// terminal
+func Unlock() error {
+	if keyFunc == nil {
+		return nil
+	}
+	_, err := key()
+	return err
+}
+
+// key returns the store key, fetching it on first use
+func key() ([]byte, error) {
+	keyOnce.Do(func() {
+		if keyFunc == nil {
+			keyErr = fmt.Errorf("encryption is off; set mode under [encryption] in the config to read encrypted stores")
+			return
+		}
+		storeKey, keyErr = keyFunc()
+	})
+	return storeKey, keyErr
+}
+
+// isPersonal reports whether a store file holds history or personal data
+func isPersonal(name string) bool {
+	for _, f := range personalFiles {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+// readFile reads a store file, decrypting it if it was sealed
+func readFile(name string) ([]byte, error) {
+	path, err := getStorePath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil || !vault.IsSealed(data) {
+		return data, err
+	}
+
+	k, err := key()
+	if err != nil {
+		return nil, fmt.Errorf("%s is encrypted: %w", name, err)
+	}
+	data, err = vault.Open(k, data)
+	if err != nil {
+		return nil, fmt.Errorf("could not decrypt %s: %w", name, err)
+	}
+	return data, nil
+}
+
+// writeFile saves a private store file, sealing personal stores when
+// encryption is on
+func writeFile(name string, data []byte) error {
+	path, err := getStorePath(name)
+	if err != nil {
+		return err
+	}
+
+	if sealing && isPersonal(name) {
+		k, err := key()
+		if err != nil {
+			return err
+		}
+		if data, err = vault.Seal(k, data); err != nil {
+			return err
+		}
+	}
+	return config.WriteFile(path, data)
+}
+
+// Reseal rewrites every personal store with the current encryption
+// setting, encrypting or decrypting them as needed
+func Reseal() error {
+	for _, name := range personalFiles {
+		data, err := readFile(name)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		if err := writeFile(name, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FileStatus describes whether a personal store file is encrypted
+type FileStatus struct {
+	Name   string
+	Exists bool
+	Sealed bool
+}
+
+// PersonalFiles reports the encryption state of each personal store
+func PersonalFiles() ([]FileStatus, error) {
+	var files []FileStatus
+	for _, name := range personalFiles {
+		path, err := getStorePath(name)
+		if err != nil {
+			return nil, err
+		}
+		status := FileStatus{Name: name}
+		if data, err := os.ReadFile(path); err == nil {
+			status.Exists = true
+			status.Sealed = vault.IsSealed(data)
+		}
+		files = append(files, status)
+	}
+	return files, nil
+}
+
+// getStorePath returns the full path to a store file in the data directory
+func getStorePath(name string) (string, error) {
+	dataDir, err := config.GetDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, name), nil
+}
+
+// readJSON loads a store file into v. A missing file leaves v untouched.
+func readJSON(name string, v interface{}) error {
+	data, err := readFile(name)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// writeJSON saves v to a private store file, creating the data directory if needed
+func writeJSON(name string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFile(name, data)
+}
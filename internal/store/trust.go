@@ -0,0 +1,44 @@
+package store
+
+// TrustedFiles are the project files, such as a repository's
+// .cliq/context.md, that the user has allowed into prompts. Each is
+// recorded by path with the SHA-256 of the contents allowed, so a file
+// that changes afterwards isn't used until it's trusted again.
+type TrustedFiles struct {
+	Files map[string]string `json:"files"`
+}
+
+// LoadTrustedFiles loads the trusted project files from disk
+func LoadTrustedFiles() (*TrustedFiles, error) {
+	t := &TrustedFiles{}
+	if err := readJSON("trusted.json", t); err != nil {
+		return nil, err
+	}
+	if t.Files == nil {
+		t.Files = make(map[string]string)
+	}
+	return t, nil
+}
+
+// Save saves the trusted project files to disk
+func (t *TrustedFiles) Save() error {
+	return writeJSON("trusted.json", t)
+}
+
+// Trust allows the file at path, as long as its contents hash to sum
+func (t *TrustedFiles) Trust(path, sum string) {
+	t.Files[path] = sum
+}
+
+// Untrust removes the file at path, reporting whether it was trusted
+func (t *TrustedFiles) Untrust(path string) bool {
+	_, ok := t.Files[path]
+	delete(t.Files, path)
+	return ok
+}
+
+// Trusted reports whether the file at path was trusted with contents that
+// hash to sum
+func (t *TrustedFiles) Trusted(path, sum string) bool {
+	return sum != "" && t.Files[path] == sum
+}
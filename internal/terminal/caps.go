@@ -0,0 +1,278 @@
+package terminal
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cliq-cli/cliq/internal/config"
+	"github.com/cliq-cli/cliq/internal/parser"
+)
+
+// Capabilities describes the terminal stack cliq is running in. Detection is
+// based on environment variables and the parsed tmux config only; the
+// terminal itself is never queried.
+type Capabilities struct {
+	// Emulator is the terminal emulator, empty if unknown
+	Emulator string
+
+	// Multiplexer is tmux, screen, or zellij when running inside one
+	Multiplexer        string
+	MultiplexerVersion string
+
+	// Term is the value of $TERM
+	Term string
+
+	// Emulator features
+	TrueColor     bool
+	KittyKeyboard bool
+	OSC52         bool
+
+	// tmux settings that decide whether those features reach applications
+	TmuxExtendedKeys string
+	TmuxSetClipboard string
+	TmuxRGB          bool
+}
+
+// emulatorInfo lists what a known terminal emulator supports
+type emulatorInfo struct {
+	name          string
+	trueColor     bool
+	kittyKeyboard bool
+	osc52         bool
+}
+
+// emulators maps detection keys to emulator features. Support for the kitty
+// keyboard protocol and OSC 52 reflects recent releases of each emulator.
+var emulators = map[string]emulatorInfo{
+	"kitty":     {"kitty", true, true, true},
+	"wezterm":   {"WezTerm", true, true, true},
+	"ghostty":   {"Ghostty", true, true, true},
+	"foot":      {"foot", true, true, true},
+	"alacritty": {"Alacritty", true, true, true},
+	"iterm":     {"iTerm2", true, true, true},
+	"apple":     {"Apple Terminal", false, false, false},
+	"vscode":    {"VS Code terminal", true, false, true},
+	"windows":   {"Windows Terminal", true, false, true},
+	"konsole":   {"Konsole", true, false, true},
+	"vte":       {"VTE-based terminal (GNOME Terminal, Tilix, ...)", true, false, false},
+	"xterm":     {"xterm", false, false, true},
+}
+
+// Detect inspects the environment (and tmux config, when given) to work out
+// the user's terminal capabilities
+func Detect(tmuxConfig *parser.TmuxConfig) *Capabilities {
+	caps := &Capabilities{
+		Term: os.Getenv("TERM"),
+	}
+
+	if key := detectEmulator(); key != "" {
+		info := emulators[key]
+		caps.Emulator = info.name
+		caps.TrueColor = info.trueColor
+		caps.KittyKeyboard = info.kittyKeyboard
+		caps.OSC52 = info.osc52
+	}
+
+	colorterm := strings.ToLower(os.Getenv("COLORTERM"))
+	if colorterm == "truecolor" || colorterm == "24bit" {
+		caps.TrueColor = true
+	}
+
+	switch {
+	case os.Getenv("TMUX") != "":
+		caps.Multiplexer = "tmux"
+		caps.MultiplexerVersion = cachedTmuxVersion()
+	case os.Getenv("ZELLIJ") != "":
+		caps.Multiplexer = "zellij"
+	case os.Getenv("STY") != "":
+		caps.Multiplexer = "screen"
+	}
+
+	if tmuxConfig != nil {
+		caps.TmuxExtendedKeys = tmuxConfig.Options["extended-keys"]
+		caps.TmuxSetClipboard = tmuxConfig.Options["set-clipboard"]
+		for name, value := range tmuxConfig.Options {
+			if strings.Contains(name, "terminal-overrides") || strings.Contains(name, "terminal-features") {
+				if strings.Contains(value, "RGB") || strings.Contains(value, "Tc") {
+					caps.TmuxRGB = true
+				}
+			}
+		}
+	}
+
+	return caps
+}
+
+// detectEmulator returns the emulators key for the running terminal. Most
+// emulators export a variable of their own, which (unlike TERM_PROGRAM)
+// usually survives into tmux sessions.
+func detectEmulator() string {
+	switch {
+	case os.Getenv("KITTY_WINDOW_ID") != "":
+		return "kitty"
+	case os.Getenv("WEZTERM_PANE") != "":
+		return "wezterm"
+	case os.Getenv("GHOSTTY_RESOURCES_DIR") != "":
+		return "ghostty"
+	case os.Getenv("ALACRITTY_WINDOW_ID") != "" || os.Getenv("ALACRITTY_SOCKET") != "":
+		return "alacritty"
+	case os.Getenv("ITERM_SESSION_ID") != "":
+		return "iterm"
+	case os.Getenv("WT_SESSION") != "":
+		return "windows"
+	case os.Getenv("KONSOLE_VERSION") != "":
+		return "konsole"
+	}
+
+	switch os.Getenv("TERM_PROGRAM") {
+	case "iTerm.app":
+		return "iterm"
+	case "Apple_Terminal":
+		return "apple"
+	case "WezTerm":
+		return "wezterm"
+	case "ghostty":
+		return "ghostty"
+	case "vscode":
+		return "vscode"
+	}
+
+	term := os.Getenv("TERM")
+	switch {
+	case term == "xterm-kitty":
+		return "kitty"
+	case term == "xterm-ghostty":
+		return "ghostty"
+	case strings.HasPrefix(term, "foot"):
+		return "foot"
+	case term == "alacritty":
+		return "alacritty"
+	case os.Getenv("VTE_VERSION") != "":
+		return "vte"
+	case strings.HasPrefix(term, "xterm") && os.Getenv("XTERM_VERSION") != "":
+		return "xterm"
+	}
+
+	return ""
+}
+
+// TmuxVersion returns the version reported by tmux -V, e.g. "3.4"
+func TmuxVersion() string {
+	out, err := exec.Command("tmux", "-V").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.TrimSpace(string(out)), "tmux ")
+}
+
+// tmuxVersionCache is the version tmux -V last reported, and the binary
+// that reported it
+type tmuxVersionCache struct {
+	Binary  string    `json:"binary"`
+	ModTime time.Time `json:"mod_time"`
+	Version string    `json:"version"`
+}
+
+var (
+	tmuxVersionOnce   sync.Once
+	tmuxVersionCached string
+)
+
+// cachedTmuxVersion returns TmuxVersion without forking tmux on every query.
+// The version is kept in the cache dir until the tmux binary changes.
+func cachedTmuxVersion() string {
+	tmuxVersionOnce.Do(func() {
+		binary, err := exec.LookPath("tmux")
+		if err != nil {
+			return
+		}
+		info, err := os.Stat(binary)
+		if err != nil {
+			return
+		}
+		dir, err := config.GetCacheDir()
+		if err != nil {
+			tmuxVersionCached = TmuxVersion()
+			return
+		}
+		path := filepath.Join(dir, "tmux-version.json")
+		var cached tmuxVersionCache
+		if data, err := os.ReadFile(path); err == nil && json.Unmarshal(data, &cached) == nil &&
+			cached.Binary == binary && cached.ModTime.Equal(info.ModTime()) && cached.Version != "" {
+			tmuxVersionCached = cached.Version
+			return
+		}
+		tmuxVersionCached = TmuxVersion()
+		if tmuxVersionCached == "" {
+			return
+		}
+		data, err := json.Marshal(tmuxVersionCache{Binary: binary, ModTime: info.ModTime(), Version: tmuxVersionCached})
+		if err == nil {
+			config.WriteFile(path, data)
+		}
+	})
+	return tmuxVersionCached
+}
+
+// Lines describes the capabilities for inclusion in a prompt
+func (c *Capabilities) Lines() []string {
+	var lines []string
+
+	emulator := c.Emulator
+	if emulator == "" {
+		emulator = "unknown"
+	}
+	lines = append(lines, "Terminal emulator: "+emulator)
+
+	if c.Multiplexer != "" {
+		mux := c.Multiplexer
+		if c.MultiplexerVersion != "" {
+			mux += " " + c.MultiplexerVersion
+		}
+		lines = append(lines, "Running inside: "+mux)
+	}
+	if c.Term != "" {
+		lines = append(lines, "TERM: "+c.Term)
+	}
+
+	if c.Emulator != "" {
+		lines = append(lines, "True color: "+yesNo(c.TrueColor))
+		lines = append(lines, "Kitty keyboard protocol: "+yesNo(c.KittyKeyboard))
+		lines = append(lines, "OSC 52 clipboard: "+yesNo(c.OSC52))
+	} else if c.TrueColor {
+		lines = append(lines, "True color: yes")
+	}
+
+	if c.Multiplexer == "tmux" {
+		// tmux sits between the emulator and applications, so emulator
+		// features only reach Neovim if tmux is configured to pass them on
+		lines = append(lines, "tmux extended-keys: "+valueOr(c.TmuxExtendedKeys, "off (default)"))
+		lines = append(lines, "tmux set-clipboard: "+valueOr(c.TmuxSetClipboard, "external (default)"))
+		lines = append(lines, "tmux RGB override: "+yesNo(c.TmuxRGB))
+		if c.KittyKeyboard {
+			lines = append(lines, "Note: tmux does not forward the kitty keyboard protocol; "+
+				"modified keys like Ctrl+Shift+letter only reach applications with extended-keys on")
+		}
+	}
+
+	return lines
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
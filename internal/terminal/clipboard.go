@@ -0,0 +1,24 @@
+package terminal
+
+import (
+	"os"
+
+	"github.com/atotto/clipboard"
+	"github.com/aymanbagabas/go-osc52/v2"
+)
+
+// Copy puts text on the system clipboard. When no clipboard utility is
+// available, as over SSH, it falls back to an OSC 52 escape sequence,
+// wrapped for tmux when running inside it.
+func Copy(text string) error {
+	if err := clipboard.WriteAll(text); err == nil {
+		return nil
+	}
+
+	seq := osc52.New(text)
+	if os.Getenv("TMUX") != "" {
+		seq = seq.Tmux()
+	}
+	_, err := seq.WriteTo(os.Stderr)
+	return err
+}
@@ -0,0 +1,31 @@
+package terminal
+
+import (
+	"os"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// colorDisabled is set by DisableColor, for --no-color
+var colorDisabled bool
+
+// DisableColor turns off styled output for the rest of the run, including
+// the colors lipgloss would otherwise pick for the terminal
+func DisableColor() {
+	colorDisabled = true
+	lipgloss.SetColorProfile(termenv.Ascii)
+}
+
+// ColorEnabled reports whether styled output should be written to f. It
+// follows --no-color, then NO_COLOR (https://no-color.org) and
+// CLICOLOR_FORCE, then whether f is a terminal.
+func ColorEnabled(f *os.File) bool {
+	if colorDisabled || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	if force := os.Getenv("CLICOLOR_FORCE"); force != "" && force != "0" {
+		return true
+	}
+	return IsTerminal(f)
+}
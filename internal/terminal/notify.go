@@ -0,0 +1,135 @@
+package terminal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// ErrNoNotifier is returned when there's no way to show a desktop
+// notification
+var ErrNoNotifier = errors.New("no way to show desktop notifications (install notify-send)")
+
+// askTimeout bounds each command run to send a notification or tell focus
+const askTimeout = 2 * time.Second
+
+// toastScript shows a Windows toast with the title and body from the
+// environment, which spares quoting them for PowerShell
+const toastScript = `[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null
+$t = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$text = $t.GetElementsByTagName('text')
+$text.Item(0).AppendChild($t.CreateTextNode($env:CLIQ_NOTIFY_TITLE)) > $null
+$text.Item(1).AppendChild($t.CreateTextNode($env:CLIQ_NOTIFY_BODY)) > $null
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('cliq').Show([Windows.UI.Notifications.ToastNotification]::new($t))`
+
+// Notify shows a desktop notification: with notify-send on Linux and the
+// BSDs, Notification Center on macOS, and a toast on Windows
+func Notify(title, body string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), askTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	switch {
+	case runtime.GOOS == "darwin":
+		cmd = exec.CommandContext(ctx, "osascript", "-e",
+			`display notification (system attribute "CLIQ_NOTIFY_BODY") with title (system attribute "CLIQ_NOTIFY_TITLE")`)
+	case runtime.GOOS == "windows":
+		cmd = exec.CommandContext(ctx, "powershell", "-NoProfile", "-NonInteractive", "-Command", toastScript)
+	case hasCommand("notify-send"):
+		cmd = exec.CommandContext(ctx, "notify-send", "--app-name=cliq", "--", title, body)
+	default:
+		return ErrNoNotifier
+	}
+	cmd.Env = append(os.Environ(), "CLIQ_NOTIFY_TITLE="+title, "CLIQ_NOTIFY_BODY="+body)
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%v: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// Focused reports whether the terminal cliq runs in is the window in
+// front, and inside tmux, whether cliq's pane is the one shown. known is
+// false when that can't be told, as on Wayland or Windows.
+func Focused() (focused, known bool) {
+	if os.Getenv("TMUX") != "" {
+		if shown, ok := tmuxPaneShown(); ok && !shown {
+			return false, true
+		}
+	}
+
+	switch {
+	case runtime.GOOS == "darwin":
+		front, err := ask("osascript", "-e",
+			`tell application "System Events" to get name of first application process whose frontmost is true`)
+		app := macTerminalApp()
+		if err != nil || app == "" {
+			return false, false
+		}
+		return strings.EqualFold(front, app), true
+	case os.Getenv("WINDOWID") != "" && os.Getenv("DISPLAY") != "" && hasCommand("xdotool"):
+		active, err := ask("xdotool", "getactivewindow")
+		if err != nil {
+			return false, false
+		}
+		return active == os.Getenv("WINDOWID"), true
+	}
+	return false, false
+}
+
+// tmuxPaneShown reports whether cliq's pane is the active one of its
+// session's current window, with a client attached to see it
+func tmuxPaneShown() (shown, ok bool) {
+	out, err := ask("tmux", "display-message", "-p", "-t", os.Getenv("TMUX_PANE"),
+		"#{pane_active}#{window_active}#{?session_attached,1,0}")
+	if err != nil {
+		return false, false
+	}
+	return out == "111", true
+}
+
+// macTerminalApp returns the process name macOS gives the terminal app cliq
+// runs in, from TERM_PROGRAM, or "" for one it doesn't know
+func macTerminalApp() string {
+	switch os.Getenv("TERM_PROGRAM") {
+	case "Apple_Terminal":
+		return "Terminal"
+	case "iTerm.app":
+		return "iTerm2"
+	case "WezTerm":
+		return "wezterm-gui"
+	case "ghostty":
+		return "Ghostty"
+	case "vscode":
+		return "Code"
+	}
+	if os.Getenv("KITTY_WINDOW_ID") != "" {
+		return "kitty"
+	}
+	if os.Getenv("ALACRITTY_WINDOW_ID") != "" {
+		return "Alacritty"
+	}
+	return ""
+}
+
+// ask runs a command and returns what it printed, trimmed
+func ask(name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), askTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, name, args...).Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// hasCommand reports whether a command is on PATH
+func hasCommand(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
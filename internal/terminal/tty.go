@@ -0,0 +1,23 @@
+package terminal
+
+import (
+	"os"
+
+	"golang.org/x/term"
+)
+
+// IsTerminal reports whether f is connected to a terminal rather than a pipe
+// or file
+func IsTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// Width returns the width of the terminal f is connected to, or 0 when it
+// isn't a terminal or the size can't be read
+func Width(f *os.File) int {
+	w, _, err := term.GetSize(int(f.Fd()))
+	if err != nil {
+		return 0
+	}
+	return w
+}
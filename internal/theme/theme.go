@@ -0,0 +1,132 @@
+package theme
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/pelletier/go-toml/v2"
+
+	"github.com/cliq-cli/cliq/internal/config"
+)
+
+// Theme is a named palette of colors. Values are anything lipgloss accepts:
+// ANSI 256 color numbers like "42" or hex colors like "#5fd787".
+type Theme struct {
+	Name    string `toml:"-"`
+	Base    string `toml:"base"` // built-in theme a custom theme starts from
+	Accent  string `toml:"accent"`
+	Heading string `toml:"heading"`
+	Text    string `toml:"text"`
+	Tip     string `toml:"tip"`
+	Keymap  string `toml:"keymap"`
+	Dim     string `toml:"dim"`
+	Error   string `toml:"error"`
+	TitleBg string `toml:"title_bg"`
+	Border  string `toml:"border"`
+	Flag    string `toml:"flag"`
+	String  string `toml:"string"`
+}
+
+// Dark is the default theme, for terminals with a dark background
+var Dark = Theme{
+	Name:    "dark",
+	Accent:  "42",
+	Heading: "99",
+	Text:    "252",
+	Tip:     "214",
+	Keymap:  "141",
+	Dim:     "241",
+	Error:   "196",
+	TitleBg: "235",
+	Border:  "238",
+	Flag:    "141",
+	String:  "214",
+}
+
+// Light is for terminals with a light background
+var Light = Theme{
+	Name:    "light",
+	Accent:  "28",
+	Heading: "55",
+	Text:    "235",
+	Tip:     "130",
+	Keymap:  "91",
+	Dim:     "244",
+	Error:   "160",
+	TitleBg: "254",
+	Border:  "250",
+	Flag:    "91",
+	String:  "130",
+}
+
+// Load resolves a tui.theme setting. "auto" (or empty) picks dark or light
+// from the terminal's background; "dark" and "light" are built in. Anything
+// else names a theme file: a path to a .toml file, or a file in the themes
+// directory of the config dir, such as "nord" for themes/nord.toml.
+func Load(name string) (Theme, error) {
+	switch strings.ToLower(name) {
+	case "", "auto":
+		if lipgloss.HasDarkBackground() {
+			return Dark, nil
+		}
+		return Light, nil
+	case "dark":
+		return Dark, nil
+	case "light":
+		return Light, nil
+	}
+
+	path := name
+	if !strings.HasSuffix(path, ".toml") {
+		configDir, err := config.GetConfigDir()
+		if err != nil {
+			return Dark, err
+		}
+		path = filepath.Join(configDir, "themes", name+".toml")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Dark, fmt.Errorf("theme %q: %w", name, err)
+	}
+
+	var custom Theme
+	if err := toml.Unmarshal(data, &custom); err != nil {
+		return Dark, fmt.Errorf("theme %q: %w", name, err)
+	}
+
+	base := Dark
+	switch strings.ToLower(custom.Base) {
+	case "", "dark":
+	case "light", "auto":
+		base, _ = Load(custom.Base)
+	default:
+		return Dark, fmt.Errorf("theme %q: base must be dark, light, or auto", name)
+	}
+	base.merge(custom)
+	base.Name = strings.TrimSuffix(filepath.Base(name), ".toml")
+	return base, nil
+}
+
+// merge overrides the theme's colors with those set in other
+func (t *Theme) merge(other Theme) {
+	set := func(dst *string, src string) {
+		if src != "" {
+			*dst = src
+		}
+	}
+	set(&t.Accent, other.Accent)
+	set(&t.Heading, other.Heading)
+	set(&t.Text, other.Text)
+	set(&t.Tip, other.Tip)
+	set(&t.Keymap, other.Keymap)
+	set(&t.Dim, other.Dim)
+	set(&t.Error, other.Error)
+	set(&t.TitleBg, other.TitleBg)
+	set(&t.Border, other.Border)
+	set(&t.Flag, other.Flag)
+	set(&t.String, other.String)
+}
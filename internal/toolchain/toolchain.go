@@ -0,0 +1,251 @@
+// Package toolchain detects the version managers and per-directory
+// environment tools (direnv, mise, asdf, and friends) that decide which
+// version of a tool runs in the current directory.
+package toolchain
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// VersionFile is a file that pins tool versions for a directory tree, such
+// as .tool-versions or .nvmrc
+type VersionFile struct {
+	Path string
+	// Tools maps tool names to the versions the file asks for
+	Tools map[string]string
+}
+
+// Toolchain describes how tool versions are layered for the current
+// directory
+type Toolchain struct {
+	// Managers are the version managers active in this shell, in the order
+	// their directories appear on PATH
+	Managers []string
+
+	// Direnv is the .envrc direnv has loaded, empty if none
+	Direnv string
+
+	// Files are the version files that apply here, nearest first
+	Files []VersionFile
+
+	// Resolved maps each pinned tool to the executable PATH finds for it
+	Resolved map[string]string
+}
+
+// pathManagers recognise version managers by their directories on PATH
+var pathManagers = []struct {
+	name    string
+	markers []string
+}{
+	{"mise", []string{"/mise/shims", "/mise/installs"}},
+	{"asdf", []string{"/.asdf/shims", "/asdf/shims"}},
+	{"nvm", []string{"/.nvm/versions"}},
+	{"fnm", []string{"/fnm_multishells", "/fnm/node-versions"}},
+	{"volta", []string{"/.volta/bin"}},
+	{"pyenv", []string{"/.pyenv/shims"}},
+	{"rbenv", []string{"/.rbenv/shims"}},
+	{"goenv", []string{"/.goenv/shims"}},
+	{"sdkman", []string{"/.sdkman/candidates"}},
+}
+
+// singleToolFiles pin one tool's version each
+var singleToolFiles = map[string]string{
+	".nvmrc":          "node",
+	".node-version":   "node",
+	".python-version": "python",
+	".ruby-version":   "ruby",
+	".go-version":     "go",
+	".java-version":   "java",
+}
+
+// Detect inspects PATH, the environment, and the version files from dir up
+// to the home directory
+func Detect(dir string) *Toolchain {
+	tc := &Toolchain{
+		Resolved: map[string]string{},
+		Direnv:   os.Getenv("DIRENV_FILE"),
+	}
+
+	seen := map[string]bool{}
+	for _, entry := range filepath.SplitList(os.Getenv("PATH")) {
+		entry = filepath.ToSlash(entry)
+		for _, m := range pathManagers {
+			if seen[m.name] {
+				continue
+			}
+			for _, marker := range m.markers {
+				if strings.Contains(entry, marker) {
+					tc.Managers = append(tc.Managers, m.name)
+					seen[m.name] = true
+					break
+				}
+			}
+		}
+	}
+	// mise can also activate without shims, by rewriting PATH on each prompt
+	if !seen["mise"] && os.Getenv("MISE_SHELL") != "" {
+		tc.Managers = append(tc.Managers, "mise")
+	}
+
+	tc.Files = findVersionFiles(dir)
+	for _, f := range tc.Files {
+		for tool := range f.Tools {
+			if _, ok := tc.Resolved[tool]; ok {
+				continue
+			}
+			if path, err := exec.LookPath(executableFor(tool)); err == nil {
+				tc.Resolved[tool] = path
+			} else {
+				tc.Resolved[tool] = ""
+			}
+		}
+	}
+
+	return tc
+}
+
+// IsEmpty reports whether nothing about the toolchain was detected
+func (tc *Toolchain) IsEmpty() bool {
+	return len(tc.Managers) == 0 && tc.Direnv == "" && len(tc.Files) == 0
+}
+
+// Lines describes the toolchain for a prompt or listing
+func (tc *Toolchain) Lines() []string {
+	var lines []string
+	if len(tc.Managers) > 0 {
+		lines = append(lines, "Version managers on PATH (first wins): "+strings.Join(tc.Managers, ", "))
+	}
+	if tc.Direnv != "" {
+		lines = append(lines, "direnv has loaded: "+tc.Direnv)
+	}
+	for _, f := range tc.Files {
+		var pins []string
+		for _, tool := range sortedKeys(f.Tools) {
+			pins = append(pins, tool+" "+f.Tools[tool])
+		}
+		lines = append(lines, "Pinned in "+f.Path+": "+strings.Join(pins, ", "))
+	}
+	for _, tool := range sortedKeys(tc.Resolved) {
+		path := tc.Resolved[tool]
+		if path == "" {
+			path = "not found on PATH"
+		}
+		lines = append(lines, "`"+executableFor(tool)+"` resolves to: "+path)
+	}
+	return lines
+}
+
+// findVersionFiles collects version files from dir up to the home
+// directory, nearest first
+func findVersionFiles(dir string) []VersionFile {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil
+	}
+	home, _ := os.UserHomeDir()
+
+	var files []VersionFile
+	for {
+		names := []string{".tool-versions", ".mise.toml", "mise.toml", ".envrc"}
+		for name := range singleToolFiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			path := filepath.Join(dir, name)
+			data, err := os.ReadFile(path)
+			if err != nil {
+				continue
+			}
+			tools := parseVersionFile(name, string(data))
+			if len(tools) > 0 || name == ".envrc" {
+				files = append(files, VersionFile{Path: path, Tools: tools})
+			}
+		}
+
+		if dir == home {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return files
+}
+
+// parseVersionFile extracts the tool versions a version file pins
+func parseVersionFile(name, data string) map[string]string {
+	tools := map[string]string{}
+
+	if tool, ok := singleToolFiles[name]; ok {
+		if v := strings.TrimSpace(data); v != "" {
+			tools[tool] = strings.Fields(v)[0]
+		}
+		return tools
+	}
+
+	inTools := false
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		switch name {
+		case ".tool-versions":
+			// node 20.11.0
+			if fields := strings.Fields(line); len(fields) >= 2 {
+				tools[fields[0]] = fields[1]
+			}
+		case ".mise.toml", "mise.toml":
+			// [tools]
+			// node = "20"
+			if strings.HasPrefix(line, "[") {
+				inTools = line == "[tools]"
+				continue
+			}
+			if k, v, ok := strings.Cut(line, "="); inTools && ok {
+				tools[strings.Trim(strings.TrimSpace(k), `"`)] = strings.Trim(strings.TrimSpace(v), `"'`)
+			}
+		case ".envrc":
+			// use node 20, layout python python3.12
+			fields := strings.Fields(line)
+			if len(fields) >= 3 && (fields[0] == "use" || fields[0] == "layout") {
+				tools[fields[1]] = fields[2]
+			}
+		}
+	}
+	return tools
+}
+
+// executableFor returns the command a tool is run as
+func executableFor(tool string) string {
+	switch tool {
+	case "nodejs":
+		return "node"
+	case "golang":
+		return "go"
+	case "python":
+		return "python3"
+	}
+	return tool
+}
+
+// sortedKeys returns a map's keys in order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
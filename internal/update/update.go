@@ -0,0 +1,171 @@
+// Package update checks whether a newer cliq release exists. The check is
+// off unless the user turns it on, runs at most once a day, and sends
+// nothing but the running version and OS, in the User-Agent header.
+package update
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cliq-cli/cliq/internal/config"
+)
+
+const (
+	// ReleaseURL is asked for the latest release
+	ReleaseURL = "https://api.github.com/repos/cliq-cli/cliq/releases/latest"
+	// Interval is how long a check's result is reused before asking again
+	Interval = 24 * time.Hour
+	// timeout bounds the request, which runs alongside the command
+	timeout = 3 * time.Second
+)
+
+// state is the result of the last check, kept in the data directory
+type state struct {
+	CheckedAt time.Time `json:"checked_at"`
+	Latest    string    `json:"latest,omitempty"`
+	URL       string    `json:"url,omitempty"`
+}
+
+// Due reports whether the last check is older than Interval
+func Due() bool {
+	s, _ := load()
+	return time.Since(s.CheckedAt) >= Interval
+}
+
+// Refresh asks for the latest release and records it. The request carries
+// only the User-Agent "cliq/<version> (<os>)"; no queries, configuration,
+// or identifiers are sent. The attempt is recorded before the request, so
+// a check that fails or is cut short still waits Interval before the next.
+func Refresh(ctx context.Context, version string) error {
+	prev, _ := load()
+	prev.CheckedAt = time.Now()
+	if err := save(prev); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ReleaseURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", fmt.Sprintf("cliq/%s (%s)", version, runtime.GOOS))
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("update check: %s", resp.Status)
+	}
+
+	var release struct {
+		TagName string `json:"tag_name"`
+		HTMLURL string `json:"html_url"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return fmt.Errorf("update check: %w", err)
+	}
+	return save(state{CheckedAt: time.Now(), Latest: release.TagName, URL: release.HTMLURL})
+}
+
+// Notice returns a one-line notice when the last check found a release
+// newer than version, or "" otherwise
+func Notice(version string) string {
+	s, err := load()
+	if err != nil || s.Latest == "" || !Newer(s.Latest, version) {
+		return ""
+	}
+	notice := fmt.Sprintf("cliq %s is available (you have %s)", strings.TrimPrefix(s.Latest, "v"), strings.TrimPrefix(version, "v"))
+	if s.URL != "" {
+		notice += ": " + s.URL
+	}
+	return notice
+}
+
+// Newer reports whether version a is newer than b. Versions are compared
+// by their dotted numbers; b that isn't a release version (such as "dev")
+// is never out of date.
+func Newer(a, b string) bool {
+	pa, okA := parseVersion(a)
+	pb, okB := parseVersion(b)
+	if !okA || !okB {
+		return false
+	}
+	for i := range pa {
+		if pa[i] != pb[i] {
+			return pa[i] > pb[i]
+		}
+	}
+	return false
+}
+
+// parseVersion parses v1.2.3 or 1.2 into its numbers, ignoring any
+// pre-release or build suffix
+func parseVersion(v string) ([3]int, bool) {
+	var parts [3]int
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	v, _, _ = strings.Cut(v, "-")
+	v, _, _ = strings.Cut(v, "+")
+	fields := strings.Split(v, ".")
+	if len(fields) == 0 || len(fields) > 3 {
+		return parts, false
+	}
+	for i, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil {
+			return parts, false
+		}
+		parts[i] = n
+	}
+	return parts, true
+}
+
+// load reads the last check's result; a missing file is a check that never
+// happened
+func load() (state, error) {
+	var s state
+	path, err := statePath()
+	if err != nil {
+		return s, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return s, err
+	}
+	err = json.Unmarshal(data, &s)
+	return s, err
+}
+
+// save records a check's result
+func save(s state) error {
+	path, err := statePath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return config.WriteFile(path, data)
+}
+
+// statePath returns the path of the update check file
+func statePath() (string, error) {
+	dataDir, err := config.GetDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, "update_check.json"), nil
+}
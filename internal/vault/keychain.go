@@ -0,0 +1,112 @@
+package vault
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Keychain entries are kept under the service keychainService, and the
+// store key under the account keychainAccount
+const (
+	keychainService = "cliq"
+	keychainAccount = "store-key"
+)
+
+// ErrNoKeychain is returned when no supported keychain tool is installed
+var ErrNoKeychain = errors.New("no keychain available (needs macOS security or secret-tool from libsecret)")
+
+// KeychainGet reads the store key from the OS keychain
+func KeychainGet() ([]byte, error) {
+	secret, err := SecretGet(keychainAccount)
+	if errors.Is(err, ErrNoKeychain) {
+		return nil, err
+	} else if err != nil {
+		return nil, fmt.Errorf("no store key in the keychain: %w", err)
+	}
+	key, err := hex.DecodeString(secret)
+	if err != nil || len(key) != KeySize {
+		return nil, fmt.Errorf("the keychain's store key is malformed")
+	}
+	return key, nil
+}
+
+// KeychainSet saves the store key in the OS keychain, replacing any
+// existing one
+func KeychainSet(key []byte) error {
+	err := SecretSet(keychainAccount, "cliq store key", hex.EncodeToString(key))
+	if err != nil && !errors.Is(err, ErrNoKeychain) {
+		return fmt.Errorf("could not save the store key: %w", err)
+	}
+	return err
+}
+
+// KeychainDelete removes the store key from the OS keychain
+func KeychainDelete() error {
+	return SecretDelete(keychainAccount)
+}
+
+// SecretGet reads the secret kept in the OS keychain under account, as
+// cliq's
+func SecretGet(account string) (string, error) {
+	var cmd *exec.Cmd
+	switch {
+	case runtime.GOOS == "darwin":
+		cmd = exec.Command("security", "find-generic-password", "-s", keychainService, "-a", account, "-w")
+	case hasCommand("secret-tool"):
+		cmd = exec.Command("secret-tool", "lookup", "service", keychainService, "account", account)
+	default:
+		return "", ErrNoKeychain
+	}
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// SecretSet saves a secret in the OS keychain under account, replacing
+// any existing one. label is how keychain tools show it.
+func SecretSet(account, label, secret string) error {
+	var cmd *exec.Cmd
+	switch {
+	case runtime.GOOS == "darwin":
+		cmd = exec.Command("security", "add-generic-password", "-U", "-s", keychainService, "-a", account, "-l", label, "-w", secret)
+	case hasCommand("secret-tool"):
+		// secret-tool reads the secret from stdin, keeping it out of ps
+		cmd = exec.Command("secret-tool", "store", "--label="+label, "service", keychainService, "account", account)
+		cmd.Stdin = bytes.NewBufferString(secret)
+	default:
+		return ErrNoKeychain
+	}
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// SecretDelete removes the secret kept in the OS keychain under account
+func SecretDelete(account string) error {
+	var cmd *exec.Cmd
+	switch {
+	case runtime.GOOS == "darwin":
+		cmd = exec.Command("security", "delete-generic-password", "-s", keychainService, "-a", account)
+	case hasCommand("secret-tool"):
+		cmd = exec.Command("secret-tool", "clear", "service", keychainService, "account", account)
+	default:
+		return ErrNoKeychain
+	}
+	return cmd.Run()
+}
+
+// hasCommand reports whether a command is on PATH
+func hasCommand(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
@@ -0,0 +1,93 @@
+// Package vault encrypts cliq's history and personal stores at rest with
+// XChaCha20-Poly1305, keyed by a passphrase or a key kept in the OS keychain.
+package vault
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+)
+
+// magic starts every sealed file, so sealed and plain files can be told apart
+var magic = []byte("cliq-vault-v1\n")
+
+// KeySize is the size of a store key in bytes
+const KeySize = chacha20poly1305.KeySize
+
+// SaltSize is the size of the salt a passphrase is stretched with
+const SaltSize = 16
+
+// ErrWrongKey is returned when a sealed file can't be opened with the key,
+// usually because the passphrase is wrong
+var ErrWrongKey = errors.New("wrong passphrase or key, or the file is corrupted")
+
+// IsSealed reports whether data was written by Seal
+func IsSealed(data []byte) bool {
+	return bytes.HasPrefix(data, magic)
+}
+
+// Seal encrypts data with key
+func Seal(key, data []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(data)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	out := append([]byte{}, magic...)
+	out = append(out, nonce...)
+	// The header is authenticated so a file can't be passed off as another
+	// format version
+	return aead.Seal(out, nonce, data, magic), nil
+}
+
+// Open decrypts data sealed with key
+func Open(key, data []byte) ([]byte, error) {
+	if !IsSealed(data) {
+		return nil, fmt.Errorf("not a sealed file")
+	}
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data = data[len(magic):]
+	if len(data) < aead.NonceSize() {
+		return nil, ErrWrongKey
+	}
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+
+	plain, err := aead.Open(nil, nonce, ciphertext, magic)
+	if err != nil {
+		return nil, ErrWrongKey
+	}
+	return plain, nil
+}
+
+// NewKey returns a random store key
+func NewKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	_, err := rand.Read(key)
+	return key, err
+}
+
+// NewSalt returns a random salt for DeriveKey
+func NewSalt() ([]byte, error) {
+	salt := make([]byte, SaltSize)
+	_, err := rand.Read(salt)
+	return salt, err
+}
+
+// DeriveKey stretches a passphrase into a store key. It takes a noticeable
+// fraction of a second by design, so callers should derive the key once.
+func DeriveKey(passphrase string, salt []byte) ([]byte, error) {
+	return scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, KeySize)
+}
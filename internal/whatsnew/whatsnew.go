@@ -0,0 +1,160 @@
+// Package whatsnew holds the structured release notes embedded in the
+// binary and remembers which release the user last read them for
+package whatsnew
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"go.yaml.in/yaml/v3"
+
+	"github.com/cliq-cli/cliq/internal/config"
+	"github.com/cliq-cli/cliq/internal/update"
+)
+
+//go:embed data/changelog.yaml
+var changelogData []byte
+
+// Release is one version's notes
+type Release struct {
+	Version string `yaml:"version"`
+	Notes   []Note `yaml:"notes"`
+}
+
+// Note describes one change
+type Note struct {
+	Feature string `yaml:"feature"`
+	Title   string `yaml:"title"`
+	Detail  string `yaml:"detail"`
+	// When lists the features of a setup the change matters to; empty
+	// means everyone
+	When []string `yaml:"when"`
+}
+
+// Relevant reports whether the note matters to a setup with features
+func (n Note) Relevant(features map[string]bool) bool {
+	if len(n.When) == 0 {
+		return true
+	}
+	for _, f := range n.When {
+		if features[f] {
+			return true
+		}
+	}
+	return false
+}
+
+// Changelog returns the embedded releases, newest first
+func Changelog() ([]Release, error) {
+	var releases []Release
+	if err := yaml.Unmarshal(changelogData, &releases); err != nil {
+		return nil, fmt.Errorf("parsing changelog: %w", err)
+	}
+	return releases, nil
+}
+
+// Between returns the releases newer than since, up to and including until.
+// An empty since starts from until's own release; an until that isn't a
+// release version (a development build) takes every release.
+func Between(releases []Release, since, until string) []Release {
+	var selected []Release
+	for _, r := range releases {
+		if update.Newer(r.Version, until) {
+			continue
+		}
+		if since == "" {
+			if !update.Newer(until, r.Version) {
+				selected = append(selected, r)
+			}
+			continue
+		}
+		if update.Newer(r.Version, since) {
+			selected = append(selected, r)
+		}
+	}
+	return selected
+}
+
+// state records what the user has been shown, in the data directory
+type state struct {
+	// Seen is the version whose notes were last read with cliq whatsnew
+	Seen string `json:"seen,omitempty"`
+	// Notified is the version the upgrade notice was last printed for
+	Notified string `json:"notified,omitempty"`
+}
+
+// Seen returns the version the notes were last read for, "" if never
+func Seen() string {
+	s, _ := load()
+	return s.Seen
+}
+
+// MarkSeen records that the notes up to version have been read
+func MarkSeen(version string) error {
+	s, _ := load()
+	s.Seen = version
+	if s.Notified == "" || update.Newer(version, s.Notified) {
+		s.Notified = version
+	}
+	return save(s)
+}
+
+// NeedsNotice reports whether version's upgrade notice hasn't been printed
+// yet, and records that it now has been. With no record of either, it's
+// an upgrade from a version before whatsnew if cliq was already set up
+// before this run, and a fresh install, recorded without a notice, if
+// not. Seen is left unset either way, so whatsnew first shows the running
+// version's notes.
+func NeedsNotice(version string, setUp bool) bool {
+	s, err := load()
+	if err != nil {
+		save(state{Notified: version})
+		return setUp
+	}
+	if s.Notified != "" && !update.Newer(version, s.Notified) {
+		return false
+	}
+	s.Notified = version
+	save(s)
+	return true
+}
+
+// load reads the state; a missing file is an error, meaning a first run
+func load() (state, error) {
+	var s state
+	path, err := statePath()
+	if err != nil {
+		return s, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return s, err
+	}
+	err = json.Unmarshal(data, &s)
+	return s, err
+}
+
+// save writes the state
+func save(s state) error {
+	path, err := statePath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return config.WriteFile(path, data)
+}
+
+// statePath returns the path of the whatsnew state file
+func statePath() (string, error) {
+	dataDir, err := config.GetDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, "whatsnew.json"), nil
+}
@@ -0,0 +1,194 @@
+// Package writeback adds keymaps and tmux bindings to the user's config
+// files, backing each file up before it's changed
+package writeback
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ErrPresent is returned by Append when the file already has every line
+var ErrPresent = errors.New("already in the config")
+
+// Change is an edit to one file, not yet written
+type Change struct {
+	Path    string
+	Old     string
+	New     string
+	Created bool // the file doesn't exist yet
+	Added   []string
+}
+
+var (
+	// keymapLineRe matches a vim.keymap.set call on one line
+	keymapLineRe = regexp.MustCompile(`^\s*vim\.keymap\.set\(.*\)\s*$`)
+	// tmuxBindLineRe matches a tmux bind-key or unbind-key command
+	tmuxBindLineRe = regexp.MustCompile(`^\s*(?:bind|bind-key|unbind|unbind-key)\s+\S`)
+)
+
+// Keymaps returns the vim.keymap.set lines in text, such as an answer's
+// command
+func Keymaps(text string) []string {
+	return matching(text, keymapLineRe)
+}
+
+// TmuxBindings returns the tmux bind-key and unbind-key lines in text. A
+// leading "tmux " is dropped, since the config takes the bare command.
+func TmuxBindings(text string) []string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimPrefix(strings.TrimSpace(line), "tmux ")
+		if tmuxBindLineRe.MatchString(line) {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func matching(text string, re *regexp.Regexp) []string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if re.MatchString(line) {
+			lines = append(lines, strings.TrimSpace(line))
+		}
+	}
+	return lines
+}
+
+// Append prepares adding lines to the end of the file at path, leaving out
+// lines it already has
+func Append(path string, lines []string) (*Change, error) {
+	content, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	c := &Change{Path: path, Old: string(content), Created: errors.Is(err, fs.ErrNotExist)}
+
+	existing := map[string]bool{}
+	for _, line := range strings.Split(c.Old, "\n") {
+		existing[strings.TrimSpace(line)] = true
+	}
+	for _, line := range lines {
+		if !existing[line] {
+			c.Added = append(c.Added, line)
+			existing[line] = true
+		}
+	}
+	if len(c.Added) == 0 {
+		return nil, ErrPresent
+	}
+
+	c.New = c.Old
+	if c.New != "" && !strings.HasSuffix(c.New, "\n") {
+		c.New += "\n"
+	}
+	c.New += strings.Join(c.Added, "\n") + "\n"
+	return c, nil
+}
+
+// diffContext is how many lines before the added ones a diff shows
+const diffContext = 3
+
+// Diff shows the change as a unified diff
+func (c *Change) Diff() string {
+	var old []string
+	if c.Old != "" {
+		old = strings.Split(strings.TrimSuffix(c.Old, "\n"), "\n")
+	}
+	start := max(len(old)-diffContext, 0)
+
+	var sb strings.Builder
+	if c.Created {
+		sb.WriteString("--- /dev/null\n")
+	} else {
+		fmt.Fprintf(&sb, "--- %s\n", c.Path)
+	}
+	fmt.Fprintf(&sb, "+++ %s\n", c.Path)
+	context := len(old) - start
+	fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n", start+min(context, 1), context, start+1, context+len(c.Added))
+	for _, line := range old[start:] {
+		sb.WriteString(" " + line + "\n")
+	}
+	for _, line := range c.Added {
+		sb.WriteString("+" + line + "\n")
+	}
+	return sb.String()
+}
+
+// Apply writes the change, first copying an existing file to a timestamped
+// backup beside it, and returns the backup's path
+func (c *Change) Apply() (backup string, err error) {
+	mode := fs.FileMode(0644)
+	if info, err := os.Stat(c.Path); err == nil {
+		mode = info.Mode().Perm()
+		backup, err = writeBackup(c.Path, c.Old, mode)
+		if err != nil {
+			return "", fmt.Errorf("failed to back up %s: %w", c.Path, err)
+		}
+	} else if err := os.MkdirAll(filepath.Dir(c.Path), 0755); err != nil {
+		return "", err
+	}
+
+	if err := os.WriteFile(c.Path, []byte(c.New), mode); err != nil {
+		return backup, err
+	}
+	return backup, nil
+}
+
+// writeBackup writes content to a new backup of path, never replacing an
+// earlier one: a second backup within the same second is numbered
+func writeBackup(path, content string, mode fs.FileMode) (string, error) {
+	stamp := fmt.Sprintf("%s.cliq-%s", path, time.Now().Format("20060102-150405"))
+	for n := 1; ; n++ {
+		backup := stamp + ".bak"
+		if n > 1 {
+			backup = fmt.Sprintf("%s-%d.bak", stamp, n)
+		}
+		f, err := os.OpenFile(backup, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		if _, err := f.WriteString(content); err != nil {
+			f.Close()
+			return "", err
+		}
+		return backup, f.Close()
+	}
+}
+
+// LuaModule names the module require() loads file as, for a file under the
+// config's lua directory, or "" for one elsewhere
+func LuaModule(configPath, file string) string {
+	rel, err := filepath.Rel(filepath.Join(configPath, "lua"), file)
+	if err != nil || strings.HasPrefix(rel, "..") || filepath.Ext(rel) != ".lua" {
+		return ""
+	}
+	rel = strings.TrimSuffix(strings.TrimSuffix(rel, ".lua"), string(filepath.Separator)+"init")
+	return strings.ReplaceAll(rel, string(filepath.Separator), ".")
+}
+
+// Required reports whether any Lua file in the config mentions module, as
+// a require() of it would
+func Required(configPath, module string) bool {
+	found := false
+	filepath.WalkDir(configPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || found || d.IsDir() || filepath.Ext(path) != ".lua" {
+			return nil
+		}
+		if content, err := os.ReadFile(path); err == nil && strings.Contains(string(content), module) {
+			found = true
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return found
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"os"
 
 	"github.com/cliq-cli/cliq/cmd"
@@ -16,6 +17,16 @@ var (
 func main() {
 	cmd.SetVersionInfo(version, commit, date)
 	if err := cmd.Execute(); err != nil {
+		if errors.Is(err, cmd.ErrInterrupted) {
+			// Conventional exit status for termination by Ctrl-C
+			os.Exit(130)
+		}
+		if errors.Is(err, cmd.ErrNoCommand) {
+			os.Exit(3)
+		}
+		if errors.Is(err, cmd.ErrNoBackend) {
+			os.Exit(4)
+		}
 		os.Exit(1)
 	}
 }